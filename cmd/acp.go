@@ -0,0 +1,87 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/acp"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/security"
+)
+
+// acpCmd represents the acp command
+var acpCmd = &cobra.Command{
+	Use:   "acp",
+	Short: "Run as an Agent Client Protocol server over stdio",
+	Long: `Run CODA as an agent for editors that speak the Agent Client Protocol
+(ACP), such as Zed or Neovim plugins.
+
+Requests are read from stdin and responses written to stdout as
+newline-delimited JSON-RPC. Tool calls are sent to the editor for approval
+unless --auto-approve is set. Diagnostics are written to stderr.
+
+Example Zed configuration:
+  "agent_servers": {
+    "CODA": { "command": "coda", "args": ["acp"] }
+  }`,
+	Args: cobra.NoArgs,
+	RunE: runACP,
+}
+
+func init() {
+	rootCmd.AddCommand(acpCmd)
+
+	acpCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+	acpCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool executions (use with caution)")
+	acpCmd.Flags().BoolVar(&strictModel, "strict", false, "fail instead of warning when the configured model is deprecated or unavailable")
+}
+
+func runACP(cmd *cobra.Command, args []string) error {
+	// stdout carries protocol messages only; route every other print to stderr
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	handler, err := setupChatHandler(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to setup chat handler: %w", err)
+	}
+	defer handler.Close()
+
+	cfg := GetConfig()
+	toolManager, err := createToolManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create tool manager: %w", err)
+	}
+
+	server := acp.NewServer(handler, os.Stdin, protocolOut,
+		acp.WithAutoApprove(autoApprove || cfg.Tools.AutoApprove),
+	)
+
+	approvalRules, err := setupApprovalRules(cfg, &simpleLogger{})
+	if err != nil {
+		return fmt.Errorf("failed to load approval rules: %w", err)
+	}
+
+	// The server doubles as the approval handler, forwarding requests to the
+	// editor; the timeout also covers the time the user takes to answer
+	validator := security.NewDefaultValidator(".")
+	server.SetToolRunner(chat.NewToolExecutor(toolManager, GetMCPManager(), validator, server,
+		chat.WithTimeout(10*time.Minute),
+		chat.WithApprovalRules(approvalRules),
+		chat.WithWorkspace(cfg.Tools.WorkspaceRoot),
+	))
+
+	return server.Serve(ctx)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/web"
+)
+
+var attachToken string
+
+// attachCmd represents the attach command
+var attachCmd = &cobra.Command{
+	Use:   "attach <url>",
+	Short: "Follow a served session read-only",
+	Long: `Follow a session run by coda serve from another terminal, printing the
+conversation so far and then new messages and tool activity as they happen.
+
+Attaching is read-only: it cannot send prompts or answer approvals, which makes
+it suitable for a navigator pairing with the driver, or for demos. Start the
+server with --spectate and pass the printed spectator URL:
+  coda serve --spectate
+  coda attach 'http://localhost:7777/?token=...'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAttach,
+}
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+
+	attachCmd.Flags().StringVar(&attachToken, "token", "", "access token (default: the token in the URL)")
+}
+
+func runAttach(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	spectator, err := web.NewSpectator(args[0], attachToken)
+	if err != nil {
+		return err
+	}
+
+	state, err := spectator.Session(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	ShowInfo("Attached to session %s (Ctrl+C to detach)", state.SessionID)
+	for _, msg := range state.Messages {
+		printAttachedMessage(msg.Role, msg.Content)
+	}
+	if state.Running {
+		ShowInfo("[a prompt is running]")
+	}
+
+	return spectator.Follow(ctx, func(event web.Event) {
+		if line := formatAttachedEvent(event); line != "" {
+			fmt.Println(line)
+		}
+	})
+}
+
+func printAttachedMessage(role, content string) {
+	fmt.Printf("%s: %s\n\n", role, content)
+}
+
+// formatAttachedEvent renders an event for the terminal, or "" to skip it
+func formatAttachedEvent(event web.Event) string {
+	var data map[string]interface{}
+	if raw, err := json.Marshal(event.Data); err == nil {
+		json.Unmarshal(raw, &data)
+	}
+	field := func(key string) string {
+		s, _ := data[key].(string)
+		return s
+	}
+
+	switch event.Type {
+	case web.EventPromptStarted, web.EventMessage:
+		return fmt.Sprintf("%s: %s\n", field("role"), field("content"))
+	case web.EventToolCall:
+		return fmt.Sprintf("  → %s %s", field("tool"), field("arguments"))
+	case web.EventToolDone:
+		if errText := field("error"); errText != "" {
+			return fmt.Sprintf("  ✗ %s failed: %s", field("tool"), errText)
+		}
+		return fmt.Sprintf("  ✓ %s done", field("tool"))
+	case web.EventApprovalRequest:
+		return fmt.Sprintf("  ? waiting for approval of %s", field("tool"))
+	case web.EventApprovalResolved:
+		if approved, _ := data["approved"].(bool); approved {
+			return "  approved"
+		}
+		return "  denied"
+	case web.EventPromptDone:
+		if errText := field("error"); errText != "" {
+			return fmt.Sprintf("[prompt failed: %s]\n", errText)
+		}
+		return "[prompt done]\n"
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/common-creation/coda/internal/changes"
+)
+
+// offerToCommitChanges shows the files changed during the session and offers
+// to stage or commit them. It does nothing outside a git work tree or when
+// stdin is not a terminal.
+func offerToCommitChanges(tracker *changes.Tracker) {
+	fileChanges := tracker.Changes(changes.AllSessions)
+
+	// Only files inside the project can be staged
+	var paths []string
+	for _, c := range fileChanges {
+		if !filepath.IsAbs(c.Path) {
+			paths = append(paths, c.Path)
+		}
+	}
+	if len(paths) == 0 || !isTerminal(os.Stdin) || !insideGitWorkTree() {
+		return
+	}
+
+	fmt.Println("\nFiles changed in this session:")
+	fmt.Println(changes.DiffStat(fileChanges))
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Stage them? [y]es / [c]ommit / [N]o: ")
+	answer, _ := reader.ReadString('\n')
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		if err := runGit(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+			ShowError("Failed to stage changes: %v", err)
+			return
+		}
+		ShowSuccess("Staged %d file(s)", len(paths))
+
+	case "c", "commit":
+		fmt.Print("Commit message: ")
+		message, _ := reader.ReadString('\n')
+		message = strings.TrimSpace(message)
+		if message == "" {
+			ShowWarning("Empty commit message; nothing committed")
+			return
+		}
+		if err := runGit(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+			ShowError("Failed to stage changes: %v", err)
+			return
+		}
+		// Commit only the session's files, leaving anything else staged as is
+		if err := runGit(append([]string{"commit", "-m", message, "--"}, paths...)...); err != nil {
+			ShowError("Failed to commit changes: %v", err)
+			return
+		}
+		ShowSuccess("Committed %d file(s)", len(paths))
+	}
+}
+
+// insideGitWorkTree reports whether the current directory is in a git work tree
+func insideGitWorkTree() bool {
+	out, err := exec.Command("git", "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// runGit runs a git command, returning its stderr as the error message
+func runGit(args ...string) error {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return nil
+}
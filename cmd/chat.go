@@ -4,23 +4,41 @@ Copyright © 2025 CODA Project
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/artifacts"
+	"github.com/common-creation/coda/internal/changes"
 	"github.com/common-creation/coda/internal/chat"
 	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/database"
+	"github.com/common-creation/coda/internal/deps"
+	"github.com/common-creation/coda/internal/embeddings"
+	"github.com/common-creation/coda/internal/journal"
+	"github.com/common-creation/coda/internal/k8s"
+	"github.com/common-creation/coda/internal/projectfacts"
 	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/snippet"
+	"github.com/common-creation/coda/internal/startup"
+	"github.com/common-creation/coda/internal/tokenizer"
 	"github.com/common-creation/coda/internal/tools"
+	"github.com/common-creation/coda/internal/transcript"
 	"github.com/common-creation/coda/internal/ui"
+	"github.com/common-creation/coda/internal/vulnscan"
+	"github.com/common-creation/coda/internal/watch"
 )
 
 // These variables are shared between root.go and chat.go
@@ -29,9 +47,28 @@ var (
 	model           string
 	continueSession bool
 	autoApprove     bool
+	strictModel     bool
+	inlineMode      bool   // Render without the alternate screen
 	initialMessage  string // Initial message to send when starting chat
+
+	transcriptPath   string // Mirror the conversation to this file, or "-" for stdout
+	transcriptFormat string // text or jsonl
+
+	// startupTracker times the startup of the TUI and runs its slow checks
+	// in the background; nil for the commands without a TUI
+	startupTracker *startup.Tracker
+
+	// auditLog is shared by the tool managers and the approval rules
+	auditLog     *security.AuditLogger
+	auditLogOnce sync.Once
 )
 
+// snippetRunner is shared by the tool managers, so the languages that can run
+// isolated are only checked once
+var snippetRunner = sync.OnceValue(func() *snippet.Runner {
+	return snippet.NewRunner()
+})
+
 // chatCmd represents the chat command
 var chatCmd = &cobra.Command{
 	Use:   "chat",
@@ -45,7 +82,9 @@ through natural language interaction.
 Examples:
   coda chat                    # Start a new chat session
   coda chat --continue         # Continue the last session
-  coda chat --model o4-mini    # Use a specific model`,
+  coda chat --model o4-mini    # Use a specific model
+  coda chat --inline           # Keep the conversation in the terminal scrollback
+  coda chat --transcript - --transcript-format jsonl | tee run.jsonl`,
 	RunE: runChat,
 }
 
@@ -56,6 +95,10 @@ func init() {
 	chatCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
 	chatCmd.Flags().BoolVar(&continueSession, "continue", false, "continue last session")
 	chatCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool executions (use with caution)")
+	chatCmd.Flags().BoolVar(&strictModel, "strict", false, "fail instead of warning when the configured model is deprecated or unavailable")
+	chatCmd.Flags().BoolVar(&inlineMode, "inline", false, "render without the alternate screen, appending messages to the terminal scrollback")
+	chatCmd.Flags().StringVar(&transcriptPath, "transcript", "", "mirror the conversation to a file as it happens (\"-\" for stdout)")
+	chatCmd.Flags().StringVar(&transcriptFormat, "transcript-format", "text", "transcript format: text or jsonl")
 }
 
 func runChat(cmd *cobra.Command, args []string) error {
@@ -76,44 +119,209 @@ func runChat(cmd *cobra.Command, args []string) error {
 		initialMessage = strings.Join(args, " ")
 	}
 
+	// Initialize what the first frame does not need while the rest starts
+	startupTracker = startup.NewTracker()
+	startupTracker.Mark("chat command")
+	tokenizerModel := GetConfig().AI.Model
+	if model != "" {
+		tokenizerModel = model
+	}
+	startupTracker.Go("MCP", func() error {
+		GetMCPManager()
+		return nil
+	})
+	startupTracker.Go("tokenizer", func() error {
+		return tokenizer.Preload(tokenizerModel)
+	})
+	startupTracker.Go("snippet languages", func() error {
+		snippetRunner().Languages()
+		return nil
+	})
+
 	// Setup chat components
+	done := startupTracker.Step("chat handler")
 	handler, err := setupChatHandler(ctx)
+	done()
 	if err != nil {
 		return fmt.Errorf("failed to setup chat handler: %w", err)
 	}
+	defer handler.Close()
 
 	// Always use TUI mode
 	return runTUIChat(ctx, handler)
 }
 
 func runTUIChat(ctx context.Context, handler *chat.ChatHandler) error {
+	done := startupTracker.Step("tools and UI")
+
 	// Create tool manager (same as in setupChatHandler)
 	cfg := GetConfig()
 	validator := security.NewDefaultValidator(".")
 	logger := &simpleLogger{}
 	wrappedValidator := &securityValidatorWrapper{validator: validator}
 	toolManager := tools.NewManager(wrappedValidator, logger)
+	toolManager.SetEnabledTools(cfg.Tools.Enabled, cfg.Tools.Disabled)
 
 	// Register tools
-	toolManager.Register(tools.NewReadFileTool(wrappedValidator))
+	toolManager.Register(tools.NewReadFileTool(wrappedValidator, readFileToolOptions(cfg)...))
 	toolManager.Register(tools.NewWriteFileTool(wrappedValidator))
 	toolManager.Register(tools.NewEditFileTool(wrappedValidator))
 	toolManager.Register(tools.NewListFilesTool(wrappedValidator))
 	toolManager.Register(tools.NewSearchFilesTool(wrappedValidator))
+	toolManager.Register(tools.NewSaveArtifactTool(artifacts.NewStore("")))
+	commandOpts, err := commandToolOptions(cfg)
+	if err != nil {
+		return err
+	}
+	toolManager.Register(tools.NewRunCommandTool(wrappedValidator, cfg.Tools.WorkspaceRoot, commandOpts...))
+	searchIndex, err := newSearchIndex(cfg)
+	if err != nil {
+		return err
+	}
+	toolManager.Register(tools.NewSemanticSearchTool(searchIndex))
+	toolManager.Register(tools.NewPackageAPITool(cfg.Tools.WorkspaceRoot))
+	toolManager.Register(tools.NewRunBenchmarksTool(cfg.Tools.WorkspaceRoot))
+	if deps.IsGoModule(cfg.Tools.WorkspaceRoot) {
+		for _, tool := range tools.NewDepsTools(wrappedValidator, cfg.Tools.WorkspaceRoot) {
+			toolManager.Register(tool)
+		}
+	}
+	if len(vulnscan.Installed()) > 0 {
+		toolManager.Register(tools.NewSecurityScanTool(cfg.Tools.WorkspaceRoot))
+	}
+	toolManager.Register(tools.NewValidateDockerfileTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	toolManager.Register(tools.NewValidateComposeTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	if tools.DockerAvailable() {
+		toolManager.Register(tools.NewDockerBuildTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	}
+	toolManager.Register(tools.NewValidateK8sTool(wrappedValidator, cfg.Tools.WorkspaceRoot, k8sOptions(cfg)...))
+	if tools.KubectlAvailable() {
+		toolManager.Register(tools.NewK8sDiffTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	}
+	if dbs := databases(cfg); len(dbs) > 0 {
+		toolManager.Register(tools.NewQueryDBTool(cfg.Tools.WorkspaceRoot, dbs, cfg.Tools.Database.ResultTokens()))
+	}
+	if len(cfg.Tools.HTTP.AllowedDomains) > 0 {
+		httpOpts, err := httpToolOptions(cfg)
+		if err != nil {
+			return err
+		}
+		toolManager.Register(tools.NewHTTPRequestTool(cfg.Tools.HTTP.AllowedDomains, httpOpts...))
+	}
+	if runner := snippetRunner(); runner.Installed() {
+		toolManager.Register(tools.NewEvalSnippetTool(runner))
+	}
+
+	// Supervise tool execution
+	configureToolSandbox(toolManager, cfg, logger)
+	configureToolHooks(toolManager, cfg, logger)
+	toolManager.SetDryRun(dryRun || cfg.Tools.DryRun)
+
+	// Reuse the results of read tools until the files change
+	cache := tools.NewResultCache()
+	toolManager.Use(cache.Middleware())
+	go watchResultCache(ctx, cache, cfg.Tools.WorkspaceRoot)
+
+	// Remember files before tools change them for /changes
+	tracker := changes.NewTracker(".")
+	toolManager.AddHook(tracker.Observe)
+
+	// Project-wide Go renames, when gopls is installed
+	if tools.GoplsAvailable() {
+		toolManager.Register(tools.NewRenameSymbolTool(wrappedValidator, cfg.Tools.WorkspaceRoot, tools.WithRenameRecorder(func(ctx context.Context, path string) {
+			tracker.Record(tools.SessionIDFromContext(ctx), path)
+		})))
+	}
+
+	// Remove old sessions, artifacts and logs while the chat runs
+	startJanitor(ctx, cfg)
+
+	// Log the run in the project's daily journal
+	var recorder *journal.Recorder
+	if !cfg.Session.DisableJournal {
+		recorder = journal.NewRecorder(journal.New(""))
+		toolManager.AddHook(recorder.Observe)
+	}
+
+	// Answer matching tool calls without asking
+	approvalRules, err := setupApprovalRules(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load approval rules: %w", err)
+	}
+
+	// Mirror the conversation for CI wrappers and pipes
+	transcriptWriter, output, err := openTranscript()
+	if err != nil {
+		return err
+	}
+	if transcriptWriter != nil {
+		defer transcriptWriter.Close()
+	}
+	if output != nil {
+		defer output.Close()
+	}
 
 	// Create and run the Bubbletea UI app
 	app, err := ui.NewApp(ui.AppOptions{
 		Config:         cfg,
 		ChatHandler:    handler,
 		ToolManager:    toolManager,
+		ChangeTracker:  tracker,
 		Logger:         nil, // Will use default logger
 		InitialMessage: initialMessage,
+		Inline:         inlineMode,
+		Transcript:     transcriptWriter,
+		Output:         output,
+		ApprovalRules:  approvalRules,
+		Journal:        recorder,
+		Startup:        startupTracker,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create app: %w", err)
 	}
 
-	return app.Run()
+	done()
+	err = app.Run()
+	if os.Getenv(startup.ProfileEnv) != "" {
+		fmt.Fprint(os.Stderr, startupTracker.Report())
+	}
+	if err != nil {
+		return err
+	}
+
+	offerToCommitChanges(tracker)
+	return nil
+}
+
+// openTranscript opens the --transcript target. When the transcript goes to
+// stdout the TUI is drawn on the controlling terminal instead, which is
+// returned as output.
+func openTranscript() (*transcript.Writer, *os.File, error) {
+	if transcriptPath == "" {
+		return nil, nil, nil
+	}
+
+	format, err := transcript.ParseFormat(transcriptFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var output *os.File
+	if transcriptPath == transcript.StdoutPath {
+		output, err = os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("a terminal is required to draw the TUI while the transcript goes to stdout: %w", err)
+		}
+	}
+
+	writer, err := transcript.Open(transcriptPath, format)
+	if err != nil {
+		if output != nil {
+			output.Close()
+		}
+		return nil, nil, err
+	}
+	return writer, output, nil
 }
 
 func setupChatHandler(ctx context.Context) (*chat.ChatHandler, error) {
@@ -130,6 +338,16 @@ func setupChatHandler(ctx context.Context) (*chat.ChatHandler, error) {
 		return nil, fmt.Errorf("failed to create AI client: %w", err)
 	}
 
+	// Track latency and failures for the health indicator and /ping
+	aiClient = ai.NewMonitoredClient(aiClient, ai.NewLatencyTracker())
+
+	loadModelMetadata(aiClient, cfg)
+
+	// Warn about deprecated or unavailable models
+	if err := checkConfiguredModel(ctx, aiClient, cfg); err != nil {
+		return nil, err
+	}
+
 	// Create tool manager
 	toolManager, err := createToolManager(cfg)
 	if err != nil {
@@ -140,13 +358,6 @@ func setupChatHandler(ctx context.Context) (*chat.ChatHandler, error) {
 	// Use default values for now as SessionConfig doesn't have MaxAge and MaxTokens
 	sessionManager := chat.NewSessionManager(30*24*60*60, 1000000) // 30 days, 1M tokens
 
-	// Handle session continuation
-	if continueSession {
-		if err := loadPreviousSession(sessionManager, ""); err != nil {
-			ShowWarning("Failed to load previous session: %v", err)
-		}
-	}
-
 	// Create history manager
 	historyPath := filepath.Join(getDataDir(), "history")
 	history, err := chat.NewHistory(historyPath)
@@ -159,6 +370,25 @@ func setupChatHandler(ctx context.Context) (*chat.ChatHandler, error) {
 	// Create chat handler
 	handler := chat.NewChatHandler(aiClient, toolManager, GetMCPManager(), sessionManager, cfg, history)
 
+	// Send the related project documentation with each message
+	if len(cfg.Embeddings.Docs) > 0 {
+		embedder, err := newEmbedder(cfg)
+		if err != nil {
+			return nil, err
+		}
+		docsIndex := embeddings.NewIndex(cfg.Tools.WorkspaceRoot, embedder, embeddings.WithPatterns(cfg.Embeddings.Docs...))
+		handler.SetDocsRetriever(chat.NewDocsRetriever(docsIndex, cfg.Embeddings.DocsResults))
+	}
+
+	// Rewrite answers as configured before they are shown and saved
+	if len(cfg.AI.PostProcess.Steps) > 0 {
+		pipeline, err := chat.NewPostProcessPipeline(cfg.AI.PostProcess, cfg.Tools.WorkspaceRoot)
+		if err != nil {
+			return nil, err
+		}
+		handler.SetPostProcessor(pipeline)
+	}
+
 	// Create and set prompt builder
 	promptBuilder := chat.NewPromptBuilder(cfg.AI.MaxTokens, nil)
 
@@ -173,6 +403,13 @@ func setupChatHandler(ctx context.Context) (*chat.ChatHandler, error) {
 		chat.ApplyWorkspaceConfig(workspaceConfig, promptBuilder)
 	}
 
+	// Tell the model what project it is in without spending tool calls
+	if !cfg.Session.DisableBootContext {
+		if facts := projectfacts.Collect(cfg.Tools.WorkspaceRoot).Render(); facts != "" {
+			promptBuilder.AddCustomPrompt("project_facts", facts)
+		}
+	}
+
 	// Build and set system prompt
 	systemPrompt, err := promptBuilder.Build()
 	if err != nil {
@@ -182,6 +419,18 @@ func setupChatHandler(ctx context.Context) (*chat.ChatHandler, error) {
 		handler.SetSystemPrompt(systemPrompt)
 	}
 
+	// Handle session continuation
+	if continueSession {
+		if err := resumePreviousSession(handler, cfg.Session.LockPolicy); err != nil {
+			if errors.Is(err, chat.ErrSessionLocked) {
+				return nil, err
+			}
+			ShowWarning("Failed to load previous session: %v", err)
+		}
+	} else if handler.LatestSessionInterrupted() {
+		ShowInfo("The last session ended in the middle of a response; run with --continue to recover it")
+	}
+
 	return handler, nil
 }
 
@@ -209,6 +458,77 @@ func createAIClient(cfg *config.Config) (ai.Client, error) {
 	return ai.NewClient(cfg.AI)
 }
 
+// loadModelMetadata applies the cached model metadata and refreshes it in
+// the background when it is older than ai.metadata_refresh_hours
+func loadModelMetadata(client ai.Client, cfg *config.Config) {
+	registry := ai.DefaultModelRegistry()
+	if err := registry.LoadCache(cfg.AI.MetadataCache); err != nil {
+		ShowWarning("%v", err)
+	}
+
+	hours := cfg.AI.MetadataRefreshHours
+	if hours < 0 || !registry.Stale(time.Duration(hours)*time.Hour, time.Now()) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		// Failures are not reported: the bundled and cached metadata remain usable
+		_, _ = registry.Refresh(ctx, client, cfg.AI.MetadataURL, cfg.AI.MetadataCache)
+	}()
+}
+
+// checkConfiguredModel reports deprecation and availability problems with the
+// configured model. With --strict any problem is returned as an error.
+func checkConfiguredModel(ctx context.Context, client ai.Client, cfg *config.Config) error {
+	// The TUI does not wait for the list of models: whether the model is
+	// available is shown in the chat once known
+	if startupTracker != nil && !strictModel {
+		aiConfig := cfg.AI
+		reportModelWarnings(ai.CheckModel(ctx, nil, aiConfig, time.Now()))
+		startupTracker.Go("model check", func() error {
+			checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			static := len(ai.CheckModel(checkCtx, nil, aiConfig, time.Now()))
+			for _, w := range ai.CheckModel(checkCtx, client, aiConfig, time.Now())[static:] {
+				startupTracker.Notify("Warning: %s. Change the model with 'coda config set ai.model <model>'", w.String())
+			}
+			return nil
+		})
+		return nil
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	warnings := ai.CheckModel(checkCtx, client, cfg.AI, time.Now())
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	if strictModel {
+		messages := make([]string, len(warnings))
+		for i, w := range warnings {
+			messages[i] = w.String()
+		}
+		return fmt.Errorf("model check failed (--strict): %s", strings.Join(messages, "; "))
+	}
+
+	reportModelWarnings(warnings)
+	return nil
+}
+
+// reportModelWarnings prints the problems found with the configured model
+func reportModelWarnings(warnings []ai.ModelWarning) {
+	if len(warnings) == 0 {
+		return
+	}
+	for _, w := range warnings {
+		ShowWarning("%s", w.String())
+	}
+	ShowInfo("Change the model with 'coda config set ai.model <model>' or pass --strict to fail on these warnings")
+}
+
 func createToolManager(cfg *config.Config) (*tools.Manager, error) {
 	// Create security validator
 	validator := security.NewDefaultValidator(".")
@@ -221,64 +541,351 @@ func createToolManager(cfg *config.Config) (*tools.Manager, error) {
 
 	// Create tool manager
 	manager := tools.NewManager(wrappedValidator, logger)
+	manager.SetEnabledTools(cfg.Tools.Enabled, cfg.Tools.Disabled)
 
 	// Register file tools
-	manager.Register(tools.NewReadFileTool(wrappedValidator))
+	manager.Register(tools.NewReadFileTool(wrappedValidator, readFileToolOptions(cfg)...))
 	manager.Register(tools.NewWriteFileTool(wrappedValidator))
 	manager.Register(tools.NewEditFileTool(wrappedValidator))
 	manager.Register(tools.NewListFilesTool(wrappedValidator))
 	manager.Register(tools.NewSearchFilesTool(wrappedValidator))
+	manager.Register(tools.NewSaveArtifactTool(artifacts.NewStore("")))
+	commandOpts, err := commandToolOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	manager.Register(tools.NewRunCommandTool(wrappedValidator, cfg.Tools.WorkspaceRoot, commandOpts...))
+	searchIndex, err := newSearchIndex(cfg)
+	if err != nil {
+		return nil, err
+	}
+	manager.Register(tools.NewSemanticSearchTool(searchIndex))
+	manager.Register(tools.NewPackageAPITool(cfg.Tools.WorkspaceRoot))
+	manager.Register(tools.NewRunBenchmarksTool(cfg.Tools.WorkspaceRoot))
+	if deps.IsGoModule(cfg.Tools.WorkspaceRoot) {
+		for _, tool := range tools.NewDepsTools(wrappedValidator, cfg.Tools.WorkspaceRoot) {
+			manager.Register(tool)
+		}
+	}
+	if len(vulnscan.Installed()) > 0 {
+		manager.Register(tools.NewSecurityScanTool(cfg.Tools.WorkspaceRoot))
+	}
+	manager.Register(tools.NewValidateDockerfileTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	manager.Register(tools.NewValidateComposeTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	if tools.DockerAvailable() {
+		manager.Register(tools.NewDockerBuildTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	}
+	manager.Register(tools.NewValidateK8sTool(wrappedValidator, cfg.Tools.WorkspaceRoot, k8sOptions(cfg)...))
+	if tools.KubectlAvailable() {
+		manager.Register(tools.NewK8sDiffTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	}
+	if dbs := databases(cfg); len(dbs) > 0 {
+		manager.Register(tools.NewQueryDBTool(cfg.Tools.WorkspaceRoot, dbs, cfg.Tools.Database.ResultTokens()))
+	}
+	if len(cfg.Tools.HTTP.AllowedDomains) > 0 {
+		httpOpts, err := httpToolOptions(cfg)
+		if err != nil {
+			return nil, err
+		}
+		manager.Register(tools.NewHTTPRequestTool(cfg.Tools.HTTP.AllowedDomains, httpOpts...))
+	}
+	if runner := snippetRunner(); runner.Installed() {
+		manager.Register(tools.NewEvalSnippetTool(runner))
+	}
+	if tools.GoplsAvailable() {
+		manager.Register(tools.NewRenameSymbolTool(wrappedValidator, cfg.Tools.WorkspaceRoot))
+	}
+
+	// Supervise tool execution
+	configureToolSandbox(manager, cfg, logger)
+	configureToolHooks(manager, cfg, logger)
+	manager.SetDryRun(dryRun || cfg.Tools.DryRun)
 
 	return manager, nil
 }
 
-func loadPreviousSession(sessionManager *chat.SessionManager, specificID string) error {
-	// Get project-specific session path
-	sessionPath, err := chat.GetProjectSessionPath()
+// readFileToolOptions adds Go package APIs to Go files read by read_file
+// when tools.go_package_context is set
+func readFileToolOptions(cfg *config.Config) []tools.ReadFileOption {
+	if !cfg.Tools.GoPackageContext {
+		return nil
+	}
+	return []tools.ReadFileOption{tools.WithGoPackageContext(cfg.Tools.WorkspaceRoot)}
+}
+
+// commandToolOptions applies the exec environment policy to run_command:
+// the inherited variables and the secrets injected by reference
+func commandToolOptions(cfg *config.Config) ([]tools.CommandOption, error) {
+	var opts []tools.CommandOption
+
+	inherit := cfg.Tools.Exec.InheritedEnv()
+	if !slices.Contains(inherit, "*") {
+		opts = append(opts, tools.WithInheritedEnv(inherit))
+	}
+
+	if len(cfg.Tools.Exec.Secrets) > 0 {
+		secrets, err := resolveSecretRefs(cfg.Tools.Exec.Secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve exec secrets: %w", err)
+		}
+		opts = append(opts, tools.WithSecrets(secrets))
+	}
+
+	return opts, nil
+}
+
+// httpToolOptions configures http_request: the headers injected by host
+// from secret references and the redacted header names
+func httpToolOptions(cfg *config.Config) ([]tools.HTTPOption, error) {
+	opts := []tools.HTTPOption{
+		tools.WithSecretHeaders(cfg.Tools.HTTP.SecretHeaders),
+		tools.WithMaxResponseBytes(cfg.Tools.HTTP.MaxResponseBytes),
+	}
+	if len(cfg.Tools.HTTP.Headers) > 0 {
+		headers := make(map[string]map[string]string, len(cfg.Tools.HTTP.Headers))
+		for host, refs := range cfg.Tools.HTTP.Headers {
+			values, err := resolveSecretRefs(refs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve http headers of %s: %w", host, err)
+			}
+			headers[host] = values
+		}
+		opts = append(opts, tools.WithHostHeaders(headers))
+	}
+	return opts, nil
+}
+
+// resolveSecretRefs resolves secret references, opening the secret store
+// only when one refers to it
+func resolveSecretRefs(refs map[string]string) (map[string]string, error) {
+	var store config.SecretsManager
+	for _, ref := range refs {
+		if strings.HasPrefix(ref, config.SecretRefKeychain) {
+			var err error
+			if store, err = config.NewSecretsManager(); err != nil {
+				return nil, fmt.Errorf("failed to open secret store: %w", err)
+			}
+			break
+		}
+	}
+	return config.ResolveSecretRefs(refs, store)
+}
+
+// newSearchIndex creates the workspace index searched by semantic_search with
+// the configured embeddings. The index is built on the first search.
+func newSearchIndex(cfg *config.Config) (*embeddings.Index, error) {
+	embedder, err := newEmbedder(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get session path: %w", err)
+		return nil, err
 	}
+	return embeddings.NewIndex(cfg.Tools.WorkspaceRoot, embedder), nil
+}
 
-	// Create persistence manager
-	persistence, err := chat.NewFilePersistence(sessionPath, true, 5*time.Minute)
+// newEmbedder creates the configured embedder
+func newEmbedder(cfg *config.Config) (embeddings.Embedder, error) {
+	if cfg.Embeddings.Provider != config.EmbeddingsAI {
+		return embeddings.NewLocalEmbedder(), nil
+	}
+	client, err := ai.NewClient(cfg.AI)
 	if err != nil {
-		return fmt.Errorf("failed to create persistence: %w", err)
+		return nil, fmt.Errorf("failed to create embeddings client: %w", err)
+	}
+	embedder, ok := client.(ai.Embedder)
+	if !ok {
+		return nil, fmt.Errorf("AI provider %s does not support embeddings", cfg.AI.Provider)
+	}
+	return embeddings.NewProviderEmbedder(embedder, cfg.Embeddings.Model), nil
+}
+
+// configureToolSandbox installs the tool sandbox described by the config
+func configureToolSandbox(manager *tools.Manager, cfg *config.Config, logger tools.Logger) {
+	sandboxCfg := cfg.Tools.Sandbox
+	if sandboxCfg.Disabled {
+		return
+	}
+
+	limits := tools.DefaultSandboxLimits()
+	if sandboxCfg.TimeoutSeconds != 0 {
+		limits.Timeout = time.Duration(max(sandboxCfg.TimeoutSeconds, 0)) * time.Second
 	}
+	if sandboxCfg.MaxMemoryMB != 0 {
+		limits.MaxMemory = uint64(max(sandboxCfg.MaxMemoryMB, 0)) * 1024 * 1024
+	}
+	if sandboxCfg.MaxOpenFiles != 0 {
+		limits.MaxOpenFiles = max(sandboxCfg.MaxOpenFiles, 0)
+	}
+	if sandboxCfg.MaxOutputBytes != 0 {
+		limits.MaxOutputBytes = max(sandboxCfg.MaxOutputBytes, 0)
+	}
+
+	// Violations are written to the audit log when it can be opened
+	var recorder tools.AuditRecorder
+	if auditLogger := openAuditLog(cfg, logger); auditLogger != nil {
+		recorder = &auditRecorderWrapper{audit: auditLogger}
+	}
+
+	manager.SetSandbox(tools.NewSandbox(limits, recorder, logger))
+}
+
+// configureToolHooks runs the configured hook commands around tool calls
+// and the formatters after file writes
+func configureToolHooks(manager *tools.Manager, cfg *config.Config, logger tools.Logger) {
+	for _, hook := range cfg.Tools.Hooks {
+		manager.Use(tools.NewCommandHook(tools.CommandHook{
+			Name:    hook.Name,
+			Tool:    hook.Tool,
+			Before:  hook.Before,
+			After:   hook.After,
+			Dir:     cfg.Tools.WorkspaceRoot,
+			Timeout: time.Duration(hook.TimeoutSeconds) * time.Second,
+		}, logger))
+	}
+
+	// Formatters run inside the hooks, so after hooks see formatted files
+	if len(cfg.Tools.Format) > 0 {
+		formatters := make([]tools.Formatter, len(cfg.Tools.Format))
+		for i, f := range cfg.Tools.Format {
+			formatters[i] = tools.Formatter{Files: f.Files, Command: f.Command}
+		}
+		manager.Use(tools.NewFormatHook(formatters, cfg.Tools.WorkspaceRoot, logger))
+	}
+}
+
+// watchResultCache drops cached tool results when files under root change
+// outside of the tools, until ctx is done
+func watchResultCache(ctx context.Context, cache *tools.ResultCache, root string) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	watcher := watch.New(root, []string{"**"}, watch.WithDebounce(0))
+	watcher.Run(ctx, func(changes []watch.Change) {
+		paths := make([]string, len(changes))
+		for i, change := range changes {
+			paths[i] = filepath.Join(root, filepath.FromSlash(change.Path))
+		}
+		cache.Invalidate(paths...)
+	})
+}
+
+// openAuditLog opens the configured audit log, or returns nil when it cannot
+// be opened. It is opened once and shared by the sandbox and the approval
+// rules until CloseAuditLog.
+func openAuditLog(cfg *config.Config, logger tools.Logger) *security.AuditLogger {
+	auditLogOnce.Do(func() {
+		auditPath := cfg.Tools.Sandbox.AuditLog
+		if auditPath == "" {
+			auditPath = security.DefaultAuditLogPath()
+		} else if strings.HasPrefix(auditPath, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				auditPath = filepath.Join(home, auditPath[2:])
+			}
+		}
 
-	// If specific ID provided, load it
-	if specificID != "" {
-		session, err := persistence.LoadSession(specificID)
+		var err error
+		auditLog, err = security.NewAuditLogger(auditPath)
 		if err != nil {
-			return fmt.Errorf("failed to load session %s: %w", specificID, err)
+			logger.Warn("Failed to open audit log", "path", auditPath, "error", err)
 		}
-		// TODO: Add session to sessionManager
-		ShowInfo("Loaded session: %s", session.ID)
+	})
+	return auditLog
+}
+
+// CloseAuditLog closes the audit log opened by openAuditLog
+func CloseAuditLog() error {
+	// Keeps a later open from starting
+	auditLogOnce.Do(func() {})
+	if auditLog == nil {
 		return nil
 	}
+	return auditLog.Close()
+}
 
-	// Otherwise, list available sessions
-	sessions, err := persistence.ListSessions()
-	if err != nil {
-		return fmt.Errorf("failed to list sessions: %w", err)
+// k8sOptions returns the manifest validation options of the configuration
+func k8sOptions(cfg *config.Config) []k8s.Option {
+	var opts []k8s.Option
+	if cfg.Tools.Kubernetes.Version != "" {
+		opts = append(opts, k8s.WithKubernetesVersion(cfg.Tools.Kubernetes.Version))
+	}
+	if len(cfg.Tools.Kubernetes.SchemaLocations) > 0 {
+		opts = append(opts, k8s.WithSchemaLocations(cfg.Tools.Kubernetes.SchemaLocations...))
 	}
+	return opts
+}
 
-	if len(sessions) == 0 {
-		ShowInfo("No previous sessions found for this project")
-		_, err := sessionManager.CreateSession()
+// databases returns the databases query_db can query
+func databases(cfg *config.Config) []database.Database {
+	dbs := make([]database.Database, 0, len(cfg.Tools.Database.Connections))
+	for _, conn := range cfg.Tools.Database.Connections {
+		dbs = append(dbs, database.Database{Name: conn.Name, DSN: conn.DSN, AllowWrites: conn.AllowWrites})
+	}
+	return dbs
+}
+
+// setupApprovalRules compiles the configured approval rules, after the rule
+// denying kubectl changes unless they are allowed. Their matches are written
+// to the audit log.
+func setupApprovalRules(cfg *config.Config, logger tools.Logger) (*security.ApprovalRules, error) {
+	return security.ConfiguredApprovalRules(cfg, openAuditLog(cfg, logger))
+}
+
+// resumePreviousSession makes the most recent saved session current. When it
+// is open in another instance the lock policy decides whether to open it
+// read-only, start a new session, or ask.
+func resumePreviousSession(handler *chat.ChatHandler, policy string) error {
+	session, err := handler.ResumeSession("", false)
+	if err == nil {
+		ShowInfo("Continuing session %s", session.ID)
+		return nil
+	}
+
+	var locked *chat.SessionLockedError
+	if !errors.As(err, &locked) {
 		return err
 	}
 
-	// For now, load the most recent session
-	// TODO: Implement TUI session selector
-	if len(sessions) > 0 {
-		session, err := persistence.LoadSession(sessions[0])
-		if err != nil {
-			return fmt.Errorf("failed to load session: %w", err)
+	if policy == config.LockPolicyPrompt || policy == "" {
+		policy = promptLockedSession(locked)
+	}
+
+	switch policy {
+	case config.LockPolicyReadOnly:
+		if _, err := handler.ResumeSession(locked.SessionID, true); err != nil {
+			return err
 		}
-		ShowInfo("Loaded most recent session: %s", session.ID)
+		ShowWarning("%v; opened read-only, changes will not be saved", locked)
+		return nil
+	case config.LockPolicyNew:
+		ShowWarning("%v; starting a new session", locked)
+		return handler.CreateNewSession()
+	default:
+		return fmt.Errorf("%w; quitting", locked)
 	}
+}
 
-	return nil
+// promptLockedSession asks what to do with a session open elsewhere. Without
+// a terminal a new session is started.
+func promptLockedSession(locked *chat.SessionLockedError) string {
+	if !isTerminal(os.Stdin) {
+		return config.LockPolicyNew
+	}
+
+	ShowWarning("%v", locked)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("Open it [r]ead-only, start a [n]ew session, or [q]uit? ")
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return ""
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "r", "read-only", "readonly":
+			return config.LockPolicyReadOnly
+		case "n", "new":
+			return config.LockPolicyNew
+		case "q", "quit":
+			return ""
+		}
+	}
 }
 
 func getDataDir() string {
@@ -337,6 +944,27 @@ func (l *simpleLogger) Warn(msg string, keysAndValues ...interface{}) {
 	fmt.Println()
 }
 
+// auditRecorderWrapper wraps security.AuditLogger to implement tools.AuditRecorder
+type auditRecorderWrapper struct {
+	audit *security.AuditLogger
+}
+
+func (w *auditRecorderWrapper) RecordViolation(tool string, kind tools.ViolationKind, message string, details map[string]interface{}) {
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+	details["kind"] = string(kind)
+
+	if err := w.audit.Record(security.AuditEvent{
+		Type:    "sandbox_violation",
+		Tool:    tool,
+		Message: message,
+		Details: details,
+	}); err != nil && IsDebug() {
+		fmt.Fprintf(os.Stderr, "[DEBUG] failed to write audit event: %v\n", err)
+	}
+}
+
 // securityValidatorWrapper wraps security.DefaultValidator to implement tools.SecurityValidator
 type securityValidatorWrapper struct {
 	validator *security.DefaultValidator
@@ -108,6 +108,27 @@ Examples:
 	RunE: runConfigSetApiKey,
 }
 
+// setSecretCmd stores secrets injected into commands
+var setSecretCmd = &cobra.Command{
+	Use:   "set-secret NAME [VALUE]",
+	Short: "Store a secret for commands run by tools",
+	Long: `Store a secret in the secure credential storage.
+
+Reference it from tools.exec.secrets to inject it into commands run by
+tools; its value is masked in their output. If VALUE is not provided, you
+will be prompted to enter it securely.
+
+Examples:
+  coda config set-secret github
+  # then in config.yaml:
+  #   tools:
+  #     exec:
+  #       secrets:
+  #         GITHUB_TOKEN: keychain:github`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runConfigSetSecret,
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(showCmd)
@@ -116,6 +137,7 @@ func init() {
 	configCmd.AddCommand(initCmd)
 	configCmd.AddCommand(validateCmd)
 	configCmd.AddCommand(setApiKeyCmd)
+	configCmd.AddCommand(setSecretCmd)
 
 	// Flags for show command
 	showCmd.Flags().StringVarP(&outputFormat, "output", "o", "yaml", "output format (yaml, json)")
@@ -293,6 +315,41 @@ func runConfigSetApiKey(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runConfigSetSecret(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		fmt.Printf("Enter value of secret %s: ", name)
+
+		// Read securely (without echo)
+		valueBytes, err := readPassword()
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		value = string(valueBytes)
+		fmt.Println() // New line after password input
+	}
+
+	if value == "" {
+		return fmt.Errorf("secret cannot be empty")
+	}
+
+	store, err := config.NewSecretsManager()
+	if err != nil {
+		return fmt.Errorf("failed to create secrets manager: %w", err)
+	}
+	if err := config.SetSecret(store, name, value); err != nil {
+		return fmt.Errorf("failed to store secret: %w", err)
+	}
+
+	ShowSuccess("Secret %s has been securely stored", name)
+	ShowInfo("Reference it in tools.exec.secrets as %s%s", config.SecretRefKeychain, name)
+	return nil
+}
+
 // Helper functions
 
 func maskSensitiveConfig(cfg *config.Config) *config.Config {
@@ -326,6 +383,8 @@ func setConfigValue(cfg *config.Config, key, value string) error {
 		return setLoggingConfigValue(cfg, parts[1:], value)
 	case "session":
 		return setSessionConfigValue(cfg, parts[1:], value)
+	case "update":
+		return setUpdateConfigValue(cfg, parts[1:], value)
 	default:
 		return fmt.Errorf("unknown configuration section: %s", parts[0])
 	}
@@ -393,6 +452,27 @@ func setSessionConfigValue(cfg *config.Config, parts []string, value string) err
 	return fmt.Errorf("session configuration not yet implemented")
 }
 
+func setUpdateConfigValue(cfg *config.Config, parts []string, value string) error {
+	if len(parts) == 0 {
+		return fmt.Errorf("incomplete configuration key")
+	}
+
+	switch parts[0] {
+	case "disable_check":
+		disabled, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		cfg.Update.DisableCheck = disabled
+	case "public_key":
+		cfg.Update.PublicKey = value
+	default:
+		return fmt.Errorf("unknown update configuration key: %s", parts[0])
+	}
+
+	return nil
+}
+
 func getConfigValue(cfg *config.Config, key string) (string, error) {
 	// Simple implementation - would need to handle all nested fields
 	parts := strings.Split(key, ".")
@@ -0,0 +1,164 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/deps"
+)
+
+var (
+	depsIndirect     bool
+	depsGateCommands []string
+	depsSummaryFile  string
+)
+
+// depsCmd represents the deps command
+var depsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "Inspect and upgrade Go module dependencies",
+	Long: `Inspect and upgrade the dependencies of the Go module in the current
+directory. The same operations are available to the AI in chat as the
+deps_outdated, deps_bump and deps_test tools.`,
+}
+
+// depsOutdatedCmd lists outdated dependencies
+var depsOutdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List dependencies with newer versions",
+	Args:  cobra.NoArgs,
+	RunE:  runDepsOutdated,
+}
+
+// depsUpgradeCmd upgrades dependencies one at a time
+var depsUpgradeCmd = &cobra.Command{
+	Use:   "upgrade [modules...]",
+	Short: "Upgrade dependencies one at a time, keeping those that pass the gates",
+	Long: `Upgrade outdated dependencies (or only the given modules) one at a time.
+
+Before starting, the gates (go build ./... and go test ./..., or the --gate
+commands) must pass. Each module is then upgraded to its newest version with
+go get and go mod tidy, and the gates run again: when one fails, go.mod and
+go.sum are restored and the next module is tried. A summary of the upgraded,
+reverted and failed modules is printed at the end.
+
+Examples:
+  coda deps upgrade
+  coda deps upgrade golang.org/x/text github.com/spf13/cobra
+  coda deps upgrade --indirect --gate "go vet ./..." --gate "go test -short ./..."`,
+	RunE: runDepsUpgrade,
+}
+
+func init() {
+	rootCmd.AddCommand(depsCmd)
+	depsCmd.AddCommand(depsOutdatedCmd)
+	depsCmd.AddCommand(depsUpgradeCmd)
+
+	depsOutdatedCmd.Flags().BoolVar(&depsIndirect, "indirect", false, "include indirect dependencies")
+	depsUpgradeCmd.Flags().BoolVar(&depsIndirect, "indirect", false, "include indirect dependencies")
+	depsUpgradeCmd.Flags().StringArrayVar(&depsGateCommands, "gate", nil, "command that must pass to keep an upgrade (repeatable, replaces the default build and test)")
+	depsUpgradeCmd.Flags().StringVar(&depsSummaryFile, "summary-file", "", "also write the summary to this file")
+}
+
+func runDepsOutdated(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	modules, err := deps.NewProject(".").Outdated(ctx, depsIndirect)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		ShowInfo("All dependencies are up to date")
+		return nil
+	}
+	for _, m := range modules {
+		fmt.Printf("%-50s %-12s -> %s\n", m.Path, m.Version, m.Update)
+	}
+	return nil
+}
+
+func runDepsUpgrade(cmd *cobra.Command, args []string) error {
+	if !deps.IsGoModule(".") {
+		return fmt.Errorf("no go.mod in the current directory")
+	}
+	var opts []deps.Option
+	if len(depsGateCommands) > 0 {
+		gates := make([]deps.Gate, 0, len(depsGateCommands))
+		for _, command := range depsGateCommands {
+			gate, err := deps.ParseGate(command)
+			if err != nil {
+				return err
+			}
+			gates = append(gates, gate)
+		}
+		opts = append(opts, deps.WithGates(gates...))
+	}
+	project := deps.NewProject(".", opts...)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	ShowInfo("Checking that the build and tests pass before upgrading")
+	if check := project.Check(ctx); !check.Passed {
+		return fmt.Errorf("%s fails before any upgrade; fix it first:\n%s", check.Failed, check.Output)
+	}
+
+	modules, err := project.Outdated(ctx, depsIndirect || len(args) > 0)
+	if err != nil {
+		return err
+	}
+	modules, err = selectModules(modules, args)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		ShowInfo("All dependencies are up to date")
+		return nil
+	}
+
+	results, err := project.Upgrade(ctx, modules, func(m deps.Module, result *deps.Result) {
+		if result == nil {
+			fmt.Fprintf(os.Stderr, "Upgrading %s %s -> %s\n", m.Path, m.Version, m.Update)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "  %s\n", result.Status)
+	})
+	summary := deps.Summary(results)
+	fmt.Print(summary)
+	if depsSummaryFile != "" {
+		if writeErr := os.WriteFile(depsSummaryFile, []byte(summary), 0644); writeErr != nil {
+			ShowWarning("Failed to write summary: %v", writeErr)
+		}
+	}
+	return err
+}
+
+// selectModules keeps the outdated modules named in paths, or all of them
+// when paths is empty
+func selectModules(outdated []deps.Module, paths []string) ([]deps.Module, error) {
+	if len(paths) == 0 {
+		return outdated, nil
+	}
+	byPath := make(map[string]deps.Module, len(outdated))
+	for _, m := range outdated {
+		byPath[m.Path] = m
+	}
+	selected := make([]deps.Module, 0, len(paths))
+	for _, path := range paths {
+		m, ok := byPath[path]
+		if !ok {
+			return nil, fmt.Errorf("%s is not an outdated dependency", path)
+		}
+		selected = append(selected, m)
+	}
+	return selected, nil
+}
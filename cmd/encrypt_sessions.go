@@ -0,0 +1,65 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// encryptSessionsCmd represents the encrypt-sessions command
+var encryptSessionsCmd = &cobra.Command{
+	Use:   "encrypt-sessions",
+	Short: "Encrypt the project's unencrypted sessions",
+	Long: `Encrypt the project's saved sessions, their backups and partial responses
+that were written before session.encryption.key was set. While encryption is
+on, unencrypted session files are refused rather than read.
+
+Sealed sessions are left as they were sealed, since encrypting them would
+change their checksum; they can still be opened. Sessions open in another
+CODA instance are skipped; run the command again once it has exited.`,
+	Args: cobra.NoArgs,
+	RunE: runEncryptSessions,
+}
+
+func init() {
+	rootCmd.AddCommand(encryptSessionsCmd)
+}
+
+func runEncryptSessions(cmd *cobra.Command, args []string) error {
+	cfg := GetConfig()
+	cipher, err := chat.NewSessionCipher(cfg.Session.Encryption)
+	if err != nil {
+		return fmt.Errorf("session encryption key: %w", err)
+	}
+	if cipher == nil {
+		return fmt.Errorf("session encryption is off; set session.encryption.key first")
+	}
+
+	sessionPath, err := chat.GetProjectSessionPath()
+	if err != nil {
+		return fmt.Errorf("failed to locate sessions: %w", err)
+	}
+	persistence, err := chat.NewFilePersistence(sessionPath, false, 0, chat.WithCipher(cipher))
+	if err != nil {
+		return err
+	}
+	defer persistence.Close()
+
+	report, err := persistence.EncryptSessions()
+	if report != nil {
+		ShowSuccess("Encrypted %d session(s) and %d other file(s)", len(report.Sessions), report.Files)
+		if len(report.Sealed) > 0 {
+			ShowInfo("Left sealed sessions as they are: %s", strings.Join(report.Sealed, ", "))
+		}
+		if len(report.Locked) > 0 {
+			ShowWarning("Skipped sessions open in another instance: %s", strings.Join(report.Locked, ", "))
+		}
+	}
+	return err
+}
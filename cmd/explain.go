@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/explain"
+	"github.com/common-creation/coda/internal/symbols"
+)
+
+var (
+	explainQuestion     string
+	explainContextLines int
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain <file>[:start-end]",
+	Short: "Explain a file or line range",
+	Long: `Explain a file or a range of lines without starting an interactive session.
+
+The selected code is sent together with the surrounding lines and, for Go
+files, the definitions it references from the workspace symbol index. The
+explanation is streamed to stdout.
+
+Examples:
+  coda explain internal/chat/handler.go
+  coda explain internal/chat/handler.go:120-180
+  coda explain main.go:42 -q "why is the error ignored here?"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().StringVarP(&explainQuestion, "question", "q", "", "question to focus the explanation on")
+	explainCmd.Flags().IntVar(&explainContextLines, "context", explain.DefaultContextLines, "lines of surrounding context to include")
+	explainCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	target, err := explain.ParseTarget(args[0])
+	if err != nil {
+		return err
+	}
+
+	req := explain.Request{
+		Target:       target,
+		Question:     explainQuestion,
+		ContextLines: explainContextLines,
+		Root:         ".",
+	}
+	if strings.HasSuffix(target.Path, ".go") {
+		idx, err := symbols.BuildIndex(".")
+		if err != nil {
+			ShowWarning("Symbol index unavailable: %v", err)
+		} else {
+			req.Index = idx
+		}
+	}
+
+	messages, err := explain.BuildMessages(req)
+	if err != nil {
+		return err
+	}
+
+	cfg := GetConfig()
+	if model != "" {
+		cfg.AI.Model = model
+	}
+
+	client, err := createAIClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	_, err = explain.Stream(context.Background(), client, cfg.AI.Model, messages, func(delta string) {
+		fmt.Fprint(os.Stdout, delta)
+	})
+	fmt.Println()
+	return err
+}
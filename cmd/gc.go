@@ -0,0 +1,205 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/artifacts"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/journal"
+	"github.com/common-creation/coda/internal/retention"
+)
+
+var (
+	gcDryRun     bool
+	gcCategories []string
+	gcVerbose    bool
+)
+
+// gcCmd represents the gc command
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove old sessions, artifacts and logs",
+	Long: `Remove the sessions, artifacts and logs of the project that are older or
+larger than the limits under retention in the config, and report the space
+reclaimed.
+
+Categories:
+  sessions   saved sessions of the project, their backups and quarantined
+             files; sealed sessions and sessions open in a running instance
+             are always kept
+  artifacts  session directories under .coda/artifacts/
+  logs       daily journal files under .coda/journal/ and rotated log files
+
+A category without limits is left alone. The same limits are enforced during
+chat sessions every retention.janitor_interval seconds.`,
+	Example: `  coda gc --dry-run
+  coda gc --category artifacts,logs
+  coda gc -v`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+
+	gcCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "n", false, "report what would be removed without removing it")
+	gcCmd.Flags().StringSliceVar(&gcCategories, "category", nil, "only collect these categories (sessions, artifacts, logs)")
+	gcCmd.Flags().BoolVarP(&gcVerbose, "verbose", "v", false, "list every item removed")
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	cfg := GetConfig()
+	for _, category := range gcCategories {
+		if !slices.Contains(retention.Categories, category) {
+			return fmt.Errorf("unknown category %q (use %s)", category, strings.Join(retention.Categories, ", "))
+		}
+	}
+
+	policies := retentionPolicies(cfg)
+	if len(policies) == 0 {
+		ShowInfo("No retention limits are configured; set them under retention in the config")
+		return nil
+	}
+
+	sources, err := retentionSources(cfg)
+	if err != nil {
+		return err
+	}
+	if len(gcCategories) > 0 {
+		sources = slices.DeleteFunc(sources, func(s retention.Source) bool {
+			return !slices.Contains(gcCategories, s.Category())
+		})
+	}
+
+	report := retention.NewJanitor(policies, sources...).Run(gcDryRun)
+	printGCReport(report, policies)
+	return nil
+}
+
+// printGCReport prints a table of what was reclaimed per category
+func printGCReport(report *retention.Report, policies map[string]retention.Policy) {
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CATEGORY\tREMOVED\tRECLAIMED\tKEPT\tLIMITS\n")
+	for _, result := range report.Results {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d (%s)\t%s\n", result.Category, len(result.Removed),
+			retention.FormatSize(result.Freed), result.Kept, retention.FormatSize(result.KeptSize),
+			describePolicy(policies[result.Category]))
+	}
+	w.Flush()
+
+	for _, result := range report.Results {
+		if gcVerbose {
+			for _, item := range result.Removed {
+				fmt.Printf("%s %s: %s (%s, %s)\n", verb, result.Category, item.Name,
+					retention.FormatSize(item.Size), item.ModTime.Format("2006-01-02"))
+			}
+		}
+		for _, err := range result.Errors {
+			ShowWarning("%s: %v", result.Category, err)
+		}
+	}
+
+	if report.DryRun {
+		ShowInfo("%s would be reclaimed (dry run)", retention.FormatSize(report.Freed()))
+	} else {
+		ShowSuccess("%s reclaimed", retention.FormatSize(report.Freed()))
+	}
+}
+
+// describePolicy summarizes the limits of a category
+func describePolicy(policy retention.Policy) string {
+	var limits []string
+	if policy.MaxAge > 0 {
+		limits = append(limits, fmt.Sprintf("%d days", int(policy.MaxAge/(24*time.Hour))))
+	}
+	if policy.MaxSize > 0 {
+		limits = append(limits, retention.FormatSize(policy.MaxSize))
+	}
+	if len(limits) == 0 {
+		return "none"
+	}
+	return strings.Join(limits, ", ")
+}
+
+// retentionPolicies returns the configured limits by category, without the
+// categories that have none
+func retentionPolicies(cfg *config.Config) map[string]retention.Policy {
+	policies := make(map[string]retention.Policy)
+	for category, policy := range map[string]config.RetentionPolicy{
+		retention.CategorySessions:  cfg.Retention.Sessions,
+		retention.CategoryArtifacts: cfg.Retention.Artifacts,
+		retention.CategoryLogs:      cfg.Retention.Logs,
+	} {
+		p := retention.Policy{
+			MaxAge:  time.Duration(policy.MaxAgeDays) * 24 * time.Hour,
+			MaxSize: int64(policy.MaxSizeMB) * 1024 * 1024,
+		}
+		if !p.IsZero() {
+			policies[category] = p
+		}
+	}
+	return policies
+}
+
+// retentionSources returns the data of the project that retention applies to
+func retentionSources(cfg *config.Config) ([]retention.Source, error) {
+	var sources []retention.Source
+
+	sessionPath, err := chat.GetProjectSessionPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate sessions: %w", err)
+	}
+	persistence, err := chat.NewFilePersistence(sessionPath, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, persistence.RetentionSource())
+
+	sources = append(sources, retention.NewDirSource(retention.CategoryArtifacts, artifacts.NewStore("").Root()))
+
+	// Rotated log files are named <base>.<timestamp><ext> next to the log
+	logPatterns := []string{filepath.Join(journal.DefaultDir, "*.md")}
+	for _, output := range cfg.Logging.Outputs {
+		if output.Type != "file" || output.Target == "" {
+			continue
+		}
+		ext := filepath.Ext(output.Target)
+		logPatterns = append(logPatterns, strings.TrimSuffix(output.Target, ext)+".*"+ext)
+	}
+	sources = append(sources, retention.NewFileSource(retention.CategoryLogs, logPatterns...))
+
+	return sources, nil
+}
+
+// startJanitor enforces the retention limits in the background until ctx
+// is done
+func startJanitor(ctx context.Context, cfg *config.Config) {
+	policies := retentionPolicies(cfg)
+	if len(policies) == 0 || cfg.Retention.JanitorInterval < 0 {
+		return
+	}
+	sources, err := retentionSources(cfg)
+	if err != nil {
+		return
+	}
+	interval := time.Duration(cfg.Retention.JanitorInterval) * time.Second
+	retention.NewJanitor(policies, sources...).Start(ctx, interval)
+}
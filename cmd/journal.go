@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/journal"
+)
+
+var journalList bool
+
+// journalCmd represents the journal command
+var journalCmd = &cobra.Command{
+	Use:   "journal [date]",
+	Short: "Show the daily log of agent actions",
+	Long: `Show the project's journal: one markdown file per day under .coda/journal/,
+summarizing each chat run with its sessions, prompts, files touched, commands
+run and costs.
+
+The date is YYYY-MM-DD, "today" or "yesterday"; without one the most recent
+day is shown. Writing the journal can be turned off with
+session.disable_journal in the config.`,
+	Example: `  coda journal
+  coda journal yesterday
+  coda journal 2025-06-30
+  coda journal --list`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runJournal,
+}
+
+func init() {
+	rootCmd.AddCommand(journalCmd)
+
+	journalCmd.Flags().BoolVarP(&journalList, "list", "l", false, "list the days with a journal")
+}
+
+func runJournal(cmd *cobra.Command, args []string) error {
+	j := journal.New("")
+
+	if journalList {
+		days, err := j.Days()
+		if err != nil {
+			return err
+		}
+		if len(days) == 0 {
+			ShowInfo("No journal yet in %s", journal.DefaultDir)
+			return nil
+		}
+		for _, day := range days {
+			fmt.Println(day.Format("2006-01-02"))
+		}
+		return nil
+	}
+
+	var day time.Time
+	if len(args) == 1 {
+		parsed, err := parseJournalDay(args[0], time.Now())
+		if err != nil {
+			return err
+		}
+		day = parsed
+	} else {
+		days, err := j.Days()
+		if err != nil {
+			return err
+		}
+		if len(days) == 0 {
+			ShowInfo("No journal yet in %s", journal.DefaultDir)
+			return nil
+		}
+		day = days[0]
+	}
+
+	content, err := j.Read(day)
+	if err != nil {
+		return err
+	}
+	fmt.Print(content)
+	return nil
+}
+
+// parseJournalDay parses a journal date relative to now
+func parseJournalDay(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD, today or yesterday)", s)
+	}
+	return day, nil
+}
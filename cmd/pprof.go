@@ -0,0 +1,43 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+)
+
+// pprofAddr is the address the runtime profiles are served on, if any
+var pprofAddr string
+
+// startPprof serves the runtime profiles of net/http/pprof on addr for as
+// long as the process runs, e.g. for
+//
+//	go tool pprof http://localhost:6060/debug/pprof/profile?seconds=10
+func startPprof(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go http.Serve(listener, mux)
+
+	// Profiles expose the command line and the memory of the process
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			fmt.Fprintf(os.Stderr, "Warning: runtime profiles are reachable from other hosts on %s\n", listener.Addr())
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Serving runtime profiles on http://%s/debug/pprof/\n", listener.Addr())
+	return nil
+}
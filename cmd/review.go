@@ -0,0 +1,165 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/review"
+	"github.com/common-creation/coda/internal/styles"
+	"github.com/common-creation/coda/internal/ui/views"
+)
+
+var (
+	reviewDiffFile     string
+	reviewStaged       bool
+	reviewOutput       string
+	reviewNoTUI        bool
+	reviewBudget       int
+	reviewInstructions string
+)
+
+// reviewCmd represents the review command
+var reviewCmd = &cobra.Command{
+	Use:   "review [git-range]",
+	Short: "Review a diff with AI",
+	Long: `Review a diff and report findings grouped by file and line.
+
+The diff is taken from a git range, the staged changes, a diff file, or stdin.
+Large diffs are split into chunks that fit the token budget and reviewed
+part by part. Findings are shown in an interactive view (press 'e' to export
+them as markdown) or printed as markdown with --no-tui.
+
+Examples:
+  coda review                      # Uncommitted changes (git diff HEAD)
+  coda review main...feature       # Changes on a branch
+  coda review --staged             # Staged changes only
+  coda review --file change.diff   # A diff file ("-" reads stdin)
+  coda review -o review.md         # Write the report to a file`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReview,
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().StringVarP(&reviewDiffFile, "file", "f", "", "read the diff from a file (\"-\" for stdin)")
+	reviewCmd.Flags().BoolVar(&reviewStaged, "staged", false, "review staged changes")
+	reviewCmd.Flags().StringVarP(&reviewOutput, "output", "o", "", "write the markdown report to this file")
+	reviewCmd.Flags().BoolVar(&reviewNoTUI, "no-tui", false, "print the markdown report instead of opening the interactive view")
+	reviewCmd.Flags().IntVar(&reviewBudget, "budget", review.DefaultTokenBudget, "maximum diff tokens per review request")
+	reviewCmd.Flags().StringVar(&reviewInstructions, "focus", "", "additional review instructions (e.g. \"security\")")
+	reviewCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+}
+
+func runReview(cmd *cobra.Command, args []string) error {
+	diff, err := loadReviewDiff(args)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(diff) == "" {
+		ShowInfo("No changes to review")
+		return nil
+	}
+
+	cfg := GetConfig()
+	if model != "" {
+		cfg.AI.Model = model
+	}
+
+	client, err := createAIClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	reviewer := review.NewReviewer(client, cfg.AI.Model,
+		review.WithTokenBudget(reviewBudget),
+		review.WithInstructions(reviewInstructions),
+	)
+
+	result, err := reviewer.Review(context.Background(), diff, func(chunk, total int, files []string) {
+		fmt.Fprintf(os.Stderr, "Reviewing part %d/%d (%s)...\n", chunk, total, strings.Join(files, ", "))
+	})
+	if err != nil {
+		return err
+	}
+
+	report := result.Markdown()
+	if reviewOutput != "" {
+		if err := os.WriteFile(reviewOutput, []byte(report), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", reviewOutput)
+	}
+
+	if reviewNoTUI || !isTerminal(os.Stdout) {
+		if reviewOutput == "" {
+			fmt.Print(report)
+		}
+		return nil
+	}
+
+	exportPath := reviewOutput
+	if exportPath == "" {
+		exportPath = "coda-review.md"
+	}
+	theme := styles.GetTheme(cfg.UI.Theme)
+	view := views.NewReviewView(result, theme.GetStyles(), exportPath)
+	_, err = tea.NewProgram(view, tea.WithAltScreen()).Run()
+	return err
+}
+
+// loadReviewDiff reads the diff from the selected source
+func loadReviewDiff(args []string) (string, error) {
+	switch {
+	case reviewDiffFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read diff from stdin: %w", err)
+		}
+		return string(data), nil
+	case reviewDiffFile != "":
+		data, err := os.ReadFile(reviewDiffFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read diff file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	gitArgs := []string{"diff", "--no-color", "--no-ext-diff"}
+	switch {
+	case reviewStaged:
+		gitArgs = append(gitArgs, "--cached")
+	case len(args) > 0:
+		gitArgs = append(gitArgs, args[0])
+	default:
+		gitArgs = append(gitArgs, "HEAD")
+	}
+
+	out, err := exec.Command("git", gitArgs...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git diff failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git diff failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -20,11 +20,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/common-creation/coda/internal/chat"
 	"github.com/common-creation/coda/internal/config"
 	"github.com/common-creation/coda/internal/mcp"
 )
@@ -33,8 +35,10 @@ var (
 	cfgFile    string
 	debugMode  bool
 	noColor    bool
+	dryRun     bool
 	cfg        *config.Config
 	mcpManager mcp.Manager
+	mcpOnce    sync.Once
 
 	// Version information
 	appVersion string
@@ -68,6 +72,9 @@ func SetVersion(version, commit, date string) {
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	if closeErr := CloseAuditLog(); closeErr != nil {
+		ShowWarning("Failed to close audit log: %v", closeErr)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -80,11 +87,17 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.coda/config.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "enable debug mode")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "report what write tools would do instead of running them")
+	rootCmd.PersistentFlags().StringVar(&pprofAddr, "pprof", "", "serve runtime profiles on this address (e.g. localhost:6060)")
 
 	// Add chat-related flags to root command for direct chat invocation
 	rootCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
 	rootCmd.Flags().BoolVar(&continueSession, "continue", false, "continue last session")
 	rootCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool executions (use with caution)")
+	rootCmd.Flags().BoolVar(&strictModel, "strict", false, "fail instead of warning when the configured model is deprecated or unavailable")
+	rootCmd.Flags().BoolVar(&inlineMode, "inline", false, "render without the alternate screen, appending messages to the terminal scrollback")
+	rootCmd.Flags().StringVar(&transcriptPath, "transcript", "", "mirror the conversation to a file as it happens (\"-\" for stdout)")
+	rootCmd.Flags().StringVar(&transcriptFormat, "transcript-format", "text", "transcript format: text or jsonl")
 
 	// Bind flags to viper
 	viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
@@ -123,9 +136,10 @@ func initConfig() {
 		disableColors()
 	}
 
-	// Initialize MCP manager
-	if err := initializeMCP(cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to initialize MCP: %v\n", err)
+	if pprofAddr != "" {
+		if err := startPprof(pprofAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to serve runtime profiles: %v\n", err)
+		}
 	}
 }
 
@@ -337,13 +351,28 @@ func initializeMCP(cfg *config.Config) error {
 	return nil
 }
 
-// GetMCPManager returns the MCP manager instance
+// GetMCPManager returns the MCP manager instance, initializing it on first
+// use so that commands which never use MCP do not pay for it
 func GetMCPManager() mcp.Manager {
+	mcpOnce.Do(func() {
+		if err := initializeMCP(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize MCP: %v\n", err)
+		}
+	})
 	return mcpManager
 }
 
+// SaveSessions saves the current session of every open chat, for exits that
+// skip the normal shutdown
+func SaveSessions() error {
+	return chat.SaveOpenSessions()
+}
+
 // ShutdownMCP gracefully shuts down the MCP manager
 func ShutdownMCP() error {
+	// Waits for an initialization in progress, and keeps a later one from
+	// starting
+	mcpOnce.Do(func() {})
 	if mcpManager != nil {
 		if debugMode {
 			fmt.Println("Shutting down MCP servers...")
@@ -0,0 +1,173 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/web"
+)
+
+var (
+	serveAddr     string
+	serveToken    string
+	serveSpectate bool
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the chat in a browser",
+	Long: `Run CODA as an HTTP server with a web frontend that shows the
+conversation, accepts prompts and asks for tool call approval in the browser.
+
+The server listens on localhost only by default. To use CODA running on a
+remote machine, forward the port over SSH and open the printed URL locally:
+  ssh -L 7777:localhost:7777 remote-host
+  coda serve
+
+Every API request must carry the access token printed at startup (a random
+one is generated unless --token is set).
+
+With --spectate a second, read-only URL is printed as well. It shows the
+session live in a browser or with coda attach, but cannot send prompts, cancel
+them or answer approvals.
+
+On shared demo machines and in workshops, serve.operators in the config adds
+operators with their own URLs and roles. A viewer's prompts may only use tools
+that read the workspace, an editor's may also change files, and an admin's
+may also run commands and reach the network. Operators only answer approvals
+of tools their role could request. serve.role sets the role of the token
+printed first (default: admin).`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7777", "address to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "access token for the API (default: random)")
+	serveCmd.Flags().BoolVar(&serveSpectate, "spectate", false, "also print a read-only URL for following the session")
+	serveCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+	serveCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool executions (use with caution)")
+	serveCmd.Flags().BoolVar(&strictModel, "strict", false, "fail instead of warning when the configured model is deprecated or unavailable")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	handler, err := setupChatHandler(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to setup chat handler: %w", err)
+	}
+	defer handler.Close()
+
+	cfg := GetConfig()
+	toolManager, err := createToolManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create tool manager: %w", err)
+	}
+
+	token := serveToken
+	if token == "" {
+		if token, err = web.GenerateToken(); err != nil {
+			return err
+		}
+	}
+
+	var spectatorToken string
+	if serveSpectate {
+		if spectatorToken, err = web.GenerateToken(); err != nil {
+			return err
+		}
+	}
+
+	operators, err := serveOperators(cfg)
+	if err != nil {
+		return err
+	}
+
+	server := web.NewServer(handler,
+		web.WithToken(token),
+		web.WithRole(cfg.Serve.Role),
+		web.WithOperators(operators...),
+		web.WithSpectatorToken(spectatorToken),
+		web.WithAutoApprove(autoApprove || cfg.Tools.AutoApprove),
+	)
+
+	approvalRules, err := setupApprovalRules(cfg, &simpleLogger{})
+	if err != nil {
+		return fmt.Errorf("failed to load approval rules: %w", err)
+	}
+
+	// The server doubles as the approval handler, forwarding requests to the
+	// browser; the timeout also covers the time the user takes to answer
+	executor := chat.NewToolExecutor(toolManager, GetMCPManager(), security.NewDefaultValidator("."), server,
+		chat.WithTimeout(10*time.Minute),
+		chat.WithApprovalRules(approvalRules),
+		chat.WithWorkspace(cfg.Tools.WorkspaceRoot),
+	)
+	server.SetRunner(chat.NewPromptRunner(handler, executor, chat.WithRunObserver(server.Observe)))
+
+	listener, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+	}
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "localhost"
+	}
+	address := net.JoinHostPort(host, port)
+	ShowInfo("Serving CODA on http://%s/?token=%s (Ctrl+C to stop)", address, url.QueryEscape(token))
+	for _, op := range operators {
+		ShowInfo("URL for %s (%s): http://%s/?token=%s", op.Name, op.Role, address, url.QueryEscape(op.Token))
+	}
+	if spectatorToken != "" {
+		ShowInfo("Read-only URL for spectators: http://%s/?token=%s", address, url.QueryEscape(spectatorToken))
+	}
+
+	return server.Serve(ctx, listener)
+}
+
+// serveOperators returns the operators of serve.operators with their tokens
+// resolved, or generated when none is configured
+func serveOperators(cfg *config.Config) ([]web.Operator, error) {
+	refs := make(map[string]string)
+	for _, op := range cfg.Serve.Operators {
+		if op.Token != "" {
+			refs[op.Name] = op.Token
+		}
+	}
+	tokens, err := resolveSecretRefs(refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve operator tokens: %w", err)
+	}
+
+	operators := make([]web.Operator, 0, len(cfg.Serve.Operators))
+	for _, op := range cfg.Serve.Operators {
+		token := tokens[op.Name]
+		if token == "" {
+			if token, err = web.GenerateToken(); err != nil {
+				return nil, err
+			}
+		}
+		operators = append(operators, web.Operator{Name: op.Name, Role: op.Role, Token: token})
+	}
+	return operators, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/coverage"
+	"github.com/common-creation/coda/internal/security"
+)
+
+var (
+	testgenThreshold    float64
+	testgenMaxFunctions int
+)
+
+// testgenApproveTools are the tools the AI may use to write tests. Running
+// commands needs --auto-approve.
+var testgenApproveTools = []string{"read_file", "write_file", "edit_file", "list_files", "search_files"}
+
+// testgenCmd represents the testgen command
+var testgenCmd = &cobra.Command{
+	Use:   "testgen [packages...]",
+	Short: "Write tests for the least covered functions",
+	Long: `Measure test coverage, then have the AI write table-driven tests for the
+functions covered least.
+
+The packages (default ./...) are tested with "go test -coverprofile" from the
+module root. Functions below --threshold percent are ranked by their number of
+uncovered statements, and the AI is asked to write tests for the first
+--max-functions of them, one fresh conversation each. Coverage is measured
+again at the end and the change of each targeted function is printed.
+
+The AI may read, write and edit files. Running commands, such as "go test"
+to check the new tests, is denied unless --auto-approve is given; pass it
+only when you trust the AI to run commands without review.
+
+Examples:
+  coda testgen
+  coda testgen ./internal/config --threshold 80 --max-functions 3`,
+	RunE: runTestgen,
+}
+
+func init() {
+	rootCmd.AddCommand(testgenCmd)
+
+	testgenCmd.Flags().Float64Var(&testgenThreshold, "threshold", 60, "target functions covered below this percentage")
+	testgenCmd.Flags().IntVar(&testgenMaxFunctions, "max-functions", 5, "maximum number of functions to write tests for")
+	testgenCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+	testgenCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool executions (use with caution)")
+}
+
+func runTestgen(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	ShowInfo("Measuring coverage of %s", packagesLabel(args))
+	before, err := coverage.Measure(ctx, ".", args)
+	if err != nil {
+		return fmt.Errorf("failed to measure coverage: %w", err)
+	}
+	targets := coverage.LowCoverage(before, testgenThreshold, testgenMaxFunctions)
+	if len(targets) == 0 {
+		ShowInfo("No function is covered below %.0f%%", testgenThreshold)
+		return nil
+	}
+
+	handler, err := setupChatHandler(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to setup chat handler: %w", err)
+	}
+	defer handler.Close()
+
+	cfg := GetConfig()
+	toolManager, err := createToolManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create tool manager: %w", err)
+	}
+
+	approvalRules, err := setupApprovalRules(cfg, &simpleLogger{})
+	if err != nil {
+		return fmt.Errorf("failed to load approval rules: %w", err)
+	}
+
+	approver := chat.NewStaticApprovalHandler(autoApprove || cfg.Tools.AutoApprove, testgenApproveTools)
+	executor := chat.NewToolExecutor(toolManager, GetMCPManager(), security.NewDefaultValidator("."), approver,
+		chat.WithApprovalRules(approvalRules),
+		chat.WithWorkspace(cfg.Tools.WorkspaceRoot),
+		chat.WithTimeout(10*time.Minute),
+	)
+	runner := chat.NewPromptRunner(handler, executor, chat.WithRunObserver(func(event chat.RunEvent) {
+		switch event.Kind {
+		case chat.RunEventToolDone:
+			status := "ok"
+			if event.Err != nil {
+				status = event.Err.Error()
+			}
+			fmt.Fprintf(os.Stderr, "    tool %s: %s\n", event.Tool, status)
+		case chat.RunEventWarning:
+			fmt.Fprintf(os.Stderr, "    %s\n", event.Text)
+		}
+	}))
+
+	for i, target := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s (%s, %.0f%% covered)\n", i+1, len(targets), target.Name, target.File, target.Percent())
+		if i > 0 {
+			if err := handler.CreateNewSession(); err != nil {
+				ShowWarning("Failed to start a new session: %v", err)
+			}
+		}
+		result, err := runner.Run(ctx, coverage.Prompt(target))
+		if err != nil {
+			ShowWarning("Writing tests for %s failed: %v", target.Name, err)
+			continue
+		}
+		if output := strings.TrimSpace(result.Output); output != "" {
+			fmt.Println(output)
+			fmt.Println()
+		}
+	}
+
+	ShowInfo("Measuring coverage again")
+	after, err := coverage.Measure(context.Background(), ".", args)
+	if err != nil {
+		return fmt.Errorf("failed to measure coverage after writing tests: %w", err)
+	}
+	fmt.Fprint(os.Stderr, coverage.Summary(before, after, targets))
+	return nil
+}
+
+// packagesLabel describes the package patterns for messages
+func packagesLabel(patterns []string) string {
+	if len(patterns) == 0 {
+		return "./..."
+	}
+	return strings.Join(patterns, " ")
+}
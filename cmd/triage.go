@@ -0,0 +1,137 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/triage"
+)
+
+var (
+	triageFile     string
+	triageOutput   string
+	triageJSON     bool
+	triageTemplate string
+	triageNoIndex  bool
+)
+
+// triageCmd represents the triage command
+var triageCmd = &cobra.Command{
+	Use:   "triage",
+	Short: "Triage an issue read from stdin",
+	Long: `Triage an issue and print a structured report: its category and
+severity, suggested labels, steps to reproduce, the files likely involved
+and what to ask the reporter.
+
+The issue is read from stdin, as printed by "gh issue view" (plain or with
+--json), or as plain text whose first line is the title. Suspect files are
+found by searching the workspace index for code similar to the issue.
+
+The prompt is a Go text/template; --template replaces it. It receives
+.Issue (Number, Title, State, Author, URL, Labels, Body), .IssueText and
+.Candidates (Path, StartLine, EndLine, Score, Code).
+
+Examples:
+  gh issue view 123 | coda triage
+  gh issue view 123 --json number,title,body,labels | coda triage --json
+  coda triage < issue.txt -o triage.md`,
+	Args: cobra.NoArgs,
+	RunE: runTriage,
+}
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+
+	triageCmd.Flags().StringVarP(&triageFile, "file", "f", "", "read the issue from a file instead of stdin")
+	triageCmd.Flags().StringVarP(&triageOutput, "output", "o", "", "write the report to this file")
+	triageCmd.Flags().BoolVar(&triageJSON, "json", false, "print the report as JSON")
+	triageCmd.Flags().StringVar(&triageTemplate, "template", "", "prompt template file")
+	triageCmd.Flags().BoolVar(&triageNoIndex, "no-index", false, "do not search the workspace index for suspect files")
+	triageCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+}
+
+func runTriage(cmd *cobra.Command, args []string) error {
+	issue, err := readTriageIssue()
+	if err != nil {
+		return err
+	}
+
+	cfg := GetConfig()
+	if model != "" {
+		cfg.AI.Model = model
+	}
+
+	client, err := createAIClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	var opts []triage.Option
+	if triageTemplate != "" {
+		data, err := os.ReadFile(triageTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		opts = append(opts, triage.WithTemplate(string(data)))
+	}
+	if !triageNoIndex {
+		index, err := newSearchIndex(cfg)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, triage.WithIndex(index))
+		fmt.Fprintln(os.Stderr, "Searching the workspace for related code...")
+	}
+
+	triager := triage.NewTriager(client, cfg.AI.Model, cfg.Tools.WorkspaceRoot, opts...)
+	report, err := triager.Triage(context.Background(), issue)
+	if err != nil {
+		return err
+	}
+
+	output := report.Markdown()
+	if triageJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		output = string(data) + "\n"
+	}
+
+	if triageOutput != "" {
+		if err := os.WriteFile(triageOutput, []byte(output), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", triageOutput)
+		return nil
+	}
+	fmt.Print(output)
+	return nil
+}
+
+// readTriageIssue reads the issue from --file or stdin
+func readTriageIssue() (string, error) {
+	if triageFile != "" && triageFile != "-" {
+		data, err := os.ReadFile(triageFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read issue file: %w", err)
+		}
+		return string(data), nil
+	}
+	if isTerminal(os.Stdin) {
+		return "", fmt.Errorf("no issue on stdin; pipe one in, e.g. gh issue view 123 | coda triage")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read issue from stdin: %w", err)
+	}
+	return string(data), nil
+}
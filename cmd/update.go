@@ -0,0 +1,218 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/updater"
+)
+
+// UpdatePublicKey is the base64 encoded ed25519 key used to verify release
+// signatures. Release builds set it with
+// -X github.com/common-creation/coda/cmd.UpdatePublicKey=...; update.public_key
+// in the configuration takes precedence.
+var UpdatePublicKey = ""
+
+var (
+	updateCheckOnly bool
+	updateYes       bool
+	updateForce     bool
+	updateInsecure  bool
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update CODA to the latest release",
+	Long: `Check GitHub for a newer CODA release and install it.
+
+The downloaded archive is verified against the release checksums.txt, and
+the checksum file itself against its ed25519 signature. Without a public key
+the update is refused; --insecure installs after checking the checksum only,
+which does not prove the release is authentic. The running binary is
+replaced atomically.
+
+Set update.disable_check to true (or CODA_DISABLE_UPDATE_CHECK=1) to disable
+update checks entirely.`,
+	RunE: runUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().BoolVar(&updateCheckOnly, "check", false, "only check for updates and show the changelog")
+	updateCmd.Flags().BoolVarP(&updateYes, "yes", "y", false, "install without asking for confirmation")
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "reinstall even if already up to date or running a dev build")
+	updateCmd.Flags().BoolVar(&updateInsecure, "insecure", false, "install without a release signature when no public key is configured")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	if updateChecksDisabled() {
+		return fmt.Errorf("update checks are disabled (update.disable_check)")
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Minute)
+	defer cancel()
+
+	current := currentVersion()
+	u := updater.New()
+
+	latest, err := u.LatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current == "dev" && !updateForce {
+		ShowWarning("Running a development build; latest release is %s", latest.TagName)
+		ShowInfo("Use --force to replace this build with the release")
+		return nil
+	}
+
+	if updater.CompareVersions(latest.Version(), current) <= 0 && !updateForce {
+		ShowSuccess("CODA %s is up to date", current)
+		return nil
+	}
+
+	fmt.Printf("Current version: %s\n", current)
+	fmt.Printf("Latest version:  %s\n", latest.Version())
+	printChangelog(ctx, u, current, latest)
+
+	if updateCheckOnly {
+		ShowInfo("Run 'coda update' to install %s", latest.TagName)
+		return nil
+	}
+
+	if !updateYes && !confirmUpdate(latest.TagName) {
+		ShowInfo("Update cancelled")
+		return nil
+	}
+
+	if err := installRelease(ctx, u, latest); err != nil {
+		return err
+	}
+
+	ShowSuccess("Updated CODA to %s", latest.TagName)
+	return nil
+}
+
+// installRelease downloads, verifies and installs the release for this platform
+func installRelease(ctx context.Context, u *updater.Updater, release *updater.Release) error {
+	assetName := updater.CurrentAssetName()
+	asset, ok := release.FindAsset(assetName)
+	if !ok {
+		return fmt.Errorf("release %s has no asset for this platform (%s)", release.TagName, assetName)
+	}
+	checksumAsset, ok := release.FindAsset(updater.ChecksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s; refusing to install unverified binary", release.TagName, updater.ChecksumsAsset)
+	}
+
+	checksums, err := u.Download(ctx, checksumAsset)
+	if err != nil {
+		return err
+	}
+
+	if publicKey := updatePublicKey(); publicKey != "" {
+		sigAsset, ok := release.FindAsset(updater.SignatureAsset)
+		if !ok {
+			return fmt.Errorf("release %s is not signed (%s missing)", release.TagName, updater.SignatureAsset)
+		}
+		signature, err := u.Download(ctx, sigAsset)
+		if err != nil {
+			return err
+		}
+		if err := updater.VerifySignature(checksums, signature, publicKey); err != nil {
+			return fmt.Errorf("failed to verify %s: %w", updater.ChecksumsAsset, err)
+		}
+		ShowInfo("Signature verified")
+	} else if updateInsecure {
+		ShowWarning("No update public key configured; verifying checksum only (--insecure)")
+	} else {
+		return fmt.Errorf("no update public key configured, so release %s cannot be authenticated; set update.public_key or pass --insecure to verify the checksum only", release.TagName)
+	}
+
+	ShowInfo("Downloading %s...", asset.Name)
+	archive, err := u.Download(ctx, asset)
+	if err != nil {
+		return err
+	}
+	if err := updater.VerifyChecksum(archive, checksums, asset.Name); err != nil {
+		return err
+	}
+	ShowInfo("Checksum verified")
+
+	binary, err := updater.ExtractBinary(asset.Name, archive, updater.BinaryName())
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate current executable: %w", err)
+	}
+
+	return updater.ReplaceExecutable(executable, binary)
+}
+
+// printChangelog prints the release notes of every release newer than current
+func printChangelog(ctx context.Context, u *updater.Updater, current string, latest *updater.Release) {
+	releases, err := u.ReleasesSince(ctx, current)
+	if err != nil || len(releases) == 0 {
+		releases = []updater.Release{*latest}
+	}
+
+	fmt.Println("\nChanges:")
+	for _, r := range releases {
+		fmt.Printf("\n## %s", r.TagName)
+		if !r.PublishedAt.IsZero() {
+			fmt.Printf(" (%s)", r.PublishedAt.Format("2006-01-02"))
+		}
+		fmt.Println()
+		if notes := strings.TrimSpace(r.Body); notes != "" {
+			fmt.Println(notes)
+		} else {
+			fmt.Println("No release notes.")
+		}
+	}
+	fmt.Println()
+}
+
+func confirmUpdate(tag string) bool {
+	fmt.Printf("Install %s? [y/N]: ", tag)
+	reader := bufio.NewReader(os.Stdin)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// currentVersion returns the running version without the leading "v"
+func currentVersion() string {
+	v := appVersion
+	if v == "" || v == "dev" {
+		v = Version
+	}
+	return strings.TrimPrefix(v, "v")
+}
+
+func updateChecksDisabled() bool {
+	return cfg != nil && cfg.Update.DisableCheck
+}
+
+func updatePublicKey() string {
+	if cfg != nil && cfg.Update.PublicKey != "" {
+		return cfg.Update.PublicKey
+	}
+	return UpdatePublicKey
+}
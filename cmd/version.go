@@ -4,13 +4,17 @@ Copyright © 2025 CODA Project
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"runtime"
 	"runtime/debug"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/updater"
 )
 
 // Version information variables
@@ -175,9 +179,19 @@ func outputVerbose(info VersionInfo) error {
 }
 
 func checkForUpdates() string {
-	// This would check for updates in a real implementation
-	// For now, return empty string
-	return ""
+	if updateChecksDisabled() || currentVersion() == "dev" {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	latest, err := updater.New().LatestRelease(ctx)
+	if err != nil || updater.CompareVersions(latest.Version(), currentVersion()) <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("A new version is available: %s (run 'coda update')", latest.TagName)
 }
 
 // GetVersionString returns a formatted version string
@@ -0,0 +1,203 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/watch"
+)
+
+var (
+	watchGlobs        []string
+	watchExcludes     []string
+	watchPromptFile   string
+	watchDebounce     time.Duration
+	watchInterval     time.Duration
+	watchApproveTools []string
+	watchRunNow       bool
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Rerun a prompt whenever matching files change",
+	Long: `Watch files and rerun a headless prompt when they change.
+
+The prompt file is re-read before every run and the list of changed files is
+appended to it. Each run starts a fresh conversation and ends with a summary.
+Changes are debounced so a burst of saves triggers a single run, and edits
+made by the run itself do not trigger another one.
+
+Tool calls are allowed when listed with --approve-tools (or with
+--auto-approve) and denied otherwise.
+
+Examples:
+  coda watch --glob '**/*.go' --prompt-file refactor.md --approve-tools read_file,edit_file
+  coda watch --glob 'docs/**/*.md' --glob 'api/*.go' --prompt-file doc-sync.md --auto-approve`,
+	Args: cobra.NoArgs,
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringArrayVar(&watchGlobs, "glob", nil, "files to watch (repeatable, supports **)")
+	watchCmd.Flags().StringArrayVar(&watchExcludes, "exclude", nil, "files to ignore (repeatable)")
+	watchCmd.Flags().StringVar(&watchPromptFile, "prompt-file", "", "file containing the prompt to run")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", watch.DefaultDebounce, "how long files must be unchanged before a run starts")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", watch.DefaultInterval, "how often to check for changes")
+	watchCmd.Flags().StringSliceVar(&watchApproveTools, "approve-tools", nil, "tools the AI may use without asking (comma separated)")
+	watchCmd.Flags().BoolVar(&watchRunNow, "run-now", false, "run the prompt once at startup")
+	watchCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+	watchCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool executions (use with caution)")
+
+	watchCmd.MarkFlagRequired("glob")
+	watchCmd.MarkFlagRequired("prompt-file")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if _, err := os.Stat(watchPromptFile); err != nil {
+		return fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	handler, err := setupChatHandler(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to setup chat handler: %w", err)
+	}
+	defer handler.Close()
+
+	cfg := GetConfig()
+	toolManager, err := createToolManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create tool manager: %w", err)
+	}
+
+	approvalRules, err := setupApprovalRules(cfg, &simpleLogger{})
+	if err != nil {
+		return fmt.Errorf("failed to load approval rules: %w", err)
+	}
+
+	approver := chat.NewStaticApprovalHandler(autoApprove || cfg.Tools.AutoApprove, watchApproveTools)
+	executor := chat.NewToolExecutor(toolManager, GetMCPManager(), security.NewDefaultValidator("."), approver,
+		chat.WithApprovalRules(approvalRules),
+		chat.WithWorkspace(cfg.Tools.WorkspaceRoot),
+		chat.WithTimeout(10*time.Minute),
+	)
+	runner := chat.NewPromptRunner(handler, executor, chat.WithRunObserver(func(event chat.RunEvent) {
+		switch event.Kind {
+		case chat.RunEventToolDone:
+			status := "ok"
+			if event.Err != nil {
+				status = event.Err.Error()
+			}
+			fmt.Fprintf(os.Stderr, "  tool %s: %s\n", event.Tool, status)
+		case chat.RunEventWarning:
+			fmt.Fprintf(os.Stderr, "  %s\n", event.Text)
+		}
+	}))
+
+	runs := 0
+	run := func(changes []watch.Change) {
+		runs++
+		if err := handler.CreateNewSession(); err != nil {
+			ShowWarning("Failed to start a new session: %v", err)
+		}
+		runWatchPrompt(ctx, runner, runs, changes)
+	}
+
+	watcher := watch.New(".", watchGlobs,
+		watch.WithExcludes(watchExcludes...),
+		watch.WithDebounce(watchDebounce),
+		watch.WithInterval(watchInterval),
+	)
+
+	if watchRunNow {
+		run(nil)
+	}
+
+	ShowInfo("Watching %s (Ctrl+C to stop)", strings.Join(watchGlobs, ", "))
+	err = watcher.Run(ctx, run)
+	if ctx.Err() != nil {
+		ShowInfo("Stopped after %d run(s)", runs)
+		return nil
+	}
+	return err
+}
+
+// runWatchPrompt runs the prompt file once and prints a summary of the run
+func runWatchPrompt(ctx context.Context, runner *chat.PromptRunner, number int, changes []watch.Change) {
+	fmt.Fprintf(os.Stderr, "\n== Run #%d: %s ==\n", number, describeChanges(changes))
+
+	data, err := os.ReadFile(watchPromptFile)
+	if err != nil {
+		ShowError("Run #%d skipped: failed to read prompt file: %v", number, err)
+		return
+	}
+
+	prompt := string(data)
+	if len(changes) > 0 {
+		var sb strings.Builder
+		sb.WriteString(strings.TrimRight(prompt, "\n"))
+		sb.WriteString("\n\nFiles changed since the last run:\n")
+		for _, c := range changes {
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", c.Path, c.Op))
+		}
+		prompt = sb.String()
+	}
+
+	start := time.Now()
+	result, err := runner.Run(ctx, prompt)
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+
+	if result != nil && strings.TrimSpace(result.Output) != "" {
+		fmt.Println(strings.TrimSpace(result.Output))
+	}
+
+	failed := 0
+	toolCalls := 0
+	if result != nil {
+		toolCalls = len(result.ToolResults)
+		for _, r := range result.ToolResults {
+			if r.Error != nil {
+				failed++
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("Run #%d finished in %s: %d tool call(s), %d failed", number, elapsed, toolCalls, failed)
+	if err != nil {
+		if ctx.Err() != nil {
+			summary = fmt.Sprintf("Run #%d cancelled after %s", number, elapsed)
+		} else {
+			summary += fmt.Sprintf(", error: %v", err)
+		}
+	}
+	fmt.Fprintln(os.Stderr, summary)
+}
+
+// describeChanges summarizes a batch of changes for the run header
+func describeChanges(changes []watch.Change) string {
+	switch len(changes) {
+	case 0:
+		return "initial run"
+	case 1:
+		return fmt.Sprintf("%s %s", changes[0].Path, changes[0].Op)
+	default:
+		return fmt.Sprintf("%d files changed", len(changes))
+	}
+}
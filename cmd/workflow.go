@@ -0,0 +1,196 @@
+/*
+Copyright © 2025 CODA Project
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/workflow"
+)
+
+var workflowVars []string
+
+// workflowCmd represents the workflow command
+var workflowCmd = &cobra.Command{
+	Use:   "workflow",
+	Short: "Run YAML-defined multi-step workflows",
+	Long: `Run repeatable multi-step operations defined in YAML.
+
+Workflows are looked up by name in .coda/workflows/ and ~/.coda/workflows/,
+or given as a file path. Each step either sends a prompt to the AI (which
+may call tools) or invokes a tool directly. Prompts, parameters and "if"
+conditions are Go templates over .Vars and the results of earlier steps
+(.Steps.<name>.Output, .Status, .Error). Prompt steps share one conversation.
+
+Example (.coda/workflows/release.yaml):
+  name: release
+  description: Update CHANGELOG and bump version
+  vars:
+    version: "1.0.0"
+  approve_tools: [read_file, edit_file]
+  steps:
+    - name: changelog
+      prompt: Add a {{.Vars.version}} section to CHANGELOG.md for the unreleased changes.
+    - name: version
+      tool: read_file
+      params: {path: VERSION}
+    - name: bump
+      if: ne (trim .Steps.version.Output) .Vars.version
+      prompt: Set the version in VERSION to {{.Vars.version}}.`,
+}
+
+// workflowRunCmd runs a workflow
+var workflowRunCmd = &cobra.Command{
+	Use:   "run NAME|FILE",
+	Short: "Run a workflow",
+	Long: `Run a workflow headlessly and print a summary of each step.
+
+Tool steps and the tool calls the AI makes during prompt steps are allowed
+when listed in the workflow's approve_tools (or with --auto-approve) and
+denied otherwise. Approval rules and high-risk checks apply to both.
+
+Examples:
+  coda workflow run release --var version=1.2.0
+  coda workflow run ./ops/docs-sync.yaml --auto-approve`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkflow,
+}
+
+// workflowListCmd lists the available workflows
+var workflowListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available workflows",
+	Args:  cobra.NoArgs,
+	RunE:  runWorkflowList,
+}
+
+func init() {
+	rootCmd.AddCommand(workflowCmd)
+	workflowCmd.AddCommand(workflowRunCmd)
+	workflowCmd.AddCommand(workflowListCmd)
+
+	workflowRunCmd.Flags().StringArrayVar(&workflowVars, "var", nil, "set a workflow variable (key=value, repeatable)")
+	workflowRunCmd.Flags().StringVar(&model, "model", "", "AI model to use (overrides config)")
+	workflowRunCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "auto-approve all tool calls made by the AI (use with caution)")
+}
+
+func runWorkflow(cmd *cobra.Command, args []string) error {
+	wf, err := workflow.Find(args[0], workflow.DefaultDirs())
+	if err != nil {
+		return err
+	}
+
+	vars, err := parseWorkflowVars(workflowVars)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	handler, err := setupChatHandler(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to setup chat handler: %w", err)
+	}
+	defer handler.Close()
+
+	cfg := GetConfig()
+	toolManager, err := createToolManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create tool manager: %w", err)
+	}
+
+	approvalRules, err := setupApprovalRules(cfg, &simpleLogger{})
+	if err != nil {
+		return fmt.Errorf("failed to load approval rules: %w", err)
+	}
+
+	approver := chat.NewStaticApprovalHandler(autoApprove || cfg.Tools.AutoApprove, wf.ApproveTools)
+	executor := chat.NewToolExecutor(toolManager, GetMCPManager(), security.NewDefaultValidator("."), approver,
+		chat.WithApprovalRules(approvalRules),
+		chat.WithWorkspace(cfg.Tools.WorkspaceRoot),
+		chat.WithTimeout(10*time.Minute),
+	)
+	runner := chat.NewPromptRunner(handler, executor, chat.WithRunObserver(func(event chat.RunEvent) {
+		switch event.Kind {
+		case chat.RunEventToolDone:
+			status := "ok"
+			if event.Err != nil {
+				status = event.Err.Error()
+			}
+			fmt.Fprintf(os.Stderr, "    tool %s: %s\n", event.Tool, status)
+		case chat.RunEventWarning:
+			fmt.Fprintf(os.Stderr, "    %s\n", event.Text)
+		}
+	}))
+
+	engine := workflow.NewEngine(
+		workflow.AgentPrompts{Runner: runner},
+		workflow.ExecutorTools{Executor: executor},
+		workflow.WithVars(vars),
+		workflow.WithObserver(func(index, total int, step workflow.Step, result *workflow.StepResult) {
+			if result == nil {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s (%s)\n", index+1, total, step.Name, step.Kind())
+				return
+			}
+			if result.Status == workflow.StatusSucceeded && result.Output != "" {
+				fmt.Println(strings.TrimSpace(result.Output))
+				fmt.Println()
+			}
+		}),
+	)
+
+	ShowInfo("Running workflow %s", wf.Name)
+	result, err := engine.Run(ctx, wf)
+	if result != nil {
+		fmt.Fprint(os.Stderr, result.Summary())
+	}
+	if err != nil {
+		return err
+	}
+	if result.Failed {
+		return fmt.Errorf("workflow %s failed", wf.Name)
+	}
+	return nil
+}
+
+func runWorkflowList(cmd *cobra.Command, args []string) error {
+	workflows, errs := workflow.List(workflow.DefaultDirs())
+	for _, err := range errs {
+		ShowWarning("%v", err)
+	}
+
+	if len(workflows) == 0 {
+		ShowInfo("No workflows found in %s", strings.Join(workflow.DefaultDirs(), ", "))
+		return nil
+	}
+
+	for _, wf := range workflows {
+		fmt.Printf("%-20s %d step(s)  %s\n", wf.Name, len(wf.Steps), wf.Description)
+	}
+	return nil
+}
+
+// parseWorkflowVars parses key=value pairs
+func parseWorkflowVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
@@ -0,0 +1,216 @@
+// Package acp implements an Agent Client Protocol server over stdio so that
+// editors (Zed, Neovim plugins, ...) can use CODA as their backend agent.
+//
+// Messages are newline-delimited JSON-RPC 2.0. The editor (client) drives
+// sessions with initialize, session/new, session/load, session/prompt and
+// session/cancel; the agent streams progress with session/update
+// notifications and asks the client for tool approval with
+// session/request_permission.
+package acp
+
+import "encoding/json"
+
+// ProtocolVersion is the ACP version implemented by this server
+const ProtocolVersion = 1
+
+// Method names
+const (
+	MethodInitialize        = "initialize"
+	MethodAuthenticate      = "authenticate"
+	MethodSessionNew        = "session/new"
+	MethodSessionLoad       = "session/load"
+	MethodSessionPrompt     = "session/prompt"
+	MethodSessionCancel     = "session/cancel"
+	MethodSessionUpdate     = "session/update"
+	MethodRequestPermission = "session/request_permission"
+)
+
+// JSON-RPC error codes
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// Stop reasons returned from session/prompt
+const (
+	StopReasonEndTurn         = "end_turn"
+	StopReasonMaxTurnRequests = "max_turn_requests"
+	StopReasonCancelled       = "cancelled"
+)
+
+// Tool call statuses
+const (
+	ToolStatusPending    = "pending"
+	ToolStatusInProgress = "in_progress"
+	ToolStatusCompleted  = "completed"
+	ToolStatusFailed     = "failed"
+)
+
+// Permission option identifiers offered to the client
+const (
+	OptionAllowOnce   = "allow"
+	OptionAllowAlways = "allow_always"
+	OptionReject      = "reject"
+)
+
+// message is a JSON-RPC 2.0 request, response or notification
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *RPCError        `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error object
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// InitializeParams is sent by the client on startup
+type InitializeParams struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+// InitializeResult describes the agent's capabilities
+type InitializeResult struct {
+	ProtocolVersion   int               `json:"protocolVersion"`
+	AgentCapabilities AgentCapabilities `json:"agentCapabilities"`
+	AuthMethods       []interface{}     `json:"authMethods"`
+}
+
+// AgentCapabilities lists optional protocol features the agent supports
+type AgentCapabilities struct {
+	LoadSession        bool               `json:"loadSession"`
+	PromptCapabilities PromptCapabilities `json:"promptCapabilities"`
+}
+
+// PromptCapabilities lists the content types accepted in prompts
+type PromptCapabilities struct {
+	Image           bool `json:"image"`
+	Audio           bool `json:"audio"`
+	EmbeddedContext bool `json:"embeddedContext"`
+}
+
+// NewSessionParams creates a session
+type NewSessionParams struct {
+	Cwd        string            `json:"cwd"`
+	MCPServers []json.RawMessage `json:"mcpServers,omitempty"`
+}
+
+// NewSessionResult returns the created session
+type NewSessionResult struct {
+	SessionID string `json:"sessionId"`
+}
+
+// LoadSessionParams resumes an existing session
+type LoadSessionParams struct {
+	SessionID  string            `json:"sessionId"`
+	Cwd        string            `json:"cwd"`
+	MCPServers []json.RawMessage `json:"mcpServers,omitempty"`
+}
+
+// PromptParams sends a user turn
+type PromptParams struct {
+	SessionID string         `json:"sessionId"`
+	Prompt    []ContentBlock `json:"prompt"`
+}
+
+// PromptResult ends a user turn
+type PromptResult struct {
+	StopReason string `json:"stopReason"`
+}
+
+// CancelParams cancels the running turn of a session
+type CancelParams struct {
+	SessionID string `json:"sessionId"`
+}
+
+// ContentBlock is a piece of prompt or message content
+type ContentBlock struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Resource *EmbeddedResource `json:"resource,omitempty"`
+}
+
+// EmbeddedResource is file content attached to a prompt by the editor
+type EmbeddedResource struct {
+	URI      string `json:"uri"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// TextContent creates a text content block
+func TextContent(text string) ContentBlock {
+	return ContentBlock{Type: "text", Text: text}
+}
+
+// SessionNotification carries a session/update
+type SessionNotification struct {
+	SessionID string        `json:"sessionId"`
+	Update    SessionUpdate `json:"update"`
+}
+
+// SessionUpdate is a single progress update. SessionUpdate selects which
+// of the remaining fields are meaningful.
+type SessionUpdate struct {
+	SessionUpdate string                 `json:"sessionUpdate"`
+	Content       interface{}            `json:"content,omitempty"`
+	ToolCallID    string                 `json:"toolCallId,omitempty"`
+	Title         string                 `json:"title,omitempty"`
+	Kind          string                 `json:"kind,omitempty"`
+	Status        string                 `json:"status,omitempty"`
+	RawInput      map[string]interface{} `json:"rawInput,omitempty"`
+}
+
+// ToolCallContent wraps content produced by a tool call
+type ToolCallContent struct {
+	Type    string       `json:"type"`
+	Content ContentBlock `json:"content"`
+}
+
+// ToolCall describes a tool call in a permission request
+type ToolCall struct {
+	ToolCallID string                 `json:"toolCallId"`
+	Title      string                 `json:"title"`
+	Kind       string                 `json:"kind"`
+	Status     string                 `json:"status"`
+	RawInput   map[string]interface{} `json:"rawInput,omitempty"`
+}
+
+// PermissionOption is a choice offered to the user
+type PermissionOption struct {
+	OptionID string `json:"optionId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+}
+
+// RequestPermissionParams asks the client to approve a tool call
+type RequestPermissionParams struct {
+	SessionID string             `json:"sessionId"`
+	ToolCall  ToolCall           `json:"toolCall"`
+	Options   []PermissionOption `json:"options"`
+}
+
+// RequestPermissionResult is the client's decision
+type RequestPermissionResult struct {
+	Outcome PermissionOutcome `json:"outcome"`
+}
+
+// PermissionOutcome is either "selected" (with OptionID) or "cancelled"
+type PermissionOutcome struct {
+	Outcome  string `json:"outcome"`
+	OptionID string `json:"optionId,omitempty"`
+}
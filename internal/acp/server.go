@@ -0,0 +1,554 @@
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+// ChatBackend is the part of chat.ChatHandler used by the server
+type ChatBackend interface {
+	CreateNewSession() error
+	SwitchSession(id string) error
+	GetCurrentSession() *chat.Session
+	HandleMessageWithResponse(ctx context.Context, input string, tokenCallback func(int)) (*chat.ChatResponse, error)
+	ContinueConversation(ctx context.Context, tokenCallback func(int)) (*chat.ChatResponse, error)
+	AddMessageToSession(message ai.Message) error
+}
+
+// ToolRunner executes tool calls requested by the model (chat.ToolExecutor)
+type ToolRunner interface {
+	ExecuteToolCalls(ctx context.Context, toolCalls []ai.ToolCall) ([]chat.ToolResult, error)
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithAutoApprove approves every tool call without asking the client
+func WithAutoApprove(autoApprove bool) Option {
+	return func(s *Server) {
+		s.autoApprove = autoApprove
+	}
+}
+
+// WithMaxTurns bounds the number of tool round trips within one prompt
+func WithMaxTurns(n int) Option {
+	return func(s *Server) {
+		s.maxTurns = n
+	}
+}
+
+type sessionKey struct{}
+type toolCallKey struct{}
+
+// Server serves the Agent Client Protocol over a pair of streams
+type Server struct {
+	backend     ChatBackend
+	tools       ToolRunner
+	in          io.Reader
+	out         io.Writer
+	autoApprove bool
+	maxTurns    int
+
+	writeMu sync.Mutex
+	nextID  int64
+
+	pendingMu sync.Mutex
+	pending   map[string]chan *message
+
+	// The chat handler has a single current session, so prompts and session
+	// creation are serialized
+	promptMu sync.Mutex
+
+	cancelMu sync.Mutex
+	cancels  map[string]context.CancelFunc
+
+	allowMu sync.Mutex
+	allowed map[string]map[string]bool // session ID -> tool name -> always allowed
+}
+
+// NewServer creates an ACP server reading requests from in and writing to out
+func NewServer(backend ChatBackend, in io.Reader, out io.Writer, opts ...Option) *Server {
+	s := &Server{
+		backend:  backend,
+		in:       in,
+		out:      out,
+		maxTurns: 25,
+		pending:  make(map[string]chan *message),
+		cancels:  make(map[string]context.CancelFunc),
+		allowed:  make(map[string]map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetToolRunner sets the tool executor. It is separate from NewServer because
+// the executor normally uses the server itself as its ApprovalHandler.
+func (s *Server) SetToolRunner(runner ToolRunner) {
+	s.tools = runner
+}
+
+// Serve processes messages until the input is closed or ctx is cancelled
+func (s *Server) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scanner := bufio.NewScanner(s.in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var msg message
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			s.writeError(nil, &RPCError{Code: ErrCodeParse, Message: fmt.Sprintf("parse error: %v", err)})
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			// Requests run concurrently: a prompt blocks while waiting for
+			// permission responses that arrive on this same stream
+			wg.Add(1)
+			go func(msg message) {
+				defer wg.Done()
+				s.handleRequest(ctx, &msg)
+			}(msg)
+		case msg.Method != "":
+			s.handleNotification(&msg)
+		case msg.ID != nil:
+			s.handleResponse(&msg)
+		}
+	}
+
+	cancel()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read from client: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleRequest(ctx context.Context, msg *message) {
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch msg.Method {
+	case MethodInitialize:
+		result = InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			AgentCapabilities: AgentCapabilities{
+				PromptCapabilities: PromptCapabilities{EmbeddedContext: true},
+			},
+			AuthMethods: []interface{}{},
+		}
+	case MethodAuthenticate:
+		result = struct{}{}
+	case MethodSessionNew:
+		result, err = s.newSession()
+	case MethodSessionPrompt:
+		var params PromptParams
+		if err = decodeParams(msg.Params, &params); err == nil {
+			result, err = s.prompt(ctx, params)
+		}
+	default:
+		err = &RPCError{Code: ErrCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	if err != nil {
+		rpcErr, ok := err.(*RPCError)
+		if !ok {
+			rpcErr = &RPCError{Code: ErrCodeInternal, Message: err.Error()}
+		}
+		s.writeError(msg.ID, rpcErr)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		s.writeError(msg.ID, &RPCError{Code: ErrCodeInternal, Message: err.Error()})
+		return
+	}
+	s.write(&message{JSONRPC: "2.0", ID: msg.ID, Result: data})
+}
+
+func (s *Server) handleNotification(msg *message) {
+	if msg.Method != MethodSessionCancel {
+		return
+	}
+
+	var params CancelParams
+	if err := decodeParams(msg.Params, &params); err != nil {
+		return
+	}
+
+	s.cancelMu.Lock()
+	cancel, ok := s.cancels[params.SessionID]
+	s.cancelMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *Server) handleResponse(msg *message) {
+	key := string(*msg.ID)
+
+	s.pendingMu.Lock()
+	ch, ok := s.pending[key]
+	delete(s.pending, key)
+	s.pendingMu.Unlock()
+
+	if ok {
+		ch <- msg
+	}
+}
+
+// newSession creates a session, waiting for a running prompt to finish
+// since creating one changes the backend's current session
+func (s *Server) newSession() (*NewSessionResult, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+
+	if err := s.backend.CreateNewSession(); err != nil {
+		return nil, err
+	}
+	session := s.backend.GetCurrentSession()
+	if session == nil {
+		return nil, fmt.Errorf("failed to create session")
+	}
+	return &NewSessionResult{SessionID: session.ID}, nil
+}
+
+// prompt runs one user turn, executing tool calls until the model stops asking for them
+func (s *Server) prompt(ctx context.Context, params PromptParams) (*PromptResult, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+
+	if err := s.backend.SwitchSession(params.SessionID); err != nil {
+		return nil, &RPCError{Code: ErrCodeInvalidParams, Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancelMu.Lock()
+	s.cancels[params.SessionID] = cancel
+	s.cancelMu.Unlock()
+	defer func() {
+		s.cancelMu.Lock()
+		delete(s.cancels, params.SessionID)
+		s.cancelMu.Unlock()
+		cancel()
+	}()
+
+	ctx = context.WithValue(ctx, sessionKey{}, params.SessionID)
+
+	input := promptText(params.Prompt)
+	if input == "" {
+		return nil, &RPCError{Code: ErrCodeInvalidParams, Message: "prompt is empty"}
+	}
+
+	resp, err := s.backend.HandleMessageWithResponse(ctx, input, nil)
+	for turn := 0; ; turn++ {
+		if ctx.Err() != nil {
+			return &PromptResult{StopReason: StopReasonCancelled}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Content != "" {
+			s.notify(params.SessionID, SessionUpdate{
+				SessionUpdate: "agent_message_chunk",
+				Content:       TextContent(resp.Content),
+			})
+		}
+
+		if len(resp.ToolCalls) == 0 {
+			return &PromptResult{StopReason: StopReasonEndTurn}, nil
+		}
+		if turn >= s.maxTurns {
+			return &PromptResult{StopReason: StopReasonMaxTurnRequests}, nil
+		}
+
+		for _, call := range resp.ToolCalls {
+			if ctx.Err() != nil {
+				return &PromptResult{StopReason: StopReasonCancelled}, nil
+			}
+			s.runToolCall(ctx, params.SessionID, call)
+		}
+
+		resp, err = s.backend.ContinueConversation(ctx, nil)
+	}
+}
+
+// runToolCall executes a single tool call, reporting progress to the client
+// and recording the result in the session
+func (s *Server) runToolCall(ctx context.Context, sessionID string, call ai.ToolCall) {
+	info := describeToolCall(call)
+	s.notify(sessionID, SessionUpdate{
+		SessionUpdate: "tool_call",
+		ToolCallID:    info.ToolCallID,
+		Title:         info.Title,
+		Kind:          info.Kind,
+		Status:        ToolStatusPending,
+		RawInput:      info.RawInput,
+	})
+
+	result := chat.ToolResult{ToolCallID: call.ID, ToolName: call.Function.Name}
+	if s.tools == nil {
+		result.Error = fmt.Errorf("tool execution is not available")
+	} else {
+		toolCtx := tools.WithSessionID(context.WithValue(ctx, toolCallKey{}, info), sessionID)
+		results, err := s.tools.ExecuteToolCalls(toolCtx, []ai.ToolCall{call})
+		switch {
+		case err != nil:
+			result.Error = err
+		case len(results) == 0:
+			result.Error = fmt.Errorf("tool produced no result")
+		default:
+			result = results[0]
+		}
+	}
+
+	status := ToolStatusCompleted
+	if result.Error != nil {
+		status = ToolStatusFailed
+	}
+	s.notify(sessionID, SessionUpdate{
+		SessionUpdate: "tool_call_update",
+		ToolCallID:    info.ToolCallID,
+		Status:        status,
+		Content: []ToolCallContent{{
+			Type:    "content",
+			Content: TextContent(chat.ToolResultContent(result)),
+		}},
+	})
+
+	// Errors here only mean the session vanished; the next model call reports it
+	_ = s.backend.AddMessageToSession(chat.ToolResultMessage(result))
+}
+
+// RequestApproval implements chat.ApprovalHandler by forwarding the decision to the client
+func (s *Server) RequestApproval(ctx context.Context, tool string, params map[string]interface{}) (bool, error) {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	if s.isAlwaysAllowed(sessionID, tool) {
+		return true, nil
+	}
+
+	option, err := s.requestPermission(ctx, tool, params, nil)
+	switch {
+	case err != nil:
+		return false, err
+	case option == OptionAllowAlways:
+		s.allowAlways(sessionID, tool)
+		return true, nil
+	default:
+		return option == OptionAllowOnce, nil
+	}
+}
+
+// ConfirmDangerous implements chat.DangerConfirmer. The client is asked
+// every time, with the dangers in the title and without an option to
+// always allow the tool.
+func (s *Server) ConfirmDangerous(ctx context.Context, tool string, params map[string]interface{}, dangers []string) (bool, error) {
+	option, err := s.requestPermission(ctx, tool, params, dangers)
+	return err == nil && option == OptionAllowOnce, err
+}
+
+// requestPermission asks the client about a tool call and returns the
+// option selected, or "" when the request was canceled
+func (s *Server) requestPermission(ctx context.Context, tool string, params map[string]interface{}, dangers []string) (string, error) {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	info, ok := ctx.Value(toolCallKey{}).(ToolCall)
+	if !ok {
+		info = ToolCall{ToolCallID: tool, Title: tool, Kind: toolKind(tool), RawInput: params}
+	}
+	info.Status = ToolStatusPending
+
+	options := []PermissionOption{
+		{OptionID: OptionAllowOnce, Name: "Allow", Kind: "allow_once"},
+		{OptionID: OptionAllowAlways, Name: "Always allow " + tool, Kind: "allow_always"},
+		{OptionID: OptionReject, Name: "Reject", Kind: "reject_once"},
+	}
+	if len(dangers) > 0 {
+		info.Title = fmt.Sprintf("%s (high risk: %s)", info.Title, strings.Join(dangers, ", "))
+		options = []PermissionOption{options[0], options[2]}
+	}
+
+	var result RequestPermissionResult
+	err := s.call(ctx, MethodRequestPermission, RequestPermissionParams{
+		SessionID: sessionID,
+		ToolCall:  info,
+		Options:   options,
+	}, &result)
+	if err != nil || result.Outcome.Outcome != "selected" {
+		return "", err
+	}
+	return result.Outcome.OptionID, nil
+}
+
+// IsAutoApproved implements chat.ApprovalHandler
+func (s *Server) IsAutoApproved(tool string) bool {
+	return s.autoApprove
+}
+
+func (s *Server) isAlwaysAllowed(sessionID, tool string) bool {
+	s.allowMu.Lock()
+	defer s.allowMu.Unlock()
+	return s.allowed[sessionID][tool]
+}
+
+func (s *Server) allowAlways(sessionID, tool string) {
+	s.allowMu.Lock()
+	defer s.allowMu.Unlock()
+	if s.allowed[sessionID] == nil {
+		s.allowed[sessionID] = make(map[string]bool)
+	}
+	s.allowed[sessionID][tool] = true
+}
+
+// call sends a request to the client and waits for its response
+func (s *Server) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s params: %w", method, err)
+	}
+
+	id := json.RawMessage(fmt.Sprintf("%d", atomic.AddInt64(&s.nextID, 1)))
+	ch := make(chan *message, 1)
+
+	s.pendingMu.Lock()
+	s.pending[string(id)] = ch
+	s.pendingMu.Unlock()
+
+	s.write(&message{JSONRPC: "2.0", ID: &id, Method: method, Params: data})
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("invalid %s response: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		s.pendingMu.Lock()
+		delete(s.pending, string(id))
+		s.pendingMu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) notify(sessionID string, update SessionUpdate) {
+	data, err := json.Marshal(SessionNotification{SessionID: sessionID, Update: update})
+	if err != nil {
+		return
+	}
+	s.write(&message{JSONRPC: "2.0", Method: MethodSessionUpdate, Params: data})
+}
+
+func (s *Server) writeError(id *json.RawMessage, rpcErr *RPCError) {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	s.write(&message{JSONRPC: "2.0", ID: id, Error: rpcErr})
+}
+
+func (s *Server) write(msg *message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.out.Write(append(data, '\n'))
+}
+
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return &RPCError{Code: ErrCodeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &RPCError{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// promptText flattens prompt content blocks into a single user message
+func promptText(blocks []ContentBlock) string {
+	var parts []string
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			parts = append(parts, block.Text)
+		case "resource":
+			if block.Resource != nil && block.Resource.Text != "" {
+				parts = append(parts, fmt.Sprintf("File: %s\n```\n%s\n```", block.Resource.URI, block.Resource.Text))
+			}
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("File: %s", block.URI))
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
+
+// describeToolCall converts a model tool call to its ACP description
+func describeToolCall(call ai.ToolCall) ToolCall {
+	var input map[string]interface{}
+	_ = json.Unmarshal([]byte(call.Function.Arguments), &input)
+
+	title := call.Function.Name
+	for _, key := range []string{"path", "file_path", "directory", "pattern"} {
+		if v, ok := input[key].(string); ok && v != "" {
+			title = fmt.Sprintf("%s: %s", call.Function.Name, v)
+			break
+		}
+	}
+
+	id := call.ID
+	if id == "" {
+		id = call.Function.Name
+	}
+
+	return ToolCall{
+		ToolCallID: id,
+		Title:      title,
+		Kind:       toolKind(call.Function.Name),
+		Status:     ToolStatusPending,
+		RawInput:   input,
+	}
+}
+
+// toolKind maps built-in tools to ACP tool kinds so editors can pick icons
+func toolKind(name string) string {
+	switch name {
+	case "read_file":
+		return "read"
+	case "write_file", "edit_file", "save_artifact":
+		return "edit"
+	case "list_files", "search_files":
+		return "search"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,250 @@
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// fakeBackend answers the first prompt with a tool call and the follow-up with text
+type fakeBackend struct {
+	session  *chat.Session
+	created  int
+	messages []ai.Message
+	targets  []string // Current session ID of each added message
+}
+
+func (b *fakeBackend) CreateNewSession() error {
+	b.created++
+	b.session = &chat.Session{ID: fmt.Sprintf("session-%d", b.created)}
+	return nil
+}
+
+func (b *fakeBackend) SwitchSession(id string) error {
+	if b.session == nil || b.session.ID != id {
+		return fmt.Errorf("session not found: %s", id)
+	}
+	return nil
+}
+
+func (b *fakeBackend) GetCurrentSession() *chat.Session {
+	return b.session
+}
+
+func (b *fakeBackend) HandleMessageWithResponse(ctx context.Context, input string, cb func(int)) (*chat.ChatResponse, error) {
+	b.messages = append(b.messages, ai.Message{Role: ai.RoleUser, Content: input})
+	return &chat.ChatResponse{
+		Content: "Reading the file",
+		ToolCalls: []ai.ToolCall{{
+			ID:       "call-1",
+			Type:     "function",
+			Function: ai.FunctionCall{Name: "read_file", Arguments: `{"path":"main.go"}`},
+		}},
+	}, nil
+}
+
+func (b *fakeBackend) ContinueConversation(ctx context.Context, cb func(int)) (*chat.ChatResponse, error) {
+	return &chat.ChatResponse{Content: "Done"}, nil
+}
+
+func (b *fakeBackend) AddMessageToSession(message ai.Message) error {
+	b.messages = append(b.messages, message)
+	b.targets = append(b.targets, b.session.ID)
+	return nil
+}
+
+// approvingRunner asks the server for approval like chat.ToolExecutor does
+type approvingRunner struct {
+	server *Server
+}
+
+func (r *approvingRunner) ExecuteToolCalls(ctx context.Context, calls []ai.ToolCall) ([]chat.ToolResult, error) {
+	results := make([]chat.ToolResult, 0, len(calls))
+	for _, call := range calls {
+		result := chat.ToolResult{ToolCallID: call.ID, ToolName: call.Function.Name}
+		approved, err := r.server.RequestApproval(ctx, call.Function.Name, nil)
+		switch {
+		case err != nil:
+			result.Error = err
+		case !approved:
+			result.Error = fmt.Errorf("tool execution not approved by user")
+		default:
+			result.Result = "package main"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// testClient drives the server like an editor would
+type testClient struct {
+	t       *testing.T
+	writer  io.Writer
+	scanner *bufio.Scanner
+}
+
+func (c *testClient) send(v interface{}) {
+	data, err := json.Marshal(v)
+	require.NoError(c.t, err)
+	_, err = c.writer.Write(append(data, '\n'))
+	require.NoError(c.t, err)
+}
+
+func (c *testClient) read() map[string]interface{} {
+	require.True(c.t, c.scanner.Scan(), "expected a message from the server")
+	var msg map[string]interface{}
+	require.NoError(c.t, json.Unmarshal(c.scanner.Bytes(), &msg))
+	return msg
+}
+
+func startServer(t *testing.T) (*testClient, *fakeBackend) {
+	clientToServer, serverIn := io.Pipe()
+	serverOut, serverToClient := io.Pipe()
+
+	backend := &fakeBackend{}
+	server := NewServer(backend, clientToServer, serverToClient)
+	server.SetToolRunner(&approvingRunner{server: server})
+
+	go func() {
+		server.Serve(context.Background())
+		serverToClient.Close()
+	}()
+	t.Cleanup(func() { serverIn.Close() })
+
+	client := &testClient{t: t, writer: serverIn, scanner: bufio.NewScanner(serverOut)}
+	return client, backend
+}
+
+func TestServerPromptWithPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		optionID   string
+		wantStatus string
+	}{
+		{name: "allowed", optionID: OptionAllowOnce, wantStatus: ToolStatusCompleted},
+		{name: "rejected", optionID: OptionReject, wantStatus: ToolStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, backend := startServer(t)
+
+			client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": MethodInitialize,
+				"params": map[string]interface{}{"protocolVersion": ProtocolVersion}})
+			initResp := client.read()
+			assert.EqualValues(t, ProtocolVersion, initResp["result"].(map[string]interface{})["protocolVersion"])
+
+			client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": MethodSessionNew,
+				"params": map[string]interface{}{"cwd": "/tmp"}})
+			newResp := client.read()
+			sessionID := newResp["result"].(map[string]interface{})["sessionId"]
+			assert.Equal(t, "session-1", sessionID)
+
+			client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": MethodSessionPrompt,
+				"params": map[string]interface{}{
+					"sessionId": sessionID,
+					"prompt":    []map[string]interface{}{{"type": "text", "text": "What is in main.go?"}},
+				}})
+
+			var updates []map[string]interface{}
+			var stopReason string
+			done := time.After(5 * time.Second)
+			for stopReason == "" {
+				select {
+				case <-done:
+					t.Fatal("timed out waiting for prompt response")
+				default:
+				}
+
+				msg := client.read()
+				switch {
+				case msg["method"] == MethodSessionUpdate:
+					updates = append(updates, msg["params"].(map[string]interface{})["update"].(map[string]interface{}))
+				case msg["method"] == MethodRequestPermission:
+					toolCall := msg["params"].(map[string]interface{})["toolCall"].(map[string]interface{})
+					assert.Equal(t, "call-1", toolCall["toolCallId"])
+					client.send(map[string]interface{}{"jsonrpc": "2.0", "id": msg["id"],
+						"result": map[string]interface{}{"outcome": map[string]interface{}{"outcome": "selected", "optionId": tt.optionID}}})
+				case msg["id"] != nil:
+					stopReason = msg["result"].(map[string]interface{})["stopReason"].(string)
+				}
+			}
+
+			assert.Equal(t, StopReasonEndTurn, stopReason)
+			require.Len(t, updates, 4)
+			assert.Equal(t, "agent_message_chunk", updates[0]["sessionUpdate"])
+			assert.Equal(t, "tool_call", updates[1]["sessionUpdate"])
+			assert.Equal(t, "read", updates[1]["kind"])
+			assert.Equal(t, "tool_call_update", updates[2]["sessionUpdate"])
+			assert.Equal(t, tt.wantStatus, updates[2]["status"])
+			assert.Equal(t, "agent_message_chunk", updates[3]["sessionUpdate"])
+
+			// User prompt plus the TOOL_RESULT message
+			require.Len(t, backend.messages, 2)
+			assert.Contains(t, backend.messages[1].Content, "TOOL_RESULT[read_file]")
+		})
+	}
+}
+
+func TestServerNewSessionWaitsForPrompt(t *testing.T) {
+	client, backend := startServer(t)
+
+	client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": MethodSessionNew,
+		"params": map[string]interface{}{"cwd": "/tmp"}})
+	sessionID := client.read()["result"].(map[string]interface{})["sessionId"]
+
+	client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": MethodSessionPrompt,
+		"params": map[string]interface{}{
+			"sessionId": sessionID,
+			"prompt":    []map[string]interface{}{{"type": "text", "text": "What is in main.go?"}},
+		}})
+
+	// Open a second session while the prompt waits for permission
+	var responses []float64
+	for len(responses) < 2 {
+		msg := client.read()
+		switch {
+		case msg["method"] == MethodRequestPermission:
+			client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": MethodSessionNew,
+				"params": map[string]interface{}{"cwd": "/tmp"}})
+			time.Sleep(50 * time.Millisecond)
+			client.send(map[string]interface{}{"jsonrpc": "2.0", "id": msg["id"],
+				"result": map[string]interface{}{"outcome": map[string]interface{}{"outcome": "selected", "optionId": OptionAllowOnce}}})
+		case msg["method"] == nil && msg["id"] != nil:
+			responses = append(responses, msg["id"].(float64))
+		}
+	}
+
+	assert.Equal(t, []float64{2, 3}, responses, "session/new is answered after the prompt")
+	assert.Equal(t, []string{"session-1"}, backend.targets, "the tool result went to the prompted session")
+}
+
+func TestServerUnknownMethod(t *testing.T) {
+	client, _ := startServer(t)
+
+	client.send(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "session/unknown"})
+	resp := client.read()
+	require.NotNil(t, resp["error"])
+	assert.EqualValues(t, ErrCodeMethodNotFound, resp["error"].(map[string]interface{})["code"])
+}
+
+func TestPromptText(t *testing.T) {
+	text := promptText([]ContentBlock{
+		TextContent("Explain this"),
+		{Type: "resource", Resource: &EmbeddedResource{URI: "file:///a.go", Text: "package a"}},
+		{Type: "resource_link", URI: "file:///b.go"},
+		{Type: "image"},
+	})
+
+	assert.Equal(t, "Explain this\n\nFile: file:///a.go\n```\npackage a\n```\n\nFile: file:///b.go", text)
+}
@@ -13,6 +13,10 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 )
 
+// azureStreamUsageAPIVersion is the first Azure API version accepting stream_options.
+// API versions are date based, so plain string comparison orders them correctly.
+const azureStreamUsageAPIVersion = "2024-09-01"
+
 // AzureClient implements the Client interface for Azure OpenAI Service.
 type AzureClient struct {
 	client         *openai.Client
@@ -72,7 +76,8 @@ func NewAzureClient(config AIConfig, azureConfig AzureConfig) (*AzureClient, err
 
 	// Create HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: rateLimitTransport{},
 	}
 
 	// Create Azure OpenAI client configuration
@@ -97,6 +102,7 @@ func NewAzureClient(config AIConfig, azureConfig AzureConfig) (*AzureClient, err
 
 // ChatCompletion implements the Client interface for non-streaming chat completion.
 func (c *AzureClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	ctx = withRetryHint(ctx)
 	azureReq, err := c.convertChatRequest(req)
 	if err != nil {
 		return nil, err
@@ -129,7 +135,7 @@ func (c *AzureClient) ChatCompletion(ctx context.Context, req ChatRequest) (*Cha
 	}
 
 	if lastErr != nil {
-		return nil, c.wrapError(lastErr)
+		return nil, c.wrapError(ctx, lastErr)
 	}
 
 	return c.convertChatResponse(resp), nil
@@ -137,6 +143,7 @@ func (c *AzureClient) ChatCompletion(ctx context.Context, req ChatRequest) (*Cha
 
 // ChatCompletionStream implements the Client interface for streaming chat completion.
 func (c *AzureClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	ctx = withRetryHint(ctx)
 	azureReq, err := c.convertChatRequest(req)
 	if err != nil {
 		return nil, err
@@ -145,9 +152,15 @@ func (c *AzureClient) ChatCompletionStream(ctx context.Context, req ChatRequest)
 	// Force streaming
 	azureReq.Stream = true
 
+	// Ask for a final usage chunk so callers get real token counts.
+	// Older API versions reject stream_options, so only send it when supported.
+	if c.azureConfig.APIVersion >= azureStreamUsageAPIVersion {
+		azureReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+
 	stream, err := c.client.CreateChatCompletionStream(ctx, azureReq)
 	if err != nil {
-		return nil, c.wrapError(err)
+		return nil, c.wrapError(ctx, err)
 	}
 
 	return &azureStreamReader{
@@ -209,6 +222,18 @@ func (c *AzureClient) Ping(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities implements the Client interface. The deployment name is used
+// when the underlying model is not configured.
+func (c *AzureClient) Capabilities() Capabilities {
+	model := c.config.Model
+	if model == "" {
+		model = c.deploymentName
+	}
+	caps := ModelCapabilities(model)
+	caps.StreamingUsage = c.azureConfig.APIVersion >= azureStreamUsageAPIVersion
+	return caps
+}
+
 // convertChatRequest converts our ChatRequest to Azure OpenAI's format.
 func (c *AzureClient) convertChatRequest(req ChatRequest) (openai.ChatCompletionRequest, error) {
 	azureReq := openai.ChatCompletionRequest{
@@ -217,20 +242,15 @@ func (c *AzureClient) convertChatRequest(req ChatRequest) (openai.ChatCompletion
 		Stream:   req.Stream,
 	}
 	
-	// Handle GPT-5 specific settings
-	if (strings.Contains(strings.ToLower(c.deploymentName), "gpt-5") || 
-		strings.HasPrefix(req.Model, "gpt-5")) && req.ReasoningEffort != nil {
-		// TODO: When go-openai library supports GPT-5 reasoning effort,
-		// add the reasoning effort parameter to the request.
-		// For now, we'll prepare the structure but cannot send it due to SDK limitations.
-		//
-		// Expected future implementation:
-		// azureReq.Reasoning = &openai.ReasoningConfig{
-		//     Effort: *req.ReasoningEffort,
-		// }
-		//
-		// Note: GPT-5 uses a different API endpoint (responses.create) instead of
-		// chat.completions, which may require a different client method when supported.
+	// Drop the parameters the model rejects. The deployment name is used
+	// when the underlying model is not configured.
+	model := req.Model
+	if model == "" {
+		model = c.deploymentName
+	}
+	req = AdaptRequest(req, model)
+	if req.ReasoningEffort != nil {
+		azureReq.ReasoningEffort = *req.ReasoningEffort
 	}
 
 	// Convert messages
@@ -250,15 +270,17 @@ func (c *AzureClient) convertChatRequest(req ChatRequest) (openai.ChatCompletion
 	// Convert optional parameters
 	if req.Temperature != nil {
 		azureReq.Temperature = *req.Temperature
-	} else if c.config.Model != "" {
+	} else if c.config.Model != "" && ParamsFor(model).Sampling {
 		// Use configured temperature if not specified
 		azureReq.Temperature = DefaultTemperature
 	}
 
-	// OpenAI reasoning models (o1, o3, o4, etc.) do not support MaxTokens, they use MaxCompletionTokens instead
-	if req.MaxTokens != nil && !strings.HasPrefix(azureReq.Model, "o") {
+	if req.MaxTokens != nil {
 		azureReq.MaxTokens = *req.MaxTokens
 	}
+	if req.MaxCompletionTokens != nil {
+		azureReq.MaxCompletionTokens = *req.MaxCompletionTokens
+	}
 	if req.TopP != nil {
 		azureReq.TopP = *req.TopP
 	}
@@ -376,7 +398,7 @@ func (c *AzureClient) isRetryableError(err error) bool {
 }
 
 // wrapError converts Azure errors to our error types.
-func (c *AzureClient) wrapError(err error) error {
+func (c *AzureClient) wrapError(ctx context.Context, err error) error {
 	if err == nil {
 		return nil
 	}
@@ -399,6 +421,9 @@ func (c *AzureClient) wrapError(err error) error {
 		if apiErr.Type != "" {
 			aiErr = aiErr.WithDetail("type", apiErr.Type)
 		}
+		if wait := retryAfterFrom(ctx); wait > 0 && aiErr.Type == ErrTypeRateLimit {
+			aiErr = aiErr.WithDetail("retry_after", wait)
+		}
 
 		return aiErr
 	}
@@ -431,6 +456,8 @@ func (c *AzureClient) getErrorType(apiErr *openai.APIError) ErrorType {
 		return ErrTypeInvalidRequest
 	case http.StatusPaymentRequired:
 		return ErrTypeQuotaExceeded
+	case http.StatusNotFound:
+		return ErrTypeModelNotFound
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
 		return ErrTypeServerError
 	case http.StatusGatewayTimeout:
@@ -467,6 +494,15 @@ func (r *azureStreamReader) Read() (*StreamChunk, error) {
 		Choices:           make([]StreamChoice, len(chunk.Choices)),
 	}
 
+	// Usage is only sent in the final chunk, which carries no choices
+	if chunk.Usage != nil {
+		streamChunk.Usage = &Usage{
+			PromptTokens:     chunk.Usage.PromptTokens,
+			CompletionTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:      chunk.Usage.TotalTokens,
+		}
+	}
+
 	// Convert choices
 	for i, choice := range chunk.Choices {
 		streamChunk.Choices[i] = StreamChoice{
@@ -509,3 +545,23 @@ func (r *azureStreamReader) Close() error {
 	}
 	return nil
 }
+
+// Embed implements the Embedder interface.
+func (c *AzureClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	ctx = withRetryHint(ctx)
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, c.wrapError(ctx, err)
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, data := range resp.Data {
+		if data.Index >= 0 && data.Index < len(vectors) {
+			vectors[data.Index] = data.Embedding
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,67 @@
+package ai
+
+import "strings"
+
+// Capabilities describes the features a provider supports for a model
+type Capabilities struct {
+	// Tools reports native function calling support
+	Tools bool
+
+	// Vision reports whether messages may contain images
+	Vision bool
+
+	// JSONSchema reports Structured Outputs support (json_schema response format)
+	JSONSchema bool
+
+	// StreamingUsage reports whether streamed responses end with token usage
+	StreamingUsage bool
+
+	// Reasoning reports reasoning_effort support
+	Reasoning bool
+}
+
+// modelCapabilities maps model name prefixes to the features that depend on
+// the model. More specific prefixes must come before broader ones; models
+// not listed are assumed to be current chat models without vision.
+var modelCapabilities = []struct {
+	prefix string
+	caps   Capabilities
+}{
+	{"o1-mini", Capabilities{Reasoning: true}},
+	{"o1-preview", Capabilities{Reasoning: true}},
+	{"o1", Capabilities{Tools: true, Vision: true, JSONSchema: true, Reasoning: true}},
+	{"o3-mini", Capabilities{Tools: true, JSONSchema: true, Reasoning: true}},
+	{"o3", Capabilities{Tools: true, Vision: true, JSONSchema: true, Reasoning: true}},
+	{"o4", Capabilities{Tools: true, Vision: true, JSONSchema: true, Reasoning: true}},
+	{"gpt-5-chat", Capabilities{Vision: true, JSONSchema: true}},
+	{"gpt-5", Capabilities{Tools: true, Vision: true, JSONSchema: true, Reasoning: true}},
+	{"gpt-4.1", Capabilities{Tools: true, Vision: true, JSONSchema: true}},
+	{"gpt-4o", Capabilities{Tools: true, Vision: true, JSONSchema: true}},
+	{"gpt-4-turbo", Capabilities{Tools: true, Vision: true}},
+	{"gpt-4", Capabilities{Tools: true}},
+	{"gpt-35", Capabilities{Tools: true}},
+	{"gpt-3.5", Capabilities{Tools: true}},
+}
+
+// ModelCapabilities returns the model-dependent capabilities of model;
+// StreamingUsage depends on the provider and is left false
+func ModelCapabilities(model string) Capabilities {
+	model = strings.ToLower(model)
+	for _, entry := range modelCapabilities {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.caps
+		}
+	}
+	return Capabilities{Tools: true, JSONSchema: true}
+}
+
+// ForModel returns c with the model-dependent capabilities of model, for
+// requests to another model than the client's own. An empty model keeps c.
+func (c Capabilities) ForModel(model string) Capabilities {
+	if model == "" {
+		return c
+	}
+	caps := ModelCapabilities(model)
+	caps.StreamingUsage = c.StreamingUsage
+	return caps
+}
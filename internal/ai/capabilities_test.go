@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelCapabilities(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Capabilities
+	}{
+		{"gpt-4o-mini", Capabilities{Tools: true, Vision: true, JSONSchema: true}},
+		{"o3-mini", Capabilities{Tools: true, JSONSchema: true, Reasoning: true}},
+		{"o1-mini", Capabilities{Reasoning: true}},
+		{"GPT-5", Capabilities{Tools: true, Vision: true, JSONSchema: true, Reasoning: true}},
+		{"gpt-5-chat-latest", Capabilities{Vision: true, JSONSchema: true}},
+		{"gpt-35-turbo", Capabilities{Tools: true}},
+		{"my-deployment", Capabilities{Tools: true, JSONSchema: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Equal(t, tt.want, ModelCapabilities(tt.model))
+		})
+	}
+}
+
+func TestClientCapabilities(t *testing.T) {
+	openai := &OpenAIClient{config: AIConfig{Model: "o3"}}
+	caps := openai.Capabilities()
+	assert.True(t, caps.Reasoning)
+	assert.True(t, caps.StreamingUsage)
+
+	// Another model keeps the provider capabilities
+	caps = caps.ForModel("gpt-3.5-turbo")
+	assert.False(t, caps.Reasoning)
+	assert.False(t, caps.JSONSchema)
+	assert.True(t, caps.StreamingUsage)
+	assert.Equal(t, caps, caps.ForModel(""))
+
+	azure := &AzureClient{deploymentName: "gpt-4o-prod", azureConfig: AzureConfig{APIVersion: "2024-06-01"}}
+	caps = azure.Capabilities()
+	assert.True(t, caps.Vision)
+	assert.False(t, caps.StreamingUsage)
+
+	assert.Equal(t, Capabilities{}, NewDummyClient("").Capabilities())
+}
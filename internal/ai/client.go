@@ -53,6 +53,17 @@ type Client interface {
 	// Ping checks if the AI service is accessible and responding.
 	// It returns an error if the service is unavailable or authentication fails.
 	Ping(ctx context.Context) error
+
+	// Capabilities reports the features available with the configured model,
+	// so callers can adapt to the provider instead of relying on settings.
+	Capabilities() Capabilities
+}
+
+// Embedder is implemented by clients that can turn text into embedding
+// vectors, e.g. for semantic search
+type Embedder interface {
+	// Embed returns one vector per input, in order
+	Embed(ctx context.Context, model string, inputs []string) ([][]float32, error)
 }
 
 // Note: Type definitions for ChatRequest, Message, ChatResponse, Choice, Usage,
@@ -151,19 +162,43 @@ func NewClient(cfg config.AIConfig, opts ...ClientOptions) (Client, error) {
 	}
 
 	// Create client based on provider
+	var client Client
 	switch cfg.Provider {
 	case "openai":
-		return NewOpenAIClient(aiConfig)
+		openaiClient, err := NewOpenAIClient(aiConfig)
+		if err != nil {
+			return nil, err
+		}
+		client = openaiClient
+		if len(cfg.FallbackModels) > 0 {
+			client = NewFallbackClient(openaiClient, cfg.FallbackModels)
+		}
 	case "azure":
 		azureConfig := AzureConfig{
 			Endpoint:       cfg.Azure.Endpoint,
 			DeploymentName: cfg.Azure.DeploymentName,
 			APIVersion:     cfg.Azure.APIVersion,
 		}
-		return NewAzureClient(aiConfig, azureConfig)
+		azureClient, err := NewAzureClient(aiConfig, azureConfig)
+		if err != nil {
+			return nil, err
+		}
+		client = azureClient
 	default:
 		return nil, fmt.Errorf("unsupported ai provider: %s", cfg.Provider)
 	}
+
+	if cfg.MaxRequestCost > 0 || cfg.MaxSessionCost > 0 {
+		client = NewCostLimitClient(client, cfg.MaxRequestCost, cfg.MaxSessionCost)
+	}
+	if len(cfg.Redact) > 0 {
+		redacting, err := NewRedactingClient(client, cfg.Redact)
+		if err != nil {
+			return nil, err
+		}
+		client = redacting
+	}
+	return client, nil
 }
 
 // WithTimeout returns a context with the specified timeout.
@@ -0,0 +1,344 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The contract suite holds every Client implementation to the same
+// behavior, so that the chat loop works alike whichever provider is
+// configured. Each provider brings a fake of its API that plays the suite's
+// scenarios in its own wire format; a new provider joins the suite with an
+// entry in contractProviders.
+
+// contractRetries is the retries the clients under test are configured with
+const contractRetries = 2
+
+// contractScenario is what the fake API of a provider answers
+type contractScenario struct {
+	chunks []string // The answer, one stream chunk per piece
+	usage  Usage
+
+	status int    // An error status to answer with instead
+	code   string // The provider's error code sent with the status
+
+	// hang waits for the request to be canceled instead of answering or,
+	// when streaming, after sending the chunks
+	hang bool
+
+	requests   atomic.Int32
+	askedUsage atomic.Bool // A stream request asked for a final usage chunk
+}
+
+// contractProvider is a Client implementation under contract
+type contractProvider struct {
+	name string
+	// fake serves the provider's API, answering with the scenario
+	fake func(s *contractScenario) http.HandlerFunc
+	// newClient creates a client of the fake API at url
+	newClient func(url string) (Client, error)
+}
+
+var contractProviders = []contractProvider{
+	{
+		name: "openai",
+		fake: openAICompatibleFake,
+		newClient: func(url string) (Client, error) {
+			return NewOpenAIClient(AIConfig{
+				APIKey:     "test-api-key",
+				BaseURL:    url + "/v1",
+				Model:      "gpt-4o",
+				MaxRetries: contractRetries,
+				RetryDelay: time.Millisecond,
+			})
+		},
+	},
+	{
+		name: "azure",
+		fake: openAICompatibleFake,
+		newClient: func(url string) (Client, error) {
+			return NewAzureClient(
+				AIConfig{APIKey: "test-api-key", Model: "gpt-4o", MaxRetries: contractRetries, RetryDelay: time.Millisecond},
+				AzureConfig{Endpoint: url, DeploymentName: "gpt-4o-deployment", APIVersion: "2024-10-21"},
+			)
+		},
+	},
+	{
+		// API versions before 2024-09-01 do not report usage while streaming
+		name: "azure 2024-02-01",
+		fake: openAICompatibleFake,
+		newClient: func(url string) (Client, error) {
+			return NewAzureClient(
+				AIConfig{APIKey: "test-api-key", Model: "gpt-4o", MaxRetries: contractRetries, RetryDelay: time.Millisecond},
+				AzureConfig{Endpoint: url, DeploymentName: "gpt-4o-deployment", APIVersion: "2024-02-01"},
+			)
+		},
+	},
+}
+
+// openAICompatibleFake serves the chat completions and models endpoints of
+// the OpenAI API, which Azure OpenAI shares under other paths
+func openAICompatibleFake(s *contractScenario) http.HandlerFunc {
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	usage := map[string]int{
+		"prompt_tokens":     s.usage.PromptTokens,
+		"completion_tokens": s.usage.CompletionTokens,
+		"total_tokens":      s.usage.TotalTokens,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.status != 0 {
+			writeJSON(w, s.status, map[string]interface{}{
+				"error": map[string]interface{}{"message": http.StatusText(s.status), "type": "error", "code": s.code},
+			})
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/models") {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"object": "list",
+				"data":   []map[string]string{{"id": "gpt-4o", "object": "model", "owned_by": "system"}},
+			})
+			return
+		}
+
+		var req struct {
+			Stream        bool `json:"stream"`
+			StreamOptions *struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if !req.Stream {
+			if s.hang {
+				<-r.Context().Done()
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"id":     "chatcmpl-1",
+				"object": "chat.completion",
+				"model":  "gpt-4o",
+				"choices": []map[string]interface{}{{
+					"index":         0,
+					"message":       map[string]string{"role": RoleAssistant, "content": strings.Join(s.chunks, "")},
+					"finish_reason": "stop",
+				}},
+				"usage": usage,
+			})
+			return
+		}
+
+		s.askedUsage.Store(req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		send := func(chunk map[string]interface{}) {
+			chunk["id"], chunk["object"], chunk["model"] = "chatcmpl-1", "chat.completion.chunk", "gpt-4o"
+			data, _ := json.Marshal(chunk)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+		for _, piece := range s.chunks {
+			send(map[string]interface{}{"choices": []map[string]interface{}{{
+				"index": 0,
+				"delta": map[string]string{"role": RoleAssistant, "content": piece},
+			}}})
+		}
+		if s.hang {
+			<-r.Context().Done()
+			return
+		}
+		send(map[string]interface{}{"choices": []map[string]interface{}{{
+			"index": 0, "delta": map[string]string{}, "finish_reason": "stop",
+		}}})
+		if s.askedUsage.Load() {
+			send(map[string]interface{}{"choices": []interface{}{}, "usage": usage})
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+// contractRequest is the request the suite sends
+var contractRequest = ChatRequest{Messages: []Message{{Role: RoleUser, Content: "Say hello"}}}
+
+// newContractClient starts the provider's fake API for s and returns a client of it
+func newContractClient(t *testing.T, p contractProvider, s *contractScenario) Client {
+	t.Helper()
+	server := setupMockServer(t, p.fake(s))
+	client, err := p.newClient(server.URL)
+	require.NoError(t, err)
+	return client
+}
+
+// readStream reads a stream to its end and returns the text, the finish
+// reasons and the usage it reported
+func readStream(t *testing.T, stream StreamReader) (string, []string, *Usage) {
+	t.Helper()
+	var text strings.Builder
+	var finishReasons []string
+	var usage *Usage
+	for {
+		chunk, err := stream.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		for _, choice := range chunk.Choices {
+			text.WriteString(choice.Delta.Content)
+			if choice.FinishReason != nil {
+				finishReasons = append(finishReasons, *choice.FinishReason)
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	return text.String(), finishReasons, usage
+}
+
+func TestClientContract(t *testing.T) {
+	answer := []string{"Hel", "lo", ", world"}
+	usage := Usage{PromptTokens: 12, CompletionTokens: 4, TotalTokens: 16}
+
+	for _, p := range contractProviders {
+		t.Run(p.name, func(t *testing.T) {
+			t.Run("completion", func(t *testing.T) {
+				s := &contractScenario{chunks: answer, usage: usage}
+				resp, err := newContractClient(t, p, s).ChatCompletion(context.Background(), contractRequest)
+				require.NoError(t, err)
+
+				require.Len(t, resp.Choices, 1)
+				assert.Equal(t, RoleAssistant, resp.Choices[0].Message.Role)
+				assert.Equal(t, "Hello, world", resp.Choices[0].Message.Content)
+				assert.Equal(t, "stop", resp.Choices[0].FinishReason)
+				assert.Equal(t, usage, resp.Usage, "usage is reported")
+				assert.Equal(t, int32(1), s.requests.Load())
+			})
+
+			t.Run("streaming", func(t *testing.T) {
+				s := &contractScenario{chunks: answer, usage: usage}
+				client := newContractClient(t, p, s)
+				stream, err := client.ChatCompletionStream(context.Background(), contractRequest)
+				require.NoError(t, err)
+
+				text, finishReasons, streamUsage := readStream(t, stream)
+				assert.Equal(t, "Hello, world", text, "the deltas add up to the answer")
+				assert.Equal(t, []string{"stop"}, finishReasons, "the finish reason is reported once")
+
+				// Capabilities tell callers whether to count the tokens themselves
+				if client.Capabilities().StreamingUsage {
+					assert.True(t, s.askedUsage.Load())
+					assert.Equal(t, &usage, streamUsage, "usage is reported in a final chunk")
+				} else {
+					assert.False(t, s.askedUsage.Load(), "usage is not asked for when it is not supported")
+					assert.Nil(t, streamUsage)
+				}
+
+				_, err = stream.Read()
+				assert.Equal(t, io.EOF, err, "a finished stream stays finished")
+				assert.NoError(t, stream.Close())
+				assert.NoError(t, stream.Close(), "closing twice is harmless")
+			})
+
+			t.Run("error mapping", func(t *testing.T) {
+				tests := []struct {
+					name      string
+					status    int
+					code      string
+					want      ErrorType
+					retryable bool
+				}{
+					{name: "unauthorized", status: http.StatusUnauthorized, want: ErrTypeAuthentication},
+					{name: "rate limited", status: http.StatusTooManyRequests, want: ErrTypeRateLimit, retryable: true},
+					{name: "context too long", status: http.StatusBadRequest, code: "context_length_exceeded", want: ErrTypeContextLength},
+					{name: "content filtered", status: http.StatusBadRequest, code: "content_filter", want: ErrTypeContentFilter},
+					{name: "bad request", status: http.StatusBadRequest, want: ErrTypeInvalidRequest},
+					{name: "out of quota", status: http.StatusPaymentRequired, want: ErrTypeQuotaExceeded},
+					{name: "unknown model", status: http.StatusNotFound, want: ErrTypeModelNotFound},
+					{name: "server error", status: http.StatusInternalServerError, want: ErrTypeServerError, retryable: true},
+					{name: "overloaded", status: http.StatusServiceUnavailable, want: ErrTypeServerError, retryable: true},
+				}
+				for _, tt := range tests {
+					t.Run(tt.name, func(t *testing.T) {
+						s := &contractScenario{status: tt.status, code: tt.code}
+						client := newContractClient(t, p, s)
+
+						_, err := client.ChatCompletion(context.Background(), contractRequest)
+						require.Error(t, err)
+						assert.Equal(t, tt.want, GetErrorType(err))
+						assert.Equal(t, tt.retryable, IsRetryableError(err))
+						wantRequests := int32(1)
+						if tt.retryable {
+							wantRequests += contractRetries
+						}
+						assert.Equal(t, wantRequests, s.requests.Load(), "only transient errors are retried")
+
+						// Streams are not retried: the caller may have shown part of the answer
+						s.requests.Store(0)
+						_, err = client.ChatCompletionStream(context.Background(), contractRequest)
+						require.Error(t, err)
+						assert.Equal(t, tt.want, GetErrorType(err))
+						assert.Equal(t, int32(1), s.requests.Load())
+					})
+				}
+			})
+
+			t.Run("cancellation", func(t *testing.T) {
+				s := &contractScenario{chunks: []string{"Hel"}, hang: true}
+				client := newContractClient(t, p, s)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				start := time.Now()
+				_, err := client.ChatCompletion(ctx, contractRequest)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, context.Canceled)
+				assert.Less(t, time.Since(start), 2*time.Second, "a canceled request returns right away")
+				assert.Equal(t, int32(1), s.requests.Load(), "a canceled request is not retried")
+
+				ctx, cancel = context.WithCancel(context.Background())
+				defer cancel()
+				stream, err := client.ChatCompletionStream(ctx, contractRequest)
+				require.NoError(t, err)
+				defer stream.Close()
+				chunk, err := stream.Read()
+				require.NoError(t, err)
+				require.NotEmpty(t, chunk.Choices)
+				assert.Equal(t, "Hel", chunk.Choices[0].Delta.Content)
+
+				time.AfterFunc(50*time.Millisecond, cancel)
+				start = time.Now()
+				_, err = stream.Read()
+				require.Error(t, err)
+				assert.NotEqual(t, io.EOF, err, "a canceled stream is not a finished one")
+				assert.ErrorIs(t, err, context.Canceled)
+				assert.Less(t, time.Since(start), 2*time.Second, "a canceled stream returns right away")
+			})
+
+			t.Run("models", func(t *testing.T) {
+				s := &contractScenario{chunks: answer, usage: usage}
+				client := newContractClient(t, p, s)
+
+				models, err := client.ListModels(context.Background())
+				require.NoError(t, err)
+				assert.NotEmpty(t, models)
+				assert.NoError(t, client.Ping(context.Background()))
+			})
+		})
+	}
+}
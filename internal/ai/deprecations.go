@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+// ModelDeprecation describes a model that is deprecated or scheduled for retirement.
+type ModelDeprecation struct {
+	// Model name or prefix (a trailing "*" matches any suffix)
+	Model string
+
+	// Suggested replacement model
+	Replacement string
+
+	// Date after which the provider stops serving the model (zero if unknown)
+	RetiresAt time.Time
+}
+
+// matches reports whether the deprecation entry covers the given model name.
+func (d ModelDeprecation) matches(model string) bool {
+	if prefix, ok := strings.CutSuffix(d.Model, "*"); ok {
+		return strings.HasPrefix(model, prefix)
+	}
+	return model == d.Model
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// knownDeprecations lists models announced as deprecated by OpenAI and Azure OpenAI.
+// More specific entries must come before broader prefixes.
+var knownDeprecations = []ModelDeprecation{
+	{Model: "gpt-4.5-preview*", Replacement: "gpt-4.1", RetiresAt: date(2025, time.July, 14)},
+	{Model: "o1-preview*", Replacement: "o3", RetiresAt: date(2025, time.July, 28)},
+	{Model: "o1-mini*", Replacement: "o4-mini", RetiresAt: date(2025, time.October, 27)},
+	{Model: "gpt-4-vision-preview", Replacement: "gpt-4o", RetiresAt: date(2024, time.December, 6)},
+	{Model: "gpt-4-32k*", Replacement: "gpt-4o", RetiresAt: date(2025, time.June, 6)},
+	{Model: "gpt-4-0314", Replacement: "gpt-4o", RetiresAt: date(2024, time.June, 13)},
+	{Model: "gpt-4-0613", Replacement: "gpt-4o", RetiresAt: date(2025, time.June, 6)},
+	{Model: "gpt-4-1106-preview", Replacement: "gpt-4o", RetiresAt: date(2025, time.June, 6)},
+	{Model: "gpt-4-0125-preview", Replacement: "gpt-4o", RetiresAt: date(2025, time.June, 6)},
+	{Model: "gpt-35-turbo*", Replacement: "gpt-4o-mini", RetiresAt: date(2025, time.February, 13)},
+	{Model: "gpt-3.5-turbo-0301", Replacement: "gpt-4o-mini", RetiresAt: date(2024, time.June, 13)},
+	{Model: "gpt-3.5-turbo-0613", Replacement: "gpt-4o-mini", RetiresAt: date(2024, time.September, 13)},
+	{Model: "gpt-3.5-turbo-16k-0613", Replacement: "gpt-4o-mini", RetiresAt: date(2024, time.September, 13)},
+	{Model: "text-davinci-*", Replacement: "gpt-4o-mini", RetiresAt: date(2024, time.January, 4)},
+}
+
+// retiredAzureAPIVersions lists Azure OpenAI API versions that are no longer served.
+var retiredAzureAPIVersions = map[string]string{
+	"2023-03-15-preview": "2024-10-21",
+	"2023-06-01-preview": "2024-10-21",
+	"2023-07-01-preview": "2024-10-21",
+	"2023-08-01-preview": "2024-10-21",
+	"2023-09-01-preview": "2024-10-21",
+	"2023-12-01-preview": "2024-10-21",
+}
+
+// retirementWarningWindow is how long before retirement a model is reported
+var retirementWarningWindow = 90 * 24 * time.Hour
+
+// ModelWarning is a problem found with the configured model.
+type ModelWarning struct {
+	Model       string
+	Message     string
+	Replacement string
+
+	// Retired is true when the model is already unavailable (or not listed by the provider)
+	Retired bool
+}
+
+// String formats the warning for display.
+func (w ModelWarning) String() string {
+	if w.Replacement != "" {
+		return fmt.Sprintf("%s (suggested replacement: %s)", w.Message, w.Replacement)
+	}
+	return w.Message
+}
+
+// LookupDeprecation returns the deprecation entry for a model, if any.
+// Retirement dates from refreshed model metadata take precedence.
+func LookupDeprecation(model string) (ModelDeprecation, bool) {
+	if info, ok := LookupModel(model); ok && info.RetiresAt != "" {
+		return ModelDeprecation{Model: info.Model, Replacement: info.Replacement, RetiresAt: info.Retirement()}, true
+	}
+
+	model = strings.ToLower(model)
+	for _, d := range knownDeprecations {
+		if d.matches(model) {
+			return d, true
+		}
+	}
+	return ModelDeprecation{}, false
+}
+
+// CheckModel validates the configured model against the deprecation table and,
+// for OpenAI, the provider's model list. A failure to list models is not
+// reported as a warning since it usually means the key is scoped or offline.
+func CheckModel(ctx context.Context, client Client, cfg config.AIConfig, now time.Time) []ModelWarning {
+	var warnings []ModelWarning
+
+	model := cfg.Model
+	if cfg.Provider == "azure" && model == "" {
+		model = cfg.Azure.DeploymentName
+	}
+
+	if d, ok := LookupDeprecation(model); ok {
+		warning := ModelWarning{Model: model, Replacement: d.Replacement}
+		switch {
+		case d.RetiresAt.IsZero():
+			warning.Message = fmt.Sprintf("model %s is deprecated", model)
+		case !now.Before(d.RetiresAt):
+			warning.Message = fmt.Sprintf("model %s was retired on %s", model, d.RetiresAt.Format("2006-01-02"))
+			warning.Retired = true
+		case d.RetiresAt.Sub(now) <= retirementWarningWindow:
+			warning.Message = fmt.Sprintf("model %s retires on %s", model, d.RetiresAt.Format("2006-01-02"))
+		default:
+			warning.Message = fmt.Sprintf("model %s is deprecated and retires on %s", model, d.RetiresAt.Format("2006-01-02"))
+		}
+		warnings = append(warnings, warning)
+	}
+
+	// Azure deployment names do not always tell the model, so its reasoning
+	// support is not checked
+	if cfg.ReasoningEffort != nil && cfg.Provider != "azure" {
+		if err := ValidateReasoningEffort(model, *cfg.ReasoningEffort); err != nil {
+			warnings = append(warnings, ModelWarning{Model: model, Message: err.Error()})
+		}
+	}
+
+	if cfg.Provider == "azure" {
+		if replacement, ok := retiredAzureAPIVersions[cfg.Azure.APIVersion]; ok {
+			warnings = append(warnings, ModelWarning{
+				Model:       model,
+				Message:     fmt.Sprintf("Azure ai.azure.api_version %s has been retired", cfg.Azure.APIVersion),
+				Replacement: replacement,
+				Retired:     true,
+			})
+		}
+		// Azure deployments are named by the user, so the model list cannot be checked
+		return warnings
+	}
+
+	if client == nil || model == "" || (len(warnings) > 0 && warnings[0].Retired) {
+		return warnings
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil || len(models) == 0 {
+		return warnings
+	}
+
+	for _, m := range models {
+		if strings.EqualFold(m.ID, model) {
+			return warnings
+		}
+	}
+
+	warnings = append(warnings, ModelWarning{
+		Model:   model,
+		Message: fmt.Sprintf("model %s is not available for this API key", model),
+		Retired: true,
+	})
+	return warnings
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestCheckModel(t *testing.T) {
+	now := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		cfg         config.AIConfig
+		client      Client
+		wantCount   int
+		wantRetired bool
+		wantReplace string
+	}{
+		{
+			name:      "current model listed by provider",
+			cfg:       config.AIConfig{Provider: "openai", Model: "o3"},
+			client:    NewDummyClient("o3"),
+			wantCount: 0,
+		},
+		{
+			name:        "model missing from provider list",
+			cfg:         config.AIConfig{Provider: "openai", Model: "o3"},
+			client:      NewDummyClient("gpt-4o"),
+			wantCount:   1,
+			wantRetired: true,
+		},
+		{
+			name:        "retired model",
+			cfg:         config.AIConfig{Provider: "openai", Model: "gpt-4-32k-0613"},
+			client:      NewDummyClient("gpt-4o"),
+			wantCount:   1,
+			wantRetired: true,
+			wantReplace: "gpt-4o",
+		},
+		{
+			name:        "model retiring soon",
+			cfg:         config.AIConfig{Provider: "openai", Model: "o1-mini"},
+			client:      NewDummyClient("o1-mini"),
+			wantCount:   1,
+			wantReplace: "o4-mini",
+		},
+		{
+			name:      "reasoning effort unsupported by model",
+			cfg:       config.AIConfig{Provider: "openai", Model: "gpt-4o", ReasoningEffort: strPtr("high")},
+			client:    NewDummyClient("gpt-4o"),
+			wantCount: 1,
+		},
+		{
+			name:      "reasoning effort supported by model",
+			cfg:       config.AIConfig{Provider: "openai", Model: "o3", ReasoningEffort: strPtr("high")},
+			client:    NewDummyClient("o3"),
+			wantCount: 0,
+		},
+		{
+			name: "azure supported api version",
+			cfg: config.AIConfig{
+				Provider: "azure",
+				Model:    "gpt-4o",
+				Azure:    config.AzureConfig{DeploymentName: "prod", APIVersion: "2023-05-15"},
+			},
+			wantCount: 0,
+		},
+		{
+			name: "azure preview api version retired",
+			cfg: config.AIConfig{
+				Provider: "azure",
+				Model:    "gpt-4o",
+				Azure:    config.AzureConfig{DeploymentName: "prod", APIVersion: "2023-12-01-preview"},
+			},
+			wantCount:   1,
+			wantRetired: true,
+			wantReplace: "2024-10-21",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := CheckModel(context.Background(), tt.client, tt.cfg, now)
+			require.Len(t, warnings, tt.wantCount)
+			if tt.wantCount == 0 {
+				return
+			}
+			assert.Equal(t, tt.wantRetired, warnings[0].Retired)
+			assert.Equal(t, tt.wantReplace, warnings[0].Replacement)
+		})
+	}
+}
+
+func TestLookupDeprecation(t *testing.T) {
+	d, ok := LookupDeprecation("GPT-4.5-preview-2025-02-27")
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4.1", d.Replacement)
+
+	_, ok = LookupDeprecation("gpt-4o")
+	assert.False(t, ok)
+}
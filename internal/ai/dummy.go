@@ -149,3 +149,8 @@ func (d *DummyClient) ListModels(ctx context.Context) ([]Model, error) {
 func (d *DummyClient) Ping(ctx context.Context) error {
 	return nil
 }
+
+// Capabilities implements Client interface; dummy responses use none
+func (d *DummyClient) Capabilities() Capabilities {
+	return Capabilities{}
+}
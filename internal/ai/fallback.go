@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FallbackClient sends requests to a prioritized list of models. When the
+// requested model is unavailable, blocks the content or stays rate limited,
+// the next model is tried and the response names the model that answered.
+type FallbackClient struct {
+	Client
+	fallbacks []string
+}
+
+// NewFallbackClient wraps client to fall back to the given models, in order
+func NewFallbackClient(client Client, fallbacks []string) *FallbackClient {
+	return &FallbackClient{Client: client, fallbacks: fallbacks}
+}
+
+// ChatCompletion implements the Client interface.
+func (f *FallbackClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var errs []error
+	for i, model := range f.candidates(req.Model) {
+		attempt := req
+		attempt.Model = model
+		resp, err := f.Client.ChatCompletion(ctx, attempt)
+		if err == nil {
+			if i > 0 {
+				resp.FallbackModel = model
+			}
+			return resp, nil
+		}
+		if !ShouldFallback(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", model, err))
+	}
+	return nil, fallbackError(errs)
+}
+
+// ChatCompletionStream implements the Client interface. Only errors returned
+// when the stream is opened lead to a fallback.
+func (f *FallbackClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	var errs []error
+	for i, model := range f.candidates(req.Model) {
+		attempt := req
+		attempt.Model = model
+		stream, err := f.Client.ChatCompletionStream(ctx, attempt)
+		if err == nil {
+			if i > 0 {
+				return &fallbackStreamReader{StreamReader: stream, model: model}, nil
+			}
+			return stream, nil
+		}
+		if !ShouldFallback(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", model, err))
+	}
+	return nil, fallbackError(errs)
+}
+
+// Embed implements Embedder when the wrapped client does
+func (f *FallbackClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	embedder, ok := f.Client.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("embeddings are not supported by this provider")
+	}
+	return embedder.Embed(ctx, model, inputs)
+}
+
+// candidates returns the requested model followed by the fallbacks not
+// already listed
+func (f *FallbackClient) candidates(requested string) []string {
+	models := []string{requested}
+	for _, model := range f.fallbacks {
+		if model != "" && model != requested {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// ShouldFallback reports whether another model may succeed where the model
+// of a failed request did not
+func ShouldFallback(err error) bool {
+	var aiErr *Error
+	if !errors.As(err, &aiErr) {
+		return false
+	}
+	switch aiErr.Type {
+	case ErrTypeModelNotFound, ErrTypeContentFilter, ErrTypeRateLimit:
+		return true
+	}
+	return false
+}
+
+// fallbackError reports that every model failed, wrapping each model's
+// error so errors.As still finds their types
+func fallbackError(errs []error) error {
+	if len(errs) == 1 {
+		return errors.Unwrap(errs[0])
+	}
+	return fmt.Errorf("all models failed: %w", errors.Join(errs...))
+}
+
+// fallbackStreamReader marks the chunks of a fallback model's stream
+type fallbackStreamReader struct {
+	StreamReader
+	model string
+}
+
+// Read implements StreamReader.
+func (r *fallbackStreamReader) Read() (*StreamChunk, error) {
+	chunk, err := r.StreamReader.Read()
+	if chunk != nil {
+		chunk.FallbackModel = r.model
+	}
+	return chunk, err
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingClient fails requests for the models in failures
+type failingClient struct {
+	*DummyClient
+	failures map[string]error
+	tried    []string
+}
+
+func (c *failingClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	c.tried = append(c.tried, req.Model)
+	if err := c.failures[req.Model]; err != nil {
+		return nil, err
+	}
+	return c.DummyClient.ChatCompletion(ctx, req)
+}
+
+func (c *failingClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	c.tried = append(c.tried, req.Model)
+	if err := c.failures[req.Model]; err != nil {
+		return nil, err
+	}
+	return c.DummyClient.ChatCompletionStream(ctx, req)
+}
+
+func TestFallbackClientChatCompletion(t *testing.T) {
+	notFound := NewError(ErrTypeModelNotFound, "model not found")
+	tests := []struct {
+		name      string
+		failures  map[string]error
+		wantTried []string
+		wantModel string
+		wantErr   ErrorType
+	}{
+		{
+			name:      "requested model answers",
+			wantTried: []string{"o3"},
+		},
+		{
+			name:      "falls back on missing model",
+			failures:  map[string]error{"o3": notFound},
+			wantTried: []string{"o3", "gpt-4.1"},
+			wantModel: "gpt-4.1",
+		},
+		{
+			name:      "falls back on rate limit and content filter",
+			failures:  map[string]error{"o3": NewError(ErrTypeRateLimit, "slow down"), "gpt-4.1": NewError(ErrTypeContentFilter, "filtered")},
+			wantTried: []string{"o3", "gpt-4.1", "gpt-4o"},
+			wantModel: "gpt-4o",
+		},
+		{
+			name:      "other errors are returned at once",
+			failures:  map[string]error{"o3": NewError(ErrTypeAuthentication, "bad key")},
+			wantTried: []string{"o3"},
+			wantErr:   ErrTypeAuthentication,
+		},
+		{
+			name:      "all models fail",
+			failures:  map[string]error{"o3": notFound, "gpt-4.1": notFound, "gpt-4o": NewError(ErrTypeRateLimit, "slow down")},
+			wantTried: []string{"o3", "gpt-4.1", "gpt-4o"},
+			wantErr:   ErrTypeModelNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &failingClient{DummyClient: NewDummyClient(""), failures: tt.failures}
+			client := NewFallbackClient(inner, []string{"gpt-4.1", "o3", "gpt-4o"})
+
+			resp, err := client.ChatCompletion(context.Background(), ChatRequest{Model: "o3"})
+			assert.Equal(t, tt.wantTried, inner.tried)
+			if tt.wantErr != "" {
+				var aiErr *Error
+				require.True(t, errors.As(err, &aiErr))
+				assert.Equal(t, tt.wantErr, aiErr.Type)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantModel, resp.FallbackModel)
+		})
+	}
+}
+
+func TestFallbackClientStream(t *testing.T) {
+	inner := &failingClient{DummyClient: NewDummyClient(""), failures: map[string]error{"o3": NewError(ErrTypeModelNotFound, "model not found")}}
+	client := NewFallbackClient(inner, []string{"gpt-4.1"})
+
+	stream, err := client.ChatCompletionStream(context.Background(), ChatRequest{Model: "o3"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	chunk, err := stream.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4.1", chunk.FallbackModel)
+}
@@ -0,0 +1,242 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// ErrCostLimit is returned for requests that would exceed a cost limit
+var ErrCostLimit = errors.New("cost limit reached")
+
+// RedactedText replaces redacted matches in requests
+const RedactedText = "[redacted]"
+
+// RedactingClient replaces the matches of patterns in message contents, tool
+// call arguments and embedding inputs before they are sent
+type RedactingClient struct {
+	Client
+	patterns []*regexp.Regexp
+}
+
+// NewRedactingClient wraps client to redact the matches of patterns
+func NewRedactingClient(client Client, patterns []string) (*RedactingClient, error) {
+	r := &RedactingClient{Client: client}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// ChatCompletion implements the Client interface.
+func (r *RedactingClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return r.Client.ChatCompletion(ctx, r.redactRequest(req))
+}
+
+// ChatCompletionStream implements the Client interface.
+func (r *RedactingClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	return r.Client.ChatCompletionStream(ctx, r.redactRequest(req))
+}
+
+// Embed implements Embedder when the wrapped client does
+func (r *RedactingClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	embedder, ok := r.Client.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("embeddings are not supported by this provider")
+	}
+	redacted := make([]string, len(inputs))
+	for i, input := range inputs {
+		redacted[i] = r.redact(input)
+	}
+	return embedder.Embed(ctx, model, redacted)
+}
+
+// redactRequest returns a copy of req with its messages redacted
+func (r *RedactingClient) redactRequest(req ChatRequest) ChatRequest {
+	messages := make([]Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.Content = r.redact(msg.Content)
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]ToolCall, len(msg.ToolCalls))
+			for j, call := range msg.ToolCalls {
+				call.Function.Arguments = r.redact(call.Function.Arguments)
+				calls[j] = call
+			}
+			msg.ToolCalls = calls
+		}
+		messages[i] = msg
+	}
+	req.Messages = messages
+	return req
+}
+
+func (r *RedactingClient) redact(text string) string {
+	for _, re := range r.patterns {
+		text = re.ReplaceAllString(text, RedactedText)
+	}
+	return text
+}
+
+// CostLimitClient refuses requests whose estimated cost exceeds the limit
+// per request, or that would take the cost of all requests past the limit
+// per session. Estimates count the prompt at about four characters a token
+// plus MaxTokens of completion when set; the session total adds the
+// reported usage of each answer. Models without a known price are refused.
+type CostLimitClient struct {
+	Client
+	maxRequest float64
+	maxSession float64
+
+	mu    sync.Mutex
+	spent float64
+}
+
+// NewCostLimitClient wraps client to enforce the limits in USD, 0 for none
+func NewCostLimitClient(client Client, maxRequest, maxSession float64) *CostLimitClient {
+	return &CostLimitClient{Client: client, maxRequest: maxRequest, maxSession: maxSession}
+}
+
+// Spent returns the cost of the answers received so far
+func (c *CostLimitClient) Spent() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.spent
+}
+
+// ChatCompletion implements the Client interface.
+func (c *CostLimitClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	info, _, err := c.check(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.ChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.add(info, resp.Usage)
+	return resp, nil
+}
+
+// ChatCompletionStream implements the Client interface.
+func (c *CostLimitClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	info, prompt, err := c.check(req)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.Client.ChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &costStreamReader{StreamReader: stream, client: c, info: info, prompt: prompt}, nil
+}
+
+// Embed implements Embedder when the wrapped client does. Embeddings are
+// not counted.
+func (c *CostLimitClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	embedder, ok := c.Client.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("embeddings are not supported by this provider")
+	}
+	return embedder.Embed(ctx, model, inputs)
+}
+
+// check returns the price of the request's model and its estimated prompt
+// tokens, or an error when the request may not be sent
+func (c *CostLimitClient) check(req ChatRequest) (ModelInfo, int, error) {
+	info, ok := LookupModel(req.Model)
+	if !ok || (info.InputPrice == 0 && info.OutputPrice == 0) {
+		return info, 0, fmt.Errorf("%w: the price of model %s is unknown", ErrCostLimit, req.Model)
+	}
+
+	chars := 0
+	for _, msg := range req.Messages {
+		chars += len(msg.Content)
+		for _, call := range msg.ToolCalls {
+			chars += len(call.Function.Arguments)
+		}
+	}
+	completion := 0
+	if req.MaxTokens != nil {
+		completion = *req.MaxTokens
+	}
+	prompt := chars / 4
+	estimate := cost(info, prompt, completion)
+
+	if c.maxRequest > 0 && estimate > c.maxRequest {
+		return info, 0, fmt.Errorf("%w: the request would cost about $%.4f, more than the $%.2f allowed per request", ErrCostLimit, estimate, c.maxRequest)
+	}
+	if c.maxSession > 0 {
+		spent := c.Spent()
+		if spent+estimate > c.maxSession {
+			return info, 0, fmt.Errorf("%w: $%.4f of the $%.2f allowed per session is spent", ErrCostLimit, spent, c.maxSession)
+		}
+	}
+	return info, prompt, nil
+}
+
+// add counts the cost of an answer
+func (c *CostLimitClient) add(info ModelInfo, usage Usage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.spent += cost(info, usage.PromptTokens, usage.CompletionTokens)
+}
+
+// cost returns the price in USD of the tokens
+func cost(info ModelInfo, prompt, completion int) float64 {
+	return (float64(prompt)*info.InputPrice + float64(completion)*info.OutputPrice) / 1e6
+}
+
+// costStreamReader counts the usage reported at the end of a stream
+type costStreamReader struct {
+	StreamReader
+	client *CostLimitClient
+	info   ModelInfo
+	prompt int
+	chars  int
+	usage  *Usage
+	done   bool
+}
+
+// Read implements StreamReader.
+func (r *costStreamReader) Read() (*StreamChunk, error) {
+	chunk, err := r.StreamReader.Read()
+	if chunk != nil {
+		if chunk.Usage != nil {
+			r.usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			r.chars += len(choice.Delta.Content)
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		r.finish()
+	}
+	return chunk, err
+}
+
+// Close implements StreamReader.
+func (r *costStreamReader) Close() error {
+	r.finish()
+	return r.StreamReader.Close()
+}
+
+// finish counts the stream once, estimating its tokens when the provider
+// reported no usage
+func (r *costStreamReader) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+	usage := Usage{PromptTokens: r.prompt, CompletionTokens: r.chars / 4}
+	if r.usage != nil {
+		usage = *r.usage
+	}
+	r.client.add(r.info, usage)
+}
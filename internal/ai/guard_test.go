@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingClient records requests and answers with fixed usage
+type recordingClient struct {
+	*DummyClient
+	requests []ChatRequest
+	inputs   []string
+	usage    Usage
+}
+
+func (c *recordingClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	c.requests = append(c.requests, req)
+	return &ChatResponse{Usage: c.usage}, nil
+}
+
+func (c *recordingClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	c.requests = append(c.requests, req)
+	return c.DummyClient.ChatCompletionStream(ctx, req)
+}
+
+func (c *recordingClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	c.inputs = append(c.inputs, inputs...)
+	return make([][]float32, len(inputs)), nil
+}
+
+func TestRedactingClient(t *testing.T) {
+	inner := &recordingClient{DummyClient: NewDummyClient("")}
+	client, err := NewRedactingClient(inner, []string{`[a-z]+\.corp\.example\.com`, `sk-[A-Za-z0-9]+`})
+	require.NoError(t, err)
+
+	messages := []Message{
+		{Role: RoleUser, Content: "Why does db.corp.example.com reject sk-abc123?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{Function: FunctionCall{Name: "http_request", Arguments: `{"url":"https://api.corp.example.com"}`}}}},
+	}
+	_, err = client.ChatCompletion(context.Background(), ChatRequest{Model: "o3", Messages: messages})
+	require.NoError(t, err)
+
+	sent := inner.requests[0].Messages
+	assert.Equal(t, "Why does [redacted] reject [redacted]?", sent[0].Content)
+	assert.Equal(t, `{"url":"https://[redacted]"}`, sent[1].ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "Why does db.corp.example.com reject sk-abc123?", messages[0].Content, "the caller's messages are unchanged")
+	assert.Contains(t, messages[1].ToolCalls[0].Function.Arguments, "api.corp.example.com")
+
+	_, err = client.Embed(context.Background(), "m", []string{"host x.corp.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host [redacted]"}, inner.inputs)
+
+	_, err = NewRedactingClient(inner, []string{"("})
+	assert.Error(t, err)
+}
+
+func TestCostLimitClient(t *testing.T) {
+	// gpt-4o costs $2.50 per million input and $10 per million output tokens
+	inner := &recordingClient{DummyClient: NewDummyClient(""), usage: Usage{PromptTokens: 100000, CompletionTokens: 10000}}
+	client := NewCostLimitClient(inner, 0.5, 0.6)
+	ctx := context.Background()
+	short := []Message{{Role: RoleUser, Content: "hi"}}
+
+	_, err := client.ChatCompletion(ctx, ChatRequest{Model: "gpt-4o", Messages: short})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.35, client.Spent(), 1e-9)
+
+	// About 250k prompt tokens cost $0.625, over the per request limit
+	long := []Message{{Role: RoleUser, Content: strings.Repeat("x", 1000000)}}
+	_, err = client.ChatCompletion(ctx, ChatRequest{Model: "gpt-4o", Messages: long})
+	assert.ErrorIs(t, err, ErrCostLimit)
+	assert.ErrorContains(t, err, "allowed per request")
+
+	// max_tokens counts at the output price
+	_, err = client.ChatCompletion(ctx, ChatRequest{Model: "gpt-4o", Messages: short, MaxTokens: IntPtr(30000)})
+	assert.ErrorContains(t, err, "allowed per session")
+
+	_, err = client.ChatCompletion(ctx, ChatRequest{Model: "gpt-4o", Messages: short})
+	require.NoError(t, err)
+	_, err = client.ChatCompletion(ctx, ChatRequest{Model: "gpt-4o", Messages: short})
+	assert.ErrorContains(t, err, "allowed per session")
+	assert.Len(t, inner.requests, 2)
+
+	_, err = NewCostLimitClient(inner, 1, 0).ChatCompletion(ctx, ChatRequest{Model: "unpriced-model", Messages: short})
+	assert.ErrorContains(t, err, "price of model unpriced-model is unknown")
+}
+
+func TestCostLimitClientStream(t *testing.T) {
+	inner := &recordingClient{DummyClient: NewDummyClient("")}
+	client := NewCostLimitClient(inner, 0, 1)
+
+	stream, err := client.ChatCompletionStream(context.Background(), ChatRequest{Model: "gpt-4o", Messages: []Message{{Role: RoleUser, Content: "hello"}}})
+	require.NoError(t, err)
+	for {
+		_, err := stream.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.NoError(t, stream.Close())
+
+	// Without reported usage the answer's text is estimated, and counted once
+	assert.Greater(t, client.Spent(), 0.0)
+	spent := client.Spent()
+	require.NoError(t, stream.Close())
+	assert.Equal(t, spent, client.Spent())
+}
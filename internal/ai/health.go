@@ -0,0 +1,206 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthStatus summarizes how the provider has been responding.
+type HealthStatus int
+
+const (
+	// HealthUnknown means no request has completed yet.
+	HealthUnknown HealthStatus = iota
+	// HealthGood means recent requests succeeded at a normal latency.
+	HealthGood
+	// HealthDegraded means requests are slow or some of them failed.
+	HealthDegraded
+	// HealthDown means the last request failed, as did most recent ones.
+	HealthDown
+)
+
+// String returns the name of the status.
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthGood:
+		return "good"
+	case HealthDegraded:
+		return "degraded"
+	case HealthDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// latencyWindow is the number of recent requests health is computed from.
+	latencyWindow = 20
+
+	// slowLatency is the median latency above which the provider is degraded.
+	slowLatency = 10 * time.Second
+)
+
+// Health is a snapshot of the provider's recent latency and failures.
+type Health struct {
+	Status   HealthStatus
+	P50      time.Duration // Median latency of the successful requests
+	Last     time.Duration // Latency of the most recent successful request
+	Requests int           // Requests in the window
+	Failures int           // Failed requests in the window
+	LastErr  error         // Error of the most recent request, if it failed
+}
+
+// latencySample is one completed request.
+type latencySample struct {
+	latency time.Duration
+	err     error
+}
+
+// LatencyTracker keeps the latency and outcome of the most recent requests.
+// It is safe for concurrent use.
+type LatencyTracker struct {
+	mu      sync.Mutex
+	samples []latencySample
+}
+
+// NewLatencyTracker creates an empty tracker.
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{}
+}
+
+// Record adds a completed request. Canceled requests say nothing about the
+// provider and are ignored.
+func (t *LatencyTracker) Record(latency time.Duration, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = append(t.samples, latencySample{latency: latency, err: err})
+	if len(t.samples) > latencyWindow {
+		t.samples = t.samples[len(t.samples)-latencyWindow:]
+	}
+}
+
+// Health summarizes the recorded requests.
+func (t *LatencyTracker) Health() Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := Health{Requests: len(t.samples)}
+	if h.Requests == 0 {
+		return h
+	}
+
+	var latencies []time.Duration
+	for _, s := range t.samples {
+		if s.err != nil {
+			h.Failures++
+			continue
+		}
+		latencies = append(latencies, s.latency)
+		h.Last = s.latency
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		h.P50 = latencies[(len(latencies)-1)/2]
+	}
+	h.LastErr = t.samples[len(t.samples)-1].err
+
+	switch {
+	case h.LastErr != nil && h.Failures*2 >= h.Requests:
+		h.Status = HealthDown
+	case h.Failures > 0 || h.P50 > slowLatency:
+		h.Status = HealthDegraded
+	default:
+		h.Status = HealthGood
+	}
+	return h
+}
+
+// MonitoredClient records the latency of the requests sent through the
+// wrapped client: the time to the response, or to the first chunk of a
+// stream, which is what the user waits for.
+type MonitoredClient struct {
+	Client
+	tracker *LatencyTracker
+}
+
+// NewMonitoredClient wraps client to record its requests in tracker.
+func NewMonitoredClient(client Client, tracker *LatencyTracker) *MonitoredClient {
+	return &MonitoredClient{Client: client, tracker: tracker}
+}
+
+// Health summarizes the recent requests of the client.
+func (c *MonitoredClient) Health() Health {
+	return c.tracker.Health()
+}
+
+// ChatCompletion implements the Client interface.
+func (c *MonitoredClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+	resp, err := c.Client.ChatCompletion(ctx, req)
+	c.tracker.Record(time.Since(start), err)
+	return resp, err
+}
+
+// ChatCompletionStream implements the Client interface.
+func (c *MonitoredClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	start := time.Now()
+	stream, err := c.Client.ChatCompletionStream(ctx, req)
+	if err != nil {
+		c.tracker.Record(time.Since(start), err)
+		return nil, err
+	}
+	return &monitoredStreamReader{StreamReader: stream, start: start, tracker: c.tracker}, nil
+}
+
+// Ping implements the Client interface. Pings are recorded like requests.
+func (c *MonitoredClient) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := c.Client.Ping(ctx)
+	c.tracker.Record(time.Since(start), err)
+	return err
+}
+
+// Embed implements Embedder when the wrapped client does.
+func (c *MonitoredClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	embedder, ok := c.Client.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("embeddings are not supported by this provider")
+	}
+	return embedder.Embed(ctx, model, inputs)
+}
+
+// monitoredStreamReader records the time to the first chunk of a stream.
+type monitoredStreamReader struct {
+	StreamReader
+	start    time.Time
+	tracker  *LatencyTracker
+	recorded bool
+}
+
+// Read implements the StreamReader interface.
+func (r *monitoredStreamReader) Read() (*StreamChunk, error) {
+	chunk, err := r.StreamReader.Read()
+	if !r.recorded {
+		r.recorded = true
+		r.tracker.Record(time.Since(r.start), streamError(err))
+	}
+	return chunk, err
+}
+
+// streamError returns the error of a failed first read; an empty stream
+// still answered.
+func streamError(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyTrackerHealth(t *testing.T) {
+	failure := errors.New("503")
+	tests := []struct {
+		name     string
+		samples  []latencySample
+		status   HealthStatus
+		p50      time.Duration
+		failures int
+	}{
+		{
+			name:   "no requests",
+			status: HealthUnknown,
+		},
+		{
+			name:    "fast",
+			samples: []latencySample{{latency: time.Second}, {latency: 3 * time.Second}, {latency: 2 * time.Second}},
+			status:  HealthGood,
+			p50:     2 * time.Second,
+		},
+		{
+			name:    "slow",
+			samples: []latencySample{{latency: 12 * time.Second}, {latency: 15 * time.Second}},
+			status:  HealthDegraded,
+			p50:     12 * time.Second,
+		},
+		{
+			name:     "some failures",
+			samples:  []latencySample{{err: failure}, {latency: time.Second}, {latency: time.Second}},
+			status:   HealthDegraded,
+			p50:      time.Second,
+			failures: 1,
+		},
+		{
+			name:     "failing",
+			samples:  []latencySample{{latency: time.Second}, {err: failure}},
+			status:   HealthDown,
+			p50:      time.Second,
+			failures: 1,
+		},
+		{
+			name:    "canceled requests are ignored",
+			samples: []latencySample{{latency: time.Second}, {latency: time.Minute, err: context.Canceled}},
+			status:  HealthGood,
+			p50:     time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewLatencyTracker()
+			for _, s := range tt.samples {
+				tracker.Record(s.latency, s.err)
+			}
+			health := tracker.Health()
+			assert.Equal(t, tt.status, health.Status)
+			assert.Equal(t, tt.p50, health.P50)
+			assert.Equal(t, tt.failures, health.Failures)
+		})
+	}
+}
+
+func TestLatencyTrackerWindow(t *testing.T) {
+	tracker := NewLatencyTracker()
+	for i := 0; i < latencyWindow; i++ {
+		tracker.Record(0, errors.New("down"))
+	}
+	for i := 0; i < latencyWindow; i++ {
+		tracker.Record(time.Second, nil)
+	}
+
+	health := tracker.Health()
+	assert.Equal(t, latencyWindow, health.Requests)
+	assert.Zero(t, health.Failures, "old failures leave the window")
+	assert.Equal(t, HealthGood, health.Status)
+}
+
+func TestMonitoredClient(t *testing.T) {
+	inner := &failingClient{DummyClient: NewDummyClient(""), failures: map[string]error{"broken": NewError(ErrTypeServerError, "unavailable")}}
+	client := NewMonitoredClient(inner, NewLatencyTracker())
+	ctx := context.Background()
+
+	_, err := client.ChatCompletion(ctx, ChatRequest{Model: "o3"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.Health().Requests)
+
+	// Streams are recorded at their first chunk
+	stream, err := client.ChatCompletionStream(ctx, ChatRequest{Model: "o3"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.Health().Requests)
+	for {
+		if _, err := stream.Read(); err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			break
+		}
+	}
+	assert.Equal(t, 2, client.Health().Requests)
+
+	_, err = client.ChatCompletionStream(ctx, ChatRequest{Model: "broken"})
+	require.Error(t, err)
+	require.NoError(t, client.Ping(ctx))
+
+	health := client.Health()
+	assert.Equal(t, 4, health.Requests)
+	assert.Equal(t, 1, health.Failures)
+	assert.Equal(t, HealthDegraded, health.Status)
+}
@@ -0,0 +1,327 @@
+package ai
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed models.json
+var bundledModels []byte
+
+// maxModelDatasetSize bounds a downloaded model dataset
+const maxModelDatasetSize = 4 << 20
+
+// ModelInfo describes the limits, prices and lifecycle of a model.
+type ModelInfo struct {
+	// Model name or prefix (a trailing "*" matches any suffix)
+	Model string `json:"model"`
+
+	// Context window in tokens (0 if unknown)
+	ContextWindow int `json:"context_window,omitempty"`
+
+	// Prices in USD per million tokens (0 if unknown)
+	InputPrice  float64 `json:"input_price,omitempty"`
+	OutputPrice float64 `json:"output_price,omitempty"`
+
+	// Retirement date as YYYY-MM-DD and the suggested replacement
+	RetiresAt   string `json:"retires_at,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// matches reports whether the entry covers the given (lower case) model name.
+func (i ModelInfo) matches(model string) bool {
+	if prefix, ok := strings.CutSuffix(strings.ToLower(i.Model), "*"); ok {
+		return strings.HasPrefix(model, prefix)
+	}
+	return model == strings.ToLower(i.Model)
+}
+
+// Retirement returns the parsed retirement date, or the zero time.
+func (i ModelInfo) Retirement() time.Time {
+	t, err := time.Parse("2006-01-02", i.RetiresAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// modelDataset is the format of the bundled, cached and downloaded metadata.
+type modelDataset struct {
+	UpdatedAt time.Time   `json:"updated_at,omitempty"`
+	Models    []ModelInfo `json:"models"`
+
+	// Models listed by the provider at the last refresh
+	Available []string `json:"available,omitempty"`
+}
+
+// ModelRefresh summarizes a metadata refresh.
+type ModelRefresh struct {
+	// Entries read from the metadata source
+	Updated int
+
+	// Models listed by the provider
+	Listed int
+}
+
+// ModelRegistry holds the model metadata behind context limits, cost
+// estimates and retirement warnings. It starts with the bundled dataset;
+// refreshed entries take precedence and are kept in a cache file.
+type ModelRegistry struct {
+	mu        sync.RWMutex
+	bundled   []ModelInfo
+	refreshed map[string]ModelInfo
+	available []string
+	updatedAt time.Time
+}
+
+// NewModelRegistry creates a registry with the bundled dataset
+func NewModelRegistry() *ModelRegistry {
+	var dataset modelDataset
+	if err := json.Unmarshal(bundledModels, &dataset); err != nil {
+		panic(fmt.Sprintf("invalid bundled model metadata: %v", err))
+	}
+	return &ModelRegistry{bundled: dataset.Models, refreshed: make(map[string]ModelInfo)}
+}
+
+var defaultModelRegistry = NewModelRegistry()
+
+// DefaultModelRegistry returns the registry shared by the application.
+func DefaultModelRegistry() *ModelRegistry {
+	return defaultModelRegistry
+}
+
+// LookupModel returns the metadata of model from the default registry.
+func LookupModel(model string) (ModelInfo, bool) {
+	return defaultModelRegistry.Lookup(model)
+}
+
+// Lookup returns the metadata of model. An exact entry wins over prefixes,
+// and the longest matching prefix wins over shorter ones.
+func (r *ModelRegistry) Lookup(model string) (ModelInfo, bool) {
+	model = strings.ToLower(model)
+	if model == "" {
+		return ModelInfo{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best ModelInfo
+	found := false
+	consider := func(info ModelInfo) {
+		if !info.matches(model) {
+			return
+		}
+		if !found || specificity(info.Model) > specificity(best.Model) {
+			best, found = info, true
+		}
+	}
+	// Refreshed entries are considered first so they win ties
+	for _, key := range sortedKeys(r.refreshed) {
+		consider(r.refreshed[key])
+	}
+	for _, info := range r.bundled {
+		consider(info)
+	}
+	return best, found
+}
+
+// specificity ranks entries: exact names above prefixes, longer prefixes
+// above shorter ones
+func specificity(pattern string) int {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return len(prefix)
+	}
+	return 1 << 16
+}
+
+func sortedKeys(m map[string]ModelInfo) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Listed reports whether the provider listed model at the last refresh; ok
+// is false when the provider's list is unknown.
+func (r *ModelRegistry) Listed(model string) (listed, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.available) == 0 {
+		return false, false
+	}
+	for _, id := range r.available {
+		if strings.EqualFold(id, model) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// UpdatedAt returns when the metadata was last refreshed (zero if never).
+func (r *ModelRegistry) UpdatedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.updatedAt
+}
+
+// Stale reports whether the metadata is older than maxAge.
+func (r *ModelRegistry) Stale(maxAge time.Duration, now time.Time) bool {
+	return now.Sub(r.UpdatedAt()) >= maxAge
+}
+
+// LoadCache applies a cache file written by Refresh. A missing file is not
+// an error.
+func (r *ModelRegistry) LoadCache(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read model metadata cache: %w", err)
+	}
+	var dataset modelDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return fmt.Errorf("invalid model metadata cache %s: %w", path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply(dataset)
+	return nil
+}
+
+// Refresh updates the metadata from source (a URL or file with a dataset in
+// the bundled format, optional) and the models the provider lists, then
+// writes the refreshed metadata to cachePath when set. Sources that fail are
+// reported in the error while the others are still applied.
+func (r *ModelRegistry) Refresh(ctx context.Context, client Client, source, cachePath string) (ModelRefresh, error) {
+	var result ModelRefresh
+	var errs []error
+	dataset := modelDataset{UpdatedAt: time.Now()}
+
+	if source != "" {
+		fetched, err := readModelDataset(ctx, source)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			dataset.Models = fetched.Models
+			result.Updated = len(fetched.Models)
+		}
+	}
+
+	if client != nil {
+		models, err := client.ListModels(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list models: %w", err))
+		}
+		for _, m := range models {
+			dataset.Available = append(dataset.Available, m.ID)
+		}
+		result.Listed = len(dataset.Available)
+	}
+
+	if len(errs) > 0 && result.Updated == 0 && result.Listed == 0 {
+		return result, errors.Join(errs...)
+	}
+
+	r.mu.Lock()
+	r.apply(dataset)
+	cache := r.snapshot()
+	r.mu.Unlock()
+
+	if cachePath != "" {
+		if err := writeModelDataset(cachePath, cache); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// apply merges dataset into the registry; the caller holds the lock
+func (r *ModelRegistry) apply(dataset modelDataset) {
+	for _, info := range dataset.Models {
+		if info.Model != "" {
+			r.refreshed[strings.ToLower(info.Model)] = info
+		}
+	}
+	if len(dataset.Available) > 0 {
+		r.available = dataset.Available
+	}
+	if dataset.UpdatedAt.After(r.updatedAt) {
+		r.updatedAt = dataset.UpdatedAt
+	}
+}
+
+// snapshot returns the refreshed metadata for the cache; the caller holds
+// the lock
+func (r *ModelRegistry) snapshot() modelDataset {
+	dataset := modelDataset{UpdatedAt: r.updatedAt, Available: r.available}
+	for _, key := range sortedKeys(r.refreshed) {
+		dataset.Models = append(dataset.Models, r.refreshed[key])
+	}
+	return dataset
+}
+
+// readModelDataset reads a dataset from a URL or a file
+func readModelDataset(ctx context.Context, source string) (modelDataset, error) {
+	var data []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return modelDataset{}, fmt.Errorf("invalid model metadata URL: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return modelDataset{}, fmt.Errorf("failed to fetch model metadata: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return modelDataset{}, fmt.Errorf("failed to fetch model metadata: %s", resp.Status)
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxModelDatasetSize))
+		if err != nil {
+			return modelDataset{}, fmt.Errorf("failed to fetch model metadata: %w", err)
+		}
+	} else {
+		var err error
+		data, err = os.ReadFile(source)
+		if err != nil {
+			return modelDataset{}, fmt.Errorf("failed to read model metadata: %w", err)
+		}
+	}
+
+	var dataset modelDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return modelDataset{}, fmt.Errorf("invalid model metadata from %s: %w", source, err)
+	}
+	return dataset, nil
+}
+
+// writeModelDataset writes the cache file
+func writeModelDataset(path string, dataset modelDataset) error {
+	data, err := json.MarshalIndent(dataset, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode model metadata: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create model metadata cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write model metadata cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelRegistryLookup(t *testing.T) {
+	registry := NewModelRegistry()
+
+	tests := []struct {
+		model   string
+		want    string
+		wantCtx int
+	}{
+		{"gpt-4o-mini-2024-07-18", "gpt-4o-mini*", 128000},
+		{"GPT-4.1", "gpt-4.1*", 1000000},
+		{"gpt-4-32k-0613", "gpt-4-32k*", 32768},
+		{"o3-mini", "o3-mini*", 200000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			info, ok := registry.Lookup(tt.model)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, info.Model)
+			assert.Equal(t, tt.wantCtx, info.ContextWindow)
+		})
+	}
+
+	_, ok := registry.Lookup("my-local-model")
+	assert.False(t, ok)
+}
+
+func TestModelRegistryRefresh(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "dataset.json")
+	require.NoError(t, os.WriteFile(source, []byte(`{"models": [
+		{"model": "gpt-4o", "context_window": 64000, "input_price": 1},
+		{"model": "gpt-9*", "context_window": 2000000, "retires_at": "2030-01-02", "replacement": "gpt-10"}
+	]}`), 0644))
+	cache := filepath.Join(dir, "cache", "models.json")
+
+	registry := NewModelRegistry()
+	assert.True(t, registry.UpdatedAt().IsZero())
+
+	refresh, err := registry.Refresh(context.Background(), NewDummyClient("gpt-9-turbo"), source, cache)
+	require.NoError(t, err)
+	assert.Equal(t, ModelRefresh{Updated: 2, Listed: 1}, refresh)
+	assert.False(t, registry.Stale(time.Hour, time.Now()))
+
+	// Exact refreshed entries beat bundled prefixes; prefixes still apply to other versions
+	info, _ := registry.Lookup("gpt-4o")
+	assert.Equal(t, 64000, info.ContextWindow)
+	info, _ = registry.Lookup("gpt-4o-2024-08-06")
+	assert.Equal(t, 128000, info.ContextWindow)
+
+	info, ok := registry.Lookup("gpt-9-turbo")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC), info.Retirement())
+
+	listed, known := registry.Listed("gpt-9-turbo")
+	assert.True(t, known)
+	assert.True(t, listed)
+
+	// The cache restores the refreshed metadata
+	restored := NewModelRegistry()
+	require.NoError(t, restored.LoadCache(cache))
+	info, _ = restored.Lookup("gpt-4o")
+	assert.Equal(t, 64000, info.ContextWindow)
+	assert.Equal(t, registry.UpdatedAt().Unix(), restored.UpdatedAt().Unix())
+
+	assert.NoError(t, NewModelRegistry().LoadCache(filepath.Join(dir, "missing.json")))
+
+	_, err = NewModelRegistry().Refresh(context.Background(), nil, filepath.Join(dir, "missing.json"), "")
+	assert.Error(t, err)
+}
@@ -49,7 +49,8 @@ func NewOpenAIClient(config AIConfig) (*OpenAIClient, error) {
 
 	// Create HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: config.RequestTimeout,
+		Timeout:   config.RequestTimeout,
+		Transport: rateLimitTransport{},
 	}
 
 	// Create OpenAI client configuration
@@ -73,6 +74,7 @@ func NewOpenAIClient(config AIConfig) (*OpenAIClient, error) {
 
 // ChatCompletion implements the Client interface for non-streaming chat completion.
 func (c *OpenAIClient) ChatCompletion(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	ctx = withRetryHint(ctx)
 	openaiReq, err := c.convertChatRequest(req)
 	if err != nil {
 		return nil, err
@@ -105,7 +107,7 @@ func (c *OpenAIClient) ChatCompletion(ctx context.Context, req ChatRequest) (*Ch
 	}
 
 	if lastErr != nil {
-		return nil, c.wrapError(lastErr)
+		return nil, c.wrapError(ctx, lastErr)
 	}
 
 	return c.convertChatResponse(resp), nil
@@ -113,6 +115,7 @@ func (c *OpenAIClient) ChatCompletion(ctx context.Context, req ChatRequest) (*Ch
 
 // ChatCompletionStream implements the Client interface for streaming chat completion.
 func (c *OpenAIClient) ChatCompletionStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	ctx = withRetryHint(ctx)
 	openaiReq, err := c.convertChatRequest(req)
 	if err != nil {
 		return nil, err
@@ -121,9 +124,12 @@ func (c *OpenAIClient) ChatCompletionStream(ctx context.Context, req ChatRequest
 	// Force streaming
 	openaiReq.Stream = true
 
+	// Ask for a final usage chunk so callers get real token counts
+	openaiReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
 	stream, err := c.client.CreateChatCompletionStream(ctx, openaiReq)
 	if err != nil {
-		return nil, c.wrapError(err)
+		return nil, c.wrapError(ctx, err)
 	}
 
 	return &openAIStreamReader{
@@ -135,7 +141,7 @@ func (c *OpenAIClient) ChatCompletionStream(ctx context.Context, req ChatRequest
 func (c *OpenAIClient) ListModels(ctx context.Context) ([]Model, error) {
 	modelsList, err := c.client.ListModels(ctx)
 	if err != nil {
-		return nil, c.wrapError(err)
+		return nil, c.wrapError(ctx, err)
 	}
 
 	models := make([]Model, len(modelsList.Models))
@@ -162,12 +168,19 @@ func (c *OpenAIClient) Ping(ctx context.Context) error {
 
 	_, err := c.client.ListModels(ctx)
 	if err != nil {
-		return c.wrapError(err)
+		return c.wrapError(ctx, err)
 	}
 
 	return nil
 }
 
+// Capabilities implements the Client interface.
+func (c *OpenAIClient) Capabilities() Capabilities {
+	caps := ModelCapabilities(c.config.Model)
+	caps.StreamingUsage = true
+	return caps
+}
+
 // convertChatRequest converts our ChatRequest to OpenAI's format.
 func (c *OpenAIClient) convertChatRequest(req ChatRequest) (openai.ChatCompletionRequest, error) {
 	openaiReq := openai.ChatCompletionRequest{
@@ -185,19 +198,10 @@ func (c *OpenAIClient) convertChatRequest(req ChatRequest) (openai.ChatCompletio
 		}
 	}
 	
-	// Handle GPT-5 specific settings
-	if strings.HasPrefix(openaiReq.Model, "gpt-5") && req.ReasoningEffort != nil {
-		// TODO: When go-openai library supports GPT-5 reasoning effort,
-		// add the reasoning effort parameter to the request.
-		// For now, we'll prepare the structure but cannot send it due to SDK limitations.
-		//
-		// Expected future implementation:
-		// openaiReq.Reasoning = &openai.ReasoningConfig{
-		//     Effort: *req.ReasoningEffort,
-		// }
-		//
-		// Note: GPT-5 uses a different API endpoint (responses.create) instead of
-		// chat.completions, which may require a different client method when supported.
+	// Drop the parameters the model rejects
+	req = AdaptRequest(req, openaiReq.Model)
+	if req.ReasoningEffort != nil {
+		openaiReq.ReasoningEffort = *req.ReasoningEffort
 	}
 
 	// Convert messages
@@ -218,10 +222,12 @@ func (c *OpenAIClient) convertChatRequest(req ChatRequest) (openai.ChatCompletio
 	if req.Temperature != nil {
 		openaiReq.Temperature = *req.Temperature
 	}
-	// OpenAI reasoning models (o1, o3, o4, etc.) do not support MaxTokens, they use MaxCompletionTokens instead
-	if req.MaxTokens != nil && !strings.HasPrefix(openaiReq.Model, "o") {
+	if req.MaxTokens != nil {
 		openaiReq.MaxTokens = *req.MaxTokens
 	}
+	if req.MaxCompletionTokens != nil {
+		openaiReq.MaxCompletionTokens = *req.MaxCompletionTokens
+	}
 	if req.TopP != nil {
 		openaiReq.TopP = *req.TopP
 	}
@@ -339,7 +345,7 @@ func (c *OpenAIClient) isRetryableError(err error) bool {
 }
 
 // wrapError converts OpenAI errors to our error types.
-func (c *OpenAIClient) wrapError(err error) error {
+func (c *OpenAIClient) wrapError(ctx context.Context, err error) error {
 	if err == nil {
 		return nil
 	}
@@ -362,6 +368,9 @@ func (c *OpenAIClient) wrapError(err error) error {
 		if apiErr.Type != "" {
 			aiErr = aiErr.WithDetail("type", apiErr.Type)
 		}
+		if wait := retryAfterFrom(ctx); wait > 0 && aiErr.Type == ErrTypeRateLimit {
+			aiErr = aiErr.WithDetail("retry_after", wait)
+		}
 
 		return aiErr
 	}
@@ -394,6 +403,8 @@ func (c *OpenAIClient) getErrorType(apiErr *openai.APIError) ErrorType {
 		return ErrTypeInvalidRequest
 	case http.StatusPaymentRequired:
 		return ErrTypeQuotaExceeded
+	case http.StatusNotFound:
+		return ErrTypeModelNotFound
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
 		return ErrTypeServerError
 	case http.StatusGatewayTimeout:
@@ -430,6 +441,15 @@ func (r *openAIStreamReader) Read() (*StreamChunk, error) {
 		Choices:           make([]StreamChoice, len(chunk.Choices)),
 	}
 
+	// Usage is only sent in the final chunk, which carries no choices
+	if chunk.Usage != nil {
+		streamChunk.Usage = &Usage{
+			PromptTokens:     chunk.Usage.PromptTokens,
+			CompletionTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:      chunk.Usage.TotalTokens,
+		}
+	}
+
 	// Convert choices
 	for i, choice := range chunk.Choices {
 		streamChunk.Choices[i] = StreamChoice{
@@ -472,3 +492,23 @@ func (r *openAIStreamReader) Close() error {
 	}
 	return nil
 }
+
+// Embed implements the Embedder interface.
+func (c *OpenAIClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	ctx = withRetryHint(ctx)
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, c.wrapError(ctx, err)
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, data := range resp.Data {
+		if data.Index >= 0 && data.Index < len(vectors) {
+			vectors[data.Index] = data.Embedding
+		}
+	}
+	return vectors, nil
+}
@@ -272,6 +272,7 @@ func TestChatCompletionStream(t *testing.T) {
 		var req map[string]interface{}
 		json.NewDecoder(r.Body).Decode(&req)
 		assert.Equal(t, true, req["stream"])
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, req["stream_options"])
 
 		// Send SSE response
 		w.Header().Set("Content-Type", "text/event-stream")
@@ -283,6 +284,7 @@ func TestChatCompletionStream(t *testing.T) {
 			`{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1234567890,"model":"o3","choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null}]}`,
 			`{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1234567890,"model":"o3","choices":[{"index":0,"delta":{"content":" world!"},"finish_reason":null}]}`,
 			`{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1234567890,"model":"o3","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1234567890,"model":"o3","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`,
 		}
 
 		for _, chunk := range chunks {
@@ -312,6 +314,7 @@ func TestChatCompletionStream(t *testing.T) {
 	// Read all chunks
 	var content strings.Builder
 	var role string
+	var usage *Usage
 	for {
 		chunk, err := stream.Read()
 		if err == io.EOF {
@@ -319,6 +322,10 @@ func TestChatCompletionStream(t *testing.T) {
 		}
 		require.NoError(t, err)
 
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
 		if len(chunk.Choices) > 0 {
 			if chunk.Choices[0].Delta.Role != "" {
 				role = chunk.Choices[0].Delta.Role
@@ -329,6 +336,8 @@ func TestChatCompletionStream(t *testing.T) {
 
 	assert.Equal(t, RoleAssistant, role)
 	assert.Equal(t, "Hello world!", content.String())
+	require.NotNil(t, usage)
+	assert.Equal(t, Usage{PromptTokens: 9, CompletionTokens: 3, TotalTokens: 12}, *usage)
 }
 
 func TestListModels(t *testing.T) {
@@ -0,0 +1,76 @@
+package ai
+
+import "strings"
+
+// ModelParams describes the request parameters a model accepts
+type ModelParams struct {
+	// Sampling reports whether temperature, top_p and the penalties can be set;
+	// reasoning models fix them and reject requests that change them
+	Sampling bool
+
+	// MaxCompletionTokens reports whether the output limit is sent as
+	// max_completion_tokens; reasoning models reject max_tokens
+	MaxCompletionTokens bool
+
+	// ReasoningEfforts lists the accepted reasoning_effort values (nil when
+	// the model does not reason)
+	ReasoningEfforts []string
+}
+
+// modelParams maps model name prefixes to their parameters. More specific
+// prefixes must come before broader ones.
+var modelParams = []struct {
+	prefix string
+	params ModelParams
+}{
+	{"gpt-5-chat", ModelParams{Sampling: true, MaxCompletionTokens: true}},
+	{"gpt-5", ModelParams{MaxCompletionTokens: true, ReasoningEfforts: []string{"minimal", "low", "medium", "high"}}},
+	{"o1", ModelParams{MaxCompletionTokens: true, ReasoningEfforts: []string{"low", "medium", "high"}}},
+	{"o3", ModelParams{MaxCompletionTokens: true, ReasoningEfforts: []string{"low", "medium", "high"}}},
+	{"o4", ModelParams{MaxCompletionTokens: true, ReasoningEfforts: []string{"low", "medium", "high"}}},
+}
+
+// defaultModelParams are the parameters of chat models such as gpt-4o
+var defaultModelParams = ModelParams{Sampling: true}
+
+// ParamsFor returns the parameters accepted by model
+func ParamsFor(model string) ModelParams {
+	model = strings.ToLower(model)
+	for _, entry := range modelParams {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.params
+		}
+	}
+	return defaultModelParams
+}
+
+// AdaptRequest rewrites req for model: parameters the model rejects are
+// dropped and the output limit is moved to the field the model expects
+func AdaptRequest(req ChatRequest, model string) ChatRequest {
+	params := ParamsFor(model)
+
+	if !params.Sampling {
+		req.Temperature = nil
+		req.TopP = nil
+		req.PresencePenalty = nil
+		req.FrequencyPenalty = nil
+	}
+
+	switch {
+	case params.MaxCompletionTokens && req.MaxTokens != nil:
+		if req.MaxCompletionTokens == nil {
+			req.MaxCompletionTokens = req.MaxTokens
+		}
+		req.MaxTokens = nil
+	case !params.MaxCompletionTokens && req.MaxCompletionTokens != nil:
+		if req.MaxTokens == nil {
+			req.MaxTokens = req.MaxCompletionTokens
+		}
+		req.MaxCompletionTokens = nil
+	}
+
+	if req.ReasoningEffort != nil && params.ReasoningEfforts == nil {
+		req.ReasoningEffort = nil
+	}
+	return req
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptRequest(t *testing.T) {
+	temperature := float32(0.2)
+	maxTokens := 500
+	effort := "high"
+	base := ChatRequest{Temperature: &temperature, TopP: &temperature, MaxTokens: &maxTokens, ReasoningEffort: &effort}
+
+	tests := []struct {
+		name            string
+		model           string
+		wantTemperature bool
+		wantMaxTokens   bool
+		wantCompletion  bool
+		wantEffort      bool
+	}{
+		{"chat model", "gpt-4o", true, true, false, false},
+		{"o-series", "o3-mini", false, false, true, true},
+		{"gpt-5", "gpt-5", false, false, true, true},
+		{"gpt-5 chat", "gpt-5-chat-latest", true, false, true, false},
+		{"upper case deployment", "O4-MINI-prod", false, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AdaptRequest(base, tt.model)
+			assert.Equal(t, tt.wantTemperature, req.Temperature != nil)
+			assert.Equal(t, tt.wantTemperature, req.TopP != nil)
+			assert.Equal(t, tt.wantMaxTokens, req.MaxTokens != nil)
+			assert.Equal(t, tt.wantCompletion, req.MaxCompletionTokens != nil)
+			assert.Equal(t, tt.wantEffort, req.ReasoningEffort != nil)
+			if req.MaxCompletionTokens != nil {
+				assert.Equal(t, maxTokens, *req.MaxCompletionTokens)
+			}
+		})
+	}
+
+	// The original request is not modified
+	assert.NotNil(t, base.Temperature)
+	assert.NotNil(t, base.MaxTokens)
+}
+
+func TestOpenAIConvertChatRequestAdaptsParams(t *testing.T) {
+	temperature := float32(0.2)
+	maxTokens := 500
+	client := &OpenAIClient{}
+
+	req, err := client.convertChatRequest(ChatRequest{Model: "o3", Temperature: &temperature, MaxTokens: &maxTokens})
+	assert.NoError(t, err)
+	assert.Zero(t, req.Temperature)
+	assert.Zero(t, req.MaxTokens)
+	assert.Equal(t, maxTokens, req.MaxCompletionTokens)
+
+	req, err = client.convertChatRequest(ChatRequest{Model: "gpt-4o", Temperature: &temperature, MaxTokens: &maxTokens})
+	assert.NoError(t, err)
+	assert.Equal(t, temperature, req.Temperature)
+	assert.Equal(t, maxTokens, req.MaxTokens)
+}
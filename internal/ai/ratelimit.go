@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// retryAfterKey is the context key of the retryHint requests record their
+// rate limit wait in. go-openai drops the response headers of failed
+// requests, so the transport reads them before the library does.
+type retryAfterKey struct{}
+
+// retryHint holds the wait the last rate limited response asked for
+type retryHint struct {
+	mu   sync.Mutex
+	wait time.Duration
+}
+
+// withRetryHint returns a context whose rate limited requests record the
+// wait the server asks for, read back by retryAfterFrom
+func withRetryHint(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryAfterKey{}, &retryHint{})
+}
+
+// retryAfterFrom returns the wait recorded in ctx, or 0
+func retryAfterFrom(ctx context.Context) time.Duration {
+	hint, ok := ctx.Value(retryAfterKey{}).(*retryHint)
+	if !ok {
+		return 0
+	}
+	hint.mu.Lock()
+	defer hint.mu.Unlock()
+	return hint.wait
+}
+
+// rateLimitTransport records the wait of 429 responses in the request's
+// retryHint
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*retryHint); ok {
+		hint.mu.Lock()
+		hint.wait = ParseRetryAfter(resp.Header, time.Now())
+		hint.mu.Unlock()
+	}
+	return resp, err
+}
+
+// ParseRetryAfter returns how long a rate limited response asks to wait,
+// from Retry-After (seconds or an HTTP date), retry-after-ms or the longest
+// of the x-ratelimit-reset-* headers ("1s", "6m0s"). It returns 0 when none
+// is set.
+func ParseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if at, err := http.ParseTime(value); err == nil && at.After(now) {
+			return at.Sub(now)
+		}
+	}
+
+	var wait time.Duration
+	for _, name := range []string{"X-Ratelimit-Reset-Requests", "X-Ratelimit-Reset-Tokens"} {
+		if d, err := time.ParseDuration(header.Get(name)); err == nil && d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
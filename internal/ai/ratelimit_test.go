@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{"none", nil, 0},
+		{"seconds", map[string]string{"Retry-After": "7"}, 7 * time.Second},
+		{"http date", map[string]string{"Retry-After": now.Add(90 * time.Second).Format(http.TimeFormat)}, 90 * time.Second},
+		{"past date", map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)}, 0},
+		{"milliseconds first", map[string]string{"Retry-After-Ms": "1500", "Retry-After": "7"}, 1500 * time.Millisecond},
+		{"longest reset", map[string]string{"X-Ratelimit-Reset-Requests": "1s", "X-Ratelimit-Reset-Tokens": "6m0s"}, 6 * time.Minute},
+		{"garbage", map[string]string{"Retry-After": "soon", "X-Ratelimit-Reset-Tokens": "later"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, ParseRetryAfter(header, now))
+		})
+	}
+}
+
+func TestRateLimitErrorCarriesRetryAfter(t *testing.T) {
+	server := setupMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests"}}`))
+	})
+	config := createTestConfig(server.URL + "/v1")
+	config.MaxRetries = 1
+
+	openaiClient, err := NewOpenAIClient(config)
+	require.NoError(t, err)
+	azureClient, err := NewAzureClient(config, AzureConfig{Endpoint: server.URL, DeploymentName: "test-deployment"})
+	require.NoError(t, err)
+
+	req := ChatRequest{Model: "o3", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	for name, client := range map[string]Client{"openai": openaiClient, "azure": azureClient} {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.ChatCompletion(context.Background(), req)
+			var aiErr *Error
+			require.True(t, errors.As(err, &aiErr))
+			assert.Equal(t, ErrTypeRateLimit, aiErr.Type)
+			assert.Equal(t, 12*time.Second, aiErr.Details["retry_after"])
+
+			_, err = client.ChatCompletionStream(context.Background(), req)
+			require.True(t, errors.As(err, &aiErr))
+			assert.Equal(t, 12*time.Second, aiErr.Details["retry_after"])
+		})
+	}
+}
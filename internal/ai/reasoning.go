@@ -0,0 +1,26 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReasoningEfforts lists the reasoning_effort values accepted by model, or
+// nil when the model does not reason
+func ReasoningEfforts(model string) []string {
+	return ParamsFor(model).ReasoningEfforts
+}
+
+// ValidateReasoningEffort checks that model accepts effort
+func ValidateReasoningEffort(model, effort string) error {
+	efforts := ReasoningEfforts(model)
+	if efforts == nil {
+		return fmt.Errorf("model %s does not support reasoning_effort", model)
+	}
+	for _, e := range efforts {
+		if e == effort {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid reasoning_effort %q for model %s (must be one of: %s)", effort, model, strings.Join(efforts, ", "))
+}
@@ -0,0 +1,37 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestValidateReasoningEffort(t *testing.T) {
+	tests := []struct {
+		model   string
+		effort  string
+		wantErr bool
+	}{
+		{"gpt-5", "minimal", false},
+		{"gpt-5-mini", "high", false},
+		{"gpt-5-chat-latest", "low", true},
+		{"o3", "medium", false},
+		{"o4-mini", "minimal", true},
+		{"O1", "low", false},
+		{"gpt-4o", "low", true},
+		{"gpt-5", "extreme", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model+"/"+tt.effort, func(t *testing.T) {
+			err := ValidateReasoningEffort(tt.model, tt.effort)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
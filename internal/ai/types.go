@@ -100,6 +100,10 @@ type ChatRequest struct {
 	// Maximum tokens to generate
 	MaxTokens *int `json:"max_tokens,omitempty"`
 
+	// Maximum tokens to generate for models that reject max_tokens (see
+	// AdaptRequest, which sets it from MaxTokens)
+	MaxCompletionTokens *int `json:"max_completion_tokens,omitempty"`
+
 	// Whether to stream the response
 	Stream bool `json:"stream,omitempty"`
 
@@ -149,6 +153,10 @@ type ChatResponse struct {
 	// Model used for completion
 	Model string `json:"model"`
 
+	// FallbackModel names the fallback model that answered when the
+	// requested one could not (see FallbackClient)
+	FallbackModel string `json:"-"`
+
 	// Completion choices
 	Choices []Choice `json:"choices"`
 
@@ -320,11 +328,19 @@ type StreamChunk struct {
 	// Model used for completion
 	Model string `json:"model"`
 
+	// FallbackModel names the fallback model that answered when the
+	// requested one could not (see FallbackClient)
+	FallbackModel string `json:"-"`
+
 	// Streaming choices
 	Choices []StreamChoice `json:"choices"`
 
 	// System fingerprint for reproducibility
 	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Token usage for the whole completion (only present in the final chunk
+	// when the provider was asked to include usage)
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // StreamChoice represents a streaming choice.
@@ -0,0 +1,210 @@
+// Package artifacts stores standalone files generated during a session
+// (reports, diagrams, exports) under .coda/artifacts/<session>/ with an index.
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDir is where artifacts are stored, relative to the project
+	DefaultDir = ".coda/artifacts"
+
+	// IndexFile is the per-session index of artifacts
+	IndexFile = "index.json"
+
+	// DefaultSession holds artifacts saved outside of a session
+	DefaultSession = "default"
+)
+
+// Artifact describes a saved file
+type Artifact struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Session     string    `json:"session"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Path is the file location (not stored in the index)
+	Path string `json:"-"`
+}
+
+// Store manages the artifacts directory
+type Store struct {
+	root string
+	mu   sync.Mutex
+}
+
+// NewStore creates a store rooted at dir (DefaultDir when empty)
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Store{root: dir}
+}
+
+// Root returns the artifacts directory
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Save writes an artifact for the session and records it in the session
+// index. Saving an existing name replaces the file and its index entry.
+func (s *Store) Save(session, name, description string, content []byte) (*Artifact, error) {
+	session = sanitizeSession(session)
+	name, err := sanitizeName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := filepath.Join(s.root, session)
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	index, err := s.readIndex(session)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	artifact := Artifact{
+		Name:        name,
+		Description: description,
+		Session:     session,
+		Size:        int64(len(content)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	replaced := false
+	for i, existing := range index {
+		if existing.Name == name {
+			artifact.CreatedAt = existing.CreatedAt
+			index[i] = artifact
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		index = append(index, artifact)
+	}
+
+	if err := s.writeIndex(session, index); err != nil {
+		return nil, err
+	}
+
+	artifact.Path = path
+	return &artifact, nil
+}
+
+// List returns the artifacts of a session in the order they were created
+func (s *Store) List(session string) ([]Artifact, error) {
+	session = sanitizeSession(session)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readIndex(session)
+	if err != nil {
+		return nil, err
+	}
+	for i := range index {
+		index[i].Path = filepath.Join(s.root, session, index[i].Name)
+	}
+	return index, nil
+}
+
+// ListAll returns the artifacts of every session, newest first
+func (s *Store) ListAll() ([]Artifact, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read artifacts directory: %w", err)
+	}
+
+	var all []Artifact
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		list, err := s.List(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].UpdatedAt.After(all[j].UpdatedAt) })
+	return all, nil
+}
+
+func (s *Store) readIndex(session string) ([]Artifact, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, session, IndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read artifact index: %w", err)
+	}
+
+	var index []Artifact
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse artifact index: %w", err)
+	}
+	return index, nil
+}
+
+func (s *Store) writeIndex(session string, index []Artifact) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode artifact index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.root, session, IndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write artifact index: %w", err)
+	}
+	return nil
+}
+
+// sanitizeName keeps artifact names inside the session directory
+func sanitizeName(name string) (string, error) {
+	name = filepath.ToSlash(strings.TrimSpace(name))
+	clean := filepath.ToSlash(filepath.Clean("/" + name))[1:]
+	if clean == "" || clean == IndexFile {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	return filepath.FromSlash(clean), nil
+}
+
+// sanitizeSession turns a session ID into a safe directory name
+func sanitizeSession(session string) string {
+	session = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, session)
+	if session == "" {
+		return DefaultSession
+	}
+	return session
+}
@@ -0,0 +1,75 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSaveAndList(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	first, err := store.Save("session-1", "report.md", "Weekly report", []byte("# Report"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(store.Root(), "session-1", "report.md"), first.Path)
+
+	_, err = store.Save("session-1", "diagrams/flow.mmd", "", []byte("graph TD"))
+	require.NoError(t, err)
+
+	// Saving the same name replaces the entry but keeps its creation time
+	updated, err := store.Save("session-1", "report.md", "Weekly report v2", []byte("# Report v2"))
+	require.NoError(t, err)
+	assert.True(t, first.CreatedAt.Equal(updated.CreatedAt))
+
+	_, err = store.Save("session-2", "export.csv", "", []byte("a,b"))
+	require.NoError(t, err)
+
+	list, err := store.List("session-1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "report.md", list[0].Name)
+	assert.Equal(t, "Weekly report v2", list[0].Description)
+	assert.Equal(t, int64(len("# Report v2")), list[0].Size)
+	assert.Equal(t, filepath.Join("diagrams", "flow.mmd"), list[1].Name)
+
+	content, err := os.ReadFile(list[1].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "graph TD", string(content))
+
+	// The index is plain JSON next to the artifacts
+	data, err := os.ReadFile(filepath.Join(store.Root(), "session-1", IndexFile))
+	require.NoError(t, err)
+	var index []Artifact
+	require.NoError(t, json.Unmarshal(data, &index))
+	assert.Len(t, index, 2)
+
+	all, err := store.ListAll()
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "export.csv", all[0].Name)
+}
+
+func TestStoreSanitizes(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	a, err := store.Save("", "../../escape.txt", "", []byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(store.Root(), DefaultSession, "escape.txt"), a.Path)
+
+	a, err = store.Save("../odd/id", "ok.txt", "", []byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(store.Root(), "___odd_id", "ok.txt"), a.Path)
+
+	_, err = store.Save("s", IndexFile, "", []byte("x"))
+	assert.Error(t, err)
+	_, err = store.Save("s", "  ", "", []byte("x"))
+	assert.Error(t, err)
+
+	list, err := NewStore(filepath.Join(t.TempDir(), "missing")).ListAll()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
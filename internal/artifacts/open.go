@@ -0,0 +1,27 @@
+package artifacts
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Open opens a file or URL with the system's default application
+func Open(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// Do not wait for the viewer; reap it in the background
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,194 @@
+// Package bench runs Go benchmarks, stores their results and compares them
+// against a stored baseline, in the manner of benchstat.
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is one line of benchmark output
+type Sample struct {
+	Package    string
+	Name       string
+	Iterations int
+	// Metrics maps a unit (ns/op, B/op, allocs/op, MB/s, ...) to its value
+	Metrics map[string]float64
+}
+
+// Benchmark gathers the samples of one benchmark across runs
+type Benchmark struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+	// Values maps a unit to the value of each run
+	Values map[string][]float64 `json:"values"`
+}
+
+// Key identifies the benchmark across runs
+func (b Benchmark) Key() string {
+	if b.Package == "" {
+		return b.Name
+	}
+	return b.Package + "." + b.Name
+}
+
+// Units returns the units of the benchmark, ns/op first
+func (b Benchmark) Units() []string {
+	units := make([]string, 0, len(b.Values))
+	for unit := range b.Values {
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return unitRank(units[i]) < unitRank(units[j]) ||
+			unitRank(units[i]) == unitRank(units[j]) && units[i] < units[j]
+	})
+	return units
+}
+
+// unitRank orders the standard units before custom ones
+func unitRank(unit string) int {
+	switch unit {
+	case "ns/op":
+		return 0
+	case "MB/s":
+		return 1
+	case "B/op":
+		return 2
+	case "allocs/op":
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Results are the benchmarks of one run, in output order
+type Results struct {
+	CreatedAt  time.Time   `json:"created_at"`
+	Packages   []string    `json:"packages"`
+	Benchmarks []Benchmark `json:"benchmarks"`
+}
+
+// Find returns the benchmark with the key
+func (r *Results) Find(key string) (Benchmark, bool) {
+	for _, b := range r.Benchmarks {
+		if b.Key() == key {
+			return b, true
+		}
+	}
+	return Benchmark{}, false
+}
+
+// Options select the benchmarks to run
+type Options struct {
+	Packages []string
+	// Bench is the -bench regular expression
+	Bench string
+	// Count is how many times each benchmark runs
+	Count     int
+	Benchtime string
+}
+
+// Run runs the benchmarks with "go test -bench" in dir. Failing tests or
+// builds are an error, with their output.
+func Run(ctx context.Context, dir string, opts Options) (*Results, error) {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	pattern := opts.Bench
+	if pattern == "" {
+		pattern = "."
+	}
+	args := []string{"test", "-run=^$", "-bench=" + pattern, "-benchmem"}
+	if opts.Count > 0 {
+		args = append(args, "-count="+strconv.Itoa(opts.Count))
+	}
+	if opts.Benchtime != "" {
+		args = append(args, "-benchtime="+opts.Benchtime)
+	}
+	args = append(args, packages...)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go test -bench failed: %w\n%s", err, strings.TrimSpace(output.String()))
+	}
+
+	samples, err := Parse(&output)
+	if err != nil {
+		return nil, err
+	}
+	results := Collect(samples)
+	results.CreatedAt = time.Now()
+	results.Packages = packages
+	return results, nil
+}
+
+// Parse reads the samples from the output of "go test -bench"
+func Parse(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(name)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		iterations, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		sample := Sample{Package: pkg, Name: fields[0], Iterations: iterations, Metrics: make(map[string]float64)}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid benchmark line %q", line)
+			}
+			sample.Metrics[fields[i+1]] = value
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read benchmark output: %w", err)
+	}
+	return samples, nil
+}
+
+// Collect groups samples by benchmark
+func Collect(samples []Sample) *Results {
+	results := &Results{}
+	index := make(map[string]int)
+	for _, s := range samples {
+		key := Benchmark{Package: s.Package, Name: s.Name}.Key()
+		i, ok := index[key]
+		if !ok {
+			i = len(results.Benchmarks)
+			index[key] = i
+			results.Benchmarks = append(results.Benchmarks, Benchmark{
+				Package: s.Package,
+				Name:    s.Name,
+				Values:  make(map[string][]float64),
+			})
+		}
+		for unit, value := range s.Metrics {
+			results.Benchmarks[i].Values[unit] = append(results.Benchmarks[i].Values[unit], value)
+		}
+	}
+	return results
+}
@@ -0,0 +1,115 @@
+package bench
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: example.com/m/sorts
+cpu: Test CPU
+BenchmarkSort-8     	   10000	      1000 ns/op	     64 B/op	       2 allocs/op
+BenchmarkSort-8     	   10000	      1100 ns/op	     64 B/op	       2 allocs/op
+BenchmarkCopy-8     	  500000	       200 ns/op	 512.00 MB/s
+PASS
+ok  	example.com/m/sorts	3.210s
+`
+
+func TestParse(t *testing.T) {
+	samples, err := Parse(strings.NewReader(benchOutput))
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	assert.Equal(t, "example.com/m/sorts", samples[0].Package)
+	assert.Equal(t, "BenchmarkSort-8", samples[0].Name)
+	assert.Equal(t, 10000, samples[0].Iterations)
+	assert.Equal(t, map[string]float64{"ns/op": 1000, "B/op": 64, "allocs/op": 2}, samples[0].Metrics)
+	assert.Equal(t, 512.0, samples[2].Metrics["MB/s"])
+
+	results := Collect(samples)
+	require.Len(t, results.Benchmarks, 2)
+	sort := results.Benchmarks[0]
+	assert.Equal(t, "example.com/m/sorts.BenchmarkSort-8", sort.Key())
+	assert.Equal(t, []float64{1000, 1100}, sort.Values["ns/op"])
+	assert.Equal(t, []string{"ns/op", "B/op", "allocs/op"}, sort.Units())
+}
+
+func results(values map[string]map[string][]float64) *Results {
+	r := &Results{}
+	for name, units := range values {
+		r.Benchmarks = append(r.Benchmarks, Benchmark{Name: name, Values: units})
+	}
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		unit    string
+		old     []float64
+		new     []float64
+		verdict Verdict
+	}{
+		{"slower", "ns/op", []float64{100, 101, 99, 100, 102}, []float64{120, 121, 119, 122, 120}, VerdictRegression},
+		{"faster", "ns/op", []float64{100, 101, 99, 100, 102}, []float64{80, 81, 79, 82, 80}, VerdictImprovement},
+		{"noise", "ns/op", []float64{100, 110, 90, 105, 95}, []float64{101, 109, 92, 104, 96}, VerdictUnchanged},
+		{"below threshold", "ns/op", []float64{100, 100, 100, 100, 100}, []float64{102, 102, 102, 102, 102}, VerdictUnchanged},
+		{"too few samples", "ns/op", []float64{100}, []float64{200}, VerdictUnchanged},
+		{"lower throughput", "MB/s", []float64{500, 501, 502, 499, 500}, []float64{400, 401, 402, 399, 400}, VerdictRegression},
+		{"more allocations", "allocs/op", []float64{2, 2, 2, 2, 2}, []float64{3, 3, 3, 3, 3}, VerdictRegression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := results(map[string]map[string][]float64{"BenchmarkX": {tt.unit: tt.old}})
+			current := results(map[string]map[string][]float64{"BenchmarkX": {tt.unit: tt.new}})
+			report := Compare(base, current, 5)
+			require.Len(t, report.Comparisons, 1)
+			assert.Equal(t, tt.verdict, report.Comparisons[0].Verdict, "p=%f", report.Comparisons[0].P)
+		})
+	}
+}
+
+func TestCompareReport(t *testing.T) {
+	base := results(map[string]map[string][]float64{
+		"BenchmarkA":   {"ns/op": {100, 100, 101, 99, 100}},
+		"BenchmarkOld": {"ns/op": {10}},
+	})
+	current := results(map[string]map[string][]float64{
+		"BenchmarkA":   {"ns/op": {150, 151, 149, 150, 152}},
+		"BenchmarkNew": {"ns/op": {10}},
+	})
+
+	report := Compare(base, current, 5)
+	require.Len(t, report.Regressions(), 1)
+	assert.Empty(t, report.Improvements())
+	assert.InDelta(t, 50, report.Regressions()[0].Delta, 0.01)
+	assert.Equal(t, []string{"BenchmarkNew"}, report.Added)
+	assert.Equal(t, []string{"BenchmarkOld"}, report.Removed)
+
+	text := report.String()
+	assert.Contains(t, text, "BenchmarkA")
+	assert.Contains(t, text, "+50.0%")
+	assert.Contains(t, text, "regression")
+	assert.Contains(t, text, "new benchmarks: BenchmarkNew")
+	assert.Contains(t, text, "missing benchmarks: BenchmarkOld")
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(t.TempDir())
+	saved := results(map[string]map[string][]float64{"BenchmarkA": {"ns/op": {1, 2}}})
+	require.NoError(t, store.Save("baseline", saved))
+
+	loaded, err := store.Load("baseline")
+	require.NoError(t, err)
+	assert.Equal(t, saved.Benchmarks, loaded.Benchmarks)
+
+	_, err = store.Load("other")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	assert.Error(t, store.Save("../escape", saved))
+}
@@ -0,0 +1,213 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Alpha is the significance level below which a change is reported
+const Alpha = 0.05
+
+// Verdict classifies the change of a metric
+type Verdict string
+
+const (
+	VerdictRegression  Verdict = "regression"
+	VerdictImprovement Verdict = "improvement"
+	// VerdictUnchanged is a change that is not significant or is below the
+	// threshold
+	VerdictUnchanged Verdict = "unchanged"
+)
+
+// Comparison is the change of one metric of a benchmark
+type Comparison struct {
+	Benchmark string  `json:"benchmark"`
+	Unit      string  `json:"unit"`
+	Old       float64 `json:"old"`
+	New       float64 `json:"new"`
+	// Delta is the change of the median in percent
+	Delta   float64 `json:"delta_percent"`
+	P       float64 `json:"p_value"`
+	Samples string  `json:"samples"`
+	Verdict Verdict `json:"verdict"`
+}
+
+// Report compares two runs
+type Report struct {
+	Comparisons []Comparison `json:"comparisons"`
+	// Added and Removed list the benchmarks found in only one run
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Regressions returns the comparisons that got worse
+func (r *Report) Regressions() []Comparison {
+	return r.filter(VerdictRegression)
+}
+
+// Improvements returns the comparisons that got better
+func (r *Report) Improvements() []Comparison {
+	return r.filter(VerdictImprovement)
+}
+
+func (r *Report) filter(verdict Verdict) []Comparison {
+	var result []Comparison
+	for _, c := range r.Comparisons {
+		if c.Verdict == verdict {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Compare compares the medians of every metric found in both runs. A change
+// counts when the Mann-Whitney U test finds it significant at Alpha and it is
+// at least threshold percent; whether it is a regression depends on the unit
+// (lower is better, except for rates such as MB/s).
+func Compare(base, current *Results, threshold float64) *Report {
+	report := &Report{}
+	for _, b := range current.Benchmarks {
+		old, ok := base.Find(b.Key())
+		if !ok {
+			report.Added = append(report.Added, b.Key())
+			continue
+		}
+		for _, unit := range b.Units() {
+			oldValues, ok := old.Values[unit]
+			if !ok || len(oldValues) == 0 || len(b.Values[unit]) == 0 {
+				continue
+			}
+			report.Comparisons = append(report.Comparisons, compareValues(b.Key(), unit, oldValues, b.Values[unit], threshold))
+		}
+	}
+	for _, b := range base.Benchmarks {
+		if _, ok := current.Find(b.Key()); !ok {
+			report.Removed = append(report.Removed, b.Key())
+		}
+	}
+	return report
+}
+
+func compareValues(name, unit string, old, new []float64, threshold float64) Comparison {
+	c := Comparison{
+		Benchmark: name,
+		Unit:      unit,
+		Old:       Median(old),
+		New:       Median(new),
+		P:         mannWhitney(old, new),
+		Samples:   fmt.Sprintf("%d+%d", len(old), len(new)),
+		Verdict:   VerdictUnchanged,
+	}
+	switch {
+	case c.Old != 0:
+		c.Delta = 100 * (c.New - c.Old) / c.Old
+	case c.New != 0:
+		c.Delta = math.Inf(1)
+	}
+	if c.P >= Alpha || math.Abs(c.Delta) < threshold {
+		return c
+	}
+	worse := c.Delta > 0
+	if higherIsBetter(unit) {
+		worse = !worse
+	}
+	if worse {
+		c.Verdict = VerdictRegression
+	} else {
+		c.Verdict = VerdictImprovement
+	}
+	return c
+}
+
+// higherIsBetter reports whether larger values of the unit are better
+func higherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
+
+// Median returns the median of values
+func Median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mannWhitney returns the two-sided p-value of the Mann-Whitney U test of
+// the samples, using the normal approximation with a tie correction
+func mannWhitney(x, y []float64) float64 {
+	n1, n2 := float64(len(x)), float64(len(y))
+	type ranked struct {
+		value float64
+		first bool
+	}
+	all := make([]ranked, 0, len(x)+len(y))
+	for _, v := range x {
+		all = append(all, ranked{v, true})
+	}
+	for _, v := range y {
+		all = append(all, ranked{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Rank the values, averaging the ranks of ties
+	rankSum, ties := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	u := rankSum - n1*(n1+1)/2
+	n := n1 + n2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := math.Abs(u-n1*n2/2) / math.Sqrt(variance)
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// String formats the report as a table
+func (r *Report) String() string {
+	var b strings.Builder
+	if len(r.Comparisons) > 0 {
+		fmt.Fprintf(&b, "%-40s %-10s %12s %12s %9s  %s\n", "benchmark", "unit", "old", "new", "delta", "")
+	}
+	for _, c := range r.Comparisons {
+		delta := "~"
+		if c.Verdict != VerdictUnchanged {
+			delta = fmt.Sprintf("%+.1f%%", c.Delta)
+		}
+		note := fmt.Sprintf("(p=%.3f n=%s)", c.P, c.Samples)
+		if c.Verdict != VerdictUnchanged {
+			note += " " + string(c.Verdict)
+		}
+		fmt.Fprintf(&b, "%-40s %-10s %12s %12s %9s  %s\n", c.Benchmark, c.Unit, formatValue(c.Old), formatValue(c.New), delta, note)
+	}
+	if len(r.Added) > 0 {
+		fmt.Fprintf(&b, "new benchmarks: %s\n", strings.Join(r.Added, ", "))
+	}
+	if len(r.Removed) > 0 {
+		fmt.Fprintf(&b, "missing benchmarks: %s\n", strings.Join(r.Removed, ", "))
+	}
+	return b.String()
+}
+
+func formatValue(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
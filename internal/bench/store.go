@@ -0,0 +1,81 @@
+package bench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// DefaultDir is where results are stored, relative to the project
+const DefaultDir = ".coda/benchmarks"
+
+// DefaultBaseline is the name results are compared to by default
+const DefaultBaseline = "baseline"
+
+// ErrNotFound is returned when no results are stored under a name
+var ErrNotFound = errors.New("no stored benchmark results")
+
+// namePattern restricts stored names to safe file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Store keeps named benchmark results as JSON files
+type Store struct {
+	root string
+}
+
+// NewStore creates a store rooted at dir (DefaultDir when empty)
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Store{root: dir}
+}
+
+// Save stores results under name, replacing earlier ones
+func (s *Store) Save(name string, results *Results) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode benchmark results: %w", err)
+	}
+	if err := os.MkdirAll(s.root, 0755); err != nil {
+		return fmt.Errorf("failed to create benchmarks directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write benchmark results: %w", err)
+	}
+	return nil
+}
+
+// Load returns the results stored under name
+func (s *Store) Load(name string) (*Results, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w named %q", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark results: %w", err)
+	}
+	var results Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode benchmark results %s: %w", path, err)
+	}
+	return &results, nil
+}
+
+func (s *Store) path(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid results name %q", name)
+	}
+	return filepath.Join(s.root, name+".json"), nil
+}
@@ -0,0 +1,67 @@
+package changes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/tools"
+)
+
+func TestTracker(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "main.go")
+	created := filepath.Join(root, "pkg", "new.go")
+	untouched := filepath.Join(root, "same.go")
+	removed := filepath.Join(root, "gone.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n\nfunc main() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(untouched, []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(removed, []byte("package main\n"), 0644))
+
+	tracker := NewTracker(root)
+	ctx := tools.WithSessionID(context.Background(), "s1")
+
+	// Snapshots are taken before the tool writes
+	tracker.Observe(ctx, "edit_file", map[string]interface{}{"path": existing})
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n\nfunc main() {\n\tprintln(1)\n}\n"), 0644))
+
+	tracker.Observe(ctx, "write_file", map[string]interface{}{"path": created})
+	require.NoError(t, os.MkdirAll(filepath.Dir(created), 0755))
+	require.NoError(t, os.WriteFile(created, []byte("package pkg\n"), 0644))
+
+	// A second write keeps the original snapshot
+	tracker.Observe(ctx, "write_file", map[string]interface{}{"path": created})
+
+	// Read-only tools and rewrites to the same content are ignored
+	tracker.Observe(ctx, "read_file", map[string]interface{}{"path": untouched})
+	tracker.Observe(ctx, "write_file", map[string]interface{}{"path": untouched})
+
+	other := tools.WithSessionID(context.Background(), "s2")
+	tracker.Observe(other, "edit_file", map[string]interface{}{"path": removed})
+	require.NoError(t, os.Remove(removed))
+
+	s1 := tracker.Changes("s1")
+	require.Len(t, s1, 2)
+	assert.Equal(t, "main.go", s1[0].Path)
+	assert.Equal(t, StatusModified, s1[0].Status)
+	assert.Equal(t, 3, s1[0].Added)
+	assert.Equal(t, 1, s1[0].Removed)
+	assert.Equal(t, "pkg/new.go", s1[1].Path)
+	assert.Equal(t, StatusAdded, s1[1].Status)
+
+	s2 := tracker.Changes("s2")
+	require.Len(t, s2, 1)
+	assert.Equal(t, StatusDeleted, s2[0].Status)
+
+	assert.Equal(t, []string{"gone.go", "main.go", "pkg/new.go"}, tracker.Paths(AllSessions))
+
+	stat := DiffStat(tracker.Changes(AllSessions))
+	assert.Contains(t, stat, " main.go    |    4 +++-\n")
+	assert.Contains(t, stat, "(added)")
+	assert.True(t, strings.HasSuffix(stat, "3 file(s) changed, 4 insertion(s)(+), 2 deletion(s)(-)"))
+}
@@ -0,0 +1,224 @@
+// Package changes tracks the files the agent creates or modifies during a
+// session and summarizes them as a diffstat and unified diffs.
+package changes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/common-creation/coda/internal/textdiff"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+// writeTools are the tools whose "path" parameter is a file they modify
+var writeTools = map[string]bool{
+	"write_file": true,
+	"edit_file":  true,
+}
+
+// AllSessions collects the files touched in any session
+const AllSessions = "*"
+
+// Status describes how a file differs from its state before the session
+type Status string
+
+const (
+	StatusAdded    Status = "added"
+	StatusModified Status = "modified"
+	StatusDeleted  Status = "deleted"
+)
+
+// FileChange is the net change to one file
+type FileChange struct {
+	Path    string
+	Status  Status
+	Added   int
+	Removed int
+	Diff    string
+}
+
+type original struct {
+	content []byte
+	existed bool
+}
+
+// Tracker remembers the content of every file before the agent first
+// touched it in each session
+type Tracker struct {
+	root string
+
+	mu       sync.Mutex
+	sessions map[string]map[string]*original
+	order    map[string][]string
+}
+
+// NewTracker creates a tracker; paths are reported relative to root
+func NewTracker(root string) *Tracker {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Tracker{
+		root:     root,
+		sessions: make(map[string]map[string]*original),
+		order:    make(map[string][]string),
+	}
+}
+
+// Observe is a tools.ExecuteHook that records files before write tools
+// change them
+func (t *Tracker) Observe(ctx context.Context, name string, params map[string]interface{}) {
+	if !writeTools[name] {
+		return
+	}
+	path, ok := params["path"].(string)
+	if !ok || path == "" {
+		return
+	}
+	t.Record(tools.SessionIDFromContext(ctx), path)
+}
+
+// Record snapshots a file for the session unless it is already tracked
+func (t *Tracker) Record(session, path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.record(session, abs)
+	if session != AllSessions {
+		t.record(AllSessions, abs)
+	}
+}
+
+func (t *Tracker) record(session, abs string) {
+	files := t.sessions[session]
+	if files == nil {
+		files = make(map[string]*original)
+		t.sessions[session] = files
+	}
+	if _, ok := files[abs]; ok {
+		return
+	}
+
+	orig := &original{}
+	if content, err := os.ReadFile(abs); err == nil {
+		orig.content = content
+		orig.existed = true
+	}
+	files[abs] = orig
+	t.order[session] = append(t.order[session], abs)
+}
+
+// Changes returns the net change of each tracked file in the session (or
+// AllSessions), in the order the files were first touched. Files back to
+// their original content are omitted.
+func (t *Tracker) Changes(session string) []FileChange {
+	t.mu.Lock()
+	paths := append([]string{}, t.order[session]...)
+	originals := make([]original, len(paths))
+	for i, p := range paths {
+		originals[i] = *t.sessions[session][p]
+	}
+	t.mu.Unlock()
+
+	var result []FileChange
+	for i, abs := range paths {
+		orig := originals[i]
+		current, err := os.ReadFile(abs)
+		exists := err == nil
+
+		rel := t.relative(abs)
+		diff, added, removed := textdiff.Unified(rel, string(orig.content), string(current), orig.existed, exists)
+		if diff == "" {
+			continue
+		}
+
+		status := StatusModified
+		switch {
+		case !orig.existed && exists:
+			status = StatusAdded
+		case orig.existed && !exists:
+			status = StatusDeleted
+		case !orig.existed && !exists:
+			continue
+		}
+
+		result = append(result, FileChange{
+			Path:    rel,
+			Status:  status,
+			Added:   added,
+			Removed: removed,
+			Diff:    diff,
+		})
+	}
+	return result
+}
+
+// Paths returns the changed files of the session relative to the root
+func (t *Tracker) Paths(session string) []string {
+	var paths []string
+	for _, c := range t.Changes(session) {
+		paths = append(paths, c.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (t *Tracker) relative(abs string) string {
+	if rel, err := filepath.Rel(t.root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return abs
+}
+
+// DiffStat renders changes like git diff --stat
+func DiffStat(changes []FileChange) string {
+	if len(changes) == 0 {
+		return "No changes"
+	}
+
+	width := 0
+	most := 0
+	for _, c := range changes {
+		width = max(width, len(c.Path))
+		most = max(most, c.Added+c.Removed)
+	}
+
+	const barWidth = 40
+	var sb strings.Builder
+	totalAdded, totalRemoved := 0, 0
+	for _, c := range changes {
+		totalAdded += c.Added
+		totalRemoved += c.Removed
+
+		plus, minus := c.Added, c.Removed
+		if most > barWidth {
+			plus = scaleBar(c.Added, most, barWidth)
+			minus = scaleBar(c.Removed, most, barWidth)
+		}
+
+		note := ""
+		if c.Status != StatusModified {
+			note = fmt.Sprintf(" (%s)", c.Status)
+		}
+		sb.WriteString(fmt.Sprintf(" %-*s | %4d %s%s%s\n", width, c.Path, c.Added+c.Removed,
+			strings.Repeat("+", plus), strings.Repeat("-", minus), note))
+	}
+	sb.WriteString(fmt.Sprintf(" %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)", len(changes), totalAdded, totalRemoved))
+	return sb.String()
+}
+
+func scaleBar(n, most, width int) int {
+	if n == 0 {
+		return 0
+	}
+	return max(n*width/most, 1)
+}
@@ -369,3 +369,32 @@ func (h *InteractiveApprovalHandler) getSessionID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// StaticApprovalHandler approves a fixed set of tools, or all of them, and
+// denies everything else without asking. It is used for headless runs where
+// nobody can answer an approval request.
+type StaticApprovalHandler struct {
+	all   bool
+	tools map[string]bool
+}
+
+// NewStaticApprovalHandler creates a handler approving the listed tools
+// (every tool when all is set)
+func NewStaticApprovalHandler(all bool, tools []string) *StaticApprovalHandler {
+	h := &StaticApprovalHandler{all: all, tools: make(map[string]bool)}
+	for _, name := range tools {
+		h.tools[name] = true
+	}
+	return h
+}
+
+// RequestApproval implements ApprovalHandler; tools that are not approved
+// up front are denied
+func (h *StaticApprovalHandler) RequestApproval(ctx context.Context, tool string, params map[string]interface{}) (bool, error) {
+	return false, nil
+}
+
+// IsAutoApproved implements ApprovalHandler
+func (h *StaticApprovalHandler) IsAutoApproved(tool string) bool {
+	return h.all || h.tools[tool]
+}
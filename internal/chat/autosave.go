@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// openHandlers are the handlers with persistence that were not closed yet,
+// saved by SaveOpenSessions on exit paths that skip Close
+var (
+	openHandlersMu sync.Mutex
+	openHandlers   = make(map[*ChatHandler]struct{})
+)
+
+// saveState tracks what was saved, so unchanged sessions are not written
+// again, and the last failure
+type saveState struct {
+	mu      sync.Mutex
+	savedAt map[string]time.Time // LastActive of each session when it was saved
+	err     error
+}
+
+// SaveCurrentSession saves the current session if it changed since it was
+// last saved. Empty and read-only sessions are skipped.
+func (h *ChatHandler) SaveCurrentSession() error {
+	if h.persistence == nil {
+		return nil
+	}
+	session := h.session.GetCurrent()
+	if session == nil || (len(session.Messages) == 0 && len(session.Context) == 0) || h.persistence.IsReadOnly(session.ID) {
+		return nil
+	}
+
+	h.saves.mu.Lock()
+	saved, ok := h.saves.savedAt[session.ID]
+	h.saves.mu.Unlock()
+	if ok && !session.LastActive.After(saved) {
+		return nil
+	}
+
+	err := h.persistence.SaveSession(session)
+	h.recordSave(session, err)
+	if err != nil {
+		return fmt.Errorf("failed to save session %s: %w", session.ID, err)
+	}
+	return nil
+}
+
+// recordSave remembers the outcome of saving session
+func (h *ChatHandler) recordSave(session *Session, err error) {
+	if errors.Is(err, ErrSessionReadOnly) {
+		return
+	}
+	h.saves.mu.Lock()
+	defer h.saves.mu.Unlock()
+	h.saves.err = err
+	if err != nil {
+		return
+	}
+	if h.saves.savedAt == nil {
+		h.saves.savedAt = make(map[string]time.Time)
+	}
+	h.saves.savedAt[session.ID] = session.LastActive
+}
+
+// SaveError returns the error of the last save if it failed, so the user can
+// be told history is not being written. A successful save clears it.
+func (h *ChatHandler) SaveError() error {
+	h.saves.mu.Lock()
+	defer h.saves.mu.Unlock()
+	return h.saves.err
+}
+
+// registerOpen adds h to the handlers saved by SaveOpenSessions
+func (h *ChatHandler) registerOpen() {
+	openHandlersMu.Lock()
+	defer openHandlersMu.Unlock()
+	openHandlers[h] = struct{}{}
+}
+
+// unregisterOpen removes h from the handlers saved by SaveOpenSessions
+func (h *ChatHandler) unregisterOpen() {
+	openHandlersMu.Lock()
+	defer openHandlersMu.Unlock()
+	delete(openHandlers, h)
+}
+
+// SaveOpenSessions saves the current session of every handler that was not
+// closed yet. It is meant for signal handlers that exit without running the
+// normal shutdown.
+func SaveOpenSessions() error {
+	openHandlersMu.Lock()
+	handlers := make([]*ChatHandler, 0, len(openHandlers))
+	for h := range openHandlers {
+		handlers = append(handlers, h)
+	}
+	openHandlersMu.Unlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h.SaveCurrentSession(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/tokenizer"
+)
+
+// PromptBudget describes how a prompt fits into the model's context window
+type PromptBudget struct {
+	Tokens int // Estimated prompt tokens including the new message
+	Limit  int // Context window of the model
+
+	// Dropped lists the oldest history messages that go before the prompt is
+	// sent: those the session trims to stay within its token limit, then
+	// those that do not fit into the context window
+	Dropped       []ai.Message
+	DroppedTokens int
+	Truncated     bool // The session trims history when the message is added
+}
+
+// Fits reports that the prompt is sent with its whole history
+func (b PromptBudget) Fits() bool {
+	return len(b.Dropped) == 0 && b.Tokens <= b.Limit
+}
+
+// Rejected reports that the prompt exceeds the context window even without
+// the history
+func (b PromptBudget) Rejected() bool {
+	return b.Tokens-b.DroppedTokens > b.Limit
+}
+
+// PromptBudget estimates the prompt for userInput against a context window of
+// limit tokens and works out which history would have to be dropped
+func (h *ChatHandler) PromptBudget(userInput string, limit int) (PromptBudget, error) {
+	tokens, err := h.EstimatePromptTokens(userInput)
+	if err != nil {
+		return PromptBudget{}, err
+	}
+	budget := PromptBudget{Tokens: tokens, Limit: limit}
+
+	session := h.session.GetCurrent()
+	if session == nil {
+		return budget, nil
+	}
+	trimmed, err := h.session.TrimPreview(session.ID, userInput)
+	if err != nil {
+		return budget, err
+	}
+	history, err := h.session.GetMessages(session.ID)
+	if err != nil {
+		return budget, err
+	}
+	if len(history) > 0 && history[0].Role == ai.RoleSystem {
+		history = history[1:]
+	}
+
+	budget.Truncated = trimmed > 0
+	for i, msg := range history {
+		if i >= trimmed && tokens-budget.DroppedTokens <= limit {
+			break
+		}
+		budget.Dropped = append(budget.Dropped, msg)
+		budget.DroppedTokens += h.estimateContentTokens(msg.Content)
+	}
+	return budget, nil
+}
+
+// DropOldestMessages removes the n oldest messages of the current session so
+// a prompt fits into the context window
+func (h *ChatHandler) DropOldestMessages(n int) error {
+	session := h.session.GetCurrent()
+	if session == nil {
+		return fmt.Errorf("no active session")
+	}
+	return h.session.DropOldest(session.ID, n)
+}
+
+// estimateContentTokens counts the tokens of one message, falling back to a
+// rough estimate when the model has no known encoding
+func (h *ChatHandler) estimateContentTokens(content string) int {
+	if content == "" {
+		return 0
+	}
+	tokens, _ := tokenizer.Count(content, h.config.AI.Model)
+	return tokens
+}
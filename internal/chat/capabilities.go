@@ -0,0 +1,22 @@
+package chat
+
+import (
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// Capabilities returns the features the AI client supports for the model of
+// this handler, which may differ from the client's own model (e.g. in a tab
+// opened with another model)
+func (h *ChatHandler) Capabilities() ai.Capabilities {
+	if h.aiClient == nil {
+		return ai.ModelCapabilities(h.config.AI.Model)
+	}
+	return h.aiClient.Capabilities().ForModel(h.config.AI.Model)
+}
+
+// useStructuredOutputs reports whether responses are requested as Structured
+// Outputs: when enabled in the configuration and supported by the model.
+// Otherwise tool calls are parsed from the response text.
+func (h *ChatHandler) useStructuredOutputs() bool {
+	return h.config.AI.UseStructuredOutputs && h.Capabilities().JSONSchema
+}
@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/embeddings"
+)
+
+const (
+	// DefaultDocsResults is the number of documentation sections attached to
+	// a message by default
+	DefaultDocsResults = 3
+
+	// minDocsScore leaves out sections barely related to the message
+	minDocsScore = 0.2
+)
+
+// DocSource is a documentation section attached to a message, cited as [N]
+type DocSource struct {
+	N         int
+	Path      string
+	StartLine int
+	EndLine   int
+	Title     string
+	Text      string
+}
+
+// Citation formats the source as "[N] path:start-end (title)"
+func (s DocSource) Citation() string {
+	citation := fmt.Sprintf("[%d] %s:%d-%d", s.N, s.Path, s.StartLine, s.EndLine)
+	if s.Title != "" {
+		citation += " (" + s.Title + ")"
+	}
+	return citation
+}
+
+// DocsRetriever finds the project documentation relevant to a message
+type DocsRetriever struct {
+	index *embeddings.Index
+	limit int
+}
+
+// NewDocsRetriever creates a retriever returning up to limit sections of the
+// documentation in index (DefaultDocsResults when limit is not positive)
+func NewDocsRetriever(index *embeddings.Index, limit int) *DocsRetriever {
+	if limit <= 0 {
+		limit = DefaultDocsResults
+	}
+	return &DocsRetriever{index: index, limit: limit}
+}
+
+// Retrieve returns the documentation sections most related to query
+func (r *DocsRetriever) Retrieve(ctx context.Context, query string) ([]DocSource, error) {
+	if err := r.index.Update(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update the docs index: %w", err)
+	}
+	results, err := r.index.Search(ctx, query, "", r.limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the docs: %w", err)
+	}
+
+	var sources []DocSource
+	for _, result := range results {
+		if result.Score < minDocsScore {
+			continue
+		}
+		sources = append(sources, DocSource{
+			N:         len(sources) + 1,
+			Path:      result.Path,
+			StartLine: result.StartLine,
+			EndLine:   result.EndLine,
+			Title:     result.Title,
+			Text:      result.Text,
+		})
+	}
+	return sources, nil
+}
+
+// FormatDocsContext renders sources as the context message sent with the
+// user's message
+func FormatDocsContext(sources []DocSource) string {
+	var b strings.Builder
+	b.WriteString("Project documentation that may help answer the next message. ")
+	b.WriteString("Cite the sections you use as [N]; ignore them if they are not relevant.\n")
+	for _, source := range sources {
+		fmt.Fprintf(&b, "\n%s\n%s\n", source.Citation(), source.Text)
+	}
+	return b.String()
+}
+
+// SetDocsRetriever attaches the documentation related to each message sent
+// with HandleMessageWithResponse; nil disables it
+func (h *ChatHandler) SetDocsRetriever(docs *DocsRetriever) {
+	h.docs = docs
+}
+
+// withDocs inserts the documentation related to input before the last
+// message. The context is sent with this request only and is not saved in
+// the session. Retrieval failures leave messages unchanged.
+func (h *ChatHandler) withDocs(ctx context.Context, input string, messages []ai.Message) ([]ai.Message, []DocSource) {
+	if h.docs == nil || len(messages) == 0 {
+		return messages, nil
+	}
+	sources, err := h.docs.Retrieve(ctx, input)
+	if err != nil || len(sources) == 0 {
+		return messages, nil
+	}
+
+	last := len(messages) - 1
+	withContext := make([]ai.Message, 0, len(messages)+1)
+	withContext = append(withContext, messages[:last]...)
+	withContext = append(withContext, ai.Message{Role: ai.RoleSystem, Content: FormatDocsContext(sources)})
+	withContext = append(withContext, messages[last])
+	return withContext, sources
+}
@@ -0,0 +1,246 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/sessioncrypt"
+)
+
+// PersistenceOption configures a FilePersistence
+type PersistenceOption func(*FilePersistence)
+
+// WithCipher encrypts the session files, their backups and the partial
+// response journals. Plaintext files are refused, except sealed sessions,
+// until EncryptSessions encrypts them.
+func WithCipher(c *sessioncrypt.Cipher) PersistenceOption {
+	return func(fp *FilePersistence) {
+		fp.cipher = c
+	}
+}
+
+// NewSessionCipher returns the cipher of the configured session encryption
+// key, or nil when encryption is off
+func NewSessionCipher(cfg config.SessionEncryptionConfig) (*sessioncrypt.Cipher, error) {
+	if cfg.Key == "" {
+		return nil, nil
+	}
+
+	var store config.SecretsManager
+	if s, err := config.NewSecretsManager(); err == nil {
+		store = s
+	}
+	values, err := config.ResolveSecretRefs(map[string]string{"session.encryption.key": cfg.Key}, store)
+	if err != nil {
+		return nil, err
+	}
+	return sessioncrypt.New(values["session.encryption.key"])
+}
+
+// ErrSessionNotEncrypted is returned when a plaintext file is read while
+// session encryption is on
+var ErrSessionNotEncrypted = errors.New("file is not encrypted although session encryption is on; run coda encrypt-sessions to encrypt existing sessions")
+
+// isKeyError reports whether err means the session could not be decrypted,
+// which is never repaired from a backup or quarantined
+func isKeyError(err error) bool {
+	return errors.Is(err, sessioncrypt.ErrDecrypt) || errors.Is(err, sessioncrypt.ErrEncrypted) || errors.Is(err, ErrSessionNotEncrypted)
+}
+
+// seal encrypts file contents when encryption is on
+func (fp *FilePersistence) seal(data []byte) ([]byte, error) {
+	if fp.cipher == nil {
+		return data, nil
+	}
+	sealed, err := fp.cipher.Seal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt session: %w", err)
+	}
+	return sealed, nil
+}
+
+// open decrypts file contents written by seal. Plaintext passes through
+// only when encryption is off.
+func (fp *FilePersistence) open(data []byte) ([]byte, error) {
+	if !sessioncrypt.IsEncrypted(data) {
+		if fp.cipher != nil {
+			return nil, ErrSessionNotEncrypted
+		}
+		return data, nil
+	}
+	if fp.cipher == nil {
+		return nil, sessioncrypt.ErrEncrypted
+	}
+	return fp.cipher.Open(data)
+}
+
+// sealLine encrypts one journal line as base64 so that lines stay
+// newline-delimited
+func sealLine(c *sessioncrypt.Cipher, line []byte) ([]byte, error) {
+	if c == nil {
+		return line, nil
+	}
+	sealed, err := c.Seal(line)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// openLine decrypts a journal line written by sealLine. JSON lines pass
+// through only when encryption is off.
+func (fp *FilePersistence) openLine(line []byte) ([]byte, error) {
+	if bytes.HasPrefix(line, []byte("{")) {
+		if fp.cipher != nil {
+			return nil, ErrSessionNotEncrypted
+		}
+		return line, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return nil, fmt.Errorf("invalid partial response line: %w", err)
+	}
+	return fp.open(sealed)
+}
+
+// EncryptionReport is the outcome of EncryptSessions
+type EncryptionReport struct {
+	Sessions []string // Sessions whose files were encrypted
+	Files    int      // Backups and partial response journals encrypted
+	Sealed   []string // Sealed sessions, left as they were sealed
+	Locked   []string // Sessions open in another instance, left for later
+}
+
+// EncryptSessions encrypts the plaintext session files, backups and partial
+// response journals with the cipher of the persistence. Sealed sessions and
+// sessions open in another instance are left alone and reported.
+func (fp *FilePersistence) EncryptSessions() (*EncryptionReport, error) {
+	if fp.cipher == nil {
+		return nil, fmt.Errorf("session encryption is not configured")
+	}
+	ids, err := fp.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	report := &EncryptionReport{}
+	for _, id := range ids {
+		if metadata, err := fp.loadMetadata(id); err == nil && metadata.SealedAt != nil {
+			report.Sealed = append(report.Sealed, id)
+			continue
+		}
+		if _, held := fp.locks[id]; !held {
+			lock, err := AcquireSessionLock(filepath.Join(fp.basePath, "locks"), id)
+			if errors.Is(err, ErrSessionLocked) {
+				report.Locked = append(report.Locked, id)
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+			err = fp.encryptSession(id, report)
+			lock.Release()
+			if err != nil {
+				return report, err
+			}
+			continue
+		}
+		if err := fp.encryptSession(id, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// encryptSession encrypts the files of a session that are still plaintext.
+// The caller holds its lock.
+func (fp *FilePersistence) encryptSession(id string, report *EncryptionReport) error {
+	path := fp.sessionPath(id)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read session %s: %w", id, err)
+	}
+	if !sessioncrypt.IsEncrypted(data) {
+		session, err := decodeSession(data)
+		if err != nil {
+			return fmt.Errorf("session %s: %w", id, err)
+		}
+		sealed, err := fp.seal(data)
+		if err != nil {
+			return err
+		}
+		if err := fp.writeAtomic(path, sealed); err != nil {
+			return fmt.Errorf("failed to encrypt session %s: %w", id, err)
+		}
+		if err := fp.saveMetadata(id, newSessionMetadata(session, sealed)); err != nil {
+			return fmt.Errorf("failed to save metadata: %w", err)
+		}
+		report.Sessions = append(report.Sessions, id)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(fp.basePath, "backup", id+"_*.json"))
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		if sessioncrypt.IsEncrypted(data) {
+			continue
+		}
+		sealed, err := fp.seal(data)
+		if err != nil {
+			return err
+		}
+		if err := fp.writeAtomic(backup, sealed); err != nil {
+			return fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		report.Files++
+	}
+
+	return fp.encryptPartialResponse(id, report)
+}
+
+// encryptPartialResponse encrypts the plaintext lines of a partial response
+// journal
+func (fp *FilePersistence) encryptPartialResponse(id string, report *EncryptionReport) error {
+	path := fp.partialPath(id)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read partial response journal: %w", err)
+	}
+
+	var out bytes.Buffer
+	changed := false
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("{")) {
+			if line, err = sealLine(fp.cipher, line); err != nil {
+				return err
+			}
+			changed = true
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	if !changed {
+		return nil
+	}
+	if err := fp.writeAtomic(path, out.Bytes()); err != nil {
+		return fmt.Errorf("failed to encrypt partial response journal: %w", err)
+	}
+	report.Files++
+	return nil
+}
@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/sessioncrypt"
+)
+
+func TestEncryptedPersistenceRefusesPlaintext(t *testing.T) {
+	dir := t.TempDir()
+	plain, err := NewFilePersistence(dir, false, 0)
+	require.NoError(t, err)
+	require.NoError(t, plain.SaveSession(&Session{ID: "s1", StartedAt: time.Now(), Messages: []ai.Message{{Role: "user", Content: "secret"}}}))
+	require.NoError(t, plain.Close())
+
+	c, err := sessioncrypt.New("passphrase", sessioncrypt.WithIterations(1))
+	require.NoError(t, err)
+	fp, err := NewFilePersistence(dir, false, 0, WithCipher(c))
+	require.NoError(t, err)
+	defer fp.Close()
+
+	_, err = fp.LoadSession("s1")
+	assert.ErrorIs(t, err, ErrSessionNotEncrypted)
+	assert.FileExists(t, fp.sessionPath("s1"), "a plaintext session is not quarantined")
+
+	report, err := fp.EncryptSessions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1"}, report.Sessions)
+
+	data, err := os.ReadFile(fp.sessionPath("s1"))
+	require.NoError(t, err)
+	assert.True(t, sessioncrypt.IsEncrypted(data))
+	assert.NotContains(t, string(data), "secret")
+
+	loaded, err := fp.LoadSession("s1")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", loaded.Messages[0].Content)
+}
+
+func TestEncryptSessionsLeavesSealedSessions(t *testing.T) {
+	dir := t.TempDir()
+	plain, err := NewFilePersistence(dir, false, 0)
+	require.NoError(t, err)
+	require.NoError(t, plain.SaveSession(&Session{ID: "s1", StartedAt: time.Now()}))
+	_, err = plain.SealSession("s1")
+	require.NoError(t, err)
+	require.NoError(t, plain.Close())
+
+	c, err := sessioncrypt.New("passphrase", sessioncrypt.WithIterations(1))
+	require.NoError(t, err)
+	fp, err := NewFilePersistence(dir, false, 0, WithCipher(c))
+	require.NoError(t, err)
+	defer fp.Close()
+
+	report, err := fp.EncryptSessions()
+	require.NoError(t, err)
+	assert.Empty(t, report.Sessions)
+	assert.Equal(t, []string{"s1"}, report.Sealed)
+
+	_, err = fp.LoadSession("s1")
+	assert.NoError(t, err)
+	_, err = fp.VerifySeal("s1")
+	assert.NoError(t, err)
+}
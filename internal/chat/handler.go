@@ -3,6 +3,7 @@ package chat
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -28,6 +29,15 @@ type ChatHandler struct {
 	history       *History
 	promptBuilder *PromptBuilder
 	persistence   *FilePersistence
+	docs          *DocsRetriever
+	postProcess   *Pipeline
+	saves         saveState
+
+	// reasoningEffort overrides the configured reasoning effort (/set reasoning)
+	reasoningEffort *string
+
+	// persona is the name of the prompt preset applied with SetPersona
+	persona string
 
 	// Streaming state
 	streamingTokens int
@@ -40,7 +50,11 @@ type ChatResponse struct {
 	TokenCount      int // Total token count (deprecated, use TokenUsage.TotalTokens)
 	ToolCalls       []ai.ToolCall
 	TokenUsage      *ai.Usage // Detailed token usage from AI response
+	UsageEstimated  bool      // True when TokenUsage was estimated locally instead of reported by the provider
 	EstimatedPrompt int       // Estimated prompt tokens (before sending)
+	FallbackModel   string    // Fallback model that answered instead of the configured one
+
+	Sources []DocSource // Documentation sections attached to the message
 }
 
 // NewChatHandler creates a new chat handler
@@ -67,6 +81,9 @@ func NewChatHandler(aiClient ai.Client, toolManager *tools.Manager, mcpManager m
 		mcpTools, err := mcpManager.ListTools()
 		if err == nil {
 			for _, tool := range mcpTools {
+				if toolManager != nil && !toolManager.IsEnabled(tool.Name) {
+					continue
+				}
 				promptBuilder.AddToolPrompt(tool.Name, tool.Description)
 			}
 		}
@@ -82,12 +99,25 @@ func NewChatHandler(aiClient ai.Client, toolManager *tools.Manager, mcpManager m
 		promptBuilder: promptBuilder,
 	}
 
-	// Initialize persistence for auto-save
+	// Initialize persistence for auto-save. A session encryption key that
+	// cannot be read disables it rather than saving sessions in the clear.
+	var opts []PersistenceOption
 	sessionPath, err := GetProjectSessionPath()
+	if err == nil && cfg != nil {
+		sessionCipher, cipherErr := NewSessionCipher(cfg.Session.Encryption)
+		switch {
+		case cipherErr != nil:
+			fmt.Fprintf(os.Stderr, "Warning: sessions will not be saved: session encryption key: %v\n", cipherErr)
+			err = cipherErr
+		case sessionCipher != nil:
+			opts = append(opts, WithCipher(sessionCipher))
+		}
+	}
 	if err == nil {
-		persistence, err := NewFilePersistence(sessionPath, true, 1*time.Minute)
+		persistence, err := NewFilePersistence(sessionPath, true, 1*time.Minute, opts...)
 		if err == nil {
 			handler.persistence = persistence
+			handler.registerOpen()
 		}
 	}
 
@@ -109,6 +139,11 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 		}, nil
 	}
 
+	// A sealed session stays as it is; the conversation continues in a fork
+	if _, err := h.ForkSealedSession(); err != nil {
+		return nil, err
+	}
+
 	// Get or create current session
 	currentSession := h.session.GetCurrent()
 	if currentSession == nil {
@@ -132,6 +167,9 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 	// Build messages for AI request
 	messages := h.buildMessages(currentSession)
 
+	// Attach the documentation related to the message
+	messages, sources := h.withDocs(ctx, input, messages)
+
 	// Create chat request with streaming enabled
 	req := ai.ChatRequest{
 		Model:           h.config.AI.Model,
@@ -139,11 +177,11 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 		Temperature:     &h.config.AI.Temperature,
 		MaxTokens:       &h.config.AI.MaxTokens,
 		Stream:          true, // Enable streaming
-		ReasoningEffort: h.config.AI.ReasoningEffort,
+		ReasoningEffort: h.requestReasoningEffort(),
 	}
 	
-	// Enable Structured Outputs if configured
-	if h.config.AI.UseStructuredOutputs {
+	// Enable Structured Outputs if configured and supported by the model
+	if h.useStructuredOutputs() {
 		req.ResponseFormat = &ai.ResponseFormat{
 			Type: "json_schema",
 			JSONSchema: &ai.JSONSchema{
@@ -162,14 +200,22 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 	}
 	defer stream.Close()
 
+	// Journal the response so a crash loses at most the last chunk
+	partial := h.beginPartialResponse(currentSession)
+	defer partial.Close()
+
 	// Process streaming response
-	var fullContent strings.Builder
 	var toolCalls []ai.ToolCall
 	var totalUsage ai.Usage
+	var fallbackModel string
 	
-	// Use structured output parser if enabled, otherwise use text parser
-	useStructuredOutputs := h.config.AI.UseStructuredOutputs
-	textParser := NewTextToolCallParser() // Still needed as fallback
+	// Use structured output parser if enabled, otherwise use text parser.
+	// The response is parsed for tool calls as it streams, debounced.
+	parser := NewStreamToolParser(h.useStructuredOutputs())
+
+	// Chunks are counted as they arrive rather than counting the whole
+	// response again with each one
+	tokenCounter := tokenizer.NewStreamCounter(h.config.AI.Model)
 
 	// Reset streaming tokens at start
 	h.streamingMutex.Lock()
@@ -207,53 +253,11 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 
 			// Handle content
 			if delta.Content != "" {
-				fullContent.WriteString(delta.Content)
-
-				// Parse based on mode
-				contentStr := fullContent.String()
-				
-				if useStructuredOutputs {
-					// Try to parse as structured JSON output
-					if toolResp, err := ParseStructuredOutput(contentStr); err == nil {
-						// Successfully parsed structured output
-						if len(toolResp.ToolCalls) > 0 {
-							// Convert structured tool calls to AI format
-							if aiToolCalls, err := ConvertToAIToolCalls(toolResp.ToolCalls); err == nil {
-								toolCalls = aiToolCalls
-							}
-						}
-					}
-				} else {
-					// Fallback to text-based parsing
-					_, parsedToolCalls, err := textParser.ParseMessage(contentStr)
-					if err == nil && len(parsedToolCalls) > 0 {
-						// Replace any existing tool calls with newly parsed ones
-						toolCalls = parsedToolCalls
-					}
-				}
-
-				// Calculate tokens for current content using tokenizer
-				estimatedTokens := 0
-
-				// Use tokenizer for accurate token counting
-				if len(contentStr) > 0 {
-					// Calculate tokens using the tokenizer package
-					tokens, err := tokenizer.EstimateUserMessageTokens(contentStr, h.config.AI.Model)
-					if err != nil {
-						// Fallback to simple estimation
-						runeCount := len([]rune(contentStr))
-						estimatedTokens = runeCount / 4
-					} else {
-						estimatedTokens = tokens
-					}
+				partial.Append(delta.Content)
+				toolCalls = parser.Add(delta.Content)
 
-					// Debug logging
-					debugFile, _ := os.OpenFile("/tmp/coda-debug.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-					if debugFile != nil {
-						fmt.Fprintf(debugFile, "[ChatHandler] Token estimation: contentLen=%d, estimatedTokens=%d, toolCalls=%d\n", len(contentStr), estimatedTokens, len(toolCalls))
-						debugFile.Close()
-					}
-				}
+				// Count the tokens of the chunk
+				estimatedTokens := tokenCounter.Add(delta.Content)
 
 				// Update ChatHandler's streaming tokens
 				h.streamingMutex.Lock()
@@ -264,7 +268,7 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 				debugFile, _ := os.OpenFile("/tmp/coda-debug.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 				if debugFile != nil {
 					fmt.Fprintf(debugFile, "[ChatHandler] Token update: chunk: %d, deltaContent: %q, totalLen: %d, tokens: %d\n",
-						chunkCount, delta.Content, fullContent.Len(), estimatedTokens)
+						chunkCount, delta.Content, len(parser.Content()), estimatedTokens)
 					debugFile.Close()
 				}
 
@@ -277,8 +281,13 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 			// Note: delta.ToolCalls will be empty since we're not using structured tool calling
 		}
 
-		// Note: Usage information is typically not available in streaming chunks
-		// It will be estimated after streaming completes
+		// Providers send usage in the final chunk when stream_options.include_usage is set
+		if chunk.Usage != nil {
+			totalUsage = *chunk.Usage
+		}
+		if chunk.FallbackModel != "" {
+			fallbackModel = chunk.FallbackModel
+		}
 	}
 
 	// Reset streaming tokens after streaming completes
@@ -296,8 +305,8 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 			responseDebug := map[string]interface{}{
 				"timestamp":       time.Now().Format(time.RFC3339),
 				"model":           h.config.AI.Model,
-				"full_content":    fullContent.String(),
-				"content_length":  fullContent.Len(),
+				"full_content":    parser.Content(),
+				"content_length":  len(parser.Content()),
 				"tool_calls_count": len(toolCalls),
 				"chunk_count":     chunkCount,
 				"usage": map[string]int{
@@ -331,34 +340,8 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 	}
 
 	// Parse final message based on mode
-	var cleanContent string
-	contentStr := fullContent.String()
-	
-	if useStructuredOutputs {
-		// Parse structured JSON output
-		if toolResp, err := ParseStructuredOutput(contentStr); err == nil {
-			// Successfully parsed structured output
-			if toolResp.Text != nil {
-				cleanContent = *toolResp.Text
-			}
-			if len(toolResp.ToolCalls) > 0 {
-				// Convert structured tool calls to AI format
-				if aiToolCalls, err := ConvertToAIToolCalls(toolResp.ToolCalls); err == nil {
-					toolCalls = aiToolCalls
-				}
-			}
-		} else {
-			// If parsing fails, use raw content
-			cleanContent = contentStr
-		}
-	} else {
-		// Use text parser for final extraction
-		parsedContent, finalToolCalls, _ := textParser.ParseMessage(contentStr)
-		cleanContent = parsedContent
-		if len(finalToolCalls) > 0 {
-			toolCalls = finalToolCalls
-		}
-	}
+	cleanContent, toolCalls := parser.Finish()
+	cleanContent = h.postProcess.Apply(cleanContent)
 
 	// Create final message
 	message := ai.Message{
@@ -375,9 +358,11 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 	// Auto-save session after each message
 	if h.persistence != nil {
 		if session := h.session.GetCurrent(); session != nil {
-			if err := h.persistence.SaveSession(session); err != nil {
-				// Log error but don't fail the operation
-				// In TUI mode, we should handle this differently
+			// Failures are reported by SaveError rather than failing the message
+			err := h.persistence.SaveSession(session)
+			h.recordSave(session, err)
+			if err == nil {
+				partial.Commit()
 			}
 		}
 	}
@@ -390,12 +375,13 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 	}
 
 	// If usage wasn't provided in stream, estimate it
-	if totalUsage.TotalTokens == 0 {
+	usageEstimated := totalUsage.TotalTokens == 0
+	if usageEstimated {
 		// Use tokenizer for accurate token counting
-		tokens, err := tokenizer.EstimateUserMessageTokens(fullContent.String(), h.config.AI.Model)
+		tokens, err := tokenizer.EstimateUserMessageTokens(parser.Content(), h.config.AI.Model)
 		if err != nil {
 			// Fallback to simple estimation
-			totalUsage.CompletionTokens = fullContent.Len() / 4
+			totalUsage.CompletionTokens = len(parser.Content()) / 4
 		} else {
 			totalUsage.CompletionTokens = tokens
 		}
@@ -403,10 +389,13 @@ func (h *ChatHandler) HandleMessageWithResponse(ctx context.Context, input strin
 	}
 
 	return &ChatResponse{
-		Content:    message.Content,
-		TokenCount: totalUsage.TotalTokens,
-		ToolCalls:  toolCalls,
-		TokenUsage: &totalUsage,
+		Content:        message.Content,
+		TokenCount:     totalUsage.TotalTokens,
+		ToolCalls:      toolCalls,
+		TokenUsage:     &totalUsage,
+		UsageEstimated: usageEstimated,
+		FallbackModel:  fallbackModel,
+		Sources:        sources,
 		// EstimatedPrompt will be set by the UI layer using tiktoken
 	}, nil
 }
@@ -559,6 +548,12 @@ func (h *ChatHandler) AddMessageToSession(message ai.Message) error {
 	return h.session.AddMessage(currentSession.ID, message)
 }
 
+// AIClient returns the AI client used by the handler, for one-off requests
+// that should not be recorded in the session
+func (h *ChatHandler) AIClient() ai.Client {
+	return h.aiClient
+}
+
 // GetCurrentSession returns the current session
 func (h *ChatHandler) GetCurrentSession() *Session {
 	return h.session.GetCurrent()
@@ -579,6 +574,142 @@ func (h *ChatHandler) CreateNewSession() error {
 	return nil
 }
 
+// ForkSession forks the current session into a new one containing the first
+// upTo visible messages (all messages when upTo <= 0) and makes the fork
+// current. System messages before the cut are kept without being counted.
+func (h *ChatHandler) ForkSession(upTo int) (*Session, error) {
+	currentSession := h.session.GetCurrent()
+	if currentSession == nil {
+		return nil, fmt.Errorf("no active session")
+	}
+
+	fork, err := h.session.ForkSession(currentSession.ID, storedMessageCount(currentSession.Messages, upTo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork session: %w", err)
+	}
+
+	if err := h.session.SetCurrent(fork.ID); err != nil {
+		return nil, fmt.Errorf("failed to set current session: %w", err)
+	}
+
+	// Persist the fork right away so it survives a restart even before the next message
+	if h.persistence != nil {
+		h.recordSave(fork, h.persistence.SaveSession(fork))
+	}
+
+	return fork, nil
+}
+
+// storedMessageCount returns how many stored messages hold the first visible
+// ones. System messages are not shown, so they are not counted.
+func storedMessageCount(messages []ai.Message, visible int) int {
+	if visible <= 0 {
+		return 0
+	}
+	for i, msg := range messages {
+		if msg.Role != ai.RoleSystem {
+			if visible--; visible == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(messages)
+}
+
+// ResumeSession loads a saved session (the most recent one when id is empty)
+// and makes it current. The session is locked for this instance unless
+// readOnly is set; a session open in another instance fails with
+// ErrSessionLocked. Read-only sessions are never saved.
+func (h *ChatHandler) ResumeSession(id string, readOnly bool) (*Session, error) {
+	if h.persistence == nil {
+		return nil, fmt.Errorf("session persistence is not available")
+	}
+
+	if id == "" {
+		// Corrupted sessions are quarantined; fall back to the next most recent
+		for attempt := 0; ; attempt++ {
+			latest, err := h.persistence.LatestSessionID()
+			if err != nil {
+				return nil, err
+			}
+			if latest == "" {
+				return nil, fmt.Errorf("no saved sessions for this project")
+			}
+			session, err := h.ResumeSession(latest, readOnly)
+			if err == nil || !errors.Is(err, ErrSessionCorrupted) || attempt >= 2 {
+				return session, err
+			}
+		}
+	}
+
+	if readOnly {
+		h.persistence.SetReadOnly(id, true)
+	} else if err := h.persistence.LockSession(id); err != nil {
+		return nil, err
+	}
+
+	session, err := h.persistence.LoadSession(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+
+	h.session.AddSession(session)
+	if err := h.session.SetCurrent(session.ID); err != nil {
+		return nil, fmt.Errorf("failed to set current session: %w", err)
+	}
+	h.recoverPartialResponse(session)
+	return session, nil
+}
+
+// IsSessionReadOnly reports whether the current session was opened read-only
+func (h *ChatHandler) IsSessionReadOnly() bool {
+	current := h.session.GetCurrent()
+	return current != nil && h.persistence != nil && h.persistence.IsReadOnly(current.ID)
+}
+
+// Close saves the current session and releases the session locks held by
+// this handler
+func (h *ChatHandler) Close() error {
+	if h.persistence == nil {
+		return nil
+	}
+	h.unregisterOpen()
+	saveErr := h.SaveCurrentSession()
+	return errors.Join(saveErr, h.persistence.Close())
+}
+
+// Fork returns a handler for an independent conversation that shares the AI
+// client, tools and history of h. cfg may select a different model; nil keeps
+// the configuration of h.
+func (h *ChatHandler) Fork(cfg *config.Config) *ChatHandler {
+	if cfg == nil {
+		cfg = h.config
+	}
+	session := NewSessionManager(h.session.maxAge, h.session.maxTokens)
+	fork := NewChatHandler(h.aiClient, h.toolManager, h.mcpManager, session, cfg, h.history)
+	fork.promptBuilder = h.promptBuilder.Clone()
+	fork.persona = h.persona
+	fork.docs = h.docs
+	fork.postProcess = h.postProcess
+	if h.reasoningEffort != nil && ai.ValidateReasoningEffort(cfg.AI.Model, *h.reasoningEffort) == nil {
+		fork.reasoningEffort = h.reasoningEffort
+	}
+	return fork
+}
+
+// SwitchSession makes the session with the given ID current
+func (h *ChatHandler) SwitchSession(id string) error {
+	if err := h.session.SetCurrent(id); err != nil {
+		return fmt.Errorf("failed to switch session: %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns all sessions ordered by most recent activity
+func (h *ChatHandler) ListSessions() []*Session {
+	return h.session.ListSessionsByActivity()
+}
+
 // ContinueConversation continues the conversation without adding a new user message
 // This is used after tool execution results have been added to the session
 func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback func(int)) (*ChatResponse, error) {
@@ -598,11 +729,11 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 		Temperature:     &h.config.AI.Temperature,
 		MaxTokens:       &h.config.AI.MaxTokens,
 		Stream:          true, // Enable streaming
-		ReasoningEffort: h.config.AI.ReasoningEffort,
+		ReasoningEffort: h.requestReasoningEffort(),
 	}
 	
-	// Enable Structured Outputs if configured
-	if h.config.AI.UseStructuredOutputs {
+	// Enable Structured Outputs if configured and supported by the model
+	if h.useStructuredOutputs() {
 		req.ResponseFormat = &ai.ResponseFormat{
 			Type: "json_schema",
 			JSONSchema: &ai.JSONSchema{
@@ -621,14 +752,22 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 	}
 	defer stream.Close()
 
+	// Journal the response so a crash loses at most the last chunk
+	partial := h.beginPartialResponse(currentSession)
+	defer partial.Close()
+
 	// Process streaming response
-	var fullContent strings.Builder
 	var toolCalls []ai.ToolCall
 	var totalUsage ai.Usage
+	var fallbackModel string
 	
-	// Use structured output parser if enabled, otherwise use text parser
-	useStructuredOutputs := h.config.AI.UseStructuredOutputs
-	textParser := NewTextToolCallParser() // Still needed as fallback
+	// Use structured output parser if enabled, otherwise use text parser.
+	// The response is parsed for tool calls as it streams, debounced.
+	parser := NewStreamToolParser(h.useStructuredOutputs())
+
+	// Chunks are counted as they arrive rather than counting the whole
+	// response again with each one
+	tokenCounter := tokenizer.NewStreamCounter(h.config.AI.Model)
 
 	// Reset streaming tokens at start
 	h.streamingMutex.Lock()
@@ -653,46 +792,11 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 
 			// Handle content
 			if delta.Content != "" {
-				fullContent.WriteString(delta.Content)
-
-				// Parse based on mode
-				contentStr := fullContent.String()
-				
-				if useStructuredOutputs {
-					// Try to parse as structured JSON output
-					if toolResp, err := ParseStructuredOutput(contentStr); err == nil {
-						// Successfully parsed structured output
-						if len(toolResp.ToolCalls) > 0 {
-							// Convert structured tool calls to AI format
-							if aiToolCalls, err := ConvertToAIToolCalls(toolResp.ToolCalls); err == nil {
-								toolCalls = aiToolCalls
-							}
-						}
-					}
-				} else {
-					// Fallback to text-based parsing
-					_, parsedToolCalls, err := textParser.ParseMessage(contentStr)
-					if err == nil && len(parsedToolCalls) > 0 {
-						// Replace any existing tool calls with newly parsed ones
-						toolCalls = parsedToolCalls
-					}
-				}
+				partial.Append(delta.Content)
+				toolCalls = parser.Add(delta.Content)
 
-				// Calculate tokens for current content using tokenizer
-				estimatedTokens := 0
-
-				// Use tokenizer for accurate token counting
-				if len(contentStr) > 0 {
-					// Calculate tokens using the tokenizer package
-					tokens, err := tokenizer.EstimateUserMessageTokens(contentStr, h.config.AI.Model)
-					if err != nil {
-						// Fallback to simple estimation
-						runeCount := len([]rune(contentStr))
-						estimatedTokens = runeCount / 4
-					} else {
-						estimatedTokens = tokens
-					}
-				}
+				// Count the tokens of the chunk
+				estimatedTokens := tokenCounter.Add(delta.Content)
 
 				// Update ChatHandler's streaming tokens
 				h.streamingMutex.Lock()
@@ -707,6 +811,14 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 
 			// Note: delta.ToolCalls will be empty since we're not using structured tool calling
 		}
+
+		// Providers send usage in the final chunk when stream_options.include_usage is set
+		if chunk.Usage != nil {
+			totalUsage = *chunk.Usage
+		}
+		if chunk.FallbackModel != "" {
+			fallbackModel = chunk.FallbackModel
+		}
 	}
 
 	// Reset streaming tokens after streaming completes
@@ -724,8 +836,8 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 			responseDebug := map[string]interface{}{
 				"timestamp":       time.Now().Format(time.RFC3339),
 				"model":           h.config.AI.Model,
-				"full_content":    fullContent.String(),
-				"content_length":  fullContent.Len(),
+				"full_content":    parser.Content(),
+				"content_length":  len(parser.Content()),
 				"tool_calls_count": len(toolCalls),
 				"chunk_count":     chunkCount,
 				"usage": map[string]int{
@@ -759,34 +871,8 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 	}
 
 	// Parse final message based on mode
-	var cleanContent string
-	contentStr := fullContent.String()
-	
-	if useStructuredOutputs {
-		// Parse structured JSON output
-		if toolResp, err := ParseStructuredOutput(contentStr); err == nil {
-			// Successfully parsed structured output
-			if toolResp.Text != nil {
-				cleanContent = *toolResp.Text
-			}
-			if len(toolResp.ToolCalls) > 0 {
-				// Convert structured tool calls to AI format
-				if aiToolCalls, err := ConvertToAIToolCalls(toolResp.ToolCalls); err == nil {
-					toolCalls = aiToolCalls
-				}
-			}
-		} else {
-			// If parsing fails, use raw content
-			cleanContent = contentStr
-		}
-	} else {
-		// Use text parser for final extraction
-		parsedContent, finalToolCalls, _ := textParser.ParseMessage(contentStr)
-		cleanContent = parsedContent
-		if len(finalToolCalls) > 0 {
-			toolCalls = finalToolCalls
-		}
-	}
+	cleanContent, toolCalls := parser.Finish()
+	cleanContent = h.postProcess.Apply(cleanContent)
 
 	// Create final message
 	message := ai.Message{
@@ -803,8 +889,11 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 	// Auto-save session after each message
 	if h.persistence != nil {
 		if session := h.session.GetCurrent(); session != nil {
-			if err := h.persistence.SaveSession(session); err != nil {
-				// Log error but don't fail the operation
+			// Failures are reported by SaveError rather than failing the message
+			err := h.persistence.SaveSession(session)
+			h.recordSave(session, err)
+			if err == nil {
+				partial.Commit()
 			}
 		}
 	}
@@ -817,12 +906,13 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 	}
 
 	// If usage wasn't provided in stream, estimate it
-	if totalUsage.TotalTokens == 0 {
+	usageEstimated := totalUsage.TotalTokens == 0
+	if usageEstimated {
 		// Use tokenizer for accurate token counting
-		tokens, err := tokenizer.EstimateUserMessageTokens(fullContent.String(), h.config.AI.Model)
+		tokens, err := tokenizer.EstimateUserMessageTokens(parser.Content(), h.config.AI.Model)
 		if err != nil {
 			// Fallback to simple estimation
-			totalUsage.CompletionTokens = fullContent.Len() / 4
+			totalUsage.CompletionTokens = len(parser.Content()) / 4
 		} else {
 			totalUsage.CompletionTokens = tokens
 		}
@@ -830,10 +920,12 @@ func (h *ChatHandler) ContinueConversation(ctx context.Context, tokenCallback fu
 	}
 
 	return &ChatResponse{
-		Content:    message.Content,
-		TokenCount: totalUsage.TotalTokens,
-		ToolCalls:  toolCalls,
-		TokenUsage: &totalUsage,
+		Content:        message.Content,
+		TokenCount:     totalUsage.TotalTokens,
+		ToolCalls:      toolCalls,
+		TokenUsage:     &totalUsage,
+		UsageEstimated: usageEstimated,
+		FallbackModel:  fallbackModel,
 	}, nil
 }
 
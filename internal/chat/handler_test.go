@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestSessionManagerForkSession(t *testing.T) {
+	sm := NewSessionManager(time.Hour, 100000)
+	id, err := sm.CreateSession()
+	assert.NoError(t, err)
+
+	for _, content := range []string{"one", "two", "three"} {
+		assert.NoError(t, sm.AddMessage(id, ai.Message{Role: ai.RoleUser, Content: content}))
+	}
+
+	tests := []struct {
+		name     string
+		upTo     int
+		expected int
+	}{
+		{"all messages", 0, 3},
+		{"prefix", 2, 2},
+		{"beyond end", 10, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fork, err := sm.ForkSession(id, tt.upTo)
+			assert.NoError(t, err)
+			assert.NotEqual(t, id, fork.ID)
+			assert.Len(t, fork.Messages, tt.expected)
+			assert.Equal(t, id, fork.Context["forked_from"])
+
+			// The original session must be untouched
+			original, err := sm.GetMessages(id)
+			assert.NoError(t, err)
+			assert.Len(t, original, 3)
+		})
+	}
+
+	_, err = sm.ForkSession("missing", 0)
+	assert.Error(t, err)
+}
+
+func TestChatHandlerForkSessionCountsVisibleMessages(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	handler := NewChatHandler(nil, nil, nil, NewSessionManager(time.Hour, 100000), config.NewDefaultConfig(), nil)
+	defer handler.Close()
+	require.NoError(t, handler.CreateNewSession())
+
+	for _, msg := range []ai.Message{
+		{Role: ai.RoleSystem, Content: "system prompt"},
+		{Role: ai.RoleUser, Content: "one"},
+		{Role: ai.RoleAssistant, Content: "two"},
+		{Role: ai.RoleUser, Content: "three"},
+	} {
+		require.NoError(t, handler.AddMessageToSession(msg))
+	}
+
+	fork, err := handler.ForkSession(2)
+	require.NoError(t, err)
+	require.Len(t, fork.Messages, 3, "the system prompt is kept but not counted")
+	assert.Equal(t, ai.RoleSystem, fork.Messages[0].Role)
+	assert.Equal(t, "two", fork.Messages[2].Content)
+}
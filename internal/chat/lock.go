@@ -0,0 +1,221 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSessionLocked is returned when another CODA instance has the session open
+var ErrSessionLocked = errors.New("session is open in another CODA instance")
+
+// LockInfo identifies the process holding a session lock
+type LockInfo struct {
+	PID        int       `json:"pid"`
+	Hostname   string    `json:"hostname"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// SessionLockedError reports which process holds a session lock
+type SessionLockedError struct {
+	SessionID string
+	Holder    LockInfo
+}
+
+func (e *SessionLockedError) Error() string {
+	return fmt.Sprintf("session %s is open in another CODA instance (pid %d on %s since %s)",
+		e.SessionID, e.Holder.PID, e.Holder.Hostname, e.Holder.AcquiredAt.Format(time.RFC3339))
+}
+
+func (e *SessionLockedError) Unwrap() error {
+	return ErrSessionLocked
+}
+
+// SessionLock is an exclusive lockfile for one session. Locks taken on the
+// same file within this process share it; the file is removed when the last
+// of them is released.
+type SessionLock struct {
+	path     string
+	released bool
+}
+
+// heldLocks counts the SessionLocks of this process by lockfile path
+var heldLocks = struct {
+	sync.Mutex
+	refs map[string]int
+}{refs: make(map[string]int)}
+
+// AcquireSessionLock creates the lockfile for a session in dir. Locks left
+// behind by processes that are no longer running on this host are taken over.
+// A lock this process already holds is shared with the new holder.
+func AcquireSessionLock(dir, id string) (*SessionLock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.lock", id))
+
+	heldLocks.Lock()
+	defer heldLocks.Unlock()
+	if heldLocks.refs[path] > 0 {
+		heldLocks.refs[path]++
+		return &SessionLock{path: path}, nil
+	}
+
+	hostname, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Hostname: hostname, AcquiredAt: time.Now()}
+
+	// A second attempt is made after removing a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		err := createLockFile(path, info)
+		if err == nil {
+			heldLocks.refs[path] = 1
+			return &SessionLock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock: %w", err)
+		}
+
+		holder, err := readLockInfo(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			// Lock files are written in full before they appear, so an
+			// unreadable one is not evidence that its holder has exited
+			return nil, fmt.Errorf("%w: %v; remove it if no other CODA instance is running", ErrSessionLocked, err)
+		}
+		if holder.Hostname == hostname && holder.PID == info.PID {
+			// Left behind by this process without a SessionLock
+			heldLocks.refs[path] = 1
+			return &SessionLock{path: path}, nil
+		}
+		if !lockIsStale(holder, hostname) {
+			return nil, &SessionLockedError{SessionID: id, Holder: *holder}
+		}
+		if err := removeStaleLock(path, holder); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("failed to acquire lock for session %s", id)
+}
+
+// createLockFile writes info to a temp file and links it to path, so the
+// lock never exists without its contents. It fails with an os.ErrExist error
+// when path already exists.
+func createLockFile(path string, info LockInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to encode lock: %w", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	_, writeErr := temp.Write(data)
+	closeErr := temp.Close()
+	if writeErr != nil || closeErr != nil {
+		return fmt.Errorf("failed to write lock: %w", errors.Join(writeErr, closeErr))
+	}
+
+	if err := os.Link(temp.Name(), path); err != nil {
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) && os.IsExist(linkErr.Err) {
+			return linkErr.Err
+		}
+		return err
+	}
+	return nil
+}
+
+// removeStaleLock removes a lock whose holder has exited. The lock is moved
+// aside first and put back if another process replaced it in the meantime.
+func removeStaleLock(path string, stale *LockInfo) error {
+	aside := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock: %w", err)
+	}
+	defer os.Remove(aside)
+
+	moved, err := readLockInfo(aside)
+	if err == nil && (moved.PID != stale.PID || moved.Hostname != stale.Hostname || !moved.AcquiredAt.Equal(stale.AcquiredAt)) {
+		// A live lock was taken after the stale one was read
+		os.Link(aside, path)
+		return &SessionLockedError{SessionID: strings.TrimSuffix(filepath.Base(path), ".lock"), Holder: *moved}
+	}
+	return nil
+}
+
+// ReadSessionLock returns the holder of a session lock, or nil when the
+// session is not locked by a running process
+func ReadSessionLock(dir, id string) (*LockInfo, error) {
+	holder, err := readLockInfo(filepath.Join(dir, fmt.Sprintf("%s.lock", id)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	if lockIsStale(holder, hostname) {
+		return nil, nil
+	}
+	return holder, nil
+}
+
+// Release gives up this hold on the lock, removing the lockfile once no
+// other lock of this process shares it. Releasing twice has no effect.
+func (l *SessionLock) Release() error {
+	heldLocks.Lock()
+	defer heldLocks.Unlock()
+	if l.released {
+		return nil
+	}
+	l.released = true
+
+	heldLocks.refs[l.path]--
+	if heldLocks.refs[l.path] > 0 {
+		return nil
+	}
+	delete(heldLocks.refs, l.path)
+
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release session lock: %w", err)
+	}
+	return nil
+}
+
+func readLockInfo(path string) (*LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse lock %s: %w", path, err)
+	}
+	return &info, nil
+}
+
+// lockIsStale reports whether the holder is known to have exited. Locks held
+// on other hosts cannot be checked and are respected.
+func lockIsStale(holder *LockInfo, hostname string) bool {
+	if holder.Hostname != hostname {
+		return false
+	}
+	return holder.PID <= 0 || !processAlive(holder.PID)
+}
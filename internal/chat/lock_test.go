@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLockSharedWithinProcess(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.lock")
+
+	first, err := AcquireSessionLock(dir, "s1")
+	require.NoError(t, err)
+	second, err := AcquireSessionLock(dir, "s1")
+	require.NoError(t, err)
+
+	require.NoError(t, first.Release())
+	require.NoError(t, first.Release())
+	assert.FileExists(t, path, "the second holder still relies on the lock")
+
+	require.NoError(t, second.Release())
+	assert.NoFileExists(t, path)
+}
+
+func TestSessionLockUnreadableIsHeld(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.lock")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+
+	_, err := AcquireSessionLock(dir, "s1")
+
+	assert.ErrorIs(t, err, ErrSessionLocked)
+	assert.FileExists(t, path)
+}
+
+func TestSessionLockTakesOverStaleLock(t *testing.T) {
+	dir := t.TempDir()
+	hostname, _ := os.Hostname()
+	data, err := json.Marshal(LockInfo{PID: -1, Hostname: hostname, AcquiredAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "s1.lock"), data, 0644))
+
+	lock, err := AcquireSessionLock(dir, "s1")
+	require.NoError(t, err)
+	defer lock.Release()
+
+	holder, err := ReadSessionLock(dir, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.Equal(t, os.Getpid(), holder.PID)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp or stale files are left behind")
+}
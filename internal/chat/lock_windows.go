@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package chat
+
+import "os"
+
+// processAlive reports whether a process with the given PID is running.
+// FindProcess opens a handle to the process on Windows and fails if it exited.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
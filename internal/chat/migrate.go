@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SessionSchemaVersion is the version of the session file format written by
+// this build. Files without a schema_version field are version 1.
+const SessionSchemaVersion = 2
+
+var (
+	// ErrSessionCorrupted is returned when a saved session cannot be read
+	ErrSessionCorrupted = errors.New("session file is corrupted")
+
+	// ErrSessionTooNew is returned for sessions saved by a newer CODA
+	ErrSessionTooNew = errors.New("session was saved by a newer version of CODA")
+)
+
+// SessionMigration upgrades a decoded session document by one schema version
+type SessionMigration func(doc map[string]interface{}) error
+
+var (
+	migrationsMu      sync.RWMutex
+	sessionMigrations = map[int]SessionMigration{
+		1: migrateSessionV1,
+	}
+)
+
+// RegisterSessionMigration installs the migration from version to version+1
+func RegisterSessionMigration(from int, migration SessionMigration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	sessionMigrations[from] = migration
+}
+
+// decodeSession parses a session file, migrating older schema versions
+func decodeSession(data []byte) (*Session, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("failed to decode session: empty document")
+	}
+
+	version := 1
+	if v, ok := doc["schema_version"].(float64); ok {
+		version = int(v)
+	}
+	if version > SessionSchemaVersion {
+		return nil, fmt.Errorf("schema version %d: %w", version, ErrSessionTooNew)
+	}
+
+	if version < SessionSchemaVersion {
+		if err := migrateSession(doc, version); err != nil {
+			return nil, err
+		}
+		migrated, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode migrated session: %w", err)
+		}
+		data = migrated
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	if session.ID == "" {
+		return nil, fmt.Errorf("failed to decode session: missing id")
+	}
+	return &session, nil
+}
+
+// migrateSession applies the migrations from version up to the current one
+func migrateSession(doc map[string]interface{}, version int) error {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	for v := version; v < SessionSchemaVersion; v++ {
+		migration, ok := sessionMigrations[v]
+		if !ok {
+			return fmt.Errorf("no migration from session schema version %d", v)
+		}
+		if err := migration(doc); err != nil {
+			return fmt.Errorf("failed to migrate session from schema version %d: %w", v, err)
+		}
+		doc["schema_version"] = v + 1
+	}
+	return nil
+}
+
+// migrateSessionV1 upgrades files written before schema versioning, which
+// could contain null messages and context
+func migrateSessionV1(doc map[string]interface{}) error {
+	if doc["messages"] == nil {
+		doc["messages"] = []interface{}{}
+	}
+	if doc["context"] == nil {
+		doc["context"] = map[string]interface{}{}
+	}
+	return nil
+}
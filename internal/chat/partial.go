@@ -0,0 +1,315 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/sessioncrypt"
+)
+
+// InterruptedContextKey marks a session whose last assistant response was
+// cut off by a crash and recovered from its partial response journal
+const InterruptedContextKey = "interrupted_response"
+
+// interruptedMarker is appended to recovered responses in the session
+const interruptedMarker = "\n\n[response interrupted]"
+
+// continuePrompt asks the model to finish an interrupted response
+const continuePrompt = "Your previous response was interrupted before it finished. Continue it from exactly where it stopped, without repeating what you already wrote."
+
+// PartialResponse is an assistant response recovered from the journal
+type PartialResponse struct {
+	SessionID string
+	StartedAt time.Time
+	Content   string
+
+	// MessageCount is the number of session messages when the response began
+	MessageCount int
+}
+
+// partialHeader is the first line of a journal; each following line holds
+// one streamed chunk
+type partialHeader struct {
+	SessionID    string    `json:"session_id"`
+	StartedAt    time.Time `json:"started_at"`
+	MessageCount int       `json:"message_count"`
+}
+
+type partialChunk struct {
+	Delta string `json:"d"`
+}
+
+// PartialResponseWriter journals a streaming response chunk by chunk so that
+// a crash loses at most the chunk being written. A nil writer does nothing.
+type PartialResponseWriter struct {
+	file   *os.File
+	path   string
+	cipher *sessioncrypt.Cipher
+}
+
+// BeginPartialResponse starts the journal of a new response for a session
+// holding messageCount messages, replacing any previous journal
+func (fp *FilePersistence) BeginPartialResponse(sessionID string, messageCount int) (*PartialResponseWriter, error) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	if fp.readOnly[sessionID] {
+		return nil, ErrSessionReadOnly
+	}
+
+	dir := filepath.Join(fp.basePath, "partial")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create partial response directory: %w", err)
+	}
+
+	path := fp.partialPath(sessionID)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create partial response journal: %w", err)
+	}
+
+	w := &PartialResponseWriter{file: file, path: path, cipher: fp.cipher}
+	header := partialHeader{SessionID: sessionID, StartedAt: time.Now(), MessageCount: messageCount}
+	if err := w.writeLine(header); err != nil {
+		w.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Append records a streamed chunk
+func (w *PartialResponseWriter) Append(delta string) error {
+	if w == nil || w.file == nil || delta == "" {
+		return nil
+	}
+	return w.writeLine(partialChunk{Delta: delta})
+}
+
+// Close stops journaling and keeps the journal for recovery
+func (w *PartialResponseWriter) Close() {
+	if w == nil || w.file == nil {
+		return
+	}
+	w.file.Close()
+	w.file = nil
+}
+
+// Commit removes the journal once the full response has been saved
+func (w *PartialResponseWriter) Commit() error {
+	if w == nil {
+		return nil
+	}
+	w.Close()
+	if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove partial response journal: %w", err)
+	}
+	return nil
+}
+
+func (w *PartialResponseWriter) writeLine(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode partial response: %w", err)
+	}
+	if data, err = sealLine(w.cipher, data); err != nil {
+		return fmt.Errorf("failed to encrypt partial response: %w", err)
+	}
+	if _, err := w.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write partial response: %w", err)
+	}
+	return nil
+}
+
+// LoadPartialResponse returns the journaled response of a session, or nil
+// when there is none. A truncated last line is ignored.
+func (fp *FilePersistence) LoadPartialResponse(sessionID string) (*PartialResponse, error) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+
+	file, err := os.Open(fp.partialPath(sessionID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open partial response journal: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		return nil, nil
+	}
+	line, err := fp.openLine(scanner.Bytes())
+	if err != nil {
+		if isKeyError(err) {
+			return nil, fmt.Errorf("partial response of session %s: %w", sessionID, err)
+		}
+		return nil, nil
+	}
+	var header partialHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, nil
+	}
+
+	var content strings.Builder
+	for scanner.Scan() {
+		line, err := fp.openLine(scanner.Bytes())
+		if err != nil {
+			break
+		}
+		var chunk partialChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			break
+		}
+		content.WriteString(chunk.Delta)
+	}
+
+	return &PartialResponse{
+		SessionID:    sessionID,
+		StartedAt:    header.StartedAt,
+		Content:      content.String(),
+		MessageCount: header.MessageCount,
+	}, nil
+}
+
+// DiscardPartialResponse removes the journal of a session
+func (fp *FilePersistence) DiscardPartialResponse(sessionID string) error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	if err := os.Remove(fp.partialPath(sessionID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove partial response journal: %w", err)
+	}
+	return nil
+}
+
+// InterruptedSessions returns the sessions with a journaled response
+func (fp *FilePersistence) InterruptedSessions() ([]string, error) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(fp.basePath, "partial"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read partial response directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".jsonl" {
+			ids = append(ids, strings.TrimSuffix(entry.Name(), ".jsonl"))
+		}
+	}
+	return ids, nil
+}
+
+func (fp *FilePersistence) partialPath(sessionID string) string {
+	return filepath.Join(fp.basePath, "partial", fmt.Sprintf("%s.jsonl", sessionID))
+}
+
+// beginPartialResponse saves the session, so the prompt survives a crash,
+// and starts journaling the response. It returns nil when persistence is
+// unavailable.
+func (h *ChatHandler) beginPartialResponse(session *Session) *PartialResponseWriter {
+	if h.persistence == nil || session == nil || h.persistence.IsReadOnly(session.ID) {
+		return nil
+	}
+	if err := h.persistence.SaveSession(session); err != nil {
+		return nil
+	}
+	w, err := h.persistence.BeginPartialResponse(session.ID, len(session.Messages))
+	if err != nil {
+		return nil
+	}
+	return w
+}
+
+// recoverPartialResponse adds a response cut off by a crash to the session
+// and marks the session as interrupted
+func (h *ChatHandler) recoverPartialResponse(session *Session) {
+	if h.persistence == nil || h.persistence.IsReadOnly(session.ID) {
+		return
+	}
+
+	partial, err := h.persistence.LoadPartialResponse(session.ID)
+	if err != nil || partial == nil {
+		return
+	}
+
+	// A journal left behind after the full response was saved is obsolete
+	if strings.TrimSpace(partial.Content) != "" && partial.MessageCount == len(session.Messages) {
+		// Incomplete tool calls cannot be executed; keep only the text
+		content, _, _ := NewTextToolCallParser().ParseMessage(partial.Content)
+		if strings.TrimSpace(content) == "" {
+			content = partial.Content
+		}
+		h.session.AddMessage(session.ID, ai.Message{
+			Role:    ai.RoleAssistant,
+			Content: content + interruptedMarker,
+		})
+		h.session.SetContext(session.ID, InterruptedContextKey, true)
+		if err := h.persistence.SaveSession(session); err != nil {
+			return
+		}
+	}
+
+	h.persistence.DiscardPartialResponse(session.ID)
+}
+
+// HasInterruptedResponse reports whether the current session ends with a
+// response recovered after a crash
+func (h *ChatHandler) HasInterruptedResponse() bool {
+	current := h.session.GetCurrent()
+	if current == nil {
+		return false
+	}
+	interrupted, _ := h.session.GetContext(current.ID, InterruptedContextKey)
+	flag, _ := interrupted.(bool)
+	return flag
+}
+
+// ContinueInterruptedResponse asks the model to finish the response that was
+// interrupted in the current session
+func (h *ChatHandler) ContinueInterruptedResponse(ctx context.Context, tokenCallback func(int)) (*ChatResponse, error) {
+	current := h.session.GetCurrent()
+	if current == nil {
+		return nil, fmt.Errorf("no active session")
+	}
+	if !h.HasInterruptedResponse() {
+		return nil, fmt.Errorf("the current session has no interrupted response")
+	}
+
+	h.session.SetContext(current.ID, InterruptedContextKey, false)
+	return h.HandleMessageWithResponse(ctx, continuePrompt, tokenCallback)
+}
+
+// LatestSessionInterrupted reports whether the most recently saved session
+// has a response that was cut off by a crash
+func (h *ChatHandler) LatestSessionInterrupted() bool {
+	if h.persistence == nil {
+		return false
+	}
+	latest, err := h.persistence.LatestSessionID()
+	if err != nil || latest == "" {
+		return false
+	}
+	ids, _ := h.persistence.InterruptedSessions()
+	for _, id := range ids {
+		if id == latest {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/common-creation/coda/internal/sessioncrypt"
 )
 
 // Persistence interface defines methods for session persistence
@@ -21,23 +24,32 @@ type Persistence interface {
 	DeleteSession(id string) error
 }
 
-// FilePersistence implements file-based session persistence
+// ErrSessionReadOnly is returned when saving a session opened read-only
+var ErrSessionReadOnly = errors.New("session is open read-only")
+
+// FilePersistence implements file-based session persistence.
+// Saving a session takes its lockfile so that other instances cannot write
+// the same session; locks are released by Close.
 type FilePersistence struct {
 	basePath     string
 	mu           sync.RWMutex
 	autoSave     bool
 	saveInterval time.Duration
+	locks        map[string]*SessionLock
+	readOnly     map[string]bool
+	sealed       map[string]bool
+	cipher       *sessioncrypt.Cipher
 }
 
 // NewFilePersistence creates a new file-based persistence manager
-func NewFilePersistence(basePath string, autoSave bool, saveInterval time.Duration) (*FilePersistence, error) {
+func NewFilePersistence(basePath string, autoSave bool, saveInterval time.Duration, opts ...PersistenceOption) (*FilePersistence, error) {
 	// Create base directory if it doesn't exist
 	if err := os.MkdirAll(basePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create persistence directory: %w", err)
 	}
 
 	// Create subdirectories
-	dirs := []string{"sessions", "metadata", "backup", "temp"}
+	dirs := []string{"sessions", "metadata", "backup", "temp", "locks", "quarantine", "partial"}
 	for _, dir := range dirs {
 		path := filepath.Join(basePath, dir)
 		if err := os.MkdirAll(path, 0755); err != nil {
@@ -45,66 +57,116 @@ func NewFilePersistence(basePath string, autoSave bool, saveInterval time.Durati
 		}
 	}
 
-	return &FilePersistence{
+	fp := &FilePersistence{
 		basePath:     basePath,
 		autoSave:     autoSave,
 		saveInterval: saveInterval,
-	}, nil
+		locks:        make(map[string]*SessionLock),
+		readOnly:     make(map[string]bool),
+		sealed:       make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(fp)
+	}
+	return fp, nil
 }
 
-// SaveSession saves a session to persistent storage
-func (fp *FilePersistence) SaveSession(session *Session) error {
+// LockSession takes the lock of a session for this instance. It fails with
+// a *SessionLockedError when another running instance holds it.
+func (fp *FilePersistence) LockSession(id string) error {
 	fp.mu.Lock()
 	defer fp.mu.Unlock()
+	return fp.lockSession(id)
+}
 
-	// Validate session
-	if session == nil || session.ID == "" {
-		return fmt.Errorf("invalid session")
+func (fp *FilePersistence) lockSession(id string) error {
+	if _, held := fp.locks[id]; held {
+		return nil
 	}
-
-	// Save to temp file first (atomic write)
-	tempPath := filepath.Join(fp.basePath, "temp", fmt.Sprintf("%s.tmp", session.ID))
-	finalPath := filepath.Join(fp.basePath, "sessions", fmt.Sprintf("%s.json", session.ID))
-
-	// Create temp file
-	tempFile, err := os.Create(tempPath)
+	lock, err := AcquireSessionLock(filepath.Join(fp.basePath, "locks"), id)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return err
 	}
-	defer os.Remove(tempPath) // Clean up temp file
+	fp.locks[id] = lock
+	delete(fp.readOnly, id)
+	return nil
+}
 
-	// Encode session data
-	encoder := json.NewEncoder(tempFile)
-	encoder.SetIndent("", "  ")
+// SessionLockHolder returns the other instance holding a session, if any
+func (fp *FilePersistence) SessionLockHolder(id string) (*LockInfo, error) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
 
-	if err := encoder.Encode(session); err != nil {
-		tempFile.Close()
-		return fmt.Errorf("failed to encode session: %w", err)
+	if _, held := fp.locks[id]; held {
+		return nil, nil
 	}
+	return ReadSessionLock(filepath.Join(fp.basePath, "locks"), id)
+}
 
-	// Close temp file
-	if err := tempFile.Close(); err != nil {
-		return fmt.Errorf("failed to close temp file: %w", err)
+// SetReadOnly marks a session so that it is never written by this instance
+func (fp *FilePersistence) SetReadOnly(id string, readOnly bool) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if readOnly {
+		fp.readOnly[id] = true
+	} else {
+		delete(fp.readOnly, id)
 	}
+}
 
-	// Calculate checksum
-	checksum, err := fp.calculateChecksum(tempPath)
-	if err != nil {
-		return fmt.Errorf("failed to calculate checksum: %w", err)
+// IsReadOnly reports whether a session was opened read-only or is sealed
+func (fp *FilePersistence) IsReadOnly(id string) bool {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+	return fp.readOnly[id] || fp.sealed[id]
+}
+
+// Close releases every session lock held by this instance
+func (fp *FilePersistence) Close() error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	var errs []error
+	for id, lock := range fp.locks {
+		if err := lock.Release(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(fp.locks, id)
 	}
+	return errors.Join(errs...)
+}
 
-	// Save metadata
-	metadata := SessionMetadata{
-		ID:           session.ID,
-		Checksum:     checksum,
-		SavedAt:      time.Now(),
-		Version:      "1.0",
-		MessageCount: len(session.Messages),
-		TokenCount:   session.TokenCount,
+// SaveSession saves a session to persistent storage
+func (fp *FilePersistence) SaveSession(session *Session) error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	// Validate session
+	if session == nil || session.ID == "" {
+		return fmt.Errorf("invalid session")
 	}
 
-	if err := fp.saveMetadata(session.ID, metadata); err != nil {
-		return fmt.Errorf("failed to save metadata: %w", err)
+	// Never interleave writes with another instance
+	if fp.readOnly[session.ID] || fp.sealed[session.ID] {
+		return fmt.Errorf("cannot save session %s: %w", session.ID, ErrSessionReadOnly)
+	}
+	if err := fp.lockSession(session.ID); err != nil {
+		return fmt.Errorf("cannot save session %s: %w", session.ID, err)
+	}
+
+	finalPath := fp.sessionPath(session.ID)
+
+	// Encode with the schema version so older formats can be migrated on load
+	data, err := json.MarshalIndent(struct {
+		SchemaVersion int `json:"schema_version"`
+		*Session
+	}{SessionSchemaVersion, session}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	data = append(data, '\n')
+	if data, err = fp.seal(data); err != nil {
+		return err
 	}
 
 	// Create backup if file already exists
@@ -116,61 +178,167 @@ func (fp *FilePersistence) SaveSession(session *Session) error {
 		}
 	}
 
-	// Atomic rename
-	if err := os.Rename(tempPath, finalPath); err != nil {
+	// Write to a temp file and rename it over the session (atomic write)
+	if err := fp.writeAtomic(finalPath, data); err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
 	}
 
+	// Save metadata. A crash before this point leaves metadata describing
+	// the previous file; LoadSession accepts a file that still decodes.
+	if err := fp.saveMetadata(session.ID, newSessionMetadata(session, data)); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+
 	return nil
 }
 
-// LoadSession loads a session from persistent storage
-func (fp *FilePersistence) LoadSession(id string) (*Session, error) {
-	fp.mu.RLock()
-	defer fp.mu.RUnlock()
-
-	sessionPath := filepath.Join(fp.basePath, "sessions", fmt.Sprintf("%s.json", id))
-
-	// Check if file exists
-	if _, err := os.Stat(sessionPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("session not found: %s", id)
+// newSessionMetadata describes the saved bytes of a session
+func newSessionMetadata(session *Session, data []byte) SessionMetadata {
+	sum := sha256.Sum256(data)
+	return SessionMetadata{
+		ID:            session.ID,
+		Checksum:      hex.EncodeToString(sum[:]),
+		SavedAt:       time.Now(),
+		Version:       "1.0",
+		SchemaVersion: SessionSchemaVersion,
+		MessageCount:  len(session.Messages),
+		TokenCount:    session.TokenCount,
 	}
+}
 
-	// Load metadata for validation
-	metadata, err := fp.loadMetadata(id)
+// writeAtomic writes data to a temp file, syncs it and renames it to path so
+// that readers never see a partially written file
+func (fp *FilePersistence) writeAtomic(path string, data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Join(fp.basePath, "temp"), filepath.Base(path)+".*.tmp")
 	if err != nil {
-		// Continue without metadata validation
-		fmt.Printf("Warning: failed to load metadata: %v\n", err)
+		return fmt.Errorf("failed to create temp file: %w", err)
 	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath) // Clean up temp file if the rename did not happen
 
-	// Open session file
-	file, err := os.Open(sessionPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open session file: %w", err)
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
 	}
-	defer file.Close()
 
-	// Decode session
-	var session Session
-	decoder := json.NewDecoder(file)
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
 
-	if err := decoder.Decode(&session); err != nil {
-		return nil, fmt.Errorf("failed to decode session: %w", err)
+func (fp *FilePersistence) sessionPath(id string) string {
+	return filepath.Join(fp.basePath, "sessions", fmt.Sprintf("%s.json", id))
+}
+
+// LoadSession loads a session from persistent storage. Sessions saved in an
+// older schema are migrated. A damaged session that cannot be recovered from
+// a backup is moved to the quarantine directory and ErrSessionCorrupted is
+// returned, so that one bad file does not prevent CODA from starting.
+func (fp *FilePersistence) LoadSession(id string) (*Session, error) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	sessionPath := fp.sessionPath(id)
+
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read session file: %w", err)
 	}
 
 	// Validate checksum if metadata exists
-	if metadata != nil {
-		checksum, err := fp.calculateChecksum(sessionPath)
-		if err == nil && checksum != metadata.Checksum {
-			// Try to recover from backup
-			if recoveredSession, err := fp.recoverFromBackup(id); err == nil {
-				return recoveredSession, nil
+	var mismatch error
+	if metadata, err := fp.loadMetadata(id); err == nil {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != metadata.Checksum {
+			mismatch = fmt.Errorf("checksum mismatch")
+		}
+		if metadata.SealedAt != nil {
+			fp.sealed[id] = true
+		}
+	}
+
+	// The file is decoded even when the checksum does not match: a save
+	// interrupted after the rename leaves a good file with stale metadata
+	var damage error
+	var session *Session
+	plain, err := fp.open(data)
+	if errors.Is(err, ErrSessionNotEncrypted) && fp.sealed[id] {
+		// Sealed sessions are never rewritten, so they stay as they were sealed
+		plain, err = data, nil
+	}
+	switch {
+	case err != nil && mismatch != nil && !errors.Is(err, ErrSessionNotEncrypted):
+		damage = mismatch
+	case err != nil:
+		// A wrong key is not damage: the file must stay where it is
+		return nil, fmt.Errorf("session %s: %w", id, err)
+	default:
+		session, err = decodeSession(plain)
+		switch {
+		case errors.Is(err, ErrSessionTooNew):
+			return nil, err
+		case err != nil:
+			damage = err
+		case mismatch != nil && fp.sealed[id]:
+			damage = mismatch
+		case mismatch != nil:
+			if err := fp.saveMetadata(id, newSessionMetadata(session, data)); err != nil {
+				fmt.Printf("Warning: failed to update metadata of session %s: %v\n", id, err)
 			}
-			return nil, fmt.Errorf("session data corrupted (checksum mismatch)")
 		}
 	}
 
-	return &session, nil
+	// A sealed session is evidence; a changed one is reported, not repaired
+	if damage != nil && fp.sealed[id] {
+		return nil, fmt.Errorf("session %s (%v): %w", id, damage, ErrSealBroken)
+	}
+
+	if damage != nil {
+		// Try to recover from backup
+		if recoveredSession, err := fp.recoverFromBackup(id); err == nil {
+			return recoveredSession, nil
+		}
+		quarantinePath, err := fp.quarantine(id)
+		if err != nil {
+			return nil, fmt.Errorf("session %s is corrupted (%v) and could not be quarantined: %w", id, damage, err)
+		}
+		return nil, fmt.Errorf("session %s (%v) moved to %s: %w", id, damage, quarantinePath, ErrSessionCorrupted)
+	}
+
+	return session, nil
+}
+
+// quarantine moves a damaged session file out of the sessions directory and
+// returns its new location
+func (fp *FilePersistence) quarantine(id string) (string, error) {
+	dir := filepath.Join(fp.basePath, "quarantine")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	target := filepath.Join(dir, fmt.Sprintf("%s_%d.json", id, time.Now().Unix()))
+	if err := os.Rename(fp.sessionPath(id), target); err != nil {
+		return "", fmt.Errorf("failed to quarantine session: %w", err)
+	}
+
+	// The metadata describes the quarantined file, not a session any more
+	os.Remove(filepath.Join(fp.basePath, "metadata", fmt.Sprintf("%s.json", id)))
+	if lock, held := fp.locks[id]; held {
+		lock.Release()
+		delete(fp.locks, id)
+	}
+	return target, nil
 }
 
 // ListSessions returns a list of all session IDs
@@ -197,11 +365,47 @@ func (fp *FilePersistence) ListSessions() ([]string, error) {
 	return sessionIDs, nil
 }
 
+// LatestSessionID returns the most recently saved session, or "" when there
+// are none
+func (fp *FilePersistence) LatestSessionID() (string, error) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(fp.basePath, "sessions"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	latestID := ""
+	var latestTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latestID == "" || info.ModTime().After(latestTime) {
+			latestID = strings.TrimSuffix(entry.Name(), ".json")
+			latestTime = info.ModTime()
+		}
+	}
+
+	return latestID, nil
+}
+
 // DeleteSession removes a session from persistent storage
 func (fp *FilePersistence) DeleteSession(id string) error {
 	fp.mu.Lock()
 	defer fp.mu.Unlock()
 
+	if holder, err := ReadSessionLock(filepath.Join(fp.basePath, "locks"), id); err == nil && holder != nil {
+		if _, held := fp.locks[id]; !held {
+			return &SessionLockedError{SessionID: id, Holder: *holder}
+		}
+	}
+
 	// Create backup before deletion
 	sessionPath := filepath.Join(fp.basePath, "sessions", fmt.Sprintf("%s.json", id))
 	if _, err := os.Stat(sessionPath); err == nil {
@@ -224,17 +428,26 @@ func (fp *FilePersistence) DeleteSession(id string) error {
 		fmt.Printf("Warning: failed to delete metadata: %v\n", err)
 	}
 
+	if lock, held := fp.locks[id]; held {
+		lock.Release()
+		delete(fp.locks, id)
+	}
+
 	return nil
 }
 
 // SessionMetadata contains metadata about a saved session
 type SessionMetadata struct {
-	ID           string    `json:"id"`
-	Checksum     string    `json:"checksum"`
-	SavedAt      time.Time `json:"saved_at"`
-	Version      string    `json:"version"`
-	MessageCount int       `json:"message_count"`
-	TokenCount   int       `json:"token_count"`
+	ID            string    `json:"id"`
+	Checksum      string    `json:"checksum"`
+	SavedAt       time.Time `json:"saved_at"`
+	Version       string    `json:"version"`
+	SchemaVersion int       `json:"schema_version,omitempty"`
+	MessageCount  int       `json:"message_count"`
+	TokenCount    int       `json:"token_count"`
+
+	// When the session was sealed; Checksum is then that of the sealed file
+	SealedAt *time.Time `json:"sealed_at,omitempty"`
 }
 
 // saveMetadata saves session metadata
@@ -246,7 +459,7 @@ func (fp *FilePersistence) saveMetadata(id string, metadata SessionMetadata) err
 		return err
 	}
 
-	return os.WriteFile(metadataPath, data, 0644)
+	return fp.writeAtomic(metadataPath, data)
 }
 
 // loadMetadata loads session metadata
@@ -333,21 +546,21 @@ func (fp *FilePersistence) recoverFromBackup(id string) (*Session, error) {
 
 	// Load from backup
 	backupPath := filepath.Join(backupDir, latestBackup)
-	file, err := os.Open(backupPath)
+	data, err := os.ReadFile(backupPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open backup: %w", err)
 	}
-	defer file.Close()
-
-	var session Session
-	decoder := json.NewDecoder(file)
+	if data, err = fp.open(data); err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+	}
 
-	if err := decoder.Decode(&session); err != nil {
+	session, err := decodeSession(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode backup: %w", err)
 	}
 
 	fmt.Printf("Warning: recovered session %s from backup\n", id)
-	return &session, nil
+	return session, nil
 }
 
 // ValidateIntegrity checks the integrity of all saved sessions
@@ -376,7 +589,8 @@ func (fp *FilePersistence) ValidateIntegrity() ([]string, error) {
 			continue
 		}
 
-		if checksum != metadata.Checksum {
+		// Stale metadata of a file that still decodes is not corruption
+		if checksum != metadata.Checksum && (metadata.SealedAt != nil || !fp.decodes(sessionPath)) {
 			corruptedSessions = append(corruptedSessions, id)
 		}
 	}
@@ -384,6 +598,19 @@ func (fp *FilePersistence) ValidateIntegrity() ([]string, error) {
 	return corruptedSessions, nil
 }
 
+// decodes reports whether the session file at path decrypts and decodes
+func (fp *FilePersistence) decodes(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if data, err = fp.open(data); err != nil {
+		return false
+	}
+	_, err = decodeSession(data)
+	return err == nil
+}
+
 // CleanupBackups removes old backup files
 func (fp *FilePersistence) CleanupBackups(maxAge time.Duration) error {
 	fp.mu.Lock()
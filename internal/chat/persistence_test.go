@@ -0,0 +1,51 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+func TestLoadSessionAfterInterruptedSave(t *testing.T) {
+	fp, err := NewFilePersistence(t.TempDir(), false, 0)
+	require.NoError(t, err)
+	defer fp.Close()
+
+	session := &Session{ID: "s1", StartedAt: time.Now(), Messages: []ai.Message{{Role: "user", Content: "first"}}}
+	require.NoError(t, fp.SaveSession(session))
+	staleMetadata, err := os.ReadFile(filepath.Join(fp.basePath, "metadata", "s1.json"))
+	require.NoError(t, err)
+
+	session.Messages = append(session.Messages, ai.Message{Role: "assistant", Content: "second"})
+	require.NoError(t, fp.SaveSession(session))
+
+	// A crash between the rename and the metadata write leaves the new file
+	// described by the old metadata
+	require.NoError(t, os.WriteFile(filepath.Join(fp.basePath, "metadata", "s1.json"), staleMetadata, 0644))
+
+	loaded, err := fp.LoadSession("s1")
+	require.NoError(t, err)
+	assert.Len(t, loaded.Messages, 2)
+
+	corrupted, err := fp.ValidateIntegrity()
+	require.NoError(t, err)
+	assert.Empty(t, corrupted, "the metadata is repaired on load")
+}
+
+func TestLoadSessionQuarantinesUndecodableFile(t *testing.T) {
+	fp, err := NewFilePersistence(t.TempDir(), false, 0)
+	require.NoError(t, err)
+	defer fp.Close()
+
+	require.NoError(t, fp.SaveSession(&Session{ID: "s1", StartedAt: time.Now()}))
+	require.NoError(t, os.WriteFile(fp.sessionPath("s1"), []byte(`{"id": "s1", "messages": [`), 0644))
+
+	_, err = fp.LoadSession("s1")
+	assert.ErrorIs(t, err, ErrSessionCorrupted)
+}
@@ -0,0 +1,26 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+// personaPrompt names the prompt section holding a persona's instructions
+const personaPrompt = "persona"
+
+// SetPersona adds the instructions of a persona to the system prompt of this
+// handler's conversations. An empty name restores the default prompt.
+func (h *ChatHandler) SetPersona(name string, persona config.Persona) {
+	h.promptBuilder.RemoveCustomPrompt(personaPrompt)
+	if prompt := strings.TrimSpace(persona.Prompt); name != "" && prompt != "" {
+		h.promptBuilder.AddCustomPrompt(personaPrompt, fmt.Sprintf("## Persona: %s\n%s", name, prompt))
+	}
+	h.persona = name
+}
+
+// Persona returns the name of the active persona, or "" for the default
+func (h *ChatHandler) Persona() string {
+	return h.persona
+}
@@ -0,0 +1,278 @@
+package chat
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+// Transformer rewrites the content of an answer
+type Transformer interface {
+	Transform(content string) string
+}
+
+// TransformerFunc adapts a function to a Transformer
+type TransformerFunc func(content string) string
+
+// Transform calls f
+func (f TransformerFunc) Transform(content string) string {
+	return f(content)
+}
+
+// Pipeline applies transformers to answers in order
+type Pipeline struct {
+	transformers []Transformer
+}
+
+// NewPipeline chains the given transformers
+func NewPipeline(transformers ...Transformer) *Pipeline {
+	return &Pipeline{transformers: transformers}
+}
+
+// NewPostProcessPipeline builds the steps configured in cfg. root is the
+// workspace that relative_paths makes paths relative to.
+func NewPostProcessPipeline(cfg config.PostProcessConfig, root string) (*Pipeline, error) {
+	pipeline := NewPipeline()
+	for _, step := range cfg.Steps {
+		switch step {
+		case config.PostProcessStripDisclaimers:
+			patterns := append([]string{}, defaultDisclaimers...)
+			patterns = append(patterns, cfg.Disclaimers...)
+			t, err := NewDisclaimerStripper(patterns)
+			if err != nil {
+				return nil, err
+			}
+			pipeline.Add(t)
+		case config.PostProcessFenceLanguages:
+			pipeline.Add(TransformerFunc(LabelCodeFences))
+		case config.PostProcessRelativePaths:
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve workspace root: %w", err)
+			}
+			pipeline.Add(NewPathRelativizer(abs))
+		case config.PostProcessReplace:
+			t, err := NewReplacer(cfg.Replacements)
+			if err != nil {
+				return nil, err
+			}
+			pipeline.Add(t)
+		default:
+			return nil, fmt.Errorf("unknown post-processing step: %s", step)
+		}
+	}
+	return pipeline, nil
+}
+
+// Add appends a transformer to the pipeline
+func (p *Pipeline) Add(t Transformer) {
+	p.transformers = append(p.transformers, t)
+}
+
+// Apply runs content through every transformer. A nil pipeline returns
+// content unchanged.
+func (p *Pipeline) Apply(content string) string {
+	if p == nil {
+		return content
+	}
+	for _, t := range p.transformers {
+		content = t.Transform(content)
+	}
+	return content
+}
+
+// SetPostProcessor sets the pipeline applied to answers before they are
+// saved and returned; nil disables it
+func (h *ChatHandler) SetPostProcessor(p *Pipeline) {
+	h.postProcess = p
+}
+
+// defaultDisclaimers match boilerplate sentences about the model itself
+var defaultDisclaimers = []string{
+	`(?i)as an ai(?: language)? model,?[^.!?\n]*[.!?]`,
+	`(?i)i(?:'m| am) (?:just |only )?an ai(?: language model| assistant)?[^.!?\n]*[.!?]`,
+	`(?i)i (?:do not|don't|cannot|can't) have (?:personal )?(?:opinions|feelings|access to real-time)[^.!?\n]*[.!?]`,
+}
+
+// disclaimerStripper removes matching sentences outside code blocks and
+// drops lines left empty
+type disclaimerStripper struct {
+	patterns []*regexp.Regexp
+}
+
+// NewDisclaimerStripper removes the sentences matching the patterns
+func NewDisclaimerStripper(patterns []string) (Transformer, error) {
+	s := &disclaimerStripper{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid disclaimer pattern %q: %w", pattern, err)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+func (s *disclaimerStripper) Transform(content string) string {
+	lines := strings.Split(content, "\n")
+	out := lines[:0]
+	inFence := false
+	for _, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		stripped := line
+		for _, re := range s.patterns {
+			stripped = re.ReplaceAllString(stripped, "")
+		}
+		if stripped != line {
+			stripped = strings.TrimSpace(stripped)
+			if stripped == "" {
+				continue
+			}
+		}
+		out = append(out, stripped)
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+// isFence reports whether a line opens or closes a code block
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// LabelCodeFences adds a language to code blocks that have none, guessed
+// from their first lines, so they are highlighted and saved with the right
+// extension
+func LabelCodeFences(content string) string {
+	lines := strings.Split(content, "\n")
+	inFence := false
+	for i, line := range lines {
+		if !isFence(line) {
+			continue
+		}
+		if inFence {
+			inFence = false
+			continue
+		}
+		inFence = true
+		if strings.TrimSpace(line) != "```" {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !isFence(lines[end]) {
+			end++
+		}
+		if language := guessLanguage(lines[i+1 : end]); language != "" {
+			lines[i] = line + language
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// languageHints map a prefix of the first code line to its language
+var languageHints = []struct {
+	prefix   string
+	language string
+}{
+	{"package ", "go"},
+	{"#!/bin/bash", "bash"},
+	{"#!/bin/sh", "sh"},
+	{"#!/usr/bin/env python", "python"},
+	{"$ ", "bash"},
+	{"def ", "python"},
+	{"from ", "python"},
+	{"<?php", "php"},
+	{"<!doctype html", "html"},
+	{"<html", "html"},
+	{"<?xml", "xml"},
+	{"fn ", "rust"},
+	{"select ", "sql"},
+	{"create table", "sql"},
+	{"insert into", "sql"},
+	{"function ", "javascript"},
+	{"const ", "javascript"},
+	{"apiversion:", "yaml"},
+	{"---", "yaml"},
+	{"{", "json"},
+	{"[", "json"},
+}
+
+// guessLanguage returns the language of a code block, or "" when unsure
+func guessLanguage(code []string) string {
+	for _, line := range code {
+		first := strings.ToLower(strings.TrimSpace(line))
+		if first == "" {
+			continue
+		}
+		for _, hint := range languageHints {
+			if strings.HasPrefix(first, hint.prefix) {
+				return hint.language
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// pathRelativizer rewrites absolute paths inside the workspace relative to it
+type pathRelativizer struct {
+	root string
+}
+
+// NewPathRelativizer rewrites absolute paths under root relative to it
+func NewPathRelativizer(root string) Transformer {
+	return &pathRelativizer{root: filepath.Clean(root)}
+}
+
+func (p *pathRelativizer) Transform(content string) string {
+	if p.root == string(filepath.Separator) {
+		return content
+	}
+	prefix := p.root + string(filepath.Separator)
+	content = strings.ReplaceAll(content, prefix, "")
+	if filepath.Separator != '/' {
+		content = strings.ReplaceAll(content, filepath.ToSlash(prefix), "")
+	}
+	return content
+}
+
+// replacer applies configured regular expression rewrites
+type replacer struct {
+	rules []replacement
+}
+
+type replacement struct {
+	re   *regexp.Regexp
+	with string
+}
+
+// NewReplacer rewrites the matches of each replacement in order
+func NewReplacer(replacements []config.Replacement) (Transformer, error) {
+	r := &replacer{}
+	for _, rep := range replacements {
+		re, err := regexp.Compile(rep.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replacement pattern %q: %w", rep.Pattern, err)
+		}
+		r.rules = append(r.rules, replacement{re: re, with: rep.With})
+	}
+	return r, nil
+}
+
+func (r *replacer) Transform(content string) string {
+	for _, rule := range r.rules {
+		content = rule.re.ReplaceAllString(content, rule.with)
+	}
+	return content
+}
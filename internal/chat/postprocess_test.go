@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestPostProcessPipeline(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "internal", "app.go")
+
+	tests := []struct {
+		name  string
+		cfg   config.PostProcessConfig
+		input string
+		want  string
+	}{
+		{
+			name:  "no steps",
+			input: "As an AI language model, I have no opinion.",
+			want:  "As an AI language model, I have no opinion.",
+		},
+		{
+			name:  "strip disclaimers",
+			cfg:   config.PostProcessConfig{Steps: []string{config.PostProcessStripDisclaimers}, Disclaimers: []string{`(?i)I hope this helps!`}},
+			input: "As an AI language model, I cannot run code. Here is the fix:\n\n```\n// As an AI model, keep this.\n```\nI hope this helps!",
+			want:  "Here is the fix:\n\n```\n// As an AI model, keep this.\n```",
+		},
+		{
+			name:  "label code fences",
+			cfg:   config.PostProcessConfig{Steps: []string{config.PostProcessFenceLanguages}},
+			input: "```\npackage main\n```\n\n```python\nimport os\n```\n\n```\n\n$ go test ./...\n```\n\n```\nsomething else\n```",
+			want:  "```go\npackage main\n```\n\n```python\nimport os\n```\n\n```bash\n\n$ go test ./...\n```\n\n```\nsomething else\n```",
+		},
+		{
+			name:  "relative paths",
+			cfg:   config.PostProcessConfig{Steps: []string{config.PostProcessRelativePaths}},
+			input: "See " + file + " and /etc/hosts",
+			want:  "See " + filepath.Join("internal", "app.go") + " and /etc/hosts",
+		},
+		{
+			name: "steps run in order",
+			cfg: config.PostProcessConfig{
+				Steps:        []string{config.PostProcessReplace, config.PostProcessFenceLanguages},
+				Replacements: []config.Replacement{{Pattern: "(?m)^```golang$", With: "```go"}, {Pattern: `\bcolour\b`, With: "color"}},
+			},
+			input: "The colour:\n```golang\nx := 1\n```\n```\n{\"a\": 1}\n```",
+			want:  "The color:\n```go\nx := 1\n```\n```json\n{\"a\": 1}\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := NewPostProcessPipeline(tt.cfg, root)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pipeline.Apply(tt.input))
+		})
+	}
+}
+
+func TestPostProcessPipelineErrors(t *testing.T) {
+	_, err := NewPostProcessPipeline(config.PostProcessConfig{Steps: []string{"shout"}}, ".")
+	assert.EqualError(t, err, "unknown post-processing step: shout")
+
+	_, err = NewPostProcessPipeline(config.PostProcessConfig{Steps: []string{config.PostProcessReplace}, Replacements: []config.Replacement{{Pattern: "("}}}, ".")
+	assert.ErrorContains(t, err, `invalid replacement pattern "("`)
+
+	var pipeline *Pipeline
+	assert.Equal(t, "unchanged", pipeline.Apply("unchanged"), "a nil pipeline")
+}
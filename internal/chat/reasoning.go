@@ -0,0 +1,41 @@
+package chat
+
+import (
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// SetReasoningEffort overrides the configured reasoning effort for the next
+// requests after checking that the model accepts it; "" restores the
+// configured value
+func (h *ChatHandler) SetReasoningEffort(effort string) error {
+	if effort == "" {
+		h.reasoningEffort = nil
+		return nil
+	}
+	if err := ai.ValidateReasoningEffort(h.config.AI.Model, effort); err != nil {
+		return err
+	}
+	h.reasoningEffort = &effort
+	return nil
+}
+
+// ReasoningEffort returns the reasoning effort sent with requests, or "" when
+// the model's default is used
+func (h *ChatHandler) ReasoningEffort() string {
+	if effort := h.requestReasoningEffort(); effort != nil {
+		return *effort
+	}
+	return ""
+}
+
+// requestReasoningEffort returns the override set with SetReasoningEffort,
+// falling back to the configuration; nil when the model does not reason
+func (h *ChatHandler) requestReasoningEffort() *string {
+	if !h.Capabilities().Reasoning {
+		return nil
+	}
+	if h.reasoningEffort != nil {
+		return h.reasoningEffort
+	}
+	return h.config.AI.ReasoningEffort
+}
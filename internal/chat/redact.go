@@ -0,0 +1,110 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// RedactedText replaces redacted content in session messages
+const RedactedText = "[redacted]"
+
+// DeleteMessage removes the message at index from the current session and
+// saves it. Deleting an answer that called tools also removes the tool
+// results that followed it. The removed messages are returned.
+func (h *ChatHandler) DeleteMessage(index int) ([]ai.Message, error) {
+	var removed []ai.Message
+	err := h.editMessage(index, func(messages []ai.Message) ([]ai.Message, error) {
+		end := index + 1
+		if len(messages[index].ToolCalls) > 0 {
+			for end < len(messages) && IsToolResult(messages[end]) {
+				end++
+			}
+		}
+		removed = append(removed, messages[index:end]...)
+		return append(messages[:index], messages[end:]...), nil
+	})
+	return removed, err
+}
+
+// RedactMessage replaces each occurrence of text in the message at index of
+// the current session, or its whole content when text is empty, and saves
+// the session. The message is returned as it was before.
+func (h *ChatHandler) RedactMessage(index int, text string) (ai.Message, error) {
+	var original ai.Message
+	err := h.editMessage(index, func(messages []ai.Message) ([]ai.Message, error) {
+		original = messages[index]
+		msg := messages[index]
+		msg.ToolCalls = append([]ai.ToolCall(nil), msg.ToolCalls...)
+		if text != "" && !containsText(msg, text) {
+			return nil, fmt.Errorf("the message does not contain %q", text)
+		}
+		if text == "" {
+			msg.Content = RedactedText
+			if IsToolResult(original) {
+				// Keep the tool name so the conversation still reads as a tool call
+				name, _, _ := strings.Cut(original.Content, ":")
+				msg.Content = name + ": " + RedactedText
+			}
+		} else {
+			msg.Content = strings.ReplaceAll(msg.Content, text, RedactedText)
+			for i := range msg.ToolCalls {
+				msg.ToolCalls[i].Function.Arguments = strings.ReplaceAll(msg.ToolCalls[i].Function.Arguments, text, RedactedText)
+			}
+		}
+		messages[index] = msg
+		return messages, nil
+	})
+	return original, err
+}
+
+// containsText reports whether text occurs in the content or the tool call
+// arguments of msg
+func containsText(msg ai.Message, text string) bool {
+	if strings.Contains(msg.Content, text) {
+		return true
+	}
+	for _, call := range msg.ToolCalls {
+		if strings.Contains(call.Function.Arguments, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsToolResult reports whether a session message carries a tool result
+func IsToolResult(msg ai.Message) bool {
+	return msg.Role == ai.RoleUser && strings.HasPrefix(msg.Content, "TOOL_RESULT[")
+}
+
+// editMessage applies edit to the messages of the current session when index
+// is a message of the conversation, then saves the session
+func (h *ChatHandler) editMessage(index int, edit func([]ai.Message) ([]ai.Message, error)) error {
+	current := h.session.GetCurrent()
+	if current == nil {
+		return fmt.Errorf("no active session")
+	}
+
+	err := h.session.EditMessages(current.ID, func(messages []ai.Message) ([]ai.Message, error) {
+		if index < 0 || index >= len(messages) {
+			return nil, fmt.Errorf("no message %d in the session", index)
+		}
+		if messages[index].Role == ai.RoleSystem {
+			return nil, fmt.Errorf("the system prompt cannot be changed")
+		}
+		return edit(messages)
+	})
+	if err != nil {
+		return err
+	}
+
+	if h.persistence != nil && !h.persistence.IsReadOnly(current.ID) {
+		err := h.persistence.SaveSession(current)
+		h.recordSave(current, err)
+		if err != nil {
+			return fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// DropLastTurn removes the last user message of the current session and the
+// answers and tool results that followed it, so the message can be sent
+// again to regenerate its answer
+func (h *ChatHandler) DropLastTurn() error {
+	session := h.session.GetCurrent()
+	if session == nil {
+		return fmt.Errorf("no active session")
+	}
+
+	messages, err := h.session.GetMessages(session.ID)
+	if err != nil {
+		return err
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == ai.RoleUser {
+			return h.session.TruncateMessages(session.ID, i)
+		}
+	}
+	return fmt.Errorf("the session has no message to regenerate")
+}
@@ -0,0 +1,119 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/common-creation/coda/internal/retention"
+)
+
+// sessionRetention lists saved sessions, backups and quarantined files for
+// garbage collection. Sealed sessions and sessions open in a running
+// instance are never listed.
+type sessionRetention struct {
+	fp *FilePersistence
+}
+
+// RetentionSource returns the source of the sessions category
+func (fp *FilePersistence) RetentionSource() retention.Source {
+	return &sessionRetention{fp: fp}
+}
+
+// Category implements retention.Source.
+func (s *sessionRetention) Category() string {
+	return retention.CategorySessions
+}
+
+// Items implements retention.Source.
+func (s *sessionRetention) Items() ([]retention.Item, error) {
+	fp := s.fp
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(fp.basePath, "sessions"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	var items []retention.Item
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if !fp.removable(id) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		item := retention.Item{Name: id, Size: info.Size(), ModTime: info.ModTime()}
+		for _, path := range []string{
+			fp.sessionPath(id),
+			filepath.Join(fp.basePath, "metadata", fmt.Sprintf("%s.json", id)),
+			fp.partialPath(id),
+		} {
+			if info, err := os.Stat(path); err == nil {
+				item.Paths = append(item.Paths, path)
+				if path != fp.sessionPath(id) {
+					item.Size += info.Size()
+				}
+			}
+		}
+		items = append(items, item)
+	}
+
+	// Backups and quarantined files go one by one, oldest first
+	for _, dir := range []string{"backup", "quarantine"} {
+		entries, err := os.ReadDir(filepath.Join(fp.basePath, dir))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			path := filepath.Join(fp.basePath, dir, entry.Name())
+			items = append(items, retention.Item{
+				Name:    filepath.Join(dir, entry.Name()),
+				Paths:   []string{path},
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+		}
+	}
+	return items, nil
+}
+
+// Remove implements retention.Source.
+func (s *sessionRetention) Remove(item retention.Item) error {
+	fp := s.fp
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	// The session may have been opened since it was listed
+	if !strings.Contains(item.Name, string(filepath.Separator)) && !fp.removable(item.Name) {
+		return fmt.Errorf("session is in use")
+	}
+	return retention.RemovePaths(item)
+}
+
+// removable reports whether a session is neither sealed nor open here or in
+// another running instance
+func (fp *FilePersistence) removable(id string) bool {
+	if _, held := fp.locks[id]; held || fp.sealed[id] {
+		return false
+	}
+	if holder, err := ReadSessionLock(filepath.Join(fp.basePath, "locks"), id); err != nil || holder != nil {
+		return false
+	}
+	if metadata, err := fp.loadMetadata(id); err == nil && metadata.SealedAt != nil {
+		return false
+	}
+	return true
+}
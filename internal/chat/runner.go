@@ -0,0 +1,156 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+// RunEventKind identifies a PromptRunner progress event
+type RunEventKind string
+
+const (
+	RunEventMessage  RunEventKind = "message"
+	RunEventToolCall RunEventKind = "tool_call"
+	RunEventToolDone RunEventKind = "tool_done"
+	RunEventWarning  RunEventKind = "warning"
+)
+
+// RunEvent reports progress while a prompt runs
+type RunEvent struct {
+	Kind RunEventKind
+	Text string
+	Tool string
+	Err  error
+}
+
+// RunResult is the outcome of a headless prompt
+type RunResult struct {
+	// Output is the final assistant response
+	Output string
+
+	// ToolResults holds every tool call executed while answering
+	ToolResults []ToolResult
+
+	// Turns is the number of model responses
+	Turns int
+}
+
+// PromptRunnerOption configures a PromptRunner
+type PromptRunnerOption func(*PromptRunner)
+
+// WithMaxTurns bounds the number of tool call rounds per prompt
+func WithMaxTurns(n int) PromptRunnerOption {
+	return func(r *PromptRunner) {
+		if n > 0 {
+			r.maxTurns = n
+		}
+	}
+}
+
+// WithRunObserver receives progress events
+func WithRunObserver(observer func(RunEvent)) PromptRunnerOption {
+	return func(r *PromptRunner) {
+		r.observer = observer
+	}
+}
+
+// PromptRunner drives a prompt to completion without a UI. Tool calls
+// requested by the model are executed and their results sent back until the
+// model answers without requesting more tools.
+type PromptRunner struct {
+	handler  *ChatHandler
+	executor *ToolExecutor
+	maxTurns int
+	observer func(RunEvent)
+}
+
+// NewPromptRunner creates a runner on the handler's current session
+func NewPromptRunner(handler *ChatHandler, executor *ToolExecutor, opts ...PromptRunnerOption) *PromptRunner {
+	r := &PromptRunner{
+		handler:  handler,
+		executor: executor,
+		maxTurns: 25,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run sends the prompt and executes tool calls until the model is done
+func (r *PromptRunner) Run(ctx context.Context, prompt string) (*RunResult, error) {
+	result := &RunResult{}
+
+	resp, err := r.handler.HandleMessageWithResponse(ctx, prompt, nil)
+	for {
+		if err != nil {
+			return result, err
+		}
+		result.Turns++
+		result.Output = resp.Content
+
+		if resp.FallbackModel != "" {
+			r.emit(RunEvent{Kind: RunEventWarning, Text: fmt.Sprintf("answered by fallback model %s", resp.FallbackModel)})
+		}
+		if resp.Content != "" {
+			r.emit(RunEvent{Kind: RunEventMessage, Text: resp.Content})
+		}
+		if len(resp.ToolCalls) == 0 {
+			return result, nil
+		}
+		if result.Turns > r.maxTurns {
+			return result, fmt.Errorf("stopped after %d turns without a final answer", r.maxTurns)
+		}
+
+		for _, call := range resp.ToolCalls {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			toolResult := r.executeToolCall(ctx, call)
+			result.ToolResults = append(result.ToolResults, toolResult)
+
+			if err := r.handler.AddMessageToSession(ToolResultMessage(toolResult)); err != nil {
+				return result, fmt.Errorf("failed to record tool result: %w", err)
+			}
+		}
+
+		resp, err = r.handler.ContinueConversation(ctx, nil)
+	}
+}
+
+func (r *PromptRunner) executeToolCall(ctx context.Context, call ai.ToolCall) ToolResult {
+	r.emit(RunEvent{Kind: RunEventToolCall, Tool: call.Function.Name, Text: call.Function.Arguments})
+
+	result := ToolResult{ToolCallID: call.ID, ToolName: call.Function.Name}
+	if r.executor == nil {
+		result.Error = fmt.Errorf("tool execution is not available")
+	} else {
+		if session := r.handler.GetCurrentSession(); session != nil {
+			ctx = tools.WithSessionID(ctx, session.ID)
+		}
+		results, err := r.executor.ExecuteToolCalls(ctx, []ai.ToolCall{call})
+		switch {
+		case err != nil:
+			result.Error = err
+		case len(results) == 0:
+			result.Error = fmt.Errorf("tool produced no result")
+		default:
+			result = results[0]
+		}
+	}
+
+	r.emit(RunEvent{Kind: RunEventToolDone, Tool: call.Function.Name, Err: result.Error})
+	if warning := InjectionWarning(result); warning != "" {
+		r.emit(RunEvent{Kind: RunEventWarning, Tool: call.Function.Name, Text: warning})
+	}
+	return result
+}
+
+func (r *PromptRunner) emit(event RunEvent) {
+	if r.observer != nil {
+		r.observer(event)
+	}
+}
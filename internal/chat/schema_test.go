@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzParseStructuredOutput feeds the structured output parser malformed
+// and truncated JSON. Run it with
+//
+//	go test ./internal/chat -run '^$' -fuzz FuzzParseStructuredOutput
+func FuzzParseStructuredOutput(f *testing.F) {
+	seeds := []string{
+		`{"response_type":"both","text":"Reading it","tool_calls":[{"tool":"read_file","arguments":{"path":"main.go"}}]}`,
+		`{"response_type":"tool_call","text":null,"tool_calls":[{"tool":"write_file","arguments":{"content":"func main() {}\n"}}]}`,
+		`{"response_type":"tool_call","text":null,"tool_calls":[{"tool":"x"}]}`,
+		`{"response_type":"text","text":"done","tool_calls":null}`,
+		`{"response_type":"text","text":"unterminated`,
+		`{"tool_calls":[{"tool":1,"arguments":[]}]}`,
+		`{"tool_calls":[{"arguments":{"n":1e400}}]}`,
+		`{"tool_calls":[{"arguments":{"s":"\ud800"}}]}`,
+		`null`,
+		`{"tool_calls":[{"arguments":` + strings.Repeat(`{"a":[`, 100),
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		resp, err := ParseStructuredOutput(content)
+		if err != nil {
+			return
+		}
+		toolCalls, err := ConvertToAIToolCalls(resp.ToolCalls)
+		if err != nil {
+			t.Fatalf("parsed tool calls do not convert: %v", err)
+		}
+		checkToolCalls(t, toolCalls)
+	})
+}
@@ -0,0 +1,42 @@
+package chat
+
+import "fmt"
+
+// scratchpadKey is the session context entry holding the scratchpad
+const scratchpadKey = "scratchpad"
+
+// Scratchpad returns the notes kept with the current session. They are never
+// sent to the model.
+func (h *ChatHandler) Scratchpad() string {
+	current := h.session.GetCurrent()
+	if current == nil {
+		return ""
+	}
+	text, _ := h.session.GetContext(current.ID, scratchpadKey)
+	s, _ := text.(string)
+	return s
+}
+
+// SetScratchpad replaces the notes of the current session and saves the
+// session unless it was opened read-only
+func (h *ChatHandler) SetScratchpad(text string) error {
+	current := h.session.GetCurrent()
+	if current == nil {
+		return fmt.Errorf("no active session")
+	}
+	if text == h.Scratchpad() {
+		return nil
+	}
+	if err := h.session.SetContext(current.ID, scratchpadKey, text); err != nil {
+		return fmt.Errorf("failed to update scratchpad: %w", err)
+	}
+
+	if h.persistence != nil && !h.persistence.IsReadOnly(current.ID) {
+		err := h.persistence.SaveSession(current)
+		h.recordSave(current, err)
+		if err != nil {
+			return fmt.Errorf("failed to save scratchpad: %w", err)
+		}
+	}
+	return nil
+}
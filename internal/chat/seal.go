@@ -0,0 +1,135 @@
+package chat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrSealBroken is returned when a sealed session file no longer matches
+// the checksum recorded when it was sealed
+var ErrSealBroken = errors.New("sealed session was modified")
+
+// SealSession marks a saved session as final: it is never written again and
+// its file is made read-only. The metadata returned records the checksum of
+// the sealed file.
+func (fp *FilePersistence) SealSession(id string) (*SessionMetadata, error) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	metadata, err := fp.loadMetadata(id)
+	if err != nil {
+		return nil, fmt.Errorf("session %s has not been saved: %w", id, err)
+	}
+	if metadata.SealedAt != nil {
+		fp.sealed[id] = true
+		return metadata, nil
+	}
+	if err := fp.checkSessionFile(id, metadata); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	metadata.SealedAt = &now
+	if err := fp.saveMetadata(id, *metadata); err != nil {
+		return nil, fmt.Errorf("failed to save metadata: %w", err)
+	}
+	fp.sealed[id] = true
+
+	// Best effort: the seal does not depend on the file mode
+	os.Chmod(fp.sessionPath(id), 0444)
+	return metadata, nil
+}
+
+// VerifySeal checks that a sealed session file still has the checksum it was
+// sealed with
+func (fp *FilePersistence) VerifySeal(id string) (*SessionMetadata, error) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+
+	metadata, err := fp.loadMetadata(id)
+	if err != nil || metadata.SealedAt == nil {
+		return nil, fmt.Errorf("session %s is not sealed", id)
+	}
+	if err := fp.checkSessionFile(id, metadata); err != nil {
+		return metadata, err
+	}
+	return metadata, nil
+}
+
+// IsSealed reports whether a session has been sealed
+func (fp *FilePersistence) IsSealed(id string) bool {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+	return fp.sealed[id]
+}
+
+// checkSessionFile compares the session file with the checksum of its metadata
+func (fp *FilePersistence) checkSessionFile(id string, metadata *SessionMetadata) error {
+	data, err := os.ReadFile(fp.sessionPath(id))
+	if err != nil {
+		return fmt.Errorf("failed to read session file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != metadata.Checksum {
+		if metadata.SealedAt != nil {
+			return fmt.Errorf("session %s: checksum mismatch: %w", id, ErrSealBroken)
+		}
+		return fmt.Errorf("session %s changed since it was saved", id)
+	}
+	return nil
+}
+
+// SealSession saves the current session and seals it, so it can serve as a
+// record of the conversation. Sending another message continues in a fork.
+func (h *ChatHandler) SealSession() (*SessionMetadata, error) {
+	if h.persistence == nil {
+		return nil, fmt.Errorf("session persistence is not available")
+	}
+	current := h.session.GetCurrent()
+	if current == nil || len(current.Messages) == 0 {
+		return nil, fmt.Errorf("there is no conversation to seal")
+	}
+
+	if !h.persistence.IsReadOnly(current.ID) {
+		err := h.persistence.SaveSession(current)
+		h.recordSave(current, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save session: %w", err)
+		}
+	} else if !h.persistence.IsSealed(current.ID) {
+		return nil, fmt.Errorf("the session is open in another instance: %w", ErrSessionReadOnly)
+	}
+	return h.persistence.SealSession(current.ID)
+}
+
+// VerifySessionSeal checks the seal of the current session
+func (h *ChatHandler) VerifySessionSeal() (*SessionMetadata, error) {
+	current := h.session.GetCurrent()
+	if h.persistence == nil || current == nil {
+		return nil, fmt.Errorf("there is no session to verify")
+	}
+	return h.persistence.VerifySeal(current.ID)
+}
+
+// IsSessionSealed reports whether the current session is sealed
+func (h *ChatHandler) IsSessionSealed() bool {
+	current := h.session.GetCurrent()
+	return current != nil && h.persistence != nil && h.persistence.IsSealed(current.ID)
+}
+
+// ForkSealedSession makes a fork of the current session current when it is
+// sealed, and returns it; it returns nil when the session is not sealed
+func (h *ChatHandler) ForkSealedSession() (*Session, error) {
+	if !h.IsSessionSealed() {
+		return nil, nil
+	}
+	fork, err := h.ForkSession(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork sealed session: %w", err)
+	}
+	return fork, nil
+}
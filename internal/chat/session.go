@@ -2,6 +2,7 @@ package chat
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -59,11 +60,7 @@ func NewBetterTokenCounter(model string) *BetterTokenCounter {
 
 // CountTokens estimates token count using the tokenizer package
 func (b *BetterTokenCounter) CountTokens(text string) int {
-	tokens, err := tokenizer.EstimateUserMessageTokens(text, b.model)
-	if err != nil {
-		// Fallback to simple estimation on error
-		return len(text) / 4
-	}
+	tokens, _ := tokenizer.Count(text, b.model)
 	return tokens
 }
 
@@ -174,6 +171,104 @@ func (sm *SessionManager) trimMessages(session *Session) {
 	}
 }
 
+// TrimPreview returns how many of the oldest messages trimMessages would
+// remove from the session if a message with text were added
+func (sm *SessionManager) TrimPreview(id string, text string) (int, error) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, exists := sm.sessions[id]
+	if !exists {
+		return 0, fmt.Errorf("session not found: %s", id)
+	}
+
+	startIdx := 0
+	if len(session.Messages) > 0 && session.Messages[0].Role == "system" {
+		startIdx = 1
+	}
+
+	tokens := session.TokenCount + sm.tokenizer.CountTokens(text)
+	i := startIdx
+	for tokens > session.MaxTokens && i < len(session.Messages) {
+		tokens -= sm.tokenizer.CountTokens(session.Messages[i].Content)
+		i++
+	}
+	return i - startIdx, nil
+}
+
+// DropOldest removes the n oldest messages of a session, keeping the system
+// message
+func (sm *SessionManager) DropOldest(id string, n int) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, exists := sm.sessions[id]
+	if !exists {
+		return fmt.Errorf("session not found: %s", id)
+	}
+
+	startIdx := 0
+	if len(session.Messages) > 0 && session.Messages[0].Role == "system" {
+		startIdx = 1
+	}
+	if n > len(session.Messages)-startIdx {
+		n = len(session.Messages) - startIdx
+	}
+
+	for _, msg := range session.Messages[startIdx : startIdx+n] {
+		session.TokenCount -= sm.tokenizer.CountTokens(msg.Content)
+	}
+	session.Messages = append(session.Messages[:startIdx], session.Messages[startIdx+n:]...)
+	session.LastActive = time.Now()
+	return nil
+}
+
+// TruncateMessages keeps the first n messages of a session and removes the
+// rest
+func (sm *SessionManager) TruncateMessages(id string, n int) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, exists := sm.sessions[id]
+	if !exists {
+		return fmt.Errorf("session not found: %s", id)
+	}
+	if n < 0 || n > len(session.Messages) {
+		return fmt.Errorf("invalid message count %d for %d messages", n, len(session.Messages))
+	}
+
+	for _, msg := range session.Messages[n:] {
+		session.TokenCount -= sm.tokenizer.CountTokens(msg.Content)
+	}
+	session.Messages = session.Messages[:n]
+	session.LastActive = time.Now()
+	return nil
+}
+
+// EditMessages replaces the messages of a session with the result of edit,
+// which gets a copy of them
+func (sm *SessionManager) EditMessages(id string, edit func([]ai.Message) ([]ai.Message, error)) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, exists := sm.sessions[id]
+	if !exists {
+		return fmt.Errorf("session not found: %s", id)
+	}
+
+	messages, err := edit(append([]ai.Message(nil), session.Messages...))
+	if err != nil {
+		return err
+	}
+	session.Messages = messages
+	session.TokenCount = 0
+	for _, msg := range messages {
+		session.TokenCount += sm.tokenizer.CountTokens(msg.Content)
+	}
+	session.LastActive = time.Now()
+	return nil
+}
+
 // SetContext sets a context value for the session
 func (sm *SessionManager) SetContext(id string, key string, value interface{}) error {
 	sm.mu.Lock()
@@ -349,6 +444,21 @@ func (sm *SessionManager) CreateSession() (string, error) {
 	return session.ID, nil
 }
 
+// AddSession registers an existing session, such as one loaded from disk,
+// replacing any session with the same ID
+func (sm *SessionManager) AddSession(session *Session) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if session.Context == nil {
+		session.Context = make(map[string]interface{})
+	}
+	if session.MaxTokens == 0 {
+		session.MaxTokens = sm.maxTokens
+	}
+	sm.sessions[session.ID] = session
+}
+
 // SetCurrent sets the current session by ID
 func (sm *SessionManager) SetCurrent(id string) error {
 	sm.mu.Lock()
@@ -366,3 +476,65 @@ func (sm *SessionManager) SetCurrent(id string) error {
 func (sm *SessionManager) AddMessage(sessionID string, msg ai.Message) error {
 	return sm.UpdateSession(sessionID, msg)
 }
+
+// ForkSession creates a new session that copies the first upTo messages of
+// the source session. A non-positive upTo (or one past the end) copies all
+// messages. The source session is left untouched.
+func (sm *SessionManager) ForkSession(id string, upTo int) (*Session, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	source, exists := sm.sessions[id]
+	if !exists {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+
+	if upTo <= 0 || upTo > len(source.Messages) {
+		upTo = len(source.Messages)
+	}
+
+	messages := make([]ai.Message, upTo)
+	copy(messages, source.Messages[:upTo])
+
+	context := make(map[string]interface{}, len(source.Context)+2)
+	for key, value := range source.Context {
+		context[key] = value
+	}
+	context["forked_from"] = source.ID
+	context["forked_at_message"] = upTo
+
+	tokenCount := 0
+	for _, msg := range messages {
+		tokenCount += sm.tokenizer.CountTokens(msg.Content)
+	}
+
+	fork := &Session{
+		ID:         uuid.New().String(),
+		StartedAt:  time.Now(),
+		LastActive: time.Now(),
+		Messages:   messages,
+		Context:    context,
+		MaxTokens:  source.MaxTokens,
+		TokenCount: tokenCount,
+	}
+
+	sm.sessions[fork.ID] = fork
+	return fork, nil
+}
+
+// ListSessionsByActivity returns all sessions ordered by most recent activity
+func (sm *SessionManager) ListSessionsByActivity() []*Session {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActive.After(sessions[j].LastActive)
+	})
+
+	return sessions
+}
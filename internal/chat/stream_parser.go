@@ -0,0 +1,140 @@
+package chat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// DefaultReparseInterval is the least time between two parses of a response
+// for tool calls while it streams
+const DefaultReparseInterval = 100 * time.Millisecond
+
+// StreamToolParser finds the tool calls of a streamed response. Parsing
+// reads the whole response, so it is not done for every chunk: only after a
+// chunk with a closing brace, since tool calls are JSON objects, and at most
+// once per interval. Finish parses whatever arrived since.
+type StreamToolParser struct {
+	structured bool
+	text       *TextToolCallParser
+	interval   time.Duration
+	now        func() time.Time
+
+	content   strings.Builder
+	lastParse time.Time
+	dirty     bool // A closing brace arrived since the last parse
+	parses    int
+	toolCalls []ai.ToolCall
+}
+
+// StreamToolParserOption configures a StreamToolParser
+type StreamToolParserOption func(*StreamToolParser)
+
+// WithReparseInterval sets the least time between two parses; 0 parses
+// after every chunk with a closing brace
+func WithReparseInterval(interval time.Duration) StreamToolParserOption {
+	return func(p *StreamToolParser) {
+		p.interval = interval
+	}
+}
+
+// withParserClock replaces the clock in tests
+func withParserClock(now func() time.Time) StreamToolParserOption {
+	return func(p *StreamToolParser) {
+		p.now = now
+	}
+}
+
+// NewStreamToolParser creates a parser for a response in the structured
+// output format when structured is set, or in the text format
+func NewStreamToolParser(structured bool, opts ...StreamToolParserOption) *StreamToolParser {
+	p := &StreamToolParser{
+		structured: structured,
+		text:       NewTextToolCallParser(),
+		interval:   DefaultReparseInterval,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Add appends a chunk of the response and returns the tool calls found so
+// far
+func (p *StreamToolParser) Add(chunk string) []ai.ToolCall {
+	p.content.WriteString(chunk)
+	if strings.Contains(chunk, "}") {
+		p.dirty = true
+	}
+	if p.dirty && p.now().Sub(p.lastParse) >= p.interval {
+		p.parse()
+	}
+	return p.toolCalls
+}
+
+// Content returns the response so far
+func (p *StreamToolParser) Content() string {
+	return p.content.String()
+}
+
+// ToolCalls returns the tool calls found so far
+func (p *StreamToolParser) ToolCalls() []ai.ToolCall {
+	return p.toolCalls
+}
+
+// Finish parses the complete response and returns its text without the tool
+// calls, and the tool calls. A structured response that does not parse is
+// returned as it is.
+func (p *StreamToolParser) Finish() (string, []ai.ToolCall) {
+	content := p.content.String()
+	p.parses++
+
+	if p.structured {
+		toolResp, err := ParseStructuredOutput(content)
+		if err != nil {
+			return content, p.toolCalls
+		}
+		var text string
+		if toolResp.Text != nil {
+			text = *toolResp.Text
+		}
+		if len(toolResp.ToolCalls) > 0 {
+			if toolCalls, err := ConvertToAIToolCalls(toolResp.ToolCalls); err == nil {
+				p.toolCalls = toolCalls
+			}
+		}
+		return text, p.toolCalls
+	}
+
+	text, toolCalls, _ := p.text.ParseMessage(content)
+	if len(toolCalls) > 0 {
+		p.toolCalls = toolCalls
+	}
+	return text, p.toolCalls
+}
+
+// parse looks for tool calls in the response so far. A response that does
+// not parse yet keeps the tool calls found before.
+func (p *StreamToolParser) parse() {
+	p.dirty = false
+	p.lastParse = p.now()
+	p.parses++
+
+	content := p.content.String()
+	if p.structured {
+		toolResp, err := ParseStructuredOutput(content)
+		if err != nil || len(toolResp.ToolCalls) == 0 {
+			return
+		}
+		if toolCalls, err := ConvertToAIToolCalls(toolResp.ToolCalls); err == nil {
+			p.toolCalls = toolCalls
+		}
+		return
+	}
+
+	if _, toolCalls, err := p.text.ParseMessage(content); err == nil && len(toolCalls) > 0 {
+		p.toolCalls = toolCalls
+	}
+}
@@ -0,0 +1,123 @@
+package chat
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunks splits s into pieces of size bytes
+func chunks(s string, size int) []string {
+	var pieces []string
+	for i := 0; i < len(s); i += size {
+		pieces = append(pieces, s[i:min(i+size, len(s))])
+	}
+	return pieces
+}
+
+func TestStreamToolParserSplitBoundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		structured bool
+		response   string
+		wantText   string
+		wantTool   string
+		wantArgs   string
+	}{
+		{
+			name:       "structured",
+			structured: true,
+			response:   `{"response_type":"both","text":"Reading it","tool_calls":[{"tool":"read_file","arguments":{"path":"main.go"}}]}`,
+			wantText:   "Reading it",
+			wantTool:   "read_file",
+			wantArgs:   `{"path":"main.go"}`,
+		},
+		{
+			name:       "structured with braces in a string",
+			structured: true,
+			response:   `{"response_type":"tool_call","text":null,"tool_calls":[{"tool":"write_file","arguments":{"content":"func main() {}\n","path":"main.go"}}]}`,
+			wantTool:   "write_file",
+			wantArgs:   `{"content":"func main() {}\n","path":"main.go"}`,
+		},
+		{
+			name:     "text",
+			response: "Let me look.\n----\n{\"tool\": \"list_files\", \"arguments\": {\"path\": \".\"}}",
+			wantText: "Let me look.",
+			wantTool: "list_files",
+			wantArgs: `{"path":"."}`,
+		},
+	}
+	for _, tt := range tests {
+		for _, size := range []int{1, 2, 7, len(tt.response)} {
+			t.Run(fmt.Sprintf("%s in chunks of %d", tt.name, size), func(t *testing.T) {
+				parser := NewStreamToolParser(tt.structured, WithReparseInterval(0))
+				pieces := chunks(tt.response, size)
+				for i, piece := range pieces {
+					toolCalls := parser.Add(piece)
+					if i < len(pieces)-1 {
+						assert.Empty(t, toolCalls, "no tool call before the JSON is complete")
+					}
+				}
+				require.Len(t, parser.ToolCalls(), 1, "the call is found once its last chunk arrives")
+
+				text, toolCalls := parser.Finish()
+				assert.Equal(t, tt.wantText, text)
+				require.Len(t, toolCalls, 1)
+				assert.Equal(t, tt.wantTool, toolCalls[0].Function.Name)
+				assert.JSONEq(t, tt.wantArgs, toolCalls[0].Function.Arguments)
+				assert.Equal(t, tt.response, parser.Content())
+			})
+		}
+	}
+}
+
+func TestStreamToolParserOnlyParsesClosingChunks(t *testing.T) {
+	parser := NewStreamToolParser(false, WithReparseInterval(0))
+	for _, piece := range chunks("A long answer without any tool call in it. ", 4) {
+		parser.Add(piece)
+	}
+	assert.Zero(t, parser.parses, "chunks without a closing brace are not parsed")
+
+	parser.Add("{}")
+	assert.Equal(t, 1, parser.parses)
+}
+
+func TestStreamToolParserDebounces(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	parser := NewStreamToolParser(true, WithReparseInterval(100*time.Millisecond), withParserClock(func() time.Time {
+		return now
+	}))
+
+	response := `{"response_type":"tool_call","text":null,"tool_calls":[{"tool":"read_file","arguments":{"path":"a.go"}}]}`
+	head, tail := response[:len(response)-2], response[len(response)-2:]
+
+	parser.Add(head)
+	assert.Equal(t, 1, parser.parses, "the first closing brace is parsed")
+
+	now = now.Add(10 * time.Millisecond)
+	assert.Empty(t, parser.Add(tail), "a parse within the interval waits")
+	assert.Equal(t, 1, parser.parses)
+
+	now = now.Add(100 * time.Millisecond)
+	toolCalls := parser.Add("\n")
+	require.Len(t, toolCalls, 1, "the next chunk after the interval parses what was waiting")
+	assert.Equal(t, 2, parser.parses)
+
+	parser.Add(" ")
+	assert.Equal(t, 2, parser.parses, "nothing waits once parsed")
+
+	_, toolCalls = parser.Finish()
+	assert.Len(t, toolCalls, 1)
+}
+
+func TestStreamToolParserFinishKeepsUnparsedStructuredOutput(t *testing.T) {
+	parser := NewStreamToolParser(true)
+	parser.Add("plain text instead of JSON")
+
+	text, toolCalls := parser.Finish()
+	assert.Equal(t, "plain text instead of JSON", text)
+	assert.Empty(t, toolCalls)
+}
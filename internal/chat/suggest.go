@@ -0,0 +1,64 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// Limits on the conversation sent for a prompt suggestion
+const (
+	suggestionMessages     = 6
+	suggestionMessageChars = 600
+)
+
+const suggestionPrompt = `You predict the next message a developer will send to a coding assistant.
+Given the end of their conversation and what they have typed so far, reply with
+the single most likely next message: one line, no quotes, no explanation.
+If they have started typing, the message must start with exactly that text.`
+
+// SuggestPrompt asks model for the prompt the user is likely to send next,
+// continuing partial when it is not empty. Only the text of the last user
+// and assistant messages is sent; tool results stay local.
+func SuggestPrompt(ctx context.Context, client ai.Client, model string, history []ai.Message, partial string) (string, error) {
+	var recent []string
+	for i := len(history) - 1; i >= 0 && len(recent) < suggestionMessages; i-- {
+		msg := history[i]
+		if (msg.Role != ai.RoleUser && msg.Role != ai.RoleAssistant) || msg.Content == "" {
+			continue
+		}
+		if strings.HasPrefix(msg.Content, "TOOL_RESULT[") {
+			continue
+		}
+		recent = append(recent, fmt.Sprintf("%s: %s", msg.Role, strings.ToValidUTF8(truncateString(msg.Content, suggestionMessageChars), "")))
+	}
+
+	var conversation strings.Builder
+	for i := len(recent) - 1; i >= 0; i-- {
+		conversation.WriteString(recent[i])
+		conversation.WriteString("\n\n")
+	}
+	if conversation.Len() == 0 {
+		conversation.WriteString("(no messages yet)\n\n")
+	}
+	fmt.Fprintf(&conversation, "Typed so far: %s", partial)
+
+	resp, err := client.ChatCompletion(ctx, ai.ChatRequest{
+		Model: model,
+		Messages: []ai.Message{
+			{Role: ai.RoleSystem, Content: suggestionPrompt},
+			{Role: ai.RoleUser, Content: conversation.String()},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("suggestion request failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("suggestion request returned no choices")
+	}
+
+	suggestion, _, _ := strings.Cut(strings.TrimSpace(resp.Choices[0].Message.Content), "\n")
+	return strings.Trim(strings.TrimSpace(suggestion), "\"`"), nil
+}
@@ -245,6 +245,12 @@ func (p *TextToolCallParser) ParseMessage(content string) (string, []ai.ToolCall
 		}
 	}
 
+	// Each part numbers its calls from 1, so number them again across parts
+	for i := range allToolCalls {
+		allToolCalls[i].ID = fmt.Sprintf("call_%d", i+1)
+		allToolCalls[i].Index = i
+	}
+
 	// Join clean text parts
 	cleanContent := strings.Join(cleanTexts, "\n\n")
 
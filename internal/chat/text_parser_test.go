@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+func TestParseMessageNumbersCallsAcrossParts(t *testing.T) {
+	content := "Listing first.\n----\n{\"tool\": \"list_files\", \"arguments\": {\"path\": \".\"}}\n----\n{\"tool\": \"read_file\", \"arguments\": {\"path\": \"go.mod\"}}"
+
+	text, toolCalls, err := NewTextToolCallParser().ParseMessage(content)
+	require.NoError(t, err)
+	assert.Equal(t, "Listing first.", text)
+	require.Len(t, toolCalls, 2)
+	assert.Equal(t, "call_1", toolCalls[0].ID)
+	assert.Equal(t, "call_2", toolCalls[1].ID)
+	assert.Equal(t, 1, toolCalls[1].Index)
+}
+
+// checkToolCalls fails when parsed tool calls could not be executed and
+// answered: their IDs must be distinct and their arguments JSON
+func checkToolCalls(t *testing.T, toolCalls []ai.ToolCall) {
+	t.Helper()
+	ids := make(map[string]bool)
+	for i, call := range toolCalls {
+		if ids[call.ID] {
+			t.Fatalf("tool call %d reuses the ID %q", i, call.ID)
+		}
+		ids[call.ID] = true
+		if call.Index != i {
+			t.Fatalf("tool call %d has the index %d", i, call.Index)
+		}
+		if !json.Valid([]byte(call.Function.Arguments)) {
+			t.Fatalf("tool call %d has arguments that are not JSON: %q", i, call.Function.Arguments)
+		}
+	}
+}
+
+// FuzzParseMessage feeds the text format parser what models write instead
+// of well-formed tool calls. Run it with
+//
+//	go test ./internal/chat -run '^$' -fuzz FuzzParseMessage
+func FuzzParseMessage(f *testing.F) {
+	seeds := []string{
+		"Let me look.\n----\n{\"tool\": \"list_files\", \"arguments\": {\"path\": \".\"}}",
+		"{\"tool\": \"a\", \"arguments\": {}}\n----\n{\"tool\": \"b\", \"arguments\": {}}",
+		"{\"tool\": \"read_file\", \"arguments\": {\"path\": \"main.go\"",
+		"{\"tool\": \"write_file\", \"arguments\": {\"content\": \"func main() {}\", \"path\": \"main.go\"}}",
+		"{\"tool\": \"x\", \"arguments\": {\"nested\": {\"deep\": [1, {\"a\": null}]}}}",
+		`{"response_type": "both", "text": "Reading", "tool_calls": [{"tool": "read_file", "arguments": {"path": "a"}}]}`,
+		`{"response_type": "tool_call", "text": null, "tool_calls": [{"tool": "", "arguments": null}]}`,
+		`{"response_type": "text", "text": 42}`,
+		"\n----\n\n----\n",
+		"```json\n{\"tool\": \"run\", \"arguments\": {\"cmd\": \"ls\"}}\n```",
+		strings.Repeat("{\"tool\": \"t\", \"arguments\": {", 50),
+		strings.Repeat("[", 1000),
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	parser := NewTextToolCallParser()
+	f.Fuzz(func(t *testing.T, content string) {
+		_, toolCalls, err := parser.ParseMessage(content)
+		if err != nil {
+			return
+		}
+		checkToolCalls(t, toolCalls)
+		parser.ExtractContentWithoutToolCalls(content)
+	})
+}
+
+// FuzzStreamToolParser checks that a response parses the same however it is
+// split into chunks
+func FuzzStreamToolParser(f *testing.F) {
+	f.Add("Let me look.\n----\n{\"tool\": \"list_files\", \"arguments\": {\"path\": \".\"}}", uint(20), false)
+	f.Add(`{"response_type":"both","text":"Reading it","tool_calls":[{"tool":"read_file","arguments":{"path":"main.go"}}]}`, uint(7), true)
+	f.Add(`{"response_type":"tool_call","text":null,"tool_calls":[{"tool":"x","arguments":{"a":"}"`, uint(3), true)
+
+	f.Fuzz(func(t *testing.T, response string, size uint, structured bool) {
+		whole := NewStreamToolParser(structured, WithReparseInterval(0))
+		whole.Add(response)
+		wantText, wantCalls := whole.Finish()
+
+		parser := NewStreamToolParser(structured, WithReparseInterval(0))
+		for _, piece := range chunks(response, int(size%64)+1) {
+			checkToolCalls(t, parser.Add(piece))
+		}
+		text, toolCalls := parser.Finish()
+		checkToolCalls(t, toolCalls)
+
+		if text != wantText {
+			t.Fatalf("text %q, want %q", text, wantText)
+		}
+		if len(wantCalls) > 0 && fmt.Sprint(toolCalls) != fmt.Sprint(wantCalls) {
+			t.Fatalf("tool calls %v, want %v", toolCalls, wantCalls)
+		}
+	})
+}
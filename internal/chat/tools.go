@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,11 @@ type ToolExecutor struct {
 	concurrency int
 	timeout     time.Duration
 	retryPolicy RetryPolicy
+
+	workspace   string
+	rules       *security.ApprovalRules
+	rulesMu     sync.Mutex
+	ruleMatches map[string]int
 }
 
 // ApprovalHandler defines the interface for tool execution approval
@@ -30,6 +36,13 @@ type ApprovalHandler interface {
 	IsAutoApproved(tool string) bool
 }
 
+// DangerConfirmer is implemented by approval handlers that ask a person about
+// each high-risk tool call, showing why it is dangerous. ToolExecutor denies
+// high-risk calls when its approval handler cannot confirm them.
+type DangerConfirmer interface {
+	ConfirmDangerous(ctx context.Context, tool string, params map[string]interface{}, dangers []string) (bool, error)
+}
+
 // RetryPolicy defines retry behavior for tool execution
 type RetryPolicy struct {
 	MaxAttempts int
@@ -74,6 +87,25 @@ func WithRetryPolicy(policy RetryPolicy) ToolExecutorOption {
 	}
 }
 
+// WithApprovalRules decides tool calls with the approval rules before the
+// approval handler is asked: calls a rule denies fail, and calls a rule
+// approves run without asking
+func WithApprovalRules(rules *security.ApprovalRules) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.rules = rules
+	}
+}
+
+// WithWorkspace sets the workspace root that writes outside of are
+// high-risk, the current directory by default
+func WithWorkspace(root string) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		if root != "" {
+			e.workspace = root
+		}
+	}
+}
+
 // NewToolExecutor creates a new tool executor
 func NewToolExecutor(manager *tools.Manager, mcpManager mcp.Manager, validator security.SecurityValidator, approver ApprovalHandler, opts ...ToolExecutorOption) *ToolExecutor {
 	e := &ToolExecutor{
@@ -83,11 +115,13 @@ func NewToolExecutor(manager *tools.Manager, mcpManager mcp.Manager, validator s
 		approver:    approver,
 		concurrency: 5, // Default concurrency
 		timeout:     30 * time.Second,
+		workspace:   ".",
 		retryPolicy: RetryPolicy{
 			MaxAttempts: 3,
 			Delay:       1 * time.Second,
 			BackoffRate: 2.0,
 		},
+		ruleMatches: make(map[string]int),
 	}
 
 	// Apply options
@@ -181,34 +215,57 @@ func (e *ToolExecutor) executeSingleTool(ctx context.Context, toolCall ai.ToolCa
 	// Parse arguments
 	var args map[string]interface{}
 	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-		result.Error = fmt.Errorf("failed to parse arguments: %w", err)
+		result.Error = &tools.ToolError{Tool: result.ToolName, Code: tools.ErrCodeInvalidParams, Message: fmt.Sprintf("failed to parse arguments: %v", err), Err: err}
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
 	// Security validation
 	if err := e.validateToolCall(toolCall.Function.Name, args); err != nil {
-		result.Error = fmt.Errorf("security validation failed: %w", err)
+		result.Error = &tools.ToolError{Tool: result.ToolName, Code: tools.ErrCodePermissionDenied, Message: fmt.Sprintf("security validation failed: %v", err), Err: err}
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
-	// Request approval if needed
-	approved, err := e.requestApproval(ctx, toolCall.Function.Name, args)
+	// Approval rules decide before anyone is asked
+	match := e.matchApprovalRule(toolCall.Function.Name, args)
+	if match != nil && !match.Approved() {
+		result.Error = &tools.ToolError{Tool: result.ToolName, Code: tools.ErrCodePermissionDenied, Message: fmt.Sprintf("tool call denied by approval rule %s", match.Rule)}
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	// High-risk calls are never approved by rules or auto-approval
+	dangers := security.DangerousArguments(toolCall.Function.Name, args, e.workspace)
+	var approved bool
+	var err error
+	switch {
+	case len(dangers) > 0:
+		approved, err = e.confirmDangerous(ctx, toolCall.Function.Name, args, dangers)
+	case match != nil:
+		approved = true
+	default:
+		approved, err = e.requestApproval(ctx, toolCall.Function.Name, args)
+	}
 	if err != nil {
 		result.Error = fmt.Errorf("approval request failed: %w", err)
 		result.Duration = time.Since(startTime)
 		return result
 	}
 	if !approved {
-		result.Error = fmt.Errorf("tool execution not approved by user")
+		message := "tool execution not approved by user"
+		if len(dangers) > 0 {
+			message = fmt.Sprintf("high-risk tool call not confirmed by user (%s)", strings.Join(dangers, ", "))
+		}
+		result.Error = &tools.ToolError{Tool: result.ToolName, Code: tools.ErrCodePermissionDenied, Message: message}
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
-	// Execute with retry
-	var execErr error
-	for attempt := 1; attempt <= e.retryPolicy.MaxAttempts; attempt++ {
+	// Execute, retrying transient failures only
+	var execErr *tools.ToolError
+	attempt := 1
+	for ; attempt <= e.retryPolicy.MaxAttempts; attempt++ {
 		var execResult interface{}
 		var err error
 
@@ -234,13 +291,13 @@ func (e *ToolExecutor) executeSingleTool(ctx context.Context, toolCall ai.ToolCa
 					// Execute MCP tool
 					execResult, err = e.mcpManager.ExecuteTool(serverName, toolCall.Function.Name, args)
 				} else {
-					err = fmt.Errorf("MCP tool not found: %s", toolCall.Function.Name)
+					err = tools.NewToolError(tools.ErrCodeNotFound, "MCP tool not found: %s", toolCall.Function.Name)
 				}
 			} else {
-				err = mcpErr
+				err = &tools.ToolError{Code: tools.ErrCodeUnavailable, Message: mcpErr.Error(), Retryable: true, Err: mcpErr}
 			}
 		} else {
-			err = fmt.Errorf("tool not available: %s", toolCall.Function.Name)
+			err = tools.NewToolError(tools.ErrCodeNotFound, "tool not available: %s", toolCall.Function.Name)
 		}
 
 		if err == nil {
@@ -249,7 +306,10 @@ func (e *ToolExecutor) executeSingleTool(ctx context.Context, toolCall ai.ToolCa
 			return result
 		}
 
-		execErr = err
+		execErr = tools.AsToolError(toolCall.Function.Name, err)
+		if !execErr.Retryable {
+			break
+		}
 		if attempt < e.retryPolicy.MaxAttempts {
 			delay := time.Duration(float64(e.retryPolicy.Delay) * float64(attempt-1) * e.retryPolicy.BackoffRate)
 			select {
@@ -263,13 +323,20 @@ func (e *ToolExecutor) executeSingleTool(ctx context.Context, toolCall ai.ToolCa
 		}
 	}
 
-	result.Error = fmt.Errorf("failed after %d attempts: %w", e.retryPolicy.MaxAttempts, execErr)
+	if attempt > 1 {
+		execErr.WithDetails(map[string]interface{}{"attempts": min(attempt, e.retryPolicy.MaxAttempts)})
+	}
+	result.Error = execErr
 	result.Duration = time.Since(startTime)
 	return result
 }
 
 // validateToolCall performs security validation on a tool call
 func (e *ToolExecutor) validateToolCall(toolName string, args map[string]interface{}) error {
+	if !e.manager.IsEnabled(toolName) {
+		return fmt.Errorf("tool %s is disabled for this project", toolName)
+	}
+
 	// Check if tool exists in regular manager or MCP manager
 	_, err := e.manager.Get(toolName)
 	if err != nil {
@@ -321,6 +388,16 @@ func (e *ToolExecutor) validateToolCall(toolName string, args map[string]interfa
 	return nil
 }
 
+// matchApprovalRule returns the approval rule deciding a call, or nil
+func (e *ToolExecutor) matchApprovalRule(toolName string, args map[string]interface{}) *security.RuleMatch {
+	if e.rules.Len() == 0 {
+		return nil
+	}
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+	return e.rules.Evaluate(toolName, args, e.ruleMatches)
+}
+
 // requestApproval requests user approval for tool execution
 func (e *ToolExecutor) requestApproval(ctx context.Context, toolName string, args map[string]interface{}) (bool, error) {
 	// Check if auto-approved
@@ -332,6 +409,16 @@ func (e *ToolExecutor) requestApproval(ctx context.Context, toolName string, arg
 	return e.approver.RequestApproval(ctx, toolName, args)
 }
 
+// confirmDangerous asks a person to confirm a high-risk call. Approval
+// handlers that cannot ask, like those of headless runs, deny it.
+func (e *ToolExecutor) confirmDangerous(ctx context.Context, toolName string, args map[string]interface{}, dangers []string) (bool, error) {
+	confirmer, ok := e.approver.(DangerConfirmer)
+	if !ok {
+		return false, nil
+	}
+	return confirmer.ConfirmDangerous(ctx, toolName, args, dangers)
+}
+
 // FormatToolResults formats tool results for AI consumption
 func (e *ToolExecutor) FormatToolResults(results []ToolResult) []ai.Message {
 	messages := make([]ai.Message, 0, len(results))
@@ -351,7 +438,7 @@ func (e *ToolExecutor) FormatToolResults(results []ToolResult) []ai.Message {
 // formatSingleResult formats a single tool result
 func (e *ToolExecutor) formatSingleResult(result ToolResult) string {
 	if result.Error != nil {
-		return fmt.Sprintf("Error executing %s: %v", result.ToolName, result.Error)
+		return fmt.Sprintf("Error executing %s: %s", result.ToolName, tools.FormatError(result.ToolName, result.Error))
 	}
 
 	// Format based on result type
@@ -371,6 +458,87 @@ func (e *ToolExecutor) formatSingleResult(result ToolResult) string {
 	}
 }
 
+// ToolResultContent renders a tool result as the text sent back to the model
+func ToolResultContent(result ToolResult) string {
+	content := ""
+	if result.Error != nil {
+		content = fmt.Sprintf("Tool execution failed: %s", tools.FormatError(result.ToolName, result.Error))
+	} else if result.Result == nil {
+		content = "Tool executed successfully"
+	} else {
+		switch v := result.Result.(type) {
+		case string:
+			content = v
+		case []byte:
+			content = string(v)
+		default:
+			if data, err := json.Marshal(v); err == nil {
+				content = string(data)
+			} else {
+				content = fmt.Sprintf("%v", v)
+			}
+		}
+	}
+
+	// Ensure content is never empty
+	if content == "" {
+		content = "Tool executed successfully with empty result"
+	}
+
+	return content
+}
+
+// ToolResultMessage builds the session message carrying a tool result.
+// Results are sent as user messages (text-based tool calling) in the form
+// TOOL_RESULT[name]: content. Content the agent did not write is wrapped in
+// untrusted-content markers.
+func ToolResultMessage(result ToolResult) ai.Message {
+	content := ToolResultContent(result)
+	if result.Error == nil && IsUntrustedTool(result.ToolName) {
+		content = security.WrapUntrusted(result.ToolName, content)
+	}
+	return ai.Message{
+		Role:    ai.RoleUser,
+		Content: fmt.Sprintf("TOOL_RESULT[%s]: %s", result.ToolName, content),
+	}
+}
+
+// trustedTools return only data coda produces itself (confirmations);
+// every other tool, including MCP tools, returns outside content. File
+// names are chosen by whoever wrote the repository, so list_files is not
+// trusted either.
+var trustedTools = map[string]bool{
+	"write_file":    true,
+	"edit_file":     true,
+	"save_artifact": true,
+}
+
+// IsUntrustedTool reports whether the results of the tool may contain
+// content written by someone else, such as files, command output or web pages
+func IsUntrustedTool(name string) bool {
+	return !trustedTools[name]
+}
+
+// InjectionWarning describes the instruction-like passages of an untrusted
+// tool result, or returns "" when there are none
+func InjectionWarning(result ToolResult) string {
+	if result.Error != nil || !IsUntrustedTool(result.ToolName) {
+		return ""
+	}
+	findings := security.ScanInjection(ToolResultContent(result))
+	if len(findings) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Warning: the result of %s contains text that may try to instruct the agent:", result.ToolName)
+	for _, finding := range findings {
+		fmt.Fprintf(&b, "\n  - %s: %q", finding.Reason, finding.Excerpt)
+	}
+	b.WriteString("\nIt was sent as untrusted content; review the next actions carefully.")
+	return b.String()
+}
+
 // ToolCallAnalyzer analyzes tool calls for patterns and optimization
 type ToolCallAnalyzer struct {
 	history []ToolResult
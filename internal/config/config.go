@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/common-creation/coda/internal/logging"
+	"gopkg.in/yaml.v3"
 )
 
 // Config represents the complete configuration for CODA
@@ -26,6 +29,35 @@ type Config struct {
 
 	// Session configuration
 	Session SessionConfig `yaml:"session" json:"session"`
+
+	// Self-update configuration
+	Update UpdateConfig `yaml:"update" json:"update"`
+
+	// Embeddings used by semantic_search and documentation retrieval
+	Embeddings EmbeddingsConfig `yaml:"embeddings" json:"embeddings"`
+
+	// System prompt presets selected with /persona, by name
+	Personas map[string]Persona `yaml:"personas,omitempty" json:"personas,omitempty"`
+
+	// Operators of coda serve and the tools their roles may request
+	Serve ServeConfig `yaml:"serve" json:"serve"`
+
+	// How long old sessions, artifacts and logs are kept
+	Retention RetentionConfig `yaml:"retention" json:"retention"`
+}
+
+// Persona is a named system prompt preset such as "reviewer" or
+// "test-writer", selected for a session with /persona
+type Persona struct {
+	// Shown in the /persona list
+	Description string `yaml:"description" json:"description"`
+
+	// Instructions added to the system prompt while the persona is active
+	Prompt string `yaml:"prompt" json:"prompt"`
+
+	// Approval rules checked before tools.permit.rules while the persona is
+	// active, e.g. to deny write_file for a reviewer
+	Rules []ApprovalRule `yaml:"rules" json:"rules"`
 }
 
 // AIConfig contains AI provider specific configuration
@@ -36,9 +68,14 @@ type AIConfig struct {
 	// API key for authentication
 	APIKey string `yaml:"api_key" json:"api_key"`
 
-	// Model name to use
+	// Model name to use. In YAML it may also be a prioritized list, whose
+	// first entry becomes Model and the others FallbackModels.
 	Model string `yaml:"model" json:"model"`
 
+	// Models tried in order when Model is unavailable, blocks the content or
+	// stays rate limited (openai provider only)
+	FallbackModels []string `yaml:"fallback_models,omitempty" json:"fallback_models,omitempty"`
+
 	// Temperature for response generation (0-2)
 	Temperature float32 `yaml:"temperature" json:"temperature"`
 
@@ -55,10 +92,64 @@ type AIConfig struct {
 	// Valid values: "minimal", "low", "medium", "high"
 	ReasoningEffort *string `yaml:"reasoning_effort,omitempty" json:"reasoning_effort,omitempty"`
 
-	// Use Structured Outputs for tool calls (requires GPT-4o-2024-08-06 or later)
+	// Use Structured Outputs for tool calls; ignored for models without
+	// support, whose tool calls are parsed from the response text
 	UseStructuredOutputs bool `yaml:"use_structured_outputs" json:"use_structured_outputs"`
+
+	// URL or file of a model metadata dataset (context windows, prices,
+	// retirement dates) applied on refresh (optional)
+	MetadataURL string `yaml:"metadata_url,omitempty" json:"metadata_url,omitempty"`
+
+	// File caching the refreshed model metadata
+	MetadataCache string `yaml:"metadata_cache" json:"metadata_cache"`
+
+	// Hours after which model metadata is refreshed at startup (negative
+	// disables the refresh; /models refresh always works)
+	MetadataRefreshHours int `yaml:"metadata_refresh_hours" json:"metadata_refresh_hours"`
+
+	// Transformations applied to each answer before it is shown and saved
+	PostProcess PostProcessConfig `yaml:"post_process" json:"post_process"`
+
+	// Regular expressions whose matches are replaced with [redacted] in
+	// everything sent to the provider, e.g. internal host names
+	Redact []string `yaml:"redact,omitempty" json:"redact,omitempty"`
+
+	// Cost limits in USD of a single request and of all requests of a run,
+	// checked with the model's prices before each request (0 for none).
+	// Requests to models without a known price fail while a limit is set.
+	MaxRequestCost float64 `yaml:"max_request_cost,omitempty" json:"max_request_cost,omitempty"`
+	MaxSessionCost float64 `yaml:"max_session_cost,omitempty" json:"max_session_cost,omitempty"`
 }
 
+// PostProcessConfig selects the transformations applied to answers
+type PostProcessConfig struct {
+	// Steps applied in order: "strip_disclaimers", "fence_languages",
+	// "relative_paths" and "replace"
+	Steps []string `yaml:"steps" json:"steps"`
+
+	// Regular expressions of sentences removed by strip_disclaimers in
+	// addition to the built-in ones
+	Disclaimers []string `yaml:"disclaimers,omitempty" json:"disclaimers,omitempty"`
+
+	// Rewrites applied by replace
+	Replacements []Replacement `yaml:"replacements,omitempty" json:"replacements,omitempty"`
+}
+
+// Replacement rewrites the matches of a regular expression; With may refer
+// to groups as $1
+type Replacement struct {
+	Pattern string `yaml:"pattern" json:"pattern"`
+	With    string `yaml:"with" json:"with"`
+}
+
+// Post-processing steps
+const (
+	PostProcessStripDisclaimers = "strip_disclaimers"
+	PostProcessFenceLanguages   = "fence_languages"
+	PostProcessRelativePaths    = "relative_paths"
+	PostProcessReplace          = "replace"
+)
+
 // OpenAIConfig contains OpenAI specific settings
 type OpenAIConfig struct {
 	// Base URL for OpenAI API (optional, for custom endpoints)
@@ -90,6 +181,259 @@ type ToolsConfig struct {
 
 	// Auto-approval for certain operations
 	AutoApprove bool `yaml:"auto_approve" json:"auto_approve"`
+
+	// Report what mutating tools would do instead of running them
+	DryRun bool `yaml:"dry_run" json:"dry_run"`
+
+	// Resource limits enforced around every tool execution
+	Sandbox SandboxConfig `yaml:"sandbox" json:"sandbox"`
+
+	// Tool call permission prompt behavior
+	Permit PermitConfig `yaml:"permit" json:"permit"`
+
+	// Environment of tools that run commands
+	Exec ExecConfig `yaml:"exec" json:"exec"`
+
+	// Append the exported API of a Go file's package and of its direct
+	// imports when read_file reads a whole Go file
+	GoPackageContext bool `yaml:"go_package_context" json:"go_package_context"`
+
+	// Tools offered to the model: names or globs such as "mcp_*". When
+	// Enabled is set only matching tools are registered; Disabled tools never
+	// are. Set them in a project's .coda/config.yaml to, for example, allow
+	// only read-only tools in an unfamiliar repository.
+	Enabled  []string `yaml:"enabled" json:"enabled"`
+	Disabled []string `yaml:"disabled" json:"disabled"`
+
+	// Commands run before and after tool calls
+	Hooks []ToolHook `yaml:"hooks" json:"hooks"`
+
+	// Formatters run on the files written by write_file and edit_file
+	Format []FormatterConfig `yaml:"format" json:"format"`
+
+	// Build or typecheck run after tool calls change files
+	CompileCheck CompileCheckConfig `yaml:"compile_check" json:"compile_check"`
+
+	// Kubernetes manifest validation and cluster access
+	Kubernetes KubernetesConfig `yaml:"kubernetes" json:"kubernetes"`
+
+	// Databases the query_db tool can query
+	Database DatabaseConfig `yaml:"database" json:"database"`
+
+	// Hosts and credentials of the http_request tool
+	HTTP HTTPConfig `yaml:"http" json:"http"`
+}
+
+// CompileCheckConfig runs a quick build after each batch of tool calls that
+// changes files. Failures are sent back to the model to fix, at most
+// MaxRetries times in a row.
+type CompileCheckConfig struct {
+	// Shell command, e.g. "go build ./..." or "npx tsc --noEmit"; empty
+	// disables the check
+	Command string `yaml:"command" json:"command"`
+
+	// Failing checks sent back to the model before giving up (default: 3)
+	MaxRetries int `yaml:"max_retries" json:"max_retries"`
+
+	// Time limit of the command (default: 120)
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"`
+}
+
+// KubernetesConfig configures the validate_k8s and k8s_diff tools
+type KubernetesConfig struct {
+	// Kubernetes version the manifests are validated against, e.g. "1.29.0"
+	// (default: the latest schemas)
+	Version string `yaml:"version" json:"version"`
+
+	// Extra schema locations for kubeconform, e.g. for custom resources
+	SchemaLocations []string `yaml:"schema_locations" json:"schema_locations"`
+
+	// Let run_command change clusters with kubectl apply, create, replace,
+	// patch or delete; such commands are denied unless this is set
+	AllowApply bool `yaml:"allow_apply" json:"allow_apply"`
+}
+
+// DatabaseConfig configures the query_db tool
+type DatabaseConfig struct {
+	// Databases the tool can query; it is registered only when one is set
+	Connections []DatabaseConnection `yaml:"connections" json:"connections"`
+
+	// Token budget of a result table; rows past it are left out
+	// (default: 2000)
+	MaxResultTokens int `yaml:"max_result_tokens" json:"max_result_tokens"`
+}
+
+// DatabaseConnection is a database the query_db tool can query
+type DatabaseConnection struct {
+	// Name the model refers to the database by
+	Name string `yaml:"name" json:"name"`
+
+	// postgres://, mysql:// or sqlite: URL; ${VAR} references are expanded
+	// from the environment
+	DSN string `yaml:"dsn" json:"dsn"`
+
+	// Let approved queries change the database; queries are read-only
+	// unless this is set
+	AllowWrites bool `yaml:"allow_writes" json:"allow_writes"`
+}
+
+// HTTPConfig configures the http_request tool
+type HTTPConfig struct {
+	// Hosts requests may be sent to: "api.example.com", "*.example.com" for
+	// its subdomains, "localhost:8080" for one port, or "*" for any host. The
+	// tool is registered only when one is set.
+	AllowedDomains []string `yaml:"allowed_domains" json:"allowed_domains"`
+
+	// Headers added to the requests to a host, by host pattern and header
+	// name. Values are secret references: "keychain:NAME" (stored with
+	// 'coda config set-secret') or "env:VAR". They are redacted in results.
+	Headers map[string]map[string]string `yaml:"headers" json:"headers"`
+
+	// Further header names whose values are redacted in results, besides
+	// Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-Api-Key and
+	// X-Auth-Token
+	SecretHeaders []string `yaml:"secret_headers" json:"secret_headers"`
+
+	// Response body bytes returned to the model (default: 65536)
+	MaxResponseBytes int `yaml:"max_response_bytes" json:"max_response_bytes"`
+}
+
+// DefaultMaxResultTokens is used when DatabaseConfig.MaxResultTokens is 0
+const DefaultMaxResultTokens = 2000
+
+// ResultTokens returns the configured or default token budget
+func (c DatabaseConfig) ResultTokens() int {
+	if c.MaxResultTokens <= 0 {
+		return DefaultMaxResultTokens
+	}
+	return c.MaxResultTokens
+}
+
+// DefaultCompileCheckRetries is used when CompileCheckConfig.MaxRetries is 0
+const DefaultCompileCheckRetries = 3
+
+// Retries returns the configured or default number of retries
+func (c CompileCheckConfig) Retries() int {
+	if c.MaxRetries <= 0 {
+		return DefaultCompileCheckRetries
+	}
+	return c.MaxRetries
+}
+
+// FormatterConfig formats files the agent writes, e.g. "*.go" with
+// "goimports -w". Every matching formatter runs, in order.
+type FormatterConfig struct {
+	// Glob of the file names, e.g. "*.go" or "*.tsx"
+	Files string `yaml:"files" json:"files"`
+
+	// Command run with the file path as its last argument
+	Command string `yaml:"command" json:"command"`
+}
+
+// ToolHook runs shell commands around calls of matching tools. The commands
+// receive the call as JSON on stdin; a failing Before command blocks it.
+type ToolHook struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Glob of the tool names, e.g. "mcp_*" (default: every tool)
+	Tool string `yaml:"tool" json:"tool"`
+
+	// Commands run before and after the call; at least one is required
+	Before string `yaml:"before" json:"before"`
+	After  string `yaml:"after" json:"after"`
+
+	// Time limit of each command (default: 30)
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"`
+}
+
+// DefaultExecEnv lists the variables commands inherit when ExecConfig.Env is empty
+var DefaultExecEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LC_*", "TERM", "TMPDIR", "TZ"}
+
+// ExecConfig controls what tools that run commands (e.g. run_command) see
+// of the environment.
+type ExecConfig struct {
+	// Variables inherited from CODA's environment: names or globs such as
+	// "GO*", or "*" for all (default: DefaultExecEnv)
+	Env []string `yaml:"env" json:"env"`
+
+	// Variables injected by reference: "keychain:NAME" (stored with
+	// 'coda config set-secret') or "env:VAR". Their values are masked in
+	// command output.
+	Secrets map[string]string `yaml:"secrets" json:"secrets"`
+}
+
+// InheritedEnv returns the allowlist of inherited variables
+func (e ExecConfig) InheritedEnv() []string {
+	if len(e.Env) == 0 {
+		return DefaultExecEnv
+	}
+	return e.Env
+}
+
+// Permit decisions applied when a permission prompt times out
+const (
+	PermitDeny    = "deny"
+	PermitApprove = "approve"
+)
+
+// PermitConfig controls unanswered tool call permission prompts.
+type PermitConfig struct {
+	// Seconds to wait for an answer before applying the default decision (0 waits forever)
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"`
+
+	// Decision applied on timeout: "deny" or "approve"
+	Default string `yaml:"default" json:"default"`
+
+	// Rules answering matching tool calls without asking, checked in order by
+	// the chat and by the headless commands
+	Rules []ApprovalRule `yaml:"rules" json:"rules"`
+}
+
+// ApprovalRule approves or denies matching tool calls without a prompt.
+// Every condition that is set must hold for the rule to match.
+type ApprovalRule struct {
+	// Name shown in the chat and the audit log
+	Name string `yaml:"name" json:"name"`
+
+	// Tool name or glob pattern (e.g. "mcp_*"); empty matches every tool
+	Tool string `yaml:"tool" json:"tool"`
+
+	// Regular expressions that string arguments must match, by argument name
+	Args map[string]string `yaml:"args" json:"args"`
+
+	// Directories the "path" argument must be inside
+	Paths []string `yaml:"paths" json:"paths"`
+
+	// Stop matching after this many matches in a session (0 = no limit)
+	MaxPerSession int `yaml:"max_per_session" json:"max_per_session"`
+
+	// Decision for matching calls: "approve" or "deny"
+	Action string `yaml:"action" json:"action"`
+}
+
+// SandboxConfig contains tool sandbox limits.
+// A value of 0 keeps the default; a negative value disables that limit.
+type SandboxConfig struct {
+	// Disable the sandbox entirely
+	Disabled bool `yaml:"disabled" json:"disabled"`
+
+	// Default maximum run time per tool call in seconds. Long-running tools
+	// such as run_command and docker_build use their own limits.
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"`
+
+	// Maximum growth of the process heap in megabytes while a tool runs.
+	// Measured for the whole process, so only checked while one tool runs.
+	MaxMemoryMB int `yaml:"max_memory_mb" json:"max_memory_mb"`
+
+	// Maximum additional open file descriptors of the process while a tool
+	// runs alone (Linux only)
+	MaxOpenFiles int `yaml:"max_open_files" json:"max_open_files"`
+
+	// Maximum tool output size in bytes before truncation
+	MaxOutputBytes int `yaml:"max_output_bytes" json:"max_output_bytes"`
+
+	// Audit log path for sandbox violations (default: ~/.coda/audit.log)
+	AuditLog string `yaml:"audit_log" json:"audit_log"`
 }
 
 // FileAccessConfig contains file access restrictions
@@ -115,11 +459,41 @@ type UIConfig struct {
 	// Enable/disable markdown rendering
 	MarkdownRendering bool `yaml:"markdown_rendering" json:"markdown_rendering"`
 
+	// Markdown rendering backend: "builtin" or "glamour"
+	MarkdownBackend string `yaml:"markdown_backend" json:"markdown_backend"`
+
+	// Glamour style name or path to a JSON style file (default: chosen by theme)
+	GlamourStyle string `yaml:"glamour_style" json:"glamour_style"`
+
 	// Key bindings preset
 	KeyBindings string `yaml:"key_bindings" json:"key_bindings"`
 
 	// Input display lines (0 for unlimited)
 	InputDisplayLines int `yaml:"input_display_lines" json:"input_display_lines"`
+
+	// Pastes longer than this many characters are offered as an attachment
+	// instead of being inserted into the input (negative disables)
+	PasteThreshold int `yaml:"paste_threshold" json:"paste_threshold"`
+
+	// Answers longer than this many lines are collapsed in the chat view
+	// until expanded (negative disables)
+	CollapseLines int `yaml:"collapse_lines" json:"collapse_lines"`
+
+	// Message timestamps: "absolute", "relative" or "none"
+	Timestamps string `yaml:"timestamps" json:"timestamps"`
+
+	// Hide how long answers and tool executions took
+	HideDurations bool `yaml:"hide_durations" json:"hide_durations"`
+
+	// Hide the token count and cost shown next to each message
+	HideMessageUsage bool `yaml:"hide_message_usage" json:"hide_message_usage"`
+
+	// Offer a likely next prompt as ghost text on Ctrl+Space, accepted with
+	// Tab. The last messages of the conversation are sent to SuggestionModel.
+	PromptSuggestions bool `yaml:"prompt_suggestions" json:"prompt_suggestions"`
+
+	// Model for prompt suggestions (default: the chat model)
+	SuggestionModel string `yaml:"suggestion_model,omitempty" json:"suggestion_model,omitempty"`
 }
 
 // SessionConfig contains session related configuration
@@ -130,8 +504,177 @@ type SessionConfig struct {
 	// Maximum history entries
 	MaxHistory int `yaml:"max_history" json:"max_history"`
 
-	// Auto-save interval in seconds
+	// Seconds between background saves of the open sessions (negative
+	// disables them; sessions are still saved after each response and on exit)
 	AutoSaveInterval int `yaml:"auto_save_interval" json:"auto_save_interval"`
+
+	// What to do when a resumed session is open in another instance:
+	// "prompt", "readonly" or "new"
+	LockPolicy string `yaml:"lock_policy" json:"lock_policy"`
+
+	// Stop writing the daily journal of agent actions to .coda/journal/
+	DisableJournal bool `yaml:"disable_journal" json:"disable_journal"`
+
+	// Stop adding facts collected from the workspace (languages, module,
+	// scripts, remotes, README) to the system prompt
+	DisableBootContext bool `yaml:"disable_boot_context" json:"disable_boot_context"`
+
+	// Encryption of the saved sessions
+	Encryption SessionEncryptionConfig `yaml:"encryption" json:"encryption"`
+}
+
+// SessionEncryptionConfig encrypts the saved sessions, their backups and
+// partial responses with AES-256-GCM
+type SessionEncryptionConfig struct {
+	// Passphrase of the key: "keychain:NAME" (a secret stored with 'coda
+	// config set-secret') or "env:VAR"; empty leaves sessions unencrypted
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+}
+
+// Validate checks that the key is a secret reference
+func (e *SessionEncryptionConfig) Validate() error {
+	if e.Key != "" && !strings.HasPrefix(e.Key, SecretRefKeychain) && !strings.HasPrefix(e.Key, SecretRefEnv) {
+		return fmt.Errorf("encryption key must start with %s or %s, not be the passphrase itself", SecretRefKeychain, SecretRefEnv)
+	}
+	return nil
+}
+
+// Session lock policies
+const (
+	LockPolicyPrompt   = "prompt"
+	LockPolicyReadOnly = "readonly"
+	LockPolicyNew      = "new"
+)
+
+// UpdateConfig contains self-update related configuration
+type UpdateConfig struct {
+	// Disable all update checks, including 'coda update' and 'coda version -v'
+	DisableCheck bool `yaml:"disable_check" json:"disable_check"`
+
+	// Base64 encoded ed25519 public key used to verify checksums.txt.sig
+	// (optional, overrides the key embedded at build time)
+	PublicKey string `yaml:"public_key,omitempty" json:"public_key,omitempty"`
+}
+
+// Embedding providers
+const (
+	EmbeddingsLocal = "local"
+	EmbeddingsAI    = "ai"
+)
+
+// EmbeddingsConfig selects how workspace chunks are embedded for semantic search
+type EmbeddingsConfig struct {
+	// "local" hashes words offline; "ai" uses the embedding model of the
+	// AI provider, which costs tokens when the workspace is first indexed
+	Provider string `yaml:"provider" json:"provider"`
+
+	// Embedding model (or Azure deployment) for the "ai" provider
+	// (default: text-embedding-3-small)
+	Model string `yaml:"model" json:"model"`
+
+	// Globs of project documentation (e.g. "docs/**", "*.md"). The sections
+	// most related to each message are sent with it and cited in the answer.
+	Docs []string `yaml:"docs" json:"docs"`
+
+	// Number of documentation sections sent with a message (default: 3)
+	DocsResults int `yaml:"docs_results" json:"docs_results"`
+}
+
+// Operator roles of coda serve, from least to most trusted
+const (
+	// RoleViewer may request tools that only read the workspace
+	RoleViewer = "viewer"
+	// RoleEditor may also request tools that change workspace files
+	RoleEditor = "editor"
+	// RoleAdmin may request every tool, including commands and network access
+	RoleAdmin = "admin"
+)
+
+// ServeConfig configures the operators of coda serve, e.g. on a shared demo
+// machine or in a workshop. Each operator has an access token and a role
+// limiting the tools that prompts they send may request and the approvals
+// they may answer.
+type ServeConfig struct {
+	// Role of the access token printed at startup (default: admin)
+	Role string `yaml:"role" json:"role"`
+
+	// Further operators, each printed with their own URL
+	Operators []OperatorConfig `yaml:"operators" json:"operators"`
+}
+
+// OperatorConfig is an operator of coda serve
+type OperatorConfig struct {
+	// Name shown in the server log and the browser
+	Name string `yaml:"name" json:"name"`
+
+	// "viewer", "editor" or "admin"
+	Role string `yaml:"role" json:"role"`
+
+	// Access token as "keychain:NAME" or "env:VAR"; a random one is
+	// generated when empty
+	Token string `yaml:"token,omitempty" json:"token,omitempty"`
+}
+
+// Validate checks the roles and operator names
+func (s *ServeConfig) Validate() error {
+	roles := []string{RoleViewer, RoleEditor, RoleAdmin}
+	if s.Role != "" && !slices.Contains(roles, s.Role) {
+		return fmt.Errorf("invalid role: %s (must be one of %s)", s.Role, strings.Join(roles, ", "))
+	}
+	names := make(map[string]bool)
+	for i, op := range s.Operators {
+		if op.Name == "" {
+			return fmt.Errorf("operator %d: name is required", i+1)
+		}
+		if names[op.Name] {
+			return fmt.Errorf("operator %s is listed twice", op.Name)
+		}
+		names[op.Name] = true
+		if !slices.Contains(roles, op.Role) {
+			return fmt.Errorf("operator %s: invalid role: %q (must be one of %s)", op.Name, op.Role, strings.Join(roles, ", "))
+		}
+		if op.Token != "" && !strings.HasPrefix(op.Token, SecretRefKeychain) && !strings.HasPrefix(op.Token, SecretRefEnv) {
+			return fmt.Errorf("operator %s: token must start with %s or %s", op.Name, SecretRefKeychain, SecretRefEnv)
+		}
+	}
+	return nil
+}
+
+// RetentionConfig limits the old data kept per category. Limits are
+// enforced by 'coda gc' and by a janitor running during chat sessions.
+type RetentionConfig struct {
+	// Saved sessions of the project with their backups; sealed sessions and
+	// sessions open in a running instance are always kept
+	Sessions RetentionPolicy `yaml:"sessions" json:"sessions"`
+
+	// Session directories under .coda/artifacts
+	Artifacts RetentionPolicy `yaml:"artifacts" json:"artifacts"`
+
+	// Daily journal files and rotated log files
+	Logs RetentionPolicy `yaml:"logs" json:"logs"`
+
+	// Seconds between janitor runs during a chat session (negative disables
+	// the janitor; 'coda gc' still applies the limits)
+	JanitorInterval int `yaml:"janitor_interval" json:"janitor_interval"`
+}
+
+// RetentionPolicy limits one category; 0 means no limit
+type RetentionPolicy struct {
+	// Remove what was last changed more than this many days ago
+	MaxAgeDays int `yaml:"max_age_days" json:"max_age_days"`
+
+	// Remove the oldest until the category fits in this many megabytes
+	MaxSizeMB int `yaml:"max_size_mb" json:"max_size_mb"`
+}
+
+// Validate checks that the limits are not negative
+func (r *RetentionConfig) Validate() error {
+	for name, policy := range map[string]RetentionPolicy{"sessions": r.Sessions, "artifacts": r.Artifacts, "logs": r.Logs} {
+		if policy.MaxAgeDays < 0 || policy.MaxSizeMB < 0 {
+			return fmt.Errorf("%s: limits must not be negative", name)
+		}
+	}
+	return nil
 }
 
 // NewDefaultConfig creates a new configuration with default values
@@ -155,6 +698,8 @@ func NewDefaultConfig() *Config {
 				DeploymentName: os.Getenv("AZURE_OPENAI_DEPLOYMENT"),
 				APIVersion:     getEnvOrDefault("AZURE_OPENAI_API_VERSION", "2024-02-01"),
 			},
+			MetadataCache:        filepath.Join(configDir, "models.json"),
+			MetadataRefreshHours: 24,
 		},
 		Tools: ToolsConfig{
 			WorkspaceRoot: getEnvOrDefault("CODA_WORKSPACE", "."),
@@ -172,13 +717,28 @@ func NewDefaultConfig() *Config {
 				MaxFileSize: 10 * 1024 * 1024, // 10MB
 			},
 			AutoApprove: false,
+			DryRun:      false,
+			Sandbox: SandboxConfig{
+				TimeoutSeconds: 120,
+				MaxMemoryMB:    512,
+				MaxOpenFiles:   256,
+				MaxOutputBytes: 1024 * 1024, // 1MB
+			},
+			Permit: PermitConfig{
+				TimeoutSeconds: 0,
+				Default:        PermitDeny,
+			},
 		},
 		UI: UIConfig{
 			Theme:              "default",
 			SyntaxHighlighting: true,
 			MarkdownRendering:  true,
+			MarkdownBackend:    "builtin",
 			KeyBindings:        "default",
 			InputDisplayLines:  0, // 0 = dynamic sizing up to half screen
+			PasteThreshold:     10000,
+			CollapseLines:      40,
+			Timestamps:         "absolute",
 		},
 		Logging: func() logging.LoggingConfig {
 			cfg := logging.DefaultConfig()
@@ -191,6 +751,13 @@ func NewDefaultConfig() *Config {
 			HistoryFile:      filepath.Join(configDir, "history.json"),
 			MaxHistory:       1000,
 			AutoSaveInterval: 30,
+			LockPolicy:       LockPolicyPrompt,
+		},
+		Embeddings: EmbeddingsConfig{
+			Provider: EmbeddingsLocal,
+		},
+		Retention: RetentionConfig{
+			JanitorInterval: 3600,
 		},
 	}
 }
@@ -207,6 +774,46 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("Tools configuration error: %w", err)
 	}
 
+	// Validate UI configuration
+	if backend := strings.ToLower(c.UI.MarkdownBackend); backend != "" && !contains([]string{"builtin", "glamour"}, backend) {
+		return fmt.Errorf("UI configuration error: invalid markdown backend: %s", c.UI.MarkdownBackend)
+	}
+	if stamps := strings.ToLower(c.UI.Timestamps); stamps != "" && !contains([]string{"absolute", "relative", "none"}, stamps) {
+		return fmt.Errorf("UI configuration error: invalid timestamps: %s", c.UI.Timestamps)
+	}
+
+	// Validate Embeddings configuration
+	if provider := c.Embeddings.Provider; provider != "" && provider != EmbeddingsLocal && provider != EmbeddingsAI {
+		return fmt.Errorf("Embeddings configuration error: invalid provider: %s (must be '%s' or '%s')", provider, EmbeddingsLocal, EmbeddingsAI)
+	}
+	if c.Embeddings.DocsResults < 0 {
+		return fmt.Errorf("Embeddings configuration error: docs_results must not be negative, got %d", c.Embeddings.DocsResults)
+	}
+
+	// Validate personas
+	for name, persona := range c.Personas {
+		for i, rule := range persona.Rules {
+			if err := rule.Validate(); err != nil {
+				return fmt.Errorf("Persona configuration error: invalid rule %d of %s: %w", i+1, name, err)
+			}
+		}
+	}
+
+	// Validate Session configuration
+	if err := c.Session.Encryption.Validate(); err != nil {
+		return fmt.Errorf("Session configuration error: %w", err)
+	}
+
+	// Validate Serve configuration
+	if err := c.Serve.Validate(); err != nil {
+		return fmt.Errorf("Serve configuration error: %w", err)
+	}
+
+	// Validate Retention configuration
+	if err := c.Retention.Validate(); err != nil {
+		return fmt.Errorf("Retention configuration error: %w", err)
+	}
+
 	// Validate Logging configuration
 	if err := c.validateLogging(); err != nil {
 		return fmt.Errorf("Logging configuration error: %w", err)
@@ -271,6 +878,28 @@ func (ai *AIConfig) Validate() error {
 		}
 	}
 
+	if len(ai.FallbackModels) > 0 {
+		if ai.Provider == "azure" {
+			return errors.New("fallback_models is not supported with the azure provider")
+		}
+		if slices.Contains(ai.FallbackModels, "") {
+			return errors.New("fallback_models must not contain empty names")
+		}
+	}
+
+	if err := ai.PostProcess.Validate(); err != nil {
+		return fmt.Errorf("post_process: %w", err)
+	}
+
+	for _, pattern := range ai.Redact {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+	}
+	if ai.MaxRequestCost < 0 || ai.MaxSessionCost < 0 {
+		return errors.New("max_request_cost and max_session_cost must not be negative")
+	}
+
 	// Validate reasoning effort if specified
 	if ai.ReasoningEffort != nil {
 		validEfforts := map[string]bool{
@@ -287,6 +916,60 @@ func (ai *AIConfig) Validate() error {
 	return nil
 }
 
+// Validate checks the step names and regular expressions
+func (p *PostProcessConfig) Validate() error {
+	steps := []string{PostProcessStripDisclaimers, PostProcessFenceLanguages, PostProcessRelativePaths, PostProcessReplace}
+	for _, step := range p.Steps {
+		if !slices.Contains(steps, step) {
+			return fmt.Errorf("invalid step: %s (must be one of %s)", step, strings.Join(steps, ", "))
+		}
+	}
+	for _, pattern := range p.Disclaimers {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid disclaimer pattern %q: %w", pattern, err)
+		}
+	}
+	for _, r := range p.Replacements {
+		if r.Pattern == "" {
+			return errors.New("replacement pattern is required")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid replacement pattern %q: %w", r.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML accepts model as a single name or as a prioritized list,
+// whose first entry becomes Model and the rest lead FallbackModels
+func (ai *AIConfig) UnmarshalYAML(node *yaml.Node) error {
+	var models []string
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value != "model" || value.Kind != yaml.SequenceNode {
+				continue
+			}
+			if err := value.Decode(&models); err != nil {
+				return fmt.Errorf("invalid model list: %w", err)
+			}
+			if len(models) == 0 {
+				return errors.New("model list must not be empty")
+			}
+			node.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: models[0]}
+		}
+	}
+
+	type plain AIConfig
+	if err := node.Decode((*plain)(ai)); err != nil {
+		return err
+	}
+	if len(models) > 1 {
+		ai.FallbackModels = append(models[1:], ai.FallbackModels...)
+	}
+	return nil
+}
+
 // Validate validates the Tools configuration
 func (t *ToolsConfig) Validate() error {
 	if t.WorkspaceRoot == "" {
@@ -302,6 +985,94 @@ func (t *ToolsConfig) Validate() error {
 		return errors.New("max file size must be positive")
 	}
 
+	if t.Permit.TimeoutSeconds < 0 {
+		return fmt.Errorf("permit timeout must not be negative, got %d", t.Permit.TimeoutSeconds)
+	}
+	if t.Permit.Default != "" && t.Permit.Default != PermitDeny && t.Permit.Default != PermitApprove {
+		return fmt.Errorf("invalid permit default: %s (must be '%s' or '%s')", t.Permit.Default, PermitDeny, PermitApprove)
+	}
+	for _, pattern := range append(slices.Clone(t.Enabled), t.Disabled...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range t.Exec.Env {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exec env pattern %q: %w", pattern, err)
+		}
+	}
+	for name, ref := range t.Exec.Secrets {
+		if !strings.HasPrefix(ref, SecretRefKeychain) && !strings.HasPrefix(ref, SecretRefEnv) {
+			return fmt.Errorf("invalid exec secret %s: %q must start with %s or %s", name, ref, SecretRefKeychain, SecretRefEnv)
+		}
+	}
+	for i, hook := range t.Hooks {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("invalid tool hook %d: %w", i+1, err)
+		}
+	}
+	for i, rule := range t.Permit.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("invalid permit rule %d: %w", i+1, err)
+		}
+	}
+	for i, formatter := range t.Format {
+		if err := formatter.Validate(); err != nil {
+			return fmt.Errorf("invalid formatter %d: %w", i+1, err)
+		}
+	}
+	if t.CompileCheck.MaxRetries < 0 || t.CompileCheck.TimeoutSeconds < 0 {
+		return errors.New("compile check retries and timeout must not be negative")
+	}
+
+	return nil
+}
+
+// Validate checks that the formatter has a command and a valid file pattern
+func (f *FormatterConfig) Validate() error {
+	if strings.TrimSpace(f.Command) == "" {
+		return errors.New("command is required")
+	}
+	if f.Files == "" {
+		return errors.New("files pattern is required")
+	}
+	if _, err := filepath.Match(f.Files, ""); err != nil {
+		return fmt.Errorf("invalid files pattern %q: %w", f.Files, err)
+	}
+	return nil
+}
+
+// Validate checks that the hook has a command and a valid tool pattern
+func (h *ToolHook) Validate() error {
+	if h.Before == "" && h.After == "" {
+		return errors.New("before or after command is required")
+	}
+	if _, err := filepath.Match(h.Tool, ""); err != nil {
+		return fmt.Errorf("invalid tool pattern %q: %w", h.Tool, err)
+	}
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeout must not be negative, got %d", h.TimeoutSeconds)
+	}
+	return nil
+}
+
+// Validate checks the rule action and patterns
+func (r *ApprovalRule) Validate() error {
+	if r.Action != PermitDeny && r.Action != PermitApprove {
+		return fmt.Errorf("invalid action: %q (must be '%s' or '%s')", r.Action, PermitDeny, PermitApprove)
+	}
+	if _, err := filepath.Match(r.Tool, ""); err != nil {
+		return fmt.Errorf("invalid tool pattern %q: %w", r.Tool, err)
+	}
+	for name, pattern := range r.Args {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern for argument %s: %w", name, err)
+		}
+	}
+	if r.MaxPerSession < 0 {
+		return fmt.Errorf("max_per_session must not be negative, got %d", r.MaxPerSession)
+	}
+
 	return nil
 }
 
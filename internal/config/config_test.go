@@ -204,6 +204,32 @@ func TestConfigValidate(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "max file size must be positive")
 	})
+
+	t.Run("invalid persona rule", func(t *testing.T) {
+		cfg := NewDefaultConfig()
+		cfg.AI.APIKey = "test-key"
+		cfg.Personas = map[string]Persona{
+			"reviewer": {Prompt: "Review only", Rules: []ApprovalRule{{Tool: "write_file"}}},
+		}
+
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid rule 1 of reviewer")
+	})
+
+	t.Run("invalid post-processing step", func(t *testing.T) {
+		cfg := NewDefaultConfig()
+		cfg.AI.APIKey = "test-key"
+		cfg.AI.PostProcess.Steps = []string{PostProcessFenceLanguages, "shout"}
+
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "post_process: invalid step: shout")
+
+		cfg.AI.PostProcess.Steps = []string{PostProcessReplace}
+		cfg.AI.PostProcess.Replacements = []Replacement{{Pattern: "[", With: ""}}
+		assert.ErrorContains(t, cfg.Validate(), `invalid replacement pattern "["`)
+	})
 }
 
 func TestAIConfigValidate(t *testing.T) {
@@ -260,6 +286,23 @@ func TestAIConfigValidate(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "model is required")
 	})
+
+	t.Run("fallback models with azure", func(t *testing.T) {
+		ai := AIConfig{
+			Provider:       "azure",
+			APIKey:         "test-key",
+			Model:          "o3",
+			FallbackModels: []string{"gpt-4.1"},
+			Azure: AzureConfig{
+				Endpoint:       "https://test.openai.azure.com",
+				DeploymentName: "test-deployment",
+			},
+		}
+
+		err := ai.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "fallback_models is not supported")
+	})
 }
 
 func TestToolsConfigValidate(t *testing.T) {
@@ -290,6 +333,174 @@ func TestToolsConfigValidate(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "workspace root is required")
 	})
+	t.Run("tool selection", func(t *testing.T) {
+		tools := ToolsConfig{
+			WorkspaceRoot: tempDir,
+			FileAccess:    FileAccessConfig{MaxFileSize: 1024},
+			Enabled:       []string{"read_file", "list_*"},
+			Disabled:      []string{"mcp_*"},
+		}
+		assert.NoError(t, tools.Validate())
+
+		tools.Disabled = []string{"run_["}
+		assert.ErrorContains(t, tools.Validate(), "invalid tool pattern")
+	})
+
+	t.Run("tool hooks", func(t *testing.T) {
+		tools := ToolsConfig{
+			WorkspaceRoot: tempDir,
+			FileAccess:    FileAccessConfig{MaxFileSize: 1024},
+			Hooks:         []ToolHook{{Name: "lint", Tool: "*_file", After: "make lint"}},
+		}
+		assert.NoError(t, tools.Validate())
+
+		tools.Hooks = []ToolHook{{Name: "empty", Tool: "read_file"}}
+		assert.ErrorContains(t, tools.Validate(), "before or after command is required")
+
+		tools.Hooks = []ToolHook{{Name: "bad", Tool: "[", Before: "true"}}
+		assert.ErrorContains(t, tools.Validate(), "invalid tool hook 1")
+	})
+
+	t.Run("formatters", func(t *testing.T) {
+		tools := ToolsConfig{
+			WorkspaceRoot: tempDir,
+			FileAccess:    FileAccessConfig{MaxFileSize: 1024},
+			Format:        []FormatterConfig{{Files: "*.go", Command: "gofmt -w"}},
+		}
+		assert.NoError(t, tools.Validate())
+
+		tools.Format = []FormatterConfig{{Files: "*.go"}}
+		assert.ErrorContains(t, tools.Validate(), "invalid formatter 1: command is required")
+
+		tools.Format = []FormatterConfig{{Files: "[", Command: "black -q"}}
+		assert.ErrorContains(t, tools.Validate(), "invalid files pattern")
+	})
+
+	t.Run("compile check", func(t *testing.T) {
+		tools := ToolsConfig{
+			WorkspaceRoot: tempDir,
+			FileAccess:    FileAccessConfig{MaxFileSize: 1024},
+			CompileCheck:  CompileCheckConfig{Command: "go build ./..."},
+		}
+		assert.NoError(t, tools.Validate())
+		assert.Equal(t, DefaultCompileCheckRetries, tools.CompileCheck.Retries())
+
+		tools.CompileCheck.MaxRetries = -1
+		assert.ErrorContains(t, tools.Validate(), "must not be negative")
+	})
+
+	t.Run("exec policy", func(t *testing.T) {
+		tools := ToolsConfig{
+			WorkspaceRoot: tempDir,
+			FileAccess:    FileAccessConfig{MaxFileSize: 1024},
+			Exec:          ExecConfig{Env: []string{"PATH", "GO*"}, Secrets: map[string]string{"TOKEN": "keychain:github"}},
+		}
+		assert.NoError(t, tools.Validate())
+		assert.Equal(t, DefaultExecEnv, ExecConfig{}.InheritedEnv())
+
+		tools.Exec.Secrets["TOKEN"] = "ghp-plaintext"
+		assert.ErrorContains(t, tools.Validate(), "invalid exec secret TOKEN")
+
+		tools.Exec = ExecConfig{Env: []string{"["}}
+		assert.ErrorContains(t, tools.Validate(), "invalid exec env pattern")
+	})
+	t.Run("permit policy", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			permit  PermitConfig
+			wantErr string
+		}{
+			{"defaults", PermitConfig{}, ""},
+			{"approve on timeout", PermitConfig{TimeoutSeconds: 30, Default: PermitApprove}, ""},
+			{"negative timeout", PermitConfig{TimeoutSeconds: -1}, "permit timeout must not be negative"},
+			{"unknown decision", PermitConfig{Default: "ask"}, "invalid permit default"},
+			{"approval rule", PermitConfig{Rules: []ApprovalRule{{Tool: "run_command", Args: map[string]string{"command": "^go test"}, Action: PermitApprove}}}, ""},
+			{"rule without action", PermitConfig{Rules: []ApprovalRule{{Tool: "read_file"}}}, "invalid permit rule 1: invalid action"},
+			{"rule with bad pattern", PermitConfig{Rules: []ApprovalRule{{Args: map[string]string{"command": "("}, Action: PermitDeny}}}, "invalid pattern for argument command"},
+			{"rule with bad tool glob", PermitConfig{Rules: []ApprovalRule{{Tool: "[", Action: PermitDeny}}}, "invalid tool pattern"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tools := ToolsConfig{
+					WorkspaceRoot: tempDir,
+					FileAccess:    FileAccessConfig{MaxFileSize: 1024},
+					Permit:        tt.permit,
+				}
+
+				err := tools.Validate()
+				if tt.wantErr == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorContains(t, err, tt.wantErr)
+				}
+			})
+		}
+	})
+}
+
+func TestServeConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		serve   ServeConfig
+		wantErr string
+	}{
+		{name: "empty", serve: ServeConfig{}},
+		{
+			name: "operators",
+			serve: ServeConfig{Role: RoleEditor, Operators: []OperatorConfig{
+				{Name: "participants", Role: RoleViewer},
+				{Name: "assistant", Role: RoleAdmin, Token: "env:ASSISTANT_TOKEN"},
+			}},
+		},
+		{name: "invalid role", serve: ServeConfig{Role: "root"}, wantErr: "invalid role: root"},
+		{name: "missing name", serve: ServeConfig{Operators: []OperatorConfig{{Role: RoleViewer}}}, wantErr: "name is required"},
+		{name: "missing role", serve: ServeConfig{Operators: []OperatorConfig{{Name: "a"}}}, wantErr: "operator a: invalid role"},
+		{
+			name:    "duplicate name",
+			serve:   ServeConfig{Operators: []OperatorConfig{{Name: "a", Role: RoleViewer}, {Name: "a", Role: RoleAdmin}}},
+			wantErr: "listed twice",
+		},
+		{
+			name:    "literal token",
+			serve:   ServeConfig{Operators: []OperatorConfig{{Name: "a", Role: RoleViewer, Token: "hunter2"}}},
+			wantErr: "token must start with keychain: or env:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.serve.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetentionConfigValidate(t *testing.T) {
+	retention := RetentionConfig{Sessions: RetentionPolicy{MaxAgeDays: 90}, Artifacts: RetentionPolicy{MaxSizeMB: 200}}
+	assert.NoError(t, retention.Validate())
+
+	retention.Logs.MaxAgeDays = -1
+	assert.ErrorContains(t, retention.Validate(), "logs: limits must not be negative")
+}
+
+func TestSessionEncryptionConfigValidate(t *testing.T) {
+	for _, key := range []string{"", "keychain:session-key", "env:CODA_SESSION_PASSPHRASE"} {
+		enc := SessionEncryptionConfig{Key: key}
+		assert.NoError(t, enc.Validate(), key)
+	}
+
+	enc := SessionEncryptionConfig{Key: "correct horse"}
+	assert.ErrorContains(t, enc.Validate(), "must start with keychain: or env:")
+
+	cfg := NewDefaultConfig()
+	cfg.AI.APIKey = "test-key"
+	cfg.Session.Encryption.Key = "correct horse"
+	assert.ErrorContains(t, cfg.Validate(), "Session configuration error")
 }
 
 func TestLoggingConfigValidate(t *testing.T) {
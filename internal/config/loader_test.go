@@ -99,6 +99,32 @@ ai:
 		}
 	})
 
+	t.Run("model list", func(t *testing.T) {
+		configPath := filepath.Join(tempDir, "model-list.yaml")
+		configContent := `
+ai:
+  provider: openai
+  model: [o3, gpt-4.1]
+  fallback_models: [gpt-4o]
+  api_key: test-key
+`
+		if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := NewLoader().Load(configPath)
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+
+		if cfg.AI.Model != "o3" {
+			t.Errorf("Expected model o3, got %s", cfg.AI.Model)
+		}
+		if got := strings.Join(cfg.AI.FallbackModels, ","); got != "gpt-4.1,gpt-4o" {
+			t.Errorf("Expected fallback models gpt-4.1,gpt-4o, got %s", got)
+		}
+	})
+
 	// Skip this test - it's unreliable due to environment variables
 	// The actual environment may have different values set
 
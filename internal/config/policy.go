@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyEnv names the environment variable holding the path of the org
+// policy file
+const PolicyEnv = "CODA_POLICY_FILE"
+
+// DefaultOpenAIEndpoint is the endpoint of the openai provider without a
+// base_url
+const DefaultOpenAIEndpoint = "https://api.openai.com/v1"
+
+// ErrPolicyViolation is returned when the configuration breaks the org
+// policy
+var ErrPolicyViolation = errors.New("org policy violation")
+
+// Policy holds the constraints an organization enforces over every user's
+// configuration. It is read from the file named by CODA_POLICY_FILE and
+// applied after the config files and environment variables.
+type Policy struct {
+	// Tools never registered: names or globs such as "mcp_*", added to
+	// tools.disabled
+	BannedTools []string `yaml:"banned_tools" json:"banned_tools"`
+
+	// Regular expressions redacted from everything sent to the provider,
+	// added to ai.redact
+	RedactPatterns []string `yaml:"redact_patterns" json:"redact_patterns"`
+
+	// AI providers allowed; empty allows any
+	AllowedProviders []string `yaml:"allowed_providers" json:"allowed_providers"`
+
+	// Hosts of the AI endpoint allowed, exact or "*.example.com"; empty
+	// allows any
+	AllowedEndpoints []string `yaml:"allowed_endpoints" json:"allowed_endpoints"`
+
+	// Upper bounds of ai.max_request_cost and ai.max_session_cost in USD
+	// (0 for none)
+	MaxRequestCost float64 `yaml:"max_request_cost" json:"max_request_cost"`
+	MaxSessionCost float64 `yaml:"max_session_cost" json:"max_session_cost"`
+
+	path string
+}
+
+// LoadPolicy reads and checks the policy file at path. Unknown keys are
+// errors so that a misspelled constraint is not silently ignored.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org policy: %w", err)
+	}
+
+	policy := &Policy{path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("invalid org policy %s: %w", path, err)
+	}
+
+	for _, pattern := range policy.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid org policy %s: redact pattern %q: %w", path, pattern, err)
+		}
+	}
+	if policy.MaxRequestCost < 0 || policy.MaxSessionCost < 0 {
+		return nil, fmt.Errorf("invalid org policy %s: cost limits must not be negative", path)
+	}
+	return policy, nil
+}
+
+// Apply merges the policy over cfg and fails when cfg uses a provider or
+// endpoint the policy does not allow
+func (p *Policy) Apply(cfg *Config) error {
+	for _, tool := range p.BannedTools {
+		if !slices.Contains(cfg.Tools.Disabled, tool) {
+			cfg.Tools.Disabled = append(cfg.Tools.Disabled, tool)
+		}
+	}
+	for _, pattern := range p.RedactPatterns {
+		if !slices.Contains(cfg.AI.Redact, pattern) {
+			cfg.AI.Redact = append(cfg.AI.Redact, pattern)
+		}
+	}
+	cfg.AI.MaxRequestCost = lowerLimit(cfg.AI.MaxRequestCost, p.MaxRequestCost)
+	cfg.AI.MaxSessionCost = lowerLimit(cfg.AI.MaxSessionCost, p.MaxSessionCost)
+
+	return p.Check(&cfg.AI)
+}
+
+// Check reports whether the provider and endpoint of ai are allowed
+func (p *Policy) Check(ai *AIConfig) error {
+	if len(p.AllowedProviders) > 0 && !slices.Contains(p.AllowedProviders, ai.Provider) {
+		return fmt.Errorf("%w (%s): AI provider %q is not allowed (allowed: %s)",
+			ErrPolicyViolation, p.path, ai.Provider, strings.Join(p.AllowedProviders, ", "))
+	}
+	if len(p.AllowedEndpoints) == 0 {
+		return nil
+	}
+
+	endpoint := Endpoint(ai)
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%w (%s): AI endpoint %q is not a valid URL", ErrPolicyViolation, p.path, endpoint)
+	}
+	for _, pattern := range p.AllowedEndpoints {
+		if matchEndpoint(pattern, u) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w (%s): AI endpoint %s is not allowed (allowed: %s)",
+		ErrPolicyViolation, p.path, u.Host, strings.Join(p.AllowedEndpoints, ", "))
+}
+
+// Endpoint returns the URL requests of ai are sent to
+func Endpoint(ai *AIConfig) string {
+	if ai.Provider == "azure" {
+		return ai.Azure.Endpoint
+	}
+	if ai.OpenAI.BaseURL != "" {
+		return ai.OpenAI.BaseURL
+	}
+	return DefaultOpenAIEndpoint
+}
+
+// matchEndpoint reports whether u is on the host pattern names: an exact
+// host, a host:port, or "*.example.com" for its subdomains
+func matchEndpoint(pattern string, u *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	host := strings.ToLower(u.Hostname())
+	if _, _, err := net.SplitHostPort(pattern); err == nil {
+		return pattern == strings.ToLower(u.Host)
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// lowerLimit returns the stricter of a user and a policy limit, where 0
+// means none
+func lowerLimit(user, policy float64) float64 {
+	if policy > 0 && (user <= 0 || user > policy) {
+		return policy
+	}
+	return user
+}
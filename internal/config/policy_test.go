@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := writePolicy(t, `
+banned_tools: [run_command, "mcp_*"]
+redact_patterns: ["secret-[0-9]+"]
+allowed_providers: [azure]
+allowed_endpoints: ["*.openai.azure.com"]
+max_session_cost: 5
+`)
+	policy, err := LoadPolicy(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run_command", "mcp_*"}, policy.BannedTools)
+	assert.Equal(t, 5.0, policy.MaxSessionCost)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown key", "banned_tool: [run_command]\n", "field banned_tool not found"},
+		{"invalid pattern", "redact_patterns: [\"(\"]\n", "redact pattern"},
+		{"negative cost", "max_request_cost: -1\n", "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPolicy(writePolicy(t, tt.content))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err = LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read org policy")
+}
+
+func TestPolicyApply(t *testing.T) {
+	policy := &Policy{
+		BannedTools:    []string{"run_command", "http_request"},
+		RedactPatterns: []string{"corp\\.example\\.com"},
+		MaxRequestCost: 1,
+		MaxSessionCost: 10,
+	}
+	cfg := NewDefaultConfig()
+	cfg.Tools.Disabled = []string{"run_command"}
+	cfg.AI.Redact = []string{"token-[a-z]+"}
+	cfg.AI.MaxRequestCost = 0.5
+	cfg.AI.MaxSessionCost = 50
+
+	require.NoError(t, policy.Apply(cfg))
+	assert.Equal(t, []string{"run_command", "http_request"}, cfg.Tools.Disabled)
+	assert.Equal(t, []string{"token-[a-z]+", "corp\\.example\\.com"}, cfg.AI.Redact)
+	assert.Equal(t, 0.5, cfg.AI.MaxRequestCost, "a stricter user limit stays")
+	assert.Equal(t, 10.0, cfg.AI.MaxSessionCost, "a looser user limit is lowered")
+}
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		ai       AIConfig
+		violates bool
+	}{
+		{
+			name:   "no constraints",
+			policy: Policy{},
+			ai:     AIConfig{Provider: "openai"},
+		},
+		{
+			name:     "provider not allowed",
+			policy:   Policy{AllowedProviders: []string{"azure"}},
+			ai:       AIConfig{Provider: "openai"},
+			violates: true,
+		},
+		{
+			name:   "default openai endpoint",
+			policy: Policy{AllowedEndpoints: []string{"api.openai.com"}},
+			ai:     AIConfig{Provider: "openai"},
+		},
+		{
+			name:     "custom base URL not allowed",
+			policy:   Policy{AllowedEndpoints: []string{"api.openai.com"}},
+			ai:       AIConfig{Provider: "openai", OpenAI: OpenAIConfig{BaseURL: "https://proxy.example.com/v1"}},
+			violates: true,
+		},
+		{
+			name:   "azure subdomain",
+			policy: Policy{AllowedProviders: []string{"azure"}, AllowedEndpoints: []string{"*.openai.azure.com"}},
+			ai:     AIConfig{Provider: "azure", Azure: AzureConfig{Endpoint: "https://acme.openai.azure.com"}},
+		},
+		{
+			name:     "lookalike host",
+			policy:   Policy{AllowedEndpoints: []string{"*.openai.azure.com"}},
+			ai:       AIConfig{Provider: "azure", Azure: AzureConfig{Endpoint: "https://acme.openai.azure.com.evil.io"}},
+			violates: true,
+		},
+		{
+			name:   "host and port",
+			policy: Policy{AllowedEndpoints: []string{"localhost:8080"}},
+			ai:     AIConfig{Provider: "openai", OpenAI: OpenAIConfig{BaseURL: "http://localhost:8080/v1"}},
+		},
+		{
+			name:     "missing endpoint",
+			policy:   Policy{AllowedEndpoints: []string{"*.openai.azure.com"}},
+			ai:       AIConfig{Provider: "azure"},
+			violates: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(&tt.ai)
+			if tt.violates {
+				assert.ErrorIs(t, err, ErrPolicyViolation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoaderLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+ai:
+  provider: openai
+  api_key: test-key
+  model: o3
+  max_session_cost: 100
+tools:
+  workspace_root: "."
+`), 0644))
+
+	t.Setenv(PolicyEnv, writePolicy(t, "banned_tools: [run_command]\nmax_session_cost: 20\n"))
+	cfg, err := NewLoader().Load(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Tools.Disabled, "run_command")
+	assert.Equal(t, 20.0, cfg.AI.MaxSessionCost)
+
+	t.Setenv(PolicyEnv, writePolicy(t, "allowed_providers: [azure]\n"))
+	_, err = NewLoader().Load(configPath)
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.ErrorContains(t, err, `AI provider "openai" is not allowed (allowed: azure)`)
+
+	t.Setenv(PolicyEnv, filepath.Join(dir, "missing.yaml"))
+	_, err = NewLoader().Load(configPath)
+	assert.Error(t, err, "a missing policy file fails closed")
+}
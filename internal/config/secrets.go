@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -77,24 +78,15 @@ func NewFileSecretsManager(filePath string) (*FileSecretsManager, error) {
 
 // GetAPIKey retrieves an API key for the specified provider
 func (f *FileSecretsManager) GetAPIKey(provider string) (string, error) {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
-
 	// First check environment variables
 	if key := getAPIKeyFromEnv(provider); key != "" {
 		return key, nil
 	}
 
-	// Load from file
-	store, err := f.load()
+	key, exists, err := f.storedKey(provider)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", fmt.Errorf("no API key found for provider: %s", provider)
-		}
 		return "", err
 	}
-
-	key, exists := store.Keys[provider]
 	if !exists {
 		return "", fmt.Errorf("no API key found for provider: %s", provider)
 	}
@@ -102,6 +94,23 @@ func (f *FileSecretsManager) GetAPIKey(provider string) (string, error) {
 	return key, nil
 }
 
+// storedKey reads a key from the secrets file only
+func (f *FileSecretsManager) storedKey(name string) (string, bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	store, err := f.load()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	key, exists := store.Keys[name]
+	return key, exists, nil
+}
+
 // SetAPIKey stores an API key for the specified provider
 func (f *FileSecretsManager) SetAPIKey(provider string, key string) error {
 	f.mu.Lock()
@@ -163,6 +172,10 @@ func (f *FileSecretsManager) ListProviders() ([]string, error) {
 
 	providers := make([]string, 0, len(store.Keys))
 	for provider := range store.Keys {
+		// Secrets share the store but are not API keys
+		if strings.HasPrefix(provider, secretKeyPrefix) {
+			continue
+		}
 		providers = append(providers, provider)
 	}
 
@@ -290,6 +303,78 @@ func (p *PlatformSecretsManager) ListProviders() ([]string, error) {
 	return result, nil
 }
 
+// secretKeyPrefix keeps secrets apart from API keys in the secret store
+const secretKeyPrefix = "secret/"
+
+// SetSecret stores a named secret, e.g. a token injected into commands
+func SetSecret(store SecretsManager, name, value string) error {
+	return store.SetAPIKey(secretKeyPrefix+name, value)
+}
+
+// GetSecret returns a secret stored with SetSecret. Unlike API keys,
+// secrets are never taken from the environment.
+func GetSecret(store SecretsManager, name string) (string, error) {
+	key := secretKeyPrefix + name
+
+	var file *FileSecretsManager
+	switch s := store.(type) {
+	case *PlatformSecretsManager:
+		if value, err := getPlatformAPIKey(key); err == nil && value != "" {
+			return value, nil
+		}
+		file, _ = s.fallback.(*FileSecretsManager)
+	case *FileSecretsManager:
+		file = s
+	}
+	if file == nil {
+		return store.GetAPIKey(key)
+	}
+
+	value, exists, err := file.storedKey(key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("no secret named %s", name)
+	}
+	return value, nil
+}
+
+// Secret reference prefixes of ExecConfig.Secrets values
+const (
+	SecretRefKeychain = "keychain:"
+	SecretRefEnv      = "env:"
+)
+
+// ResolveSecretRefs returns the values of secret references by variable
+// name. "keychain:NAME" reads a secret stored with SetSecret and "env:VAR"
+// reads CODA's own environment.
+func ResolveSecretRefs(refs map[string]string, store SecretsManager) (map[string]string, error) {
+	values := make(map[string]string, len(refs))
+	for name, ref := range refs {
+		switch {
+		case strings.HasPrefix(ref, SecretRefKeychain):
+			if store == nil {
+				return nil, fmt.Errorf("secret %s: no secret store available", name)
+			}
+			value, err := GetSecret(store, strings.TrimPrefix(ref, SecretRefKeychain))
+			if err != nil {
+				return nil, fmt.Errorf("secret %s: %w", name, err)
+			}
+			values[name] = value
+		case strings.HasPrefix(ref, SecretRefEnv):
+			value, ok := os.LookupEnv(strings.TrimPrefix(ref, SecretRefEnv))
+			if !ok {
+				return nil, fmt.Errorf("secret %s: environment variable %s is not set", name, strings.TrimPrefix(ref, SecretRefEnv))
+			}
+			values[name] = value
+		default:
+			return nil, fmt.Errorf("secret %s: invalid reference %q (must start with %s or %s)", name, ref, SecretRefKeychain, SecretRefEnv)
+		}
+	}
+	return values, nil
+}
+
 // Helper functions
 
 // getAPIKeyFromEnv checks environment variables for API keys
@@ -312,3 +312,51 @@ func TestGetServiceName(t *testing.T) {
 		t.Errorf("Expected service name %s, got %s", expected, service)
 	}
 }
+
+func TestResolveSecretRefs(t *testing.T) {
+	manager, err := NewFileSecretsManager(filepath.Join(t.TempDir(), ".secrets"))
+	if err != nil {
+		t.Fatalf("Failed to create file secrets manager: %v", err)
+	}
+	if err := SetSecret(manager, "github", "ghp-secret"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	// Secrets are not listed as providers and never read from the environment
+	t.Setenv("CODA_API_KEY", "from-env")
+	providers, err := manager.ListProviders()
+	if err != nil {
+		t.Fatalf("Failed to list providers: %v", err)
+	}
+	if len(providers) != 0 {
+		t.Errorf("Expected no providers, got %v", providers)
+	}
+
+	t.Setenv("CODA_TEST_NPM_TOKEN", "npm-secret")
+	values, err := ResolveSecretRefs(map[string]string{
+		"GITHUB_TOKEN": "keychain:github",
+		"NPM_TOKEN":    "env:CODA_TEST_NPM_TOKEN",
+	}, manager)
+	if err != nil {
+		t.Fatalf("Failed to resolve secrets: %v", err)
+	}
+	if values["GITHUB_TOKEN"] != "ghp-secret" || values["NPM_TOKEN"] != "npm-secret" {
+		t.Errorf("Unexpected secret values: %v", values)
+	}
+
+	tests := []struct {
+		name string
+		ref  string
+	}{
+		{"missing keychain secret", "keychain:gitlab"},
+		{"unset variable", "env:CODA_TEST_UNSET"},
+		{"plain value", "hunter2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ResolveSecretRefs(map[string]string{"TOKEN": tt.ref}, manager); err == nil {
+				t.Errorf("Expected an error for %s", tt.ref)
+			}
+		})
+	}
+}
@@ -0,0 +1,597 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeFileNames are the default Compose file names, in the order Docker
+// Compose looks for them
+var ComposeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"}
+
+// FindComposeFile returns the path of the Compose file in dir, or "" when
+// there is none
+func FindComposeFile(dir string) string {
+	for _, name := range ComposeFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Build is the build section of a Compose service
+type Build struct {
+	Context    string `json:"context"`
+	Dockerfile string `json:"dockerfile,omitempty"`
+	Target     string `json:"target,omitempty"`
+	// Inline is set when the Dockerfile is given with dockerfile_inline
+	Inline bool `json:"inline,omitempty"`
+}
+
+// Port is a port mapping of a Compose service
+type Port struct {
+	Line      int    `json:"line"`
+	Spec      string `json:"spec"`
+	HostIP    string `json:"host_ip,omitempty"`
+	Published string `json:"published,omitempty"`
+	Target    string `json:"target"`
+	Protocol  string `json:"protocol"`
+}
+
+// Mount is a volume or bind mount of a Compose service
+type Mount struct {
+	Line   int    `json:"line"`
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target"`
+}
+
+// Reference is a name a service refers to, with its line
+type Reference struct {
+	Line int    `json:"line"`
+	Name string `json:"name"`
+}
+
+// Service is a service of a Compose file
+type Service struct {
+	Name      string      `json:"name"`
+	Line      int         `json:"line"`
+	Image     string      `json:"image,omitempty"`
+	Build     *Build      `json:"build,omitempty"`
+	Ports     []Port      `json:"ports,omitempty"`
+	Mounts    []Mount     `json:"volumes,omitempty"`
+	DependsOn []Reference `json:"depends_on,omitempty"`
+	Networks  []Reference `json:"networks,omitempty"`
+	EnvFiles  []Reference `json:"env_files,omitempty"`
+	Extends   bool        `json:"extends,omitempty"`
+
+	buildLine int
+	// optionalEnvFiles are the env files marked required: false
+	optionalEnvFiles map[string]bool
+}
+
+// Compose is a parsed Compose file
+type Compose struct {
+	Services []Service `json:"services"`
+	Volumes  []string  `json:"volumes,omitempty"`
+	Networks []string  `json:"networks,omitempty"`
+
+	// issues are the problems found while parsing
+	issues []Issue
+}
+
+// topLevelKeys are the top-level keys of the Compose specification
+var topLevelKeys = map[string]bool{
+	"version": true, "name": true, "services": true, "volumes": true,
+	"networks": true, "configs": true, "secrets": true, "include": true,
+}
+
+// ParseCompose parses a Compose file. Structural problems, like a service
+// that is not a mapping, are kept for Check to report.
+func ParseCompose(data []byte) (*Compose, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	c := &Compose{}
+	if len(doc.Content) == 0 {
+		return c, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: the Compose file must be a mapping", root.Line)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch {
+		case key.Value == "services":
+			c.parseServices(value)
+		case key.Value == "volumes":
+			c.Volumes = mappingKeys(value)
+		case key.Value == "networks":
+			c.Networks = mappingKeys(value)
+		case key.Value == "version":
+			c.issue(key.Line, SeverityInfo, "obsolete-version", "the version key is obsolete and ignored by Docker Compose")
+		case !topLevelKeys[key.Value] && !strings.HasPrefix(key.Value, "x-"):
+			c.issue(key.Line, SeverityWarning, "unknown-key", "unknown top-level key %q", key.Value)
+		}
+	}
+	return c, nil
+}
+
+func (c *Compose) issue(line int, severity, rule, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{Line: line, Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *Compose) parseServices(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		c.issue(node.Line, SeverityError, "invalid-services", "services must be a mapping of service names")
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, body := node.Content[i], node.Content[i+1]
+		service := Service{Name: name.Value, Line: name.Line}
+		if body.Kind != yaml.MappingNode {
+			c.issue(name.Line, SeverityError, "invalid-service", "service %s must be a mapping", name.Value)
+			c.Services = append(c.Services, service)
+			continue
+		}
+		for j := 0; j+1 < len(body.Content); j += 2 {
+			key, value := body.Content[j], body.Content[j+1]
+			switch key.Value {
+			case "image":
+				service.Image = value.Value
+			case "build":
+				service.Build = parseBuild(value)
+				service.buildLine = key.Line
+			case "ports":
+				for _, item := range sequence(value) {
+					service.Ports = append(service.Ports, parsePort(item))
+				}
+			case "volumes":
+				for _, item := range sequence(value) {
+					service.Mounts = append(service.Mounts, parseMount(item))
+				}
+			case "depends_on":
+				service.DependsOn = references(value)
+			case "networks":
+				service.Networks = references(value)
+			case "env_file":
+				service.EnvFiles, service.optionalEnvFiles = envFiles(value)
+			case "extends":
+				service.Extends = true
+			}
+		}
+		c.Services = append(c.Services, service)
+	}
+}
+
+func parseBuild(node *yaml.Node) *Build {
+	if node.Kind == yaml.ScalarNode {
+		return &Build{Context: node.Value}
+	}
+	build := &Build{Context: "."}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "context":
+			build.Context = node.Content[i+1].Value
+		case "dockerfile":
+			build.Dockerfile = node.Content[i+1].Value
+		case "dockerfile_inline":
+			build.Inline = true
+		case "target":
+			build.Target = node.Content[i+1].Value
+		}
+	}
+	return build
+}
+
+// parsePort reads the short syntax [[ip:]published:]target[/protocol] or
+// the long syntax mapping; Target is empty when the port is invalid
+func parsePort(node *yaml.Node) Port {
+	port := Port{Line: node.Line, Protocol: "tcp"}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1].Value
+			switch node.Content[i].Value {
+			case "target":
+				port.Target = value
+			case "published":
+				port.Published = value
+			case "host_ip":
+				port.HostIP = value
+			case "protocol":
+				port.Protocol = value
+			}
+		}
+		port.Spec = port.Published + ":" + port.Target
+		return port
+	}
+
+	port.Spec = node.Value
+	spec, protocol, hasProtocol := strings.Cut(node.Value, "/")
+	if hasProtocol {
+		port.Protocol = protocol
+	}
+	parts := strings.Split(spec, ":")
+	if strings.HasPrefix(spec, "[") {
+		// An IPv6 host address: [::1]:8080:80
+		if end := strings.Index(spec, "]"); end > 0 {
+			port.HostIP = spec[1:end]
+			parts = append([]string{""}, strings.Split(strings.TrimPrefix(spec[end+1:], ":"), ":")...)
+		}
+	}
+	switch len(parts) {
+	case 1:
+		port.Target = parts[0]
+	case 2:
+		port.Published, port.Target = parts[0], parts[1]
+	case 3:
+		if port.HostIP == "" {
+			port.HostIP = parts[0]
+		}
+		port.Published, port.Target = parts[1], parts[2]
+	}
+	return port
+}
+
+// parseMount reads the short syntax source:target[:mode] or the long
+// syntax mapping
+func parseMount(node *yaml.Node) Mount {
+	mount := Mount{Line: node.Line}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1].Value
+			switch node.Content[i].Value {
+			case "type":
+				mount.Type = value
+			case "source":
+				mount.Source = value
+			case "target":
+				mount.Target = value
+			}
+		}
+		return mount
+	}
+
+	parts := strings.Split(node.Value, ":")
+	if len(parts) >= 2 && isWindowsPath(node.Value) {
+		// C:\data:/data keeps its drive letter
+		parts = append([]string{parts[0] + ":" + parts[1]}, parts[2:]...)
+	}
+	if len(parts) == 1 {
+		mount.Type = "volume"
+		mount.Target = parts[0]
+		return mount
+	}
+	mount.Source, mount.Target = parts[0], parts[1]
+	mount.Type = "volume"
+	if isHostPath(mount.Source) {
+		mount.Type = "bind"
+	}
+	return mount
+}
+
+// isHostPath reports whether a short syntax source is a host path rather
+// than a volume name
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") ||
+		strings.HasPrefix(source, "~") || strings.HasPrefix(source, "$") || isWindowsPath(source)
+}
+
+// references reads a list of names, or the keys of a mapping
+func references(node *yaml.Node) []Reference {
+	var refs []Reference
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			refs = append(refs, Reference{Line: item.Line, Name: item.Value})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			refs = append(refs, Reference{Line: node.Content[i].Line, Name: node.Content[i].Value})
+		}
+	}
+	return refs
+}
+
+// envFiles reads env_file as a path, a list of paths, or a list of
+// mappings with path and required
+func envFiles(node *yaml.Node) ([]Reference, map[string]bool) {
+	var files []Reference
+	optional := make(map[string]bool)
+	for _, item := range sequence(node) {
+		if item.Kind != yaml.MappingNode {
+			files = append(files, Reference{Line: item.Line, Name: item.Value})
+			continue
+		}
+		var file Reference
+		required := true
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			switch item.Content[i].Value {
+			case "path":
+				file = Reference{Line: item.Content[i+1].Line, Name: item.Content[i+1].Value}
+			case "required":
+				required = item.Content[i+1].Value != "false"
+			}
+		}
+		files = append(files, file)
+		if !required {
+			optional[file.Name] = true
+		}
+	}
+	return files, optional
+}
+
+// sequence returns the items of a sequence, or the node itself when it is
+// a single scalar
+func sequence(node *yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.SequenceNode {
+		return node.Content
+	}
+	if node.Kind == yaml.ScalarNode && node.Value != "" {
+		return []*yaml.Node{node}
+	}
+	return nil
+}
+
+func mappingKeys(node *yaml.Node) []string {
+	var keys []string
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+	}
+	return keys
+}
+
+// LoadCompose reads and parses the Compose file at path
+func LoadCompose(path string) (*Compose, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Compose file: %w", err)
+	}
+	c, err := ParseCompose(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// CheckCompose parses and checks the Compose file at path, including the
+// Dockerfiles its services build
+func CheckCompose(path string) (*Compose, []Issue, error) {
+	c, err := LoadCompose(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	issues := c.Check(filepath.Dir(path))
+	for i := range issues {
+		if issues[i].File == "" {
+			issues[i].File = path
+		}
+	}
+	return c, issues, nil
+}
+
+// portNumber matches a port or a port range
+var portNumber = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// Check reports the problems of the Compose file. Relative paths are
+// resolved against dir, the directory of the file; the Dockerfiles of the
+// services that build an image are checked too, with their file set.
+func (c *Compose) Check(dir string) []Issue {
+	issues := append([]Issue(nil), c.issues...)
+	add := func(line int, severity, rule, format string, args ...interface{}) {
+		issues = append(issues, Issue{Line: line, Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(c.Services) == 0 {
+		add(0, SeverityError, "no-services", "the Compose file defines no services")
+		return issues
+	}
+
+	services := make(map[string]*Service, len(c.Services))
+	for i := range c.Services {
+		services[c.Services[i].Name] = &c.Services[i]
+	}
+	volumes := toSet(c.Volumes)
+	networks := toSet(c.Networks)
+	networks["default"] = true
+	published := make(map[string]string)
+
+	for i := range c.Services {
+		s := &c.Services[i]
+		if s.Image == "" && s.Build == nil && !s.Extends {
+			add(s.Line, SeverityError, "no-image", "service %s has neither an image nor a build section", s.Name)
+		}
+		if s.Build != nil {
+			issues = append(issues, checkBuild(s, dir)...)
+		}
+
+		for _, dep := range s.DependsOn {
+			switch {
+			case dep.Name == s.Name:
+				add(dep.Line, SeverityError, "unknown-service", "service %s depends on itself", s.Name)
+			case services[dep.Name] == nil:
+				add(dep.Line, SeverityError, "unknown-service", "service %s depends on undefined service %s", s.Name, dep.Name)
+			}
+		}
+		for _, network := range s.Networks {
+			if !networks[network.Name] {
+				add(network.Line, SeverityError, "undeclared-network", "network %s of service %s is not declared under the top-level networks", network.Name, s.Name)
+			}
+		}
+
+		for _, port := range s.Ports {
+			if strings.Contains(port.Spec, "$") {
+				continue
+			}
+			if !validPort(port.Target) || (port.Published != "" && !validPort(port.Published)) {
+				add(port.Line, SeverityError, "invalid-port", "port %q of service %s is not [[ip:]host:]container[/protocol]", port.Spec, s.Name)
+				continue
+			}
+			if port.Published == "" {
+				continue
+			}
+			key := port.HostIP + ":" + port.Published + "/" + port.Protocol
+			if other, taken := published[key]; taken {
+				add(port.Line, SeverityError, "port-conflict", "host port %s of service %s is already published by service %s", port.Published, s.Name, other)
+				continue
+			}
+			published[key] = s.Name
+		}
+
+		for _, mount := range s.Mounts {
+			switch {
+			case mount.Type == "volume" && mount.Source != "" && !volumes[mount.Source]:
+				add(mount.Line, SeverityError, "undeclared-volume", "volume %s of service %s is not declared under the top-level volumes", mount.Source, s.Name)
+			case mount.Type == "bind" && !strings.ContainsAny(mount.Source, "$~"):
+				if _, err := os.Stat(resolve(dir, mount.Source)); err != nil {
+					add(mount.Line, SeverityWarning, "missing-path", "bind mount source %s of service %s does not exist; Docker creates it as an empty directory", mount.Source, s.Name)
+				}
+			}
+		}
+
+		for _, file := range s.EnvFiles {
+			if s.optionalEnvFiles[file.Name] || strings.Contains(file.Name, "$") {
+				continue
+			}
+			if _, err := os.Stat(resolve(dir, file.Name)); err != nil {
+				add(file.Line, SeverityError, "missing-env-file", "env_file %s of service %s does not exist", file.Name, s.Name)
+			}
+		}
+	}
+
+	if cycle := dependencyCycle(c.Services, services); cycle != nil {
+		add(services[cycle[0]].Line, SeverityError, "dependency-cycle", "services depend on each other in a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return issues
+}
+
+// checkBuild checks the build context and Dockerfile of a service
+func checkBuild(s *Service, dir string) []Issue {
+	context := s.Build.Context
+	if strings.Contains(context, "://") || strings.HasPrefix(context, "git@") || strings.Contains(context, "$") {
+		return nil
+	}
+	contextDir := resolve(dir, context)
+	if info, err := os.Stat(contextDir); err != nil || !info.IsDir() {
+		return []Issue{{Line: s.buildLine, Severity: SeverityError, Rule: "missing-build-context", Message: fmt.Sprintf("build context %s of service %s is not a directory", context, s.Name)}}
+	}
+	if s.Build.Inline {
+		return nil
+	}
+
+	dockerfile := s.Build.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	path := resolve(contextDir, dockerfile)
+	if _, err := os.Stat(path); err != nil {
+		return []Issue{{Line: s.buildLine, Severity: SeverityError, Rule: "missing-dockerfile", Message: fmt.Sprintf("Dockerfile %s of service %s does not exist", path, s.Name)}}
+	}
+
+	d, issues, err := CheckDockerfile(path, contextDir)
+	if err != nil {
+		return []Issue{{Line: s.buildLine, Severity: SeverityError, Rule: "invalid-dockerfile", Message: err.Error()}}
+	}
+	if s.Build.Target != "" {
+		found := false
+		for _, stage := range d.Stages {
+			found = found || stage.Name == strings.ToLower(s.Build.Target)
+		}
+		if !found {
+			issues = append(issues, Issue{Line: s.buildLine, Severity: SeverityError, Rule: "unknown-target", Message: fmt.Sprintf("build target %s of service %s is not a stage of %s", s.Build.Target, s.Name, dockerfile)})
+		}
+	}
+	return issues
+}
+
+// dependencyCycle returns the services of a depends_on cycle, starting and
+// ending with the same service, or nil
+func dependencyCycle(list []Service, services map[string]*Service) []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range services[name].DependsOn {
+			if services[dep.Name] == nil || dep.Name == name {
+				continue
+			}
+			if cycle := visit(dep.Name); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	names := make([]string, 0, len(list))
+	for _, s := range list {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// validPort reports whether value is a port or port range in 1-65535
+func validPort(value string) bool {
+	if !portNumber.MatchString(value) {
+		return false
+	}
+	for _, part := range strings.Split(value, "-") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > 65535 {
+			return false
+		}
+	}
+	return true
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, filepath.FromSlash(path))
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
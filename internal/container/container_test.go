@@ -0,0 +1,208 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerfile(t *testing.T) {
+	data := "# syntax=docker/dockerfile:1\n" +
+		"# escape=`\n" +
+		"FROM golang:1.22 AS build\n" +
+		"# comment\n" +
+		"RUN go build `\n" +
+		"    # inside a continuation\n" +
+		"    -o /app .\n" +
+		"COPY <<EOF /etc/app.conf\n" +
+		"debug = true\n" +
+		"EOF\n" +
+		"FROM scratch\n" +
+		"COPY --from=build --chmod=755 /app /app\n"
+
+	d, err := ParseDockerfile([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, d.Instructions, 5)
+	assert.Equal(t, Instruction{Line: 5, Command: "RUN", Args: "go build  -o /app ."}, d.Instructions[1])
+	assert.Equal(t, "<<EOF /etc/app.conf\ndebug = true", d.Instructions[2].Args)
+	assert.Equal(t, 11, d.Instructions[3].Line)
+	from, ok := d.Instructions[4].Flag("from")
+	assert.True(t, ok)
+	assert.Equal(t, "build", from)
+	assert.Equal(t, []Stage{
+		{Index: 0, Name: "build", Image: "golang:1.22", Line: 3},
+		{Index: 1, Image: "scratch", Line: 11},
+	}, d.Stages)
+
+	_, err = ParseDockerfile([]byte("FROM alpine:3\nRUN <<EOF\necho hi\n"))
+	assert.ErrorContains(t, err, "heredoc EOF is not terminated")
+}
+
+func rules(issues []Issue) map[string]int {
+	found := make(map[string]int)
+	for _, issue := range issues {
+		found[issue.Rule] = issue.Line
+	}
+	return found
+}
+
+func TestLintDockerfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		want       map[string]int
+	}{
+		{
+			name:       "clean multi-stage build",
+			dockerfile: "ARG GO=1.22\nFROM golang:${GO} AS build\nWORKDIR /src\nCOPY . .\nRUN go build -o /app .\nFROM gcr.io/distroless/static:nonroot\nCOPY --from=build /app /app\nUSER nonroot\nEXPOSE 8080/tcp\nENTRYPOINT [\"/app\"]\n",
+			want:       map[string]int{},
+		},
+		{
+			name:       "instruction before FROM",
+			dockerfile: "RUN echo hi\nFROM alpine:3.19\nUSER app\n",
+			want:       map[string]int{"from-first": 1},
+		},
+		{
+			name:       "untagged and latest images",
+			dockerfile: "FROM ubuntu AS base\nFROM node:latest\nFROM base\nUSER 1000\n",
+			want:       map[string]int{"untagged-image": 1, "latest-tag": 2},
+		},
+		{
+			name:       "apt-get without -y",
+			dockerfile: "FROM debian:12\nRUN apt-get update && apt-get install curl\nUSER app\n",
+			want:       map[string]int{"apt-get-yes": 2, "apt-lists": 2},
+		},
+		{
+			name:       "unknown stage and instruction",
+			dockerfile: "FROM alpine:3.19\nCOPY --from=builder /app /app\nCOPY --from=1 /x /x\nRUNN true\nUSER app\n",
+			want:       map[string]int{"unknown-stage": 3, "unknown-instruction": 4},
+		},
+		{
+			name:       "bad practices",
+			dockerfile: "FROM alpine:3.19\nMAINTAINER me\nADD app.go /src/\nWORKDIR src\nRUN cd /src && sudo make\nEXPOSE 80000\nCMD a\nCMD b\nENTRYPOINT /app\n",
+			want: map[string]int{
+				"deprecated-maintainer": 2, "prefer-copy": 3, "relative-workdir": 4, "cd-in-run": 5,
+				"sudo": 5, "invalid-port": 6, "multiple-cmd": 8, "shell-form": 9, "root-user": 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDockerfile([]byte(tt.dockerfile))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rules(d.Lint()))
+		})
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestCheckDockerfileContext(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Dockerfile":  "FROM alpine:3.19\nCOPY go.mod go.sum ./\nCOPY cmd/*.go ./\nCOPY $SRC .\nUSER app\n",
+		"go.mod":      "module x\n",
+		"cmd/main.go": "package main\n",
+	})
+
+	_, issues, err := CheckDockerfile(filepath.Join(dir, "Dockerfile"), dir)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, Issue{
+		File:     filepath.Join(dir, "Dockerfile"),
+		Line:     2,
+		Severity: SeverityError,
+		Rule:     "missing-source",
+		Message:  "COPY source go.sum does not exist in the build context",
+	}, issues[0])
+	assert.True(t, HasErrors(issues))
+}
+
+const composeFile = `version: "3.8"
+services:
+  web:
+    build:
+      context: ./web
+      target: prod
+    ports:
+      - "8080:80"
+      - "127.0.0.1:9000:9000/udp"
+      - "70000:80"
+    depends_on:
+      - db
+      - cache
+    env_file: .env
+    volumes:
+      - data:/var/lib/data
+      - ./config:/etc/app:ro
+  db:
+    image: postgres:16
+    ports:
+      - target: 5432
+        published: 8080
+    depends_on:
+      web:
+        condition: service_started
+    networks: [backend]
+  worker:
+    restart: always
+volumes:
+  logs: {}
+`
+
+func TestCheckCompose(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"compose.yaml":   composeFile,
+		"web/Dockerfile": "FROM nginx:1.25 AS base\nCOPY site/ /usr/share/nginx/html\nUSER nginx\n",
+	})
+	assert.Equal(t, filepath.Join(dir, "compose.yaml"), FindComposeFile(dir))
+
+	c, issues, err := CheckCompose(filepath.Join(dir, "compose.yaml"))
+	require.NoError(t, err)
+	require.Len(t, c.Services, 3)
+	assert.Equal(t, &Build{Context: "./web", Target: "prod"}, c.Services[0].Build)
+	assert.Equal(t, Port{Line: 9, Spec: "127.0.0.1:9000:9000/udp", HostIP: "127.0.0.1", Published: "9000", Target: "9000", Protocol: "udp"}, c.Services[0].Ports[1])
+	assert.Equal(t, Mount{Line: 17, Type: "bind", Source: "./config", Target: "/etc/app"}, c.Services[0].Mounts[1])
+
+	byRule := make(map[string]Issue)
+	for _, issue := range issues {
+		byRule[issue.Rule] = issue
+	}
+	assert.Equal(t, 1, byRule["obsolete-version"].Line)
+	assert.Equal(t, 10, byRule["invalid-port"].Line)
+	assert.Equal(t, 13, byRule["unknown-service"].Line)
+	assert.Equal(t, 14, byRule["missing-env-file"].Line)
+	assert.Equal(t, 16, byRule["undeclared-volume"].Line)
+	assert.Equal(t, SeverityWarning, byRule["missing-path"].Severity)
+	assert.Equal(t, 21, byRule["port-conflict"].Line)
+	assert.Equal(t, 26, byRule["undeclared-network"].Line)
+	assert.Equal(t, 27, byRule["no-image"].Line)
+	assert.Equal(t, "services depend on each other in a cycle: db -> web -> db", byRule["dependency-cycle"].Message)
+	assert.Equal(t, "build target prod of service web is not a stage of Dockerfile", byRule["unknown-target"].Message)
+	assert.Equal(t, filepath.Join(dir, "web", "Dockerfile"), byRule["missing-source"].File)
+	assert.Equal(t, filepath.Join(dir, "compose.yaml"), byRule["no-image"].File)
+}
+
+func TestParseComposeErrors(t *testing.T) {
+	_, err := ParseCompose([]byte("services: [\n"))
+	assert.ErrorContains(t, err, "invalid YAML")
+
+	c, err := ParseCompose([]byte("- a\n- b\n"))
+	assert.Nil(t, c)
+	assert.Error(t, err)
+
+	c, err = ParseCompose([]byte("servces:\n  web:\n    image: nginx\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"unknown-key": 1, "no-services": 0}, rules(c.Check(t.TempDir())))
+}
@@ -0,0 +1,455 @@
+// Package container parses and checks Dockerfiles and Compose files
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severities of an issue
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Issue is a problem found in a Dockerfile or Compose file
+type Issue struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// String formats the issue as file:line: severity: message (rule)
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, i.Line)
+	}
+	if location != "" {
+		location += ": "
+	}
+	return fmt.Sprintf("%s%s: %s (%s)", location, i.Severity, i.Message, i.Rule)
+}
+
+// HasErrors reports whether one of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Instruction is one Dockerfile instruction, with its continuation lines
+// joined and heredoc bodies appended to Args
+type Instruction struct {
+	Line    int      `json:"line"`
+	Command string   `json:"command"`
+	Flags   []string `json:"flags,omitempty"`
+	Args    string   `json:"args"`
+}
+
+// Flag returns the value of the flag --name, if set
+func (i Instruction) Flag(name string) (string, bool) {
+	for _, flag := range i.Flags {
+		key, value, _ := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		if key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Stage is a build stage, started by a FROM instruction
+type Stage struct {
+	Index int    `json:"index"`
+	Name  string `json:"name,omitempty"`
+	Image string `json:"image"`
+	Line  int    `json:"line"`
+}
+
+// Dockerfile is a parsed Dockerfile
+type Dockerfile struct {
+	Instructions []Instruction `json:"instructions"`
+	Stages       []Stage       `json:"stages"`
+}
+
+// knownInstructions are the instructions the Dockerfile syntax defines
+var knownInstructions = map[string]bool{
+	"FROM": true, "RUN": true, "CMD": true, "LABEL": true, "MAINTAINER": true,
+	"EXPOSE": true, "ENV": true, "ADD": true, "COPY": true, "ENTRYPOINT": true,
+	"VOLUME": true, "USER": true, "WORKDIR": true, "ARG": true, "ONBUILD": true,
+	"STOPSIGNAL": true, "HEALTHCHECK": true, "SHELL": true,
+}
+
+var (
+	directivePattern = regexp.MustCompile(`^#\s*([a-zA-Z]+)\s*=\s*(\S+)\s*$`)
+	heredocPattern   = regexp.MustCompile(`<<-?["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+)
+
+// ParseDockerfile parses the instructions of a Dockerfile. Continuation
+// lines, comments, the escape directive and heredocs are handled; unknown
+// instructions are kept for Lint to report.
+func ParseDockerfile(data []byte) (*Dockerfile, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	escape := `\`
+	d := &Dockerfile{}
+
+	directives := true
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if directives {
+			if match := directivePattern.FindStringSubmatch(trimmed); match != nil {
+				if strings.EqualFold(match[1], "escape") {
+					if match[2] != `\` && match[2] != "`" {
+						return nil, fmt.Errorf("line %d: invalid escape character %q", i+1, match[2])
+					}
+					escape = match[2]
+				}
+				continue
+			}
+			directives = false
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		start := i
+		logical := strings.TrimSpace(lines[i])
+		for strings.HasSuffix(logical, escape) && i+1 < len(lines) {
+			logical = strings.TrimSuffix(logical, escape)
+			i++
+			next := strings.TrimSpace(lines[i])
+			for (next == "" || strings.HasPrefix(next, "#")) && i+1 < len(lines) {
+				i++
+				next = strings.TrimSpace(lines[i])
+			}
+			logical += " " + next
+		}
+		logical = strings.TrimSuffix(logical, escape)
+
+		command, args, _ := strings.Cut(logical, " ")
+		instruction := Instruction{Line: start + 1, Command: strings.ToUpper(command)}
+		args = strings.TrimSpace(args)
+		if instruction.Command != "RUN" || !strings.HasPrefix(args, "[") {
+			for strings.HasPrefix(args, "--") {
+				flag, rest, _ := strings.Cut(args, " ")
+				instruction.Flags = append(instruction.Flags, flag)
+				args = strings.TrimSpace(rest)
+			}
+		}
+
+		switch instruction.Command {
+		case "RUN", "COPY", "ADD":
+			for _, match := range heredocPattern.FindAllStringSubmatch(args, -1) {
+				body, end, ok := readHeredoc(lines, i+1, match[1])
+				if !ok {
+					return nil, fmt.Errorf("line %d: heredoc %s is not terminated", start+1, match[1])
+				}
+				args += "\n" + body
+				i = end
+			}
+		}
+		instruction.Args = args
+		d.Instructions = append(d.Instructions, instruction)
+
+		if instruction.Command == "FROM" {
+			d.Stages = append(d.Stages, parseStage(len(d.Stages), instruction))
+		}
+	}
+	return d, nil
+}
+
+// readHeredoc returns the lines from start up to the terminator word and the
+// index of the terminator line
+func readHeredoc(lines []string, start int, word string) (string, int, bool) {
+	for i := start; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == word {
+			return strings.Join(lines[start:i], "\n"), i, true
+		}
+	}
+	return "", 0, false
+}
+
+// parseStage reads the image and name of FROM image [AS name]
+func parseStage(index int, from Instruction) Stage {
+	stage := Stage{Index: index, Line: from.Line}
+	fields := strings.Fields(from.Args)
+	if len(fields) > 0 {
+		stage.Image = fields[0]
+	}
+	if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+		stage.Name = strings.ToLower(fields[2])
+	}
+	return stage
+}
+
+// LoadDockerfile reads and parses the Dockerfile at path
+func LoadDockerfile(path string) (*Dockerfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	d, err := ParseDockerfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// CheckDockerfile parses and lints the Dockerfile at path. When contextDir
+// is set, the COPY and ADD sources are also looked up in it.
+func CheckDockerfile(path, contextDir string) (*Dockerfile, []Issue, error) {
+	d, err := LoadDockerfile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	issues := d.Lint()
+	if contextDir != "" {
+		issues = append(issues, d.CheckContext(contextDir)...)
+	}
+	for i := range issues {
+		issues[i].File = path
+	}
+	return d, issues, nil
+}
+
+var (
+	cdPattern      = regexp.MustCompile(`(^|&&|;|\|\|)\s*cd\s`)
+	sudoPattern    = regexp.MustCompile(`(^|&&|;|\|\|)\s*sudo\s`)
+	aptGetInstall  = regexp.MustCompile(`apt-get\s+(\S+\s+)*install\b`)
+	aptGetYes      = regexp.MustCompile(`(\s-[a-zA-Z]*y|--yes|--assume-yes|-qq)\b`)
+	archivePattern = regexp.MustCompile(`\.(tar|tar\.gz|tgz|tar\.bz2|tbz2|tar\.xz|txz)$`)
+)
+
+// Lint checks the instructions for mistakes that break the build or the
+// image, and for common bad practices
+func (d *Dockerfile) Lint() []Issue {
+	var issues []Issue
+	add := func(line int, severity, rule, format string, args ...interface{}) {
+		issues = append(issues, Issue{Line: line, Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(d.Instructions) == 0 {
+		add(0, SeverityError, "empty-dockerfile", "the Dockerfile has no instructions")
+		return issues
+	}
+
+	stageNames := make(map[string]int)
+	stage := -1
+	var cmds, entrypoints []int
+	var user string
+	lastUserLine := 0
+
+	for _, in := range d.Instructions {
+		if !knownInstructions[in.Command] {
+			add(in.Line, SeverityError, "unknown-instruction", "unknown instruction %s", in.Command)
+			continue
+		}
+		if stage < 0 && in.Command != "FROM" && in.Command != "ARG" {
+			add(in.Line, SeverityError, "from-first", "%s comes before the first FROM; only ARG may precede it", in.Command)
+		}
+		if strings.TrimSpace(in.Args) == "" {
+			add(in.Line, SeverityError, "missing-arguments", "%s has no arguments", in.Command)
+			if in.Command == "FROM" {
+				stage++
+			}
+			continue
+		}
+
+		switch in.Command {
+		case "FROM":
+			stage++
+			s := d.Stages[stage]
+			cmds, entrypoints = nil, nil
+			user, lastUserLine = "", 0
+			fields := strings.Fields(in.Args)
+			if len(fields) != 1 && !(len(fields) == 3 && strings.EqualFold(fields[1], "AS")) {
+				add(in.Line, SeverityError, "invalid-from", "FROM takes an image and an optional AS name")
+			}
+			if _, isStage := stageNames[strings.ToLower(s.Image)]; !isStage {
+				checkImageTag(s.Image, in.Line, add)
+			}
+			if s.Name != "" {
+				if _, exists := stageNames[s.Name]; exists {
+					add(in.Line, SeverityError, "duplicate-stage", "stage name %q is already used", s.Name)
+				}
+				stageNames[s.Name] = stage
+			}
+
+		case "COPY":
+			from, ok := in.Flag("from")
+			if !ok || strings.Contains(from, "$") {
+				break
+			}
+			if n, err := strconv.Atoi(from); err == nil {
+				if n >= stage {
+					add(in.Line, SeverityError, "unknown-stage", "COPY --from=%d refers to a stage that is not defined before this one", n)
+				}
+			} else if _, exists := stageNames[strings.ToLower(from)]; !exists && !strings.ContainsAny(from, "/:.") {
+				add(in.Line, SeverityError, "unknown-stage", "COPY --from=%s refers to an undefined stage; it would be pulled as an image", from)
+			}
+
+		case "ADD":
+			sources := pathArgs(in.Args)
+			if len(sources) > 1 {
+				sources = sources[:len(sources)-1]
+			}
+			local := true
+			for _, source := range sources {
+				if isURL(source) || archivePattern.MatchString(source) {
+					local = false
+				}
+			}
+			if local {
+				add(in.Line, SeverityInfo, "prefer-copy", "use COPY for local files; ADD is for URLs and archives")
+			}
+
+		case "RUN":
+			if aptGetInstall.MatchString(in.Args) {
+				if !aptGetYes.MatchString(in.Args) {
+					add(in.Line, SeverityError, "apt-get-yes", "apt-get install without -y waits for confirmation and fails the build")
+				}
+				if !strings.Contains(in.Args, "/var/lib/apt/lists") {
+					add(in.Line, SeverityInfo, "apt-lists", "remove /var/lib/apt/lists/* in the same RUN to keep the image small")
+				}
+			}
+			if cdPattern.MatchString(in.Args) {
+				add(in.Line, SeverityInfo, "cd-in-run", "use WORKDIR instead of cd to change directories")
+			}
+			if sudoPattern.MatchString(in.Args) {
+				add(in.Line, SeverityWarning, "sudo", "sudo is not needed in RUN, which runs as the current USER; switch with USER instead")
+			}
+
+		case "WORKDIR":
+			dir := strings.Trim(in.Args, `"`)
+			if !strings.HasPrefix(dir, "/") && !strings.HasPrefix(dir, "$") && !isWindowsPath(dir) {
+				add(in.Line, SeverityWarning, "relative-workdir", "WORKDIR %s is relative to the previous WORKDIR; use an absolute path", dir)
+			}
+
+		case "EXPOSE":
+			for _, port := range strings.Fields(in.Args) {
+				if strings.Contains(port, "$") {
+					continue
+				}
+				ports, protocol, hasProtocol := strings.Cut(port, "/")
+				if !validPort(ports) || (hasProtocol && protocol != "tcp" && protocol != "udp" && protocol != "sctp") {
+					add(in.Line, SeverityError, "invalid-port", "EXPOSE %s is not a port like 8080 or 8080/udp", port)
+				}
+			}
+
+		case "CMD":
+			cmds = append(cmds, in.Line)
+			if len(cmds) > 1 {
+				add(in.Line, SeverityWarning, "multiple-cmd", "only the last CMD of a stage takes effect (previous one on line %d)", cmds[len(cmds)-2])
+			}
+		case "ENTRYPOINT":
+			entrypoints = append(entrypoints, in.Line)
+			if len(entrypoints) > 1 {
+				add(in.Line, SeverityWarning, "multiple-entrypoint", "only the last ENTRYPOINT of a stage takes effect (previous one on line %d)", entrypoints[len(entrypoints)-2])
+			}
+			if !strings.HasPrefix(in.Args, "[") {
+				add(in.Line, SeverityInfo, "shell-form", "ENTRYPOINT in shell form runs under /bin/sh -c and does not receive signals; use the JSON form")
+			}
+
+		case "USER":
+			user, lastUserLine = in.Args, in.Line
+		case "MAINTAINER":
+			add(in.Line, SeverityInfo, "deprecated-maintainer", "MAINTAINER is deprecated; use LABEL maintainer=...")
+		}
+	}
+
+	if stage < 0 {
+		add(0, SeverityError, "from-first", "the Dockerfile has no FROM instruction")
+	} else if final := d.Stages[stage]; final.Image != "scratch" && final.Image != "" {
+		name, _, _ := strings.Cut(user, ":")
+		if name == "" || name == "root" || name == "0" {
+			line := lastUserLine
+			if line == 0 {
+				line = final.Line
+			}
+			add(line, SeverityInfo, "root-user", "the final stage runs as root; add a USER with fewer privileges")
+		}
+	}
+	return issues
+}
+
+// checkImageTag reports a FROM image without a tag, or with the latest tag
+func checkImageTag(image string, line int, add func(int, string, string, string, ...interface{})) {
+	if image == "scratch" || strings.Contains(image, "$") || strings.Contains(image, "@") {
+		return
+	}
+	name := image
+	if slash := strings.LastIndex(image, "/"); slash >= 0 {
+		name = image[slash+1:]
+	}
+	_, tag, tagged := strings.Cut(name, ":")
+	switch {
+	case !tagged:
+		add(line, SeverityWarning, "untagged-image", "%s has no tag, so the latest image is used; pin a version", image)
+	case tag == "latest":
+		add(line, SeverityWarning, "latest-tag", "%s changes over time; pin a version", image)
+	}
+}
+
+// CheckContext reports COPY and ADD sources missing from the build context.
+// Sources with variables, URLs and copies from other stages are skipped.
+func (d *Dockerfile) CheckContext(contextDir string) []Issue {
+	var issues []Issue
+	for _, in := range d.Instructions {
+		if in.Command != "COPY" && in.Command != "ADD" {
+			continue
+		}
+		if _, fromStage := in.Flag("from"); fromStage || strings.Contains(in.Args, "<<") {
+			continue
+		}
+		sources := pathArgs(in.Args)
+		if len(sources) < 2 {
+			continue
+		}
+		for _, source := range sources[:len(sources)-1] {
+			if isURL(source) || strings.Contains(source, "$") {
+				continue
+			}
+			matches, err := filepath.Glob(filepath.Join(contextDir, filepath.FromSlash(source)))
+			if err == nil && len(matches) == 0 {
+				issues = append(issues, Issue{
+					Line:     in.Line,
+					Severity: SeverityError,
+					Rule:     "missing-source",
+					Message:  fmt.Sprintf("%s source %s does not exist in the build context", in.Command, source),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// pathArgs splits the arguments of COPY or ADD in shell or JSON form
+func pathArgs(args string) []string {
+	args = strings.TrimSpace(args)
+	if strings.HasPrefix(args, "[") && strings.HasSuffix(args, "]") {
+		var paths []string
+		for _, field := range strings.Split(args[1:len(args)-1], ",") {
+			paths = append(paths, strings.Trim(strings.TrimSpace(field), `"`))
+		}
+		return paths
+	}
+	return strings.Fields(args)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "git@")
+}
+
+func isWindowsPath(dir string) bool {
+	return len(dir) >= 2 && dir[1] == ':'
+}
@@ -0,0 +1,315 @@
+// Package coverage measures Go test coverage per function and turns the
+// least covered functions into prompts for writing tests.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Block is a block of statements in a cover profile
+type Block struct {
+	File       string
+	StartLine  int
+	EndLine    int
+	Statements int
+	Count      int
+}
+
+// Function is the coverage of one function
+type Function struct {
+	// Name is the function name, Receiver.Name for methods
+	Name       string
+	Package    string
+	File       string
+	Line       int
+	Statements int
+	Covered    int
+	// Uncovered lists the first line of each block never run
+	Uncovered []int
+}
+
+// Percent returns the covered share of the statements
+func (f Function) Percent() float64 {
+	if f.Statements == 0 {
+		return 100
+	}
+	return 100 * float64(f.Covered) / float64(f.Statements)
+}
+
+// Key identifies the function across profiles
+func (f Function) Key() string {
+	return f.File + ":" + f.Name
+}
+
+// Measure runs "go test -coverprofile" for the packages in dir and returns
+// the coverage of every function. Failing tests are an error, with their
+// output.
+func Measure(ctx context.Context, dir string, packages []string) ([]Function, error) {
+	profile, err := os.CreateTemp("", "coda-cover-*.out")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cover profile: %w", err)
+	}
+	profile.Close()
+	defer os.Remove(profile.Name())
+
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	args := append([]string{"test", "-coverprofile=" + profile.Name()}, packages...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go test failed: %w\n%s", err, strings.TrimSpace(output.String()))
+	}
+
+	file, err := os.Open(profile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cover profile: %w", err)
+	}
+	defer file.Close()
+	blocks, err := ParseProfile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Functions(blocks, dir)
+}
+
+// ParseProfile reads a cover profile written by "go test -coverprofile"
+func ParseProfile(r io.Reader) ([]Block, error) {
+	var blocks []Block
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if n == 1 && strings.HasPrefix(line, "mode:") || line == "" {
+			continue
+		}
+		// name.go:line.column,line.column statements count
+		file, rest, ok := strings.Cut(line, ":")
+		fields := strings.Fields(rest)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("invalid cover profile line %d: %q", n, line)
+		}
+		start, end, _ := strings.Cut(fields[0], ",")
+		block := Block{File: file}
+		var err error
+		if block.StartLine, err = lineOf(start); err == nil {
+			block.EndLine, err = lineOf(end)
+		}
+		if err == nil {
+			block.Statements, err = strconv.Atoi(fields[1])
+		}
+		if err == nil {
+			block.Count, err = strconv.Atoi(fields[2])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid cover profile line %d: %q", n, line)
+		}
+		blocks = append(blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cover profile: %w", err)
+	}
+	return blocks, nil
+}
+
+// lineOf returns the line of a "line.column" position
+func lineOf(position string) (int, error) {
+	line, _, _ := strings.Cut(position, ".")
+	return strconv.Atoi(line)
+}
+
+// Functions attributes the blocks of a profile to the functions declared in
+// the files under dir, the root of the module they belong to
+func Functions(blocks []Block, dir string) ([]Function, error) {
+	module, err := modulePath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byFile := make(map[string][]Block)
+	var files []string
+	for _, b := range blocks {
+		if _, ok := byFile[b.File]; !ok {
+			files = append(files, b.File)
+		}
+		byFile[b.File] = append(byFile[b.File], b)
+	}
+	sort.Strings(files)
+
+	var result []Function
+	for _, name := range files {
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, module), "/")
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filepath.Join(dir, filepath.FromSlash(rel)), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			f := Function{
+				Name:    funcName(fn),
+				Package: file.Name.Name,
+				File:    rel,
+				Line:    fset.Position(fn.Pos()).Line,
+			}
+			start, end := f.Line, fset.Position(fn.End()).Line
+			for _, b := range byFile[name] {
+				if b.StartLine < start || b.EndLine > end {
+					continue
+				}
+				f.Statements += b.Statements
+				if b.Count > 0 {
+					f.Covered += b.Statements
+				} else if b.Statements > 0 {
+					f.Uncovered = append(f.Uncovered, b.StartLine)
+				}
+			}
+			sort.Ints(f.Uncovered)
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// modulePath reads the module path from the go.mod in dir
+func modulePath(dir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module path in %s", filepath.Join(dir, "go.mod"))
+}
+
+// funcName returns Name, or Receiver.Name for methods
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	typ := fn.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+			continue
+		case *ast.IndexExpr:
+			typ = t.X
+			continue
+		case *ast.IndexListExpr:
+			typ = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		}
+		return fn.Name.Name
+	}
+}
+
+// Total returns the covered and total statements of the functions
+func Total(functions []Function) (covered, statements int) {
+	for _, f := range functions {
+		covered += f.Covered
+		statements += f.Statements
+	}
+	return covered, statements
+}
+
+// LowCoverage returns up to limit functions covered below threshold
+// percent, those with the most uncovered statements first. main and init
+// functions are left out as they are rarely tested directly.
+func LowCoverage(functions []Function, threshold float64, limit int) []Function {
+	var low []Function
+	for _, f := range functions {
+		if f.Statements == 0 || f.Percent() >= threshold || f.Name == "main" || f.Name == "init" {
+			continue
+		}
+		low = append(low, f)
+	}
+	sort.SliceStable(low, func(i, j int) bool {
+		return low[i].Statements-low[i].Covered > low[j].Statements-low[j].Covered
+	})
+	if limit > 0 && len(low) > limit {
+		low = low[:limit]
+	}
+	return low
+}
+
+// Prompt asks for table-driven tests covering the function
+func Prompt(f Function) string {
+	lines := make([]string, len(f.Uncovered))
+	for i, line := range f.Uncovered {
+		lines[i] = strconv.Itoa(line)
+	}
+	testFile := strings.TrimSuffix(f.File, ".go") + "_test.go"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write table-driven tests for %s in %s (line %d). ", f.Name, f.File, f.Line)
+	fmt.Fprintf(&b, "It is %.0f%% covered (%d of %d statements)", f.Percent(), f.Covered, f.Statements)
+	switch len(lines) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&b, "; the block starting on line %s never runs", lines[0])
+	default:
+		fmt.Fprintf(&b, "; the blocks starting on lines %s never run", strings.Join(lines, ", "))
+	}
+	b.WriteString(".\n\n")
+	fmt.Fprintf(&b, "1. Read %s and the existing tests of package %s to follow their style and helpers.\n", f.File, f.Package)
+	fmt.Fprintf(&b, "2. Add the tests to %s (create it if needed), with cases for the uncovered branches.\n", testFile)
+	fmt.Fprintf(&b, "3. Run `go test ./%s` and fix the tests until they pass. Do not change %s itself.\n", filepath.ToSlash(filepath.Dir(f.File)), f.File)
+	b.WriteString("Reply with a one-line summary of the cases you added.")
+	return b.String()
+}
+
+// Summary describes the coverage change of the targeted functions and of
+// the whole profile
+func Summary(before, after, targets []Function) string {
+	current := make(map[string]Function, len(after))
+	for _, f := range after {
+		current[f.Key()] = f
+	}
+
+	var b strings.Builder
+	b.WriteString("Coverage delta:\n")
+	for _, target := range targets {
+		now, ok := current[target.Key()]
+		if !ok {
+			fmt.Fprintf(&b, "  %-40s %5.1f%% -> (not found)\n", target.Name, target.Percent())
+			continue
+		}
+		fmt.Fprintf(&b, "  %-40s %5.1f%% -> %5.1f%%  (%s)\n", target.Name, target.Percent(), now.Percent(), target.File)
+	}
+	covered, statements := Total(before)
+	newCovered, newStatements := Total(after)
+	fmt.Fprintf(&b, "  %-40s %5.1f%% -> %5.1f%%\n", "total", percent(covered, statements), percent(newCovered, newStatements))
+	return b.String()
+}
+
+func percent(covered, statements int) float64 {
+	if statements == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(statements)
+}
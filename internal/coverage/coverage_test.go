@@ -0,0 +1,113 @@
+package coverage
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mathSource = `package mathx
+
+// Sign returns -1, 0 or 1
+func Sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	if n == 0 {
+		return 0
+	}
+	return 1
+}
+
+type Acc struct{ total int }
+
+func (a *Acc) Add(n int) {
+	a.total += n
+}
+`
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestFunctions(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"go.mod":         "module example.com/m\n\ngo 1.21\n",
+		"mathx/mathx.go": mathSource,
+	})
+	profile := `mode: set
+example.com/m/mathx/mathx.go:4.22,5.11 1 1
+example.com/m/mathx/mathx.go:5.11,7.3 1 1
+example.com/m/mathx/mathx.go:8.2,8.12 1 1
+example.com/m/mathx/mathx.go:8.12,10.3 1 0
+example.com/m/mathx/mathx.go:11.2,11.10 1 1
+example.com/m/mathx/mathx.go:16.26,18.2 1 0
+`
+	blocks, err := ParseProfile(strings.NewReader(profile))
+	require.NoError(t, err)
+	require.Len(t, blocks, 6)
+
+	functions, err := Functions(blocks, dir)
+	require.NoError(t, err)
+	require.Len(t, functions, 2)
+	assert.Equal(t, Function{Name: "Sign", Package: "mathx", File: "mathx/mathx.go", Line: 4, Statements: 5, Covered: 4, Uncovered: []int{8}}, functions[0])
+	assert.Equal(t, "Acc.Add", functions[1].Name)
+	assert.Equal(t, 0.0, functions[1].Percent())
+
+	low := LowCoverage(functions, 90, 5)
+	require.Len(t, low, 2)
+	assert.Equal(t, "Sign", low[0].Name, "most uncovered statements first")
+	assert.Len(t, LowCoverage(functions, 50, 5), 1)
+	assert.Len(t, LowCoverage(functions, 90, 1), 1)
+
+	prompt := Prompt(low[0])
+	assert.Contains(t, prompt, "Write table-driven tests for Sign in mathx/mathx.go (line 4). It is 80% covered (4 of 5 statements); the block starting on line 8 never runs.")
+	assert.Contains(t, prompt, "Add the tests to mathx/mathx_test.go")
+	assert.Contains(t, prompt, "Run `go test ./mathx`")
+
+	after := []Function{functions[0], functions[1]}
+	after[0].Covered = 5
+	assert.Equal(t, "Coverage delta:\n"+
+		"  Sign                                      80.0% -> 100.0%  (mathx/mathx.go)\n"+
+		"  total                                     66.7% ->  83.3%\n", Summary(functions, after, low[:1]))
+
+	_, err = ParseProfile(strings.NewReader("mode: set\nbroken line\n"))
+	assert.ErrorContains(t, err, "invalid cover profile line 2")
+}
+
+func TestMeasure(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	// The temporary module must not use the go flags of the environment
+	t.Setenv("GOFLAGS", "")
+
+	dir := writeModule(t, map[string]string{
+		"go.mod":              "module example.com/m\n\ngo 1.21\n",
+		"mathx/mathx.go":      mathSource,
+		"mathx/mathx_test.go": "package mathx\n\nimport \"testing\"\n\nfunc TestSign(t *testing.T) {\n\tif Sign(-3) != -1 {\n\t\tt.Fatal(\"sign\")\n\t}\n}\n",
+	})
+	functions, err := Measure(context.Background(), dir, nil)
+	require.NoError(t, err)
+	require.Len(t, functions, 2)
+	assert.Equal(t, "Sign", functions[0].Name)
+	assert.Equal(t, 2, functions[0].Covered)
+	assert.Equal(t, 0, functions[1].Covered)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mathx", "mathx_test.go"), []byte("package mathx\n\nimport \"testing\"\n\nfunc TestFail(t *testing.T) { t.Fatal(\"boom\") }\n"), 0644))
+	_, err = Measure(context.Background(), dir, []string{"./mathx"})
+	assert.ErrorContains(t, err, "boom")
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		query    string
+		readOnly bool
+	}{
+		{"SELECT * FROM users", true},
+		{"  select id from users where name = 'x';", true},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", true},
+		{"EXPLAIN SELECT 1", true},
+		{"SHOW TABLES", true},
+		{"DESCRIBE users", true},
+		{"PRAGMA table_info(users)", true},
+		{"SELECT 'DROP TABLE users' AS s", true},
+		{`SELECT "update" FROM t -- DELETE`, true},
+		{"SELECT 1 /* INSERT */", true},
+		{"SELECT 'it''s'", true},
+		{"DELETE FROM users", false},
+		{"INSERT INTO users VALUES (1)", false},
+		{"WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", false},
+		{"SELECT * INTO backup FROM users", false},
+		{"SELECT * FROM users FOR UPDATE", false},
+		{"EXPLAIN ANALYZE DELETE FROM users", false},
+		{"PRAGMA journal_mode = WAL", false},
+		{"SELECT nextval('ids')", false},
+		{"SELECT 1; DROP TABLE users", false},
+		{"SELECT 'a\\'; DROP TABLE users; --'", false},
+		{"SELECT 'unterminated", false},
+		{"SELECT 1 /* unterminated", false},
+		{"SELECT 1 \\! rm -rf /", false},
+		{"VACUUM", false},
+		{"", false},
+		{"-- only a comment", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.readOnly, ReadOnly(tt.query))
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	assert.Equal(t, 1, Statements("SELECT 1;"))
+	assert.Equal(t, 1, Statements("SELECT ';'"))
+	assert.Equal(t, 2, Statements("SELECT 1; SELECT 2"))
+	assert.Equal(t, 0, Statements(" ; -- nothing"))
+}
+
+func TestClientCommand(t *testing.T) {
+	assert.True(t, ClientCommand(".tables"))
+	assert.True(t, ClientCommand("\\dt"))
+	assert.True(t, ClientCommand("SELECT 1 \\g"))
+	assert.False(t, ClientCommand("SELECT '\\n'"))
+}
+
+func TestDriver(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		driver Driver
+	}{
+		{"postgres://localhost/app", Postgres},
+		{"postgresql://localhost/app", Postgres},
+		{"mysql://root@localhost/app", MySQL},
+		{"sqlite:data/dev.db", SQLite},
+		{"sqlite:///tmp/dev.db", SQLite},
+	}
+	for _, tt := range tests {
+		driver, err := Database{Name: "db", DSN: tt.dsn}.Driver()
+		require.NoError(t, err)
+		assert.Equal(t, tt.driver, driver, tt.dsn)
+	}
+
+	_, err := Database{Name: "db", DSN: "oracle://x"}.Driver()
+	assert.EqualError(t, err, `database db: unsupported DSN scheme "oracle"`)
+	_, err = Database{Name: "db", DSN: "app.db"}.Driver()
+	assert.Error(t, err)
+
+	assert.Equal(t, "data/dev.db", sqlitePath("sqlite:data/dev.db"))
+	assert.Equal(t, "data/dev.db", sqlitePath("sqlite://data/dev.db"))
+	assert.Equal(t, "/tmp/dev.db", sqlitePath("sqlite:///tmp/dev.db"))
+}
+
+func TestParse(t *testing.T) {
+	result, err := parseCSV([]byte("id,name\r\n1,\"a,b\"\r\n2,\"multi\nline\"\r\n"), false)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Columns: []string{"id", "name"}, Rows: [][]string{{"1", "a,b"}, {"2", "multi\nline"}}}, result)
+
+	result, err = parseCSV([]byte("id\n1\n2\n3"), true)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Columns: []string{"id"}, Rows: [][]string{{"1"}, {"2"}}, Truncated: true}, result)
+
+	result, err = parseCSV(nil, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Columns)
+
+	result = parseTabs([]byte("id\tnote\n1\ttab\\there\n2\tNULL\n"), false)
+	assert.Equal(t, &Result{Columns: []string{"id", "note"}, Rows: [][]string{{"1", "tab\there"}, {"2", "NULL"}}}, result)
+}
+
+func TestMarkdown(t *testing.T) {
+	result := &Result{Columns: []string{"id", "note"}}
+	for i := 0; i < 100; i++ {
+		result.Rows = append(result.Rows, []string{"1", "a | b\nc"})
+	}
+
+	table, shown := result.Markdown(100000)
+	assert.Equal(t, 100, shown)
+	assert.True(t, strings.HasPrefix(table, "| id | note |\n| --- | --- |\n| 1 | a \\| b c |\n"))
+
+	table, shown = result.Markdown(200)
+	assert.Less(t, shown, 100)
+	assert.Greater(t, shown, 0)
+	assert.Equal(t, shown+2, strings.Count(table, "\n"))
+
+	table, shown = (&Result{Columns: []string{"v"}, Rows: [][]string{{strings.Repeat("x", 300)}}}).Markdown(1000)
+	assert.Equal(t, 1, shown)
+	assert.Contains(t, table, strings.Repeat("x", maxCellRunes)+"…")
+
+	table, shown = (&Result{}).Markdown(1000)
+	assert.Empty(t, table)
+	assert.Zero(t, shown)
+}
+
+func TestQuerySQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	dir := t.TempDir()
+	client := NewClient()
+	require.True(t, client.Available(SQLite))
+	ctx := context.Background()
+	db := Database{Name: "dev", DSN: "sqlite:dev.db", AllowWrites: true}
+
+	result, err := client.Query(ctx, db, dir, "CREATE TABLE users (id INTEGER, name TEXT)", true)
+	require.NoError(t, err)
+	assert.Empty(t, result.Columns)
+	_, err = client.Query(ctx, db, dir, "INSERT INTO users VALUES (1, 'ada'), (2, NULL)", true)
+	require.NoError(t, err)
+
+	result, err = client.Query(ctx, db, dir, "SELECT id, name FROM users ORDER BY id", false)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Columns: []string{"id", "name"}, Rows: [][]string{{"1", "ada"}, {"2", ""}}}, result)
+
+	// Read-only sessions refuse writes the classification missed
+	_, err = client.Query(ctx, db, dir, "DELETE FROM users", false)
+	assert.ErrorContains(t, err, "readonly")
+
+	_, err = client.Query(ctx, db, dir, ".tables", false)
+	assert.EqualError(t, err, "client commands are not supported, only SQL")
+
+	_, err = client.Query(ctx, Database{Name: "abs", DSN: "sqlite://" + filepath.Join(dir, "dev.db")}, t.TempDir(), "SELECT count(*) AS n FROM users", false)
+	require.NoError(t, err)
+}
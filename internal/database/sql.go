@@ -0,0 +1,171 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// readStatements start statements that only read
+var readStatements = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"VALUES":   true,
+	"TABLE":    true,
+	"EXPLAIN":  true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"PRAGMA":   true,
+}
+
+// writeKeywords change data or schema wherever they appear, e.g. in a
+// data-modifying WITH, SELECT ... INTO, SELECT ... FOR UPDATE or
+// EXPLAIN ANALYZE DELETE
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"UPSERT":   true,
+	"CREATE":   true,
+	"DROP":     true,
+	"ALTER":    true,
+	"TRUNCATE": true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"INTO":     true,
+	"COPY":     true,
+	"CALL":     true,
+	"EXEC":     true,
+	"EXECUTE":  true,
+	"LOCK":     true,
+	"ATTACH":   true,
+	"DETACH":   true,
+	"VACUUM":   true,
+	"REINDEX":  true,
+	"NEXTVAL":  true,
+	"SETVAL":   true,
+}
+
+// statement is a statement of a query with its literals and comments
+// removed: its upper-cased words and its "=" signs
+type statement []string
+
+// scan splits query into statements. Backslashes outside literals are
+// reported, since the database clients read them as client commands.
+// Backslashes never escape quotes here and dollar quoting is not
+// recognized, so a literal can only hide text from the classification when
+// the database would also read it as a literal.
+func scan(query string) (statements []statement, backslash, unterminated bool) {
+	runes := []rune(query)
+	var current statement
+	var word strings.Builder
+	endWord := func() {
+		if word.Len() > 0 {
+			current = append(current, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+	endStatement := func() {
+		endWord()
+		if len(current) > 0 {
+			statements = append(statements, current)
+		}
+		current = nil
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			endWord()
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			endWord()
+			closed := false
+			for i += 2; i+1 < len(runes); i++ {
+				if runes[i] == '*' && runes[i+1] == '/' {
+					i++
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				return statements, backslash, true
+			}
+		case r == '\'' || r == '"' || r == '`':
+			endWord()
+			// Doubled quotes stay inside the literal
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						i++
+						continue
+					}
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				return statements, backslash, true
+			}
+		case r == ';':
+			endStatement()
+		case r == '\\':
+			endWord()
+			backslash = true
+		case r == '=':
+			endWord()
+			current = append(current, "=")
+		case r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			endWord()
+		}
+	}
+	endStatement()
+	return statements, backslash, false
+}
+
+// Statements returns the number of statements in query
+func Statements(query string) int {
+	statements, _, _ := scan(query)
+	return len(statements)
+}
+
+// ReadOnly reports whether query only reads: statements starting with
+// SELECT, WITH, VALUES, TABLE, EXPLAIN, SHOW, DESCRIBE or PRAGMA that hold
+// no keyword changing data or schema, and no PRAGMA assignment. Queries it
+// cannot tell, such as unterminated literals or client commands, count as
+// writes.
+func ReadOnly(query string) bool {
+	statements, backslash, unterminated := scan(query)
+	if backslash || unterminated || len(statements) == 0 {
+		return false
+	}
+	for _, s := range statements {
+		if !readStatements[s[0]] {
+			return false
+		}
+		for _, word := range s {
+			if writeKeywords[word] || (word == "=" && s[0] == "PRAGMA") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ClientCommand reports whether query holds commands for the database
+// client rather than the server, such as psql and mysql backslash commands
+// or sqlite3 dot-commands
+func ClientCommand(query string) bool {
+	if strings.HasPrefix(strings.TrimSpace(query), ".") {
+		return true
+	}
+	_, backslash, _ := scan(query)
+	return backslash
+}
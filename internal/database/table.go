@@ -0,0 +1,51 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/common-creation/coda/internal/tokenizer"
+)
+
+// maxCellRunes bounds each value in a table
+const maxCellRunes = 200
+
+// Markdown renders the result as a markdown table within about maxTokens
+// tokens. It returns the table and the number of rows it holds; rows past
+// the budget are left out.
+func (r *Result) Markdown(maxTokens int) (string, int) {
+	if len(r.Columns) == 0 {
+		return "", 0
+	}
+
+	var b strings.Builder
+	writeRow(&b, r.Columns)
+	b.WriteString("|" + strings.Repeat(" --- |", len(r.Columns)) + "\n")
+	tokens := tokenizer.ApproximateTokens(b.String())
+
+	shown := 0
+	for _, row := range r.Rows {
+		var line strings.Builder
+		writeRow(&line, row)
+		tokens += tokenizer.ApproximateTokens(line.String())
+		if tokens > maxTokens {
+			break
+		}
+		b.WriteString(line.String())
+		shown++
+	}
+	return b.String(), shown
+}
+
+// writeRow writes a table row, escaping pipes and folding newlines
+func writeRow(b *strings.Builder, values []string) {
+	b.WriteString("|")
+	for _, value := range values {
+		if runes := []rune(value); len(runes) > maxCellRunes {
+			value = string(runes[:maxCellRunes]) + "…"
+		}
+		value = strings.ReplaceAll(value, "|", `\|`)
+		value = strings.Join(strings.Fields(strings.ReplaceAll(value, "\n", " ")), " ")
+		b.WriteString(" " + value + " |")
+	}
+	b.WriteString("\n")
+}
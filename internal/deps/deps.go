@@ -0,0 +1,240 @@
+// Package deps lists outdated Go module dependencies and upgrades them one
+// at a time, keeping an upgrade only when the build and tests still pass.
+package deps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// outputLimit is how much of the end of a failing gate's output is kept
+const outputLimit = 4 * 1024
+
+// Module is a required module and its newest available version
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Update is the newest version, empty when Version is the newest
+	Update   string `json:"update,omitempty"`
+	Indirect bool   `json:"indirect,omitempty"`
+}
+
+// Runner runs a command in dir and returns its combined output
+type Runner func(ctx context.Context, dir string, name string, args ...string) (string, error)
+
+// execRunner runs commands with os/exec
+func execRunner(ctx context.Context, dir string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return output.String(), err
+}
+
+// Gate is a command that must pass for an upgrade to be kept
+type Gate struct {
+	Name string
+	Args []string
+}
+
+// DefaultGates build the module and run its tests
+func DefaultGates() []Gate {
+	return []Gate{
+		{Name: "go build ./...", Args: []string{"go", "build", "./..."}},
+		{Name: "go test ./...", Args: []string{"go", "test", "./..."}},
+	}
+}
+
+// ParseGate turns a command line into a gate
+func ParseGate(command string) (Gate, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return Gate{}, fmt.Errorf("empty gate command")
+	}
+	return Gate{Name: strings.Join(args, " "), Args: args}, nil
+}
+
+// Project is a Go module whose dependencies are managed
+type Project struct {
+	dir   string
+	run   Runner
+	gates []Gate
+}
+
+// Option configures a Project
+type Option func(*Project)
+
+// WithRunner runs commands with run instead of os/exec
+func WithRunner(run Runner) Option {
+	return func(p *Project) {
+		p.run = run
+	}
+}
+
+// WithGates replaces the default build and test gates
+func WithGates(gates ...Gate) Option {
+	return func(p *Project) {
+		p.gates = gates
+	}
+}
+
+// NewProject creates a Project for the module in dir
+func NewProject(dir string, opts ...Option) *Project {
+	p := &Project{dir: dir, run: execRunner, gates: DefaultGates()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// IsGoModule reports whether dir holds a go.mod
+func IsGoModule(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
+// Outdated returns the required modules that have a newer version. Indirect
+// requirements are left out unless includeIndirect is set.
+func (p *Project) Outdated(ctx context.Context, includeIndirect bool) ([]Module, error) {
+	output, err := p.run(ctx, p.dir, "go", "list", "-m", "-u", "-json", "all")
+	if err != nil {
+		return nil, fmt.Errorf("go list -m -u failed: %w\n%s", err, strings.TrimSpace(output))
+	}
+	modules, err := ParseModules(strings.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+	var outdated []Module
+	for _, m := range modules {
+		if m.Update == "" || m.Indirect && !includeIndirect {
+			continue
+		}
+		outdated = append(outdated, m)
+	}
+	return outdated, nil
+}
+
+// listedModule is the part of "go list -m -json" output that is used
+type listedModule struct {
+	Path     string
+	Version  string
+	Main     bool
+	Indirect bool
+	Update   *struct {
+		Version string
+	}
+}
+
+// ParseModules reads the stream of JSON objects printed by
+// "go list -m -json", leaving out the main module
+func ParseModules(r io.Reader) ([]Module, error) {
+	var modules []Module
+	decoder := json.NewDecoder(r)
+	for {
+		var m listedModule
+		err := decoder.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			return modules, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if m.Main {
+			continue
+		}
+		module := Module{Path: m.Path, Version: m.Version, Indirect: m.Indirect}
+		if m.Update != nil {
+			module.Update = m.Update.Version
+		}
+		modules = append(modules, module)
+	}
+}
+
+// Change is a module version change
+type Change struct {
+	Path string `json:"path"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Bump sets the required version of a module ("latest" when version is
+// empty) and tidies go.mod
+func (p *Project) Bump(ctx context.Context, path, version string) (Change, error) {
+	if version == "" {
+		version = "latest"
+	}
+	change := Change{Path: path}
+	change.From, _ = p.version(ctx, path)
+
+	if output, err := p.run(ctx, p.dir, "go", "get", path+"@"+version); err != nil {
+		return change, fmt.Errorf("go get %s@%s failed: %s", path, version, lastLines(output, err))
+	}
+	if output, err := p.run(ctx, p.dir, "go", "mod", "tidy"); err != nil {
+		return change, fmt.Errorf("go mod tidy failed: %s", lastLines(output, err))
+	}
+	to, err := p.version(ctx, path)
+	if err != nil {
+		return change, err
+	}
+	change.To = to
+	return change, nil
+}
+
+// version returns the required version of a module
+func (p *Project) version(ctx context.Context, path string) (string, error) {
+	output, err := p.run(ctx, p.dir, "go", "list", "-m", "-json", path)
+	if err != nil {
+		return "", fmt.Errorf("go list -m %s failed: %s", path, lastLines(output, err))
+	}
+	modules, err := ParseModules(strings.NewReader(output))
+	if err != nil || len(modules) == 0 {
+		return "", fmt.Errorf("module %s is not required", path)
+	}
+	return modules[0].Version, nil
+}
+
+// CheckResult is the outcome of running the gates
+type CheckResult struct {
+	Passed bool `json:"passed"`
+	// Failed is the first gate that failed and Output the end of its output
+	Failed string `json:"failed,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
+// Check runs the gates in order and stops at the first that fails
+func (p *Project) Check(ctx context.Context) CheckResult {
+	for _, gate := range p.gates {
+		output, err := p.run(ctx, p.dir, gate.Args[0], gate.Args[1:]...)
+		if err != nil {
+			return CheckResult{Failed: gate.Name, Output: tail(lastLines(output, err), outputLimit)}
+		}
+	}
+	return CheckResult{Passed: true}
+}
+
+// lastLines returns the trimmed output of a command, or its error when it
+// printed nothing
+func lastLines(output string, err error) string {
+	if output = strings.TrimSpace(output); output != "" {
+		return output
+	}
+	return err.Error()
+}
+
+// tail keeps the last limit bytes of s
+func tail(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return "..." + s[len(s)-limit:]
+}
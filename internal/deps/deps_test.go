@@ -0,0 +1,142 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listOutput = `{
+	"Path": "example.com/app",
+	"Main": true
+}
+{
+	"Path": "github.com/a/lib",
+	"Version": "v1.2.0",
+	"Update": {"Path": "github.com/a/lib", "Version": "v1.4.1"}
+}
+{
+	"Path": "github.com/b/current",
+	"Version": "v0.3.0"
+}
+{
+	"Path": "golang.org/x/text",
+	"Version": "v0.3.0",
+	"Indirect": true,
+	"Update": {"Path": "golang.org/x/text", "Version": "v0.14.0"}
+}
+`
+
+func TestOutdated(t *testing.T) {
+	run := func(ctx context.Context, dir, name string, args ...string) (string, error) {
+		assert.Equal(t, "go list -m -u -json all", name+" "+strings.Join(args, " "))
+		return listOutput, nil
+	}
+	project := NewProject(t.TempDir(), WithRunner(run))
+
+	direct, err := project.Outdated(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []Module{{Path: "github.com/a/lib", Version: "v1.2.0", Update: "v1.4.1"}}, direct)
+
+	all, err := project.Outdated(context.Background(), true)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "golang.org/x/text", all[1].Path)
+
+	_, err = ParseModules(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+// fakeGo simulates the go command on a go.mod listing "path version" lines
+type fakeGo struct {
+	// broken makes the tests fail while the module is at the version
+	broken map[string]string
+}
+
+func (f *fakeGo) run(ctx context.Context, dir, name string, args ...string) (string, error) {
+	command := name + " " + strings.Join(args, " ")
+	data, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+	versions := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if path, version, ok := strings.Cut(line, " "); ok {
+			versions[path] = version
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(command, "go list -m -json "):
+		path := args[len(args)-1]
+		return fmt.Sprintf(`{"Path": %q, "Version": %q}`, path, versions[path]), nil
+	case strings.HasPrefix(command, "go get "):
+		path, version, _ := strings.Cut(args[1], "@")
+		if version == "v9.9.9" {
+			return "go: no matching versions", errors.New("exit status 1")
+		}
+		updated := strings.Replace(string(data), path+" "+versions[path], path+" "+version, 1)
+		return "", os.WriteFile(filepath.Join(dir, "go.mod"), []byte(updated), 0644)
+	case command == "go test ./...":
+		for path, version := range f.broken {
+			if versions[path] == version {
+				return "--- FAIL: TestThing\nundefined: lib.Old", errors.New("exit status 1")
+			}
+		}
+	}
+	return "", nil
+}
+
+func TestUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	goMod := "github.com/a/lib v1.2.0\ngithub.com/b/tool v0.1.0\ngithub.com/c/gone v1.0.0\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644))
+	fake := &fakeGo{broken: map[string]string{"github.com/b/tool": "v0.2.0"}}
+	project := NewProject(dir, WithRunner(fake.run))
+
+	var started []string
+	results, err := project.Upgrade(context.Background(), []Module{
+		{Path: "github.com/a/lib", Version: "v1.2.0", Update: "v1.4.1"},
+		{Path: "github.com/b/tool", Version: "v0.1.0", Update: "v0.2.0"},
+		{Path: "github.com/c/gone", Version: "v1.0.0", Update: "v9.9.9"},
+	}, func(m Module, r *Result) {
+		if r == nil {
+			started = append(started, m.Path)
+		}
+	})
+	require.NoError(t, err)
+	assert.Len(t, started, 3)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, StatusUpgraded, results[0].Status)
+	assert.Equal(t, Change{Path: "github.com/a/lib", From: "v1.2.0", To: "v1.4.1"}, results[0].Change)
+	assert.Equal(t, StatusReverted, results[1].Status)
+	assert.Equal(t, "go test ./... failed", results[1].Reason)
+	assert.Contains(t, results[1].Output, "undefined: lib.Old")
+	assert.Equal(t, StatusFailed, results[2].Status)
+	assert.Contains(t, results[2].Reason, "no matching versions")
+
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/a/lib v1.4.1\ngithub.com/b/tool v0.1.0\ngithub.com/c/gone v1.0.0\n", string(data), "only the passing upgrade is kept")
+
+	assert.Equal(t, `Dependency upgrades:
+  upgraded  github.com/a/lib v1.2.0 -> v1.4.1
+  reverted  github.com/b/tool v0.1.0 -> v0.2.0 (go test ./... failed)
+  failed    github.com/c/gone v1.0.0 -> v9.9.9 (go get github.com/c/gone@v9.9.9 failed: go: no matching versions)
+1 upgraded, 1 reverted, 1 failed
+`, Summary(results))
+}
+
+func TestParseGate(t *testing.T) {
+	gate, err := ParseGate("  go test  -short ./... ")
+	require.NoError(t, err)
+	assert.Equal(t, Gate{Name: "go test -short ./...", Args: []string{"go", "test", "-short", "./..."}}, gate)
+
+	_, err = ParseGate(" ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Status is the outcome of upgrading one module
+type Status string
+
+const (
+	StatusUpgraded Status = "upgraded"
+	// StatusReverted is an upgrade undone because a gate failed
+	StatusReverted Status = "reverted"
+	// StatusFailed is an upgrade that could not be applied
+	StatusFailed Status = "failed"
+)
+
+// Result is the outcome of upgrading one module
+type Result struct {
+	Change
+	Status Status `json:"status"`
+	// Reason explains a reverted or failed upgrade
+	Reason string `json:"reason,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
+// modFiles are restored when an upgrade is reverted
+var modFiles = []string{"go.mod", "go.sum"}
+
+// Upgrade upgrades the modules one at a time to their Update version. After
+// each upgrade the gates run; when one fails, go.mod and go.sum are restored
+// and the next module is tried. observe, when set, is called before each
+// module and with each result.
+func (p *Project) Upgrade(ctx context.Context, modules []Module, observe func(Module, *Result)) ([]Result, error) {
+	results := make([]Result, 0, len(modules))
+	for _, m := range modules {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if observe != nil {
+			observe(m, nil)
+		}
+		result := p.upgradeOne(ctx, m)
+		results = append(results, result)
+		if observe != nil {
+			observe(m, &result)
+		}
+	}
+	return results, nil
+}
+
+func (p *Project) upgradeOne(ctx context.Context, m Module) Result {
+	saved, err := p.saveModFiles()
+	if err != nil {
+		return Result{Change: Change{Path: m.Path, From: m.Version, To: m.Update}, Status: StatusFailed, Reason: err.Error()}
+	}
+
+	change, err := p.Bump(ctx, m.Path, m.Update)
+	if change.From == "" {
+		change.From = m.Version
+	}
+	if err != nil {
+		p.restoreModFiles(saved)
+		change.To = m.Update
+		return Result{Change: change, Status: StatusFailed, Reason: err.Error()}
+	}
+
+	check := p.Check(ctx)
+	if !check.Passed {
+		if err := p.restoreModFiles(saved); err != nil {
+			return Result{Change: change, Status: StatusFailed, Reason: err.Error(), Output: check.Output}
+		}
+		return Result{Change: change, Status: StatusReverted, Reason: check.Failed + " failed", Output: check.Output}
+	}
+	return Result{Change: change, Status: StatusUpgraded}
+}
+
+// saveModFiles reads go.mod and go.sum; a missing go.sum is recorded as nil
+func (p *Project) saveModFiles() (map[string][]byte, error) {
+	saved := make(map[string][]byte, len(modFiles))
+	for _, name := range modFiles {
+		data, err := os.ReadFile(filepath.Join(p.dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		saved[name] = data
+	}
+	return saved, nil
+}
+
+// restoreModFiles writes back the saved go.mod and go.sum
+func (p *Project) restoreModFiles(saved map[string][]byte) error {
+	for _, name := range modFiles {
+		path := filepath.Join(p.dir, name)
+		if saved[name] == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, saved[name], 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Summary describes the results of an upgrade run
+func Summary(results []Result) string {
+	var b strings.Builder
+	counts := make(map[Status]int)
+	b.WriteString("Dependency upgrades:\n")
+	for _, r := range results {
+		counts[r.Status]++
+		fmt.Fprintf(&b, "  %-9s %s %s -> %s", r.Status, r.Path, r.From, r.To)
+		if r.Reason != "" {
+			fmt.Fprintf(&b, " (%s)", firstLine(r.Reason))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%d upgraded, %d reverted, %d failed\n", counts[StatusUpgraded], counts[StatusReverted], counts[StatusFailed])
+	return b.String()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
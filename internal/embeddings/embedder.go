@@ -0,0 +1,158 @@
+// Package embeddings indexes workspace files as embedded chunks for semantic
+// search, using the AI provider's embedding model or a local hashing model.
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related the texts are
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// DefaultModel is the provider embedding model used when none is configured
+const DefaultModel = "text-embedding-3-small"
+
+// ProviderEmbedder embeds texts with the AI provider's embedding model
+type ProviderEmbedder struct {
+	client ai.Embedder
+	model  string
+}
+
+// NewProviderEmbedder creates an embedder using model (DefaultModel when empty)
+func NewProviderEmbedder(client ai.Embedder, model string) *ProviderEmbedder {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &ProviderEmbedder{client: client, model: model}
+}
+
+// Embed implements Embedder
+func (p *ProviderEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := p.client.Embed(ctx, p.model, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed with %s: %w", p.model, err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding model %s returned %d vectors for %d texts", p.model, len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// localDimensions is the size of the vectors of LocalEmbedder
+const localDimensions = 1024
+
+// LocalEmbedder embeds texts offline by hashing their words and identifier
+// parts into a fixed-size vector. It finds chunks sharing vocabulary with the
+// query rather than meaning, but needs no provider.
+type LocalEmbedder struct{}
+
+// NewLocalEmbedder creates a local embedder
+func NewLocalEmbedder() *LocalEmbedder {
+	return &LocalEmbedder{}
+}
+
+// Embed implements Embedder
+func (LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		counts := make(map[string]int)
+		for _, term := range terms(text) {
+			counts[term]++
+		}
+		vector := make([]float32, localDimensions)
+		for term, count := range counts {
+			h := fnv.New32a()
+			h.Write([]byte(term))
+			sum := h.Sum32()
+			weight := float32(1 + math.Log(float64(count)))
+			if sum&0x80000000 != 0 {
+				weight = -weight // Signed hashing keeps collisions from adding up
+			}
+			vector[sum%localDimensions] += weight
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// terms splits text into lower-case words; identifiers also yield their
+// camelCase and snake_case parts
+func terms(text string) []string {
+	var result []string
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 && len(word) > 2 {
+			result = append(result, strings.ToLower(word))
+		}
+		for _, part := range parts {
+			if len(part) > 1 {
+				result = append(result, strings.ToLower(part))
+			}
+		}
+	}
+	return result
+}
+
+// splitIdentifier splits camelCase and snake_case identifiers into words
+func splitIdentifier(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		switch {
+		case i == len(runes):
+		case runes[i] == '_':
+		case unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+		default:
+			continue
+		}
+		if part := strings.Trim(string(runes[start:i]), "_"); part != "" {
+			parts = append(parts, part)
+		}
+		start = i
+	}
+	return parts
+}
+
+// normalize scales vector to unit length so dot products are cosine similarities
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(sum))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
+
+// dot returns the dot product of two vectors of the same model
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,66 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"retry", []string{"retry"}},
+		{"retryPolicy", []string{"retry", "Policy"}},
+		{"HTTPClient", []string{"HTTP", "Client"}},
+		{"max_retries", []string{"max", "retries"}},
+		{"_private", []string{"private"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, splitIdentifier(tt.word), tt.word)
+	}
+}
+
+func TestLocalEmbedder(t *testing.T) {
+	vectors, err := NewLocalEmbedder().Embed(context.Background(), []string{
+		"how are retries configured",
+		"func NewRetryPolicy(maxRetries int) *RetryPolicy",
+		"func renderMarkdown(text string) string",
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+
+	query, retry, render := normalize(vectors[0]), normalize(vectors[1]), normalize(vectors[2])
+	assert.Greater(t, dot(query, retry), dot(query, render))
+	assert.InDelta(t, 1, dot(retry, retry), 1e-5)
+}
+
+type fakeClient struct {
+	model   string
+	vectors [][]float32
+	err     error
+}
+
+func (f *fakeClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	f.model = model
+	return f.vectors, f.err
+}
+
+func TestProviderEmbedder(t *testing.T) {
+	client := &fakeClient{vectors: [][]float32{{1, 0}}}
+	vectors, err := NewProviderEmbedder(client, "").Embed(context.Background(), []string{"text"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultModel, client.model)
+	assert.Equal(t, [][]float32{{1, 0}}, vectors)
+
+	_, err = NewProviderEmbedder(client, "custom").Embed(context.Background(), []string{"a", "b"})
+	assert.ErrorContains(t, err, "returned 1 vectors for 2 texts")
+
+	client.err = errors.New("quota exceeded")
+	_, err = NewProviderEmbedder(client, "custom").Embed(context.Background(), []string{"a"})
+	assert.ErrorContains(t, err, "failed to embed with custom: quota exceeded")
+}
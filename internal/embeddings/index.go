@@ -0,0 +1,323 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/common-creation/coda/internal/watch"
+)
+
+const (
+	// DefaultChunkLines is the number of lines in a chunk
+	DefaultChunkLines = 40
+
+	// chunkOverlap is the number of lines shared by consecutive chunks, so
+	// code spanning a chunk boundary is still found whole
+	chunkOverlap = 10
+
+	// maxChunkBytes bounds the text embedded for one chunk
+	maxChunkBytes = 4000
+
+	// maxIndexedFileSize skips generated and data files
+	maxIndexedFileSize = 512 * 1024
+
+	// embedBatchSize is the number of chunks embedded per request
+	embedBatchSize = 64
+)
+
+// skipDirs are never indexed
+var skipDirs = map[string]bool{
+	".git":         true,
+	"vendor":       true,
+	"node_modules": true,
+	"testdata":     true,
+}
+
+// Chunk is a range of lines of a file
+type Chunk struct {
+	Path      string // Slash-separated, relative to the index root
+	StartLine int
+	EndLine   int
+	Title     string // Heading of the Markdown section, if any
+	Text      string
+}
+
+// Result is a chunk found by Search
+type Result struct {
+	Chunk
+	Score float64 // Cosine similarity to the query
+}
+
+type indexedFile struct {
+	modTime time.Time
+	size    int64
+	chunks  []Chunk
+	vectors [][]float32
+}
+
+// Option configures an Index
+type Option func(*Index)
+
+// WithPatterns indexes only files matching one of the globs (see
+// watch.MatchGlob); by default every text file is indexed
+func WithPatterns(patterns ...string) Option {
+	return func(idx *Index) {
+		idx.patterns = patterns
+	}
+}
+
+// WithChunkLines sets the number of lines in a chunk
+func WithChunkLines(lines int) Option {
+	return func(idx *Index) {
+		if lines > chunkOverlap {
+			idx.chunkLines = lines
+		}
+	}
+}
+
+// Index holds the embedded chunks of the text files under a root. It is
+// built lazily and refreshed incrementally: Update re-embeds only the files
+// whose modification time or size changed.
+type Index struct {
+	root       string
+	embedder   Embedder
+	patterns   []string
+	chunkLines int
+
+	mu    sync.Mutex // Serializes updates and guards files
+	files map[string]*indexedFile
+}
+
+// NewIndex creates an empty index of the files under root
+func NewIndex(root string, embedder Embedder, opts ...Option) *Index {
+	idx := &Index{
+		root:       root,
+		embedder:   embedder,
+		chunkLines: DefaultChunkLines,
+		files:      make(map[string]*indexedFile),
+	}
+	for _, opt := range opts {
+		opt(idx)
+	}
+	return idx
+}
+
+// Update brings the index up to date with the files under the root
+func (idx *Index) Update(ctx context.Context) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var changed []string
+	var states []fs.FileInfo
+	err := filepath.WalkDir(idx.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != idx.root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(idx.root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !idx.matches(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxIndexedFileSize {
+			return nil
+		}
+
+		seen[rel] = true
+		if file, ok := idx.files[rel]; !ok || !file.modTime.Equal(info.ModTime()) || file.size != info.Size() {
+			changed = append(changed, rel)
+			states = append(states, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index %s: %w", idx.root, err)
+	}
+
+	for rel := range idx.files {
+		if !seen[rel] {
+			delete(idx.files, rel)
+		}
+	}
+
+	for i, rel := range changed {
+		content, err := os.ReadFile(filepath.Join(idx.root, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		file := &indexedFile{modTime: states[i].ModTime(), size: states[i].Size()}
+		switch {
+		case isBinary(content):
+		case strings.HasSuffix(strings.ToLower(rel), ".md"):
+			file.chunks = ChunkMarkdown(rel, string(content), idx.chunkLines)
+		default:
+			file.chunks = ChunkText(rel, string(content), idx.chunkLines)
+		}
+		if err := idx.embedChunks(ctx, file); err != nil {
+			return err
+		}
+		idx.files[rel] = file
+	}
+	return nil
+}
+
+// embedChunks embeds the chunks of file
+func (idx *Index) embedChunks(ctx context.Context, file *indexedFile) error {
+	for start := 0; start < len(file.chunks); start += embedBatchSize {
+		batch := file.chunks[start:min(start+embedBatchSize, len(file.chunks))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = strings.TrimSpace(chunk.Path + " " + chunk.Title + "\n" + chunk.Text)
+		}
+		vectors, err := idx.embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed %s: %w", batch[0].Path, err)
+		}
+		for _, vector := range vectors {
+			file.vectors = append(file.vectors, normalize(vector))
+		}
+	}
+	return nil
+}
+
+// matches reports whether the index includes the file rel
+func (idx *Index) matches(rel string) bool {
+	if len(idx.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range idx.patterns {
+		if watch.MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the limit chunks most similar to query. With a prefix only
+// files under that slash-separated directory are searched.
+func (idx *Index) Search(ctx context.Context, query, prefix string, limit int) ([]Result, error) {
+	vectors, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	queryVector := normalize(vectors[0])
+	prefix = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(prefix)), "/")
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var results []Result
+	for rel, file := range idx.files {
+		if prefix != "" && prefix != "." && rel != prefix && !strings.HasPrefix(rel, prefix+"/") {
+			continue
+		}
+		for i, chunk := range file.chunks {
+			results = append(results, Result{Chunk: chunk, Score: dot(queryVector, file.vectors[i])})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Len returns the number of indexed chunks
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	n := 0
+	for _, file := range idx.files {
+		n += len(file.chunks)
+	}
+	return n
+}
+
+// ChunkText splits content into overlapping chunks of lines; blank chunks
+// are dropped
+func ChunkText(path, content string, lines int) []Chunk {
+	all := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	step := max(lines-chunkOverlap, 1)
+
+	var chunks []Chunk
+	for start := 0; start < len(all); start += step {
+		end := min(start+lines, len(all))
+		text := strings.Join(all[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			if len(text) > maxChunkBytes {
+				text = strings.ToValidUTF8(text[:maxChunkBytes], "")
+			}
+			chunks = append(chunks, Chunk{Path: path, StartLine: start + 1, EndLine: end, Text: text})
+		}
+		if end == len(all) {
+			break
+		}
+	}
+	return chunks
+}
+
+// ChunkMarkdown splits content into its heading sections, so a search finds
+// whole sections; long sections are split further like ChunkText
+func ChunkMarkdown(path, content string, lines int) []Chunk {
+	all := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	var chunks []Chunk
+	addSection := func(start, end int, title string) {
+		for _, chunk := range ChunkText(path, strings.Join(all[start:end], "\n"), lines) {
+			chunk.StartLine += start
+			chunk.EndLine += start
+			chunk.Title = title
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	start, title, fenced := 0, "", false
+	for i, line := range all {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if fenced || !strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i > start {
+			addSection(start, i, title)
+		}
+		start, title = i, strings.TrimSpace(strings.TrimLeft(line, "#"))
+	}
+	addSection(start, len(all), title)
+	return chunks
+}
+
+// isBinary reports whether content looks like a binary file
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
@@ -0,0 +1,114 @@
+package embeddings
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder counts the texts it embeds
+type countingEmbedder struct {
+	LocalEmbedder
+	texts int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.texts += len(texts)
+	return c.LocalEmbedder.Embed(ctx, texts)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestChunkText(t *testing.T) {
+	lines := make([]string, 45)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	chunks := ChunkText("a.go", strings.Join(lines, "\n")+"\n", 20)
+
+	require.Len(t, chunks, 4)
+	assert.Equal(t, 1, chunks[0].StartLine)
+	assert.Equal(t, 20, chunks[0].EndLine)
+	assert.Equal(t, 11, chunks[1].StartLine, "chunks overlap")
+	assert.Equal(t, 31, chunks[3].StartLine)
+	assert.Equal(t, 45, chunks[3].EndLine)
+	assert.Empty(t, ChunkText("blank.txt", "\n\n  \n", 20))
+}
+
+func TestIndexSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "retry", "policy.go"), "package retry\n\n// NewRetryPolicy configures how failed requests are retried\nfunc NewRetryPolicy(maxRetries int) {}\n")
+	writeFile(t, filepath.Join(root, "ui", "render.go"), "package ui\n\nfunc renderMarkdown(text string) string { return text }\n")
+	writeFile(t, filepath.Join(root, "node_modules", "retry.js"), "retry retry retry")
+	writeFile(t, filepath.Join(root, "image.bin"), "retry\x00\x01")
+
+	embedder := &countingEmbedder{}
+	idx := NewIndex(root, embedder)
+	require.NoError(t, idx.Update(context.Background()))
+	assert.Equal(t, 2, idx.Len())
+
+	results, err := idx.Search(context.Background(), "where are retries configured", "", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "retry/policy.go", results[0].Path)
+	assert.Equal(t, 1, results[0].StartLine)
+	assert.Equal(t, 4, results[0].EndLine)
+
+	results, err = idx.Search(context.Background(), "retries", "ui", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "ui/render.go", results[0].Path)
+
+	// Only changed files are embedded again
+	embedded := embedder.texts
+	require.NoError(t, idx.Update(context.Background()))
+	assert.Equal(t, embedded, embedder.texts)
+
+	path := filepath.Join(root, "ui", "render.go")
+	writeFile(t, path, "package ui\n\nfunc renderTable() {}\n")
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	require.NoError(t, os.Remove(filepath.Join(root, "retry", "policy.go")))
+	require.NoError(t, idx.Update(context.Background()))
+	assert.Equal(t, embedded+1, embedder.texts)
+	assert.Equal(t, 1, idx.Len())
+}
+
+func TestIndexPatterns(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "docs", "guide.md"), "# Guide\n")
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n")
+
+	idx := NewIndex(root, NewLocalEmbedder(), WithPatterns("docs/**"))
+	require.NoError(t, idx.Update(context.Background()))
+
+	results, err := idx.Search(context.Background(), "guide", "", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "docs/guide.md", results[0].Path)
+}
+
+func TestChunkMarkdown(t *testing.T) {
+	content := "Intro text\n\n# Install\n\nRun make.\n\n```sh\n# not a heading\n```\n\n## Configure\n\nEdit config.yaml\n"
+	chunks := ChunkMarkdown("docs/guide.md", content, 40)
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "", chunks[0].Title)
+	assert.Equal(t, 1, chunks[0].StartLine)
+	assert.Equal(t, "Install", chunks[1].Title)
+	assert.Equal(t, 3, chunks[1].StartLine)
+	assert.Equal(t, 9, chunks[1].EndLine)
+	assert.Contains(t, chunks[1].Text, "# not a heading")
+	assert.Equal(t, "Configure", chunks[2].Title)
+	assert.Equal(t, 11, chunks[2].StartLine)
+	assert.Equal(t, 13, chunks[2].EndLine)
+}
@@ -0,0 +1,166 @@
+package errors
+
+import (
+	stderrors "errors"
+	"time"
+
+	ai_errors "github.com/common-creation/coda/internal/ai"
+)
+
+// ActionKind identifies what a remediation action does when selected.
+type ActionKind string
+
+const (
+	ActionRetry       ActionKind = "retry"        // 直前の操作を再試行
+	ActionDismiss     ActionKind = "dismiss"      // エラーを閉じる
+	ActionNewSession  ActionKind = "new_session"  // 新しいセッションを開始
+	ActionShowCommand ActionKind = "show_command" // 実行すべきコマンドを表示
+	ActionQuit        ActionKind = "quit"         // アプリケーションを終了
+)
+
+// DefaultRateLimitWait is used when the provider does not say how long to wait.
+const DefaultRateLimitWait = 20 * time.Second
+
+// RemediationAction is a single selectable recovery step.
+type RemediationAction struct {
+	Kind    ActionKind
+	Label   string
+	Command string // Shell command for ActionShowCommand
+}
+
+// Remediation describes how the user can recover from an error.
+type Remediation struct {
+	// Hint is a concrete, human readable suggestion
+	Hint string
+
+	// Actions are offered to the user in order; the first one is the default
+	Actions []RemediationAction
+
+	// RetryAfter, when positive, means the failed action is retried
+	// automatically once the duration has elapsed
+	RetryAfter time.Duration
+}
+
+var (
+	retryAction      = RemediationAction{Kind: ActionRetry, Label: "再試行"}
+	dismissAction    = RemediationAction{Kind: ActionDismiss, Label: "閉じる"}
+	newSessionAction = RemediationAction{Kind: ActionNewSession, Label: "新しいセッション"}
+	quitAction       = RemediationAction{Kind: ActionQuit, Label: "終了"}
+)
+
+// Remediation returns the recovery suggestions for an error.
+func (h *ErrorHandler) Remediation(err error) Remediation {
+	if err == nil {
+		return Remediation{}
+	}
+
+	switch h.ClassifyError(err) {
+	case AIServiceError:
+		return aiServiceRemediation(err)
+	case NetworkError:
+		return Remediation{
+			Hint:    "インターネット接続とプロキシ設定を確認してから再試行してください",
+			Actions: []RemediationAction{retryAction, dismissAction},
+		}
+	case ConfigError:
+		return Remediation{
+			Hint: "'coda config show' で現在の設定を確認し、'coda config set' で修正してください",
+			Actions: []RemediationAction{
+				{Kind: ActionShowCommand, Label: "設定を確認", Command: "coda config show"},
+				dismissAction,
+			},
+		}
+	case SecurityError:
+		return Remediation{
+			Hint:    "操作対象のパスが許可されているか確認してください (tools.file_access の設定)",
+			Actions: []RemediationAction{dismissAction},
+		}
+	case UserError:
+		return Remediation{
+			Hint:    "入力内容やファイルパスを確認してください",
+			Actions: []RemediationAction{dismissAction, retryAction},
+		}
+	default:
+		return Remediation{
+			Hint:    "再試行しても解決しない場合は --debug を付けて起動し、ログを確認してください",
+			Actions: []RemediationAction{retryAction, dismissAction, quitAction},
+		}
+	}
+}
+
+// aiServiceRemediation maps AI provider error types to recovery steps.
+func aiServiceRemediation(err error) Remediation {
+	switch ai_errors.GetErrorType(err) {
+	case ai_errors.ErrTypeAuthentication:
+		return Remediation{
+			Hint: "APIキーが無効か期限切れです。'coda config set ai.api_key <key>' を実行するか OPENAI_API_KEY を設定してください",
+			Actions: []RemediationAction{
+				{Kind: ActionShowCommand, Label: "設定コマンドを表示", Command: "coda config set ai.api_key <key>"},
+				dismissAction,
+				quitAction,
+			},
+		}
+	case ai_errors.ErrTypeRateLimit:
+		return Remediation{
+			Hint:       "利用制限に達しました。待機後に自動で再試行します",
+			Actions:    []RemediationAction{retryAction, dismissAction},
+			RetryAfter: RetryAfter(err),
+		}
+	case ai_errors.ErrTypeQuotaExceeded:
+		return Remediation{
+			Hint:    "利用量の上限に達しました。プロバイダーの請求設定を確認するか、別のAPIキーを設定してください",
+			Actions: []RemediationAction{dismissAction},
+		}
+	case ai_errors.ErrTypeContextLength:
+		return Remediation{
+			Hint:    "会話が長すぎます。新しいセッションを開始するか、/fork N で会話の前半だけを引き継いでください",
+			Actions: []RemediationAction{newSessionAction, dismissAction},
+		}
+	case ai_errors.ErrTypeContentFilter:
+		return Remediation{
+			Hint:    "コンテンツフィルターに検出されました。表現を変えて再送信してください",
+			Actions: []RemediationAction{dismissAction},
+		}
+	case ai_errors.ErrTypeModelNotFound:
+		return Remediation{
+			Hint: "指定されたモデルが利用できません。'coda config set ai.model <model>' で利用可能なモデルを設定してください",
+			Actions: []RemediationAction{
+				{Kind: ActionShowCommand, Label: "設定コマンドを表示", Command: "coda config set ai.model <model>"},
+				dismissAction,
+			},
+		}
+	case ai_errors.ErrTypeServerError, ai_errors.ErrTypeTimeout:
+		return Remediation{
+			Hint:    "AIサービス側で一時的な問題が発生しています。少し待ってから再試行してください",
+			Actions: []RemediationAction{retryAction, dismissAction},
+		}
+	default:
+		return Remediation{
+			Hint:    "しばらく待ってから再試行してください",
+			Actions: []RemediationAction{retryAction, dismissAction},
+		}
+	}
+}
+
+// RetryAfter returns how long to wait before retrying a rate limited request.
+// It honours a "retry_after" detail on AI errors and falls back to DefaultRateLimitWait.
+func RetryAfter(err error) time.Duration {
+	var aiErr *ai_errors.Error
+	if stderrors.As(err, &aiErr) && aiErr.Details != nil {
+		switch v := aiErr.Details["retry_after"].(type) {
+		case time.Duration:
+			if v > 0 {
+				return v
+			}
+		case int:
+			if v > 0 {
+				return time.Duration(v) * time.Second
+			}
+		case float64:
+			if v > 0 {
+				return time.Duration(v * float64(time.Second))
+			}
+		}
+	}
+	return DefaultRateLimitWait
+}
@@ -0,0 +1,328 @@
+// Package explain builds prompts that explain a file or a line range of it.
+package explain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/symbols"
+)
+
+const (
+	// DefaultContextLines is the number of lines shown around the range
+	DefaultContextLines = 15
+
+	// maxRelatedSymbols bounds the definitions added from the symbol index
+	maxRelatedSymbols = 15
+
+	// maxFileLines bounds whole-file explanations
+	maxFileLines = 2000
+)
+
+// Target identifies the code to explain. Start and End are 1-based and
+// inclusive; zero means the whole file.
+type Target struct {
+	Path  string
+	Start int
+	End   int
+}
+
+// String formats the target as path[:start-end]
+func (t Target) String() string {
+	if t.Start == 0 {
+		return t.Path
+	}
+	if t.Start == t.End {
+		return fmt.Sprintf("%s:%d", t.Path, t.Start)
+	}
+	return fmt.Sprintf("%s:%d-%d", t.Path, t.Start, t.End)
+}
+
+// ParseTarget parses "file", "file:line" or "file:start-end"
+func ParseTarget(spec string) (Target, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Target{}, fmt.Errorf("no file given")
+	}
+
+	i := strings.LastIndex(spec, ":")
+	if i <= 0 || i == len(spec)-1 {
+		return Target{Path: spec}, nil
+	}
+
+	path, rangeSpec := spec[:i], spec[i+1:]
+	startText, endText, isRange := strings.Cut(rangeSpec, "-")
+
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		// Not a line spec (e.g. a Windows drive letter); treat it all as a path
+		return Target{Path: spec}, nil
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(endText); err != nil {
+			return Target{}, fmt.Errorf("invalid line range %q", rangeSpec)
+		}
+	}
+
+	if start < 1 || end < start {
+		return Target{}, fmt.Errorf("invalid line range %q", rangeSpec)
+	}
+
+	return Target{Path: path, Start: start, End: end}, nil
+}
+
+// Request holds everything needed to explain a target
+type Request struct {
+	Target Target
+
+	// Question optionally focuses the explanation
+	Question string
+
+	// ContextLines around the range (DefaultContextLines when zero)
+	ContextLines int
+
+	// Index supplies definitions referenced by the code (may be nil)
+	Index *symbols.Index
+
+	// Root is the directory the index paths are relative to
+	Root string
+}
+
+// BuildMessages reads the target and builds the chat messages for the explanation
+func BuildMessages(req Request) ([]ai.Message, error) {
+	data, err := os.ReadFile(req.Target.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", req.Target.Path, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	target := req.Target
+	if target.Start == 0 {
+		if len(lines) > maxFileLines {
+			return nil, fmt.Errorf("%s has %d lines; select a range of at most %d lines", target.Path, len(lines), maxFileLines)
+		}
+		target.Start, target.End = 1, len(lines)
+	}
+	if target.Start > len(lines) {
+		return nil, fmt.Errorf("%s has only %d lines", target.Path, len(lines))
+	}
+	target.End = min(target.End, len(lines))
+
+	contextLines := req.ContextLines
+	if contextLines <= 0 {
+		contextLines = DefaultContextLines
+	}
+
+	lang := languageFor(target.Path)
+	selection := strings.Join(lines[target.Start-1:target.End], "\n")
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Explain the code in %s.\n\n", target))
+	if req.Question != "" {
+		sb.WriteString(fmt.Sprintf("Focus on this question: %s\n\n", req.Question))
+	}
+
+	sb.WriteString(fmt.Sprintf("Code to explain:\n```%s\n%s\n```\n", lang, numbered(lines, target.Start, target.End)))
+
+	// Surrounding lines for orientation (only when explaining a range)
+	if req.Target.Start != 0 {
+		before := max(target.Start-contextLines, 1)
+		after := min(target.End+contextLines, len(lines))
+		if before < target.Start {
+			sb.WriteString(fmt.Sprintf("\nPreceding context:\n```%s\n%s\n```\n", lang, numbered(lines, before, target.Start-1)))
+		}
+		if after > target.End {
+			sb.WriteString(fmt.Sprintf("\nFollowing context:\n```%s\n%s\n```\n", lang, numbered(lines, target.End+1, after)))
+		}
+	}
+
+	if related := relatedDefinitions(req, target, selection); related != "" {
+		sb.WriteString("\nRelated definitions from the workspace:\n")
+		sb.WriteString(related)
+	}
+
+	return []ai.Message{
+		{Role: ai.RoleSystem, Content: systemPrompt},
+		{Role: ai.RoleUser, Content: sb.String()},
+	}, nil
+}
+
+const systemPrompt = `You are an experienced software engineer explaining code to a colleague.
+Start with a one or two sentence summary of what the code does, then walk
+through how it works, referring to line numbers where helpful. Call out
+non-obvious behavior, edge cases and potential bugs. Be concise and use
+markdown.`
+
+// relatedDefinitions looks up identifiers used in the selection in the symbol index
+func relatedDefinitions(req Request, target Target, selection string) string {
+	if req.Index == nil || !strings.HasSuffix(target.Path, ".go") {
+		return ""
+	}
+
+	relPath := target.Path
+	if req.Root != "" {
+		if abs, err := filepath.Abs(target.Path); err == nil {
+			if rootAbs, err := filepath.Abs(req.Root); err == nil {
+				if r, err := filepath.Rel(rootAbs, abs); err == nil {
+					relPath = r
+				}
+			}
+		}
+	}
+	relPath = filepath.ToSlash(relPath)
+	dir := filepath.ToSlash(filepath.Dir(relPath))
+
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	count := 0
+
+	// The declaration enclosing the selection comes first
+	if enclosing, ok := req.Index.Enclosing(relPath, target.Start); ok && (enclosing.Line < target.Start || enclosing.EndLine > target.End) {
+		writeSymbol(&sb, enclosing)
+		seen[enclosing.File+":"+strconv.Itoa(enclosing.Line)] = true
+		count++
+	}
+
+	for _, name := range symbols.Identifiers(selection) {
+		if count >= maxRelatedSymbols {
+			break
+		}
+
+		candidates := req.Index.Lookup(name)
+		// Prefer definitions from the same package directory
+		for _, sym := range orderByDir(candidates, dir) {
+			key := sym.File + ":" + strconv.Itoa(sym.Line)
+			if seen[key] {
+				continue
+			}
+			// Skip definitions inside the selection itself
+			if sym.File == relPath && sym.Line >= target.Start && sym.Line <= target.End {
+				continue
+			}
+			seen[key] = true
+			writeSymbol(&sb, sym)
+			count++
+			break
+		}
+	}
+
+	return sb.String()
+}
+
+func orderByDir(syms []symbols.Symbol, dir string) []symbols.Symbol {
+	ordered := make([]symbols.Symbol, 0, len(syms))
+	for _, s := range syms {
+		if filepath.ToSlash(filepath.Dir(s.File)) == dir {
+			ordered = append(ordered, s)
+		}
+	}
+	for _, s := range syms {
+		if filepath.ToSlash(filepath.Dir(s.File)) != dir {
+			ordered = append(ordered, s)
+		}
+	}
+	return ordered
+}
+
+func writeSymbol(sb *strings.Builder, sym symbols.Symbol) {
+	sb.WriteString(fmt.Sprintf("\n%s (%s:%d):\n```go\n", sym.QualifiedName(), sym.File, sym.Line))
+	if sym.Doc != "" {
+		for _, line := range strings.Split(sym.Doc, "\n") {
+			sb.WriteString("// " + line + "\n")
+		}
+	}
+	sb.WriteString(sym.Signature)
+	sb.WriteString("\n```\n")
+}
+
+// numbered returns lines start..end (1-based, inclusive) prefixed with line numbers
+func numbered(lines []string, start, end int) string {
+	width := len(strconv.Itoa(end))
+	var sb strings.Builder
+	for i := start; i <= end; i++ {
+		if i > start {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("%*d  %s", width, i, lines[i-1]))
+	}
+	return sb.String()
+}
+
+// languageFor returns the markdown fence language for a file
+func languageFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".js", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts", ".tsx":
+		return "typescript"
+	case ".rs":
+		return "rust"
+	case ".java":
+		return "java"
+	case ".rb":
+		return "ruby"
+	case ".sh", ".bash":
+		return "bash"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".md":
+		return "markdown"
+	case ".sql":
+		return "sql"
+	case ".c", ".h":
+		return "c"
+	case ".cpp", ".cc", ".hpp":
+		return "cpp"
+	default:
+		return ""
+	}
+}
+
+// Stream sends the messages to the model, calling onDelta with each piece of
+// content as it arrives, and returns the full explanation
+func Stream(ctx context.Context, client ai.Client, model string, messages []ai.Message, onDelta func(string)) (string, error) {
+	stream, err := client.ChatCompletionStream(ctx, ai.ChatRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to request explanation: %w", err)
+	}
+	defer stream.Close()
+
+	var sb strings.Builder
+	for {
+		chunk, err := stream.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sb.String(), fmt.Errorf("failed to read explanation: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			sb.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
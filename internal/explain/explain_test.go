@@ -0,0 +1,89 @@
+package explain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/symbols"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Target
+		wantErr bool
+	}{
+		{spec: "main.go", want: Target{Path: "main.go"}},
+		{spec: "main.go:12", want: Target{Path: "main.go", Start: 12, End: 12}},
+		{spec: "dir/main.go:3-9", want: Target{Path: "dir/main.go", Start: 3, End: 9}},
+		{spec: `C:\src\main.go`, want: Target{Path: `C:\src\main.go`}},
+		{spec: "main.go:9-3", wantErr: true},
+		{spec: "main.go:0", wantErr: true},
+		{spec: "main.go:3-x", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseTarget(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBuildMessages(t *testing.T) {
+	root := t.TempDir()
+	src := `package calc
+
+// Scale multiplies v by the configured factor
+func Scale(v int) int {
+	return v * factor
+}
+
+// factor is the scale factor
+var factor = 3
+
+func Double(v int) int {
+	total := Scale(v)
+	return total * 2
+}
+`
+	path := filepath.Join(root, "calc.go")
+	require.NoError(t, os.WriteFile(path, []byte(src), 0644))
+
+	idx, err := symbols.BuildIndex(root)
+	require.NoError(t, err)
+
+	messages, err := BuildMessages(Request{
+		Target:       Target{Path: path, Start: 12, End: 13},
+		Question:     "what does total hold?",
+		ContextLines: 2,
+		Index:        idx,
+		Root:         root,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, ai.RoleSystem, messages[0].Role)
+
+	prompt := messages[1].Content
+	assert.Contains(t, prompt, "what does total hold?")
+	assert.Contains(t, prompt, "12  \ttotal := Scale(v)")
+	assert.Contains(t, prompt, "Preceding context")
+	assert.Contains(t, prompt, "Following context")
+	// Enclosing declaration and referenced function
+	assert.Contains(t, prompt, "func Double(v int) int\n")
+	assert.Contains(t, prompt, "// Scale multiplies v by the configured factor\nfunc Scale(v int) int")
+
+	_, err = BuildMessages(Request{Target: Target{Path: path, Start: 100, End: 100}})
+	assert.Error(t, err)
+}
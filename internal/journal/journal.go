@@ -0,0 +1,233 @@
+// Package journal keeps a per-project daily log of what the agent did: the
+// sessions, files touched, commands run and costs, as one markdown file per
+// day under .coda/journal/.
+package journal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDir is where journal files are written, relative to the project
+const DefaultDir = ".coda/journal"
+
+// dayFormat names the journal files: 2006-01-02.md
+const dayFormat = "2006-01-02"
+
+// Entry summarizes one run of the chat
+type Entry struct {
+	Start time.Time
+	End   time.Time
+
+	// Sessions and models used, one per tab
+	Sessions []string
+	Models   []string
+
+	Prompts int
+	Tokens  int
+	Cost    float64 // USD, 0 if unknown
+
+	Files    []string
+	Commands []string
+}
+
+// Empty reports whether nothing happened worth recording
+func (e Entry) Empty() bool {
+	return e.Prompts == 0 && len(e.Files) == 0 && len(e.Commands) == 0
+}
+
+// Journal reads and writes the daily files
+type Journal struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// New creates a journal in dir (DefaultDir when empty)
+func New(dir string) *Journal {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Journal{dir: dir}
+}
+
+// Path returns the file for the given day
+func (j *Journal) Path(day time.Time) string {
+	return filepath.Join(j.dir, day.Format(dayFormat)+".md")
+}
+
+// Append adds the entry to the file of the day it started, creating the
+// file with a heading when needed
+func (j *Journal) Append(e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	path := j.Path(e.Start)
+	_, statErr := os.Stat(path)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	if os.IsNotExist(statErr) {
+		fmt.Fprintf(&b, "# CODA journal %s\n", e.Start.Format(dayFormat))
+	}
+	b.WriteString("\n")
+	b.WriteString(formatEntry(e))
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Days returns the days with a journal file, newest first
+func (j *Journal) Days() ([]time.Time, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
+	}
+
+	var days []time.Time
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".md")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if day, err := time.ParseInLocation(dayFormat, name, time.Local); err == nil {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(a, b int) bool { return days[a].After(days[b]) })
+	return days, nil
+}
+
+// Read returns the journal of the given day
+func (j *Journal) Read(day time.Time) (string, error) {
+	data, err := os.ReadFile(j.Path(day))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no journal for %s", day.Format(dayFormat))
+		}
+		return "", fmt.Errorf("failed to read journal: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatEntry renders an entry as a markdown section
+func formatEntry(e Entry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s–%s", e.Start.Format("15:04"), e.End.Format("15:04"))
+	if len(e.Sessions) > 0 {
+		fmt.Fprintf(&b, " · session %s", strings.Join(e.Sessions, ", "))
+	}
+	b.WriteString("\n\n")
+
+	usage := fmt.Sprintf("- Prompts: %d", e.Prompts)
+	if e.Tokens > 0 {
+		usage += fmt.Sprintf(" · Tokens: %d", e.Tokens)
+	}
+	if e.Cost > 0 {
+		usage += fmt.Sprintf(" · Cost: $%.4f", e.Cost)
+	}
+	b.WriteString(usage + "\n")
+	if len(e.Models) > 0 {
+		fmt.Fprintf(&b, "- Models: %s\n", strings.Join(e.Models, ", "))
+	}
+
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "- %s (%d):\n", title, len(items))
+		for _, item := range items {
+			fmt.Fprintf(&b, "  - `%s`\n", strings.ReplaceAll(item, "`", "'"))
+		}
+	}
+	writeList("Files touched", e.Files)
+	writeList("Commands run", e.Commands)
+	return b.String()
+}
+
+// Recorder collects the files and commands of a run from tool calls and
+// appends the entry to the journal when the run ends
+type Recorder struct {
+	journal *Journal
+	start   time.Time
+
+	mu       sync.Mutex
+	files    []string
+	seen     map[string]bool
+	commands []string
+}
+
+// NewRecorder starts recording a run
+func NewRecorder(journal *Journal) *Recorder {
+	return &Recorder{journal: journal, start: time.Now(), seen: make(map[string]bool)}
+}
+
+// Observe is a tools.ExecuteHook recording the files written and the
+// commands run
+func (r *Recorder) Observe(ctx context.Context, name string, params map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch name {
+	case "write_file", "edit_file":
+		path, _ := params["path"].(string)
+		if path == "" {
+			return
+		}
+		path = relativePath(path)
+		if !r.seen[path] {
+			r.seen[path] = true
+			r.files = append(r.files, path)
+		}
+	case "run_command":
+		if command, _ := params["command"].(string); command != "" {
+			r.commands = append(r.commands, strings.Join(strings.Fields(command), " "))
+		}
+	}
+}
+
+// Finish completes the entry with the recorded activity and appends it to
+// the journal, unless nothing happened
+func (r *Recorder) Finish(e Entry) error {
+	r.mu.Lock()
+	e.Start = r.start
+	e.End = time.Now()
+	e.Files = append([]string(nil), r.files...)
+	e.Commands = append([]string(nil), r.commands...)
+	r.mu.Unlock()
+
+	if e.Empty() {
+		return nil
+	}
+	return r.journal.Append(e)
+}
+
+// relativePath reports paths inside the working directory relative to it
+func relativePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
@@ -0,0 +1,70 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderFinish(t *testing.T) {
+	j := New(t.TempDir())
+	recorder := NewRecorder(j)
+
+	recorder.Observe(context.Background(), "write_file", map[string]interface{}{"path": "main.go"})
+	recorder.Observe(context.Background(), "edit_file", map[string]interface{}{"path": "./main.go"})
+	recorder.Observe(context.Background(), "read_file", map[string]interface{}{"path": "go.mod"})
+	recorder.Observe(context.Background(), "run_command", map[string]interface{}{"command": "go  test ./..."})
+
+	require.NoError(t, recorder.Finish(Entry{
+		Sessions: []string{"1a2b3c4d"},
+		Models:   []string{"gpt-4o"},
+		Prompts:  2,
+		Tokens:   1200,
+		Cost:     0.0125,
+	}))
+
+	content, err := j.Read(time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, content, "# CODA journal "+time.Now().Format("2006-01-02")+"\n")
+	assert.Contains(t, content, " · session 1a2b3c4d\n")
+	assert.Contains(t, content, "- Prompts: 2 · Tokens: 1200 · Cost: $0.0125\n")
+	assert.Contains(t, content, "- Models: gpt-4o\n")
+	assert.Contains(t, content, "- Files touched (1):\n  - `main.go`\n")
+	assert.Contains(t, content, "- Commands run (1):\n  - `go test ./...`\n")
+
+	// A second run is appended under the same heading
+	require.NoError(t, NewRecorder(j).Finish(Entry{Prompts: 1}))
+	content, err = j.Read(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(content, "# CODA journal"))
+	assert.Equal(t, 2, strings.Count(content, "\n## "))
+
+	// Runs without activity are not recorded
+	other := New(filepath.Join(t.TempDir(), "journal"))
+	require.NoError(t, NewRecorder(other).Finish(Entry{}))
+	days, err := other.Days()
+	require.NoError(t, err)
+	assert.Empty(t, days)
+}
+
+func TestJournalDays(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2025-06-01.md", "2025-06-03.md", "notes.md", "2025-06-02.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("# x\n"), 0644))
+	}
+
+	days, err := New(dir).Days()
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, "2025-06-03", days[0].Format("2006-01-02"))
+	assert.Equal(t, "2025-06-01", days[1].Format("2006-01-02"))
+
+	_, err = New(dir).Read(time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local))
+	assert.ErrorContains(t, err, "no journal for 2020-01-01")
+}
@@ -0,0 +1,150 @@
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: frontend
+    spec:
+      containers:
+        - name: web
+          image: nginx
+          ports:
+            - name: http
+              containerPort: 80
+        - name: web
+          image: busybox:1.36
+          resources:
+            limits:
+              memory: 64Mi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+    - port: 80
+      targetPort: http
+`
+
+const service = `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: api
+    spec:
+      selector:
+        app: frontend
+      ports:
+        - port: 80
+          targetPort: metrics
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: web
+---
+# not a Kubernetes object
+services:
+  app:
+    image: nginx
+---
+kind: Secret
+metadata:
+  labels: {}
+`
+
+func writeManifests(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "deploy", ".hidden"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy", "web.yaml"), []byte(deployment), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy", "service.yml"), []byte(service), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy", ".hidden", "x.yaml"), []byte("kind: Pod\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy", "README.md"), []byte("# deploy\n"), 0644))
+	return dir
+}
+
+func TestValidateBuiltin(t *testing.T) {
+	dir := writeManifests(t)
+	v := NewValidator(WithKubeconform("kubeconform-not-installed"))
+	assert.False(t, v.SchemaValidation())
+
+	result, err := v.Validate(context.Background(), dir, []string{"deploy"})
+	require.NoError(t, err)
+	assert.False(t, result.SchemaChecked)
+	assert.False(t, result.Valid())
+
+	ids := make([]string, 0, len(result.Resources))
+	for _, r := range result.Resources {
+		ids = append(ids, r.File+" "+r.ID())
+	}
+	assert.Equal(t, []string{
+		"deploy/service.yml Service/api",
+		"deploy/service.yml ConfigMap/web",
+		"deploy/service.yml Secret/",
+		"deploy/web.yaml Deployment/web",
+		"deploy/web.yaml Service/web",
+	}, ids)
+
+	type found struct {
+		File, Resource, Rule string
+		Line                 int
+	}
+	var issues []found
+	for _, issue := range result.Issues {
+		issues = append(issues, found{issue.File, issue.Resource, issue.Rule, issue.Line})
+	}
+	assert.Equal(t, []found{
+		{"deploy/service.yml", "Service/api", "unknown-target-port", 13},
+		{"deploy/service.yml", "Secret/", "missing-field", 24},
+		{"deploy/service.yml", "Secret/", "missing-field", 24},
+		{"deploy/web.yaml", "Deployment/web", "selector-mismatch", 7},
+		{"deploy/web.yaml", "Deployment/web", "no-resource-limits", 15},
+		{"deploy/web.yaml", "Deployment/web", "untagged-image", 16},
+		{"deploy/web.yaml", "Deployment/web", "duplicate-container", 20},
+		{"deploy/web.yaml", "Service/web", "unmatched-selector", 32},
+	}, issues)
+	assert.Equal(t, "5 resources: 5 errors, 2 warnings, 1 notes", result.Counts())
+	assert.Equal(t, "deploy/web.yaml:7: error: Deployment/web: /spec/selector/matchLabels: selector {app=web} does not match the pod template labels {app=frontend} (selector-mismatch)", result.Issues[3].String())
+}
+
+func TestParseInvalid(t *testing.T) {
+	resources, issues := Parse("bad.yaml", []byte("apiVersion: v1\nkind: Pod\nmetadata: [\n"))
+	assert.Empty(t, resources)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "invalid-yaml", issues[0].Rule)
+
+	_, err := NewValidator().Validate(context.Background(), t.TempDir(), []string{"."})
+	assert.ErrorContains(t, err, "no manifests found")
+}
+
+func TestLintDuplicates(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: other\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"
+	resources, issues := Parse("cm.yaml", []byte(manifest))
+	require.Empty(t, issues)
+	issues = Lint(resources)
+	require.Len(t, issues, 1)
+	assert.Equal(t, Issue{File: "cm.yaml", Line: 12, Resource: "ConfigMap/a", Severity: SeverityError, Rule: "duplicate-resource", Message: "already defined at cm.yaml:1"}, issues[0])
+}
@@ -0,0 +1,229 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// podTemplatePaths locate the pod template of the workload kinds
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"ReplicaSet":  {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// selectorKinds are the workloads that require spec.selector
+var selectorKinds = map[string]bool{"Deployment": true, "StatefulSet": true, "DaemonSet": true, "ReplicaSet": true}
+
+// workload is a resource running pods, with the labels and named ports of
+// its pods
+type workload struct {
+	labels map[string]string
+	ports  map[string]bool
+}
+
+// Lint checks the resources for mistakes schemas do not catch: selectors
+// that do not match the pod labels, containers without an image, unpinned
+// images, duplicate resources and Services selecting no pods
+func Lint(resources []Resource) []Issue {
+	var issues []Issue
+	seen := make(map[string]Resource)
+	var workloads []workload
+
+	for _, r := range resources {
+		add := func(node *yaml.Node, path, severity, rule, format string, args ...interface{}) {
+			line := r.Line
+			if node != nil {
+				line = node.Line
+			}
+			issues = append(issues, Issue{File: r.File, Line: line, Resource: r.ID(), Path: path, Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if r.Name != "" {
+			group, _, _ := strings.Cut(r.APIVersion, "/")
+			if !strings.Contains(r.APIVersion, "/") {
+				group = ""
+			}
+			key := strings.Join([]string{group, r.Kind, r.Namespace, r.Name}, "|")
+			if first, exists := seen[key]; exists {
+				add(nil, "", SeverityError, "duplicate-resource", "already defined at %s:%d", first.File, first.Line)
+			}
+			seen[key] = r
+		}
+
+		if path, ok := podTemplatePaths[r.Kind]; ok {
+			template := lookup(r.node, path...)
+			templatePath := "/" + strings.Join(path, "/")
+			labels := stringMap(template, "metadata", "labels")
+			if selectorKinds[r.Kind] {
+				selector := lookup(r.node, "spec", "selector")
+				matchLabels := stringMap(selector, "matchLabels")
+				switch {
+				case selector == nil:
+					add(nil, "/spec/selector", SeverityError, "missing-selector", "spec.selector is required")
+				case len(matchLabels) > 0 && !matches(matchLabels, labels):
+					add(selector, "/spec/selector/matchLabels", SeverityError, "selector-mismatch", "selector %s does not match the pod template labels %s", formatLabels(matchLabels), formatLabels(labels))
+				}
+			}
+			ports := lintPodSpec(lookup(template, "spec"), templatePath+"/spec", add)
+			workloads = append(workloads, workload{labels: labels, ports: ports})
+		} else if r.Kind == "Pod" {
+			ports := lintPodSpec(lookup(r.node, "spec"), "/spec", add)
+			workloads = append(workloads, workload{labels: stringMap(r.node, "metadata", "labels"), ports: ports})
+		}
+	}
+
+	// Services are checked against the workloads of the same manifests
+	if len(workloads) > 0 {
+		for _, r := range resources {
+			if r.Kind == "Service" {
+				issues = append(issues, lintService(r, workloads)...)
+			}
+		}
+	}
+	return issues
+}
+
+// lintPodSpec checks the containers of a pod spec and returns the names of
+// their ports
+func lintPodSpec(spec *yaml.Node, path string, add func(*yaml.Node, string, string, string, string, ...interface{})) map[string]bool {
+	ports := make(map[string]bool)
+	containers := lookup(spec, "containers")
+	if containers == nil || containers.Kind != yaml.SequenceNode || len(containers.Content) == 0 {
+		add(spec, path+"/containers", SeverityError, "no-containers", "the pod has no containers")
+		return ports
+	}
+
+	names := make(map[string]bool)
+	for _, list := range []string{"initContainers", "containers"} {
+		node := lookup(spec, list)
+		if node == nil || node.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, c := range node.Content {
+			at := fmt.Sprintf("%s/%s/%d", path, list, i)
+			name := scalar(c, "name")
+			switch {
+			case name == "":
+				add(c, at+"/name", SeverityError, "missing-field", "container name is required")
+			case names[name]:
+				add(lookup(c, "name"), at+"/name", SeverityError, "duplicate-container", "container name %s is used twice", name)
+			}
+			names[name] = true
+
+			image := scalar(c, "image")
+			switch {
+			case image == "":
+				add(c, at+"/image", SeverityError, "missing-image", "container %s has no image", name)
+			case !strings.Contains(image, "@"):
+				tag := imageTag(image)
+				if tag == "" {
+					add(lookup(c, "image"), at+"/image", SeverityWarning, "untagged-image", "%s has no tag, so the latest image is used; pin a version", image)
+				} else if tag == "latest" {
+					add(lookup(c, "image"), at+"/image", SeverityWarning, "latest-tag", "%s changes over time; pin a version", image)
+				}
+			}
+			if list == "containers" && lookup(c, "resources", "limits") == nil {
+				add(c, at+"/resources", SeverityInfo, "no-resource-limits", "container %s sets no resource limits", name)
+			}
+
+			if portList := lookup(c, "ports"); portList != nil && portList.Kind == yaml.SequenceNode {
+				for j, p := range portList.Content {
+					port := lookup(p, "containerPort")
+					if n, err := strconv.Atoi(scalar(p, "containerPort")); err != nil || n < 1 || n > 65535 {
+						add(port, fmt.Sprintf("%s/ports/%d/containerPort", at, j), SeverityError, "invalid-port", "containerPort must be a number between 1 and 65535")
+					}
+					if portName := scalar(p, "name"); portName != "" {
+						ports[portName] = true
+					}
+				}
+			}
+		}
+	}
+	return ports
+}
+
+// lintService reports a Service whose selector matches none of the
+// workloads, or whose named target ports none of them declare
+func lintService(r Resource, workloads []workload) []Issue {
+	selector := stringMap(r.node, "spec", "selector")
+	if len(selector) == 0 {
+		return nil
+	}
+	var matched []workload
+	for _, w := range workloads {
+		if matches(selector, w.labels) {
+			matched = append(matched, w)
+		}
+	}
+	if len(matched) == 0 {
+		return []Issue{{
+			File: r.File, Line: lookup(r.node, "spec", "selector").Line, Resource: r.ID(), Path: "/spec/selector",
+			Severity: SeverityWarning, Rule: "unmatched-selector",
+			Message: fmt.Sprintf("selector %s matches none of the pods in these manifests", formatLabels(selector)),
+		}}
+	}
+
+	var issues []Issue
+	ports := lookup(r.node, "spec", "ports")
+	if ports == nil || ports.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for i, p := range ports.Content {
+		target := scalar(p, "targetPort")
+		if target == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(target); err == nil {
+			continue
+		}
+		declared := false
+		for _, w := range matched {
+			declared = declared || w.ports[target]
+		}
+		if !declared {
+			issues = append(issues, Issue{
+				File: r.File, Line: lookup(p, "targetPort").Line, Resource: r.ID(), Path: fmt.Sprintf("/spec/ports/%d/targetPort", i),
+				Severity: SeverityError, Rule: "unknown-target-port",
+				Message: fmt.Sprintf("no selected container declares a port named %s", target),
+			})
+		}
+	}
+	return issues
+}
+
+// matches reports whether labels has every key and value of selector
+func matches(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// imageTag returns the tag of an image reference, or ""
+func imageTag(image string) string {
+	name := image
+	if slash := strings.LastIndex(image, "/"); slash >= 0 {
+		name = image[slash+1:]
+	}
+	_, tag, _ := strings.Cut(name, ":")
+	return tag
+}
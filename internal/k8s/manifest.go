@@ -0,0 +1,242 @@
+// Package k8s checks Kubernetes manifests: against their schemas with
+// kubeconform when it is installed, with built-in checks of common mistakes,
+// and against a cluster with kubectl diff
+package k8s
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of an issue
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Issue is a problem found in a manifest
+type Issue struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+	// Resource is Kind/name of the resource, when the issue is about one
+	Resource string `json:"resource,omitempty"`
+	// Path is the field the issue is about, e.g. /spec/replicas
+	Path     string `json:"path,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// String formats the issue as file:line: severity: resource: message (rule)
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, i.Line)
+	}
+	message := i.Message
+	if i.Path != "" {
+		message = i.Path + ": " + message
+	}
+	if i.Resource != "" {
+		message = i.Resource + ": " + message
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", location, i.Severity, message, i.Rule)
+}
+
+// Resource is one object of a manifest
+type Resource struct {
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+
+	node *yaml.Node
+}
+
+// ID returns Kind/name
+func (r Resource) ID() string {
+	return r.Kind + "/" + r.Name
+}
+
+// manifestPattern matches the files Load reads from directories
+var manifestPattern = regexp.MustCompile(`\.(ya?ml|json)$`)
+
+// ManifestFiles returns the manifest files among paths: files are kept,
+// directories are searched for .yaml, .yml and .json files, skipping hidden
+// directories. Paths are resolved against dir; the files are returned
+// relative to it when they are inside.
+func ManifestFiles(dir string, paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		full := path
+		if !filepath.IsAbs(path) {
+			full = filepath.Join(dir, path)
+		}
+		info, err := os.Stat(full)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifests: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, relative(dir, full))
+			continue
+		}
+		err = filepath.WalkDir(full, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != full && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && manifestPattern.MatchString(d.Name()) {
+				files = append(files, relative(dir, p))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifests: %w", err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func relative(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// Parse reads the resources of a manifest file with one or more YAML
+// documents. Documents that are not Kubernetes objects (neither apiVersion
+// nor kind) are skipped; List objects are expanded. Problems that keep a
+// document from being read are returned as issues.
+func Parse(file string, data []byte) ([]Resource, []Issue) {
+	var resources []Resource
+	var issues []Issue
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			issues = append(issues, Issue{File: file, Severity: SeverityError, Rule: "invalid-yaml", Message: err.Error()})
+			break
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		if root.Kind == yaml.ScalarNode && root.Value == "" {
+			continue
+		}
+		if root.Kind != yaml.MappingNode {
+			issues = append(issues, Issue{File: file, Line: root.Line, Severity: SeverityError, Rule: "invalid-object", Message: "the document is not a mapping"})
+			continue
+		}
+		found, problems := objects(file, root)
+		resources = append(resources, found...)
+		issues = append(issues, problems...)
+	}
+	return resources, issues
+}
+
+// objects returns the resource of an object node, or the items of a List
+func objects(file string, node *yaml.Node) ([]Resource, []Issue) {
+	r := Resource{
+		File:       file,
+		Line:       node.Line,
+		APIVersion: scalar(node, "apiVersion"),
+		Kind:       scalar(node, "kind"),
+		Name:       scalar(node, "metadata", "name"),
+		Namespace:  scalar(node, "metadata", "namespace"),
+		node:       node,
+	}
+	if r.APIVersion == "" && r.Kind == "" {
+		return nil, nil
+	}
+
+	if strings.HasSuffix(r.Kind, "List") {
+		var resources []Resource
+		var issues []Issue
+		if items := lookup(node, "items"); items != nil {
+			for _, item := range items.Content {
+				if item.Kind == yaml.MappingNode {
+					found, problems := objects(file, item)
+					resources = append(resources, found...)
+					issues = append(issues, problems...)
+				}
+			}
+		}
+		return resources, issues
+	}
+
+	var issues []Issue
+	missing := func(field string) {
+		issues = append(issues, Issue{File: file, Line: node.Line, Resource: r.ID(), Severity: SeverityError, Rule: "missing-field", Message: field + " is required"})
+	}
+	if r.APIVersion == "" {
+		missing("apiVersion")
+	}
+	if r.Kind == "" {
+		missing("kind")
+	}
+	if r.Name == "" && scalar(node, "metadata", "generateName") == "" {
+		missing("metadata.name")
+	}
+	return []Resource{r}, issues
+}
+
+// lookup returns the node at the path of mapping keys, or nil
+func lookup(node *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// scalar returns the value of the scalar at the path, or ""
+func scalar(node *yaml.Node, path ...string) string {
+	if n := lookup(node, path...); n != nil && n.Kind == yaml.ScalarNode {
+		return n.Value
+	}
+	return ""
+}
+
+// stringMap returns the string values of the mapping at the path
+func stringMap(node *yaml.Node, path ...string) map[string]string {
+	n := lookup(node, path...)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	values := make(map[string]string, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		values[n.Content[i].Value] = n.Content[i+1].Value
+	}
+	return values
+}
@@ -0,0 +1,285 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrKubectlUnavailable is returned by Diff when kubectl is not installed
+var ErrKubectlUnavailable = errors.New("kubectl is not installed")
+
+// Validator checks manifests with kubeconform, when it is installed, and
+// with the built-in checks of Lint
+type Validator struct {
+	kubeconform     string
+	version         string
+	schemaLocations []string
+}
+
+// Option configures a Validator
+type Option func(*Validator)
+
+// WithKubernetesVersion sets the Kubernetes version whose schemas are used
+func WithKubernetesVersion(version string) Option {
+	return func(v *Validator) {
+		v.version = strings.TrimPrefix(version, "v")
+	}
+}
+
+// WithSchemaLocations adds kubeconform schema locations, e.g. for custom
+// resources
+func WithSchemaLocations(locations ...string) Option {
+	return func(v *Validator) {
+		v.schemaLocations = append(v.schemaLocations, locations...)
+	}
+}
+
+// WithKubeconform sets the kubeconform executable
+func WithKubeconform(command string) Option {
+	return func(v *Validator) {
+		v.kubeconform = command
+	}
+}
+
+// NewValidator creates a new Validator
+func NewValidator(opts ...Option) *Validator {
+	v := &Validator{kubeconform: "kubeconform"}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// SchemaValidation reports whether kubeconform is installed, so manifests
+// are checked against their schemas
+func (v *Validator) SchemaValidation() bool {
+	_, err := exec.LookPath(v.kubeconform)
+	return err == nil
+}
+
+// Result is the outcome of validating manifests
+type Result struct {
+	Resources []Resource `json:"resources"`
+	Issues    []Issue    `json:"issues"`
+	// SchemaChecked is set when kubeconform checked the resources
+	SchemaChecked bool `json:"schema_checked"`
+	// NoSchema lists the resources kubeconform found no schema for
+	NoSchema []string `json:"no_schema,omitempty"`
+}
+
+// Valid reports whether no issue is an error
+func (r *Result) Valid() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Counts describes the number of issues of each severity
+func (r *Result) Counts() string {
+	counts := make(map[string]int)
+	for _, issue := range r.Issues {
+		counts[issue.Severity]++
+	}
+	return fmt.Sprintf("%d resources: %d errors, %d warnings, %d notes", len(r.Resources), counts[SeverityError], counts[SeverityWarning], counts[SeverityInfo])
+}
+
+// Validate checks the manifests at paths, files or directories resolved
+// against dir. Issues come sorted by file and line.
+func (v *Validator) Validate(ctx context.Context, dir string, paths []string) (*Result, error) {
+	files, err := ManifestFiles(dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no manifests found in %s", strings.Join(paths, ", "))
+	}
+
+	result := &Result{}
+	for _, file := range files {
+		data, err := os.ReadFile(resolve(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		resources, issues := Parse(file, data)
+		result.Resources = append(result.Resources, resources...)
+		result.Issues = append(result.Issues, issues...)
+	}
+	result.Issues = append(result.Issues, Lint(result.Resources)...)
+
+	if v.SchemaValidation() {
+		issues, noSchema, err := v.kubeconformIssues(ctx, dir, files, result.Resources)
+		if err != nil {
+			return nil, err
+		}
+		result.SchemaChecked = true
+		result.Issues = append(result.Issues, issues...)
+		result.NoSchema = noSchema
+	}
+
+	sort.SliceStable(result.Issues, func(i, j int) bool {
+		a, b := result.Issues[i], result.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return result, nil
+}
+
+// kubeconformReport is the JSON output of kubeconform -output json
+type kubeconformReport struct {
+	Resources []struct {
+		Filename         string `json:"filename"`
+		Kind             string `json:"kind"`
+		Name             string `json:"name"`
+		Version          string `json:"version"`
+		Status           string `json:"status"`
+		Msg              string `json:"msg"`
+		ValidationErrors []struct {
+			Path string `json:"path"`
+			Msg  string `json:"msg"`
+		} `json:"validationErrors"`
+	} `json:"resources"`
+}
+
+// kubeconformIssues runs kubeconform on the files. It exits with 1 when a
+// resource is invalid, so the exit code only counts as a failure when the
+// output is not a report.
+func (v *Validator) kubeconformIssues(ctx context.Context, dir string, files []string, resources []Resource) ([]Issue, []string, error) {
+	args := []string{"-output", "json", "-verbose", "-strict", "-ignore-missing-schemas"}
+	if v.version != "" {
+		args = append(args, "-kubernetes-version", v.version)
+	}
+	for _, location := range v.schemaLocations {
+		args = append(args, "-schema-location", location)
+	}
+	args = append(args, files...)
+
+	cmd := exec.CommandContext(ctx, v.kubeconform, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var report kubeconformReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		if runErr != nil {
+			message := strings.TrimSpace(stderr.String())
+			if message == "" {
+				message = runErr.Error()
+			}
+			return nil, nil, fmt.Errorf("kubeconform failed: %s", message)
+		}
+		return nil, nil, fmt.Errorf("failed to parse kubeconform output: %w", err)
+	}
+
+	lines := make(map[string]int, len(resources))
+	for _, r := range resources {
+		lines[r.File+"|"+r.ID()] = r.Line
+	}
+	var issues []Issue
+	var noSchema []string
+	for _, r := range report.Resources {
+		file := filepath.ToSlash(r.Filename)
+		id := r.Kind + "/" + r.Name
+		issue := Issue{File: file, Line: lines[file+"|"+id], Resource: id, Severity: SeverityError}
+		switch r.Status {
+		case "statusInvalid":
+			issue.Rule = "schema"
+			if len(r.ValidationErrors) == 0 {
+				issue.Message = r.Msg
+				issues = append(issues, issue)
+			}
+			for _, e := range r.ValidationErrors {
+				issue.Path, issue.Message = e.Path, e.Msg
+				issues = append(issues, issue)
+			}
+		case "statusError":
+			issue.Rule = "schema-error"
+			issue.Message = r.Msg
+			issues = append(issues, issue)
+		case "statusSkipped":
+			noSchema = append(noSchema, id)
+		}
+	}
+	return issues, noSchema, nil
+}
+
+// Kubectl runs kubectl against the cluster of the current context
+type Kubectl struct {
+	// Command is the kubectl executable
+	Command string
+}
+
+// NewKubectl creates a new Kubectl running kubectl from the PATH
+func NewKubectl() *Kubectl {
+	return &Kubectl{Command: "kubectl"}
+}
+
+// Available reports whether kubectl is installed
+func (k *Kubectl) Available() bool {
+	_, err := exec.LookPath(k.Command)
+	return err == nil
+}
+
+// DiffResult is the outcome of kubectl diff
+type DiffResult struct {
+	// Changed is set when applying the manifests would change the cluster
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff"`
+}
+
+// Diff compares the manifests at paths, resolved against dir, with the
+// cluster. It only reads the cluster: kubectl diff uses a server-side dry
+// run and applies nothing.
+func (k *Kubectl) Diff(ctx context.Context, dir string, paths []string, namespace string) (*DiffResult, error) {
+	args := []string{"diff", "-R"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	for _, path := range paths {
+		args = append(args, "-f", path)
+	}
+
+	cmd := exec.CommandContext(ctx, k.Command, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	// kubectl diff exits with 1 when there are differences
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return &DiffResult{}, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return &DiffResult{Changed: true, Diff: stdout.String()}, nil
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist):
+		return nil, ErrKubectlUnavailable
+	}
+	message := strings.TrimSpace(stderr.String())
+	if message == "" {
+		message = err.Error()
+	}
+	return nil, fmt.Errorf("kubectl diff failed: %s", message)
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, filepath.FromSlash(path))
+}
@@ -0,0 +1,86 @@
+//go:build !windows
+
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCommand writes a shell script recording its arguments to args
+func fakeCommand(t *testing.T, name, body string) (command, args string) {
+	t.Helper()
+	bin := t.TempDir()
+	args = filepath.Join(bin, "args")
+	command = filepath.Join(bin, name)
+	script := "#!/bin/sh\necho \"$@\" > " + args + "\n" + body
+	require.NoError(t, os.WriteFile(command, []byte(script), 0755))
+	return command, args
+}
+
+func TestValidateKubeconform(t *testing.T) {
+	dir := writeManifests(t)
+	kubeconform, args := fakeCommand(t, "kubeconform", `cat <<'EOF'
+{"resources": [
+  {"filename": "deploy/web.yaml", "kind": "Deployment", "name": "web", "version": "apps/v1", "status": "statusInvalid",
+   "msg": "problem validating schema", "validationErrors": [{"path": "/spec/replicas", "msg": "expected integer, but got string"}]},
+  {"filename": "deploy/web.yaml", "kind": "Service", "name": "web", "version": "v1", "status": "statusValid"},
+  {"filename": "deploy/service.yml", "kind": "Widget", "name": "w", "version": "example.com/v1", "status": "statusSkipped"}
+]}
+EOF
+exit 1
+`)
+	v := NewValidator(WithKubeconform(kubeconform), WithKubernetesVersion("v1.29.0"), WithSchemaLocations("default", "crds/"))
+	require.True(t, v.SchemaValidation())
+
+	result, err := v.Validate(context.Background(), dir, []string{"deploy/web.yaml"})
+	require.NoError(t, err)
+	assert.True(t, result.SchemaChecked)
+	assert.Equal(t, []string{"Widget/w"}, result.NoSchema)
+	assert.Contains(t, result.Issues, Issue{
+		File: "deploy/web.yaml", Line: 1, Resource: "Deployment/web", Path: "/spec/replicas",
+		Severity: SeverityError, Rule: "schema", Message: "expected integer, but got string",
+	})
+
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Equal(t, "-output json -verbose -strict -ignore-missing-schemas -kubernetes-version 1.29.0 -schema-location default -schema-location crds/ deploy/web.yaml\n", string(data))
+
+	broken, _ := fakeCommand(t, "kubeconform", "echo 'open schema: permission denied' >&2\nexit 2\n")
+	_, err = NewValidator(WithKubeconform(broken)).Validate(context.Background(), dir, []string{"deploy"})
+	assert.EqualError(t, err, "kubeconform failed: open schema: permission denied")
+}
+
+func TestKubectlDiff(t *testing.T) {
+	dir := t.TempDir()
+	command, args := fakeCommand(t, "kubectl", `case "$*" in
+*-n\ prod*) echo "-  replicas: 2"; echo "+  replicas: 3"; exit 1;;
+*-n\ broken*) echo "error: You must be logged in to the server (Unauthorized)" >&2; exit 2;;
+esac
+`)
+	kubectl := &Kubectl{Command: command}
+	require.True(t, kubectl.Available())
+	ctx := context.Background()
+
+	result, err := kubectl.Diff(ctx, dir, []string{"deploy/"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, &DiffResult{}, result)
+
+	result, err = kubectl.Diff(ctx, dir, []string{"deploy/", "extra.yaml"}, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, &DiffResult{Changed: true, Diff: "-  replicas: 2\n+  replicas: 3\n"}, result)
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Equal(t, "diff -R -n prod -f deploy/ -f extra.yaml\n", string(data))
+
+	_, err = kubectl.Diff(ctx, dir, []string{"deploy/"}, "broken")
+	assert.EqualError(t, err, "kubectl diff failed: error: You must be logged in to the server (Unauthorized)")
+
+	_, err = (&Kubectl{Command: filepath.Join(dir, "missing")}).Diff(ctx, dir, []string{"deploy/"}, "")
+	assert.ErrorIs(t, err, ErrKubectlUnavailable)
+}
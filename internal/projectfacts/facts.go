@@ -0,0 +1,325 @@
+// Package projectfacts collects cheap facts about a workspace (languages,
+// module, scripts, remotes, README) for the system prompt.
+package projectfacts
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxFiles bounds the walk that counts files per language
+	maxFiles = 5000
+	// maxLanguages is how many languages are listed
+	maxLanguages = 5
+	// maxScripts is how many package.json scripts are listed
+	maxScripts = 10
+	// maxSummaryLength bounds the README summary
+	maxSummaryLength = 300
+)
+
+// skipDirs are never walked
+var skipDirs = map[string]bool{
+	".git":         true,
+	".coda":        true,
+	"vendor":       true,
+	"node_modules": true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+}
+
+// languages maps file extensions to language names
+var languages = map[string]string{
+	".go":    "Go",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".php":   "PHP",
+	".sh":    "Shell",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".vue":   "Vue",
+	".proto": "Protocol Buffers",
+}
+
+// Language is the number of source files of a language
+type Language struct {
+	Name  string
+	Files int
+}
+
+// Remote is a git remote
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// Facts describes a workspace
+type Facts struct {
+	Languages  []Language
+	GoModule   string
+	GoVersion  string
+	NPMPackage string
+	NPMScripts []string
+	Remotes    []Remote
+	Readme     string
+}
+
+// Collect gathers the facts of the workspace at root. Missing files are
+// skipped; nothing is run.
+func Collect(root string) Facts {
+	var f Facts
+	f.Languages = countLanguages(root)
+	f.GoModule, f.GoVersion = readGoMod(filepath.Join(root, "go.mod"))
+	f.NPMPackage, f.NPMScripts = readPackageJSON(filepath.Join(root, "package.json"))
+	f.Remotes = readRemotes(filepath.Join(root, ".git", "config"))
+	f.Readme = readmeSummary(root)
+	return f
+}
+
+// IsEmpty reports whether nothing was found
+func (f Facts) IsEmpty() bool {
+	return len(f.Languages) == 0 && f.GoModule == "" && f.NPMPackage == "" &&
+		len(f.NPMScripts) == 0 && len(f.Remotes) == 0 && f.Readme == ""
+}
+
+// Render formats the facts as a compact prompt section, or "" when empty
+func (f Facts) Render() string {
+	if f.IsEmpty() {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Project Facts\n")
+	b.WriteString("Collected from the workspace at startup; use tools for anything more.\n")
+	if len(f.Languages) > 0 {
+		parts := make([]string, len(f.Languages))
+		for i, lang := range f.Languages {
+			parts[i] = fmt.Sprintf("%s (%d files)", lang.Name, lang.Files)
+		}
+		fmt.Fprintf(&b, "- Languages: %s\n", strings.Join(parts, ", "))
+	}
+	if f.GoModule != "" {
+		fmt.Fprintf(&b, "- Go module: %s", f.GoModule)
+		if f.GoVersion != "" {
+			fmt.Fprintf(&b, " (go %s)", f.GoVersion)
+		}
+		b.WriteString("\n")
+	}
+	if f.NPMPackage != "" {
+		fmt.Fprintf(&b, "- npm package: %s\n", f.NPMPackage)
+	}
+	if len(f.NPMScripts) > 0 {
+		fmt.Fprintf(&b, "- npm scripts: %s\n", strings.Join(f.NPMScripts, ", "))
+	}
+	for _, remote := range f.Remotes {
+		fmt.Fprintf(&b, "- git remote %s: %s\n", remote.Name, remote.URL)
+	}
+	if f.Readme != "" {
+		fmt.Fprintf(&b, "- README: %s\n", f.Readme)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// countLanguages counts source files by language, most files first
+func countLanguages(root string) []Language {
+	counts := make(map[string]int)
+	seen := 0
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if seen++; seen > maxFiles {
+			return filepath.SkipAll
+		}
+		if name, ok := languages[strings.ToLower(filepath.Ext(d.Name()))]; ok {
+			counts[name]++
+		}
+		return nil
+	})
+
+	result := make([]Language, 0, len(counts))
+	for name, n := range counts {
+		result = append(result, Language{Name: name, Files: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Files != result[j].Files {
+			return result[i].Files > result[j].Files
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > maxLanguages {
+		result = result[:maxLanguages]
+	}
+	return result
+}
+
+// readGoMod returns the module path and go version of a go.mod file
+func readGoMod(path string) (module, version string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "module":
+			module = strings.Trim(fields[1], `"`)
+		case "go":
+			version = fields[1]
+		}
+	}
+	return module, version
+}
+
+// readPackageJSON returns the name and script names of a package.json file
+func readPackageJSON(path string) (name string, scripts []string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	var pkg struct {
+		Name    string            `json:"name"`
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return "", nil
+	}
+	for script := range pkg.Scripts {
+		scripts = append(scripts, script)
+	}
+	sort.Strings(scripts)
+	if len(scripts) > maxScripts {
+		scripts = append(scripts[:maxScripts], "…")
+	}
+	return pkg.Name, scripts
+}
+
+// readRemotes reads the remotes of a git config file without running git
+func readRemotes(path string) []Remote {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var remotes []Remote
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			current = ""
+			if rest, ok := strings.CutPrefix(line, `[remote "`); ok {
+				current = strings.TrimSuffix(rest, `"]`)
+			}
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "url" {
+			remotes = append(remotes, Remote{Name: current, URL: stripCredentials(strings.TrimSpace(value))})
+		}
+	}
+	return remotes
+}
+
+// stripCredentials removes a user:password@ part from a remote URL
+func stripCredentials(url string) string {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok {
+		return url
+	}
+	host, path, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	if path == "" {
+		return scheme + "://" + host
+	}
+	return scheme + "://" + host + "/" + path
+}
+
+// readmeSummary returns the first paragraph of prose in the README
+func readmeSummary(root string) string {
+	var content []byte
+	for _, name := range []string{"README.md", "README", "README.txt", "readme.md", "README.rst"} {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err == nil {
+			content = data
+			break
+		}
+	}
+	if content == nil {
+		return ""
+	}
+
+	var paragraph []string
+	inFence := false
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if trimmed == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		// Headings, badges, images, HTML and rules are not prose
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "[![") ||
+			strings.HasPrefix(trimmed, "![") || strings.HasPrefix(trimmed, "<") ||
+			strings.HasPrefix(trimmed, "===") || strings.HasPrefix(trimmed, "---") {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+
+	summary := strings.Join(paragraph, " ")
+	if runes := []rune(summary); len(runes) > maxSummaryLength {
+		summary = string(runes[:maxSummaryLength]) + "…"
+	}
+	return summary
+}
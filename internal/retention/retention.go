@@ -0,0 +1,205 @@
+// Package retention removes old sessions, artifacts and logs according to a
+// maximum age and total size per category.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Categories of stored data
+const (
+	CategorySessions  = "sessions"
+	CategoryArtifacts = "artifacts"
+	CategoryLogs      = "logs"
+)
+
+// Categories lists the categories in report order
+var Categories = []string{CategorySessions, CategoryArtifacts, CategoryLogs}
+
+// Policy limits one category. Zero values mean no limit.
+type Policy struct {
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+// IsZero reports whether the policy keeps everything
+func (p Policy) IsZero() bool {
+	return p.MaxAge <= 0 && p.MaxSize <= 0
+}
+
+// Item is removed as a whole, e.g. a session with its metadata or the
+// artifacts directory of a session
+type Item struct {
+	Name    string
+	Paths   []string
+	Size    int64
+	ModTime time.Time
+}
+
+// Source lists the removable items of a category and removes them. Items
+// in use, such as open or sealed sessions, are not listed.
+type Source interface {
+	Category() string
+	Items() ([]Item, error)
+	Remove(item Item) error
+}
+
+// Select returns the items the policy removes at now: those older than
+// MaxAge, then the oldest ones until the rest fits in MaxSize
+func Select(items []Item, policy Policy, now time.Time) []Item {
+	if policy.IsZero() {
+		return nil
+	}
+
+	sorted := make([]Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ModTime.Before(sorted[j].ModTime)
+	})
+
+	var total int64
+	for _, item := range sorted {
+		total += item.Size
+	}
+
+	var selected []Item
+	for _, item := range sorted {
+		expired := policy.MaxAge > 0 && now.Sub(item.ModTime) > policy.MaxAge
+		oversize := policy.MaxSize > 0 && total > policy.MaxSize
+		if !expired && !oversize {
+			break
+		}
+		selected = append(selected, item)
+		total -= item.Size
+	}
+	return selected
+}
+
+// Result is what a run did in one category
+type Result struct {
+	Category string
+	Removed  []Item
+	Freed    int64
+	Kept     int
+	KeptSize int64
+	Errors   []error
+}
+
+// Report is the outcome of a run
+type Report struct {
+	DryRun  bool
+	Results []Result
+}
+
+// Freed returns the bytes reclaimed in all categories
+func (r *Report) Freed() int64 {
+	var freed int64
+	for _, result := range r.Results {
+		freed += result.Freed
+	}
+	return freed
+}
+
+// Janitor enforces the policies over its sources
+type Janitor struct {
+	sources  []Source
+	policies map[string]Policy
+	now      func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewJanitor creates a janitor applying policies by category to sources
+func NewJanitor(policies map[string]Policy, sources ...Source) *Janitor {
+	return &Janitor{sources: sources, policies: policies, now: time.Now}
+}
+
+// Run removes the items selected by the policies, or only reports them when
+// dryRun is set. Failing to list or remove an item is recorded in its
+// category's result and does not stop the run.
+func (j *Janitor) Run(dryRun bool) *Report {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	report := &Report{DryRun: dryRun}
+	now := j.now()
+	for _, source := range j.sources {
+		result := Result{Category: source.Category()}
+		items, err := source.Items()
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		removed := make(map[string]bool)
+		for _, item := range Select(items, j.policies[source.Category()], now) {
+			if !dryRun {
+				if err := source.Remove(item); err != nil {
+					result.Errors = append(result.Errors, fmt.Errorf("%s: %w", item.Name, err))
+					continue
+				}
+			}
+			removed[item.Name] = true
+			result.Removed = append(result.Removed, item)
+			result.Freed += item.Size
+		}
+		for _, item := range items {
+			if !removed[item.Name] {
+				result.Kept++
+				result.KeptSize += item.Size
+			}
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// Start runs the janitor now and then every interval until ctx is done
+func (j *Janitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		j.Run(false)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(false)
+			}
+		}
+	}()
+}
+
+// RemovePaths removes the paths of an item, ignoring those already gone
+func RemovePaths(item Item) error {
+	for _, path := range item.Paths {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// FormatSize formats a byte count for reports
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
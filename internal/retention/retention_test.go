@@ -0,0 +1,105 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	items := []Item{
+		{Name: "new", Size: 100, ModTime: now.Add(-1 * day)},
+		{Name: "old", Size: 100, ModTime: now.Add(-40 * day)},
+		{Name: "middle", Size: 300, ModTime: now.Add(-10 * day)},
+	}
+
+	names := func(items []Item) []string {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{name: "no limits", policy: Policy{}},
+		{name: "max age", policy: Policy{MaxAge: 30 * day}, want: []string{"old"}},
+		{name: "max size removes the oldest first", policy: Policy{MaxSize: 200}, want: []string{"old", "middle"}},
+		{name: "both", policy: Policy{MaxAge: 5 * day, MaxSize: 1000}, want: []string{"old", "middle"}},
+		{name: "within limits", policy: Policy{MaxAge: 60 * day, MaxSize: 500}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, names(Select(items, tt.policy, now)))
+		})
+	}
+}
+
+// writeAged writes a file last modified age ago
+func writeAged(t *testing.T, path string, size int, age time.Duration) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+	mtime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	require.NoError(t, os.Chtimes(filepath.Dir(path), mtime, mtime))
+}
+
+func TestJanitorRun(t *testing.T) {
+	dir := t.TempDir()
+	day := 24 * time.Hour
+
+	artifactsDir := filepath.Join(dir, "artifacts")
+	writeAged(t, filepath.Join(artifactsDir, "old-session", "report.md"), 2048, 40*day)
+	writeAged(t, filepath.Join(artifactsDir, "new-session", "report.md"), 1024, day)
+
+	journalDir := filepath.Join(dir, "journal")
+	writeAged(t, filepath.Join(journalDir, "2025-01-01.md"), 10, 100*day)
+	writeAged(t, filepath.Join(journalDir, "2025-06-29.md"), 10, day)
+	writeAged(t, filepath.Join(dir, "logs", "coda.2025-01-01-00-00-00.log"), 500, 100*day)
+	writeAged(t, filepath.Join(dir, "logs", "coda.log"), 500, 100*day)
+
+	policies := map[string]Policy{
+		CategoryArtifacts: {MaxAge: 30 * day},
+		CategoryLogs:      {MaxAge: 30 * day},
+	}
+	janitor := NewJanitor(policies,
+		NewDirSource(CategoryArtifacts, artifactsDir),
+		NewFileSource(CategoryLogs, filepath.Join(journalDir, "*.md"), filepath.Join(dir, "logs", "coda.*.log")),
+		NewDirSource(CategorySessions, filepath.Join(dir, "missing")),
+	)
+
+	report := janitor.Run(true)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Results, 3)
+	assert.Len(t, report.Results[0].Removed, 1)
+	assert.Equal(t, int64(2048), report.Results[0].Freed)
+	assert.DirExists(t, filepath.Join(artifactsDir, "old-session"), "a dry run removes nothing")
+
+	report = janitor.Run(false)
+	assert.Equal(t, int64(2048+10+500), report.Freed())
+	assert.Equal(t, 1, report.Results[0].Kept)
+	assert.Equal(t, int64(1024), report.Results[0].KeptSize)
+	assert.NoDirExists(t, filepath.Join(artifactsDir, "old-session"))
+	assert.DirExists(t, filepath.Join(artifactsDir, "new-session"))
+	assert.NoFileExists(t, filepath.Join(journalDir, "2025-01-01.md"))
+	assert.FileExists(t, filepath.Join(journalDir, "2025-06-29.md"))
+	assert.FileExists(t, filepath.Join(dir, "logs", "coda.log"), "the active log file is not a rotated one")
+	assert.Empty(t, report.Results[2].Removed, "a category without limits is left alone")
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KB", FormatSize(1536))
+	assert.Equal(t, "2.0 MB", FormatSize(2*1024*1024))
+}
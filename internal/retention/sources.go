@@ -0,0 +1,131 @@
+package retention
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DirSource lists each entry of a directory as an item, with the size and
+// latest modification of everything under it. Entries named in skip are
+// left alone.
+type DirSource struct {
+	category string
+	root     string
+	skip     map[string]bool
+}
+
+// NewDirSource creates a source over the entries of root
+func NewDirSource(category, root string, skip ...string) *DirSource {
+	s := &DirSource{category: category, root: root, skip: make(map[string]bool)}
+	for _, name := range skip {
+		s.skip[name] = true
+	}
+	return s
+}
+
+// Category implements Source.
+func (s *DirSource) Category() string {
+	return s.category
+}
+
+// Items implements Source.
+func (s *DirSource) Items() ([]Item, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", s.root, err)
+	}
+
+	var items []Item
+	for _, entry := range entries {
+		if s.skip[entry.Name()] {
+			continue
+		}
+		path := filepath.Join(s.root, entry.Name())
+		item, err := statTree(path)
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Remove implements Source.
+func (s *DirSource) Remove(item Item) error {
+	return RemovePaths(item)
+}
+
+// FileSource lists the files matching glob patterns as items
+type FileSource struct {
+	category string
+	patterns []string
+}
+
+// NewFileSource creates a source over the files matching patterns
+func NewFileSource(category string, patterns ...string) *FileSource {
+	return &FileSource{category: category, patterns: patterns}
+}
+
+// Category implements Source.
+func (s *FileSource) Category() string {
+	return s.category
+}
+
+// Items implements Source.
+func (s *FileSource) Items() ([]Item, error) {
+	seen := make(map[string]bool)
+	var items []Item
+	for _, pattern := range s.patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			items = append(items, Item{Name: path, Paths: []string{path}, Size: info.Size(), ModTime: info.ModTime()})
+		}
+	}
+	return items, nil
+}
+
+// Remove implements Source.
+func (s *FileSource) Remove(item Item) error {
+	return RemovePaths(item)
+}
+
+// statTree returns an item for path with the total size and latest
+// modification of the files under it
+func statTree(path string) (Item, error) {
+	item := Item{Name: path, Paths: []string{path}}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			item.Size += info.Size()
+		}
+		if info.ModTime().After(item.ModTime) {
+			item.ModTime = info.ModTime()
+		}
+		return nil
+	})
+	return item, err
+}
@@ -0,0 +1,206 @@
+// Package review implements AI code review of unified diffs.
+package review
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FileDiff is the portion of a unified diff that touches one file
+type FileDiff struct {
+	// Path of the file after the change (the old path for deletions)
+	Path string
+
+	// Header contains the "diff --git", index and ---/+++ lines
+	Header string
+
+	// Hunks in the order they appear
+	Hunks []Hunk
+}
+
+// Hunk is a single @@ section of a file diff
+type Hunk struct {
+	// NewStart is the first line number of the hunk in the new file
+	NewStart int
+
+	// Text is the hunk including its @@ header line
+	Text string
+}
+
+// String reassembles the file diff
+func (f FileDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(f.Header)
+	for _, h := range f.Hunks {
+		sb.WriteString(h.Text)
+	}
+	return sb.String()
+}
+
+// ParseDiff splits a unified diff (git or plain diff -u output) into files and hunks
+func ParseDiff(diff string) []FileDiff {
+	var (
+		files   []FileDiff
+		current *FileDiff
+		hunk    *strings.Builder
+		start   int
+	)
+
+	flushHunk := func() {
+		if current != nil && hunk != nil {
+			current.Hunks = append(current.Hunks, Hunk{NewStart: start, Text: hunk.String()})
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			files = append(files, *current)
+		}
+		current = nil
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		nextIsNewMarker := i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			current = &FileDiff{Path: pathFromGitHeader(line), Header: line}
+
+		case strings.HasPrefix(line, "--- ") && nextIsNewMarker && (current == nil || hunk != nil || len(current.Hunks) > 0):
+			// Plain "diff -u" output has no "diff --git" line; a ---/+++ pair starts a file
+			flushFile()
+			current = &FileDiff{Header: line, Path: pathFromMarker(line)}
+
+		case hunk == nil && current != nil && (strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ")):
+			current.Header += line
+			if p := pathFromMarker(line); p != "" && (current.Path == "" || strings.HasPrefix(line, "+++ ")) {
+				current.Path = p
+			}
+
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				current = &FileDiff{Path: "(unknown)"}
+			}
+			flushHunk()
+			hunk = &strings.Builder{}
+			start = parseHunkStart(line)
+			hunk.WriteString(line)
+
+		case hunk != nil:
+			hunk.WriteString(line)
+
+		case current != nil:
+			current.Header += line
+		}
+	}
+	flushFile()
+
+	return files
+}
+
+// pathFromGitHeader extracts the new path from "diff --git a/x b/x"
+func pathFromGitHeader(line string) string {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "diff --git "))
+	if i := strings.LastIndex(line, " b/"); i >= 0 {
+		return line[i+3:]
+	}
+	return line
+}
+
+// pathFromMarker extracts the path from a "--- a/x" or "+++ b/x" line
+func pathFromMarker(line string) string {
+	path := strings.TrimSpace(line[4:])
+	if i := strings.IndexByte(path, '\t'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// parseHunkStart returns the new-file start line of "@@ -a,b +c,d @@"
+func parseHunkStart(line string) int {
+	fields := strings.Fields(line)
+	for _, f := range fields {
+		if strings.HasPrefix(f, "+") {
+			f = strings.TrimPrefix(f, "+")
+			if i := strings.IndexByte(f, ','); i >= 0 {
+				f = f[:i]
+			}
+			if n, err := strconv.Atoi(f); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// Chunk is a part of a diff sized to fit the review token budget
+type Chunk struct {
+	Files  []string
+	Text   string
+	Tokens int
+}
+
+// ChunkDiff packs file diffs into chunks of at most budget tokens as measured
+// by count. Files larger than the budget are split at hunk boundaries (the
+// file header is repeated); a single hunk larger than the budget becomes its
+// own chunk.
+func ChunkDiff(files []FileDiff, budget int, count func(string) int) []Chunk {
+	var (
+		chunks  []Chunk
+		current Chunk
+	)
+
+	flush := func() {
+		if current.Text != "" {
+			chunks = append(chunks, current)
+		}
+		current = Chunk{}
+	}
+	add := func(path, text string, tokens int) {
+		if current.Tokens+tokens > budget && current.Text != "" {
+			flush()
+		}
+		current.Text += text
+		current.Tokens += tokens
+		if len(current.Files) == 0 || current.Files[len(current.Files)-1] != path {
+			current.Files = append(current.Files, path)
+		}
+	}
+
+	for _, file := range files {
+		text := file.String()
+		if tokens := count(text); tokens <= budget || len(file.Hunks) <= 1 {
+			add(file.Path, text, tokens)
+			continue
+		}
+
+		// Split an oversized file into hunk groups, each with the file header
+		headerTokens := count(file.Header)
+		part := file.Header
+		partTokens := headerTokens
+		for _, h := range file.Hunks {
+			hunkTokens := count(h.Text)
+			if partTokens+hunkTokens > budget && part != file.Header {
+				add(file.Path, part, partTokens)
+				flush()
+				part = file.Header
+				partTokens = headerTokens
+			}
+			part += h.Text
+			partTokens += hunkTokens
+		}
+		add(file.Path, part, partTokens)
+	}
+	flush()
+
+	return chunks
+}
@@ -0,0 +1,60 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severityOrder lists severities from most to least severe
+var severityOrder = []string{SeverityCritical, SeverityWarning, SeveritySuggest, SeverityNit}
+
+// Markdown renders the review as a markdown report
+func (r *Result) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("# Code Review\n\n")
+
+	counts := r.CountBySeverity()
+	var parts []string
+	for _, severity := range severityOrder {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	if len(parts) == 0 {
+		sb.WriteString(fmt.Sprintf("Reviewed %d file(s): no findings.\n", len(r.Files)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Reviewed %d file(s): %s.\n", len(r.Files), strings.Join(parts, ", ")))
+	}
+
+	if len(r.Summary) > 0 {
+		sb.WriteString("\n## Summary\n\n")
+		for _, s := range r.Summary {
+			sb.WriteString(strings.TrimSpace(s))
+			sb.WriteString("\n\n")
+		}
+	}
+
+	for _, group := range r.ByFile() {
+		sb.WriteString(fmt.Sprintf("\n## %s\n", group.File))
+		for _, f := range group.Findings {
+			location := group.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", group.File, f.Line)
+			}
+			sb.WriteString(fmt.Sprintf("\n### [%s] %s\n\n", f.Severity, f.Title))
+			sb.WriteString(fmt.Sprintf("`%s`\n\n", location))
+			if f.Detail != "" {
+				sb.WriteString(strings.TrimSpace(f.Detail))
+				sb.WriteString("\n")
+			}
+			if f.Suggestion != "" {
+				sb.WriteString("\n**Suggestion:** ")
+				sb.WriteString(strings.TrimSpace(f.Suggestion))
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,259 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/tokenizer"
+)
+
+// Severity levels used in findings, most severe first
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeveritySuggest  = "suggestion"
+	SeverityNit      = "nit"
+)
+
+// DefaultTokenBudget is the maximum diff size sent in a single review request
+const DefaultTokenBudget = 6000
+
+// Finding is a single review comment
+type Finding struct {
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Severity   string `json:"severity"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// FileFindings groups the findings for one file, ordered by line
+type FileFindings struct {
+	File     string
+	Findings []Finding
+}
+
+// Result is the aggregated review of a diff
+type Result struct {
+	// Summary holds one overview paragraph per reviewed chunk
+	Summary []string
+
+	Findings []Finding
+
+	// Files lists every file in the diff, including files without findings
+	Files []string
+
+	Chunks int
+}
+
+// ByFile returns the findings grouped by file, files in diff order
+func (r *Result) ByFile() []FileFindings {
+	groups := make(map[string][]Finding)
+	for _, f := range r.Findings {
+		groups[f.File] = append(groups[f.File], f)
+	}
+
+	order := append([]string{}, r.Files...)
+	for file := range groups {
+		if !containsString(order, file) {
+			order = append(order, file)
+		}
+	}
+
+	var result []FileFindings
+	for _, file := range order {
+		findings := groups[file]
+		if len(findings) == 0 {
+			continue
+		}
+		sort.SliceStable(findings, func(i, j int) bool {
+			return findings[i].Line < findings[j].Line
+		})
+		result = append(result, FileFindings{File: file, Findings: findings})
+	}
+	return result
+}
+
+// CountBySeverity returns the number of findings per severity
+func (r *Result) CountBySeverity() map[string]int {
+	counts := make(map[string]int)
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+// Reviewer runs structured reviews against an AI client
+type Reviewer struct {
+	client       ai.Client
+	model        string
+	budget       int
+	instructions string
+}
+
+// Option configures a Reviewer
+type Option func(*Reviewer)
+
+// WithTokenBudget sets the per-request diff token budget
+func WithTokenBudget(budget int) Option {
+	return func(r *Reviewer) {
+		if budget > 0 {
+			r.budget = budget
+		}
+	}
+}
+
+// WithInstructions adds reviewer focus instructions (e.g. "focus on security")
+func WithInstructions(instructions string) Option {
+	return func(r *Reviewer) {
+		r.instructions = instructions
+	}
+}
+
+// NewReviewer creates a reviewer for the given model
+func NewReviewer(client ai.Client, model string, opts ...Option) *Reviewer {
+	r := &Reviewer{
+		client: client,
+		model:  model,
+		budget: DefaultTokenBudget,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Review reviews a unified diff. progress, if not nil, is called before each chunk.
+func (r *Reviewer) Review(ctx context.Context, diff string, progress func(chunk, total int, files []string)) (*Result, error) {
+	files := ParseDiff(diff)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("diff is empty")
+	}
+
+	chunks := ChunkDiff(files, r.budget, r.countTokens)
+	result := &Result{Chunks: len(chunks)}
+	for _, f := range files {
+		result.Files = append(result.Files, f.Path)
+	}
+
+	for i, chunk := range chunks {
+		if progress != nil {
+			progress(i+1, len(chunks), chunk.Files)
+		}
+
+		resp, err := r.client.ChatCompletion(ctx, ai.ChatRequest{
+			Model: r.model,
+			Messages: []ai.Message{
+				{Role: ai.RoleSystem, Content: r.systemPrompt()},
+				{Role: ai.RoleUser, Content: fmt.Sprintf("Review this diff (part %d of %d):\n\n```diff\n%s```", i+1, len(chunks), chunk.Text)},
+			},
+			ResponseFormat: &ai.ResponseFormat{Type: "json_object"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("review of part %d failed: %w", i+1, err)
+		}
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("review of part %d returned no choices", i+1)
+		}
+
+		summary, findings, err := ParseResponse(resp.Choices[0].Message.Content)
+		if err != nil {
+			return nil, fmt.Errorf("review of part %d: %w", i+1, err)
+		}
+		if summary != "" {
+			result.Summary = append(result.Summary, summary)
+		}
+		result.Findings = append(result.Findings, findings...)
+	}
+
+	return result, nil
+}
+
+func (r *Reviewer) countTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	tokens, err := tokenizer.EstimateUserMessageTokens(text, r.model)
+	if err != nil {
+		// Roughly 4 characters per token
+		return len(text) / 4
+	}
+	return tokens
+}
+
+func (r *Reviewer) systemPrompt() string {
+	prompt := `You are an experienced code reviewer. Review the unified diff you are given.
+Only comment on the changed lines and their direct impact. Report real problems
+(bugs, security issues, race conditions, error handling, API misuse, missing tests)
+before style issues, and skip praise.
+
+Respond with a JSON object of this exact shape:
+{
+  "summary": "one short paragraph describing the change and overall risk",
+  "findings": [
+    {
+      "file": "path as shown in the diff",
+      "line": <line number in the new file>,
+      "severity": "critical" | "warning" | "suggestion" | "nit",
+      "title": "one line summary",
+      "detail": "why this is a problem",
+      "suggestion": "concrete fix (optional)"
+    }
+  ]
+}
+Use an empty findings array when there is nothing to report.`
+
+	if r.instructions != "" {
+		prompt += "\n\nAdditional instructions: " + r.instructions
+	}
+	return prompt
+}
+
+// ParseResponse parses the model's JSON review output
+func ParseResponse(content string) (string, []Finding, error) {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var parsed struct {
+		Summary  string    `json:"summary"`
+		Findings []Finding `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &parsed); err != nil {
+		return "", nil, fmt.Errorf("failed to parse review response: %w", err)
+	}
+
+	for i := range parsed.Findings {
+		parsed.Findings[i].Severity = normalizeSeverity(parsed.Findings[i].Severity)
+	}
+
+	return parsed.Summary, parsed.Findings, nil
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "error", "high", "blocker":
+		return SeverityCritical
+	case "warning", "medium", "major":
+		return SeverityWarning
+	case "nit", "style", "minor":
+		return SeverityNit
+	default:
+		return SeveritySuggest
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
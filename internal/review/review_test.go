@@ -0,0 +1,147 @@
+package review
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++import "os"
+ func main() {}
+@@ -10,2 +11,3 @@ func helper() {
+ 	a := 1
++	b := 2
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "main.go", files[0].Path)
+	require.Len(t, files[0].Hunks, 2)
+	assert.Equal(t, 1, files[0].Hunks[0].NewStart)
+	assert.Equal(t, 11, files[0].Hunks[1].NewStart)
+	assert.True(t, strings.HasPrefix(files[0].Header, "diff --git"))
+
+	assert.Equal(t, "old.txt", files[1].Path)
+	require.Len(t, files[1].Hunks, 1)
+
+	// Reassembling the files yields the original diff
+	var rebuilt strings.Builder
+	for _, f := range files {
+		rebuilt.WriteString(f.String())
+	}
+	assert.Equal(t, sampleDiff, rebuilt.String())
+}
+
+func TestParseDiffPlain(t *testing.T) {
+	diff := "--- a.txt\t2024-01-01\n+++ b.txt\t2024-01-02\n@@ -1 +1 @@\n-x\n+y\n--- c.txt\n+++ c.txt\n@@ -5 +5 @@\n-1\n+2\n"
+
+	files := ParseDiff(diff)
+	require.Len(t, files, 2)
+	assert.Equal(t, "b.txt", files[0].Path)
+	assert.Equal(t, "c.txt", files[1].Path)
+	assert.Equal(t, 5, files[1].Hunks[0].NewStart)
+}
+
+func TestChunkDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	lines := func(s string) int { return strings.Count(s, "\n") }
+
+	tests := []struct {
+		name       string
+		budget     int
+		wantChunks int
+	}{
+		{name: "everything fits", budget: 100, wantChunks: 1},
+		{name: "one file per chunk", budget: 12, wantChunks: 2},
+		{name: "large file split by hunk", budget: 8, wantChunks: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := ChunkDiff(files, tt.budget, lines)
+			require.Len(t, chunks, tt.wantChunks)
+			for _, c := range chunks {
+				assert.NotEmpty(t, c.Files)
+				assert.Contains(t, c.Text, "@@")
+			}
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	summary, findings, err := ParseResponse("```json\n" + `{"summary":"Adds an import","findings":[
+		{"file":"main.go","line":2,"severity":"HIGH","title":"Unused import","detail":"os is unused"},
+		{"file":"main.go","line":12,"severity":"style","title":"Name"}]}` + "\n```")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Adds an import", summary)
+	require.Len(t, findings, 2)
+	assert.Equal(t, SeverityCritical, findings[0].Severity)
+	assert.Equal(t, SeverityNit, findings[1].Severity)
+
+	_, _, err = ParseResponse("not json")
+	assert.Error(t, err)
+}
+
+// scriptedClient returns canned chat responses in order
+type scriptedClient struct {
+	ai.DummyClient
+	responses []string
+	requests  []ai.ChatRequest
+}
+
+func (c *scriptedClient) ChatCompletion(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	c.requests = append(c.requests, req)
+	content := c.responses[0]
+	c.responses = c.responses[1:]
+	return &ai.ChatResponse{Choices: []ai.Choice{{Message: ai.Message{Role: ai.RoleAssistant, Content: content}}}}, nil
+}
+
+func TestReviewerReview(t *testing.T) {
+	client := &scriptedClient{responses: []string{
+		`{"summary":"part one","findings":[{"file":"main.go","line":11,"severity":"warning","title":"b unused"},{"file":"main.go","line":2,"severity":"nit","title":"import"}]}`,
+		`{"summary":"part two","findings":[]}`,
+		`{"summary":"part three","findings":[]}`,
+	}}
+
+	reviewer := NewReviewer(client, "gpt-4o", WithTokenBudget(1))
+	var progress []int
+	result, err := reviewer.Review(context.Background(), sampleDiff, func(chunk, total int, files []string) {
+		progress = append(progress, chunk)
+	})
+	require.NoError(t, err)
+
+	// Every hunk exceeds the budget, so each becomes its own chunk
+	assert.Equal(t, 3, result.Chunks)
+	assert.Equal(t, []int{1, 2, 3}, progress)
+	assert.Equal(t, []string{"part one", "part two", "part three"}, result.Summary)
+	assert.Equal(t, "json_object", client.requests[0].ResponseFormat.Type)
+
+	groups := result.ByFile()
+	require.Len(t, groups, 1)
+	assert.Equal(t, 2, groups[0].Findings[0].Line)
+	assert.Equal(t, 11, groups[0].Findings[1].Line)
+
+	report := result.Markdown()
+	assert.Contains(t, report, "1 warning, 1 nit")
+	assert.Contains(t, report, "`main.go:11`")
+}
@@ -0,0 +1,190 @@
+package security
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+// RuleMatch is the approval rule that decided a tool call
+type RuleMatch struct {
+	Rule   string // Rule name ("rule N" when unnamed)
+	Action string // config.PermitApprove or config.PermitDeny
+}
+
+// Approved reports whether the rule approves the call
+func (m *RuleMatch) Approved() bool {
+	return m.Action == config.PermitApprove
+}
+
+// ApprovalRules answers tool call permission prompts with the configured
+// rules. The first matching rule decides; calls no rule matches are asked.
+type ApprovalRules struct {
+	rules []approvalRule
+	audit *AuditLogger
+}
+
+// KubectlApplyRule denies run_command calls that change a Kubernetes
+// cluster. ConfiguredApprovalRules puts it before the configured rules
+// unless tools.kubernetes.allow_apply is set.
+var KubectlApplyRule = config.ApprovalRule{
+	Name:   "kubectl-apply",
+	Tool:   "run_command",
+	Args:   map[string]string{"command": `\bkubectl\s+([^;&|]*\s)?(apply|create|replace|patch|delete|edit|scale|label|annotate|set|rollout\s+(restart|undo))\b`},
+	Action: config.PermitDeny,
+}
+
+// approvalRule is a config.ApprovalRule with its patterns compiled
+type approvalRule struct {
+	config.ApprovalRule
+	name  string
+	args  map[string]*regexp.Regexp
+	paths []string
+}
+
+// NewApprovalRules compiles the rules. Matches are written to audit, which
+// may be nil.
+func NewApprovalRules(rules []config.ApprovalRule, audit *AuditLogger) (*ApprovalRules, error) {
+	compiled := make([]approvalRule, 0, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid permit rule %d: %w", i+1, err)
+		}
+
+		c := approvalRule{
+			ApprovalRule: rule,
+			name:         rule.Name,
+			args:         make(map[string]*regexp.Regexp, len(rule.Args)),
+		}
+		if c.name == "" {
+			c.name = fmt.Sprintf("rule %d", i+1)
+		}
+		for arg, pattern := range rule.Args {
+			c.args[arg] = regexp.MustCompile(pattern)
+		}
+		for _, path := range rule.Paths {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path in permit rule %s: %w", c.name, err)
+			}
+			c.paths = append(c.paths, abs)
+		}
+		compiled = append(compiled, c)
+	}
+
+	return &ApprovalRules{rules: compiled, audit: audit}, nil
+}
+
+// ConfiguredApprovalRules compiles the rules of cfg, after KubectlApplyRule
+// unless kubectl changes are allowed. Every frontend checks these rules, so
+// the deny applies whether or not a person approves the calls.
+func ConfiguredApprovalRules(cfg *config.Config, audit *AuditLogger) (*ApprovalRules, error) {
+	rules := cfg.Tools.Permit.Rules
+	if !cfg.Tools.Kubernetes.AllowApply {
+		rules = append([]config.ApprovalRule{KubectlApplyRule}, rules...)
+	}
+	return NewApprovalRules(rules, audit)
+}
+
+// Len returns the number of rules
+func (r *ApprovalRules) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.rules)
+}
+
+// Evaluate returns the first rule matching the call, or nil when the user has
+// to be asked. matches counts how often each rule matched in the session and
+// is updated for the returned rule.
+func (r *ApprovalRules) Evaluate(tool string, params map[string]interface{}, matches map[string]int) *RuleMatch {
+	if r == nil {
+		return nil
+	}
+
+	for _, rule := range r.rules {
+		if rule.MaxPerSession > 0 && matches[rule.name] >= rule.MaxPerSession {
+			continue
+		}
+		if !rule.matches(tool, params) {
+			continue
+		}
+
+		if matches != nil {
+			matches[rule.name]++
+		}
+		match := &RuleMatch{Rule: rule.name, Action: rule.Action}
+		r.record(tool, params, match)
+		return match
+	}
+	return nil
+}
+
+// matches reports whether every condition of the rule holds for the call
+func (r approvalRule) matches(tool string, params map[string]interface{}) bool {
+	if r.Tool != "" {
+		if ok, _ := filepath.Match(r.Tool, tool); !ok {
+			return false
+		}
+	}
+
+	for arg, pattern := range r.args {
+		value, ok := params[arg].(string)
+		if !ok || !pattern.MatchString(value) {
+			return false
+		}
+	}
+
+	if len(r.paths) > 0 {
+		path, ok := params["path"].(string)
+		if !ok || !isUnderAny(path, r.paths) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUnderAny reports whether path is one of dirs or inside one of them
+func isUnderAny(path string, dirs []string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+// record writes a rule match to the audit log
+func (r *ApprovalRules) record(tool string, params map[string]interface{}, match *RuleMatch) {
+	if r.audit == nil {
+		return
+	}
+
+	decision := "denied"
+	if match.Approved() {
+		decision = "approved"
+	}
+	// Failing to audit must not block the tool call decision
+	_ = r.audit.Record(AuditEvent{
+		Type:    "approval_rule",
+		Tool:    tool,
+		Message: fmt.Sprintf("tool call %s by rule %s", decision, match.Rule),
+		Details: map[string]interface{}{
+			"rule":      match.Rule,
+			"action":    match.Action,
+			"arguments": params,
+		},
+	})
+}
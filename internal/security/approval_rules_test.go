@@ -0,0 +1,152 @@
+package security
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestApprovalRulesEvaluate(t *testing.T) {
+	rules, err := NewApprovalRules([]config.ApprovalRule{
+		{Name: "no-secrets", Paths: []string{".env"}, Action: config.PermitDeny},
+		{Name: "docs", Tool: "write_file", Paths: []string{"./docs"}, Action: config.PermitApprove},
+		{Name: "go-tests", Tool: "run_command", Args: map[string]string{"command": "^go test"}, Action: config.PermitApprove},
+		{Name: "mcp", Tool: "mcp_*", Action: config.PermitDeny},
+	}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		tool   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"path under docs", "write_file", map[string]interface{}{"path": "docs/guide.md"}, "docs"},
+		{"docs directory itself", "write_file", map[string]interface{}{"path": "./docs"}, "docs"},
+		{"path outside docs", "write_file", map[string]interface{}{"path": "docs/../main.go"}, ""},
+		{"sibling with docs prefix", "write_file", map[string]interface{}{"path": "docs2/x.md"}, ""},
+		{"other tool under docs", "read_file", map[string]interface{}{"path": "docs/guide.md"}, ""},
+		{"first rule wins", "write_file", map[string]interface{}{"path": ".env"}, "no-secrets"},
+		{"command pattern", "run_command", map[string]interface{}{"command": "go test ./..."}, "go-tests"},
+		{"command not matching", "run_command", map[string]interface{}{"command": "rm -rf /"}, ""},
+		{"missing argument", "run_command", nil, ""},
+		{"tool glob", "mcp_github_search", nil, "mcp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := rules.Evaluate(tt.tool, tt.params, map[string]int{})
+			if tt.want == "" {
+				assert.Nil(t, match)
+				return
+			}
+			require.NotNil(t, match)
+			assert.Equal(t, tt.want, match.Rule)
+		})
+	}
+}
+
+func TestApprovalRulesMaxPerSession(t *testing.T) {
+	rules, err := NewApprovalRules([]config.ApprovalRule{
+		{Tool: "run_command", MaxPerSession: 2, Action: config.PermitApprove},
+	}, nil)
+	require.NoError(t, err)
+
+	matches := map[string]int{}
+	for i := 0; i < 2; i++ {
+		match := rules.Evaluate("run_command", nil, matches)
+		require.NotNil(t, match)
+		assert.Equal(t, "rule 1", match.Rule)
+		assert.True(t, match.Approved())
+	}
+	assert.Nil(t, rules.Evaluate("run_command", nil, matches))
+
+	// A new session starts counting again
+	assert.NotNil(t, rules.Evaluate("run_command", nil, map[string]int{}))
+}
+
+func TestKubectlApplyRule(t *testing.T) {
+	rules, err := NewApprovalRules([]config.ApprovalRule{KubectlApplyRule}, nil)
+	require.NoError(t, err)
+
+	for command, denied := range map[string]bool{
+		"kubectl apply -f deploy/":                  true,
+		"kubectl -n prod delete pod web-1":          true,
+		"make build && kubectl rollout restart d/x": true,
+		"kubectl diff -f deploy/":                   false,
+		"kubectl get pods -o yaml":                  false,
+		"kubectl rollout status deployment/web":     false,
+		"echo kubectl; git apply fix.patch":         false,
+	} {
+		match := rules.Evaluate("run_command", map[string]interface{}{"command": command}, nil)
+		if denied {
+			require.NotNil(t, match, command)
+			assert.False(t, match.Approved(), command)
+		} else {
+			assert.Nil(t, match, command)
+		}
+	}
+}
+
+func TestConfiguredApprovalRules(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	apply := map[string]interface{}{"command": "kubectl apply -f deploy/"}
+
+	rules, err := ConfiguredApprovalRules(cfg, nil)
+	require.NoError(t, err)
+	match := rules.Evaluate("run_command", apply, nil)
+	require.NotNil(t, match)
+	assert.Equal(t, KubectlApplyRule.Name, match.Rule)
+
+	cfg.Tools.Kubernetes.AllowApply = true
+	rules, err = ConfiguredApprovalRules(cfg, nil)
+	require.NoError(t, err)
+	assert.Nil(t, rules.Evaluate("run_command", apply, nil))
+}
+
+func TestApprovalRulesAudit(t *testing.T) {
+	audit, err := NewAuditLogger(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer audit.Close()
+
+	rules, err := NewApprovalRules([]config.ApprovalRule{
+		{Name: "go-tests", Tool: "run_command", Args: map[string]string{"command": "^go test"}, Action: config.PermitApprove},
+	}, audit)
+	require.NoError(t, err)
+
+	rules.Evaluate("run_command", map[string]interface{}{"command": "go test ./..."}, nil)
+	rules.Evaluate("run_command", map[string]interface{}{"command": "make"}, nil)
+
+	file, err := os.Open(audit.Path())
+	require.NoError(t, err)
+	defer file.Close()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 1)
+	assert.Equal(t, "approval_rule", events[0].Type)
+	assert.Equal(t, "run_command", events[0].Tool)
+	assert.Equal(t, "tool call approved by rule go-tests", events[0].Message)
+	assert.Equal(t, "go-tests", events[0].Details["rule"])
+}
+
+func TestNewApprovalRulesInvalid(t *testing.T) {
+	_, err := NewApprovalRules([]config.ApprovalRule{{Tool: "read_file", Action: "ask"}}, nil)
+	assert.ErrorContains(t, err, "invalid permit rule 1")
+
+	var rules *ApprovalRules
+	assert.Equal(t, 0, rules.Len())
+	assert.Nil(t, rules.Evaluate("read_file", nil, nil))
+}
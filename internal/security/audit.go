@@ -0,0 +1,96 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditEvent is a single security relevant event written to the audit log
+type AuditEvent struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Type      string                 `json:"type"`
+	Tool      string                 `json:"tool,omitempty"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// AuditLogger appends audit events as JSON lines to a file
+type AuditLogger struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// NewAuditLogger opens (or creates) the audit log at the given path
+func NewAuditLogger(path string) (*AuditLogger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &AuditLogger{
+		path: path,
+		file: file,
+	}, nil
+}
+
+// DefaultAuditLogPath returns the default audit log location (~/.coda/audit.log)
+func DefaultAuditLogPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".coda", "audit.log")
+	}
+	return filepath.Join(home, ".coda", "audit.log")
+}
+
+// Record writes an event to the audit log
+func (a *AuditLogger) Record(event AuditEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+
+	return a.file.Sync()
+}
+
+// Path returns the audit log file path
+func (a *AuditLogger) Path() string {
+	return a.path
+}
+
+// Close closes the audit log file
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
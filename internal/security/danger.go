@@ -0,0 +1,118 @@
+package security
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/common-creation/coda/internal/database"
+)
+
+// Patterns of high-risk shell commands in tool arguments
+var (
+	recursiveDeletePattern = regexp.MustCompile(`\brm\s+(-[a-zA-Z]*r[a-zA-Z]*f|-[a-zA-Z]*f[a-zA-Z]*r|-[a-zA-Z]*[rR]\s+-[a-zA-Z]*f|-[a-zA-Z]*f\s+-[a-zA-Z]*[rR]|--recursive\s+--force|--force\s+--recursive)\b`)
+	sudoPattern            = regexp.MustCompile(`(^|[\s;&|(])sudo\b`)
+	deviceRedirectPattern  = regexp.MustCompile(`>\s*(/dev/[^\s;&|]+)`)
+)
+
+// harmlessDevices can be written to without risk
+var harmlessDevices = map[string]bool{
+	"/dev/null":   true,
+	"/dev/stdout": true,
+	"/dev/stderr": true,
+	"/dev/tty":    true,
+}
+
+// readOnlyTools change nothing: they never write to the paths in their
+// arguments, so they also run in dry-run mode
+var readOnlyTools = map[string]bool{
+	"read_file":           true,
+	"list_files":          true,
+	"search_files":        true,
+	"semantic_search":     true,
+	"get_package_api":     true,
+	"security_scan":       true,
+	"deps_outdated":       true,
+	"validate_dockerfile": true,
+	"validate_compose":    true,
+	"validate_k8s":        true,
+}
+
+// IsReadOnlyTool reports whether a tool only reads
+func IsReadOnlyTool(tool string) bool {
+	return readOnlyTools[tool]
+}
+
+// pathArguments name tool arguments that hold a file path
+var pathArguments = []string{"path", "file_path", "destination", "target"}
+
+// DangerousArguments returns why a tool call is high-risk: recursive deletes,
+// sudo, redirects to devices, writes outside workspace, and database queries
+// that are not read-only. It returns nil for ordinary calls.
+func DangerousArguments(tool string, params map[string]interface{}, workspace string) []string {
+	var reasons []string
+	add := func(reason string) {
+		for _, r := range reasons {
+			if r == reason {
+				return
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+
+	// Commands can hide in any string argument
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := params[key].(string)
+		if !ok {
+			continue
+		}
+		if recursiveDeletePattern.MatchString(value) {
+			add("deletes files recursively (rm -rf)")
+		}
+		if sudoPattern.MatchString(value) {
+			add("runs with elevated privileges (sudo)")
+		}
+		for _, match := range deviceRedirectPattern.FindAllStringSubmatch(value, -1) {
+			if !harmlessDevices[match[1]] {
+				add(fmt.Sprintf("writes to device %s", match[1]))
+			}
+		}
+	}
+
+	if tool == "query_db" {
+		if query, ok := params["query"].(string); ok && !database.ReadOnly(query) {
+			add("may change the database")
+		}
+	}
+
+	if IsReadOnlyTool(tool) {
+		return reasons
+	}
+	for _, key := range pathArguments {
+		path, ok := params[key].(string)
+		if !ok || path == "" {
+			continue
+		}
+		if strings.HasPrefix(filepath.Clean(path), "/dev/") {
+			add(fmt.Sprintf("writes to device %s", path))
+		} else if workspace != "" && !isUnderAny(path, []string{absOrSelf(workspace)}) {
+			add(fmt.Sprintf("writes outside the workspace: %s", path))
+		}
+	}
+	return reasons
+}
+
+// absOrSelf returns the absolute form of path, or path when it has none
+func absOrSelf(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
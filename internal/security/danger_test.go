@@ -0,0 +1,41 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDangerousArguments(t *testing.T) {
+	workspace := t.TempDir()
+
+	tests := []struct {
+		name   string
+		tool   string
+		params map[string]interface{}
+		want   []string
+	}{
+		{"plain command", "run_command", map[string]interface{}{"command": "go test ./..."}, nil},
+		{"rm -rf", "run_command", map[string]interface{}{"command": "rm -rf build"}, []string{"deletes files recursively (rm -rf)"}},
+		{"rm -fr", "run_command", map[string]interface{}{"command": "cd x && rm -fr ."}, []string{"deletes files recursively (rm -rf)"}},
+		{"rm -r -f", "run_command", map[string]interface{}{"command": "rm -r -f tmp"}, []string{"deletes files recursively (rm -rf)"}},
+		{"plain rm", "run_command", map[string]interface{}{"command": "rm file.txt"}, nil},
+		{"sudo", "run_command", map[string]interface{}{"command": "make && sudo make install"}, []string{"runs with elevated privileges (sudo)"}},
+		{"sudo in a word", "run_command", map[string]interface{}{"command": "echo pseudo"}, nil},
+		{"device redirect", "run_command", map[string]interface{}{"command": "cat img > /dev/sda"}, []string{"writes to device /dev/sda"}},
+		{"dev null", "run_command", map[string]interface{}{"command": "make > /dev/null 2>&1"}, nil},
+		{"write inside workspace", "write_file", map[string]interface{}{"path": workspace + "/main.go"}, nil},
+		{"write outside workspace", "write_file", map[string]interface{}{"path": "/etc/hosts"}, []string{"writes outside the workspace: /etc/hosts"}},
+		{"escape with dots", "edit_file", map[string]interface{}{"path": workspace + "/../x"}, []string{"writes outside the workspace: " + workspace + "/../x"}},
+		{"write to device", "write_file", map[string]interface{}{"path": "/dev/sda"}, []string{"writes to device /dev/sda"}},
+		{"read outside workspace", "read_file", map[string]interface{}{"path": "/etc/hosts"}, nil},
+		{"database read", "query_db", map[string]interface{}{"query": "SELECT * FROM users"}, nil},
+		{"database write", "query_db", map[string]interface{}{"query": "DELETE FROM users"}, []string{"may change the database"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DangerousArguments(tt.tool, tt.params, workspace))
+		})
+	}
+}
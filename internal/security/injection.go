@@ -0,0 +1,66 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Markers delimiting content the agent did not write, such as file contents,
+// command output and MCP results. Instructions between them are data.
+const (
+	UntrustedBegin = "<<<UNTRUSTED CONTENT"
+	UntrustedEnd   = "<<<END UNTRUSTED CONTENT>>>"
+)
+
+// maxExcerptLength bounds the excerpt reported with a finding
+const maxExcerptLength = 80
+
+// WrapUntrusted delimits content from source with the untrusted-content
+// markers. Markers inside content are defused so it cannot close the block.
+func WrapUntrusted(source, content string) string {
+	content = strings.ReplaceAll(content, "<<<", "<\u200b<<")
+	return fmt.Sprintf("%s from %s>>>\n%s\n%s", UntrustedBegin, source, content, UntrustedEnd)
+}
+
+// InjectionFinding is a passage of fetched content that looks like an
+// attempt to instruct the agent
+type InjectionFinding struct {
+	Reason  string
+	Excerpt string
+}
+
+type injectionPattern struct {
+	reason string
+	re     *regexp.Regexp
+}
+
+// injectionPatterns are phrases addressed to a model rather than a reader
+var injectionPatterns = []injectionPattern{
+	{"overrides previous instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,30}\b(previous|prior|above|earlier|all|your|system)\b.{0,20}\b(instructions?|prompts?|rules|directives)\b`)},
+	{"assigns the agent a new role", regexp.MustCompile(`(?i)\byou are now\b|\bfrom now on,? you (are|will|must)\b|\bact as (an? )?(unrestricted|different|new)\b`)},
+	{"announces new instructions", regexp.MustCompile(`(?i)\b(new|updated|real|actual) (system )?instructions?\s*:`)},
+	{"refers to the system prompt", regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b.{0,20}\bsystem prompt\b`)},
+	{"asks to hide actions from the user", regexp.MustCompile(`(?i)\b(do not|don't|never)\b.{0,20}\b(tell|inform|mention|show|alert)\b.{0,20}\bthe user\b`)},
+	{"contains chat template tokens", regexp.MustCompile(`<\|(im_start|im_end|system|endoftext)\|>|\[/?INST\]|<<SYS>>`)},
+	{"contains a tool call", regexp.MustCompile(`"response_type"\s*:\s*"(tool_call|both)"`)},
+	{"addresses the agent directly", regexp.MustCompile(`(?i)\b(attention|note to|message (for|to)) (the )?(ai|assistant|agent|llm|language model)\b`)},
+}
+
+// ScanInjection reports the passages of content that look like instructions
+// to the agent. It is a heuristic: findings warrant a warning, not a block.
+func ScanInjection(content string) []InjectionFinding {
+	var findings []InjectionFinding
+	for _, pattern := range injectionPatterns {
+		loc := pattern.re.FindStringIndex(content)
+		if loc == nil {
+			continue
+		}
+		excerpt := strings.Join(strings.Fields(content[loc[0]:loc[1]]), " ")
+		if len(excerpt) > maxExcerptLength {
+			excerpt = strings.ToValidUTF8(excerpt[:maxExcerptLength], "") + "..."
+		}
+		findings = append(findings, InjectionFinding{Reason: pattern.reason, Excerpt: excerpt})
+	}
+	return findings
+}
@@ -0,0 +1,71 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+// Tool categories, from least to most powerful
+const (
+	// CategoryRead tools read or check the workspace
+	CategoryRead = "read"
+	// CategoryWrite tools change workspace files
+	CategoryWrite = "write"
+	// CategoryExecute tools run commands, builds, snippets or queries
+	CategoryExecute = "execute"
+	// CategoryNetwork tools reach other systems: HTTP APIs and MCP servers
+	CategoryNetwork = "network"
+)
+
+// writeTools change workspace files without running anything
+var writeTools = map[string]bool{
+	"write_file":    true,
+	"edit_file":     true,
+	"rename_symbol": true,
+	"save_artifact": true,
+}
+
+// networkTools reach systems outside the machine
+var networkTools = map[string]bool{
+	"http_request": true,
+}
+
+// roleCategories lists the tool categories each operator role may request
+var roleCategories = map[string][]string{
+	config.RoleViewer: {CategoryRead},
+	config.RoleEditor: {CategoryRead, CategoryWrite},
+	config.RoleAdmin:  {CategoryRead, CategoryWrite, CategoryExecute, CategoryNetwork},
+}
+
+// ToolCategory returns the category of a tool. Tools that are not known to
+// only read or write files count as executing.
+func ToolCategory(tool string) string {
+	switch {
+	case IsReadOnlyTool(tool):
+		return CategoryRead
+	case writeTools[tool]:
+		return CategoryWrite
+	case networkTools[tool], strings.HasPrefix(tool, "mcp_"):
+		return CategoryNetwork
+	default:
+		return CategoryExecute
+	}
+}
+
+// RoleCategories returns the tool categories role may request, none for an
+// unknown role
+func RoleCategories(role string) []string {
+	return roleCategories[role]
+}
+
+// CheckRole returns an error when role may not request tool
+func CheckRole(role, tool string) error {
+	category := ToolCategory(tool)
+	if slices.Contains(roleCategories[role], category) {
+		return nil
+	}
+	return fmt.Errorf("%s is a %s tool, which the %s role cannot request", tool, category, role)
+}
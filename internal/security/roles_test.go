@@ -0,0 +1,42 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestCheckRole(t *testing.T) {
+	tests := []struct {
+		role     string
+		tool     string
+		category string
+		allowed  bool
+	}{
+		{config.RoleViewer, "read_file", CategoryRead, true},
+		{config.RoleViewer, "validate_k8s", CategoryRead, true},
+		{config.RoleViewer, "edit_file", CategoryWrite, false},
+		{config.RoleEditor, "write_file", CategoryWrite, true},
+		{config.RoleEditor, "run_command", CategoryExecute, false},
+		{config.RoleEditor, "http_request", CategoryNetwork, false},
+		{config.RoleEditor, "mcp_github_create_issue", CategoryNetwork, false},
+		{config.RoleAdmin, "run_command", CategoryExecute, true},
+		{config.RoleAdmin, "mcp_github_create_issue", CategoryNetwork, true},
+		{config.RoleAdmin, "unknown_tool", CategoryExecute, true},
+		{"guest", "read_file", CategoryRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.tool, func(t *testing.T) {
+			assert.Equal(t, tt.category, ToolCategory(tt.tool))
+			err := CheckRole(tt.role, tt.tool)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "role cannot request")
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package sessioncrypt encrypts persisted session files with AES-256-GCM
+// under a key derived from a passphrase or a secret from the OS keychain
+package sessioncrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Magic starts every encrypted file so that it is never mistaken for JSON
+const Magic = "CODAENC1"
+
+// DefaultIterations is the PBKDF2-HMAC-SHA256 work factor of key derivation
+const DefaultIterations = 600000
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// ErrDecrypt is returned when data cannot be decrypted: the key is wrong or
+// the file was changed
+var ErrDecrypt = errors.New("cannot decrypt: wrong key or damaged file")
+
+// ErrEncrypted is returned when an encrypted file is read without a key
+var ErrEncrypted = errors.New("file is encrypted and no session encryption key is configured")
+
+// Cipher seals and opens data. Each file holds the salt its key was derived
+// with, so files written by other processes or with older salts still open;
+// derived keys are cached per salt.
+type Cipher struct {
+	secret     []byte
+	iterations int
+	salt       []byte
+
+	mu    sync.Mutex
+	aeads map[string]cipher.AEAD
+}
+
+// Option configures a Cipher
+type Option func(*Cipher)
+
+// WithIterations sets the PBKDF2 work factor, DefaultIterations by default
+func WithIterations(n int) Option {
+	return func(c *Cipher) {
+		if n > 0 {
+			c.iterations = n
+		}
+	}
+}
+
+// New returns a cipher for the passphrase or keychain secret
+func New(secret string, opts ...Option) (*Cipher, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("session encryption key is empty")
+	}
+	c := &Cipher{
+		secret:     []byte(secret),
+		iterations: DefaultIterations,
+		salt:       make([]byte, saltSize),
+		aeads:      make(map[string]cipher.AEAD),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if _, err := rand.Read(c.salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return c, nil
+}
+
+// IsEncrypted reports whether data was written by Seal
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+// Seal encrypts plaintext. The result is Magic, the salt, the nonce and the
+// ciphertext; the header is authenticated with it.
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	aead, err := c.aead(c.salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(Magic)+saltSize+aead.NonceSize())
+	header = append(header, Magic...)
+	header = append(header, c.salt...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header = append(header, nonce...)
+
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// Open decrypts data written by Seal
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) || len(data) < len(Magic)+saltSize {
+		return nil, ErrDecrypt
+	}
+	salt := data[len(Magic) : len(Magic)+saltSize]
+	aead, err := c.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	headerSize := len(Magic) + saltSize + aead.NonceSize()
+	if len(data) < headerSize+aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	nonce := data[len(Magic)+saltSize : headerSize]
+	plaintext, err := aead.Open(nil, nonce, data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// aead returns the AES-GCM cipher of the key derived with salt
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if aead, ok := c.aeads[string(salt)]; ok {
+		return aead, nil
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(c.secret, salt, c.iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	c.aeads[string(salt)] = aead
+	return aead, nil
+}
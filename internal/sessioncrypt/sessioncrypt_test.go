@@ -0,0 +1,73 @@
+package sessioncrypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenExistingFile(t *testing.T) {
+	// Written by an earlier release; the key derivation must not change
+	data, err := hex.DecodeString("434f4441454e4331f0d3b908bafaadadf8be22222030b0822a1a6be044f23452a918a1e43b0f63852fedf51e9540a309def816a308f3857a902977fc2527e9")
+	require.NoError(t, err)
+
+	c, err := New("passphrase", WithIterations(1000))
+	require.NoError(t, err)
+	plaintext, err := c.Open(data)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":\"s\"}\n", string(plaintext))
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c, err := New("correct horse", WithIterations(1000))
+	require.NoError(t, err)
+
+	plaintext := []byte(`{"id":"abc","messages":[]}`)
+	sealed, err := c.Seal(plaintext)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.NotContains(t, string(sealed), "messages")
+
+	opened, err := c.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	again, err := c.Seal(plaintext)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every seal uses a new nonce")
+
+	// Another process with the same passphrase has its own salt
+	other, err := New("correct horse", WithIterations(1000))
+	require.NoError(t, err)
+	opened, err = other.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+}
+
+func TestCipherOpenErrors(t *testing.T) {
+	c, err := New("correct horse", WithIterations(1000))
+	require.NoError(t, err)
+	sealed, err := c.Seal([]byte("secret conversation"))
+	require.NoError(t, err)
+
+	wrong, err := New("battery staple", WithIterations(1000))
+	require.NoError(t, err)
+	_, err = wrong.Open(sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = c.Open(tampered)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = c.Open(sealed[:len(Magic)+4])
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = c.Open([]byte(`{"id":"abc"}`))
+	assert.ErrorIs(t, err, ErrDecrypt)
+	assert.False(t, IsEncrypted([]byte(`{"id":"abc"}`)))
+
+	_, err = New("")
+	assert.Error(t, err)
+}
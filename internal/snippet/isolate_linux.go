@@ -0,0 +1,189 @@
+package snippet
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// sandboxEnv carries the writable directories to the re-executed binary,
+// which sets up the mount namespace before running the snippet
+const sandboxEnv = "CODA_SNIPPET_SANDBOX"
+
+func init() {
+	if writable, ok := os.LookupEnv(sandboxEnv); ok {
+		enterSandbox(filepath.SplitList(writable))
+	}
+}
+
+// isolate runs cmd in new user, network and mount namespaces. Only a
+// loopback interface that is down exists, and every mount is read-only
+// except the writable directories. Canceling kills its whole process group.
+//
+// The mounts are set up by this binary, re-executed inside the namespaces,
+// which then executes the command.
+func isolate(cmd *exec.Cmd, writable ...string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIsolationUnavailable, err)
+	}
+
+	cmd.Args = append([]string{"coda-snippet", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, sandboxEnv+"="+strings.Join(writable, string(filepath.ListSeparator)))
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:     true,
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET | syscall.CLONE_NEWNS,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return nil
+}
+
+// enterSandbox runs in the re-executed binary: it makes the file system
+// read-only except the writable directories and executes the snippet
+// command. It never returns.
+func enterSandbox(writable []string) {
+	if err := mountSandbox(writable); err != nil {
+		fmt.Fprintf(os.Stderr, "snippet sandbox: %v\n", err)
+		os.Exit(126)
+	}
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "snippet sandbox: no command")
+		os.Exit(126)
+	}
+	// The working directory still refers to the mount from before the bind
+	if wd, err := os.Getwd(); err == nil {
+		_ = os.Chdir(wd)
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, sandboxEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	err := syscall.Exec(os.Args[1], os.Args[1:], env)
+	fmt.Fprintf(os.Stderr, "snippet sandbox: %v\n", err)
+	os.Exit(127)
+}
+
+// mountSandbox remounts every mount read-only, keeping the writable
+// directories writable through bind mounts of their own
+func mountSandbox(writable []string) error {
+	// Keep the changes inside this mount namespace
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	for _, dir := range writable {
+		if err := syscall.Mount(dir, dir, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", dir, err)
+		}
+	}
+
+	mounts, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	for _, point := range mounts {
+		if underAny(point, writable) {
+			continue
+		}
+		if err := remountReadOnly(point); err != nil {
+			// Kernel file systems may refuse; they hold no user files
+			if underAny(point, []string{"/proc", "/sys", "/dev"}) {
+				continue
+			}
+			return fmt.Errorf("failed to make %s read-only: %w", point, err)
+		}
+	}
+	return nil
+}
+
+// remountReadOnly makes a mount read-only. Flags the user namespace cannot
+// clear are kept.
+func remountReadOnly(point string) error {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(point, &stat); err != nil {
+		if os.IsNotExist(err) || err == syscall.EACCES {
+			// Hidden by another mount
+			return nil
+		}
+		return err
+	}
+
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+	for statFlag, mountFlag := range map[int64]uintptr{
+		0x2:    syscall.MS_NOSUID,
+		0x4:    syscall.MS_NODEV,
+		0x8:    syscall.MS_NOEXEC,
+		0x400:  syscall.MS_NOATIME,
+		0x800:  syscall.MS_NODIRATIME,
+		0x1000: syscall.MS_RELATIME,
+	} {
+		if stat.Flags&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	return syscall.Mount("", point, "", flags, "")
+}
+
+// mountPoints lists the mount points of this mount namespace
+func mountPoints() ([]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mounts: %w", err)
+	}
+	defer file.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 4 {
+			points = append(points, unescapeMountPath(fields[4]))
+		}
+	}
+	return points, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes of mountinfo paths
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// underAny reports whether path is one of dirs or inside one of them
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if dir != "" && (path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")) {
+			return true
+		}
+	}
+	return false
+}
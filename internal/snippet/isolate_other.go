@@ -0,0 +1,11 @@
+//go:build !linux
+
+package snippet
+
+import "os/exec"
+
+// isolate fails: snippets are cut off from the network and the file system
+// with Linux namespaces only
+func isolate(cmd *exec.Cmd, writable ...string) error {
+	return ErrIsolationUnavailable
+}
@@ -0,0 +1,361 @@
+// Package snippet runs short Python and Go programs in throwaway
+// environments. On Linux a snippet runs in its own user, network and mount
+// namespaces: it has no network, a private HOME and temporary directory, and
+// a read-only view of the rest of the file system. It can still read the
+// files the user can read.
+package snippet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Language is the language of a snippet
+type Language string
+
+// Supported languages
+const (
+	Python Language = "python"
+	Go     Language = "go"
+)
+
+const (
+	// DefaultTimeout bounds a snippet run that sets no timeout
+	DefaultTimeout = 10 * time.Second
+	// MaxTimeout is the longest run allowed
+	MaxTimeout = 60 * time.Second
+	// buildTimeout bounds compiling a Go snippet
+	buildTimeout = 60 * time.Second
+	// outputLimit is the tail of stdout and stderr kept
+	outputLimit = 16 * 1024
+)
+
+// ErrIsolationUnavailable is returned when snippets cannot be isolated on
+// this system
+var ErrIsolationUnavailable = errors.New("snippet isolation is unavailable")
+
+// pythonRunner runs main.py and, like a notebook cell, prints the value of
+// a final expression
+const pythonRunner = `import ast, sys
+path = sys.argv[1]
+with open(path, encoding="utf-8") as f:
+    tree = ast.parse(f.read(), path)
+last = None
+if tree.body and isinstance(tree.body[-1], ast.Expr):
+    last = ast.Expression(tree.body.pop().value)
+namespace = {"__name__": "__main__", "__file__": path}
+sys.argv = [path]
+exec(compile(tree, path, "exec"), namespace)
+if last is not None:
+    value = eval(compile(last, path, "eval"), namespace)
+    if value is not None:
+        print(repr(value))
+`
+
+// Result is the outcome of running a snippet
+type Result struct {
+	// Stage is "build" when a Go snippet failed to compile, else "run"
+	Stage    string `json:"stage"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+	TimedOut bool   `json:"timed_out"`
+	// Truncated is set when only the tail of the output was kept
+	Truncated bool          `json:"truncated"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Runner runs snippets with the installed python3 and go
+type Runner struct {
+	commands map[Language]string
+	goCache  string // Build cache shared by Go snippets, never the user's
+
+	mu        sync.Mutex
+	available map[Language]bool
+}
+
+// Option configures a Runner
+type Option func(*Runner)
+
+// WithCommand sets the interpreter or toolchain executable of lang
+func WithCommand(lang Language, command string) Option {
+	return func(r *Runner) {
+		r.commands[lang] = command
+	}
+}
+
+// NewRunner creates a new Runner
+func NewRunner(opts ...Option) *Runner {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	r := &Runner{
+		commands:  map[Language]string{Python: "python3", Go: "go"},
+		goCache:   filepath.Join(cacheDir, "coda", "snippet-go-build"),
+		available: make(map[Language]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Installed reports whether python3 or go is on the PATH, without checking
+// that they can run isolated
+func (r *Runner) Installed() bool {
+	return len(r.InstalledLanguages()) > 0
+}
+
+// InstalledLanguages returns the languages whose toolchain is on the PATH.
+// Unlike Languages it runs nothing.
+func (r *Runner) InstalledLanguages() []Language {
+	var langs []Language
+	for _, lang := range []Language{Python, Go} {
+		if _, err := exec.LookPath(r.commands[lang]); err == nil {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// Languages returns the languages that are installed and can run isolated.
+// The check runs once per language.
+func (r *Runner) Languages() []Language {
+	var langs []Language
+	for _, lang := range []Language{Python, Go} {
+		if r.Available(lang) {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// Available reports whether snippets of lang can run: its toolchain is
+// installed and starts isolated
+func (r *Runner) Available(lang Language) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if available, ok := r.available[lang]; ok {
+		return available
+	}
+
+	command, ok := r.commands[lang]
+	available := false
+	if ok {
+		if _, err := exec.LookPath(command); err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			cmd := exec.CommandContext(ctx, command, "version")
+			if lang == Python {
+				cmd = exec.CommandContext(ctx, command, "--version")
+			}
+			available = isolate(cmd) == nil && cmd.Run() == nil
+			cancel()
+		}
+	}
+	r.available[lang] = available
+	return available
+}
+
+// Run runs code in a new temporary directory and removes it afterwards.
+// timeout bounds the run, not the build of a Go snippet. Failures of the
+// snippet are reported in the result; errors mean it could not be run.
+func (r *Runner) Run(ctx context.Context, lang Language, code string, timeout time.Duration) (*Result, error) {
+	command, ok := r.commands[lang]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q", lang)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > MaxTimeout {
+		timeout = MaxTimeout
+	}
+
+	dir, err := os.MkdirTemp("", "coda-snippet-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snippet directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	// Go ignores modules in the temporary directory itself
+	for _, sub := range []string{"tmp", "home"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create snippet directory: %w", err)
+		}
+	}
+
+	switch lang {
+	case Python:
+		return r.runPython(ctx, command, dir, code, timeout)
+	default:
+		return r.runGo(ctx, command, dir, code, timeout)
+	}
+}
+
+// runPython runs code with the interpreter of a new virtual environment,
+// which sees only the standard library
+func (r *Runner) runPython(ctx context.Context, command, dir, code string, timeout time.Duration) (*Result, error) {
+	venv := filepath.Join(dir, "venv")
+	if out, err := exec.CommandContext(ctx, command, "-m", "venv", "--without-pip", venv).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to create virtual environment: %s", strings.TrimSpace(string(out)))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte(code), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "runner.py"), []byte(pythonRunner), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	python := filepath.Join(venv, "bin", "python")
+	return r.run(ctx, dir, nil, timeout, "run", python, "-I", "-B", "runner.py", "main.py")
+}
+
+// runGo builds code as the main package of a new module, then runs it.
+// Only the standard library can be imported since the module proxy is off.
+func (r *Runner) runGo(ctx context.Context, command, dir, code string, timeout time.Duration) (*Result, error) {
+	source, err := GoProgram(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module snippet\n\ngo 1.21\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	if err := os.MkdirAll(r.goCache, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snippet build cache: %w", err)
+	}
+
+	result, err := r.run(ctx, dir, []string{r.goCache}, buildTimeout, "build", command, "build", "-o", "snippet", ".")
+	if err != nil || result.ExitCode != 0 || result.TimedOut {
+		return result, err
+	}
+	return r.run(ctx, dir, nil, timeout, "run", filepath.Join(dir, "snippet"))
+}
+
+// GoProgram turns code into a main package: a whole file, a file without
+// its package clause, or statements, optionally after imports, that become
+// the body of main
+func GoProgram(code string) (string, error) {
+	fset := token.NewFileSet()
+	if file, err := parser.ParseFile(fset, "main.go", code, parser.PackageClauseOnly); err == nil && file.Name != nil {
+		return code, nil
+	}
+
+	source := "package main\n\n" + code
+	file, err := parser.ParseFile(fset, "main.go", source, 0)
+	if err == nil {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+				return source, nil
+			}
+		}
+	}
+
+	// Statements: keep the imports in front and wrap the rest in main
+	file, err = parser.ParseFile(fset, "main.go", source, parser.ImportsOnly)
+	if err != nil {
+		return "", fmt.Errorf("invalid Go snippet: %w", err)
+	}
+	header := len("package main\n\n")
+	if len(file.Decls) > 0 {
+		header = fset.Position(file.Decls[len(file.Decls)-1].End()).Offset
+	}
+	return source[:header] + "\n\nfunc main() {\n" + source[header:] + "\n}\n", nil
+}
+
+// run runs a snippet command in dir with a minimal environment, isolated
+// so that only dir and the cache directories can be written
+func (r *Runner) run(ctx context.Context, dir string, caches []string, timeout time.Duration, stage, command string, args ...string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	cmd.Env = r.environment(dir)
+	if err := isolate(cmd, append([]string{dir}, caches...)...); err != nil {
+		return nil, err
+	}
+	stdout := &tailBuffer{limit: outputLimit}
+	stderr := &tailBuffer{limit: outputLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Stage:     stage,
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+		Duration:  time.Since(start),
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return nil, fmt.Errorf("failed to run snippet: %w", err)
+	}
+	return result, nil
+}
+
+// environment is the environment of snippets: enough to find the tools,
+// with HOME, the temporary directory and GOPATH inside dir, and nothing of
+// CODA's own
+func (r *Runner) environment(dir string) []string {
+	env := []string{
+		"HOME=" + filepath.Join(dir, "home"),
+		"TMPDIR=" + filepath.Join(dir, "tmp"),
+		"GOPATH=" + filepath.Join(dir, "home", "go"),
+		"GOCACHE=" + r.goCache,
+		"GOPROXY=off",
+		"GOFLAGS=-mod=mod",
+		"GOTOOLCHAIN=local",
+		"GOWORK=off",
+		"CGO_ENABLED=0",
+		"PYTHONIOENCODING=utf-8",
+	}
+	for _, name := range []string{"PATH", "LANG", "GOROOT"} {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	if over := t.buf.Len() - t.limit; over > 0 {
+		t.truncated = true
+		t.buf.Next(over)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return t.buf.String()
+}
@@ -0,0 +1,101 @@
+package snippet
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoProgram(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"whole file", "package main\n\nfunc main() {}\n", "package main\n\nfunc main() {}\n"},
+		{"no package clause", "import \"fmt\"\n\nfunc main() { fmt.Println(1) }\n", "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(1) }\n"},
+		{"statements", "fmt.Println(1 << 10)", "package main\n\n\n\nfunc main() {\nfmt.Println(1 << 10)\n}\n"},
+		{"statements after imports", "import (\n\t\"fmt\"\n\t\"strings\"\n)\nfmt.Println(strings.Repeat(\"a\", 3))", "package main\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n\nfunc main() {\n\nfmt.Println(strings.Repeat(\"a\", 3))\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GoProgram(tt.code)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := GoProgram("import \"fmt")
+	assert.ErrorContains(t, err, "invalid Go snippet")
+}
+
+func TestRunPython(t *testing.T) {
+	runner := NewRunner()
+	if !runner.Available(Python) {
+		t.Skip("python3 is not installed or cannot run isolated")
+	}
+	ctx := context.Background()
+
+	result, err := runner.Run(ctx, Python, "import math\nprint('hi')\nmath.factorial(20)", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "hi\n2432902008176640000\n", result.Stdout)
+
+	result, err = runner.Run(ctx, Python, "1/0", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Contains(t, result.Stderr, "ZeroDivisionError")
+
+	result, err = runner.Run(ctx, Python, "import socket\nsocket.create_connection(('1.1.1.1', 53), timeout=2)", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Contains(t, result.Stderr, "unreachable")
+
+	result, err = runner.Run(ctx, Python, "import time\ntime.sleep(5)", 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, result.TimedOut)
+}
+
+func TestRunGo(t *testing.T) {
+	runner := NewRunner()
+	if !runner.Available(Go) {
+		t.Skip("go is not installed or cannot run isolated")
+	}
+	ctx := context.Background()
+
+	result, err := runner.Run(ctx, Go, "import \"fmt\"\nfmt.Println(len(\"héllo\"))", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "run", result.Stage)
+	assert.Equal(t, "6\n", result.Stdout)
+
+	result, err = runner.Run(ctx, Go, "import \"fmt\"\nx := 1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "build", result.Stage)
+	assert.NotZero(t, result.ExitCode)
+	assert.Contains(t, result.Stderr, "declared and not used")
+}
+
+func TestRunIsolatesFileSystem(t *testing.T) {
+	runner := NewRunner()
+	if !runner.Available(Python) {
+		t.Skip("python3 is not installed or cannot run isolated")
+	}
+	outside := t.TempDir()
+
+	code := "import os, pathlib\n" +
+		"pathlib.Path('here.txt').write_text('ok')\n" +
+		"pathlib.Path(os.environ['HOME'], 'home.txt').write_text('ok')\n" +
+		"print(os.path.abspath(os.environ['HOME']).startswith(os.getcwd()))\n" +
+		"pathlib.Path(" + strconv.Quote(outside) + ", 'escaped.txt').write_text('no')"
+	result, err := runner.Run(context.Background(), Python, code, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "True\n", result.Stdout)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Contains(t, result.Stderr, "Read-only file system")
+	assert.NoFileExists(t, filepath.Join(outside, "escaped.txt"))
+}
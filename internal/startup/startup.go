@@ -0,0 +1,155 @@
+// Package startup times the phases of CODA's startup and runs its slow parts
+// in the background, so the TUI can draw its first frame before they finish.
+package startup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProfileEnv names the environment variable that prints the startup profile
+// to stderr when CODA exits
+const ProfileEnv = "CODA_PROFILE_STARTUP"
+
+// Phase is a timed part of startup
+type Phase struct {
+	Name string
+	// Start is the offset from the start of the process
+	Start    time.Duration
+	Duration time.Duration
+	// Background phases run concurrently with the rest of startup
+	Background bool
+	Err        error
+}
+
+// Tracker records startup phases and the background tasks still running
+type Tracker struct {
+	start time.Time
+
+	mu      sync.Mutex
+	phases  []Phase
+	pending []string
+	notices []string
+	wg      sync.WaitGroup
+}
+
+// processStart approximates the start of the process: package variables are
+// initialized before main runs
+var processStart = time.Now()
+
+// NewTracker creates a tracker timing from the start of the process
+func NewTracker() *Tracker {
+	return &Tracker{start: processStart}
+}
+
+// Step starts timing a phase; call the returned function when it ends
+func (t *Tracker) Step(name string) func() {
+	begin := time.Now()
+	return func() {
+		t.record(Phase{Name: name, Start: begin.Sub(t.start), Duration: time.Since(begin)})
+	}
+}
+
+// Mark records an instant, such as the first frame of the TUI
+func (t *Tracker) Mark(name string) {
+	t.record(Phase{Name: name, Start: time.Since(t.start)})
+}
+
+// Go runs fn in the background. Its name is listed by Pending until it
+// returns.
+func (t *Tracker) Go(name string, fn func() error) {
+	t.mu.Lock()
+	t.pending = append(t.pending, name)
+	t.mu.Unlock()
+
+	t.wg.Add(1)
+	begin := time.Now()
+	go func() {
+		defer t.wg.Done()
+		err := fn()
+
+		t.mu.Lock()
+		for i, p := range t.pending {
+			if p == name {
+				t.pending = append(t.pending[:i], t.pending[i+1:]...)
+				break
+			}
+		}
+		t.mu.Unlock()
+		t.record(Phase{Name: name, Start: begin.Sub(t.start), Duration: time.Since(begin), Background: true, Err: err})
+	}()
+}
+
+// Pending returns the background tasks still running, in start order
+func (t *Tracker) Pending() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.pending...)
+}
+
+// Wait blocks until every background task has returned
+func (t *Tracker) Wait() {
+	t.wg.Wait()
+}
+
+// Notify queues a message for the user from a background task
+func (t *Tracker) Notify(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notices = append(t.notices, fmt.Sprintf(format, args...))
+}
+
+// TakeNotices returns the queued messages and clears them
+func (t *Tracker) TakeNotices() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	notices := t.notices
+	t.notices = nil
+	return notices
+}
+
+// Phases returns the recorded phases by start
+func (t *Tracker) Phases() []Phase {
+	t.mu.Lock()
+	phases := append([]Phase(nil), t.phases...)
+	t.mu.Unlock()
+
+	sort.SliceStable(phases, func(i, j int) bool {
+		return phases[i].Start < phases[j].Start
+	})
+	return phases
+}
+
+// Report formats the phases as a table for the startup profile
+func (t *Tracker) Report() string {
+	var b strings.Builder
+	b.WriteString("Startup profile:\n")
+	for _, p := range t.Phases() {
+		kind := ""
+		if p.Background {
+			kind = " (background)"
+		}
+		if p.Err != nil {
+			kind += fmt.Sprintf(" error: %v", p.Err)
+		}
+		fmt.Fprintf(&b, "  %8s  %8s  %s%s\n", round(p.Start), round(p.Duration), p.Name, kind)
+	}
+	return b.String()
+}
+
+func (t *Tracker) record(p Phase) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phases = append(t.phases, p)
+}
+
+// round shortens a duration to a tenth of a millisecond
+func round(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(100 * time.Microsecond).String()
+}
@@ -0,0 +1,47 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+
+	done := tracker.Step("config")
+	done()
+
+	release := make(chan struct{})
+	tracker.Go("model check", func() error {
+		<-release
+		tracker.Notify("model %s is not available", "gpt-x")
+		return nil
+	})
+	tracker.Go("tokenizer", func() error {
+		<-release
+		return errors.New("offline")
+	})
+	assert.Equal(t, []string{"model check", "tokenizer"}, tracker.Pending())
+	tracker.Mark("first frame")
+
+	close(release)
+	tracker.Wait()
+	assert.Empty(t, tracker.Pending())
+	assert.Equal(t, []string{"model gpt-x is not available"}, tracker.TakeNotices())
+	assert.Empty(t, tracker.TakeNotices(), "notices are taken once")
+
+	phases := tracker.Phases()
+	require.Len(t, phases, 4)
+	assert.Equal(t, "config", phases[0].Name)
+	for i := 1; i < len(phases); i++ {
+		assert.LessOrEqual(t, phases[i-1].Start, phases[i].Start)
+	}
+
+	report := tracker.Report()
+	assert.Contains(t, report, "Startup profile:")
+	assert.Contains(t, report, "tokenizer (background) error: offline")
+	assert.Contains(t, report, "first frame")
+}
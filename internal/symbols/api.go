@@ -0,0 +1,187 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxPackageSymbols bounds the declarations listed for the requested package
+	maxPackageSymbols = 120
+	// maxImportSymbols bounds the declarations listed for each imported package
+	maxImportSymbols = 40
+)
+
+// Package is a Go package as listed by the go command
+type Package struct {
+	Dir        string
+	ImportPath string
+	Name       string
+	GoFiles    []string
+	Imports    []string
+	Standard   bool
+	Error      *struct{ Err string }
+}
+
+// ListPackages runs "go list" in dir, so patterns and import paths resolve
+// like they do for the go command, including the module cache
+func ListPackages(ctx context.Context, dir string, patterns ...string) ([]Package, error) {
+	args := append([]string{"list", "-e", "-json=Dir,ImportPath,Name,GoFiles,Imports,Standard,Error"}, patterns...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var packages []Package
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg Package
+		if err := dec.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// ExportedSymbols returns the exported declarations of a listed package,
+// leaving out methods of unexported types
+func ExportedSymbols(pkg Package) []Symbol {
+	idx := NewIndex(pkg.Dir)
+	for _, name := range pkg.GoFiles {
+		path := filepath.Join(pkg.Dir, name)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		_ = idx.AddFile(path, src)
+	}
+
+	var result []Symbol
+	for _, sym := range idx.Symbols() {
+		if !ast.IsExported(sym.Name) || (sym.Receiver != "" && !ast.IsExported(sym.Receiver)) {
+			continue
+		}
+		// Grouped constants and variables share one signature per spec
+		if n := len(result); n > 0 && result[n-1].File == sym.File && result[n-1].Line == sym.Line && result[n-1].Signature == sym.Signature {
+			continue
+		}
+		result = append(result, sym)
+	}
+	return result
+}
+
+// PackageAPI renders the exported API of target, a package directory, Go
+// file or import path resolved from root. With imports, the APIs of its
+// direct imports outside the standard library follow.
+func PackageAPI(ctx context.Context, root, target string, imports bool) (string, error) {
+	packages, err := ListPackages(ctx, root, packagePattern(root, target))
+	if err != nil {
+		return "", err
+	}
+	if len(packages) == 0 {
+		return "", fmt.Errorf("no Go package found for %s", target)
+	}
+	pkg := packages[0]
+	if pkg.Error != nil && len(pkg.GoFiles) == 0 {
+		return "", fmt.Errorf("cannot load package %s: %s", target, pkg.Error.Err)
+	}
+
+	var b strings.Builder
+	writePackageAPI(&b, pkg, maxPackageSymbols)
+	if !imports {
+		return strings.TrimRight(b.String(), "\n"), nil
+	}
+
+	var paths []string
+	for _, path := range pkg.Imports {
+		if path != "C" && !isStandardPath(path) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		imported, err := ListPackages(ctx, root, paths...)
+		if err != nil {
+			return "", err
+		}
+		for _, dep := range imported {
+			if dep.Standard || dep.Dir == "" {
+				continue
+			}
+			b.WriteString("\n")
+			writePackageAPI(&b, dep, maxImportSymbols)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// packagePattern turns a file or directory into the go list pattern of its
+// package; anything else is taken as an import path
+func packagePattern(root, target string) string {
+	path := target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, target)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return target
+	}
+	dir := target
+	if !info.IsDir() {
+		dir = filepath.Dir(target)
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return "./" + filepath.ToSlash(filepath.Clean(dir))
+}
+
+// writePackageAPI writes the exported declarations of pkg with the first
+// sentence of their documentation
+func writePackageAPI(b *strings.Builder, pkg Package, limit int) {
+	fmt.Fprintf(b, "package %s // %s\n", pkg.Name, pkg.ImportPath)
+	symbols := ExportedSymbols(pkg)
+	for i, sym := range symbols {
+		if i == limit {
+			fmt.Fprintf(b, "\n// ... %d more declarations\n", len(symbols)-limit)
+			break
+		}
+		b.WriteString("\n")
+		if doc := firstSentence(sym.Doc); doc != "" {
+			fmt.Fprintf(b, "// %s\n", doc)
+		}
+		b.WriteString(sym.Signature)
+		b.WriteString("\n")
+	}
+}
+
+// firstSentence returns the first sentence, or paragraph, of a doc comment
+func firstSentence(doc string) string {
+	paragraph, _, _ := strings.Cut(doc, "\n\n")
+	text := strings.Join(strings.Fields(paragraph), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i+1]
+	}
+	return text
+}
+
+// isStandardPath reports whether an import path looks like the standard
+// library, whose first element has no dot
+func isStandardPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
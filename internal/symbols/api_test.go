@@ -0,0 +1,87 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageAPI(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	// The temporary module must not use the go flags of the environment
+	t.Setenv("GOFLAGS", "")
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":              "module example.com/shop\n\ngo 1.21\n",
+		"store/store.go":      sampleSource,
+		"store/store_test.go": "package store\n\nfunc TestHelper() {}\n",
+		"cart/cart.go": `package cart
+
+import (
+	"fmt"
+
+	"example.com/shop/store"
+)
+
+// Cart holds the items to buy. It is not safe for concurrent use.
+type Cart struct {
+	store *store.Store
+}
+
+// Total sums the cart
+func (c *Cart) Total() int { return 0 }
+
+func (c *Cart) reset() {}
+
+type line struct{}
+
+// Lines prints the cart
+func (l line) Lines() { fmt.Println() }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	api, err := PackageAPI(context.Background(), root, "cart/cart.go", false)
+	require.NoError(t, err)
+	assert.Equal(t, `package cart // example.com/shop/cart
+
+// Cart holds the items to buy.
+type Cart struct {
+	store *store.Store
+}
+
+// Total sums the cart
+func (c *Cart) Total() int`, api)
+
+	api, err = PackageAPI(context.Background(), root, "cart", true)
+	require.NoError(t, err)
+	assert.Contains(t, api, "package store // example.com/shop/store")
+	assert.Contains(t, api, "func New() *Store")
+	assert.Contains(t, api, "const MaxItems = 10")
+	assert.NotContains(t, api, "defaultStore")
+	assert.NotContains(t, api, "TestHelper")
+	assert.NotContains(t, api, "package fmt")
+
+	api, err = PackageAPI(context.Background(), root, "example.com/shop/store", false)
+	require.NoError(t, err)
+	assert.Contains(t, api, "func (s *Store) Put(key string, value int)")
+
+	api, err = PackageAPI(context.Background(), root, filepath.Join(root, "store", "store.go"), false)
+	require.NoError(t, err)
+	assert.Contains(t, api, "package store // example.com/shop/store")
+
+	_, err = PackageAPI(context.Background(), root, "example.com/shop/missing", false)
+	assert.Error(t, err)
+}
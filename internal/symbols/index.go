@@ -0,0 +1,321 @@
+// Package symbols builds a lightweight index of Go declarations in a workspace.
+package symbols
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is the kind of a declared symbol
+type Kind string
+
+const (
+	KindFunc   Kind = "func"
+	KindMethod Kind = "method"
+	KindType   Kind = "type"
+	KindConst  Kind = "const"
+	KindVar    Kind = "var"
+)
+
+// maxSignatureLines bounds how much of a type declaration is kept as its signature
+const maxSignatureLines = 40
+
+// skipDirs are never indexed
+var skipDirs = map[string]bool{
+	".git":         true,
+	"vendor":       true,
+	"node_modules": true,
+	"testdata":     true,
+}
+
+// Symbol is a top level declaration
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      Kind   `json:"kind"`
+	Package   string `json:"package"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"end_line"`
+	Receiver  string `json:"receiver,omitempty"`
+	Signature string `json:"signature"`
+	Doc       string `json:"doc,omitempty"`
+}
+
+// QualifiedName returns Receiver.Name for methods and Name otherwise
+func (s Symbol) QualifiedName() string {
+	if s.Receiver != "" {
+		return s.Receiver + "." + s.Name
+	}
+	return s.Name
+}
+
+// Index holds the symbols of a set of Go files
+type Index struct {
+	root    string
+	symbols []Symbol
+	byName  map[string][]int
+	mu      sync.RWMutex
+}
+
+// NewIndex creates an empty index for files under root
+func NewIndex(root string) *Index {
+	return &Index{
+		root:   root,
+		byName: make(map[string][]int),
+	}
+}
+
+// BuildIndex indexes every Go file under root. Files that fail to parse are skipped.
+func BuildIndex(root string) (*Index, error) {
+	idx := NewIndex(root)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		_ = idx.AddFile(path, src)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index %s: %w", root, err)
+	}
+
+	return idx, nil
+}
+
+// AddFile parses src and adds its top level declarations to the index
+func (idx *Index) AddFile(path string, src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	rel := path
+	if idx.root != "" {
+		if r, err := filepath.Rel(idx.root, path); err == nil {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(rel)
+
+	var found []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := Symbol{
+				Name:    d.Name.Name,
+				Kind:    KindFunc,
+				Package: file.Name.Name,
+				File:    rel,
+				Line:    fset.Position(d.Pos()).Line,
+				EndLine: fset.Position(d.End()).Line,
+				Doc:     strings.TrimSpace(d.Doc.Text()),
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Kind = KindMethod
+				sym.Receiver = receiverName(d.Recv.List[0].Type)
+			}
+			// Print the declaration without its body
+			sym.Signature = render(fset, &ast.FuncDecl{Recv: d.Recv, Name: d.Name, Type: d.Type})
+			found = append(found, sym)
+
+		case *ast.GenDecl:
+			kind := genDeclKind(d.Tok)
+			if kind == "" {
+				continue
+			}
+			for _, spec := range d.Specs {
+				doc := d.Doc
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Doc != nil {
+						doc = s.Doc
+					}
+					found = append(found, Symbol{
+						Name:      s.Name.Name,
+						Kind:      kind,
+						Package:   file.Name.Name,
+						File:      rel,
+						Line:      fset.Position(s.Pos()).Line,
+						EndLine:   fset.Position(s.End()).Line,
+						Signature: truncateLines("type "+render(fset, s), maxSignatureLines),
+						Doc:       strings.TrimSpace(doc.Text()),
+					})
+				case *ast.ValueSpec:
+					if s.Doc != nil {
+						doc = s.Doc
+					}
+					signature := truncateLines(d.Tok.String()+" "+render(fset, s), maxSignatureLines)
+					for _, name := range s.Names {
+						if name.Name == "_" {
+							continue
+						}
+						found = append(found, Symbol{
+							Name:      name.Name,
+							Kind:      kind,
+							Package:   file.Name.Name,
+							File:      rel,
+							Line:      fset.Position(s.Pos()).Line,
+							EndLine:   fset.Position(s.End()).Line,
+							Signature: signature,
+							Doc:       strings.TrimSpace(doc.Text()),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, sym := range found {
+		idx.byName[sym.Name] = append(idx.byName[sym.Name], len(idx.symbols))
+		idx.symbols = append(idx.symbols, sym)
+	}
+
+	return nil
+}
+
+// Lookup returns every symbol with the given name
+func (idx *Index) Lookup(name string) []Symbol {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	positions := idx.byName[name]
+	result := make([]Symbol, 0, len(positions))
+	for _, i := range positions {
+		result = append(result, idx.symbols[i])
+	}
+	return result
+}
+
+// Symbols returns all indexed symbols ordered by file and line
+func (idx *Index) Symbols() []Symbol {
+	idx.mu.RLock()
+	result := append([]Symbol{}, idx.symbols...)
+	idx.mu.RUnlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].File != result[j].File {
+			return result[i].File < result[j].File
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
+// Len returns the number of indexed symbols
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.symbols)
+}
+
+// Enclosing returns the declaration in file that contains line
+func (idx *Index) Enclosing(file string, line int) (Symbol, bool) {
+	file = filepath.ToSlash(file)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for _, sym := range idx.symbols {
+		if sym.File == file && sym.Line <= line && line <= sym.EndLine {
+			return sym, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// Identifiers returns the distinct identifiers used in a Go source fragment,
+// in order of first appearance. Fragments need not parse as a whole file.
+func Identifiers(src string) []string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+
+	seen := make(map[string]bool)
+	var result []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.IDENT && !seen[lit] {
+			seen[lit] = true
+			result = append(result, lit)
+		}
+	}
+	return result
+}
+
+func genDeclKind(tok token.Token) Kind {
+	switch tok {
+	case token.TYPE:
+		return KindType
+	case token.CONST:
+		return KindConst
+	case token.VAR:
+		return KindVar
+	default:
+		return ""
+	}
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+func render(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func truncateLines(s string, max int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= max {
+		return s
+	}
+	return strings.Join(lines[:max], "\n") + "\n\t// ..."
+}
@@ -0,0 +1,98 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleSource = `package store
+
+// Store keeps items in memory
+type Store struct {
+	items map[string]int
+}
+
+// MaxItems bounds the store size
+const MaxItems = 10
+
+var defaultStore, _ = New(), 1
+
+// New creates a store
+func New() *Store {
+	return &Store{items: map[string]int{}}
+}
+
+// Put adds an item
+func (s *Store) Put(key string, value int) {
+	s.items[key] = value
+}
+`
+
+func TestIndexAddFile(t *testing.T) {
+	idx := NewIndex("")
+	require.NoError(t, idx.AddFile("store.go", []byte(sampleSource)))
+
+	tests := []struct {
+		name      string
+		kind      Kind
+		line      int
+		signature string
+		doc       string
+	}{
+		{name: "Store", kind: KindType, line: 4, signature: "type Store struct", doc: "Store keeps items in memory"},
+		{name: "MaxItems", kind: KindConst, line: 9, signature: "const MaxItems = 10", doc: "MaxItems bounds the store size"},
+		{name: "defaultStore", kind: KindVar, line: 11, signature: "var defaultStore, _ = New(), 1"},
+		{name: "New", kind: KindFunc, line: 14, signature: "func New() *Store", doc: "New creates a store"},
+		{name: "Put", kind: KindMethod, line: 19, signature: "func (s *Store) Put(key string, value int)", doc: "Put adds an item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := idx.Lookup(tt.name)
+			require.Len(t, found, 1)
+			assert.Equal(t, tt.kind, found[0].Kind)
+			assert.Equal(t, tt.line, found[0].Line)
+			assert.Contains(t, found[0].Signature, tt.signature)
+			assert.NotContains(t, found[0].Signature, "return")
+			assert.Equal(t, tt.doc, found[0].Doc)
+			assert.Equal(t, "store", found[0].Package)
+		})
+	}
+
+	assert.Equal(t, "Store.Put", idx.Lookup("Put")[0].QualifiedName())
+	assert.Empty(t, idx.Lookup("_"))
+	assert.Equal(t, 5, idx.Len())
+
+	enclosing, ok := idx.Enclosing("store.go", 20)
+	require.True(t, ok)
+	assert.Equal(t, "Put", enclosing.Name)
+
+	_, ok = idx.Enclosing("store.go", 2)
+	assert.False(t, ok)
+}
+
+func TestBuildIndex(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "store"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "vendor", "dep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "store", "store.go"), []byte(sampleSource), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "vendor", "dep", "dep.go"), []byte("package dep\nfunc Dep() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "broken.go"), []byte("package broken\nfunc {"), 0644))
+
+	idx, err := BuildIndex(root)
+	require.NoError(t, err)
+
+	assert.Empty(t, idx.Lookup("Dep"))
+	found := idx.Lookup("New")
+	require.Len(t, found, 1)
+	assert.Equal(t, "store/store.go", found[0].File)
+}
+
+func TestIdentifiers(t *testing.T) {
+	ids := Identifiers(`s.items[key] = New().items // Store "Put"`)
+	assert.Equal(t, []string{"s", "items", "key", "New"}, ids)
+}
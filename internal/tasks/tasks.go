@@ -0,0 +1,223 @@
+// Package tasks provides guided templates that turn a few answered questions
+// into a detailed prompt for a common task.
+package tasks
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/common-creation/coda/internal/symbols"
+)
+
+// maxRelatedSymbols bounds the definitions added from the symbol index
+const maxRelatedSymbols = 10
+
+// Field is one question of a template
+type Field struct {
+	Key      string
+	Label    string
+	Hint     string
+	Required bool
+	// Code marks fields that name files or symbols to look up
+	Code bool
+}
+
+// Template is a guided task
+type Template struct {
+	Name        string
+	Description string
+	// Intro is the first line of the assembled prompt
+	Intro  string
+	Fields []Field
+	// Steps tell the model how to approach the task
+	Steps []string
+}
+
+var builtin = []Template{
+	{
+		Name:        "bug",
+		Description: "Fix a bug",
+		Intro:       "Fix the following bug in this workspace.",
+		Fields: []Field{
+			{Key: "problem", Label: "What goes wrong", Hint: "e.g. saving a session with no messages panics", Required: true},
+			{Key: "expected", Label: "Expected behavior", Hint: "what should happen instead"},
+			{Key: "reproduce", Label: "How to reproduce", Hint: "command, input or steps"},
+			{Key: "errors", Label: "Error output", Hint: "message, stack trace or failing test"},
+			{Key: "code", Label: "Related files or symbols", Hint: "e.g. internal/chat/handler.go SaveSession", Code: true},
+		},
+		Steps: []string{
+			"Read the related code and find the root cause before changing anything.",
+			"Explain the cause briefly, then make the smallest fix that addresses it.",
+			"Add or update a test that fails without the fix.",
+		},
+	},
+	{
+		Name:        "feature",
+		Description: "Add a feature",
+		Intro:       "Implement the following feature in this workspace.",
+		Fields: []Field{
+			{Key: "goal", Label: "Feature", Hint: "what users should be able to do", Required: true},
+			{Key: "criteria", Label: "Acceptance criteria", Hint: "how to tell it works"},
+			{Key: "constraints", Label: "Constraints", Hint: "compatibility, performance, things not to touch"},
+			{Key: "code", Label: "Related files or symbols", Hint: "where it belongs or what it resembles", Code: true},
+		},
+		Steps: []string{
+			"Study how similar features are implemented and follow the same patterns.",
+			"Outline the plan before editing files.",
+			"Add tests for the new behavior and update the documentation it affects.",
+		},
+	},
+	{
+		Name:        "tests",
+		Description: "Write tests",
+		Intro:       "Write tests for the following code in this workspace.",
+		Fields: []Field{
+			{Key: "target", Label: "Code to test", Hint: "files, functions or types", Required: true, Code: true},
+			{Key: "cases", Label: "Cases to cover", Hint: "edge cases, errors, regressions"},
+			{Key: "style", Label: "Test style", Hint: "e.g. table-driven, no network access"},
+		},
+		Steps: []string{
+			"Read the code under test and the existing tests next to it first.",
+			"Follow the conventions of the existing tests (placement, helpers, assertions).",
+			"Cover normal behavior, edge cases and error paths, then run the tests.",
+		},
+	},
+}
+
+// Builtin returns the built-in templates
+func Builtin() []Template {
+	return append([]Template(nil), builtin...)
+}
+
+// Lookup returns the built-in template with the given name
+func Lookup(name string) (Template, bool) {
+	for _, t := range builtin {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Build assembles the prompt for the answers to a template, keyed by field.
+// Files and symbols named in the answers are listed with their location in
+// the symbol index when one is given.
+func Build(t Template, values map[string]string, idx *symbols.Index) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(t.Intro)
+
+	var codeRefs, text []string
+	for _, field := range t.Fields {
+		value := strings.TrimSpace(values[field.Key])
+		if value == "" {
+			if field.Required {
+				return "", fmt.Errorf("%s is required", strings.ToLower(field.Label))
+			}
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n\n## %s\n%s", field.Label, value))
+		if field.Code {
+			codeRefs = append(codeRefs, strings.FieldsFunc(value, isRefSeparator)...)
+		} else {
+			text = append(text, value)
+		}
+	}
+
+	if related := relatedCode(codeRefs, strings.Join(text, "\n"), idx); len(related) > 0 {
+		sb.WriteString("\n\n## Relevant code\n")
+		sb.WriteString(strings.Join(related, "\n"))
+	}
+
+	if len(t.Steps) > 0 {
+		sb.WriteString("\n\n## How to proceed")
+		for i, step := range t.Steps {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, step))
+		}
+	}
+	return sb.String(), nil
+}
+
+// relatedCode lists the files named in code fields as mentions, and the
+// definitions of symbols named in them or written like code in the text
+func relatedCode(refs []string, text string, idx *symbols.Index) []string {
+	var lines []string
+	seen := make(map[string]bool)
+	var names []string
+	for _, ref := range refs {
+		ref = strings.TrimPrefix(ref, "@")
+		if looksLikePath(ref) {
+			if !seen[ref] {
+				seen[ref] = true
+				lines = append(lines, "- @"+filepath.ToSlash(ref))
+			}
+			continue
+		}
+		names = append(names, ref)
+	}
+	if idx == nil {
+		return lines
+	}
+
+	for _, name := range symbols.Identifiers(text) {
+		if looksLikeCode(name) {
+			names = append(names, name)
+		}
+	}
+
+	count := 0
+	for _, name := range names {
+		// Allow Type.Method
+		receiver, method, isMethod := strings.Cut(name, ".")
+		if isMethod {
+			name = method
+		}
+		candidates := idx.Lookup(name)
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].File < candidates[j].File })
+		for _, sym := range candidates {
+			if isMethod && sym.Receiver != receiver {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", sym.File, sym.Line)
+			if seen[key] {
+				continue
+			}
+			if count >= maxRelatedSymbols {
+				return lines
+			}
+			seen[key] = true
+			count++
+			signature, _, _ := strings.Cut(sym.Signature, "\n")
+			lines = append(lines, fmt.Sprintf("- @%s:%d `%s`", sym.File, sym.Line, strings.TrimSpace(signature)))
+		}
+	}
+	return lines
+}
+
+// isRefSeparator splits the answer of a code field into references
+func isRefSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == ',' || r == ';' || r == '`'
+}
+
+// looksLikePath reports whether a reference names a file or directory
+// rather than a symbol
+func looksLikePath(ref string) bool {
+	if strings.ContainsAny(ref, `/\`) {
+		return true
+	}
+	ext := filepath.Ext(ref)
+	return ext != "" && ext != ref && strings.ToLower(ext) == ext && !strings.ContainsAny(ext[1:], "()")
+}
+
+// looksLikeCode reports whether an identifier in prose is likely a symbol
+// name: it has an underscore or a capital letter after the first one
+func looksLikeCode(name string) bool {
+	for i, r := range name {
+		if r == '_' || (i > 0 && unicode.IsUpper(r)) {
+			return true
+		}
+	}
+	return false
+}
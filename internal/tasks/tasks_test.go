@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/symbols"
+)
+
+const storeSource = `package store
+
+// Store keeps sessions
+type Store struct{}
+
+// SaveSession writes a session
+func (s *Store) SaveSession(id string) error { return nil }
+
+func loadIndex() {}
+
+func result() {}
+`
+
+func TestBuild(t *testing.T) {
+	idx := symbols.NewIndex("")
+	require.NoError(t, idx.AddFile("internal/store/store.go", []byte(storeSource)))
+	bug, ok := Lookup("bug")
+	require.True(t, ok)
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		index    *symbols.Index
+		contains []string
+		excludes []string
+		err      string
+	}{
+		{
+			name:   "required field missing",
+			values: map[string]string{"expected": "no panic"},
+			err:    "what goes wrong is required",
+		},
+		{
+			name:   "answers become sections",
+			values: map[string]string{"problem": "saving panics", "errors": "nil pointer dereference"},
+			contains: []string{
+				"Fix the following bug in this workspace.\n\n## What goes wrong\nsaving panics\n\n## Error output\nnil pointer dereference",
+				"## How to proceed\n1. Read the related code",
+			},
+			excludes: []string{"## Expected behavior", "## Relevant code"},
+		},
+		{
+			name:   "files and symbols are located",
+			values: map[string]string{"problem": "Store.SaveSession drops the loadIndex result", "code": "@internal/ui/model.go, Store"},
+			index:  idx,
+			contains: []string{
+				"## Relevant code\n- @internal/ui/model.go\n- @internal/store/store.go:4 `type Store struct{}`\n- @internal/store/store.go:7 `func (s *Store) SaveSession(id string) error`\n- @internal/store/store.go:9 `func loadIndex()`",
+			},
+			// Plain lower case words are not looked up
+			excludes: []string{"func result()"},
+		},
+		{
+			name:     "files without an index",
+			values:   map[string]string{"problem": "panics", "code": "handler.go SaveSession"},
+			contains: []string{"## Relevant code\n- @handler.go\n\n## How to proceed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := Build(bug, tt.values, tt.index)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, prompt, s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, prompt, s)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, tmpl := range Builtin() {
+		found, ok := Lookup(tmpl.Name)
+		assert.True(t, ok)
+		assert.Equal(t, tmpl.Name, found.Name)
+		assert.NotEmpty(t, tmpl.Fields)
+		assert.True(t, tmpl.Fields[0].Required, "%s asks the required question first", tmpl.Name)
+	}
+	_, ok := Lookup("refactor")
+	assert.False(t, ok)
+}
@@ -0,0 +1,183 @@
+package textdiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// hunkHeader matches the header of a unified diff hunk
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// IsUnified reports whether text looks like a unified diff
+func IsUnified(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if hunkHeader.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyUnified applies the hunks of a unified diff to before. Hunks are
+// located by their context and removed lines rather than their line
+// numbers, which generated diffs often get wrong.
+func ApplyUnified(before, diff string) (string, error) {
+	hunks := parseHunks(diff)
+	if len(hunks) == 0 {
+		return "", fmt.Errorf("no hunks in diff")
+	}
+
+	lines := strings.Split(before, "\n")
+	from := 0
+	for i, hunk := range hunks {
+		at := findLines(lines, hunk.old, from)
+		if at < 0 {
+			at = findLines(lines, hunk.old, 0)
+		}
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d does not match the file", i+1)
+		}
+		lines = append(lines[:at], append(append([]string(nil), hunk.new...), lines[at+len(hunk.old):]...)...)
+		from = at + len(hunk.new)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+type hunk struct {
+	old []string
+	new []string
+}
+
+// parseHunks reads the hunks of a unified diff, ignoring file headers
+func parseHunks(diff string) []hunk {
+	var hunks []hunk
+	var current *hunk
+	for _, line := range strings.Split(diff, "\n") {
+		if hunkHeader.MatchString(line) {
+			hunks = append(hunks, hunk{})
+			current = &hunks[len(hunks)-1]
+			continue
+		}
+		if current == nil || strings.HasPrefix(line, `\`) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "-"):
+			current.old = append(current.old, line[1:])
+		case strings.HasPrefix(line, "+"):
+			current.new = append(current.new, line[1:])
+		case strings.HasPrefix(line, " "):
+			current.old = append(current.old, line[1:])
+			current.new = append(current.new, line[1:])
+		case line == "":
+			// Blank context lines lose their leading space in pasted diffs
+			current.old = append(current.old, "")
+			current.new = append(current.new, "")
+		default:
+			current = nil
+		}
+	}
+
+	// Trailing blank lines come from the end of the text, not the diff
+	for i := range hunks {
+		h := &hunks[i]
+		for len(h.old) > 0 && len(h.new) > 0 && h.old[len(h.old)-1] == "" && h.new[len(h.new)-1] == "" {
+			h.old, h.new = h.old[:len(h.old)-1], h.new[:len(h.new)-1]
+		}
+	}
+	return hunks
+}
+
+// findLines returns the index of the first occurrence of want in lines at
+// or after from, or -1
+func findLines(lines, want []string, from int) int {
+	if len(want) == 0 {
+		return -1
+	}
+	for i := from; i+len(want) <= len(lines); i++ {
+		match := true
+		for j, line := range want {
+			if lines[i+j] != line {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// ApplyFragment replaces the part of before that fragment is a new version
+// of, such as a function or a config section. The part starts at the only
+// line matching the fragment's first line and ends at the first line after
+// it matching the fragment's last line with the same indentation. The
+// fragment is re-indented to the part's indentation.
+func ApplyFragment(before, fragment string) (string, error) {
+	frag := trimBlankLines(strings.Split(strings.TrimRight(fragment, "\n"), "\n"))
+	if len(frag) == 0 {
+		return "", fmt.Errorf("the code block is empty")
+	}
+	lines := strings.Split(before, "\n")
+
+	first := strings.TrimSpace(frag[0])
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == first {
+			if start >= 0 {
+				return "", fmt.Errorf("the first line of the block (%q) appears more than once in the file", first)
+			}
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", fmt.Errorf("the first line of the block (%q) is not in the file", first)
+	}
+
+	// Code blocks often drop the indentation of the part they replace
+	shift := ""
+	if fileIndent, fragIndent := leadingSpace(lines[start]), leadingSpace(frag[0]); len(fileIndent) > len(fragIndent) {
+		shift = fileIndent[:len(fileIndent)-len(fragIndent)]
+	}
+	for i, line := range frag {
+		if line != "" {
+			frag[i] = shift + line
+		}
+	}
+
+	end := start
+	if len(frag) > 1 {
+		last := frag[len(frag)-1]
+		end = -1
+		for i := start + 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == strings.TrimSpace(last) && leadingSpace(lines[i]) == leadingSpace(last) {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("the last line of the block (%q) is not in the file after its first line", strings.TrimSpace(last))
+		}
+	}
+
+	after := append(append(append([]string(nil), lines[:start]...), frag...), lines[end+1:]...)
+	return strings.Join(after, "\n"), nil
+}
+
+// trimBlankLines drops the blank lines around lines
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// leadingSpace returns the indentation of line
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
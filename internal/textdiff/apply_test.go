@@ -0,0 +1,74 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyUnified(t *testing.T) {
+	before := "package main\n\nfunc a() {}\n\nfunc b() {\n\treturn\n}\n"
+
+	// Line numbers are ignored; the hunk is found by its content
+	after, err := ApplyUnified(before, "--- a/main.go\n+++ b/main.go\n@@ -40,3 +40,3 @@\n func b() {\n-\treturn\n+\tprintln(\"b\")\n }\n")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc a() {}\n\nfunc b() {\n\tprintln(\"b\")\n}\n", after)
+
+	_, err = ApplyUnified(before, "@@ -1 +1 @@\n-package other\n+package main\n")
+	assert.ErrorContains(t, err, "hunk 1 does not match")
+
+	_, err = ApplyUnified(before, "just text")
+	assert.Error(t, err)
+
+	assert.True(t, IsUnified("```\n@@ -1,2 +1,2 @@\n"))
+	assert.False(t, IsUnified("func main() {}"))
+}
+
+func TestApplyFragment(t *testing.T) {
+	before := "type T struct{}\n\nfunc (t T) A() {\n\tif x {\n\t\treturn\n\t}\n}\n\nfunc (t T) B() {}\n"
+
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "replaces a function up to its closing brace",
+			fragment: "func (t T) A() {\n\treturn\n}",
+			want:     "type T struct{}\n\nfunc (t T) A() {\n\treturn\n}\n\nfunc (t T) B() {}\n",
+		},
+		{
+			name:     "re-indents a nested fragment",
+			fragment: "if x {\n\tpanic(1)\n}\n",
+			want:     "type T struct{}\n\nfunc (t T) A() {\n\tif x {\n\t\tpanic(1)\n\t}\n}\n\nfunc (t T) B() {}\n",
+		},
+		{
+			name:     "single line",
+			fragment: "func (t T) B() {}",
+			want:     before,
+		},
+		{
+			name:     "unknown first line",
+			fragment: "func C() {}",
+			wantErr:  "is not in the file",
+		},
+		{
+			name:     "ambiguous first line",
+			fragment: "}\n",
+			wantErr:  "appears more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after, err := ApplyFragment(before, tt.fragment)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, after)
+		})
+	}
+}
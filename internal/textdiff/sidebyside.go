@@ -0,0 +1,81 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SideBySide renders two texts in columns of the given width, marking
+// changed lines with "|", removed ones with "<" and added ones with ">".
+// Unchanged runs longer than the context around changes are folded. It is
+// empty when nothing changed.
+func SideBySide(before, after string, width int) string {
+	if before == after {
+		return ""
+	}
+	width = max(width, 8)
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var rows []string
+	row := func(left, marker, right string) {
+		rows = append(rows, strings.TrimRight(fmt.Sprintf("%-*s %s %s", width, column(left, width), marker, column(right, width)), " "))
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			end := i
+			for end < len(ops) && ops[end].kind == opEqual {
+				end++
+			}
+			// Keep the context after the previous change and before the next one
+			head, tail := contextLines, contextLines
+			if i == 0 {
+				head = 0
+			}
+			if end == len(ops) {
+				tail = 0
+			}
+			if end-i > head+tail {
+				for _, op := range ops[i : i+head] {
+					row(op.text, " ", op.text)
+				}
+				rows = append(rows, fmt.Sprintf("⋯ %d unchanged lines", end-i-head-tail))
+				i = end - tail
+			}
+			for ; i < end; i++ {
+				row(ops[i].text, " ", ops[i].text)
+			}
+			continue
+		}
+
+		// Pair the removed and added lines of a change
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind != opEqual; i++ {
+			if ops[i].kind == opDelete {
+				removed = append(removed, ops[i].text)
+			} else {
+				added = append(added, ops[i].text)
+			}
+		}
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			switch {
+			case j >= len(removed):
+				row("", ">", added[j])
+			case j >= len(added):
+				row(removed[j], "<", "")
+			default:
+				row(removed[j], "|", added[j])
+			}
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// column fits a line into a column, replacing tabs so widths line up
+func column(line string, width int) string {
+	runes := []rune(strings.ReplaceAll(strings.TrimRight(line, "\r\n"), "\t", "    "))
+	if len(runes) <= width {
+		return string(runes)
+	}
+	return string(runes[:width-1]) + "…"
+}
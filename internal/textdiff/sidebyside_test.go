@@ -0,0 +1,25 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSideBySide(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	after := "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nadded line that is long\n"
+
+	assert.Equal(t, `a        | A
+b          b
+c          c
+d          d
+⋯ 4 unchanged lines
+i          i
+j          j
+k          k
+         > added l…`, SideBySide(before, after, 8))
+
+	assert.Equal(t, "one      <\ntwo        two", SideBySide("one\ntwo\n", "two\n", 8))
+	assert.Empty(t, SideBySide("same", "same", 8))
+}
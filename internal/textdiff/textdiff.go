@@ -0,0 +1,217 @@
+// Package textdiff renders line-based unified diffs and applies edits given
+// as diffs or code fragments.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// maxDiffCells bounds the work of the line matching; larger changes are
+// shown as a full replacement
+const maxDiffCells = 4_000_000
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type lineOp struct {
+	kind opKind
+	text string
+}
+
+// Unified returns a unified diff between two versions of a file along with
+// the number of added and removed lines. It is empty when nothing changed.
+func Unified(path string, before, after string, existedBefore, existsAfter bool) (string, int, int) {
+	if before == after && existedBefore == existsAfter {
+		return "", 0, 0
+	}
+
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case opInsert:
+			added++
+		case opDelete:
+			removed++
+		}
+	}
+
+	var sb strings.Builder
+	from, to := "a/"+path, "b/"+path
+	if !existedBefore {
+		from = "/dev/null"
+	}
+	if !existsAfter {
+		to = "/dev/null"
+	}
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", from, to))
+	writeHunks(&sb, ops)
+
+	return sb.String(), added, removed
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes an edit script with a longest common subsequence over
+// the lines that differ after trimming the common prefix and suffix
+func diffLines(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]lineOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, lineOp{opEqual, line})
+	}
+
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, lineOp{opEqual, line})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []lineOp {
+	var ops []lineOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, lineOp{opDelete, line})
+		}
+		for _, line := range b {
+			ops = append(ops, lineOp{opInsert, line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, lineOp{opEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, lineOp{opDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, lineOp{opInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, lineOp{opDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, lineOp{opInsert, b[j]})
+	}
+	return ops
+}
+
+// writeHunks groups the edit script into hunks with surrounding context
+func writeHunks(sb *strings.Builder, ops []lineOp) {
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == opEqual {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != opEqual {
+				last = k
+			} else if k-last > 2*contextLines {
+				break
+			}
+		}
+
+		from := max(first-contextLines, start)
+		to := min(last+contextLines+1, len(ops))
+
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != opInsert {
+				oldStart++
+			}
+			if op.kind != opDelete {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != opInsert {
+				oldCount++
+			}
+			if op.kind != opDelete {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+		for _, op := range ops[from:to] {
+			prefix := " "
+			switch op.kind {
+			case opDelete:
+				prefix = "-"
+			case opInsert:
+				prefix = "+"
+			}
+			sb.WriteString(prefix + op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = to
+	}
+}
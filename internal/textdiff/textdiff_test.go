@@ -0,0 +1,39 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+	diff, added, removed := Unified("x.txt", before, after, true, true)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`, diff)
+
+	diff, added, removed = Unified("new.txt", "", "one\ntwo", false, true)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n\\ No newline at end of file\n", diff)
+
+	diff, _, _ = Unified("same.txt", "x\n", "x\n", true, true)
+	assert.Empty(t, diff)
+}
@@ -0,0 +1,76 @@
+package tokenizer
+
+import "strings"
+
+// maxPending bounds the text encoded again with each chunk when a response
+// has no word boundaries, such as a long base64 string
+const maxPending = 4096
+
+// StreamCounter counts the tokens of a streamed response as it arrives.
+// Only the text since the last word boundary is encoded again with each
+// chunk, so a chunk costs time in proportion to its own length rather than
+// to the whole response. Tokens rarely span a word boundary, so the count
+// stays within a few tokens of encoding the whole response at once.
+type StreamCounter struct {
+	model       string
+	settled     int    // Tokens of the text before pending
+	pending     string // Text since the last word boundary
+	approximate bool
+}
+
+// NewStreamCounter creates a counter for a response of model
+func NewStreamCounter(model string) *StreamCounter {
+	return &StreamCounter{model: model}
+}
+
+// Add counts a chunk of the response and returns the tokens so far
+func (c *StreamCounter) Add(chunk string) int {
+	c.pending += chunk
+
+	cut := lastBoundary(c.pending)
+	if cut <= 0 && len(c.pending) > maxPending {
+		cut = len(c.pending)
+	}
+	if cut > 0 {
+		c.settled += c.count(c.pending[:cut])
+		c.pending = c.pending[cut:]
+	}
+	return c.Tokens()
+}
+
+// Tokens returns the tokens of the response so far, including the message
+// overhead that Count adds
+func (c *StreamCounter) Tokens() int {
+	if c.settled == 0 && c.pending == "" {
+		return 0
+	}
+	return c.settled + c.count(c.pending) + messageOverhead
+}
+
+// Approximate reports whether any of the response was counted without the
+// model's encoding
+func (c *StreamCounter) Approximate() bool {
+	return c.approximate
+}
+
+// lastBoundary returns where the last word preceded by a single space
+// starts, or -1. Encodings keep the space with the word that follows it and
+// merge runs of whitespace, so the text is cut before such a space only.
+func lastBoundary(text string) int {
+	for i := len(text) - 1; i > 0; i-- {
+		if text[i] == ' ' && !strings.ContainsRune(" \t\r\n", rune(text[i-1])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// count returns the tokens of text without the message overhead
+func (c *StreamCounter) count(text string) int {
+	if text == "" {
+		return 0
+	}
+	tokens, approximate := Count(text, c.model)
+	c.approximate = c.approximate || approximate
+	return tokens - messageOverhead
+}
@@ -0,0 +1,61 @@
+package tokenizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+func TestStreamCounter(t *testing.T) {
+	response := "Here is the function you asked for:\n\n```go\nfunc add(a, b int) int {\n\treturn a + b\n}\n```\n\nIt adds two integers."
+	want, approximate := Count(response, "gpt-4")
+	assert.False(t, approximate)
+
+	tests := []struct {
+		name      string
+		chunkSize int
+	}{
+		{name: "single chunk", chunkSize: len(response)},
+		{name: "small chunks", chunkSize: 3},
+		{name: "one byte at a time", chunkSize: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := NewStreamCounter("gpt-4")
+			assert.Equal(t, 0, counter.Tokens())
+
+			var got int
+			for i := 0; i < len(response); i += tt.chunkSize {
+				got = counter.Add(response[i:min(i+tt.chunkSize, len(response))])
+			}
+			assert.InDelta(t, want, got, 3, "counting the deltas stays close to counting the whole response")
+			assert.Equal(t, got, counter.Tokens())
+			assert.False(t, counter.Approximate())
+		})
+	}
+}
+
+func TestStreamCounterBoundsPending(t *testing.T) {
+	counter := NewStreamCounter("gpt-4")
+	blob := strings.Repeat("QUJD", maxPending)
+	for i := 0; i < len(blob); i += 64 {
+		counter.Add(blob[i : i+64])
+	}
+	assert.LessOrEqual(t, len(counter.pending), maxPending, "text without boundaries is settled once it grows")
+	assert.Positive(t, counter.Tokens())
+}
+
+func TestStreamCounterApproximates(t *testing.T) {
+	failCodecs(t, func(tokenizer.Encoding) (tokenizer.Codec, error) {
+		return nil, errors.New("offline")
+	})
+
+	counter := NewStreamCounter("gpt-4")
+	counter.Add("hello ")
+	tokens := counter.Add("world")
+	assert.True(t, counter.Approximate())
+	assert.Equal(t, ApproximateTokens("hello")-messageOverhead+ApproximateTokens(" world"), tokens)
+}
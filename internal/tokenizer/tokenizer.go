@@ -3,12 +3,35 @@ package tokenizer
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/tiktoken-go/tokenizer"
 
 	"github.com/common-creation/coda/internal/ai"
 )
 
+// messageOverhead is the number of tokens added per message for its
+// structure (role, content markers)
+const messageOverhead = 4
+
+// getCodec loads an encoding; replaced in tests
+var getCodec = tokenizer.Get
+
+type codecResult struct {
+	codec tokenizer.Codec
+	err   error
+
+	// ready is closed once the encoding is loaded
+	ready chan struct{}
+}
+
+var (
+	codecsMu sync.Mutex
+	codecs   = make(map[tokenizer.Encoding]codecResult)
+	degraded error // First encoding that failed to load
+)
+
 // EstimateTokens estimates the number of tokens for a prompt with messages
 func EstimateTokens(messages []ai.Message, model string) (int, error) {
 	// Get the appropriate encoding for the model
@@ -23,10 +46,10 @@ func EstimateTokens(messages []ai.Message, model string) (int, error) {
 	for _, msg := range messages {
 		// Add tokens for message structure (role, content markers)
 		// OpenAI models typically use ~4 tokens per message for structure
-		totalTokens += 4
+		totalTokens += messageOverhead
 
 		// Count role tokens
-		roleTokens, _, err := encoding.Encode(msg.Role)
+		roleTokens, err := encode(encoding, msg.Role)
 		if err != nil {
 			return 0, fmt.Errorf("failed to encode role: %w", err)
 		}
@@ -34,7 +57,7 @@ func EstimateTokens(messages []ai.Message, model string) (int, error) {
 
 		// Count content tokens
 		if msg.Content != "" {
-			contentTokens, _, err := encoding.Encode(msg.Content)
+			contentTokens, err := encode(encoding, msg.Content)
 			if err != nil {
 				return 0, fmt.Errorf("failed to encode content: %w", err)
 			}
@@ -65,18 +88,75 @@ func EstimateUserMessageTokens(message string, model string) (int, error) {
 		return 0, fmt.Errorf("failed to get encoding for model %s: %w", model, err)
 	}
 
-	tokens, _, err := encoding.Encode(message)
+	tokens, err := encode(encoding, message)
 	if err != nil {
 		return 0, fmt.Errorf("failed to encode message of length %d: %w", len(message), err)
 	}
 
 	// Add message structure overhead
-	tokenCount := len(tokens) + 4
+	tokenCount := len(tokens) + messageOverhead
 	return tokenCount, nil
 }
 
+// Count returns the tokens of message for model. When the model's encoding
+// cannot be used it falls back to ApproximateTokens and reports approximate.
+func Count(message string, model string) (tokens int, approximate bool) {
+	if message == "" {
+		return 0, false
+	}
+	// Counting never waits for an encoding that is still being preloaded
+	if loading(encodingForModel(model)) {
+		return ApproximateTokens(message), true
+	}
+	tokens, err := EstimateUserMessageTokens(message, model)
+	if err != nil {
+		return ApproximateTokens(message), true
+	}
+	return tokens, false
+}
+
+// ApproximateTokens estimates the tokens of a message without an encoding:
+// about four ASCII characters or one other character per token
+func ApproximateTokens(message string) int {
+	ascii, other := 0, 0
+	for _, r := range message {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other + messageOverhead
+}
+
+// Degraded returns why an encoding could not be loaded, or nil when every
+// encoding used so far works. Counts are approximate while it is not nil.
+func Degraded() error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	return degraded
+}
+
+// Preload loads the encoding of model. It is meant to run in the background
+// during startup: Count approximates until it returns.
+func Preload(model string) error {
+	_, err := getEncodingForModel(model)
+	return err
+}
+
 // getEncodingForModel returns the appropriate tokenizer encoding for a model
 func getEncodingForModel(model string) (tokenizer.Codec, error) {
+	name := encodingForModel(model)
+	<-startLoad(name, model)
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	cached := codecs[name]
+	return cached.codec, cached.err
+}
+
+// encodingForModel returns the name of the encoding used by a model
+func encodingForModel(model string) tokenizer.Encoding {
 	// Default to cl100k_base for GPT-4 and GPT-3.5-turbo models
 	// This covers most modern OpenAI models
 	encodingName := tokenizer.Cl100kBase
@@ -91,10 +171,69 @@ func getEncodingForModel(model string) (tokenizer.Codec, error) {
 	} else if strings.HasPrefix(model, "code-") {
 		encodingName = tokenizer.P50kBase
 	}
+	return encodingName
+}
+
+// startLoad loads an encoding the first time it is asked for and returns a
+// channel closed once it is loaded, which callers racing the first load wait
+// on. Encodings are loaded once; a failure is not retried on every count.
+func startLoad(name tokenizer.Encoding, model string) chan struct{} {
+	codecsMu.Lock()
+	if cached, ok := codecs[name]; ok {
+		codecsMu.Unlock()
+		return cached.ready
+	}
+	ready := make(chan struct{})
+	codecs[name] = codecResult{ready: ready}
+	codecsMu.Unlock()
 
-	codec, err := tokenizer.Get(encodingName)
+	codec, err := loadCodec(name)
+	codecsMu.Lock()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tokenizer encoding %s for model %s: %w", encodingName, model, err)
+		err = fmt.Errorf("failed to get tokenizer encoding %s for model %s: %w", name, model, err)
+		if degraded == nil {
+			degraded = err
+		}
 	}
-	return codec, nil
+	codecs[name] = codecResult{codec: codec, err: err, ready: ready}
+	codecsMu.Unlock()
+	close(ready)
+	return ready
+}
+
+// loading reports whether an encoding is being loaded by another goroutine
+func loading(name tokenizer.Encoding) bool {
+	codecsMu.Lock()
+	cached, ok := codecs[name]
+	codecsMu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case <-cached.ready:
+		return false
+	default:
+		return true
+	}
+}
+
+// loadCodec loads an encoding, turning a panic of the tokenizer into an error
+func loadCodec(name tokenizer.Encoding) (codec tokenizer.Codec, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			codec, err = nil, fmt.Errorf("tokenizer panicked: %v", r)
+		}
+	}()
+	return getCodec(name)
+}
+
+// encode encodes text, turning a panic of the tokenizer into an error
+func encode(codec tokenizer.Codec, text string) (ids []uint, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ids, err = nil, fmt.Errorf("tokenizer panicked: %v", r)
+		}
+	}()
+	ids, _, err = codec.Encode(text)
+	return ids, err
 }
@@ -0,0 +1,91 @@
+package tokenizer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// failCodecs makes every encoding fail to load until the test ends
+func failCodecs(t *testing.T, load func(tokenizer.Encoding) (tokenizer.Codec, error)) {
+	reset := func() {
+		codecsMu.Lock()
+		codecs = make(map[tokenizer.Encoding]codecResult)
+		degraded = nil
+		codecsMu.Unlock()
+	}
+	orig := getCodec
+	getCodec = load
+	reset()
+	t.Cleanup(func() {
+		getCodec = orig
+		reset()
+	})
+}
+
+func TestCount(t *testing.T) {
+	tokens, approximate := Count("hello world", "gpt-4")
+	assert.False(t, approximate)
+	assert.Equal(t, 6, tokens)
+	assert.NoError(t, Degraded())
+
+	tokens, approximate = Count("", "gpt-4")
+	assert.False(t, approximate)
+	assert.Zero(t, tokens)
+}
+
+func TestCountDegrades(t *testing.T) {
+	tests := []struct {
+		name string
+		load func(tokenizer.Encoding) (tokenizer.Codec, error)
+	}{
+		{"error", func(tokenizer.Encoding) (tokenizer.Codec, error) { return nil, errors.New("no data") }},
+		{"panic", func(tokenizer.Encoding) (tokenizer.Codec, error) { panic("corrupt table") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failCodecs(t, tt.load)
+
+			tokens, approximate := Count("hello world", "gpt-4")
+			assert.True(t, approximate)
+			assert.Equal(t, ApproximateTokens("hello world"), tokens)
+			assert.Error(t, Degraded())
+
+			_, err := EstimateTokens(nil, "gpt-4")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPreload(t *testing.T) {
+	release := make(chan struct{})
+	failCodecs(t, func(name tokenizer.Encoding) (tokenizer.Codec, error) {
+		<-release
+		return tokenizer.Get(name)
+	})
+
+	preloaded := make(chan error)
+	go func() { preloaded <- Preload("gpt-4") }()
+	require.Eventually(t, func() bool { return loading(tokenizer.Cl100kBase) }, time.Second, time.Millisecond)
+	tokens, approximate := Count("hello world", "gpt-4")
+	assert.True(t, approximate, "counting does not wait for the preload")
+	assert.Equal(t, ApproximateTokens("hello world"), tokens)
+
+	close(release)
+	require.NoError(t, <-preloaded)
+	tokens, approximate = Count("hello world", "gpt-4")
+	assert.False(t, approximate)
+	assert.Equal(t, 6, tokens)
+	assert.NoError(t, Degraded())
+}
+
+func TestApproximateTokens(t *testing.T) {
+	assert.Equal(t, messageOverhead, ApproximateTokens(""))
+	assert.Equal(t, 3+messageOverhead, ApproximateTokens("hello world"))
+	assert.Equal(t, 5+messageOverhead, ApproximateTokens("こんにちは"))
+}
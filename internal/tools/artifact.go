@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/common-creation/coda/internal/artifacts"
+)
+
+// SaveArtifactTool saves generated standalone files (reports, diagrams,
+// exports) under the session's artifacts directory instead of the project
+type SaveArtifactTool struct {
+	store *artifacts.Store
+}
+
+// NewSaveArtifactTool creates a new SaveArtifactTool instance
+func NewSaveArtifactTool(store *artifacts.Store) *SaveArtifactTool {
+	return &SaveArtifactTool{store: store}
+}
+
+func (a *SaveArtifactTool) Name() string {
+	return "save_artifact"
+}
+
+func (a *SaveArtifactTool) Description() string {
+	return "Save a generated standalone file (report, diagram, export) to the session's artifacts directory (.coda/artifacts/<session>/). Use this instead of write_file for outputs that are not part of the project source."
+}
+
+func (a *SaveArtifactTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"name": {
+				Type:        "string",
+				Description: "File name of the artifact, e.g. report.md or architecture.mmd",
+			},
+			"content": {
+				Type:        "string",
+				Description: "Content of the artifact",
+			},
+			"description": {
+				Type:        "string",
+				Description: "Short description shown in the artifact index",
+			},
+		},
+		Required: []string{"name", "content"},
+	}
+}
+
+func (a *SaveArtifactTool) Validate(params map[string]interface{}) error {
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return fmt.Errorf("name is required and must be a string")
+	}
+
+	if _, ok := params["content"].(string); !ok {
+		return fmt.Errorf("content is required and must be a string")
+	}
+
+	return nil
+}
+
+func (a *SaveArtifactTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	name := params["name"].(string)
+	content := params["content"].(string)
+	description, _ := params["description"].(string)
+
+	artifact, err := a.store.Save(SessionIDFromContext(ctx), name, description, []byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"path":  artifact.Path,
+		"size":  artifact.Size,
+		"saved": true,
+	}, nil
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/bench"
+)
+
+// RunBenchmarksToolName is the name of the benchmark tool
+const RunBenchmarksToolName = "run_benchmarks"
+
+const (
+	defaultBenchCount     = 5
+	maxBenchCount         = 20
+	defaultBenchThreshold = 5.0
+	benchTimeout          = 15 * time.Minute
+)
+
+// RunBenchmarksTool runs Go benchmarks and compares them against stored
+// results, so the model can check a change for performance regressions
+type RunBenchmarksTool struct {
+	workDir string
+	store   *bench.Store
+}
+
+// NewRunBenchmarksTool creates a new RunBenchmarksTool running in workDir and
+// storing results under its .coda/benchmarks directory
+func NewRunBenchmarksTool(workDir string) *RunBenchmarksTool {
+	return &RunBenchmarksTool{
+		workDir: workDir,
+		store:   bench.NewStore(filepath.Join(workDir, bench.DefaultDir)),
+	}
+}
+
+func (b *RunBenchmarksTool) Name() string {
+	return RunBenchmarksToolName
+}
+
+func (b *RunBenchmarksTool) Description() string {
+	return "Run Go benchmarks (go test -bench -benchmem) and compare them with stored results, reporting the median change of each metric with its significance and whether it is a regression. Save results before a change (save_as: baseline), then run again after it to compare."
+}
+
+func (b *RunBenchmarksTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"packages": {
+				Type:        "string",
+				Description: "Space-separated package patterns to benchmark",
+				Default:     "./...",
+			},
+			"bench": {
+				Type:        "string",
+				Description: "Regular expression selecting the benchmarks (go test -bench)",
+				Default:     ".",
+			},
+			"count": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Runs of each benchmark; more runs make the comparison more reliable (max %d)", maxBenchCount),
+				Default:     defaultBenchCount,
+			},
+			"benchtime": {
+				Type:        "string",
+				Description: "Time or iterations per run, e.g. 1s or 1000x",
+			},
+			"save_as": {
+				Type:        "string",
+				Description: "Store the results under this name, e.g. baseline",
+			},
+			"compare_to": {
+				Type:        "string",
+				Description: "Name of stored results to compare with",
+				Default:     bench.DefaultBaseline,
+			},
+			"threshold": {
+				Type:        "number",
+				Description: "Minimum change in percent reported as a regression or improvement",
+				Default:     defaultBenchThreshold,
+			},
+		},
+	}
+}
+
+// SandboxLimits lets benchTimeout, not the sandbox, stop a long benchmark run
+func (b *RunBenchmarksTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(benchTimeout)
+}
+
+func (b *RunBenchmarksTool) Validate(params map[string]interface{}) error {
+	for _, key := range []string{"packages", "bench", "benchtime", "save_as", "compare_to"} {
+		if value, exists := params[key]; exists {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("%s must be a string", key)
+			}
+		}
+	}
+	if value, exists := params["count"]; exists {
+		count, ok := numberParam(value)
+		if !ok || count < 1 || count > maxBenchCount {
+			return fmt.Errorf("count must be a number between 1 and %d", maxBenchCount)
+		}
+	}
+	if value, exists := params["threshold"]; exists {
+		threshold, ok := numberParam(value)
+		if !ok || threshold < 0 {
+			return fmt.Errorf("threshold must be a non-negative number")
+		}
+	}
+	return nil
+}
+
+func (b *RunBenchmarksTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	opts := bench.Options{Count: defaultBenchCount}
+	if packages, _ := params["packages"].(string); packages != "" {
+		opts.Packages = strings.Fields(packages)
+	}
+	opts.Bench, _ = params["bench"].(string)
+	opts.Benchtime, _ = params["benchtime"].(string)
+	if count, ok := numberParam(params["count"]); ok {
+		opts.Count = int(count)
+	}
+	threshold := defaultBenchThreshold
+	if value, ok := numberParam(params["threshold"]); ok {
+		threshold = value
+	}
+	saveAs, _ := params["save_as"].(string)
+	compareTo, _ := params["compare_to"].(string)
+	if compareTo == "" {
+		compareTo = bench.DefaultBaseline
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, benchTimeout)
+	defer cancel()
+	current, err := bench.Run(ctx, b.workDir, opts)
+	if err != nil {
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	if len(current.Benchmarks) == 0 {
+		return nil, NewToolError(ErrCodeNotFound, "no benchmarks matched %q", opts.Bench)
+	}
+
+	result := map[string]interface{}{
+		"benchmarks": summarizeBenchmarks(current),
+	}
+
+	// Compare before saving, so saving over the baseline still compares to it
+	base, err := b.store.Load(compareTo)
+	switch {
+	case errors.Is(err, bench.ErrNotFound):
+		result["message"] = fmt.Sprintf("No stored results named %q to compare with; run with save_as: %q to store a baseline", compareTo, compareTo)
+	case err != nil:
+		return nil, err
+	default:
+		report := bench.Compare(base, current, threshold)
+		result["compared_to"] = compareTo
+		result["comparisons"] = report.Comparisons
+		result["regressions"] = len(report.Regressions())
+		result["improvements"] = len(report.Improvements())
+		result["report"] = report.String()
+		if len(report.Added) > 0 {
+			result["added"] = report.Added
+		}
+		if len(report.Removed) > 0 {
+			result["removed"] = report.Removed
+		}
+		result["message"] = fmt.Sprintf("%d regressions and %d improvements compared to %q (changes of at least %.1f%% with p < %.2f)",
+			len(report.Regressions()), len(report.Improvements()), compareTo, threshold, bench.Alpha)
+	}
+
+	if saveAs != "" {
+		if err := b.store.Save(saveAs, current); err != nil {
+			return nil, err
+		}
+		result["saved_as"] = saveAs
+	}
+	return result, nil
+}
+
+// numberParam returns a numeric parameter, which JSON decodes as float64
+func numberParam(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// summarizeBenchmarks returns the median of each metric of each benchmark
+func summarizeBenchmarks(results *bench.Results) []map[string]interface{} {
+	summary := make([]map[string]interface{}, 0, len(results.Benchmarks))
+	for _, b := range results.Benchmarks {
+		medians := make(map[string]float64, len(b.Values))
+		runs := 0
+		for _, unit := range b.Units() {
+			medians[unit] = bench.Median(b.Values[unit])
+			runs = max(runs, len(b.Values[unit]))
+		}
+		summary = append(summary, map[string]interface{}{
+			"name":    b.Key(),
+			"runs":    runs,
+			"medians": medians,
+		})
+	}
+	return summary
+}
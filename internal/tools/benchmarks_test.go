@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmarksToolValidate(t *testing.T) {
+	tool := NewRunBenchmarksTool(t.TempDir())
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		wantErr bool
+	}{
+		{"defaults", map[string]interface{}{}, false},
+		{"all set", map[string]interface{}{"packages": "./a ./b", "bench": "Sort", "count": float64(3), "save_as": "before", "threshold": float64(10)}, false},
+		{"count too high", map[string]interface{}{"count": float64(50)}, true},
+		{"count not a number", map[string]interface{}{"count": "5"}, true},
+		{"negative threshold", map[string]interface{}{"threshold": float64(-1)}, true},
+		{"packages not a string", map[string]interface{}{"packages": []string{"./..."}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(tt.params)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunBenchmarksTool(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	// The temporary module must not use the go flags of the environment
+	t.Setenv("GOFLAGS", "")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sum_test.go"), []byte(`package m
+
+import "testing"
+
+func BenchmarkSum(b *testing.B) {
+	total := 0
+	for i := 0; i < b.N; i++ {
+		total += i
+	}
+	_ = total
+}
+`), 0644))
+
+	tool := NewRunBenchmarksTool(dir)
+	params := map[string]interface{}{"count": float64(2), "benchtime": "100x", "save_as": "baseline"}
+	result, err := tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, "baseline", info["saved_as"])
+	assert.Contains(t, info["message"], "No stored results")
+	benchmarks := info["benchmarks"].([]map[string]interface{})
+	require.Len(t, benchmarks, 1)
+	assert.Contains(t, benchmarks[0]["name"], "example.com/m.BenchmarkSum")
+	assert.Equal(t, 2, benchmarks[0]["runs"])
+	assert.FileExists(t, filepath.Join(dir, ".coda", "benchmarks", "baseline.json"))
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"count": float64(2), "benchtime": "100x"})
+	require.NoError(t, err)
+	info = result.(map[string]interface{})
+	assert.Equal(t, "baseline", info["compared_to"])
+	assert.Contains(t, info, "regressions")
+	assert.Contains(t, info["report"], "BenchmarkSum")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"bench": "Nothing", "count": float64(1)})
+	assert.ErrorContains(t, err, "no benchmarks matched")
+}
+
+func TestRunBenchmarksToolSandboxLimits(t *testing.T) {
+	assertOwnTimeoutApplies(t, NewRunBenchmarksTool(t.TempDir()), benchTimeout)
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/common-creation/coda/internal/security"
+)
+
+// maxCachedResults bounds the results a ResultCache keeps
+const maxCachedResults = 256
+
+// cachedTools are the tools whose results are cached. Their "path" parameter
+// is the file (read_file) or directory they read.
+var cachedTools = map[string]bool{
+	"read_file":    true,
+	"list_files":   true,
+	"search_files": true,
+}
+
+type cachedResult struct {
+	result  interface{}
+	path    string // Absolute path the result was read from
+	dir     bool   // Whether the result depends on everything under path
+	modTime time.Time
+	size    int64
+	stored  time.Time
+}
+
+// ResultCache reuses the results of read_file, list_files and search_files
+// within a session. A result is reused while the path it was read from keeps
+// its modification time and size, until a mutating tool runs, or until
+// Invalidate reports a change under it.
+type ResultCache struct {
+	mu      sync.Mutex
+	results map[string]*cachedResult
+}
+
+// NewResultCache creates an empty cache
+func NewResultCache() *ResultCache {
+	return &ResultCache{results: make(map[string]*cachedResult)}
+}
+
+// Middleware returns the middleware serving cached results
+func (c *ResultCache) Middleware() Middleware {
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+			if !cachedTools[name] {
+				result, err := next(ctx, name, params)
+				if !security.IsReadOnlyTool(name) && !IsDryRunRequested(params) {
+					c.afterMutation(name, params)
+				}
+				return result, err
+			}
+
+			key, ok := cacheKey(SessionIDFromContext(ctx), name, params)
+			if !ok {
+				return next(ctx, name, params)
+			}
+			if result, ok := c.lookup(key); ok {
+				return result, nil
+			}
+
+			// Stat before running so a change made while it runs is not missed
+			path, _ := params["path"].(string)
+			if path == "" {
+				path = "."
+			}
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return next(ctx, name, params)
+			}
+			info, statErr := os.Stat(abs)
+
+			result, err := next(ctx, name, params)
+			if err == nil && statErr == nil {
+				c.store(key, &cachedResult{
+					result:  result,
+					path:    abs,
+					dir:     name != "read_file",
+					modTime: info.ModTime(),
+					size:    info.Size(),
+				})
+			}
+			return result, err
+		}
+	}
+}
+
+// cacheKey identifies a call by session, tool and parameters
+func cacheKey(session, name string, params map[string]interface{}) (string, bool) {
+	data, err := json.Marshal(params) // Map keys are sorted
+	if err != nil {
+		return "", false
+	}
+	return session + "\x00" + name + "\x00" + string(data), true
+}
+
+// lookup returns the cached result for key if its path is unchanged
+func (c *ResultCache) lookup(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.results[key]
+	if !ok {
+		return nil, false
+	}
+	info, err := os.Stat(entry.path)
+	if err != nil || !info.ModTime().Equal(entry.modTime) || info.Size() != entry.size {
+		delete(c.results, key)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// store caches entry, evicting the oldest result when the cache is full
+func (c *ResultCache) store(key string, entry *cachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.results) >= maxCachedResults {
+		var oldest string
+		for k, e := range c.results {
+			if oldest == "" || e.stored.Before(c.results[oldest].stored) {
+				oldest = k
+			}
+		}
+		delete(c.results, oldest)
+	}
+	entry.stored = time.Now()
+	c.results[key] = entry
+}
+
+// afterMutation drops the results a mutating tool call may have changed.
+// Tools without a path (e.g. run_command) can change anything.
+func (c *ResultCache) afterMutation(name string, params map[string]interface{}) {
+	path, _ := params["path"].(string)
+	if path == "" || name == RunCommandToolName {
+		c.Clear()
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		c.Invalidate(abs)
+	}
+}
+
+// Invalidate drops the results read from the changed absolute paths: the
+// results of the files themselves and of the directories containing them
+func (c *ResultCache) Invalidate(paths ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.results {
+		for _, path := range paths {
+			if path == entry.path || (entry.dir && isWithin(entry.path, path)) {
+				delete(c.results, key)
+				break
+			}
+		}
+	}
+}
+
+// Clear drops every cached result
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = make(map[string]*cachedResult)
+}
+
+// isWithin reports whether path is inside dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
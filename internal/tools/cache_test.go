@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTool counts its executions and returns the count
+type countingTool struct {
+	ListFilesTool
+	name  string
+	calls int
+}
+
+func (c *countingTool) Name() string { return c.name }
+
+func (c *countingTool) Validate(params map[string]interface{}) error { return nil }
+
+func (c *countingTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	c.calls++
+	return c.calls, nil
+}
+
+func newCacheTestManager(t *testing.T) (*Manager, *ResultCache, map[string]*countingTool) {
+	t.Helper()
+	m := NewManager(nil, nil)
+	counters := make(map[string]*countingTool)
+	for _, name := range []string{"read_file", "list_files", "write_file"} {
+		counters[name] = &countingTool{name: name}
+		require.NoError(t, m.Register(counters[name]))
+	}
+	cache := NewResultCache()
+	m.Use(cache.Middleware())
+	return m, cache, counters
+}
+
+func TestResultCache(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("one"), 0644))
+
+	m, cache, counters := newCacheTestManager(t)
+	ctx := WithSessionID(context.Background(), "s1")
+	read := func(ctx context.Context) interface{} {
+		result, err := m.Execute(ctx, "read_file", map[string]interface{}{"path": file})
+		require.NoError(t, err)
+		return result
+	}
+	list := func() interface{} {
+		result, err := m.Execute(ctx, "list_files", map[string]interface{}{"path": dir})
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.Equal(t, 1, read(ctx))
+	assert.Equal(t, 1, read(ctx), "served from the cache")
+	assert.Equal(t, 2, read(WithSessionID(context.Background(), "s2")), "not shared between sessions")
+
+	// A changed modification time is noticed without invalidation
+	require.NoError(t, os.Chtimes(file, time.Now().Add(time.Hour), time.Now().Add(time.Hour)))
+	assert.Equal(t, 3, read(ctx))
+
+	// Changes reported by the watcher drop files and the directories containing them
+	assert.Equal(t, 1, list())
+	assert.Equal(t, 1, list())
+	cache.Invalidate(filepath.Join(dir, "sub", "new.txt"))
+	assert.Equal(t, 2, list())
+	assert.Equal(t, 3, read(ctx))
+
+	// Mutating tools drop what they may have changed
+	_, err := m.Execute(ctx, "write_file", map[string]interface{}{"path": file})
+	require.NoError(t, err)
+	assert.Equal(t, 4, read(ctx))
+	assert.Equal(t, 3, list())
+	assert.Equal(t, 1, counters["write_file"].calls)
+}
+
+func TestIsWithin(t *testing.T) {
+	dir := filepath.Join("/", "work", "repo")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(dir, "a.go"), true},
+		{filepath.Join(dir, "pkg", "b.go"), true},
+		{dir, true},
+		{filepath.Join("/", "work", "other"), false},
+		{filepath.Join("/", "work", "repo2", "a.go"), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isWithin(dir, tt.path), tt.path)
+	}
+}
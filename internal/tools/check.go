@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// CompileCheckName is the tool name compile check results are reported as
+const CompileCheckName = "compile_check"
+
+// defaultCheckTimeout bounds a compile check that sets no timeout
+const defaultCheckTimeout = 2 * time.Minute
+
+// checkOutputLimit is how much of the end of a failing check's output is kept
+const checkOutputLimit = 8 * 1024
+
+// CompileCheck runs a quick build or typecheck command (e.g. "go build
+// ./..." or "tsc --noEmit") after tools change files
+type CompileCheck struct {
+	Command string
+	Dir     string
+	Timeout time.Duration
+}
+
+// Run runs the check. It returns whether it passed and, when it failed, the
+// tail of its output; err is set when the command could not run at all.
+func (c CompileCheck) Run(ctx context.Context) (bool, string, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, c.Command)
+	cmd.Dir = c.Dir
+	cmd.WaitDelay = commandWaitDelay
+	killProcessGroup(cmd)
+	output := NewTailBuffer(checkOutputLimit)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, "", nil
+	case ctx.Err() == context.DeadlineExceeded:
+		return false, fmt.Sprintf("%s\n[timed out after %s]", output.String(), timeout), nil
+	case errors.As(err, &exitErr):
+		return false, output.String(), nil
+	default:
+		return false, "", fmt.Errorf("failed to run compile check: %w", err)
+	}
+}
+
+// ChangesFiles reports whether a successful call of the tool name changed
+// files in the workspace
+func ChangesFiles(name string, result interface{}) bool {
+	if IsDryRunResult(result) {
+		return false
+	}
+	switch name {
+	case "write_file", "edit_file":
+		return true
+	case RenameSymbolToolName:
+		info, _ := result.(map[string]interface{})
+		applied, _ := info["applied"].(bool)
+		return applied
+	case DepsBumpToolName:
+		info, _ := result.(map[string]interface{})
+		changed, _ := info["changed"].(bool)
+		return changed
+	default:
+		return false
+	}
+}
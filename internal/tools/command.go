@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunCommandToolName is the name of the shell command tool
+const RunCommandToolName = "run_command"
+
+// defaultCommandOutputLimit is how much of the end of the output is sent to the model
+const defaultCommandOutputLimit = 16 * 1024
+
+// commandWaitDelay bounds the wait for children holding the output pipes
+// after the command is killed
+const commandWaitDelay = 2 * time.Second
+
+// RunCommandTool runs shell commands in the workspace. The output is
+// streamed to the writer set with WithOutputStream while the command runs,
+// and the model receives its tail.
+type RunCommandTool struct {
+	security    SecurityValidator
+	workDir     string
+	outputLimit int
+	inherit     []string          // Inherited variables; nil inherits all
+	secrets     map[string]string // Injected variables masked in the output
+}
+
+// CommandOption configures a RunCommandTool
+type CommandOption func(*RunCommandTool)
+
+// WithInheritedEnv limits the variables commands inherit to names matching
+// one of the patterns (globs such as "GO*")
+func WithInheritedEnv(patterns []string) CommandOption {
+	return func(r *RunCommandTool) {
+		r.inherit = patterns
+	}
+}
+
+// WithSecrets injects variables into commands. Their values are replaced
+// by the variable name in the output, so they never reach the transcript.
+func WithSecrets(secrets map[string]string) CommandOption {
+	return func(r *RunCommandTool) {
+		r.secrets = secrets
+	}
+}
+
+// NewRunCommandTool creates a new RunCommandTool that runs commands in workDir
+func NewRunCommandTool(security SecurityValidator, workDir string, opts ...CommandOption) *RunCommandTool {
+	r := &RunCommandTool{
+		security:    security,
+		workDir:     workDir,
+		outputLimit: defaultCommandOutputLimit,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *RunCommandTool) Name() string {
+	return RunCommandToolName
+}
+
+func (r *RunCommandTool) Description() string {
+	return "Run a shell command in the workspace and return its exit code and output"
+}
+
+func (r *RunCommandTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"command": {
+				Type:        "string",
+				Description: "Shell command to run",
+			},
+			"dir": {
+				Type:        "string",
+				Description: "Working directory, relative to the workspace",
+				Default:     ".",
+			},
+		},
+		Required: []string{"command"},
+	}
+}
+
+// SandboxLimits exempts commands from the sandbox timeout. Builds and test
+// runs often take longer, and the user stops a command by cancelling it.
+func (r *RunCommandTool) SandboxLimits() SandboxLimits {
+	return SandboxLimits{Timeout: NoTimeout}
+}
+
+func (r *RunCommandTool) Validate(params map[string]interface{}) error {
+	command, ok := params["command"].(string)
+	if !ok || strings.TrimSpace(command) == "" {
+		return fmt.Errorf("command is required and must be a non-empty string")
+	}
+	if dir, exists := params["dir"]; exists {
+		if _, ok := dir.(string); !ok {
+			return fmt.Errorf("dir must be a string")
+		}
+	}
+	return nil
+}
+
+func (r *RunCommandTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	command := params["command"].(string)
+
+	dir := r.workDir
+	if sub, ok := params["dir"].(string); ok && sub != "" && sub != "." {
+		dir = joinWorkDir(r.workDir, sub)
+	}
+	if r.security != nil {
+		if err := r.security.ValidatePath(dir); err != nil {
+			return nil, fmt.Errorf("invalid working directory: %w", err)
+		}
+	}
+
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	cmd.Env = r.environment(os.Environ())
+	cmd.WaitDelay = commandWaitDelay
+	killProcessGroup(cmd)
+
+	// The model gets the tail of the output; the stream gets all of it.
+	// Secrets are masked before either sees the output.
+	tail := NewTailBuffer(r.outputLimit)
+	var out io.Writer = tail
+	if stream := OutputStreamFromContext(ctx); stream != nil {
+		out = io.MultiWriter(tail, stream)
+	}
+	masker := newSecretMasker(out, r.secrets)
+	out = &syncWriter{w: masker}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	start := time.Now()
+	err := cmd.Run()
+	masker.Flush()
+
+	result := map[string]interface{}{
+		"command":     command,
+		"exit_code":   0,
+		"output":      tail.String(),
+		"truncated":   tail.Truncated(),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		result["exit_code"] = -1
+		result["canceled"] = true
+	case errors.As(err, &exitErr):
+		result["exit_code"] = exitErr.ExitCode()
+	default:
+		return nil, fmt.Errorf("failed to run command: %w", err)
+	}
+	return result, nil
+}
+
+// environment returns the variables of environ that commands inherit, plus
+// the injected secrets
+func (r *RunCommandTool) environment(environ []string) []string {
+	env := make([]string, 0, len(environ)+len(r.secrets))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if _, isSecret := r.secrets[name]; isSecret {
+			continue
+		}
+		if r.inherit == nil || matchesAny(name, r.inherit) {
+			env = append(env, entry)
+		}
+	}
+	for name, value := range r.secrets {
+		env = append(env, name+"="+value)
+	}
+	return env
+}
+
+// matchesAny reports whether name matches one of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// secretMasker replaces secret values in the output with their names. It
+// holds back incomplete lines so a value split across writes is still found.
+type secretMasker struct {
+	w       io.Writer
+	pending []byte
+	values  []string
+	names   map[string]string
+}
+
+// maxPendingOutput bounds the output held back while waiting for a newline
+const maxPendingOutput = 4096
+
+func newSecretMasker(w io.Writer, secrets map[string]string) *secretMasker {
+	m := &secretMasker{w: w, names: make(map[string]string, len(secrets))}
+	for name, value := range secrets {
+		if value == "" {
+			continue
+		}
+		m.values = append(m.values, value)
+		m.names[value] = name
+	}
+	// Longer values first, so one containing another is masked whole
+	sort.Slice(m.values, func(i, j int) bool { return len(m.values[i]) > len(m.values[j]) })
+	return m
+}
+
+// Write implements io.Writer
+func (m *secretMasker) Write(p []byte) (int, error) {
+	if len(m.values) == 0 {
+		return m.w.Write(p)
+	}
+
+	m.pending = append(m.pending, p...)
+	cut := bytes.LastIndexByte(m.pending, '\n') + 1
+	if cut == 0 && len(m.pending) > maxPendingOutput {
+		// No line end in sight: mask what is there and hold back a tail
+		// that may be the start of a secret
+		masked := m.mask(m.pending)
+		cut = max(len(masked)-(len(m.values[0])-1), 0)
+		if _, err := m.w.Write(masked[:cut]); err != nil {
+			return 0, err
+		}
+		m.pending = masked[cut:]
+		return len(p), nil
+	}
+	if cut > 0 {
+		if _, err := m.w.Write(m.mask(m.pending[:cut])); err != nil {
+			return 0, err
+		}
+		m.pending = append(m.pending[:0], m.pending[cut:]...)
+	}
+	return len(p), nil
+}
+
+// Flush writes the output held back
+func (m *secretMasker) Flush() {
+	if len(m.pending) > 0 {
+		m.w.Write(m.mask(m.pending))
+		m.pending = nil
+	}
+}
+
+// mask replaces the secret values in data
+func (m *secretMasker) mask(data []byte) []byte {
+	text := string(data)
+	for _, value := range m.values {
+		text = strings.ReplaceAll(text, value, "[secret "+m.names[value]+"]")
+	}
+	return []byte(text)
+}
+
+// shellCommand runs command with the platform shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/c", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// joinWorkDir resolves dir against the workspace unless it is absolute
+func joinWorkDir(workDir, dir string) string {
+	if filepath.IsAbs(dir) || workDir == "" {
+		return dir
+	}
+	return filepath.Join(workDir, dir)
+}
+
+// syncWriter serializes writes from the stdout and stderr copiers
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// TailBuffer keeps the last bytes written to it
+type TailBuffer struct {
+	mu      sync.Mutex
+	limit   int
+	data    []byte
+	written int
+}
+
+// NewTailBuffer creates a buffer keeping the last limit bytes
+func NewTailBuffer(limit int) *TailBuffer {
+	return &TailBuffer{limit: limit}
+}
+
+// Write implements io.Writer
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.written += len(p)
+	t.data = append(t.data, p...)
+	if t.limit > 0 && len(t.data) > t.limit {
+		t.data = append(t.data[:0], t.data[len(t.data)-t.limit:]...)
+	}
+	return len(p), nil
+}
+
+// Truncated reports whether earlier output was dropped
+func (t *TailBuffer) Truncated() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.written > len(t.data)
+}
+
+// String returns the kept output, noting how much was dropped before it
+func (t *TailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	text := strings.ToValidUTF8(string(t.data), "")
+	if dropped := t.written - len(t.data); dropped > 0 {
+		return fmt.Sprintf("... [%d earlier bytes truncated]\n%s", dropped, text)
+	}
+	return text
+}
@@ -0,0 +1,161 @@
+//go:build !windows
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommandTool(t *testing.T) {
+	tool := NewRunCommandTool(nil, t.TempDir())
+
+	tests := []struct {
+		name     string
+		command  string
+		exitCode int
+		output   string
+	}{
+		{"stdout", "echo hello", 0, "hello\n"},
+		{"stderr", "echo oops >&2", 0, "oops\n"},
+		{"exit code", "echo failing; exit 3", 3, "failing\n"},
+		{"working directory", "touch marker && ls", 0, "marker\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]interface{}{"command": tt.command}
+			require.NoError(t, tool.Validate(params))
+
+			result, err := tool.Execute(context.Background(), params)
+			require.NoError(t, err)
+			info := result.(map[string]interface{})
+			assert.Equal(t, tt.exitCode, info["exit_code"])
+			assert.Equal(t, tt.output, info["output"])
+			assert.Equal(t, false, info["truncated"])
+		})
+	}
+
+	assert.Error(t, tool.Validate(map[string]interface{}{"command": "  "}))
+}
+
+func TestRunCommandToolStreamsAndTruncates(t *testing.T) {
+	tool := NewRunCommandTool(nil, t.TempDir())
+	tool.outputLimit = 9
+
+	var stream bytes.Buffer
+	ctx := WithOutputStream(context.Background(), &stream)
+	result, err := tool.Execute(ctx, map[string]interface{}{"command": "printf 'line one\\nline two\\n'"})
+	require.NoError(t, err)
+
+	info := result.(map[string]interface{})
+	assert.Equal(t, "line one\nline two\n", stream.String())
+	assert.Equal(t, "... [9 earlier bytes truncated]\nline two\n", info["output"])
+	assert.Equal(t, true, info["truncated"])
+}
+
+func TestRunCommandToolCancel(t *testing.T) {
+	tool := NewRunCommandTool(nil, t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := tool.Execute(ctx, map[string]interface{}{"command": "echo started; sleep 10"})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	info := result.(map[string]interface{})
+	assert.Equal(t, true, info["canceled"])
+	assert.Equal(t, "started\n", info["output"])
+}
+
+func TestRunCommandToolEnvironment(t *testing.T) {
+	t.Setenv("CODA_TEST_KEPT", "kept")
+	t.Setenv("CODA_TEST_DROPPED", "dropped")
+
+	tool := NewRunCommandTool(nil, t.TempDir(),
+		WithInheritedEnv([]string{"PATH", "CODA_TEST_K*"}),
+		WithSecrets(map[string]string{"CODA_TEST_TOKEN": "s3cr3t-value"}),
+	)
+
+	var stream bytes.Buffer
+	ctx := WithOutputStream(context.Background(), &stream)
+	result, err := tool.Execute(ctx, map[string]interface{}{
+		"command": `echo "$CODA_TEST_KEPT|$CODA_TEST_DROPPED|$CODA_TEST_TOKEN"; printf 's3cr3t'; printf -- '-value\n'`,
+	})
+	require.NoError(t, err)
+
+	want := "kept||[secret CODA_TEST_TOKEN]\n[secret CODA_TEST_TOKEN]\n"
+	assert.Equal(t, want, result.(map[string]interface{})["output"])
+	assert.Equal(t, want, stream.String())
+}
+
+func TestSecretMaskerLongLine(t *testing.T) {
+	long := strings.Repeat("x", maxPendingOutput-3)
+	tests := []struct {
+		name   string
+		writes []string
+	}{
+		{"secret straddles the forced flush", []string{long + "s3cr", "3t-value", "|tail"}},
+		{"secret inside the forced flush", []string{long + "s3cr3t-value|", "tail"}},
+		{"secret split in many writes", []string{long, "s3", "cr3t", "-va", "lue|tail"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			m := newSecretMasker(&out, map[string]string{"CODA_TEST_TOKEN": "s3cr3t-value"})
+			for _, w := range tt.writes {
+				n, err := m.Write([]byte(w))
+				require.NoError(t, err)
+				assert.Equal(t, len(w), n)
+			}
+			m.Flush()
+
+			assert.Equal(t, long+"[secret CODA_TEST_TOKEN]|tail", out.String())
+		})
+	}
+}
+
+func TestCommandHook(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(NewListFilesTool(nil)))
+	require.NoError(t, m.Register(NewReadFileTool(nil)))
+	m.Use(NewCommandHook(CommandHook{
+		Name:   "guard",
+		Tool:   "read_*",
+		Before: `grep -q secret && { echo "secrets are off limits"; exit 1; } || true`,
+		After:  `cat > after.json`,
+		Dir:    dir,
+	}, nil))
+
+	_, err := m.Execute(context.Background(), "read_file", map[string]interface{}{"path": "secret.txt"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "blocked by hook guard")
+	assert.Contains(t, err.Error(), "secrets are off limits")
+	assert.NoFileExists(t, filepath.Join(dir, "after.json"))
+
+	_, err = m.Execute(context.Background(), "read_file", map[string]interface{}{"path": filepath.Join(dir, "missing.txt")})
+	require.Error(t, err)
+	after, readErr := os.ReadFile(filepath.Join(dir, "after.json"))
+	require.NoError(t, readErr)
+	assert.Contains(t, string(after), `"hook":"after"`)
+	assert.Contains(t, string(after), `"tool":"read_file"`)
+	assert.Contains(t, string(after), `"error":`)
+
+	// Other tools are not hooked
+	require.NoError(t, os.Remove(filepath.Join(dir, "after.json")))
+	_, err = m.Execute(context.Background(), "list_files", map[string]interface{}{"path": dir})
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "after.json"))
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package tools
+
+import "os/exec"
+
+// killProcessGroup is not supported on this platform; canceling kills only
+// the shell
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,445 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/container"
+)
+
+// Names of the container tools
+const (
+	ValidateDockerfileToolName = "validate_dockerfile"
+	ValidateComposeToolName    = "validate_compose"
+	DockerBuildToolName        = "docker_build"
+)
+
+const (
+	// dockerBuildTimeout bounds an image build
+	dockerBuildTimeout = 30 * time.Minute
+	// dockerBuildOutputLimit is the tail of the build log sent to the model
+	dockerBuildOutputLimit = 16 * 1024
+	// maxBuildErrors bounds the error lines picked out of the build log
+	maxBuildErrors = 10
+)
+
+// ValidateDockerfileTool parses a Dockerfile and reports its problems
+type ValidateDockerfileTool struct {
+	security SecurityValidator
+	workDir  string
+}
+
+// NewValidateDockerfileTool creates a new ValidateDockerfileTool resolving
+// paths against workDir
+func NewValidateDockerfileTool(security SecurityValidator, workDir string) *ValidateDockerfileTool {
+	return &ValidateDockerfileTool{security: security, workDir: workDir}
+}
+
+func (v *ValidateDockerfileTool) Name() string {
+	return ValidateDockerfileToolName
+}
+
+func (v *ValidateDockerfileTool) Description() string {
+	return "Parse a Dockerfile and check it without building: instruction order, unknown instructions, undefined stages in COPY --from, COPY sources missing from the build context, unpinned base images, apt-get without -y and similar problems, each with its line. Returns the stages and the issues."
+}
+
+func (v *ValidateDockerfileTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Path of the Dockerfile",
+				Default:     "Dockerfile",
+			},
+			"context": {
+				Type:        "string",
+				Description: "Build context directory the COPY and ADD sources are checked in (default: the Dockerfile's directory)",
+			},
+		},
+	}
+}
+
+func (v *ValidateDockerfileTool) Validate(params map[string]interface{}) error {
+	for _, name := range []string{"path", "context"} {
+		if value, exists := params[name]; exists {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("%s must be a string", name)
+			}
+		}
+	}
+	return nil
+}
+
+func (v *ValidateDockerfileTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path, _ := params["path"].(string)
+	if path == "" {
+		path = "Dockerfile"
+	}
+	path, err := resolveReadPath(v.security, v.workDir, path)
+	if err != nil {
+		return nil, err
+	}
+	contextDir := filepath.Dir(path)
+	if dir, _ := params["context"].(string); dir != "" {
+		if contextDir, err = resolveReadPath(v.security, v.workDir, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	d, issues, err := container.CheckDockerfile(path, contextDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, NewToolError(ErrCodeNotFound, "%w", err)
+		}
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	return map[string]interface{}{
+		"path":   relativeTo(v.workDir, path),
+		"stages": d.Stages,
+		"issues": relativeIssues(v.workDir, issues),
+		"valid":  !container.HasErrors(issues),
+	}, nil
+}
+
+// ValidateComposeTool parses a Compose file and reports its problems
+type ValidateComposeTool struct {
+	security SecurityValidator
+	workDir  string
+}
+
+// NewValidateComposeTool creates a new ValidateComposeTool resolving paths
+// against workDir
+func NewValidateComposeTool(security SecurityValidator, workDir string) *ValidateComposeTool {
+	return &ValidateComposeTool{security: security, workDir: workDir}
+}
+
+func (v *ValidateComposeTool) Name() string {
+	return ValidateComposeToolName
+}
+
+func (v *ValidateComposeTool) Description() string {
+	return "Parse a docker-compose file and check it without running Docker: services without an image or build, depends_on on undefined services or in a cycle, invalid or conflicting ports, undeclared volumes and networks, missing env files and build contexts. The Dockerfiles the services build are validated too. Returns the services and the issues with their file and line."
+}
+
+func (v *ValidateComposeTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Path of the Compose file (default: compose.yaml, compose.yml, docker-compose.yml or docker-compose.yaml)",
+			},
+		},
+	}
+}
+
+func (v *ValidateComposeTool) Validate(params map[string]interface{}) error {
+	if value, exists := params["path"]; exists {
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("path must be a string")
+		}
+	}
+	return nil
+}
+
+func (v *ValidateComposeTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path, _ := params["path"].(string)
+	if path == "" {
+		if path = container.FindComposeFile(v.workDir); path == "" {
+			return nil, NewToolError(ErrCodeNotFound, "no Compose file found (looked for %s)", strings.Join(container.ComposeFileNames, ", "))
+		}
+	}
+	path, err := resolveReadPath(v.security, v.workDir, path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, issues, err := container.CheckCompose(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, NewToolError(ErrCodeNotFound, "%w", err)
+		}
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	return map[string]interface{}{
+		"path":     relativeTo(v.workDir, path),
+		"services": c.Services,
+		"issues":   relativeIssues(v.workDir, issues),
+		"valid":    !container.HasErrors(issues),
+	}, nil
+}
+
+// resolveReadPath makes path absolute against workDir and checks that it
+// may be read
+func resolveReadPath(security SecurityValidator, workDir, path string) (string, error) {
+	path, err := filepath.Abs(joinWorkDir(workDir, path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if security != nil {
+		if err := security.ValidatePath(path); err != nil {
+			return "", NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
+		}
+		if err := security.ValidateOperation(OpRead, path); err != nil {
+			return "", NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
+		}
+	}
+	return path, nil
+}
+
+// relativeTo returns path relative to workDir when it is inside it
+func relativeTo(workDir, path string) string {
+	base, err := filepath.Abs(workDir)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func relativeIssues(workDir string, issues []container.Issue) []container.Issue {
+	result := make([]container.Issue, len(issues))
+	for i, issue := range issues {
+		issue.File = relativeTo(workDir, issue.File)
+		result[i] = issue
+	}
+	return result
+}
+
+// DockerBuildTool builds a Docker image, streaming the build log
+type DockerBuildTool struct {
+	security SecurityValidator
+	workDir  string
+	docker   string
+}
+
+// NewDockerBuildTool creates a new DockerBuildTool building in workDir
+func NewDockerBuildTool(security SecurityValidator, workDir string) *DockerBuildTool {
+	return &DockerBuildTool{security: security, workDir: workDir, docker: "docker"}
+}
+
+// DockerAvailable reports whether docker is on the PATH
+func DockerAvailable() bool {
+	_, err := exec.LookPath("docker")
+	return err == nil
+}
+
+func (d *DockerBuildTool) Name() string {
+	return DockerBuildToolName
+}
+
+func (d *DockerBuildTool) Description() string {
+	return "Build a Docker image with docker build. Returns the exit code, the error lines of the build and the end of the log. Run validate_dockerfile first to catch mistakes without a build."
+}
+
+func (d *DockerBuildTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"context": {
+				Type:        "string",
+				Description: "Build context directory",
+				Default:     ".",
+			},
+			"file": {
+				Type:        "string",
+				Description: "Path of the Dockerfile (default: Dockerfile in the context)",
+			},
+			"tag": {
+				Type:        "string",
+				Description: "Name and tag of the image, e.g. app:dev",
+			},
+			"target": {
+				Type:        "string",
+				Description: "Stage to build",
+			},
+			"build_args": {
+				Type:        "array",
+				Description: "Build arguments as KEY=VALUE",
+				Items:       &Property{Type: "string"},
+			},
+			"no_cache": {
+				Type:        "boolean",
+				Description: "Build without the layer cache",
+				Default:     false,
+			},
+		},
+	}
+}
+
+// SandboxLimits lets dockerBuildTimeout, not the sandbox, stop a long build
+func (d *DockerBuildTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(dockerBuildTimeout)
+}
+
+func (d *DockerBuildTool) Validate(params map[string]interface{}) error {
+	for _, name := range []string{"context", "file", "tag", "target"} {
+		if value, exists := params[name]; exists {
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a string", name)
+			}
+			if strings.HasPrefix(s, "-") {
+				return fmt.Errorf("%s must not start with -", name)
+			}
+		}
+	}
+	if value, exists := params["build_args"]; exists {
+		args, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("build_args must be an array of strings")
+		}
+		for _, arg := range args {
+			s, ok := arg.(string)
+			if !ok || !strings.Contains(s, "=") || strings.HasPrefix(s, "=") {
+				return fmt.Errorf("build_args entries must be KEY=VALUE strings")
+			}
+		}
+	}
+	if value, exists := params["no_cache"]; exists {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("no_cache must be a boolean")
+		}
+	}
+	return nil
+}
+
+// DockerBuildArgs returns the docker build command line for the parameters
+// of a docker_build call
+func DockerBuildArgs(params map[string]interface{}) []string {
+	args := []string{"docker", "build", "--progress=plain"}
+	if file, _ := params["file"].(string); file != "" {
+		args = append(args, "-f", file)
+	}
+	if tag, _ := params["tag"].(string); tag != "" {
+		args = append(args, "-t", tag)
+	}
+	if target, _ := params["target"].(string); target != "" {
+		args = append(args, "--target", target)
+	}
+	if buildArgs, ok := params["build_args"].([]interface{}); ok {
+		for _, arg := range buildArgs {
+			if s, ok := arg.(string); ok {
+				args = append(args, "--build-arg", s)
+			}
+		}
+	}
+	if noCache, _ := params["no_cache"].(bool); noCache {
+		args = append(args, "--no-cache")
+	}
+	dir, _ := params["context"].(string)
+	if dir == "" {
+		dir = "."
+	}
+	return append(args, dir)
+}
+
+func (d *DockerBuildTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	args := DockerBuildArgs(params)
+	if d.security != nil {
+		for _, name := range []string{"context", "file"} {
+			if path, _ := params[name].(string); path != "" {
+				if _, err := resolveReadPath(d.security, d.workDir, path); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dockerBuildTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, d.docker, args[1:]...)
+	cmd.Dir = d.workDir
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	cmd.WaitDelay = commandWaitDelay
+	killProcessGroup(cmd)
+
+	tail := NewTailBuffer(dockerBuildOutputLimit)
+	errorLines := &buildErrorWriter{}
+	var out io.Writer = io.MultiWriter(tail, errorLines)
+	if stream := OutputStreamFromContext(ctx); stream != nil {
+		out = io.MultiWriter(out, stream)
+	}
+	out = &syncWriter{w: out}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	start := time.Now()
+	err := cmd.Run()
+	errorLines.flush()
+
+	result := map[string]interface{}{
+		"command":     strings.Join(args, " "),
+		"exit_code":   0,
+		"output":      tail.String(),
+		"truncated":   tail.Truncated(),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		if tag, _ := params["tag"].(string); tag != "" {
+			result["image"] = tag
+		}
+	case ctx.Err() != nil:
+		result["exit_code"] = -1
+		result["canceled"] = true
+	case errors.As(err, &exitErr):
+		result["exit_code"] = exitErr.ExitCode()
+		result["errors"] = errorLines.lines
+	default:
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, NewToolError(ErrCodeUnavailable, "docker is not installed")
+		}
+		return nil, fmt.Errorf("failed to run docker build: %w", err)
+	}
+	return result, nil
+}
+
+// buildErrorWriter picks the error lines out of a build log
+type buildErrorWriter struct {
+	pending []byte
+	lines   []string
+}
+
+func (b *buildErrorWriter) Write(p []byte) (int, error) {
+	b.pending = append(b.pending, p...)
+	for {
+		i := bytes.IndexByte(b.pending, '\n')
+		if i < 0 {
+			break
+		}
+		b.add(string(b.pending[:i]))
+		b.pending = b.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+func (b *buildErrorWriter) flush() {
+	if len(b.pending) > 0 {
+		b.add(string(b.pending))
+		b.pending = nil
+	}
+}
+
+func (b *buildErrorWriter) add(line string) {
+	line = strings.TrimSpace(line)
+	if len(b.lines) >= maxBuildErrors {
+		return
+	}
+	if strings.Contains(line, "ERROR") || strings.HasPrefix(strings.ToLower(line), "error") {
+		b.lines = append(b.lines, line)
+	}
+}
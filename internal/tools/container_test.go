@@ -0,0 +1,92 @@
+//go:build !windows
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/container"
+)
+
+func TestValidateContainerTools(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM golang:1.22\nCOPY main.go .\nUSER app\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services:\n  app:\n    build: .\n    depends_on: [db]\n"), 0644))
+	ctx := context.Background()
+
+	result, err := NewValidateDockerfileTool(nil, dir).Execute(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, "Dockerfile", info["path"])
+	assert.Equal(t, false, info["valid"])
+	assert.Equal(t, []container.Issue{{
+		File:     "Dockerfile",
+		Line:     2,
+		Severity: container.SeverityError,
+		Rule:     "missing-source",
+		Message:  "COPY source main.go does not exist in the build context",
+	}}, info["issues"])
+
+	result, err = NewValidateComposeTool(nil, dir).Execute(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	info = result.(map[string]interface{})
+	assert.Equal(t, "compose.yaml", info["path"])
+	issues := info["issues"].([]container.Issue)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "Dockerfile", issues[0].File, "the Dockerfile the service builds is checked")
+	assert.Equal(t, "compose.yaml", issues[1].File)
+	assert.Equal(t, "unknown-service", issues[1].Rule)
+
+	_, err = NewValidateComposeTool(nil, t.TempDir()).Execute(ctx, map[string]interface{}{})
+	var toolErr *ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeNotFound, toolErr.Code)
+}
+
+func TestDockerBuildTool(t *testing.T) {
+	dir := t.TempDir()
+	bin := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" > ` + filepath.Join(bin, "args") + `
+echo "#5 [2/3] RUN go build ./..."
+echo "#5 0.512 main.go:3:1: syntax error"
+echo "#5 ERROR: process \"/bin/sh -c go build ./...\" did not complete successfully: exit code: 1"
+echo "ERROR: failed to solve: exit code: 1" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755))
+	tool := NewDockerBuildTool(nil, dir)
+	tool.docker = filepath.Join(bin, "docker")
+
+	params := map[string]interface{}{"tag": "app:dev", "build_args": []interface{}{"VERSION=1.0"}, "no_cache": true}
+	require.NoError(t, tool.Validate(params))
+	assert.Error(t, tool.Validate(map[string]interface{}{"build_args": []interface{}{"VERSION"}}))
+	assert.Error(t, tool.Validate(map[string]interface{}{"tag": "--push"}))
+
+	stream := &bytes.Buffer{}
+	result, err := tool.Execute(WithOutputStream(context.Background(), stream), params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, 1, info["exit_code"])
+	assert.Equal(t, "docker build --progress=plain -t app:dev --build-arg VERSION=1.0 --no-cache .", info["command"])
+	assert.Equal(t, []string{
+		`#5 ERROR: process "/bin/sh -c go build ./..." did not complete successfully: exit code: 1`,
+		"ERROR: failed to solve: exit code: 1",
+	}, info["errors"])
+	assert.Contains(t, stream.String(), "syntax error")
+
+	args, err := os.ReadFile(filepath.Join(bin, "args"))
+	require.NoError(t, err)
+	assert.Equal(t, "build --progress=plain -t app:dev --build-arg VERSION=1.0 --no-cache .\n", string(args))
+}
+
+func TestDockerBuildToolSandboxLimits(t *testing.T) {
+	assertOwnTimeoutApplies(t, NewDockerBuildTool(nil, t.TempDir()), dockerBuildTimeout)
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/database"
+)
+
+// QueryDBToolName is the name of the database query tool
+const QueryDBToolName = "query_db"
+
+// queryTimeout bounds a query
+const queryTimeout = 2 * time.Minute
+
+// QueryDBTool runs SQL against the configured databases and returns the
+// result set as a markdown table. Queries are read-only unless the database
+// allows writes.
+type QueryDBTool struct {
+	workDir   string
+	databases []database.Database
+	maxTokens int
+	client    *database.Client
+}
+
+// NewQueryDBTool creates a new QueryDBTool for databases. Result tables are
+// cut to about maxTokens tokens.
+func NewQueryDBTool(workDir string, databases []database.Database, maxTokens int, opts ...database.Option) *QueryDBTool {
+	return &QueryDBTool{workDir: workDir, databases: databases, maxTokens: maxTokens, client: database.NewClient(opts...)}
+}
+
+func (q *QueryDBTool) Name() string {
+	return QueryDBToolName
+}
+
+func (q *QueryDBTool) Description() string {
+	return "Run one SQL statement against a configured PostgreSQL, MySQL or SQLite database and get the result set as a markdown table, cut to a token budget. Queries are read-only: the session refuses changes. Statements that change data or schema run only on databases configured with allow_writes, and only after the user approves them. Prefer selecting the needed columns with a LIMIT over SELECT *."
+}
+
+func (q *QueryDBTool) Schema() ToolSchema {
+	names := make([]string, len(q.databases))
+	for i, db := range q.databases {
+		names[i] = db.Name
+	}
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"database": {
+				Type:        "string",
+				Description: "Database to query (optional when only one is configured)",
+				Enum:        names,
+			},
+			"query": {
+				Type:        "string",
+				Description: "A single SQL statement",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+// SandboxLimits lets queryTimeout, not the sandbox, stop a slow query
+func (q *QueryDBTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(queryTimeout)
+}
+
+func (q *QueryDBTool) Validate(params map[string]interface{}) error {
+	query, ok := params["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query is required and must be a string")
+	}
+	if n := database.Statements(query); n != 1 {
+		return fmt.Errorf("query must be a single SQL statement, got %d", n)
+	}
+	if value, exists := params["database"]; exists {
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("database must be a string")
+		}
+	}
+	return nil
+}
+
+func (q *QueryDBTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	query := params["query"].(string)
+	name, _ := params["database"].(string)
+	db, err := q.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	readOnly := database.ReadOnly(query)
+	if !readOnly && !db.AllowWrites {
+		return nil, NewToolError(ErrCodePermissionDenied, "database %s is read-only and the query may change it; only SELECT, WITH, VALUES, TABLE, EXPLAIN, SHOW, DESCRIBE and PRAGMA reads are allowed", db.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	result, err := q.client.Query(ctx, db, q.workDir, query, !readOnly)
+	if err != nil {
+		if errors.Is(err, database.ErrClientUnavailable) {
+			return nil, NewToolError(ErrCodeUnavailable, "%w", err)
+		}
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+
+	table, shown := result.Markdown(q.maxTokens)
+	info := map[string]interface{}{
+		"database":   db.Name,
+		"read_only":  readOnly,
+		"columns":    len(result.Columns),
+		"rows":       len(result.Rows),
+		"shown_rows": shown,
+		"table":      table,
+	}
+	switch {
+	case len(result.Columns) == 0:
+		info["message"] = "Statement executed; it returned no result set"
+	case result.Truncated:
+		info["message"] = fmt.Sprintf("The output was too large to read in full; showing %d of the first %d rows. Narrow the query with WHERE or LIMIT.", shown, len(result.Rows))
+	case shown < len(result.Rows):
+		info["message"] = fmt.Sprintf("Showing %d of %d rows to stay within the token budget. Narrow the query with WHERE, LIMIT or fewer columns.", shown, len(result.Rows))
+	}
+	return info, nil
+}
+
+// DryRun runs reads, which change nothing, and describes writes
+func (q *QueryDBTool) DryRun(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	query := params["query"].(string)
+	if database.ReadOnly(query) {
+		return q.Execute(ctx, params)
+	}
+	name, _ := params["database"].(string)
+	db, err := q.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		DryRunParam: true,
+		"database":  db.Name,
+		"query":     query,
+		"message":   "Dry run: the query may change the database and was not executed",
+	}, nil
+}
+
+// lookup returns the database called name, or the only database when name
+// is empty
+func (q *QueryDBTool) lookup(name string) (database.Database, error) {
+	if name == "" && len(q.databases) == 1 {
+		return q.databases[0], nil
+	}
+	names := make([]string, len(q.databases))
+	for i, db := range q.databases {
+		if db.Name == name {
+			return db, nil
+		}
+		names[i] = db.Name
+	}
+	if name == "" {
+		return database.Database{}, NewToolError(ErrCodeInvalidParams, "database is required; choose one of %s", strings.Join(names, ", "))
+	}
+	return database.Database{}, NewToolError(ErrCodeNotFound, "unknown database %s; choose one of %s", name, strings.Join(names, ", "))
+}
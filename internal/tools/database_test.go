@@ -0,0 +1,66 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/database"
+)
+
+func TestQueryDBTool(t *testing.T) {
+	bin := t.TempDir()
+	sqlite := filepath.Join(bin, "sqlite3")
+	script := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(bin, "calls") + "\nprintf 'id,name\\n1,ada\\n2,grace\\n3,edsger\\n'\n"
+	require.NoError(t, os.WriteFile(sqlite, []byte(script), 0755))
+	dbs := []database.Database{
+		{Name: "dev", DSN: "sqlite:dev.db", AllowWrites: true},
+		{Name: "prod", DSN: "sqlite:prod.db"},
+	}
+	tool := NewQueryDBTool(t.TempDir(), dbs, 30, database.WithCommand(database.SQLite, sqlite))
+	assert.Equal(t, []string{"dev", "prod"}, tool.Schema().Properties["database"].Enum)
+
+	assert.Error(t, tool.Validate(map[string]interface{}{}))
+	assert.EqualError(t, tool.Validate(map[string]interface{}{"query": "SELECT 1; SELECT 2"}), "query must be a single SQL statement, got 2")
+	require.NoError(t, tool.Validate(map[string]interface{}{"query": "SELECT 1;", "database": "dev"}))
+
+	ctx := context.Background()
+	result, err := tool.Execute(ctx, map[string]interface{}{"query": "SELECT id, name FROM users", "database": "prod"})
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, true, info["read_only"])
+	assert.Equal(t, 3, info["rows"])
+	assert.Less(t, info["shown_rows"], 3)
+	assert.True(t, strings.HasPrefix(info["table"].(string), "| id | name |\n| --- | --- |\n"))
+	assert.Contains(t, info["message"], "to stay within the token budget")
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"query": "DELETE FROM users", "database": "prod"})
+	var toolErr *ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodePermissionDenied, toolErr.Code)
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"query": "DELETE FROM users", "database": "dev"})
+	require.NoError(t, err)
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"query": "SELECT 1"})
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeInvalidParams, toolErr.Code)
+	_, err = tool.Execute(ctx, map[string]interface{}{"query": "SELECT 1", "database": "test"})
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeNotFound, toolErr.Code)
+
+	result, err = tool.DryRun(ctx, map[string]interface{}{"query": "UPDATE users SET name = 'x'", "database": "dev"})
+	require.NoError(t, err)
+	assert.True(t, IsDryRunResult(result))
+
+	calls, err := os.ReadFile(filepath.Join(bin, "calls"))
+	require.NoError(t, err)
+	assert.Equal(t, "-bail -batch -header -csv -readonly prod.db SELECT id, name FROM users\n-bail -batch -header -csv dev.db DELETE FROM users\n", string(calls))
+}
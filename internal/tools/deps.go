@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/deps"
+)
+
+// Names of the dependency tools
+const (
+	DepsOutdatedToolName = "deps_outdated"
+	DepsBumpToolName     = "deps_bump"
+	DepsTestToolName     = "deps_test"
+)
+
+// depsTimeout bounds a dependency tool call, which may download modules
+const depsTimeout = 10 * time.Minute
+
+// NewDepsTools creates the dependency tools for the Go module in workDir
+func NewDepsTools(security SecurityValidator, workDir string, opts ...deps.Option) []Tool {
+	project := deps.NewProject(workDir, opts...)
+	return []Tool{
+		&DepsOutdatedTool{project: project},
+		&DepsBumpTool{project: project, security: security, workDir: workDir},
+		&DepsTestTool{project: project},
+	}
+}
+
+// DepsOutdatedTool lists the module dependencies that have newer versions
+type DepsOutdatedTool struct {
+	project *deps.Project
+}
+
+func (d *DepsOutdatedTool) Name() string {
+	return DepsOutdatedToolName
+}
+
+func (d *DepsOutdatedTool) Description() string {
+	return "List the Go module dependencies that have a newer version (go list -m -u), with their current and newest versions."
+}
+
+func (d *DepsOutdatedTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"include_indirect": {
+				Type:        "boolean",
+				Description: "Also list indirect dependencies",
+				Default:     false,
+			},
+		},
+	}
+}
+
+// SandboxLimits lets depsTimeout, not the sandbox, stop a long module lookup
+func (d *DepsOutdatedTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(depsTimeout)
+}
+
+func (d *DepsOutdatedTool) Validate(params map[string]interface{}) error {
+	if value, exists := params["include_indirect"]; exists {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("include_indirect must be a boolean")
+		}
+	}
+	return nil
+}
+
+func (d *DepsOutdatedTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	indirect, _ := params["include_indirect"].(bool)
+
+	ctx, cancel := context.WithTimeout(ctx, depsTimeout)
+	defer cancel()
+	modules, err := d.project.Outdated(ctx, indirect)
+	if err != nil {
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	if modules == nil {
+		modules = []deps.Module{}
+	}
+	return map[string]interface{}{
+		"outdated": modules,
+		"count":    len(modules),
+	}, nil
+}
+
+// DepsBumpTool upgrades or downgrades one module dependency
+type DepsBumpTool struct {
+	project  *deps.Project
+	security SecurityValidator
+	workDir  string
+}
+
+func (d *DepsBumpTool) Name() string {
+	return DepsBumpToolName
+}
+
+func (d *DepsBumpTool) Description() string {
+	return "Change the required version of one Go module dependency (go get module@version, then go mod tidy). Upgrade one module at a time and run deps_test after each change."
+}
+
+func (d *DepsBumpTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"module": {
+				Type:        "string",
+				Description: "Module path, e.g. golang.org/x/text",
+			},
+			"version": {
+				Type:        "string",
+				Description: "Version to require, e.g. v0.14.0",
+				Default:     "latest",
+			},
+		},
+		Required: []string{"module"},
+	}
+}
+
+// SandboxLimits lets depsTimeout, not the sandbox, stop a long upgrade
+func (d *DepsBumpTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(depsTimeout)
+}
+
+func (d *DepsBumpTool) Validate(params map[string]interface{}) error {
+	module, ok := params["module"].(string)
+	if !ok || module == "" {
+		return fmt.Errorf("module is required and must be a string")
+	}
+	if strings.ContainsAny(module, " @") {
+		return fmt.Errorf("module must be a module path without a version")
+	}
+	if value, exists := params["version"]; exists {
+		version, ok := value.(string)
+		if !ok || strings.ContainsAny(version, " @") {
+			return fmt.Errorf("version must be a version such as v1.2.3 or latest")
+		}
+	}
+	return nil
+}
+
+func (d *DepsBumpTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	module := params["module"].(string)
+	version, _ := params["version"].(string)
+
+	if d.security != nil {
+		goMod, err := filepath.Abs(filepath.Join(d.workDir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve go.mod: %w", err)
+		}
+		if err := d.security.ValidateOperation(OpWrite, goMod); err != nil {
+			return nil, NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, depsTimeout)
+	defer cancel()
+	change, err := d.project.Bump(ctx, module, version)
+	if err != nil {
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	message := fmt.Sprintf("%s is now required at %s (was %s); run deps_test to check the build and tests", change.Path, change.To, change.From)
+	if change.From == change.To {
+		message = fmt.Sprintf("%s is already at %s", change.Path, change.To)
+	}
+	return map[string]interface{}{
+		"module":  change.Path,
+		"from":    change.From,
+		"to":      change.To,
+		"changed": change.From != change.To,
+		"message": message,
+	}, nil
+}
+
+// DepsTestTool runs the build and tests that gate dependency changes
+type DepsTestTool struct {
+	project *deps.Project
+}
+
+func (d *DepsTestTool) Name() string {
+	return DepsTestToolName
+}
+
+func (d *DepsTestTool) Description() string {
+	return "Build the Go module and run its tests (go build ./... then go test ./...) to check a dependency change. Reports the first command that fails with the end of its output."
+}
+
+func (d *DepsTestTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type:       "object",
+		Properties: map[string]Property{},
+	}
+}
+
+// SandboxLimits lets depsTimeout, not the sandbox, stop a long test run
+func (d *DepsTestTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(depsTimeout)
+}
+
+func (d *DepsTestTool) Validate(params map[string]interface{}) error {
+	return nil
+}
+
+func (d *DepsTestTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, depsTimeout)
+	defer cancel()
+	check := d.project.Check(ctx)
+	result := map[string]interface{}{
+		"passed": check.Passed,
+	}
+	if !check.Passed {
+		result["failed"] = check.Failed
+		result["output"] = check.Output
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/deps"
+)
+
+func TestDepsTools(t *testing.T) {
+	version := "v1.0.0"
+	var commands []string
+	run := func(ctx context.Context, dir, name string, args ...string) (string, error) {
+		command := name + " " + strings.Join(args, " ")
+		commands = append(commands, command)
+		switch {
+		case command == "go list -m -u -json all":
+			return `{"Path": "example.com/lib", "Version": "v1.0.0", "Update": {"Version": "v1.1.0"}}`, nil
+		case strings.HasPrefix(command, "go list -m -json"):
+			return `{"Path": "example.com/lib", "Version": "` + version + `"}`, nil
+		case command == "go get example.com/lib@v1.1.0":
+			version = "v1.1.0"
+		case command == "go test ./..." && version == "v1.1.0":
+			return "undefined: lib.Old", errors.New("exit status 1")
+		}
+		return "", nil
+	}
+	byName := make(map[string]Tool)
+	for _, tool := range NewDepsTools(nil, t.TempDir(), deps.WithRunner(run)) {
+		byName[tool.Name()] = tool
+	}
+	ctx := context.Background()
+
+	result, err := byName[DepsOutdatedToolName].Execute(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.(map[string]interface{})["count"])
+
+	bump := byName[DepsBumpToolName]
+	assert.Error(t, bump.Validate(map[string]interface{}{"module": "example.com/lib@v1.1.0"}))
+	params := map[string]interface{}{"module": "example.com/lib", "version": "v1.1.0"}
+	require.NoError(t, bump.Validate(params))
+	result, err = bump.Execute(ctx, params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, "v1.0.0", info["from"])
+	assert.Equal(t, "v1.1.0", info["to"])
+	assert.True(t, ChangesFiles(DepsBumpToolName, info))
+	assert.Contains(t, commands, "go mod tidy")
+
+	result, err = byName[DepsTestToolName].Execute(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"passed": false, "failed": "go test ./...", "output": "undefined: lib.Old"}, result)
+}
+
+func TestDepsToolsSandboxLimits(t *testing.T) {
+	for _, tool := range NewDepsTools(nil, t.TempDir()) {
+		assertOwnTimeoutApplies(t, tool, depsTimeout)
+	}
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+)
+
+// DryRunParam is the per-call parameter that requests a dry run
+const DryRunParam = "dry_run"
+
+// DryRunner is implemented by mutating tools that can describe what they
+// would do without doing it
+type DryRunner interface {
+	DryRun(ctx context.Context, params map[string]interface{}) (interface{}, error)
+}
+
+// IsDryRunRequested reports whether the call asks for a dry run
+func IsDryRunRequested(params map[string]interface{}) bool {
+	dryRun, _ := params[DryRunParam].(bool)
+	return dryRun
+}
+
+// IsDryRunResult reports whether a tool result was produced by a dry run
+func IsDryRunResult(result interface{}) bool {
+	info, ok := result.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	dryRun, _ := info[DryRunParam].(bool)
+	return dryRun
+}
+
+// dryRun describes a call instead of executing it. Tools without a
+// DryRunner report the call they would have made.
+func dryRun(ctx context.Context, tool Tool, params map[string]interface{}) (interface{}, error) {
+	if runner, ok := tool.(DryRunner); ok {
+		return runner.DryRun(ctx, params)
+	}
+
+	args := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if k != DryRunParam {
+			args[k] = v
+		}
+	}
+	return map[string]interface{}{
+		DryRunParam: true,
+		"tool":      tool.Name(),
+		"params":    args,
+		"message":   "Dry run: tool was not executed",
+	}, nil
+}
+
+// dryRunResult describes a file change that was not applied
+func dryRunResult(path, action, diff string, added, removed int) map[string]interface{} {
+	message := "Dry run: no changes were made"
+	if diff == "" {
+		message = "Dry run: file would be unchanged"
+	}
+	return map[string]interface{}{
+		DryRunParam: true,
+		"path":      path,
+		"action":    action,
+		"diff":      diff,
+		"added":     added,
+		"removed":   removed,
+		"success":   true,
+		"message":   message,
+	}
+}
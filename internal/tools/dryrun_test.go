@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDryRunManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(NewReadFileTool(nil)))
+	require.NoError(t, m.Register(NewWriteFileTool(nil)))
+	require.NoError(t, m.Register(NewEditFileTool(nil)))
+	return m
+}
+
+func TestManagerDryRun(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n\nfunc main() {}\n"), 0644))
+	created := filepath.Join(dir, "new.txt")
+
+	tests := []struct {
+		name       string
+		global     bool
+		tool       string
+		params     map[string]interface{}
+		wantAction string
+		wantDiff   []string
+	}{
+		{
+			name:       "global write of new file",
+			global:     true,
+			tool:       "write_file",
+			params:     map[string]interface{}{"path": created, "content": "hello\n"},
+			wantAction: "create",
+			wantDiff:   []string{"--- /dev/null", "+hello"},
+		},
+		{
+			name:       "per-call overwrite",
+			tool:       "write_file",
+			params:     map[string]interface{}{"path": existing, "content": "package main\n", "dry_run": true},
+			wantAction: "overwrite",
+			wantDiff:   []string{"-func main() {}"},
+		},
+		{
+			name: "per-call edit",
+			tool: "edit_file",
+			params: map[string]interface{}{
+				"path": existing, "old_text": "main() {}", "new_text": "main() { run() }", "dry_run": true,
+			},
+			wantAction: "edit",
+			wantDiff:   []string{"-func main() {}", "+func main() { run() }"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newDryRunManager(t)
+			m.SetDryRun(tt.global)
+
+			result, err := m.Execute(context.Background(), tt.tool, tt.params)
+			require.NoError(t, err)
+			require.True(t, IsDryRunResult(result))
+
+			info := result.(map[string]interface{})
+			assert.Equal(t, tt.wantAction, info["action"])
+			for _, want := range tt.wantDiff {
+				assert.Contains(t, info["diff"], want)
+			}
+
+			// Nothing was written
+			content, err := os.ReadFile(existing)
+			require.NoError(t, err)
+			assert.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+			_, err = os.Stat(created)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestManagerDryRunReadOnlyAndOpaqueTools(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+
+	m := newDryRunManager(t)
+	executed := false
+	require.NoError(t, m.Register(&funcTool{name: "deploy", fn: func(ctx context.Context) (interface{}, error) {
+		executed = true
+		return "deployed", nil
+	}}))
+	m.SetDryRun(true)
+
+	// Read-only tools still run
+	result, err := m.Execute(context.Background(), "read_file", map[string]interface{}{"path": path})
+	require.NoError(t, err)
+	assert.Equal(t, "data", result)
+
+	// Tools without a DryRunner only report the call
+	result, err = m.Execute(context.Background(), "deploy", map[string]interface{}{"target": "prod"})
+	require.NoError(t, err)
+	assert.False(t, executed)
+	require.True(t, IsDryRunResult(result))
+	info := result.(map[string]interface{})
+	assert.Equal(t, "deploy", info["tool"])
+	assert.Equal(t, map[string]interface{}{"target": "prod"}, info["params"])
+
+	// Disabling dry-run executes again
+	m.SetDryRun(false)
+	_, err = m.Execute(context.Background(), "deploy", nil)
+	require.NoError(t, err)
+	assert.True(t, executed)
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"sort"
+	"strings"
+)
+
+// ErrorCode classifies why a tool call failed
+type ErrorCode string
+
+const (
+	ErrCodeInvalidParams    ErrorCode = "invalid_params"
+	ErrCodeNotFound         ErrorCode = "not_found"
+	ErrCodePermissionDenied ErrorCode = "permission_denied"
+	ErrCodeTimeout          ErrorCode = "timeout"
+	ErrCodeCanceled         ErrorCode = "canceled"
+	ErrCodeUnavailable      ErrorCode = "unavailable"
+	ErrCodeResourceLimit    ErrorCode = "resource_limit"
+	ErrCodeExecution        ErrorCode = "execution_failed"
+)
+
+// ToolError is the structured error returned by tool calls. Retryable marks
+// transient failures (timeouts, unreachable services) that may succeed when
+// the same call is repeated; logical failures such as invalid parameters or
+// missing files are not retried.
+type ToolError struct {
+	Tool      string
+	Code      ErrorCode
+	Message   string
+	Retryable bool
+	Details   map[string]interface{}
+	Err       error
+}
+
+// NewToolError creates a non-retryable error with code
+func NewToolError(code ErrorCode, format string, args ...interface{}) *ToolError {
+	err := fmt.Errorf(format, args...)
+	return &ToolError{Code: code, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// WithDetails attaches details describing the failure, returning e
+func (e *ToolError) WithDetails(details map[string]interface{}) *ToolError {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{}, len(details))
+	}
+	for k, v := range details {
+		e.Details[k] = v
+	}
+	return e
+}
+
+// Error implements the error interface
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Format renders the error consistently for the model and the UI:
+// "[code] message (retryable)" followed by the details, one per line
+func (e *ToolError) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", e.Code, e.Message)
+	if e.Retryable {
+		b.WriteString(" (retryable)")
+	}
+
+	keys := make([]string, 0, len(e.Details))
+	for k := range e.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := e.Details[k]
+		if _, ok := value.(string); !ok {
+			if data, err := json.Marshal(value); err == nil {
+				value = string(data)
+			}
+		}
+		fmt.Fprintf(&b, "\n  %s: %v", k, value)
+	}
+	return b.String()
+}
+
+// AsToolError returns err as a ToolError of tool, classifying plain errors
+// by their cause. It returns nil for a nil error.
+func AsToolError(tool string, err error) *ToolError {
+	if err == nil {
+		return nil
+	}
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) {
+		if toolErr.Tool == "" {
+			toolErr.Tool = tool
+		}
+		return toolErr
+	}
+
+	toolErr = &ToolError{Tool: tool, Code: ErrCodeExecution, Message: err.Error(), Err: err}
+	var sbErr *SandboxError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &sbErr):
+		switch sbErr.Kind {
+		case ViolationTimeout:
+			toolErr.Code, toolErr.Retryable = ErrCodeTimeout, sbErr.Message != "cancelled"
+			if !toolErr.Retryable {
+				toolErr.Code = ErrCodeCanceled
+			}
+		case ViolationPanic:
+			toolErr.Code = ErrCodeExecution
+		default:
+			toolErr.Code = ErrCodeResourceLimit
+		}
+		toolErr.Details = map[string]interface{}{"violation": string(sbErr.Kind)}
+	case errors.Is(err, context.Canceled):
+		toolErr.Code = ErrCodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		toolErr.Code, toolErr.Retryable = ErrCodeTimeout, true
+	case errors.Is(err, fs.ErrNotExist):
+		toolErr.Code = ErrCodeNotFound
+	case errors.Is(err, fs.ErrPermission):
+		toolErr.Code = ErrCodePermissionDenied
+	case errors.As(err, &netErr):
+		toolErr.Code, toolErr.Retryable = ErrCodeUnavailable, true
+		if netErr.Timeout() {
+			toolErr.Code = ErrCodeTimeout
+		}
+	}
+	return toolErr
+}
+
+// IsRetryable reports whether a failed tool call may succeed when repeated
+func IsRetryable(err error) bool {
+	var toolErr *ToolError
+	return errors.As(err, &toolErr) && toolErr.Retryable
+}
+
+// FormatError renders a tool call error for the model and the UI
+func FormatError(tool string, err error) string {
+	return AsToolError(tool, err).Format()
+}
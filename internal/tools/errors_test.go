@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsToolError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      ErrorCode
+		retryable bool
+	}{
+		{"plain", errors.New("boom"), ErrCodeExecution, false},
+		{"missing file", fmt.Errorf("failed to open file: %w", os.ErrNotExist), ErrCodeNotFound, false},
+		{"permission", fmt.Errorf("failed to write file: %w", os.ErrPermission), ErrCodePermissionDenied, false},
+		{"deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), ErrCodeTimeout, true},
+		{"canceled", context.Canceled, ErrCodeCanceled, false},
+		{"sandbox timeout", &SandboxError{Kind: ViolationTimeout, Message: "exceeded time limit"}, ErrCodeTimeout, true},
+		{"sandbox cancel", &SandboxError{Kind: ViolationTimeout, Message: "cancelled"}, ErrCodeCanceled, false},
+		{"sandbox memory", &SandboxError{Kind: ViolationMemory, Message: "too much"}, ErrCodeResourceLimit, false},
+		{"wrapped tool error", fmt.Errorf("outer: %w", NewToolError(ErrCodeInvalidParams, "bad")), ErrCodeInvalidParams, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolErr := AsToolError("read_file", tt.err)
+			require.NotNil(t, toolErr)
+			assert.Equal(t, tt.code, toolErr.Code)
+			assert.Equal(t, tt.retryable, toolErr.Retryable)
+			assert.Equal(t, tt.retryable, IsRetryable(toolErr))
+			assert.Equal(t, "read_file", toolErr.Tool)
+		})
+	}
+
+	assert.Nil(t, AsToolError("read_file", nil))
+}
+
+func TestToolErrorFormat(t *testing.T) {
+	err := NewToolError(ErrCodeNotFound, "failed to open file: %w", os.ErrNotExist)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "[not_found] failed to open file: file does not exist", err.Format())
+
+	err = &ToolError{Code: ErrCodeTimeout, Message: "timed out", Retryable: true}
+	err.WithDetails(map[string]interface{}{"attempts": 3, "url": "https://example.com"})
+	assert.Equal(t, "[timeout] timed out (retryable)\n  attempts: 3\n  url: https://example.com", err.Format())
+}
+
+func TestManagerExecuteToolErrors(t *testing.T) {
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(&funcTool{name: "fails", fn: func(ctx context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("read: %w", os.ErrNotExist)
+	}}))
+
+	_, err := m.Execute(context.Background(), "missing", nil)
+	var toolErr *ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeNotFound, toolErr.Code)
+
+	_, err = m.Execute(context.Background(), "fails", nil)
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeNotFound, toolErr.Code)
+	assert.Equal(t, "fails", toolErr.Tool)
+	assert.Contains(t, toolErr.Message, "execution failed for tool 'fails'")
+}
@@ -9,16 +9,38 @@ import (
 	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/common-creation/coda/internal/symbols"
+	"github.com/common-creation/coda/internal/textdiff"
 )
 
 // ReadFileTool implements file reading functionality
 type ReadFileTool struct {
 	security SecurityValidator
+	// goContextRoot, when set, is the workspace whose Go package APIs are
+	// added to whole Go files
+	goContextRoot string
+}
+
+// ReadFileOption configures a ReadFileTool
+type ReadFileOption func(*ReadFileTool)
+
+// WithGoPackageContext appends the exported API of the file's package and of
+// its direct imports when a whole Go file under root is read, so edits
+// compile more often on the first try
+func WithGoPackageContext(root string) ReadFileOption {
+	return func(r *ReadFileTool) {
+		r.goContextRoot = root
+	}
 }
 
 // NewReadFileTool creates a new ReadFileTool instance
-func NewReadFileTool(security SecurityValidator) *ReadFileTool {
-	return &ReadFileTool{security: security}
+func NewReadFileTool(security SecurityValidator, opts ...ReadFileOption) *ReadFileTool {
+	r := &ReadFileTool{security: security}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *ReadFileTool) Name() string {
@@ -112,10 +134,10 @@ func (r *ReadFileTool) Execute(ctx context.Context, params map[string]interface{
 	// Security check
 	if r.security != nil {
 		if err := r.security.ValidatePath(absPath); err != nil {
-			return nil, fmt.Errorf("security validation failed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
 		}
 		if err := r.security.ValidateOperation(OpRead, absPath); err != nil {
-			return nil, fmt.Errorf("operation not allowed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
 		}
 	}
 
@@ -157,9 +179,20 @@ func (r *ReadFileTool) Execute(ctx context.Context, params map[string]interface{
 		}
 	}
 
+	if r.goContextRoot != "" && offset == 0 && limit <= 0 && strings.HasSuffix(absPath, ".go") {
+		// The context is a convenience; the file is returned without it on errors
+		if api, err := symbols.PackageAPI(ctx, r.goContextRoot, absPath, true); err == nil {
+			return string(content) + "\n\n" + goContextHeader + "\n" + api, nil
+		}
+	}
+
 	return string(content), nil
 }
 
+// goContextHeader separates the package API added by WithGoPackageContext
+// from the file content
+const goContextHeader = "---- Not part of the file: exported API of its package and imports ----"
+
 // WriteFileTool implements file writing functionality
 type WriteFileTool struct {
 	security SecurityValidator
@@ -200,6 +233,11 @@ func (w *WriteFileTool) Schema() ToolSchema {
 				Description: "Create a backup of existing file",
 				Default:     false,
 			},
+			"dry_run": {
+				Type:        "boolean",
+				Description: "Report the resulting diff without changing the file",
+				Default:     false,
+			},
 		},
 		Required: []string{"path", "content"},
 	}
@@ -232,23 +270,9 @@ func (w *WriteFileTool) Execute(ctx context.Context, params map[string]interface
 		backup, _ = val.(bool)
 	}
 
-	// Normalize path
-	absPath, err := filepath.Abs(path)
+	absPath, err := w.resolve(path, content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve path: %w", err)
-	}
-
-	// Security check
-	if w.security != nil {
-		if err := w.security.ValidatePath(absPath); err != nil {
-			return nil, fmt.Errorf("security validation failed: %w", err)
-		}
-		if err := w.security.ValidateOperation(OpWrite, absPath); err != nil {
-			return nil, fmt.Errorf("operation not allowed: %w", err)
-		}
-		if err := w.security.CheckContent([]byte(content)); err != nil {
-			return nil, fmt.Errorf("content validation failed: %w", err)
-		}
+		return nil, err
 	}
 
 	// Create directories if needed
@@ -281,6 +305,52 @@ func (w *WriteFileTool) Execute(ctx context.Context, params map[string]interface
 	}, nil
 }
 
+// DryRun reports the diff write_file would produce without writing
+func (w *WriteFileTool) DryRun(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path := params["path"].(string)
+	content := params["content"].(string)
+
+	absPath, err := w.resolve(path, content)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := os.ReadFile(absPath)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	action := "overwrite"
+	if !existed {
+		action = "create"
+	}
+	diff, added, removed := textdiff.Unified(path, string(before), content, existed, true)
+	return dryRunResult(absPath, action, diff, added, removed), nil
+}
+
+// resolve normalizes the target path and runs the security checks
+func (w *WriteFileTool) resolve(path, content string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	if w.security != nil {
+		if err := w.security.ValidatePath(absPath); err != nil {
+			return "", NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
+		}
+		if err := w.security.ValidateOperation(OpWrite, absPath); err != nil {
+			return "", NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
+		}
+		if err := w.security.CheckContent([]byte(content)); err != nil {
+			return "", fmt.Errorf("content validation failed: %w", err)
+		}
+	}
+
+	return absPath, nil
+}
+
 // EditFileTool implements file editing functionality
 type EditFileTool struct {
 	security SecurityValidator
@@ -325,6 +395,11 @@ func (e *EditFileTool) Schema() ToolSchema {
 				Description: "Replace all occurrences",
 				Default:     true,
 			},
+			"dry_run": {
+				Type:        "boolean",
+				Description: "Report the resulting diff without changing the file",
+				Default:     false,
+			},
 		},
 		Required: []string{"path", "old_text", "new_text"},
 	}
@@ -356,6 +431,96 @@ func (e *EditFileTool) Validate(params map[string]interface{}) error {
 }
 
 func (e *EditFileTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	edit, err := e.plan(params)
+	if err != nil {
+		return nil, err
+	}
+	absPath := edit.absPath
+	newContent := edit.updated
+	replacements := edit.replacements
+
+	// Check if any changes were made
+	if replacements == 0 {
+		return map[string]interface{}{
+			"path":         absPath,
+			"replacements": 0,
+			"success":      true,
+			"message":      "No matches found",
+		}, nil
+	}
+
+	// Security check new content
+	if e.security != nil {
+		if err := e.security.CheckContent([]byte(newContent)); err != nil {
+			return nil, fmt.Errorf("new content validation failed: %w", err)
+		}
+	}
+
+	// Create temp file for atomic write
+	tmpFile, err := os.CreateTemp(filepath.Dir(absPath), ".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	// Write new content to temp file
+	if _, err := tmpFile.WriteString(newContent); err != nil {
+		tmpFile.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	// Get original file permissions
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat original file: %w", err)
+	}
+
+	// Set permissions on temp file
+	if err := os.Chmod(tmpPath, info.Mode()); err != nil {
+		return nil, fmt.Errorf("failed to set permissions: %w", err)
+	}
+
+	// Atomically replace original file
+	if err := os.Rename(tmpPath, absPath); err != nil {
+		return nil, fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return map[string]interface{}{
+		"path":         absPath,
+		"replacements": replacements,
+		"success":      true,
+	}, nil
+}
+
+// fileEdit is the outcome of applying an edit_file call in memory
+type fileEdit struct {
+	absPath      string
+	original     string
+	updated      string
+	replacements int
+}
+
+// DryRun reports the diff edit_file would produce without writing
+func (e *EditFileTool) DryRun(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	edit, err := e.plan(params)
+	if err != nil {
+		return nil, err
+	}
+
+	path := params["path"].(string)
+	diff, added, removed := textdiff.Unified(path, edit.original, edit.updated, true, true)
+	result := dryRunResult(edit.absPath, "edit", diff, added, removed)
+	result["replacements"] = edit.replacements
+	if edit.replacements == 0 {
+		result["message"] = "Dry run: no matches found"
+	}
+	return result, nil
+}
+
+// plan reads the file and computes its edited content
+func (e *EditFileTool) plan(params map[string]interface{}) (*fileEdit, error) {
 	path := params["path"].(string)
 	oldText := params["old_text"].(string)
 	newText := params["new_text"].(string)
@@ -379,13 +544,13 @@ func (e *EditFileTool) Execute(ctx context.Context, params map[string]interface{
 	// Security check
 	if e.security != nil {
 		if err := e.security.ValidatePath(absPath); err != nil {
-			return nil, fmt.Errorf("security validation failed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
 		}
 		if err := e.security.ValidateOperation(OpRead, absPath); err != nil {
-			return nil, fmt.Errorf("read operation not allowed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "read operation not allowed: %w", err)
 		}
 		if err := e.security.ValidateOperation(OpWrite, absPath); err != nil {
-			return nil, fmt.Errorf("write operation not allowed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "write operation not allowed: %w", err)
 		}
 	}
 
@@ -434,58 +599,11 @@ func (e *EditFileTool) Execute(ctx context.Context, params map[string]interface{
 		}
 	}
 
-	// Check if any changes were made
-	if replacements == 0 {
-		return map[string]interface{}{
-			"path":         absPath,
-			"replacements": 0,
-			"success":      true,
-			"message":      "No matches found",
-		}, nil
-	}
-
-	// Security check new content
-	if e.security != nil {
-		if err := e.security.CheckContent([]byte(newContent)); err != nil {
-			return nil, fmt.Errorf("new content validation failed: %w", err)
-		}
-	}
-
-	// Create temp file for atomic write
-	tmpFile, err := os.CreateTemp(filepath.Dir(absPath), ".tmp-*")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %w", err)
-	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
-
-	// Write new content to temp file
-	if _, err := tmpFile.WriteString(newContent); err != nil {
-		tmpFile.Close()
-		return nil, fmt.Errorf("failed to write temp file: %w", err)
-	}
-	tmpFile.Close()
-
-	// Get original file permissions
-	info, err := os.Stat(absPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat original file: %w", err)
-	}
-
-	// Set permissions on temp file
-	if err := os.Chmod(tmpPath, info.Mode()); err != nil {
-		return nil, fmt.Errorf("failed to set permissions: %w", err)
-	}
-
-	// Atomically replace original file
-	if err := os.Rename(tmpPath, absPath); err != nil {
-		return nil, fmt.Errorf("failed to replace file: %w", err)
-	}
-
-	return map[string]interface{}{
-		"path":         absPath,
-		"replacements": replacements,
-		"success":      true,
+	return &fileEdit{
+		absPath:      absPath,
+		original:     originalContent,
+		updated:      newContent,
+		replacements: replacements,
 	}, nil
 }
 
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/common-creation/coda/internal/textdiff"
+)
+
+// formatTools are the tools whose results name a file they wrote
+var formatTools = map[string]bool{
+	"write_file": true,
+	"edit_file":  true,
+}
+
+// Formatter formats files whose name matches Files with Command, run with
+// the file path as its last argument (e.g. "gofmt -w" or "prettier --write")
+type Formatter struct {
+	Files   string
+	Command string
+}
+
+// NewFormatHook returns middleware that runs the matching formatters on the
+// files written by write_file and edit_file. When formatting changes a file,
+// the result reports the diff so the model knows its new content; failures
+// (such as syntax errors) are reported too. Formatters that are not
+// installed are skipped.
+func NewFormatHook(formatters []Formatter, dir string, logger Logger) Middleware {
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+			result, err := next(ctx, name, params)
+			if err != nil || !formatTools[name] || IsDryRunResult(result) {
+				return result, err
+			}
+			info, ok := result.(map[string]interface{})
+			if !ok {
+				return result, err
+			}
+			path, _ := info["path"].(string)
+			if path == "" {
+				return result, err
+			}
+
+			var diffs, failures, used []string
+			for _, f := range formatters {
+				if ok, _ := filepath.Match(f.Files, filepath.Base(path)); !ok {
+					continue
+				}
+				diff, err := f.run(ctx, dir, path)
+				switch {
+				case errors.Is(err, exec.ErrNotFound):
+					if logger != nil {
+						logger.Warn("Formatter not found", "command", f.Command, "error", err)
+					}
+				case err != nil:
+					failures = append(failures, err.Error())
+				case diff != "":
+					used = append(used, f.Command)
+					diffs = append(diffs, diff)
+				}
+			}
+
+			if len(diffs) > 0 {
+				info["formatted_by"] = strings.Join(used, ", ")
+				info["format_diff"] = strings.Join(diffs, "")
+				info["format_message"] = "The file was reformatted after writing; its content now includes the format_diff changes"
+			}
+			if len(failures) > 0 {
+				info["format_error"] = strings.Join(failures, "\n")
+			}
+			return info, nil
+		}
+	}
+}
+
+// run formats path and returns the diff of the changes it made
+func (f Formatter) run(ctx context.Context, dir, path string) (string, error) {
+	args := strings.Fields(f.Command)
+	if len(args) == 0 {
+		return "", nil
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", err
+		}
+		message := strings.TrimSpace(output.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("%s failed: %s", f.Command, message)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if bytes.Equal(before, after) {
+		return "", nil
+	}
+	display := path
+	if abs, err := filepath.Abs(dir); err == nil {
+		if rel, err := filepath.Rel(abs, path); err == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+	}
+	diff, _, _ := textdiff.Unified(filepath.ToSlash(display), string(before), string(after), true, true)
+	return diff, nil
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatHook(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt not found")
+	}
+	dir := t.TempDir()
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(NewWriteFileTool(nil)))
+	m.Use(NewFormatHook([]Formatter{
+		{Files: "*.go", Command: "gofmt -w"},
+		{Files: "*.go", Command: "no-such-formatter --write"},
+		{Files: "*.py", Command: "black -q"},
+	}, dir, nil))
+
+	path := filepath.Join(dir, "main.go")
+	result, err := m.Execute(context.Background(), "write_file", map[string]interface{}{"path": path, "content": "package main\nfunc main(){\n}\n"})
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, "gofmt -w", info["formatted_by"])
+	assert.Contains(t, info["format_diff"], "--- a/main.go")
+	assert.Contains(t, info["format_diff"], "+func main() {")
+	assert.NotContains(t, info, "format_error")
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "package main\n\nfunc main() {\n}\n", string(content))
+
+	// Already formatted files are reported unchanged
+	result, err = m.Execute(context.Background(), "write_file", map[string]interface{}{"path": path, "content": string(content)})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "format_diff")
+
+	// Formatter errors are returned to the model with the written file
+	result, err = m.Execute(context.Background(), "write_file", map[string]interface{}{"path": path, "content": "package main\nfunc main( {\n"})
+	require.NoError(t, err)
+	assert.Contains(t, result.(map[string]interface{})["format_error"], "gofmt -w failed: ")
+
+	// Other files are left alone
+	notes := filepath.Join(dir, "notes.txt")
+	result, err = m.Execute(context.Background(), "write_file", map[string]interface{}{"path": notes, "content": "a  b"})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "formatted_by")
+	assert.NotContains(t, result, "format_error")
+}
@@ -0,0 +1,416 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// HTTPRequestToolName is the name of the HTTP request tool
+const HTTPRequestToolName = "http_request"
+
+const (
+	// httpTimeout bounds a request, including redirects and the body
+	httpTimeout = 30 * time.Second
+	// defaultMaxResponseBytes is the response body returned by default
+	defaultMaxResponseBytes = 64 * 1024
+	// maxRedirects bounds the redirects followed
+	maxRedirects = 5
+)
+
+// errHostNotAllowed rejects redirects to hosts outside the allowlist
+var errHostNotAllowed = errors.New("host is not in the allowlist")
+
+// redactedValue replaces secret header values in results
+const redactedValue = "[redacted]"
+
+// defaultSecretHeaders hold credentials in most APIs
+var defaultSecretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"}
+
+// httpMethods are the methods the tool sends
+var httpMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// HTTPRequestTool sends HTTP requests to allowed hosts, like curl, for
+// debugging APIs
+type HTTPRequestTool struct {
+	allowed  []string
+	headers  map[string]map[string]string
+	secrets  map[string]bool
+	maxBytes int
+	client   *http.Client
+}
+
+// HTTPOption configures an HTTPRequestTool
+type HTTPOption func(*HTTPRequestTool)
+
+// WithHostHeaders adds headers to the requests to hosts matching a pattern
+// of the allowlist. Their values are redacted in results.
+func WithHostHeaders(headers map[string]map[string]string) HTTPOption {
+	return func(h *HTTPRequestTool) {
+		h.headers = headers
+		for _, values := range headers {
+			for name := range values {
+				h.secrets[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+}
+
+// WithSecretHeaders redacts the values of more headers in results
+func WithSecretHeaders(names []string) HTTPOption {
+	return func(h *HTTPRequestTool) {
+		for _, name := range names {
+			h.secrets[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// WithMaxResponseBytes sets the response body bytes returned
+func WithMaxResponseBytes(n int) HTTPOption {
+	return func(h *HTTPRequestTool) {
+		if n > 0 {
+			h.maxBytes = n
+		}
+	}
+}
+
+// NewHTTPRequestTool creates a new HTTPRequestTool sending requests to the
+// hosts matching allowed: "api.example.com", "*.example.com" for its
+// subdomains, "localhost:8080" for one port, or "*" for any host
+func NewHTTPRequestTool(allowed []string, opts ...HTTPOption) *HTTPRequestTool {
+	h := &HTTPRequestTool{
+		allowed:  allowed,
+		secrets:  make(map[string]bool),
+		maxBytes: defaultMaxResponseBytes,
+	}
+	for _, name := range defaultSecretHeaders {
+		h.secrets[name] = true
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.client = &http.Client{
+		Timeout: httpTimeout,
+		// Redirects must stay on allowed hosts, and lose the injected headers
+		// when they leave the host
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if !h.hostAllowed(req.URL) {
+				return fmt.Errorf("redirect to %s: %w", req.URL.Host, errHostNotAllowed)
+			}
+			if req.URL.Host != via[0].URL.Host {
+				for name := range h.hostHeaders(via[0].URL) {
+					req.Header.Del(name)
+				}
+			}
+			return nil
+		},
+	}
+	return h
+}
+
+func (h *HTTPRequestTool) Name() string {
+	return HTTPRequestToolName
+}
+
+func (h *HTTPRequestTool) Description() string {
+	return fmt.Sprintf("Send an HTTP request, like curl, to debug an API and get the status, headers and body; JSON bodies are pretty-printed and long bodies are cut. Only these hosts are allowed: %s. Credentials configured for a host are added automatically and never shown, so do not ask for them.", strings.Join(h.allowed, ", "))
+}
+
+func (h *HTTPRequestTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"method": {
+				Type:        "string",
+				Description: "HTTP method",
+				Default:     "GET",
+				Enum:        httpMethods,
+			},
+			"url": {
+				Type:        "string",
+				Description: "Absolute http or https URL",
+			},
+			"headers": {
+				Type:        "object",
+				Description: "Request headers by name",
+			},
+			"body": {
+				Type:        "string",
+				Description: "Request body; JSON is sent with Content-Type application/json unless headers set one",
+			},
+		},
+		Required: []string{"url"},
+	}
+}
+
+func (h *HTTPRequestTool) Validate(params map[string]interface{}) error {
+	rawURL, ok := params["url"].(string)
+	if !ok || rawURL == "" {
+		return fmt.Errorf("url is required and must be a string")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if value, exists := params["method"]; exists {
+		method, ok := value.(string)
+		if !ok || !slices.Contains(httpMethods, strings.ToUpper(method)) {
+			return fmt.Errorf("method must be one of %s", strings.Join(httpMethods, ", "))
+		}
+	}
+	if value, exists := params["headers"]; exists {
+		headers, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("headers must be an object")
+		}
+		for name, v := range headers {
+			if _, ok := v.(string); !ok {
+				return fmt.Errorf("header %s must be a string", name)
+			}
+		}
+	}
+	if value, exists := params["body"]; exists {
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("body must be a string")
+		}
+	}
+	return nil
+}
+
+func (h *HTTPRequestTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	req, err := h.newRequest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errHostNotAllowed) {
+			return nil, NewToolError(ErrCodePermissionDenied, "%s", h.redactSecrets(err.Error()))
+		}
+		return nil, NewToolError(ErrCodeExecution, "request failed: %s", h.redactSecrets(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(h.maxBytes)+1))
+	if err != nil {
+		return nil, NewToolError(ErrCodeExecution, "failed to read response: %w", err)
+	}
+	truncated := len(data) > h.maxBytes
+	if truncated {
+		cut := h.maxBytes
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		data = data[:cut]
+	}
+	body := h.redactSecrets(formatBody(resp.Header.Get("Content-Type"), data, truncated))
+
+	info := map[string]interface{}{
+		"request":     h.describe(req),
+		"status":      resp.StatusCode,
+		"status_text": resp.Status,
+		"headers":     h.redactHeaders(resp.Header),
+		"body":        body,
+		"truncated":   truncated,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if resp.Request != nil && resp.Request.URL.String() != req.URL.String() {
+		info["final_url"] = resp.Request.URL.String()
+	}
+	if truncated {
+		info["message"] = fmt.Sprintf("The body was cut to %d bytes", h.maxBytes)
+	}
+	return info, nil
+}
+
+// DryRun describes the request without sending it, with secrets redacted
+func (h *HTTPRequestTool) DryRun(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	req, err := h.newRequest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		DryRunParam: true,
+		"request":   h.describe(req),
+		"message":   "Dry run: the request was not sent",
+	}, nil
+}
+
+// newRequest builds the request of a call to an allowed host, with the
+// headers configured for it
+func (h *HTTPRequestTool) newRequest(ctx context.Context, params map[string]interface{}) (*http.Request, error) {
+	u, err := url.Parse(params["url"].(string))
+	if err != nil {
+		return nil, NewToolError(ErrCodeInvalidParams, "invalid url: %w", err)
+	}
+	if !h.hostAllowed(u) {
+		return nil, NewToolError(ErrCodePermissionDenied, "host %s is not in the allowlist (%s); add it to tools.http.allowed_domains", u.Host, strings.Join(h.allowed, ", "))
+	}
+
+	method := "GET"
+	if m, ok := params["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+	body, _ := params["body"].(string)
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, NewToolError(ErrCodeInvalidParams, "invalid request: %w", err)
+	}
+
+	if headers, ok := params["headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			req.Header.Set(name, value.(string))
+		}
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" && json.Valid([]byte(body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range h.hostHeaders(u) {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// hostAllowed reports whether u is on a host of the allowlist
+func (h *HTTPRequestTool) hostAllowed(u *url.URL) bool {
+	for _, pattern := range h.allowed {
+		if matchHost(pattern, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostHeaders returns the headers configured for the host of u
+func (h *HTTPRequestTool) hostHeaders(u *url.URL) map[string]string {
+	headers := make(map[string]string)
+	for pattern, values := range h.headers {
+		if matchHost(pattern, u) {
+			for name, value := range values {
+				headers[name] = value
+			}
+		}
+	}
+	return headers
+}
+
+// matchHost reports whether the host of u matches pattern: a host name, a
+// "*." wildcard for subdomains, "*", and optionally a port
+func matchHost(pattern string, u *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	if name, port, err := net.SplitHostPort(pattern); err == nil {
+		if port != u.Port() && port != defaultPort(u) {
+			return false
+		}
+		pattern = name
+	}
+	pattern = strings.Trim(pattern, "[]")
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// defaultPort returns the port of u, or that of its scheme
+func defaultPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// describe returns the method, URL and redacted headers of req
+func (h *HTTPRequestTool) describe(req *http.Request) map[string]interface{} {
+	return map[string]interface{}{
+		"method":  req.Method,
+		"url":     req.URL.Redacted(),
+		"headers": h.redactHeaders(req.Header),
+	}
+}
+
+// redactHeaders flattens headers, replacing the values of secret headers
+func (h *HTTPRequestTool) redactHeaders(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		if h.secrets[http.CanonicalHeaderKey(name)] {
+			flat[name] = redactedValue
+			continue
+		}
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// redactSecrets replaces the configured header values in text, such as an
+// echoing server's body
+func (h *HTTPRequestTool) redactSecrets(text string) string {
+	var values []string
+	for _, headers := range h.headers {
+		for _, value := range headers {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	// Longer values first, so a value containing another is replaced whole
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		text = strings.ReplaceAll(text, value, redactedValue)
+	}
+	return text
+}
+
+// formatBody pretty-prints complete JSON bodies and returns others as text
+func formatBody(contentType string, data []byte, truncated bool) string {
+	if len(data) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if !truncated && (isJSON || json.Valid(data)) {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, data, "", "  "); err == nil {
+			return pretty.String()
+		}
+	}
+	if !isText(mediaType, data) {
+		return fmt.Sprintf("[%d bytes of %s]", len(data), mediaType)
+	}
+	return string(data)
+}
+
+// isText reports whether a body can be shown as text
+func isText(mediaType string, data []byte) bool {
+	if strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") || strings.Contains(mediaType, "xml") || mediaType == "application/x-www-form-urlencoded" || mediaType == "application/javascript" {
+		return true
+	}
+	return !bytes.ContainsRune(data, 0) && strings.HasPrefix(http.DetectContentType(data), "text/")
+}
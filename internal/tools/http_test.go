@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"api.example.com", "https://api.example.com/v1", true},
+		{"api.example.com", "https://API.example.com:8443/v1", true},
+		{"api.example.com", "https://example.com", false},
+		{"*.example.com", "https://api.example.com", true},
+		{"*.example.com", "https://example.com", false},
+		{"*.example.com", "https://evilexample.com", false},
+		{"localhost:8080", "http://localhost:8080/", true},
+		{"localhost:8080", "http://localhost:9090/", false},
+		{"example.com:443", "https://example.com/", true},
+		{"[::1]:8080", "http://[::1]:8080/", true},
+		{"*", "http://anything.test", true},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, matchHost(tt.pattern, u), "%s %s", tt.pattern, tt.url)
+	}
+}
+
+func TestHTTPRequestTool(t *testing.T) {
+	var received http.Header
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		receivedBody = string(data)
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=abc")
+			io.WriteString(w, `{"token":"`+r.Header.Get("X-Api-Key")+`","items":[1,2]}`)
+		case "/away":
+			http.Redirect(w, r, "http://example.invalid/", http.StatusFound)
+		case "/large":
+			io.WriteString(w, strings.Repeat("é", 100))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tool := NewHTTPRequestTool([]string{host},
+		WithHostHeaders(map[string]map[string]string{host: {"X-Api-Key": "s3cret"}}),
+		WithSecretHeaders([]string{"x-session"}),
+		WithMaxResponseBytes(101),
+	)
+	assert.Error(t, tool.Validate(map[string]interface{}{"url": "ftp://x"}))
+	assert.Error(t, tool.Validate(map[string]interface{}{"url": server.URL, "method": "TRACE"}))
+	assert.Error(t, tool.Validate(map[string]interface{}{"url": server.URL, "headers": map[string]interface{}{"X": 1}}))
+
+	params := map[string]interface{}{
+		"url":     server.URL + "/json",
+		"method":  "post",
+		"headers": map[string]interface{}{"Authorization": "Bearer model", "X-Session": "42", "Accept": "application/json"},
+		"body":    `{"name":"x"}`,
+	}
+	require.NoError(t, tool.Validate(params))
+	result, err := tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+
+	assert.Equal(t, "s3cret", received.Get("X-Api-Key"))
+	assert.Equal(t, "application/json", received.Get("Content-Type"))
+	assert.Equal(t, `{"name":"x"}`, receivedBody)
+	assert.Equal(t, 200, info["status"])
+	assert.Equal(t, "{\n  \"token\": \"[redacted]\",\n  \"items\": [\n    1,\n    2\n  ]\n}", info["body"])
+	assert.Equal(t, "[redacted]", info["headers"].(map[string]string)["Set-Cookie"])
+	request := info["request"].(map[string]interface{})
+	assert.Equal(t, "POST", request["method"])
+	assert.Equal(t, map[string]string{
+		"Authorization": "[redacted]",
+		"X-Session":     "[redacted]",
+		"X-Api-Key":     "[redacted]",
+		"Accept":        "application/json",
+		"Content-Type":  "application/json",
+	}, request["headers"])
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/large"})
+	require.NoError(t, err)
+	info = result.(map[string]interface{})
+	assert.Equal(t, true, info["truncated"])
+	assert.Equal(t, strings.Repeat("é", 50), info["body"])
+
+	var toolErr *ToolError
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"url": "https://example.com/"})
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodePermissionDenied, toolErr.Code)
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/away"})
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodePermissionDenied, toolErr.Code)
+
+	result, err = tool.DryRun(context.Background(), params)
+	require.NoError(t, err)
+	assert.True(t, IsDryRunResult(result))
+	headers := result.(map[string]interface{})["request"].(map[string]interface{})["headers"].(map[string]string)
+	assert.Equal(t, "[redacted]", headers["X-Api-Key"])
+}
+
+func TestFormatBody(t *testing.T) {
+	assert.Equal(t, "{\n  \"a\": 1\n}", formatBody("text/plain", []byte(`{"a":1}`), false))
+	assert.Equal(t, `{"a":`, formatBody("application/json", []byte(`{"a":`), true))
+	assert.Equal(t, "<p>hi</p>", formatBody("text/html; charset=utf-8", []byte("<p>hi</p>"), false))
+	assert.Equal(t, "[4 bytes of image/png]", formatBody("image/png", []byte{0x89, 'P', 'N', 0}, false))
+	assert.Empty(t, formatBody("application/json", nil, false))
+}
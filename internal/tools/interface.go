@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -78,3 +79,36 @@ type SecurityValidator interface {
 	IsAllowedExtension(path string) bool
 	CheckContent(content []byte) error
 }
+
+type sessionIDKey struct{}
+
+// WithSessionID attaches the chat session ID to a tool execution context
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, sessionID)
+}
+
+// SessionIDFromContext returns the session ID set by WithSessionID
+func SessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionIDKey{}).(string)
+	return sessionID
+}
+
+type outputStreamKey struct{}
+
+// WithOutputStream attaches a writer that receives the output of long
+// running tools (e.g. run_command) while they execute
+func WithOutputStream(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputStreamKey{}, w)
+}
+
+// OutputStreamFromContext returns the writer set by WithOutputStream, or nil
+func OutputStreamFromContext(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(outputStreamKey{}).(io.Writer)
+	return w
+}
+
+// StreamsOutput reports whether the named tool writes its output to the
+// stream set with WithOutputStream
+func StreamsOutput(name string) bool {
+	return name == RunCommandToolName || name == DockerBuildToolName
+}
@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/common-creation/coda/internal/k8s"
+)
+
+// Names of the Kubernetes tools
+const (
+	ValidateK8sToolName = "validate_k8s"
+	K8sDiffToolName     = "k8s_diff"
+)
+
+const (
+	// k8sTimeout bounds a validation or diff, which may download schemas or
+	// talk to the cluster
+	k8sTimeout = 5 * time.Minute
+	// k8sDiffLimit is the tail of the diff sent to the model
+	k8sDiffLimit = 32 * 1024
+)
+
+// ValidateK8sTool checks Kubernetes manifests against their schemas and
+// for common mistakes
+type ValidateK8sTool struct {
+	security  SecurityValidator
+	workDir   string
+	validator *k8s.Validator
+}
+
+// NewValidateK8sTool creates a new ValidateK8sTool checking manifests under
+// workDir
+func NewValidateK8sTool(security SecurityValidator, workDir string, opts ...k8s.Option) *ValidateK8sTool {
+	return &ValidateK8sTool{security: security, workDir: workDir, validator: k8s.NewValidator(opts...)}
+}
+
+func (v *ValidateK8sTool) Name() string {
+	return ValidateK8sToolName
+}
+
+func (v *ValidateK8sTool) Description() string {
+	return "Validate Kubernetes manifests without touching a cluster: against the Kubernetes schemas with kubeconform (when installed), and for mistakes schemas miss, such as selectors that do not match pod labels, containers without images, unpinned images, duplicate resources and Services that select no pods. Check generated manifests with it before suggesting that they be applied; applying them is up to the user."
+}
+
+func (v *ValidateK8sTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Manifest file or directory (searched for .yaml, .yml and .json files)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// SandboxLimits lets k8sTimeout, not the sandbox, stop a slow schema download
+func (v *ValidateK8sTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(k8sTimeout)
+}
+
+func (v *ValidateK8sTool) Validate(params map[string]interface{}) error {
+	if path, ok := params["path"].(string); !ok || path == "" {
+		return fmt.Errorf("path is required and must be a string")
+	}
+	return nil
+}
+
+func (v *ValidateK8sTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path := params["path"].(string)
+	if _, err := resolveReadPath(v.security, v.workDir, path); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, k8sTimeout)
+	defer cancel()
+	result, err := v.validator.Validate(ctx, v.workDir, []string{path})
+	if err != nil {
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	info := map[string]interface{}{
+		"resources":      len(result.Resources),
+		"issues":         result.Issues,
+		"valid":          result.Valid(),
+		"summary":        result.Counts(),
+		"schema_checked": result.SchemaChecked,
+	}
+	if !result.SchemaChecked {
+		info["note"] = "kubeconform is not installed, so only the built-in checks ran; install it with go install github.com/yannh/kubeconform/cmd/kubeconform@latest"
+	}
+	if len(result.NoSchema) > 0 {
+		info["no_schema"] = result.NoSchema
+	}
+	return info, nil
+}
+
+// K8sDiffTool shows what applying manifests would change in the cluster
+type K8sDiffTool struct {
+	security SecurityValidator
+	workDir  string
+	kubectl  *k8s.Kubectl
+}
+
+// NewK8sDiffTool creates a new K8sDiffTool diffing manifests under workDir
+// against the cluster of the current kubectl context
+func NewK8sDiffTool(security SecurityValidator, workDir string) *K8sDiffTool {
+	return &K8sDiffTool{security: security, workDir: workDir, kubectl: k8s.NewKubectl()}
+}
+
+// KubectlAvailable reports whether kubectl is on the PATH
+func KubectlAvailable() bool {
+	return k8s.NewKubectl().Available()
+}
+
+func (d *K8sDiffTool) Name() string {
+	return K8sDiffToolName
+}
+
+func (d *K8sDiffTool) Description() string {
+	return "Show what applying Kubernetes manifests would change in the cluster of the current kubectl context (kubectl diff, a server-side dry run that changes nothing). Use it after validate_k8s; it never applies the manifests."
+}
+
+func (d *K8sDiffTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Manifest file or directory",
+			},
+			"namespace": {
+				Type:        "string",
+				Description: "Namespace of resources that do not set one",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// SandboxLimits lets k8sTimeout, not the sandbox, stop a slow cluster diff
+func (d *K8sDiffTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(k8sTimeout)
+}
+
+func (d *K8sDiffTool) Validate(params map[string]interface{}) error {
+	if path, ok := params["path"].(string); !ok || path == "" {
+		return fmt.Errorf("path is required and must be a string")
+	}
+	if value, exists := params["namespace"]; exists {
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("namespace must be a string")
+		}
+	}
+	return nil
+}
+
+func (d *K8sDiffTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	path := params["path"].(string)
+	namespace, _ := params["namespace"].(string)
+	if _, err := resolveReadPath(d.security, d.workDir, path); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, k8sTimeout)
+	defer cancel()
+	result, err := d.kubectl.Diff(ctx, d.workDir, []string{path}, namespace)
+	if err != nil {
+		if errors.Is(err, k8s.ErrKubectlUnavailable) {
+			return nil, NewToolError(ErrCodeUnavailable, "%w", err)
+		}
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+
+	diff := NewTailBuffer(k8sDiffLimit)
+	diff.Write([]byte(result.Diff))
+	return map[string]interface{}{
+		"changed":   result.Changed,
+		"diff":      diff.String(),
+		"truncated": diff.Truncated(),
+	}, nil
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/k8s"
+)
+
+func TestValidateK8sTool(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  selector:\n    matchLabels: {app: web}\n  template:\n    metadata:\n      labels: {app: web}\n    spec:\n      containers:\n        - name: web\n          image: nginx:1.25\n          resources:\n            limits: {memory: 64Mi}\n"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "deploy"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy", "web.yaml"), []byte(manifest), 0644))
+	tool := NewValidateK8sTool(nil, dir, k8s.WithKubeconform("kubeconform-not-installed"))
+
+	assert.Error(t, tool.Validate(map[string]interface{}{}))
+	params := map[string]interface{}{"path": "deploy"}
+	require.NoError(t, tool.Validate(params))
+
+	result, err := tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, true, info["valid"])
+	assert.Equal(t, 1, info["resources"])
+	assert.Equal(t, "1 resources: 0 errors, 0 warnings, 0 notes", info["summary"])
+	assert.Contains(t, info["note"], "kubeconform is not installed")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"path": "missing"})
+	var toolErr *ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeExecution, toolErr.Code)
+}
+
+func TestK8sToolsSandboxLimits(t *testing.T) {
+	assertOwnTimeoutApplies(t, NewValidateK8sTool(nil, t.TempDir()), k8sTimeout)
+	assertOwnTimeoutApplies(t, NewK8sDiffTool(nil, t.TempDir()), k8sTimeout)
+}
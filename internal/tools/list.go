@@ -167,10 +167,10 @@ func (l *ListFilesTool) Execute(ctx context.Context, params map[string]interface
 	// Security check
 	if l.security != nil {
 		if err := l.security.ValidatePath(absPath); err != nil {
-			return nil, fmt.Errorf("security validation failed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
 		}
 		if err := l.security.ValidateOperation(OpList, absPath); err != nil {
-			return nil, fmt.Errorf("operation not allowed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
 		}
 	}
 
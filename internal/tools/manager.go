@@ -4,14 +4,28 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/common-creation/coda/internal/security"
 )
 
+// ExecuteHook is called before a tool runs, after its parameters are validated
+type ExecuteHook func(ctx context.Context, name string, params map[string]interface{})
+
 // Manager manages tool registration, discovery, and execution
 type Manager struct {
 	tools    map[string]Tool
 	mu       sync.RWMutex
 	security SecurityValidator
 	logger   Logger
+	sandbox  *Sandbox
+	stats    *UsageStats
+	hooks    []ExecuteHook
+	dryRun   bool
+	enabled  []string // Tool name globs that may be registered; empty allows all
+	disabled []string // Tool name globs that are never registered
+
+	middleware []Middleware
 }
 
 // NewManager creates a new tool manager instance
@@ -20,6 +34,7 @@ func NewManager(validator SecurityValidator, logger Logger) *Manager {
 		tools:    make(map[string]Tool),
 		security: validator,
 		logger:   logger,
+		stats:    NewUsageStats(),
 	}
 }
 
@@ -37,6 +52,13 @@ func (m *Manager) Register(tool Tool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if !m.isEnabled(name) {
+		if m.logger != nil {
+			m.logger.Debug("Skipped disabled tool", "name", name)
+		}
+		return nil
+	}
+
 	if _, exists := m.tools[name]; exists {
 		return fmt.Errorf("tool '%s' is already registered", name)
 	}
@@ -66,7 +88,27 @@ func (m *Manager) Get(name string) (Tool, error) {
 func (m *Manager) Execute(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
 	tool, err := m.Get(name)
 	if err != nil {
-		return nil, err
+		return nil, &ToolError{Tool: name, Code: ErrCodeNotFound, Message: err.Error()}
+	}
+
+	run := m.chain(func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+		return m.execute(ctx, tool, name, params)
+	})
+
+	start := time.Now()
+	result, err := run(ctx, name, params)
+	m.stats.Record(SessionIDFromContext(ctx), name, time.Since(start), err != nil)
+	if err != nil {
+		return nil, AsToolError(name, err)
+	}
+	return result, nil
+}
+
+// execute validates and runs tool
+func (m *Manager) execute(ctx context.Context, tool Tool, name string, params map[string]interface{}) (interface{}, error) {
+	// Calls canceled before they start do nothing
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("tool '%s' canceled: %w", name, err)
 	}
 
 	// Log execution start
@@ -79,11 +121,41 @@ func (m *Manager) Execute(ctx context.Context, name string, params map[string]in
 		if m.logger != nil {
 			m.logger.Error("Tool validation failed", "name", name, "error", err)
 		}
-		return nil, fmt.Errorf("validation failed for tool '%s': %w", name, err)
+		return nil, &ToolError{
+			Tool:    name,
+			Code:    ErrCodeInvalidParams,
+			Message: fmt.Sprintf("validation failed for tool '%s': %v", name, err),
+			Err:     err,
+		}
 	}
 
-	// Execute the tool
-	result, err := tool.Execute(ctx, params)
+	// In dry-run mode mutating tools only describe what they would do
+	if !security.IsReadOnlyTool(name) && (m.IsDryRun() || IsDryRunRequested(params)) {
+		if m.logger != nil {
+			m.logger.Debug("Dry-running tool", "name", name)
+		}
+		result, err := dryRun(ctx, tool, params)
+		if err != nil {
+			return nil, fmt.Errorf("dry run failed for tool '%s': %w", name, err)
+		}
+		return result, nil
+	}
+
+	m.mu.RLock()
+	hooks := m.hooks
+	m.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(ctx, name, params)
+	}
+
+	// Execute the tool, under supervision when a sandbox is configured
+	var result interface{}
+	var err error
+	if sandbox := m.GetSandbox(); sandbox != nil {
+		result, err = sandbox.Run(ctx, tool, params)
+	} else {
+		result, err = tool.Execute(ctx, params)
+	}
 	if err != nil {
 		if m.logger != nil {
 			m.logger.Error("Tool execution failed", "name", name, "error", err)
@@ -166,6 +238,76 @@ func (m *Manager) GetSecurityValidator() SecurityValidator {
 	return m.security
 }
 
+// SetSandbox sets the sandbox used to supervise tool execution (nil disables it)
+func (m *Manager) SetSandbox(sandbox *Sandbox) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sandbox = sandbox
+}
+
+// GetSandbox returns the current sandbox, if any
+func (m *Manager) GetSandbox() *Sandbox {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sandbox
+}
+
+// AddHook registers a hook called before every tool execution
+func (m *Manager) AddHook(hook ExecuteHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// SetDryRun enables or disables dry-run mode for every call
+func (m *Manager) SetDryRun(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dryRun = enabled
+}
+
+// IsDryRun reports whether dry-run mode is enabled for every call
+func (m *Manager) IsDryRun() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.dryRun
+}
+
+// SetEnabledTools limits the tools that can be registered to names matching
+// one of enabled (all when empty) and none of disabled. The patterns are globs
+// such as "mcp_*". Registered tools that are no longer enabled are removed.
+func (m *Manager) SetEnabledTools(enabled, disabled []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enabled = enabled
+	m.disabled = disabled
+	for name := range m.tools {
+		if !m.isEnabled(name) {
+			delete(m.tools, name)
+		}
+	}
+}
+
+// IsEnabled reports whether the tool name may be registered and run
+func (m *Manager) IsEnabled(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.isEnabled(name)
+}
+
+// isEnabled is IsEnabled for callers holding the lock
+func (m *Manager) isEnabled(name string) bool {
+	if len(m.enabled) > 0 && !matchesAny(name, m.enabled) {
+		return false
+	}
+	return !matchesAny(name, m.disabled)
+}
+
+// Stats returns the usage statistics of the tools run by the manager
+func (m *Manager) Stats() *UsageStats {
+	return m.stats
+}
+
 // GetAll returns all registered tools
 func (m *Manager) GetAll() []Tool {
 	m.mu.RLock()
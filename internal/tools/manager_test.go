@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerEnabledTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  []string
+		disabled []string
+		want     []string
+	}{
+		{"all by default", nil, nil, []string{"edit_file", "list_files", "read_file", "write_file"}},
+		{"enabled only", []string{"read_file", "list_*"}, nil, []string{"list_files", "read_file"}},
+		{"disabled removed", nil, []string{"*_file"}, []string{"list_files"}},
+		{"disabled wins", []string{"read_file", "write_file"}, []string{"write_file"}, []string{"read_file"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(nil, nil)
+			m.SetEnabledTools(tt.enabled, tt.disabled)
+			require.NoError(t, m.Register(NewReadFileTool(nil)))
+			require.NoError(t, m.Register(NewWriteFileTool(nil)))
+			require.NoError(t, m.Register(NewEditFileTool(nil)))
+			require.NoError(t, m.Register(NewListFilesTool(nil)))
+
+			assert.ElementsMatch(t, tt.want, m.List())
+			for _, name := range tt.want {
+				assert.True(t, m.IsEnabled(name))
+			}
+		})
+	}
+
+	t.Run("filters registered tools", func(t *testing.T) {
+		m := NewManager(nil, nil)
+		require.NoError(t, m.Register(NewReadFileTool(nil)))
+		require.NoError(t, m.Register(NewWriteFileTool(nil)))
+
+		m.SetEnabledTools([]string{"read_file"}, nil)
+		assert.Equal(t, []string{"read_file"}, m.List())
+		_, err := m.Get("write_file")
+		assert.Error(t, err)
+	})
+}
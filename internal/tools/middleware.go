@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExecuteFunc runs the tool name with params
+type ExecuteFunc func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error)
+
+// Middleware wraps tool execution. It can inspect or change the parameters,
+// skip the call by not calling next, or change the result.
+type Middleware func(next ExecuteFunc) ExecuteFunc
+
+// Use adds middleware around every tool execution. The first middleware
+// added is the outermost; all of them run before parameter validation.
+func (m *Manager) Use(middleware ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.middleware = append(m.middleware, middleware...)
+}
+
+// chain wraps run in the middleware added with Use
+func (m *Manager) chain(run ExecuteFunc) ExecuteFunc {
+	m.mu.RLock()
+	middleware := m.middleware
+	m.mu.RUnlock()
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		run = middleware[i](run)
+	}
+	return run
+}
+
+// defaultHookTimeout bounds a command hook that sets no timeout
+const defaultHookTimeout = 30 * time.Second
+
+// CommandHook runs shell commands before and after calls of matching tools.
+// The commands get the call as JSON on stdin and CODA_TOOL in their
+// environment; a Before command that fails blocks the call.
+type CommandHook struct {
+	Name    string
+	Tool    string // Glob of the tool names; empty matches every tool
+	Before  string
+	After   string
+	Dir     string
+	Timeout time.Duration
+}
+
+// hookInput is the JSON written to a hook command's stdin
+type hookInput struct {
+	Hook   string                 `json:"hook"`
+	Tool   string                 `json:"tool"`
+	Params map[string]interface{} `json:"params"`
+	Result interface{}            `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// NewCommandHook returns middleware running hook. Failures of the After
+// command are logged and leave the result unchanged.
+func NewCommandHook(hook CommandHook, logger Logger) Middleware {
+	if hook.Timeout <= 0 {
+		hook.Timeout = defaultHookTimeout
+	}
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+			if hook.Tool != "" && !matchesAny(name, []string{hook.Tool}) {
+				return next(ctx, name, params)
+			}
+
+			if hook.Before != "" {
+				input := hookInput{Hook: "before", Tool: name, Params: params}
+				if err := hook.run(ctx, hook.Before, input); err != nil {
+					return nil, NewToolError(ErrCodePermissionDenied, "blocked by hook %s: %w", hook.Name, err)
+				}
+			}
+
+			result, err := next(ctx, name, params)
+
+			if hook.After != "" {
+				input := hookInput{Hook: "after", Tool: name, Params: params, Result: result}
+				if err != nil {
+					input.Error = err.Error()
+				}
+				if hookErr := hook.run(ctx, hook.After, input); hookErr != nil && logger != nil {
+					logger.Warn("Tool hook failed", "hook", hook.Name, "tool", name, "error", hookErr)
+				}
+			}
+			return result, err
+		}
+	}
+}
+
+// run runs command with input on stdin; its output explains a failure
+func (h CommandHook) run(ctx context.Context, command string, input hookInput) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook input: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = h.Dir
+	cmd.Env = append(cmd.Environ(), "CODA_TOOL="+input.Tool)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.WaitDelay = commandWaitDelay
+	killProcessGroup(cmd)
+
+	output := NewTailBuffer(1024)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(output.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerMiddleware(t *testing.T) {
+	var calls []string
+	trace := func(label string) Middleware {
+		return func(next ExecuteFunc) ExecuteFunc {
+			return func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+				calls = append(calls, label+" before "+name)
+				result, err := next(ctx, name, params)
+				calls = append(calls, label+" after")
+				return result, err
+			}
+		}
+	}
+
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(NewListFilesTool(nil)))
+	m.Use(trace("outer"), trace("inner"))
+
+	_, err := m.Execute(context.Background(), "list_files", map[string]interface{}{"path": t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer before list_files", "inner before list_files", "inner after", "outer after"}, calls)
+}
+
+func TestManagerMiddlewareSkipsCall(t *testing.T) {
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(NewWriteFileTool(nil)))
+	m.Use(func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, name string, params map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("rate limited")
+		}
+	})
+
+	path := t.TempDir() + "/out.txt"
+	_, err := m.Execute(context.Background(), "write_file", map[string]interface{}{"path": path, "content": "x"})
+	assert.EqualError(t, err, "rate limited")
+	assert.NoFileExists(t, path)
+
+	usage := m.Stats().Usage("")
+	require.Len(t, usage, 1)
+	assert.Equal(t, 1, usage[0].Failures)
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/common-creation/coda/internal/symbols"
+)
+
+// PackageAPIToolName is the name of the Go package API tool
+const PackageAPIToolName = "get_package_api"
+
+// PackageAPITool shows the exported declarations of a Go package, so code
+// using it can be written without reading every file
+type PackageAPITool struct {
+	root string
+}
+
+// NewPackageAPITool creates a new PackageAPITool resolving packages from root
+func NewPackageAPITool(root string) *PackageAPITool {
+	return &PackageAPITool{root: root}
+}
+
+func (p *PackageAPITool) Name() string {
+	return PackageAPIToolName
+}
+
+func (p *PackageAPITool) Description() string {
+	return "Show the exported API (types, functions, methods, constants with their doc) of a Go package, optionally with the APIs of the non-standard packages it imports. Use it before writing code that calls into a package."
+}
+
+func (p *PackageAPITool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"package": {
+				Type:        "string",
+				Description: "Package directory or Go file relative to the workspace, or an import path",
+			},
+			"include_imports": {
+				Type:        "boolean",
+				Description: "Also show the APIs of the packages it imports, except the standard library",
+				Default:     false,
+			},
+		},
+		Required: []string{"package"},
+	}
+}
+
+func (p *PackageAPITool) Validate(params map[string]interface{}) error {
+	pkg, ok := params["package"].(string)
+	if !ok || pkg == "" {
+		return fmt.Errorf("package is required and must be a non-empty string")
+	}
+	if imports, exists := params["include_imports"]; exists {
+		if _, ok := imports.(bool); !ok {
+			return fmt.Errorf("include_imports must be a boolean")
+		}
+	}
+	return nil
+}
+
+func (p *PackageAPITool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	pkg := params["package"].(string)
+	imports, _ := params["include_imports"].(bool)
+
+	api, err := symbols.PackageAPI(ctx, p.root, pkg, imports)
+	if err != nil {
+		return nil, NewToolError(ErrCodeNotFound, "failed to load package API: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RenameSymbolToolName is the name of the gopls rename tool
+const RenameSymbolToolName = "rename_symbol"
+
+// RenameSymbolTool renames a Go identifier and every reference to it across
+// the workspace with gopls. Without apply it only reports the files that
+// would change and the diff, so the rename can be reviewed first.
+type RenameSymbolTool struct {
+	security SecurityValidator
+	workDir  string
+	gopls    string
+	record   func(ctx context.Context, path string)
+}
+
+// RenameOption configures a RenameSymbolTool
+type RenameOption func(*RenameSymbolTool)
+
+// WithRenameRecorder calls record with each file before a rename changes it,
+// so the changes can be reviewed and reverted like edit_file changes
+func WithRenameRecorder(record func(ctx context.Context, path string)) RenameOption {
+	return func(r *RenameSymbolTool) {
+		r.record = record
+	}
+}
+
+// NewRenameSymbolTool creates a new RenameSymbolTool running gopls in workDir
+func NewRenameSymbolTool(security SecurityValidator, workDir string, opts ...RenameOption) *RenameSymbolTool {
+	r := &RenameSymbolTool{security: security, workDir: workDir, gopls: "gopls"}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// GoplsAvailable reports whether gopls is on the PATH
+func GoplsAvailable() bool {
+	_, err := exec.LookPath("gopls")
+	return err == nil
+}
+
+func (r *RenameSymbolTool) Name() string {
+	return RenameSymbolToolName
+}
+
+func (r *RenameSymbolTool) Description() string {
+	return "Rename a Go identifier (function, type, method, field, variable, package member) and all its references across the workspace, type-checked by gopls. Call it first without apply to get the changed files and diff, then with apply: true to write them. Prefer it over editing several files by hand."
+}
+
+func (r *RenameSymbolTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Go file containing a declaration or use of the identifier",
+			},
+			"line": {
+				Type:        "integer",
+				Description: "Line (1-based) where the identifier appears",
+			},
+			"symbol": {
+				Type:        "string",
+				Description: "Current name of the identifier",
+			},
+			"new_name": {
+				Type:        "string",
+				Description: "New name of the identifier",
+			},
+			"apply": {
+				Type:        "boolean",
+				Description: "Write the changes; without it only the changed files and diff are returned",
+				Default:     false,
+			},
+		},
+		Required: []string{"path", "line", "symbol", "new_name"},
+	}
+}
+
+// identifierPattern matches a Go identifier
+var identifierPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{Nd}_]*$`)
+
+func (r *RenameSymbolTool) Validate(params map[string]interface{}) error {
+	path, ok := params["path"].(string)
+	if !ok || path == "" {
+		return fmt.Errorf("path is required and must be a string")
+	}
+	if !strings.HasSuffix(path, ".go") {
+		return fmt.Errorf("path must be a Go file")
+	}
+	switch v := params["line"].(type) {
+	case int:
+		if v < 1 {
+			return fmt.Errorf("line must be at least 1")
+		}
+	case float64:
+		if v < 1 {
+			return fmt.Errorf("line must be at least 1")
+		}
+	default:
+		return fmt.Errorf("line is required and must be a number")
+	}
+	for _, key := range []string{"symbol", "new_name"} {
+		name, ok := params[key].(string)
+		if !ok || !identifierPattern.MatchString(name) {
+			return fmt.Errorf("%s is required and must be a Go identifier", key)
+		}
+	}
+	if apply, exists := params["apply"]; exists {
+		if _, ok := apply.(bool); !ok {
+			return fmt.Errorf("apply must be a boolean")
+		}
+	}
+	return nil
+}
+
+func (r *RenameSymbolTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	apply, _ := params["apply"].(bool)
+	return r.rename(ctx, params, apply)
+}
+
+// DryRun previews the rename without writing it
+func (r *RenameSymbolTool) DryRun(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	result, err := r.rename(ctx, params, false)
+	if err != nil {
+		return nil, err
+	}
+	result[DryRunParam] = true
+	result["message"] = "Dry run: no changes were made"
+	return result, nil
+}
+
+// rename computes the diff of the rename and writes it when apply is set
+func (r *RenameSymbolTool) rename(ctx context.Context, params map[string]interface{}, apply bool) (map[string]interface{}, error) {
+	path := params["path"].(string)
+	symbol := params["symbol"].(string)
+	newName := params["new_name"].(string)
+	line := 0
+	switch v := params["line"].(type) {
+	case int:
+		line = v
+	case float64:
+		line = int(v)
+	}
+
+	absPath := path
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(r.workDir, path)
+	}
+	absPath, err := filepath.Abs(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if r.security != nil {
+		if err := r.security.ValidatePath(absPath); err != nil {
+			return nil, NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
+		}
+		if apply {
+			if err := r.security.ValidateOperation(OpWrite, absPath); err != nil {
+				return nil, NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
+			}
+		}
+	}
+
+	column, err := symbolColumn(absPath, line, symbol)
+	if err != nil {
+		return nil, NewToolError(ErrCodeInvalidParams, "%w", err)
+	}
+	position := fmt.Sprintf("%s:%d:%d", absPath, line, column)
+
+	diff, err := r.runGopls(ctx, "rename", "-d", position, newName)
+	if err != nil {
+		return nil, err
+	}
+	files := diffFiles(diff, r.workDir)
+	if len(files) == 0 {
+		return nil, NewToolError(ErrCodeExecution, "gopls found nothing to rename at %s:%d", path, line)
+	}
+
+	result := map[string]interface{}{
+		"symbol":        symbol,
+		"new_name":      newName,
+		"changed_files": files,
+		"diff":          diff,
+		"applied":       false,
+		"message":       fmt.Sprintf("Renaming %s to %s changes %d files; call rename_symbol again with apply: true to write them", symbol, newName, len(files)),
+	}
+	if !apply {
+		return result, nil
+	}
+
+	if r.record != nil {
+		for _, file := range files {
+			r.record(ctx, filepath.Join(r.workDir, file))
+		}
+	}
+	if _, err := r.runGopls(ctx, "rename", "-w", position, newName); err != nil {
+		return nil, err
+	}
+	result["applied"] = true
+	result["message"] = fmt.Sprintf("Renamed %s to %s in %d files", symbol, newName, len(files))
+	return result, nil
+}
+
+// runGopls runs gopls in the workspace and returns its output
+func (r *RenameSymbolTool) runGopls(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, r.gopls, args...)
+	cmd.Dir = r.workDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+			return "", NewToolError(ErrCodeUnavailable, "gopls is not installed (go install golang.org/x/tools/gopls@latest): %w", err)
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", NewToolError(ErrCodeExecution, "gopls rename failed: %s", message)
+	}
+	return stdout.String(), nil
+}
+
+// symbolColumn returns the 1-based byte column of symbol as a whole word on
+// line of the file
+func symbolColumn(path string, line int, symbol string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	content := strings.Split(string(data), "\n")
+	if line > len(content) {
+		return 0, fmt.Errorf("%s has only %d lines", filepath.Base(path), len(content))
+	}
+	re := regexp.MustCompile(`(^|[^\p{L}\p{Nd}_])` + regexp.QuoteMeta(symbol) + `($|[^\p{L}\p{Nd}_])`)
+	loc := re.FindStringSubmatchIndex(content[line-1])
+	if loc == nil {
+		return 0, fmt.Errorf("%s does not appear on line %d", symbol, line)
+	}
+	return loc[3] + 1, nil
+}
+
+// diffFiles returns the files of a unified diff from gopls, relative to root
+func diffFiles(diff, root string) []string {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		absRoot = root
+	}
+	var files []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(diff, "\n") {
+		name, ok := strings.CutPrefix(line, "+++ ")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, "\t")
+		name = strings.TrimSpace(name)
+		if filepath.IsAbs(name) {
+			if rel, err := filepath.Rel(absRoot, name); err == nil {
+				name = rel
+			}
+		}
+		name = filepath.ToSlash(name)
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	return files
+}
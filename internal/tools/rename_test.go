@@ -0,0 +1,94 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGopls prints a rename diff for "-d" and renames with sed for "-w"
+const fakeGopls = `#!/bin/sh
+case "$2" in
+-d)
+	echo "--- $PWD/store.go.orig"
+	echo "+++ $PWD/store.go"
+	echo "@@ -1 +1 @@"
+	echo "-func Put() {}"
+	echo "+func Add() {}"
+	echo "--- $PWD/main.go.orig"
+	echo "+++ $PWD/main.go"
+	;;
+-w)
+	sed -i.bak "s/Put/$4/" store.go main.go && rm -f *.bak
+	;;
+esac
+`
+
+func TestRenameSymbolTool(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte("package store\n\nfunc Put() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package store\n\nvar _ = Put\n"), 0644))
+	gopls := filepath.Join(t.TempDir(), "gopls")
+	require.NoError(t, os.WriteFile(gopls, []byte(fakeGopls), 0755))
+
+	var recorded []string
+	tool := NewRenameSymbolTool(nil, dir, WithRenameRecorder(func(ctx context.Context, path string) {
+		recorded = append(recorded, filepath.Base(path))
+	}))
+	tool.gopls = gopls
+
+	params := map[string]interface{}{"path": "store.go", "line": float64(3), "symbol": "Put", "new_name": "Add"}
+	require.NoError(t, tool.Validate(params))
+	result, err := tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, []string{"store.go", "main.go"}, info["changed_files"])
+	assert.Equal(t, false, info["applied"])
+	assert.Contains(t, info["diff"], "+func Add() {}")
+	content, _ := os.ReadFile(filepath.Join(dir, "store.go"))
+	assert.Contains(t, string(content), "func Put()", "previews change nothing")
+
+	params["apply"] = true
+	result, err = tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, true, result.(map[string]interface{})["applied"])
+	assert.Equal(t, []string{"store.go", "main.go"}, recorded)
+	content, _ = os.ReadFile(filepath.Join(dir, "main.go"))
+	assert.Equal(t, "package store\n\nvar _ = Add\n", string(content))
+}
+
+func TestRenameSymbolToolErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte("package store\n\nfunc Put() {}\n"), 0644))
+	tool := NewRenameSymbolTool(nil, dir)
+	tool.gopls = filepath.Join(dir, "missing-gopls")
+
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		err    string
+	}{
+		{"not go", map[string]interface{}{"path": "a.txt", "line": 1, "symbol": "A", "new_name": "B"}, "path must be a Go file"},
+		{"bad name", map[string]interface{}{"path": "a.go", "line": 1, "symbol": "A", "new_name": "b-c"}, "new_name is required and must be a Go identifier"},
+		{"no line", map[string]interface{}{"path": "a.go", "symbol": "A", "new_name": "B"}, "line is required and must be a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tool.Validate(tt.params), tt.err)
+		})
+	}
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"path": "store.go", "line": 3, "symbol": "Putter", "new_name": "Add"})
+	assert.EqualError(t, err, "Putter does not appear on line 3")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"path": "store.go", "line": 3, "symbol": "Put", "new_name": "Add"})
+	var toolErr *ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeUnavailable, toolErr.Code)
+}
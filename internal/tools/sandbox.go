@@ -0,0 +1,459 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// ViolationKind identifies which sandbox limit a tool run broke
+type ViolationKind string
+
+const (
+	ViolationPanic   ViolationKind = "panic"
+	ViolationTimeout ViolationKind = "timeout"
+	ViolationMemory  ViolationKind = "memory"
+	ViolationFiles   ViolationKind = "file_descriptors"
+	ViolationOutput  ViolationKind = "output_size"
+)
+
+// SandboxLimits defines the resource limits enforced around a tool run.
+// A zero value disables the corresponding limit.
+type SandboxLimits struct {
+	// Timeout bounds the run time of a tool. Tools run in-process, so this
+	// wall-clock deadline is used as the CPU time limit.
+	Timeout time.Duration
+
+	// MaxMemory is the allowed growth of the process heap in bytes while
+	// the tool runs. The heap is shared by the whole process, so the check
+	// only applies while no other sandboxed tool is running.
+	MaxMemory uint64
+
+	// MaxOpenFiles is the number of additional file descriptors the process
+	// may hold open while the tool runs. Like MaxMemory it is measured for
+	// the whole process and only applies while the tool runs alone. Only
+	// enforced on platforms that expose the fd table.
+	MaxOpenFiles int
+
+	// MaxOutputBytes truncates tool output beyond this size
+	MaxOutputBytes int
+}
+
+// DefaultSandboxLimits returns conservative limits suitable for the built-in tools
+func DefaultSandboxLimits() SandboxLimits {
+	return SandboxLimits{
+		Timeout:        2 * time.Minute,
+		MaxMemory:      512 * 1024 * 1024, // 512MB
+		MaxOpenFiles:   256,
+		MaxOutputBytes: 1024 * 1024, // 1MB
+	}
+}
+
+// NoTimeout exempts a tool from the sandbox timeout when returned as
+// SandboxLimits.Timeout by a LimitedTool
+const NoTimeout time.Duration = -1
+
+// ownTimeoutGrace is added to a tool's own timeout so that the tool, not the
+// sandbox, stops the run and reports it
+const ownTimeoutGrace = 30 * time.Second
+
+// maxAbandonedRuns is how many timed-out runs of a tool may still be
+// finishing before new calls to it are refused
+const maxAbandonedRuns = 2
+
+// LimitedTool is implemented by tools that need limits other than the
+// sandbox defaults, such as long-running builds. Non-zero fields of the
+// returned limits replace the defaults.
+type LimitedTool interface {
+	SandboxLimits() SandboxLimits
+}
+
+// ownTimeoutLimits returns the limits of a tool that enforces its own timeout
+func ownTimeoutLimits(timeout time.Duration) SandboxLimits {
+	return SandboxLimits{Timeout: timeout + ownTimeoutGrace}
+}
+
+// activeRuns counts the sandboxed tool runs in progress across the process.
+// Memory and file descriptor usage can only be attributed to a tool while
+// it is the only one running.
+var activeRuns atomic.Int32
+
+// SandboxError is the structured error returned when a tool run is aborted
+// or altered by the sandbox
+type SandboxError struct {
+	Tool    string
+	Kind    ViolationKind
+	Message string
+	Stack   string
+}
+
+// Error implements the error interface
+func (e *SandboxError) Error() string {
+	return fmt.Sprintf("tool '%s' %s: %s", e.Tool, e.Kind, e.Message)
+}
+
+// AuditRecorder receives sandbox violations for the audit log
+type AuditRecorder interface {
+	RecordViolation(tool string, kind ViolationKind, message string, details map[string]interface{})
+}
+
+// Sandbox supervises tool execution, enforcing resource limits and
+// converting panics into structured errors
+type Sandbox struct {
+	limits        SandboxLimits
+	audit         AuditRecorder
+	logger        Logger
+	pollInterval  time.Duration
+	openFileCount func() int
+
+	mu        sync.Mutex
+	abandoned map[string]int // Timed-out runs still executing, by tool
+}
+
+// NewSandbox creates a sandbox with the given limits.
+// audit and logger may be nil.
+func NewSandbox(limits SandboxLimits, audit AuditRecorder, logger Logger) *Sandbox {
+	return &Sandbox{
+		limits:        limits,
+		audit:         audit,
+		logger:        logger,
+		pollInterval:  50 * time.Millisecond,
+		openFileCount: countOpenFiles,
+		abandoned:     make(map[string]int),
+	}
+}
+
+// Limits returns the configured limits
+func (s *Sandbox) Limits() SandboxLimits {
+	return s.limits
+}
+
+// LimitsFor returns the limits applied to the given tool
+func (s *Sandbox) LimitsFor(tool Tool) SandboxLimits {
+	limits := s.limits
+	limited, ok := tool.(LimitedTool)
+	if !ok {
+		return limits
+	}
+
+	own := limited.SandboxLimits()
+	if own.Timeout != 0 {
+		limits.Timeout = max(own.Timeout, 0)
+	}
+	if own.MaxMemory != 0 {
+		limits.MaxMemory = own.MaxMemory
+	}
+	if own.MaxOpenFiles != 0 {
+		limits.MaxOpenFiles = max(own.MaxOpenFiles, 0)
+	}
+	if own.MaxOutputBytes != 0 {
+		limits.MaxOutputBytes = max(own.MaxOutputBytes, 0)
+	}
+	return limits
+}
+
+// Abandoned returns how many timed-out runs of the tool are still executing
+func (s *Sandbox) Abandoned(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.abandoned[name]
+}
+
+type sandboxResult struct {
+	value interface{}
+	err   error
+}
+
+// runState tracks whether a run finished or was abandoned by the sandbox
+type runState struct {
+	mu        sync.Mutex
+	finished  bool
+	abandoned bool
+}
+
+// Run executes the tool under supervision
+func (s *Sandbox) Run(ctx context.Context, tool Tool, params map[string]interface{}) (interface{}, error) {
+	name := tool.Name()
+	limits := s.LimitsFor(tool)
+
+	if n := s.Abandoned(name); n >= maxAbandonedRuns {
+		sbErr := &SandboxError{
+			Tool:    name,
+			Kind:    ViolationTimeout,
+			Message: fmt.Sprintf("%d earlier calls timed out and are still running", n),
+		}
+		s.report(sbErr, nil)
+		return nil, sbErr
+	}
+
+	// The tool's context is always cancelled when Run returns, so a run
+	// abandoned after a timeout is asked to stop
+	var cancel context.CancelFunc
+	if limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	activeRuns.Add(1)
+	baseHeap := heapInUse()
+	baseFiles := s.openFileCount()
+
+	state := &runState{}
+	done := make(chan sandboxResult, 1)
+	go func() {
+		defer activeRuns.Add(-1)
+		defer s.finish(name, state)
+		defer func() {
+			if r := recover(); r != nil {
+				done <- sandboxResult{err: &SandboxError{
+					Tool:    name,
+					Kind:    ViolationPanic,
+					Message: fmt.Sprintf("panic: %v", r),
+					Stack:   string(debug.Stack()),
+				}}
+			}
+		}()
+
+		value, err := tool.Execute(ctx, params)
+		done <- sandboxResult{value: value, err: err}
+	}()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case res := <-done:
+			if sbErr, ok := res.err.(*SandboxError); ok {
+				s.report(sbErr, map[string]interface{}{"stack": sbErr.Stack})
+				return nil, sbErr
+			}
+			if res.err != nil {
+				return nil, res.err
+			}
+			return s.limitOutput(name, limits.MaxOutputBytes, res.value), nil
+
+		case <-ctx.Done():
+			// The tool goroutine cannot be killed. Its context is cancelled
+			// on return and it is tracked until it finishes.
+			s.abandon(name, state)
+			sbErr := &SandboxError{
+				Tool:    name,
+				Kind:    ViolationTimeout,
+				Message: fmt.Sprintf("exceeded time limit of %s", limits.Timeout),
+			}
+			if ctx.Err() == context.Canceled {
+				sbErr.Message = "cancelled"
+				return nil, sbErr
+			}
+			s.report(sbErr, nil)
+			return nil, sbErr
+
+		case <-ticker.C:
+			if err := s.checkResources(name, limits, baseHeap, baseFiles); err != nil {
+				s.abandon(name, state)
+				s.report(err, nil)
+				return nil, err
+			}
+		}
+	}
+}
+
+// abandon marks a run the sandbox stopped waiting for
+func (s *Sandbox) abandon(name string, state *runState) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.finished {
+		return
+	}
+	state.abandoned = true
+
+	s.mu.Lock()
+	s.abandoned[name]++
+	s.mu.Unlock()
+}
+
+// finish records the end of a run, releasing it if it was abandoned
+func (s *Sandbox) finish(name string, state *runState) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.finished = true
+	if !state.abandoned {
+		return
+	}
+
+	s.mu.Lock()
+	s.abandoned[name]--
+	if s.abandoned[name] <= 0 {
+		delete(s.abandoned, name)
+	}
+	s.mu.Unlock()
+
+	if s.logger != nil {
+		s.logger.Info("Abandoned tool run finished", "tool", name)
+	}
+}
+
+// checkResources compares current process usage against the baseline taken
+// before the run. Usage is only attributed to the tool while it is the sole
+// sandboxed run in the process.
+func (s *Sandbox) checkResources(name string, limits SandboxLimits, baseHeap uint64, baseFiles int) *SandboxError {
+	if activeRuns.Load() > 1 {
+		return nil
+	}
+
+	if limits.MaxMemory > 0 {
+		if current := heapInUse(); current > baseHeap && current-baseHeap > limits.MaxMemory {
+			return &SandboxError{
+				Tool:    name,
+				Kind:    ViolationMemory,
+				Message: fmt.Sprintf("process heap grew by %d bytes while the tool ran (limit %d)", current-baseHeap, limits.MaxMemory),
+			}
+		}
+	}
+
+	if limits.MaxOpenFiles > 0 && baseFiles >= 0 {
+		if current := s.openFileCount(); current >= 0 && current-baseFiles > limits.MaxOpenFiles {
+			return &SandboxError{
+				Tool:    name,
+				Kind:    ViolationFiles,
+				Message: fmt.Sprintf("process opened %d file descriptors while the tool ran (limit %d)", current-baseFiles, limits.MaxOpenFiles),
+			}
+		}
+	}
+
+	return nil
+}
+
+// limitOutput truncates oversized output and reports the violation.
+// Structured results keep their shape: their long strings are shortened
+// until the JSON encoding fits the limit.
+func (s *Sandbox) limitOutput(name string, limit int, value interface{}) interface{} {
+	if limit <= 0 || value == nil {
+		return value
+	}
+
+	var size int
+	var limited interface{}
+	switch v := value.(type) {
+	case string:
+		size = len(v)
+		if size > limit {
+			limited = truncateUTF8(v, limit) + fmt.Sprintf("\n... [output truncated: %d of %d bytes shown]", limit, size)
+		}
+	case []byte:
+		size = len(v)
+		if size > limit {
+			limited = truncateUTF8(string(v), limit) + fmt.Sprintf("\n... [output truncated: %d of %d bytes shown]", limit, size)
+		}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil || len(data) <= limit {
+			return value
+		}
+		size = len(data)
+		limited = shrinkStructured(v, limit)
+	}
+
+	if limited == nil {
+		return value
+	}
+
+	s.report(&SandboxError{
+		Tool:    name,
+		Kind:    ViolationOutput,
+		Message: fmt.Sprintf("output of %d bytes truncated to %d", size, limit),
+	}, nil)
+	return limited
+}
+
+// shrinkStructured shortens the strings inside a map or slice result,
+// halving the allowed string length until the JSON encoding fits the limit
+func shrinkStructured(value interface{}, limit int) interface{} {
+	const minStringLen = 64
+
+	maxLen := limit
+	for {
+		shrunk := truncateStrings(value, maxLen)
+		data, err := json.Marshal(shrunk)
+		if err != nil || len(data) <= limit || maxLen <= minStringLen {
+			return shrunk
+		}
+		maxLen /= 2
+	}
+}
+
+// truncateStrings returns a copy of value with every string longer than
+// maxLen bytes truncated. Maps and slices are copied; other values are
+// returned unchanged.
+func truncateStrings(value interface{}, maxLen int) interface{} {
+	switch v := value.(type) {
+	case string:
+		if len(v) <= maxLen {
+			return v
+		}
+		return truncateUTF8(v, maxLen) + fmt.Sprintf("\n... [truncated: %d of %d bytes shown]", maxLen, len(v))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = truncateStrings(item, maxLen)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = truncateStrings(item, maxLen)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			out[i] = truncateStrings(item, maxLen).(map[string]interface{})
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = truncateStrings(item, maxLen).(string)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// report forwards a violation to the logger and the audit log
+func (s *Sandbox) report(err *SandboxError, details map[string]interface{}) {
+	if s.logger != nil {
+		s.logger.Warn("Tool sandbox violation", "tool", err.Tool, "kind", err.Kind, "message", err.Message)
+	}
+	if s.audit != nil {
+		s.audit.RecordViolation(err.Tool, err.Kind, err.Message, details)
+	}
+}
+
+// heapInUse returns the bytes of heap currently in use
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
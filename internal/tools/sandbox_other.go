@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package tools
+
+// countOpenFiles is not supported on this platform; -1 disables the limit
+func countOpenFiles() int {
+	return -1
+}
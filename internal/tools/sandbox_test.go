@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcTool is a minimal Tool backed by a function for sandbox tests
+type funcTool struct {
+	name string
+	fn   func(ctx context.Context) (interface{}, error)
+}
+
+func (f *funcTool) Name() string                                 { return f.name }
+func (f *funcTool) Description() string                          { return "test tool" }
+func (f *funcTool) Schema() ToolSchema                           { return ToolSchema{Type: "object"} }
+func (f *funcTool) Validate(params map[string]interface{}) error { return nil }
+func (f *funcTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return f.fn(ctx)
+}
+
+// recordingAudit collects violations reported by the sandbox
+type recordingAudit struct {
+	mu    sync.Mutex
+	kinds []ViolationKind
+}
+
+func (r *recordingAudit) RecordViolation(tool string, kind ViolationKind, message string, details map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kinds = append(r.kinds, kind)
+}
+
+func TestSandboxRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    SandboxLimits
+		fn        func(ctx context.Context) (interface{}, error)
+		wantKind  ViolationKind
+		wantValue interface{}
+	}{
+		{
+			name:      "successful run",
+			limits:    DefaultSandboxLimits(),
+			fn:        func(ctx context.Context) (interface{}, error) { return "ok", nil },
+			wantValue: "ok",
+		},
+		{
+			name:     "panic becomes structured error",
+			limits:   DefaultSandboxLimits(),
+			fn:       func(ctx context.Context) (interface{}, error) { panic("boom") },
+			wantKind: ViolationPanic,
+		},
+		{
+			name:   "timeout",
+			limits: SandboxLimits{Timeout: 20 * time.Millisecond},
+			fn: func(ctx context.Context) (interface{}, error) {
+				time.Sleep(time.Second)
+				return "late", nil
+			},
+			wantKind: ViolationTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &recordingAudit{}
+			sandbox := NewSandbox(tt.limits, audit, nil)
+
+			value, err := sandbox.Run(context.Background(), &funcTool{name: "test", fn: tt.fn}, nil)
+
+			if tt.wantKind == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantValue, value)
+				assert.Empty(t, audit.kinds)
+				return
+			}
+
+			var sbErr *SandboxError
+			require.True(t, errors.As(err, &sbErr))
+			assert.Equal(t, tt.wantKind, sbErr.Kind)
+			assert.Equal(t, []ViolationKind{tt.wantKind}, audit.kinds)
+		})
+	}
+}
+
+func TestSandboxTruncatesOutput(t *testing.T) {
+	audit := &recordingAudit{}
+	sandbox := NewSandbox(SandboxLimits{MaxOutputBytes: 10}, audit, nil)
+
+	value, err := sandbox.Run(context.Background(), &funcTool{
+		name: "big",
+		fn: func(ctx context.Context) (interface{}, error) {
+			return strings.Repeat("x", 100), nil
+		},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value.(string), strings.Repeat("x", 10)+"\n... [output truncated"))
+	assert.Equal(t, []ViolationKind{ViolationOutput}, audit.kinds)
+}
+
+func TestManagerExecuteUsesSandbox(t *testing.T) {
+	manager := NewManager(nil, nil)
+	require.NoError(t, manager.Register(&funcTool{
+		name: "panicky",
+		fn:   func(ctx context.Context) (interface{}, error) { panic("boom") },
+	}))
+	manager.SetSandbox(NewSandbox(DefaultSandboxLimits(), nil, nil))
+
+	_, err := manager.Execute(context.Background(), "panicky", nil)
+
+	var sbErr *SandboxError
+	require.True(t, errors.As(err, &sbErr))
+	assert.Equal(t, ViolationPanic, sbErr.Kind)
+}
+
+// limitedTool is a funcTool that declares its own sandbox limits
+type limitedTool struct {
+	funcTool
+	limits SandboxLimits
+}
+
+func (l *limitedTool) SandboxLimits() SandboxLimits { return l.limits }
+
+func TestSandboxToolLimits(t *testing.T) {
+	slow := func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	sandbox := NewSandbox(SandboxLimits{Timeout: 20 * time.Millisecond}, nil, nil)
+
+	tests := []struct {
+		name   string
+		limits SandboxLimits
+	}{
+		{name: "own timeout", limits: SandboxLimits{Timeout: time.Second}},
+		{name: "exempt", limits: SandboxLimits{Timeout: NoTimeout}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &limitedTool{funcTool: funcTool{name: "slow", fn: slow}, limits: tt.limits}
+
+			value, err := sandbox.Run(context.Background(), tool, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, "done", value)
+		})
+	}
+
+	assert.Equal(t, time.Duration(0), sandbox.LimitsFor(&limitedTool{limits: SandboxLimits{Timeout: NoTimeout}}).Timeout)
+	assert.Equal(t, 20*time.Millisecond, sandbox.LimitsFor(&funcTool{}).Timeout)
+}
+
+func TestSandboxTracksAbandonedRuns(t *testing.T) {
+	release := make(chan struct{})
+	stopped := make(chan struct{}, maxAbandonedRuns)
+	tool := &funcTool{
+		name: "stuck",
+		fn: func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			stopped <- struct{}{}
+			<-release
+			return nil, nil
+		},
+	}
+	sandbox := NewSandbox(SandboxLimits{Timeout: 10 * time.Millisecond}, nil, nil)
+
+	for i := 0; i < maxAbandonedRuns; i++ {
+		_, err := sandbox.Run(context.Background(), tool, nil)
+		require.Error(t, err)
+		<-stopped // The abandoned run saw its context cancelled
+	}
+	assert.Equal(t, maxAbandonedRuns, sandbox.Abandoned("stuck"))
+
+	_, err := sandbox.Run(context.Background(), tool, nil)
+	var sbErr *SandboxError
+	require.True(t, errors.As(err, &sbErr))
+	assert.Contains(t, sbErr.Message, "still running")
+
+	close(release)
+	assert.Eventually(t, func() bool { return sandbox.Abandoned("stuck") == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestSandboxSkipsResourceChecksForConcurrentRuns(t *testing.T) {
+	sandbox := NewSandbox(SandboxLimits{MaxOpenFiles: 1}, nil, nil)
+	files := 0
+	sandbox.openFileCount = func() int {
+		files += 10
+		return files
+	}
+
+	activeRuns.Add(1)
+	defer activeRuns.Add(-1)
+
+	value, err := sandbox.Run(context.Background(), &funcTool{
+		name: "busy",
+		fn: func(ctx context.Context) (interface{}, error) {
+			time.Sleep(3 * sandbox.pollInterval)
+			return "ok", nil
+		},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ok", value)
+}
+
+func TestSandboxTruncatesOnRuneBoundary(t *testing.T) {
+	sandbox := NewSandbox(SandboxLimits{MaxOutputBytes: 10}, nil, nil)
+
+	value, err := sandbox.Run(context.Background(), &funcTool{
+		name: "runes",
+		fn: func(ctx context.Context) (interface{}, error) {
+			return strings.Repeat("あ", 20), nil
+		},
+	}, nil)
+
+	require.NoError(t, err)
+	text := value.(string)
+	assert.True(t, utf8.ValidString(text))
+	assert.True(t, strings.HasPrefix(text, "あああ\n"))
+}
+
+func TestSandboxKeepsStructuredOutput(t *testing.T) {
+	audit := &recordingAudit{}
+	sandbox := NewSandbox(SandboxLimits{MaxOutputBytes: 512}, audit, nil)
+
+	value, err := sandbox.Run(context.Background(), &funcTool{
+		name: RunCommandToolName,
+		fn: func(ctx context.Context) (interface{}, error) {
+			return map[string]interface{}{
+				"command":   "make",
+				"exit_code": 2,
+				"output":    strings.Repeat("é", 2000),
+			}, nil
+		},
+	}, nil)
+
+	require.NoError(t, err)
+	info, ok := value.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "make", info["command"])
+	assert.Equal(t, 2, info["exit_code"])
+	assert.True(t, utf8.ValidString(info["output"].(string)))
+	assert.Contains(t, info["output"], "[truncated")
+	data, err := json.Marshal(info)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(data), 512)
+	assert.Equal(t, []ViolationKind{ViolationOutput}, audit.kinds)
+}
+
+// assertOwnTimeoutApplies checks that a tool's own timeout fires before the
+// default sandbox timeout
+func assertOwnTimeoutApplies(t *testing.T, tool Tool, own time.Duration) {
+	t.Helper()
+	sandbox := NewSandbox(DefaultSandboxLimits(), nil, nil)
+	require.Less(t, DefaultSandboxLimits().Timeout, own)
+	assert.Greater(t, sandbox.LimitsFor(tool).Timeout, own, "the sandbox would stop %s before its own timeout", tool.Name())
+}
@@ -184,10 +184,10 @@ func (s *SearchFilesTool) Execute(ctx context.Context, params map[string]interfa
 	// Security check
 	if s.security != nil {
 		if err := s.security.ValidatePath(absPath); err != nil {
-			return nil, fmt.Errorf("security validation failed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "security validation failed: %w", err)
 		}
 		if err := s.security.ValidateOperation(OpRead, absPath); err != nil {
-			return nil, fmt.Errorf("operation not allowed: %w", err)
+			return nil, NewToolError(ErrCodePermissionDenied, "operation not allowed: %w", err)
 		}
 	}
 
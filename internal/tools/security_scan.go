@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/vulnscan"
+)
+
+// SecurityScanToolName is the name of the security scanning tool
+const SecurityScanToolName = "security_scan"
+
+// securityScanTimeout bounds a scan, which may download vulnerability data
+const securityScanTimeout = 10 * time.Minute
+
+// SecurityScanTool runs gosec on the Go source and osv-scanner on the
+// dependencies of the workspace and returns their findings
+type SecurityScanTool struct {
+	workDir string
+}
+
+// NewSecurityScanTool creates a new SecurityScanTool scanning workDir
+func NewSecurityScanTool(workDir string) *SecurityScanTool {
+	return &SecurityScanTool{workDir: workDir}
+}
+
+func (s *SecurityScanTool) Name() string {
+	return SecurityScanToolName
+}
+
+func (s *SecurityScanTool) Description() string {
+	return "Scan the workspace for security problems: gosec for Go source code (file and line of each issue) and osv-scanner for dependencies with known vulnerabilities (package, version and fixed version). Findings come sorted by severity. Use it to find vulnerabilities, then fix them and scan again to confirm."
+}
+
+func (s *SecurityScanTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"scanner": {
+				Type:        "string",
+				Description: "Scanner to run",
+				Enum:        []string{"all", "gosec", "osv-scanner"},
+				Default:     "all",
+			},
+			"min_severity": {
+				Type:        "string",
+				Description: "Only report findings at least this severe",
+				Enum:        []string{vulnscan.SeverityLow, vulnscan.SeverityMedium, vulnscan.SeverityHigh, vulnscan.SeverityCritical},
+			},
+		},
+	}
+}
+
+// SandboxLimits lets securityScanTimeout, not the sandbox, stop a long scan
+func (s *SecurityScanTool) SandboxLimits() SandboxLimits {
+	return ownTimeoutLimits(securityScanTimeout)
+}
+
+func (s *SecurityScanTool) Validate(params map[string]interface{}) error {
+	if value, exists := params["scanner"]; exists {
+		name, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("scanner must be a string")
+		}
+		if _, found := vulnscan.Find(name); !found && name != "all" && name != "" {
+			return fmt.Errorf("unknown scanner %q (use all, gosec or osv-scanner)", name)
+		}
+	}
+	if value, exists := params["min_severity"]; exists {
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("min_severity must be a string")
+		}
+	}
+	return nil
+}
+
+func (s *SecurityScanTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	scanners := vulnscan.Scanners()
+	if name, _ := params["scanner"].(string); name != "" && name != "all" {
+		scanner, _ := vulnscan.Find(name)
+		scanners = []*vulnscan.Scanner{scanner}
+	}
+	minSeverity, _ := params["min_severity"].(string)
+
+	ctx, cancel := context.WithTimeout(ctx, securityScanTimeout)
+	defer cancel()
+	report, err := vulnscan.Scan(ctx, s.workDir, scanners)
+	if errors.Is(err, vulnscan.ErrUnavailable) {
+		return nil, NewToolError(ErrCodeUnavailable, "%w", err)
+	}
+	if err != nil {
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+	report.Filter(minSeverity)
+
+	result := map[string]interface{}{
+		"scanned":  report.Scanned,
+		"findings": report.Findings,
+		"count":    len(report.Findings),
+		"counts":   report.Counts(),
+		"summary":  report.CountsText(),
+	}
+	if len(report.Skipped) > 0 {
+		result["skipped"] = report.Skipped
+	}
+	if len(report.Findings) == 0 {
+		result["message"] = fmt.Sprintf("%s found nothing", strings.Join(report.Scanned, " and "))
+	}
+	return result, nil
+}
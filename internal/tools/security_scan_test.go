@@ -0,0 +1,55 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/vulnscan"
+)
+
+func TestSecurityScanTool(t *testing.T) {
+	dir := t.TempDir()
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	tool := NewSecurityScanTool(dir)
+
+	for _, params := range []map[string]interface{}{{}, {"scanner": "osv-scanner", "min_severity": "HIGH"}} {
+		assert.NoError(t, tool.Validate(params))
+	}
+	assert.EqualError(t, tool.Validate(map[string]interface{}{"scanner": "trivy"}), `unknown scanner "trivy" (use all, gosec or osv-scanner)`)
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{})
+	var toolErr *ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, ErrCodeUnavailable, toolErr.Code)
+
+	issues := `{"Issues": [
+		{"severity": "HIGH", "rule_id": "G101", "details": "Potential hardcoded credentials", "file": "` + filepath.Join(dir, "config.go") + `", "line": "12"},
+		{"severity": "LOW", "rule_id": "G104", "details": "Errors unhandled", "file": "` + filepath.Join(dir, "main.go") + `", "line": "7"}
+	]}`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "output.json"), []byte(issues), 0644))
+	script := "#!/bin/sh\n/bin/cat " + filepath.Join(bin, "output.json") + "\nexit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "gosec"), []byte(script), 0755))
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"min_severity": "MEDIUM"})
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, []string{"gosec"}, info["scanned"])
+	assert.Contains(t, info["skipped"], "osv-scanner")
+	findings := info["findings"].([]vulnscan.Finding)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "G101", findings[0].ID)
+	assert.Equal(t, "config.go:12", findings[0].Location())
+	assert.Equal(t, "1 HIGH", info["summary"])
+}
+
+func TestSecurityScanToolSandboxLimits(t *testing.T) {
+	assertOwnTimeoutApplies(t, NewSecurityScanTool(t.TempDir()), securityScanTimeout)
+}
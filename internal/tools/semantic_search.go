@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/common-creation/coda/internal/embeddings"
+)
+
+// SemanticSearchToolName is the name of the embedding search tool
+const SemanticSearchToolName = "semantic_search"
+
+// defaultSemanticResults is the number of snippets returned by default
+const defaultSemanticResults = 5
+
+// SemanticSearchTool finds the workspace code most related to a natural
+// language query, complementing search_files for conceptual questions
+type SemanticSearchTool struct {
+	index *embeddings.Index
+}
+
+// NewSemanticSearchTool creates a new SemanticSearchTool searching index
+func NewSemanticSearchTool(index *embeddings.Index) *SemanticSearchTool {
+	return &SemanticSearchTool{index: index}
+}
+
+func (s *SemanticSearchTool) Name() string {
+	return SemanticSearchToolName
+}
+
+func (s *SemanticSearchTool) Description() string {
+	return "Find the code most related to a description (e.g. \"where are retries configured\") by meaning rather than exact text. Returns snippets with file and line numbers; use search_files for exact names."
+}
+
+func (s *SemanticSearchTool) Schema() ToolSchema {
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"query": {
+				Type:        "string",
+				Description: "What the code does or is about, in natural language",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Only search files under this directory, relative to the workspace",
+			},
+			"max_results": {
+				Type:        "number",
+				Description: "Maximum number of snippets to return",
+				Default:     defaultSemanticResults,
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (s *SemanticSearchTool) Validate(params map[string]interface{}) error {
+	query, ok := params["query"].(string)
+	if !ok || query == "" {
+		return fmt.Errorf("query is required and must be a non-empty string")
+	}
+	if path, exists := params["path"]; exists {
+		if _, ok := path.(string); !ok {
+			return fmt.Errorf("path must be a string")
+		}
+	}
+	if maxResults, exists := params["max_results"]; exists {
+		switch v := maxResults.(type) {
+		case int:
+			if v < 1 {
+				return fmt.Errorf("max_results must be at least 1")
+			}
+		case float64:
+			if v < 1 {
+				return fmt.Errorf("max_results must be at least 1")
+			}
+		default:
+			return fmt.Errorf("max_results must be a number")
+		}
+	}
+	return nil
+}
+
+func (s *SemanticSearchTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	query := params["query"].(string)
+	path, _ := params["path"].(string)
+	limit := defaultSemanticResults
+	switch v := params["max_results"].(type) {
+	case int:
+		limit = v
+	case float64:
+		limit = int(v)
+	}
+
+	// Files changed since the last search are re-embedded first
+	if err := s.index.Update(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update the search index: %w", err)
+	}
+	found, err := s.index.Search(ctx, query, path, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, len(found))
+	for i, r := range found {
+		results[i] = map[string]interface{}{
+			"file":       r.Path,
+			"start_line": r.StartLine,
+			"end_line":   r.EndLine,
+			"score":      fmt.Sprintf("%.3f", r.Score),
+			"snippet":    r.Text,
+		}
+	}
+	return map[string]interface{}{
+		"query":   query,
+		"results": results,
+		"count":   len(results),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/embeddings"
+)
+
+func TestSemanticSearchTool(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "retry.go"), []byte("package main\n\nfunc retryRequest() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "render.go"), []byte("package main\n\nfunc renderView() {}\n"), 0644))
+
+	tool := NewSemanticSearchTool(embeddings.NewIndex(root, embeddings.NewLocalEmbedder()))
+	assert.Error(t, tool.Validate(map[string]interface{}{}))
+	assert.Error(t, tool.Validate(map[string]interface{}{"query": "x", "max_results": 0.0}))
+
+	params := map[string]interface{}{"query": "retry the request", "max_results": 1.0}
+	require.NoError(t, tool.Validate(params))
+	result, err := tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+
+	info := result.(map[string]interface{})
+	assert.Equal(t, 1, info["count"])
+	results := info["results"].([]map[string]interface{})
+	assert.Equal(t, "retry.go", results[0]["file"])
+	assert.Equal(t, 1, results[0]["start_line"])
+	assert.Contains(t, results[0]["snippet"], "func retryRequest")
+}
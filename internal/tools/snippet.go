@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/common-creation/coda/internal/snippet"
+)
+
+// EvalSnippetToolName is the name of the snippet evaluation tool
+const EvalSnippetToolName = "eval_snippet"
+
+// EvalSnippetTool runs short Python or Go snippets in throwaway, isolated
+// environments
+type EvalSnippetTool struct {
+	runner *snippet.Runner
+}
+
+// NewEvalSnippetTool creates a new EvalSnippetTool for the languages the
+// runner can run. Whether they run isolated is checked on first execution,
+// not here or when listing the tool, since the check runs the interpreters.
+func NewEvalSnippetTool(runner *snippet.Runner) *EvalSnippetTool {
+	return &EvalSnippetTool{runner: runner}
+}
+
+func (e *EvalSnippetTool) Name() string {
+	return EvalSnippetToolName
+}
+
+func (e *EvalSnippetTool) Description() string {
+	return "Run a short Python or Go snippet to check a computation, a regular expression or an example, and get its output and errors. Snippets run in an empty temporary directory with only the standard library, no network access and a private HOME; the rest of the file system is read-only but can still be read. Like a notebook cell, the value of a final Python expression is printed; Go snippets may be whole programs or statements, after imports, that become the body of main. Not for running the project's code: use run_command for that."
+}
+
+func (e *EvalSnippetTool) Schema() ToolSchema {
+	available := e.runner.InstalledLanguages()
+	languages := make([]string, len(available))
+	for i, lang := range available {
+		languages[i] = string(lang)
+	}
+	return ToolSchema{
+		Type: "object",
+		Properties: map[string]Property{
+			"language": {
+				Type:        "string",
+				Description: "Language of the snippet",
+				Enum:        languages,
+			},
+			"code": {
+				Type:        "string",
+				Description: "Source code of the snippet",
+			},
+			"timeout_seconds": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Run time limit in seconds (default: %d, at most %d)", int(snippet.DefaultTimeout.Seconds()), int(snippet.MaxTimeout.Seconds())),
+			},
+		},
+		Required: []string{"language", "code"},
+	}
+}
+
+func (e *EvalSnippetTool) Validate(params map[string]interface{}) error {
+	available := e.runner.InstalledLanguages()
+	if len(available) == 0 {
+		return fmt.Errorf("neither python3 nor go is installed")
+	}
+	language, ok := params["language"].(string)
+	if !ok || !slices.Contains(available, snippet.Language(language)) {
+		return fmt.Errorf("language must be one of %v", available)
+	}
+	if code, ok := params["code"].(string); !ok || code == "" {
+		return fmt.Errorf("code is required and must be a string")
+	}
+	if value, exists := params["timeout_seconds"]; exists {
+		seconds, ok := value.(float64)
+		if !ok || seconds <= 0 {
+			return fmt.Errorf("timeout_seconds must be a positive number")
+		}
+	}
+	return nil
+}
+
+func (e *EvalSnippetTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	language := snippet.Language(params["language"].(string))
+	code := params["code"].(string)
+	timeout := snippet.DefaultTimeout
+	if seconds, ok := params["timeout_seconds"].(float64); ok {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if !e.runner.Available(language) {
+		return nil, NewToolError(ErrCodeUnavailable, "%s snippets cannot run isolated on this machine", language)
+	}
+
+	result, err := e.runner.Run(ctx, language, code, timeout)
+	if err != nil {
+		if errors.Is(err, snippet.ErrIsolationUnavailable) {
+			return nil, NewToolError(ErrCodeUnavailable, "%w", err)
+		}
+		return nil, NewToolError(ErrCodeExecution, "%w", err)
+	}
+
+	info := map[string]interface{}{
+		"language":    string(language),
+		"stage":       result.Stage,
+		"stdout":      result.Stdout,
+		"stderr":      result.Stderr,
+		"exit_code":   result.ExitCode,
+		"duration_ms": result.Duration.Milliseconds(),
+	}
+	switch {
+	case result.TimedOut && result.Stage == "build":
+		info["message"] = "The snippet did not compile in time"
+	case result.TimedOut:
+		info["message"] = fmt.Sprintf("The snippet was stopped after %s", min(timeout, snippet.MaxTimeout))
+	case result.Stage == "build":
+		info["message"] = "The snippet did not compile"
+	}
+	if result.Truncated {
+		info["truncated"] = true
+	}
+	return info, nil
+}
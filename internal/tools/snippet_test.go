@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/snippet"
+)
+
+func TestEvalSnippetTool(t *testing.T) {
+	runner := snippet.NewRunner(snippet.WithCommand(snippet.Go, "go-not-installed"))
+	if !runner.Available(snippet.Python) {
+		t.Skip("python3 is not installed or cannot run isolated")
+	}
+	tool := NewEvalSnippetTool(runner)
+	assert.Equal(t, []string{"python"}, tool.Schema().Properties["language"].Enum)
+
+	assert.Error(t, tool.Validate(map[string]interface{}{"language": "go", "code": "x := 1"}))
+	assert.Error(t, tool.Validate(map[string]interface{}{"language": "python"}))
+	assert.Error(t, tool.Validate(map[string]interface{}{"language": "python", "code": "1", "timeout_seconds": -1.0}))
+
+	params := map[string]interface{}{"language": "python", "code": "import re\nre.findall(r'\\d+', 'a1b22')", "timeout_seconds": 5.0}
+	require.NoError(t, tool.Validate(params))
+	result, err := tool.Execute(context.Background(), params)
+	require.NoError(t, err)
+	info := result.(map[string]interface{})
+	assert.Equal(t, "['1', '22']\n", info["stdout"])
+	assert.Equal(t, 0, info["exit_code"])
+	assert.NotContains(t, info, "message")
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"language": "python", "code": "while True: pass", "timeout_seconds": 0.2})
+	require.NoError(t, err)
+	assert.Equal(t, "The snippet was stopped after 200ms", result.(map[string]interface{})["message"])
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AllSessions selects the tool usage of every session
+const AllSessions = "*"
+
+// maxLatencySamples bounds the latencies kept per tool and session; the
+// percentiles describe the most recent calls
+const maxLatencySamples = 1000
+
+// ToolUsage summarizes the calls of one tool
+type ToolUsage struct {
+	Name      string
+	Calls     int
+	Failures  int
+	TotalTime time.Duration
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+}
+
+// FailureRate is the share of calls that failed, from 0 to 1
+func (u ToolUsage) FailureRate() float64 {
+	if u.Calls == 0 {
+		return 0
+	}
+	return float64(u.Failures) / float64(u.Calls)
+}
+
+type toolCounters struct {
+	calls     int
+	failures  int
+	totalTime time.Duration
+	latencies []time.Duration // Ring of the most recent latencies
+	next      int
+}
+
+// UsageStats counts tool calls, failures and latencies per session
+type UsageStats struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]*toolCounters
+}
+
+// NewUsageStats creates an empty usage store
+func NewUsageStats() *UsageStats {
+	return &UsageStats{sessions: make(map[string]map[string]*toolCounters)}
+}
+
+// Record adds a call of tool that took elapsed in session
+func (s *UsageStats) Record(session, tool string, elapsed time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(session, tool, elapsed, failed)
+	if session != AllSessions {
+		s.record(AllSessions, tool, elapsed, failed)
+	}
+}
+
+func (s *UsageStats) record(session, tool string, elapsed time.Duration, failed bool) {
+	tools, ok := s.sessions[session]
+	if !ok {
+		tools = make(map[string]*toolCounters)
+		s.sessions[session] = tools
+	}
+	c, ok := tools[tool]
+	if !ok {
+		c = &toolCounters{}
+		tools[tool] = c
+	}
+
+	c.calls++
+	if failed {
+		c.failures++
+	}
+	c.totalTime += elapsed
+	if len(c.latencies) < maxLatencySamples {
+		c.latencies = append(c.latencies, elapsed)
+	} else {
+		c.latencies[c.next] = elapsed
+		c.next = (c.next + 1) % maxLatencySamples
+	}
+}
+
+// Usage returns the usage of every tool called in session (AllSessions for
+// all of them), the most time-consuming first
+func (s *UsageStats) Usage(session string) []ToolUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make([]ToolUsage, 0, len(s.sessions[session]))
+	for name, c := range s.sessions[session] {
+		sorted := append([]time.Duration(nil), c.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		usage = append(usage, ToolUsage{
+			Name:      name,
+			Calls:     c.calls,
+			Failures:  c.failures,
+			TotalTime: c.totalTime,
+			P50:       percentile(sorted, 50),
+			P90:       percentile(sorted, 90),
+			P99:       percentile(sorted, 99),
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].TotalTime != usage[j].TotalTime {
+			return usage[i].TotalTime > usage[j].TotalTime
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageStats(t *testing.T) {
+	stats := NewUsageStats()
+	for i := 1; i <= 10; i++ {
+		stats.Record("s1", "read_file", time.Duration(i)*time.Millisecond, i == 10)
+	}
+	stats.Record("s2", "run_command", time.Second, false)
+
+	usage := stats.Usage("s1")
+	require.Len(t, usage, 1)
+	assert.Equal(t, "read_file", usage[0].Name)
+	assert.Equal(t, 10, usage[0].Calls)
+	assert.Equal(t, 1, usage[0].Failures)
+	assert.InDelta(t, 0.1, usage[0].FailureRate(), 1e-9)
+	assert.Equal(t, 55*time.Millisecond, usage[0].TotalTime)
+	assert.Equal(t, 5*time.Millisecond, usage[0].P50)
+	assert.Equal(t, 9*time.Millisecond, usage[0].P90)
+	assert.Equal(t, 10*time.Millisecond, usage[0].P99)
+
+	all := stats.Usage(AllSessions)
+	require.Len(t, all, 2)
+	assert.Equal(t, "run_command", all[0].Name, "most time-consuming first")
+	assert.Empty(t, stats.Usage("unknown"))
+}
+
+func TestUsageStatsKeepsRecentLatencies(t *testing.T) {
+	stats := NewUsageStats()
+	for i := 0; i < maxLatencySamples; i++ {
+		stats.Record("s", "list_files", time.Hour, false)
+	}
+	for i := 0; i < maxLatencySamples; i++ {
+		stats.Record("s", "list_files", time.Millisecond, false)
+	}
+
+	usage := stats.Usage("s")
+	require.Len(t, usage, 1)
+	assert.Equal(t, 2*maxLatencySamples, usage[0].Calls)
+	assert.Equal(t, time.Millisecond, usage[0].P99)
+}
+
+func TestManagerRecordsUsage(t *testing.T) {
+	m := NewManager(nil, nil)
+	require.NoError(t, m.Register(NewReadFileTool(nil)))
+
+	ctx := WithSessionID(context.Background(), "session-1")
+	_, err := m.Execute(ctx, "read_file", map[string]interface{}{})
+	assert.Error(t, err)
+	_, err = m.Execute(ctx, "missing_tool", nil)
+	assert.Error(t, err)
+
+	usage := m.Stats().Usage("session-1")
+	require.Len(t, usage, 1)
+	assert.Equal(t, "read_file", usage[0].Name)
+	assert.Equal(t, 1, usage[0].Calls)
+	assert.Equal(t, 1, usage[0].Failures)
+}
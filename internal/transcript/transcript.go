@@ -0,0 +1,115 @@
+// Package transcript mirrors a conversation to a file or stdout as it
+// happens, as plain text or JSON lines
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the encoding of transcript entries
+type Format string
+
+const (
+	// FormatText writes "[time] role: content" blocks
+	FormatText Format = "text"
+
+	// FormatJSONL writes one JSON object per entry
+	FormatJSONL Format = "jsonl"
+)
+
+// StdoutPath selects stdout as the transcript target
+const StdoutPath = "-"
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatText, "":
+		return FormatText, nil
+	case FormatJSONL:
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unknown transcript format %q (use text or jsonl)", name)
+	}
+}
+
+// Entry is one conversation message
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Tab     int       `json:"tab,omitempty"`
+	Role    string    `json:"role"`
+	Content string    `json:"content"`
+	Tokens  int       `json:"tokens,omitempty"`
+}
+
+// Writer appends entries to an output. It is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	out    io.Writer
+	closer io.Closer
+	format Format
+}
+
+// New creates a writer on out
+func New(out io.Writer, format Format) *Writer {
+	return &Writer{out: out, format: format}
+}
+
+// Open creates a writer appending to the file at path, or to stdout when
+// path is StdoutPath
+func Open(path string, format Format) (*Writer, error) {
+	if path == StdoutPath {
+		return New(os.Stdout, format), nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	w := New(file, format)
+	w.closer = file
+	return w, nil
+}
+
+// Write appends an entry
+func (w *Writer) Write(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	var data []byte
+	switch w.format {
+	case FormatJSONL:
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode transcript entry: %w", err)
+		}
+		data = append(encoded, '\n')
+	default:
+		prefix := ""
+		if entry.Tab > 0 {
+			prefix = fmt.Sprintf("[tab %d] ", entry.Tab)
+		}
+		data = []byte(fmt.Sprintf("%s[%s] %s: %s\n\n", prefix, entry.Time.Format("2006-01-02 15:04:05"), entry.Role, entry.Content))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(data); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// Close closes the transcript file. Stdout is left open.
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
@@ -0,0 +1,72 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{name: "", want: FormatText},
+		{name: "text", want: FormatText},
+		{name: "JSONL", want: FormatJSONL},
+		{name: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriterFormats(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	var text bytes.Buffer
+	require.NoError(t, New(&text, FormatText).Write(Entry{Time: at, Tab: 2, Role: "user", Content: "hello"}))
+	assert.Equal(t, "[tab 2] [2025-03-01 12:30:00] user: hello\n\n", text.String())
+
+	var jsonl bytes.Buffer
+	w := New(&jsonl, FormatJSONL)
+	require.NoError(t, w.Write(Entry{Time: at, Role: "assistant", Content: "hi", Tokens: 3}))
+	require.NoError(t, w.Write(Entry{Time: at, Role: "tool", Content: "done"}))
+
+	lines := bytes.Split(bytes.TrimSpace(jsonl.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var entry Entry
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	assert.Equal(t, Entry{Time: at, Role: "assistant", Content: "hi", Tokens: 3}, entry)
+}
+
+func TestOpenAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	require.NoError(t, os.WriteFile(path, []byte("earlier\n"), 0644))
+
+	w, err := Open(path, FormatText)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(Entry{Role: "user", Content: "again"}))
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "earlier\n")
+	assert.Contains(t, string(data), "user: again")
+}
@@ -0,0 +1,103 @@
+package triage
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Issue is the issue being triaged
+type Issue struct {
+	Number int      `json:"number,omitempty"`
+	Title  string   `json:"title"`
+	State  string   `json:"state,omitempty"`
+	Author string   `json:"author,omitempty"`
+	URL    string   `json:"url,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	Body   string   `json:"body"`
+}
+
+// ParseIssue reads an issue as printed by gh issue view, piped or with
+// --json, or as plain text whose first line is the title
+func ParseIssue(text string) Issue {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if strings.HasPrefix(text, "{") {
+		if issue, ok := parseJSON(text); ok {
+			return issue
+		}
+	}
+	if strings.HasPrefix(text, "title:\t") {
+		return parseGHText(text)
+	}
+
+	title, body, _ := strings.Cut(text, "\n")
+	return Issue{
+		Title: strings.TrimSpace(strings.TrimLeft(title, "# ")),
+		Body:  strings.TrimSpace(body),
+	}
+}
+
+// parseJSON reads the output of gh issue view --json
+func parseJSON(text string) (Issue, bool) {
+	var raw struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		URL    string `json:"url"`
+		Body   string `json:"body"`
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := json.Unmarshal([]byte(text), &raw); err != nil || raw.Title == "" {
+		return Issue{}, false
+	}
+	issue := Issue{
+		Number: raw.Number,
+		Title:  raw.Title,
+		State:  raw.State,
+		Author: raw.Author.Login,
+		URL:    raw.URL,
+		Body:   strings.TrimSpace(raw.Body),
+	}
+	for _, label := range raw.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	return issue, true
+}
+
+// parseGHText reads the piped output of gh issue view: "key:\tvalue" lines,
+// a "--" line and the body
+func parseGHText(text string) Issue {
+	header, body, _ := strings.Cut(text, "\n--\n")
+	issue := Issue{Body: strings.TrimSpace(body)}
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":\t")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "title":
+			issue.Title = value
+		case "state":
+			issue.State = value
+		case "author":
+			issue.Author = value
+		case "url":
+			issue.URL = value
+		case "number":
+			issue.Number, _ = strconv.Atoi(value)
+		case "labels":
+			for _, label := range strings.Split(value, ",") {
+				if label = strings.TrimSpace(label); label != "" {
+					issue.Labels = append(issue.Labels, label)
+				}
+			}
+		}
+	}
+	return issue
+}
@@ -0,0 +1,70 @@
+package triage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the report as a markdown document, e.g. for an issue
+// comment
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+
+	title := r.Issue.Title
+	if r.Issue.Number > 0 {
+		title = fmt.Sprintf("%s (#%d)", title, r.Issue.Number)
+	}
+	sb.WriteString(fmt.Sprintf("# Triage: %s\n\n", title))
+	sb.WriteString(fmt.Sprintf("**Category:** %s · **Severity:** %s", r.Category, r.Severity))
+	if len(r.Labels) > 0 {
+		sb.WriteString(" · **Suggested labels:** " + strings.Join(r.Labels, ", "))
+	}
+	sb.WriteString("\n")
+
+	if r.Summary != "" {
+		sb.WriteString("\n## Summary\n\n" + strings.TrimSpace(r.Summary) + "\n")
+	}
+
+	if len(r.Reproduction) > 0 || r.Expected != "" || r.Actual != "" {
+		sb.WriteString("\n## Reproduction\n\n")
+		for i, step := range r.Reproduction {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, strings.TrimSpace(step)))
+		}
+		if r.Expected != "" {
+			sb.WriteString("\n**Expected:** " + strings.TrimSpace(r.Expected) + "\n")
+		}
+		if r.Actual != "" {
+			sb.WriteString("\n**Actual:** " + strings.TrimSpace(r.Actual) + "\n")
+		}
+	}
+
+	if len(r.SuspectFiles) > 0 {
+		sb.WriteString("\n## Suspect files\n\n")
+		for _, f := range r.SuspectFiles {
+			location := f.Path
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+			}
+			sb.WriteString(fmt.Sprintf("- `%s`", location))
+			if f.Reason != "" {
+				sb.WriteString(" — " + strings.TrimSpace(f.Reason))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	writeList(&sb, "Missing information", r.MissingInformation)
+	writeList(&sb, "Next steps", r.NextSteps)
+	return sb.String()
+}
+
+// writeList writes a section of bullets, or nothing when items is empty
+func writeList(sb *strings.Builder, heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	sb.WriteString("\n## " + heading + "\n\n")
+	for _, item := range items {
+		sb.WriteString("- " + strings.TrimSpace(item) + "\n")
+	}
+}
@@ -0,0 +1,342 @@
+// Package triage turns an issue into a structured triage report: its kind
+// and severity, how to reproduce it and the files likely involved
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/embeddings"
+	"github.com/common-creation/coda/internal/security"
+)
+
+// Categories of issues
+const (
+	CategoryBug         = "bug"
+	CategoryFeature     = "feature"
+	CategoryQuestion    = "question"
+	CategoryDocs        = "docs"
+	CategoryPerformance = "performance"
+	CategorySecurity    = "security"
+	CategoryOther       = "other"
+)
+
+// Severities of issues, most severe first
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+const (
+	// DefaultCandidates is the number of index matches shown to the model
+	DefaultCandidates = 6
+
+	// maxCandidateLines bounds the code shown of each match
+	maxCandidateLines = 40
+
+	// maxIssueBytes bounds the issue text sent
+	maxIssueBytes = 24 * 1024
+)
+
+// DefaultTemplate is the user prompt. It receives the Issue, the issue text
+// wrapped as untrusted content as IssueText, and the index matches as
+// Candidates, each with Path, StartLine, EndLine and Code.
+const DefaultTemplate = `Triage this issue.
+
+{{.IssueText}}
+{{if .Candidates}}
+These parts of the repository match the issue best, most similar first.
+Base suspect_files on them and on paths the issue names.
+{{range .Candidates}}
+{{.Code}}
+{{end}}{{else}}
+No code search results are available; only name files the issue mentions.
+{{end}}`
+
+// systemPrompt describes the report
+const systemPrompt = `You are a maintainer triaging an issue of this repository. Classify it,
+work out how to reproduce it and point to the code most likely involved.
+Do not invent facts: list what the reporter has to provide instead.
+
+Respond with a JSON object of this exact shape:
+{
+  "summary": "one or two sentences restating the problem or request",
+  "category": "bug" | "feature" | "question" | "docs" | "performance" | "security" | "other",
+  "severity": "critical" | "high" | "medium" | "low",
+  "labels": ["suggested labels"],
+  "reproduction": ["numbered steps to reproduce, empty when not a bug"],
+  "expected": "expected behavior (optional)",
+  "actual": "actual behavior (optional)",
+  "suspect_files": [
+    {"path": "repository-relative path", "line": <line or 0>, "reason": "why it is involved"}
+  ],
+  "missing_information": ["what to ask the reporter"],
+  "next_steps": ["concrete next actions for a maintainer"]
+}`
+
+// SuspectFile is a file the issue likely involves
+type SuspectFile struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Report is the triage of an issue
+type Report struct {
+	Issue              Issue         `json:"issue"`
+	Summary            string        `json:"summary"`
+	Category           string        `json:"category"`
+	Severity           string        `json:"severity"`
+	Labels             []string      `json:"labels"`
+	Reproduction       []string      `json:"reproduction"`
+	Expected           string        `json:"expected,omitempty"`
+	Actual             string        `json:"actual,omitempty"`
+	SuspectFiles       []SuspectFile `json:"suspect_files"`
+	MissingInformation []string      `json:"missing_information"`
+	NextSteps          []string      `json:"next_steps"`
+}
+
+// Candidate is a part of the repository the index matched with the issue
+type Candidate struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Score     float64
+	// Code is the matched lines, wrapped as untrusted content
+	Code string
+}
+
+// Triager triages issues with an AI client
+type Triager struct {
+	client     ai.Client
+	model      string
+	root       string
+	index      *embeddings.Index
+	candidates int
+	template   string
+}
+
+// Option configures a Triager
+type Option func(*Triager)
+
+// WithIndex searches index for the code the issue involves
+func WithIndex(index *embeddings.Index) Option {
+	return func(t *Triager) {
+		t.index = index
+	}
+}
+
+// WithCandidates sets the number of index matches shown to the model
+func WithCandidates(n int) Option {
+	return func(t *Triager) {
+		if n > 0 {
+			t.candidates = n
+		}
+	}
+}
+
+// WithTemplate replaces DefaultTemplate
+func WithTemplate(text string) Option {
+	return func(t *Triager) {
+		if strings.TrimSpace(text) != "" {
+			t.template = text
+		}
+	}
+}
+
+// NewTriager creates a triager for the repository at root
+func NewTriager(client ai.Client, model, root string, opts ...Option) *Triager {
+	t := &Triager{
+		client:     client,
+		model:      model,
+		root:       root,
+		candidates: DefaultCandidates,
+		template:   DefaultTemplate,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Triage parses the issue text, searches the index for the code it involves
+// and asks the model for a report
+func (t *Triager) Triage(ctx context.Context, text string) (*Report, error) {
+	issue := ParseIssue(text)
+	if issue.Title == "" && issue.Body == "" {
+		return nil, fmt.Errorf("issue is empty")
+	}
+
+	candidates, err := t.search(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := t.Prompt(issue, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.ChatCompletion(ctx, ai.ChatRequest{
+		Model: t.model,
+		Messages: []ai.Message{
+			{Role: ai.RoleSystem, Content: systemPrompt},
+			{Role: ai.RoleUser, Content: prompt},
+		},
+		ResponseFormat: &ai.ResponseFormat{Type: "json_object"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("triage failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("triage returned no choices")
+	}
+
+	report, err := ParseResponse(resp.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	report.Issue = issue
+	report.SuspectFiles = t.existingFiles(report.SuspectFiles)
+	return report, nil
+}
+
+// Prompt renders the user prompt of the template
+func (t *Triager) Prompt(issue Issue, candidates []Candidate) (string, error) {
+	tmpl, err := template.New("triage").Parse(t.template)
+	if err != nil {
+		return "", fmt.Errorf("invalid triage template: %w", err)
+	}
+
+	var text strings.Builder
+	if issue.Number > 0 {
+		fmt.Fprintf(&text, "Issue #%d: ", issue.Number)
+	}
+	text.WriteString(issue.Title + "\n")
+	if len(issue.Labels) > 0 {
+		text.WriteString("Labels: " + strings.Join(issue.Labels, ", ") + "\n")
+	}
+	body := issue.Body
+	if len(body) > maxIssueBytes {
+		body = body[:maxIssueBytes] + "\n[truncated]"
+	}
+	text.WriteString("\n" + body)
+
+	var prompt strings.Builder
+	err = tmpl.Execute(&prompt, map[string]interface{}{
+		"Issue":      issue,
+		"IssueText":  security.WrapUntrusted("the issue", text.String()),
+		"Candidates": candidates,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render triage template: %w", err)
+	}
+	return prompt.String(), nil
+}
+
+// search returns the parts of the repository most similar to the issue
+func (t *Triager) search(ctx context.Context, issue Issue) ([]Candidate, error) {
+	if t.index == nil {
+		return nil, nil
+	}
+	if err := t.index.Update(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update the search index: %w", err)
+	}
+	query := issue.Title + "\n" + issue.Body
+	if len(query) > maxIssueBytes {
+		query = query[:maxIssueBytes]
+	}
+	results, err := t.index.Search(ctx, query, "", t.candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the index: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(results))
+	for _, r := range results {
+		lines := strings.Split(strings.TrimRight(r.Text, "\n"), "\n")
+		if len(lines) > maxCandidateLines {
+			lines = lines[:maxCandidateLines]
+		}
+		end := r.StartLine + len(lines) - 1
+		source := fmt.Sprintf("%s:%d-%d", r.Path, r.StartLine, end)
+		candidates = append(candidates, Candidate{
+			Path:      r.Path,
+			StartLine: r.StartLine,
+			EndLine:   end,
+			Score:     r.Score,
+			Code:      security.WrapUntrusted(source, strings.Join(lines, "\n")),
+		})
+	}
+	return candidates, nil
+}
+
+// existingFiles drops the suspect files that do not exist under the root
+func (t *Triager) existingFiles(files []SuspectFile) []SuspectFile {
+	var kept []SuspectFile
+	for _, f := range files {
+		f.Path = filepath.ToSlash(filepath.Clean(strings.TrimPrefix(f.Path, "./")))
+		if f.Path == "." || filepath.IsAbs(f.Path) || strings.HasPrefix(f.Path, "../") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(t.root, filepath.FromSlash(f.Path))); err != nil {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// ParseResponse parses the model's JSON triage report
+func ParseResponse(content string) (*Report, error) {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var report Report
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse triage response: %w", err)
+	}
+	report.Category = normalizeCategory(report.Category)
+	report.Severity = normalizeSeverity(report.Severity)
+	return &report, nil
+}
+
+func normalizeCategory(category string) string {
+	switch strings.ToLower(strings.TrimSpace(category)) {
+	case "bug", "defect", "regression", "crash":
+		return CategoryBug
+	case "feature", "enhancement", "feature request":
+		return CategoryFeature
+	case "question", "support":
+		return CategoryQuestion
+	case "docs", "documentation":
+		return CategoryDocs
+	case "performance", "perf":
+		return CategoryPerformance
+	case "security", "vulnerability":
+		return CategorySecurity
+	default:
+		return CategoryOther
+	}
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "blocker", "urgent":
+		return SeverityCritical
+	case "high", "major":
+		return SeverityHigh
+	case "low", "minor", "trivial":
+		return SeverityLow
+	default:
+		return SeverityMedium
+	}
+}
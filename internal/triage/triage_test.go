@@ -0,0 +1,161 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/embeddings"
+)
+
+func TestParseIssue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Issue
+	}{
+		{
+			name: "gh issue view",
+			input: "title:\tCrash on empty config\nstate:\tOPEN\nauthor:\toctocat\nlabels:\tbug, config\n" +
+				"comments:\t0\nnumber:\t42\nurl:\thttps://github.com/o/r/issues/42\n--\nRunning coda with an empty file panics.\r\n",
+			want: Issue{
+				Number: 42,
+				Title:  "Crash on empty config",
+				State:  "OPEN",
+				Author: "octocat",
+				URL:    "https://github.com/o/r/issues/42",
+				Labels: []string{"bug", "config"},
+				Body:   "Running coda with an empty file panics.",
+			},
+		},
+		{
+			name:  "gh issue view --json",
+			input: `{"number":7,"title":"Add dark theme","body":"Please","labels":[{"name":"enhancement"}],"author":{"login":"me"}}`,
+			want: Issue{
+				Number: 7,
+				Title:  "Add dark theme",
+				Author: "me",
+				Labels: []string{"enhancement"},
+				Body:   "Please",
+			},
+		},
+		{
+			name:  "plain text",
+			input: "\n# Slow startup\n\nStartup takes 10 seconds.\n",
+			want:  Issue{Title: "Slow startup", Body: "Startup takes 10 seconds."},
+		},
+		{
+			name:  "JSON without a title is text",
+			input: `{"a":1}`,
+			want:  Issue{Title: `{"a":1}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseIssue(tt.input))
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	report, err := ParseResponse("```json\n{\"summary\":\"s\",\"category\":\"Enhancement\",\"severity\":\"blocker\"}\n```")
+	require.NoError(t, err)
+	assert.Equal(t, "s", report.Summary)
+	assert.Equal(t, CategoryFeature, report.Category)
+	assert.Equal(t, SeverityCritical, report.Severity)
+
+	report, err = ParseResponse(`{"category":"chore","severity":"?"}`)
+	require.NoError(t, err)
+	assert.Equal(t, CategoryOther, report.Category)
+	assert.Equal(t, SeverityMedium, report.Severity)
+
+	_, err = ParseResponse("not json")
+	assert.Error(t, err)
+}
+
+// scriptedClient returns a canned chat response
+type scriptedClient struct {
+	ai.DummyClient
+	response string
+	requests []ai.ChatRequest
+}
+
+func (c *scriptedClient) ChatCompletion(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	c.requests = append(c.requests, req)
+	return &ai.ChatResponse{Choices: []ai.Choice{{Message: ai.Message{Role: ai.RoleAssistant, Content: c.response}}}}, nil
+}
+
+func TestTriagerTriage(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "config"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "loader.go"),
+		[]byte("package config\n\n// Load parses the config file\nfunc Load(path string) (*Config, error) {\n\treturn parse(path)\n}\n"), 0644))
+
+	client := &scriptedClient{response: `{
+		"summary": "Loading an empty config panics",
+		"category": "bug",
+		"severity": "high",
+		"labels": ["bug"],
+		"reproduction": ["Create an empty config", "Run coda"],
+		"expected": "An error",
+		"actual": "A panic",
+		"suspect_files": [
+			{"path": "./config/loader.go", "line": 4, "reason": "Load parses the file"},
+			{"path": "config/missing.go", "reason": "made up"},
+			{"path": "../outside.go", "reason": "outside"}
+		],
+		"missing_information": ["The coda version"],
+		"next_steps": ["Return an error for empty files"]
+	}`}
+
+	index := embeddings.NewIndex(root, embeddings.NewLocalEmbedder())
+	triager := NewTriager(client, "gpt-4o", root, WithIndex(index))
+	report, err := triager.Triage(context.Background(), "title:\tCrash when loading an empty config\nnumber:\t42\n--\nThe config loader panics on an empty file.")
+	require.NoError(t, err)
+
+	require.Len(t, client.requests, 1)
+	req := client.requests[0]
+	assert.Equal(t, "json_object", req.ResponseFormat.Type)
+	prompt := req.Messages[1].Content
+	assert.Contains(t, prompt, "Issue #42: Crash when loading an empty config")
+	assert.Contains(t, prompt, "config/loader.go:1-")
+	assert.Contains(t, prompt, "func Load(path string)")
+
+	assert.Equal(t, 42, report.Issue.Number)
+	assert.Equal(t, CategoryBug, report.Category)
+	assert.Equal(t, SeverityHigh, report.Severity)
+	assert.Equal(t, []SuspectFile{{Path: "config/loader.go", Line: 4, Reason: "Load parses the file"}}, report.SuspectFiles)
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "# Triage: Crash when loading an empty config (#42)")
+	assert.Contains(t, markdown, "**Category:** bug · **Severity:** high")
+	assert.Contains(t, markdown, "1. Create an empty config\n2. Run coda\n")
+	assert.Contains(t, markdown, "- `config/loader.go:4` — Load parses the file")
+	assert.Contains(t, markdown, "## Missing information\n\n- The coda version\n")
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"suspect_files":[{"path":"config/loader.go","line":4`)
+}
+
+func TestTriagerTemplate(t *testing.T) {
+	client := &scriptedClient{response: `{"summary":"s"}`}
+	triager := NewTriager(client, "m", t.TempDir(), WithTemplate("Issue {{.Issue.Title}} with {{len .Candidates}} matches"))
+	_, err := triager.Triage(context.Background(), "Typo in README")
+	require.NoError(t, err)
+	assert.Equal(t, "Issue Typo in README with 0 matches", client.requests[0].Messages[1].Content)
+
+	triager = NewTriager(client, "m", t.TempDir(), WithTemplate("{{.Issue"))
+	_, err = triager.Triage(context.Background(), "Typo in README")
+	assert.ErrorContains(t, err, "invalid triage template")
+
+	_, err = triager.Triage(context.Background(), "  \n")
+	assert.Error(t, err)
+}
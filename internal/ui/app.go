@@ -3,6 +3,7 @@ package ui
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,9 +14,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
 
+	"github.com/common-creation/coda/internal/changes"
 	"github.com/common-creation/coda/internal/chat"
 	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/journal"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/startup"
 	"github.com/common-creation/coda/internal/tools"
+	"github.com/common-creation/coda/internal/transcript"
 )
 
 // App represents the main TUI application
@@ -25,6 +31,7 @@ type App struct {
 	config      *config.Config
 	chatHandler *chat.ChatHandler
 	toolManager *tools.Manager
+	journal     *journal.Recorder
 	logger      *log.Logger
 	ctx         context.Context
 	cancel      context.CancelFunc
@@ -35,8 +42,19 @@ type AppOptions struct {
 	Config         *config.Config
 	ChatHandler    *chat.ChatHandler
 	ToolManager    *tools.Manager
+	ChangeTracker  *changes.Tracker // Files modified by tools (optional)
 	Logger         *log.Logger
 	InitialMessage string // Initial message to send on startup
+	Inline         bool   // Render without the alternate screen (see ModelOptions.Inline)
+
+	Transcript *transcript.Writer // Mirror of the conversation (optional)
+
+	ApprovalRules *security.ApprovalRules // Rules answering tool calls without asking (optional)
+	Output        io.Writer               // Terminal to draw on (default stdout)
+
+	Journal *journal.Recorder // Writes the day's journal entry on exit (optional)
+
+	Startup *startup.Tracker // Startup tasks still running in the background (optional)
 }
 
 // NewApp creates a new TUI application instance
@@ -61,14 +79,24 @@ func NewApp(opts AppOptions) (*App, error) {
 		Config:         opts.Config,
 		ChatHandler:    opts.ChatHandler,
 		ToolManager:    opts.ToolManager,
+		ChangeTracker:  opts.ChangeTracker,
 		Logger:         opts.Logger,
 		Context:        ctx,
 		InitialMessage: opts.InitialMessage,
+		Inline:         opts.Inline,
+		Transcript:     opts.Transcript,
+		ApprovalRules:  opts.ApprovalRules,
+		Startup:        opts.Startup,
 	})
 
 	// Configure program options
 	var programOpts []tea.ProgramOption
-	programOpts = append(programOpts, tea.WithAltScreen())
+	if !opts.Inline {
+		programOpts = append(programOpts, tea.WithAltScreen())
+	}
+	if opts.Output != nil {
+		programOpts = append(programOpts, tea.WithOutput(opts.Output))
+	}
 
 	program := tea.NewProgram(model, programOpts...)
 
@@ -78,6 +106,7 @@ func NewApp(opts AppOptions) (*App, error) {
 		config:      opts.Config,
 		chatHandler: opts.ChatHandler,
 		toolManager: opts.ToolManager,
+		journal:     opts.Journal,
 		logger:      opts.Logger,
 		ctx:         ctx,
 		cancel:      cancel,
@@ -105,7 +134,16 @@ func (a *App) Run() error {
 			}
 		}()
 
-		if _, err := a.program.Run(); err != nil {
+		final, err := a.program.Run()
+		if model, ok := final.(Model); ok {
+			model.closeTabs()
+			if a.journal != nil {
+				if err := a.journal.Finish(model.journalEntry()); err != nil {
+					a.logger.Warn("Failed to write journal", "error", err)
+				}
+			}
+		}
+		if err != nil {
 			errChan <- fmt.Errorf("failed to run program: %w", err)
 		} else {
 			errChan <- nil
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/security"
+)
+
+// decideToolCalls answers the calls the approval rules match. It returns the
+// calls that still need the permit dialog, the calls to run and the results
+// of the denied calls. Dangerous calls are always asked, and recorded in
+// permitDangers for the dialog. Undecided calls run without asking only when
+// all of them are of tools allowed for the session.
+func (m *Model) decideToolCalls(calls []ai.ToolCall) (ask, run []ai.ToolCall, denied []chat.ToolResult) {
+	m.permitDangers = nil
+	for _, call := range calls {
+		dangers := m.toolCallDangers(call)
+		match := m.matchApprovalRule(call)
+		switch {
+		case match != nil && !match.Approved():
+			m.addSystemMessage(fmt.Sprintf("%s denied by rule %s", call.Function.Name, match.Rule))
+			denied = append(denied, chat.ToolResult{
+				ToolCallID: call.ID,
+				ToolName:   call.Function.Name,
+				Error:      fmt.Errorf("tool call denied by approval rule %s", match.Rule),
+				ExecutedAt: time.Now(),
+			})
+		case len(dangers) > 0:
+			if match != nil {
+				m.addSystemMessage(fmt.Sprintf("%s matched rule %s but needs confirmation: %s", call.Function.Name, match.Rule, strings.Join(dangers, ", ")))
+			}
+			if m.permitDangers == nil {
+				m.permitDangers = make(map[string][]string)
+			}
+			m.permitDangers[call.ID] = dangers
+			ask = append(ask, call)
+		case match == nil:
+			ask = append(ask, call)
+		default:
+			m.addSystemMessage(fmt.Sprintf("%s approved by rule %s", call.Function.Name, match.Rule))
+			run = append(run, call)
+		}
+	}
+
+	if !m.permitEscalated() && m.toolCallsAllowed(ask) {
+		m.addSystemMessage(fmt.Sprintf("Running %d tool call(s) allowed for this session", len(ask)))
+		run = append(run, ask...)
+		ask = nil
+	}
+	return ask, run, denied
+}
+
+// matchApprovalRule returns the approval rule deciding call, or nil
+func (m *Model) matchApprovalRule(call ai.ToolCall) *security.RuleMatch {
+	if m.approvalRules.Len() == 0 && m.personaRules.Len() == 0 {
+		return nil
+	}
+	if m.ruleMatches == nil {
+		m.ruleMatches = make(map[string]int)
+	}
+
+	// Calls with unreadable arguments only match rules without argument conditions
+	var params map[string]interface{}
+	_ = json.Unmarshal([]byte(call.Function.Arguments), &params)
+
+	// The rules of the active persona come first
+	if match := m.personaRules.Evaluate(call.Function.Name, params, m.ruleMatches); match != nil {
+		return match
+	}
+	return m.approvalRules.Evaluate(call.Function.Name, params, m.ruleMatches)
+}
+
+// runToolCalls runs the approved calls and sends their results to the model
+// together with the results of the denied calls
+func (m *Model) runToolCalls(approved []ai.ToolCall, denied []chat.ToolResult) tea.Cmd {
+	if len(approved) == 0 {
+		if m.chatHandler == nil || len(denied) == 0 {
+			m.finishJob()
+			return nil
+		}
+		return m.sendToolResults(denied)
+	}
+
+	execute := m.executeToolCalls(approved)
+	if len(denied) > 0 {
+		run := execute
+		execute = func() tea.Msg {
+			msg := run()
+			if done, ok := msg.(toolExecutionMsg); ok {
+				done.results = append(done.results, denied...)
+				return done
+			}
+			return msg
+		}
+	}
+
+	// Redraw the output of commands while they run
+	if job := m.activeJob(); job != nil && job.command != nil {
+		return tea.Batch(execute, commandPaneTick(job.command))
+	}
+	return execute
+}
+
+// resumeToolCalls answers the permit dialog: the calls the rules decided
+// before it opened are handled together with the user's answer
+func (m *Model) resumeToolCalls(approved []ai.ToolCall, denied []chat.ToolResult) tea.Cmd {
+	approved = append(m.ruleApproved, approved...)
+	denied = append(m.ruleDenied, denied...)
+	m.ruleApproved, m.ruleDenied = nil, nil
+	return m.runToolCalls(approved, denied)
+}
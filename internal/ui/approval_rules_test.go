@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+)
+
+func TestDecideToolCalls(t *testing.T) {
+	rules, err := security.NewApprovalRules([]config.ApprovalRule{
+		{Name: "go-tests", Tool: "run_command", Args: map[string]string{"command": "^go test"}, MaxPerSession: 1, Action: config.PermitApprove},
+		{Name: "no-deletes", Tool: "delete_file", Action: config.PermitDeny},
+	}, nil)
+	require.NoError(t, err)
+
+	command := func(id, cmd string) ai.ToolCall {
+		call := toolCall(id, "run_command")
+		call.Function.Arguments = `{"command": "` + cmd + `"}`
+		return call
+	}
+
+	m := newPermitTestModel()
+	m.currentMode = ModeInsert
+	m.permitDialogVisible = false
+	m.approvalRules = rules
+
+	// Calls every rule answers run without the dialog
+	ask, run, denied := m.decideToolCalls([]ai.ToolCall{command("1", "go test ./..."), toolCall("2", "delete_file")})
+	assert.Empty(t, ask)
+	assert.Equal(t, []ai.ToolCall{command("1", "go test ./...")}, run)
+	require.Len(t, denied, 1)
+	assert.Equal(t, "2", denied[0].ToolCallID)
+	assert.EqualError(t, denied[0].Error, "tool call denied by approval rule no-deletes")
+	assert.Equal(t, "delete_file denied by rule no-deletes", m.messages[len(m.messages)-1].Content)
+
+	// The rule is used up for the session, so the dialog asks only for the
+	// undecided calls and keeps the decided ones for the answer
+	updated, _ := m.Update(chatResponseMsg{ID: "r", ToolCalls: []ai.ToolCall{command("3", "go test ./..."), toolCall("4", "delete_file")}})
+	m = updated.(Model)
+	assert.Equal(t, ModePermit, m.currentMode)
+	assert.Equal(t, []ai.ToolCall{command("3", "go test ./...")}, m.pendingToolCalls)
+	assert.Len(t, m.ruleDenied, 1)
+
+	updated, _ = m.exitPermitMode(false)
+	m = *updated.(*Model)
+	assert.Nil(t, m.ruleDenied)
+
+	// A new session counts the matches again
+	m.startNewSession()
+	ask, run, _ = m.decideToolCalls([]ai.ToolCall{command("5", "go test ./...")})
+	assert.Empty(t, ask)
+	assert.Len(t, run, 1)
+}
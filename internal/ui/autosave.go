@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/ui/components"
+)
+
+// autoSaveMsg saves the sessions of the open tabs
+type autoSaveMsg struct{}
+
+// autoSaveDoneMsg reports the result of an autosave
+type autoSaveDoneMsg struct {
+	err error
+}
+
+// autoSaveInterval returns how often sessions are saved in the background,
+// or 0 when only the saves after each response and on exit are made
+func (m Model) autoSaveInterval() time.Duration {
+	if m.config == nil || m.config.Session.AutoSaveInterval <= 0 {
+		return 0
+	}
+	return time.Duration(m.config.Session.AutoSaveInterval) * time.Second
+}
+
+// autoSaveTick schedules the next autosave
+func (m Model) autoSaveTick() tea.Cmd {
+	interval := m.autoSaveInterval()
+	if interval == 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return autoSaveMsg{}
+	})
+}
+
+// autoSave saves the current session of every tab in the background. The
+// error of a save made after a response is reported too, so history that is
+// not being written does not go unnoticed.
+func (m Model) autoSave() tea.Cmd {
+	var handlers []*chat.ChatHandler
+	seen := make(map[*chat.ChatHandler]bool)
+	add := func(h *chat.ChatHandler) {
+		if h != nil && !seen[h] {
+			seen[h] = true
+			handlers = append(handlers, h)
+		}
+	}
+	add(m.chatHandler)
+	for _, tab := range m.tabs {
+		add(tab.chatHandler)
+	}
+	if len(handlers) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		var errs []error
+		for _, h := range handlers {
+			if err := h.SaveCurrentSession(); err != nil {
+				errs = append(errs, err)
+			} else if err := h.SaveError(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return autoSaveDoneMsg{err: errors.Join(errs...)}
+	}
+}
+
+// reportSaveError shows a toast when saving sessions starts failing; the same
+// failure is not shown again until a save succeeds
+func (m *Model) reportSaveError(err error) {
+	if err == nil {
+		m.saveErrorShown = ""
+		return
+	}
+	if err.Error() == m.saveErrorShown {
+		return
+	}
+	m.saveErrorShown = err.Error()
+	m.logger.Warn("Autosave failed", "error", err)
+	m.toast = components.NewToastNotification(fmt.Sprintf("Autosave failed, recent history is not saved: %v", err), 10*time.Second)
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestAutoSaveInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		want    time.Duration
+	}{
+		{"default", 30, 30 * time.Second},
+		{"disabled", -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTabsTestModel()
+			m.config = config.NewDefaultConfig()
+			m.config.Session.AutoSaveInterval = tt.seconds
+			assert.Equal(t, tt.want, m.autoSaveInterval())
+			assert.Equal(t, tt.want != 0, m.autoSaveTick() != nil)
+		})
+	}
+
+	assert.Zero(t, newTabsTestModel().autoSaveInterval(), "no config")
+}
+
+func TestReportSaveError(t *testing.T) {
+	m := newTabsTestModel()
+
+	m.reportSaveError(errors.New("disk full"))
+	if assert.NotNil(t, m.toast) {
+		assert.Contains(t, m.toast.Render(), "disk full")
+	}
+
+	// The same failure is shown once
+	m.toast = nil
+	m.reportSaveError(errors.New("disk full"))
+	assert.Nil(t, m.toast)
+
+	// ...until a save succeeds
+	m.reportSaveError(nil)
+	m.reportSaveError(errors.New("disk full"))
+	assert.NotNil(t, m.toast)
+}
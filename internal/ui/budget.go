@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// maxDroppedPreview is the number of dropped messages listed in a warning
+const maxDroppedPreview = 5
+
+// confirmPromptBudget checks the prompt for input against the model's
+// context window before it is sent. When history would be dropped it warns
+// and returns false; sending the same message again confirms and drops the
+// history. A message that does not fit even without history is blocked.
+func (m *Model) confirmPromptBudget(input string) bool {
+	if m.chatHandler == nil || m.config == nil || m.config.AI.Model == "" {
+		return true
+	}
+
+	modelName := m.config.AI.Model
+	budget, err := m.chatHandler.PromptBudget(input, getModelTokenLimit(modelName))
+	if err != nil {
+		m.logger.Debug("Failed to check prompt budget", "error", err)
+		return true
+	}
+
+	if budget.Fits() {
+		m.budgetConfirmed = ""
+		return true
+	}
+	if budget.Rejected() {
+		m.budgetConfirmed = ""
+		m.addSystemMessage(fmt.Sprintf("This message does not fit into the context window of %s even without history (≈%d / %d tokens). Shorten it before sending.",
+			modelName, budget.Tokens-budget.DroppedTokens, budget.Limit))
+		return false
+	}
+	if m.budgetConfirmed != input {
+		m.budgetConfirmed = input
+		m.addSystemMessage(formatBudgetWarning(budget, modelName))
+		return false
+	}
+
+	m.budgetConfirmed = ""
+	if err := m.chatHandler.DropOldestMessages(len(budget.Dropped)); err != nil {
+		m.logger.Error("Failed to drop history", "error", err)
+	}
+	return true
+}
+
+// formatBudgetWarning describes the history a prompt would lose
+func formatBudgetWarning(budget chat.PromptBudget, modelName string) string {
+	var b strings.Builder
+	if budget.Tokens > budget.Limit {
+		fmt.Fprintf(&b, "This prompt would be ≈%d tokens, over the %d token context window of %s.", budget.Tokens, budget.Limit, modelName)
+	} else {
+		b.WriteString("This message takes the session over its token limit.")
+	}
+	fmt.Fprintf(&b, "\nSending it drops the %d oldest messages (≈%d tokens):", len(budget.Dropped), budget.DroppedTokens)
+
+	for i, msg := range budget.Dropped {
+		if i == maxDroppedPreview {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(budget.Dropped)-maxDroppedPreview)
+			break
+		}
+		fmt.Fprintf(&b, "\n  - %s: %s", msg.Role, jobTitle(msg.Content))
+	}
+	b.WriteString("\nPress Enter again to send anyway, or Ctrl+N to start a new session.")
+	return b.String()
+}
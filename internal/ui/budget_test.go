@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+func TestFormatBudgetWarning(t *testing.T) {
+	dropped := make([]ai.Message, 7)
+	for i := range dropped {
+		dropped[i] = ai.Message{Role: ai.RoleUser, Content: "question"}
+	}
+	dropped[0].Content = strings.Repeat("long ", 20)
+
+	tests := []struct {
+		name   string
+		budget chat.PromptBudget
+		want   []string
+	}{
+		{
+			name:   "over the context window",
+			budget: chat.PromptBudget{Tokens: 9000, Limit: 8192, Dropped: dropped[:2], DroppedTokens: 900},
+			want:   []string{"≈9000 tokens, over the 8192 token context window of gpt-4", "drops the 2 oldest messages (≈900 tokens)", "- user: long long", "Press Enter again"},
+		},
+		{
+			name:   "session trimming",
+			budget: chat.PromptBudget{Tokens: 100, Limit: 8192, Dropped: dropped, DroppedTokens: 70, Truncated: true},
+			want:   []string{"over its token limit", "drops the 7 oldest messages", "... and 2 more"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning := formatBudgetWarning(tt.budget, "gpt-4")
+			for _, want := range tt.want {
+				assert.Contains(t, warning, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/textdiff"
+)
+
+// maxPreviewLines bounds the diff shown in the permit dialog
+const maxPreviewLines = 40
+
+// patchCodeBlock returns the content of the file at path with block
+// applied: a diff block is applied hunk by hunk, other blocks replace the
+// part of the file they are a new version of, and a missing file is created
+// with the block
+func patchCodeBlock(block codeBlock, before string, existed bool) (string, error) {
+	switch {
+	case !existed:
+		return block.Code + "\n", nil
+	case block.Lang == "diff" || block.Lang == "patch" || textdiff.IsUnified(block.Code):
+		return textdiff.ApplyUnified(before, block.Code)
+	default:
+		return textdiff.ApplyFragment(before, block.Code)
+	}
+}
+
+// applyCodeBlock computes the change block makes to the file at path and
+// asks for approval in the permit dialog, showing the diff
+func (m *Model) applyCodeBlock(id string, block codeBlock, path string) {
+	if m.busy() || m.permitDialogVisible {
+		m.addSystemMessage("Wait for the current response to finish before applying a code block")
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		m.addSystemMessage(fmt.Sprintf("Cannot apply [%s]: %v", id, err))
+		return
+	}
+	before := string(data)
+	after, err := patchCodeBlock(block, before, existed)
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Cannot apply [%s] to %s: %v\nUse /code %s save %s to replace the whole file.", id, path, err, id, path))
+		return
+	}
+	diff, _, _ := textdiff.Unified(path, before, after, existed, true)
+	if diff == "" {
+		m.addSystemMessage(fmt.Sprintf("%s already matches [%s]", path, id))
+		return
+	}
+
+	args, _ := json.Marshal(map[string]string{"path": path, "content": after})
+	call := ai.ToolCall{
+		ID:       "code-" + id,
+		Type:     "function",
+		Function: ai.FunctionCall{Name: "write_file", Arguments: string(args)},
+	}
+	m.pendingToolCalls = []ai.ToolCall{call}
+	m.permitPreviews = map[string]string{call.ID: diff}
+	m.permitLocal = true
+	m.permitDialogVisible = true
+	m.selectedPermitOption = permitDeny
+	m.permitSeq++
+	m.permitDeadline = time.Time{}
+	if m.currentMode != ModePermit {
+		m.previousMode = m.currentMode
+		m.currentMode = ModePermit
+	}
+}
+
+// answerLocalPermit closes a permit dialog opened by /code, writing the
+// files when approved. Nothing is sent to the model either way.
+func (m *Model) answerLocalPermit(approved bool) tea.Cmd {
+	calls := m.closePermitDialog()
+	if !approved {
+		m.addSystemMessage("Code block not applied")
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for _, call := range calls {
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
+			continue
+		}
+		path, _ := params["path"].(string)
+		cmds = append(cmds, m.runCodeTool(strings.TrimPrefix(call.ID, "code-"), "apply", path, call.Function.Name, params))
+	}
+	return tea.Batch(cmds...)
+}
+
+// renderDiffPreview colors a unified diff for the permit dialog, keeping
+// the first maxPreviewLines lines
+func renderDiffPreview(diff string) string {
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	hunk := lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	more := 0
+	if len(lines) > maxPreviewLines {
+		more = len(lines) - maxPreviewLines
+		lines = lines[:maxPreviewLines]
+	}
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			lines[i] = added.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removed.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunk.Render(line)
+		}
+	}
+	if more > 0 {
+		lines = append(lines, fmt.Sprintf("… %d more lines", more))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,342 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/tools"
+)
+
+// fenceLine matches the opening line of a fenced code block, capturing the
+// fence and the first word of the info string ("go", "go:main.go")
+var fenceLine = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+
+// shellLanguages are the languages of code blocks /code can run
+var shellLanguages = map[string]bool{
+	"sh":      true,
+	"bash":    true,
+	"zsh":     true,
+	"shell":   true,
+	"console": true,
+}
+
+// maxListedCodeBlocks bounds the blocks listed by /code
+const maxListedCodeBlocks = 20
+
+// writeClipboard copies text to the system clipboard. It is a variable so
+// tests do not touch the clipboard.
+var writeClipboard = func(text string) error {
+	result := (&ContextActionManager{}).copyToClipboardSync(text)
+	if !result.Success {
+		return errors.New(result.Message)
+	}
+	return nil
+}
+
+// codeBlock is a fenced code block in an answer
+type codeBlock struct {
+	Lang string
+	Path string // File name given after the language ("go:main.go"), if any
+	Code string
+}
+
+// lines returns the number of lines of code
+func (b codeBlock) lines() int {
+	if b.Code == "" {
+		return 0
+	}
+	return strings.Count(b.Code, "\n") + 1
+}
+
+// runnable reports whether the block is a shell script /code can run
+func (b codeBlock) runnable() bool {
+	return shellLanguages[b.Lang]
+}
+
+// command returns the shell script of the block, without the "$ " prompts
+// of console transcripts
+func (b codeBlock) command() string {
+	if b.Lang != "console" {
+		return b.Code
+	}
+	var commands []string
+	for _, line := range strings.Split(b.Code, "\n") {
+		if command, ok := strings.CutPrefix(strings.TrimSpace(line), "$ "); ok {
+			commands = append(commands, command)
+		}
+	}
+	return strings.Join(commands, "\n")
+}
+
+// extractCodeBlocks returns the closed fenced code blocks in content in
+// order of appearance
+func extractCodeBlocks(content string) []codeBlock {
+	var blocks []codeBlock
+	var fence string
+	var current codeBlock
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if fence == "" {
+			match := fenceLine.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			fence = match[1]
+			lang, path, _ := strings.Cut(match[2], ":")
+			current = codeBlock{Lang: strings.ToLower(lang), Path: path}
+			lines = nil
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			current.Code = strings.Join(lines, "\n")
+			blocks = append(blocks, current)
+			fence = ""
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return blocks
+}
+
+// codeBlockID identifies block n (from 1) of answer a (from 1)
+func codeBlockID(answer, n int) string {
+	return fmt.Sprintf("%d.%d", answer, n)
+}
+
+// answerCountBefore returns the number of assistant messages before the
+// i-th message, which numbers the code blocks of the messages after it
+func (m Model) answerCountBefore(i int) int {
+	count := 0
+	for _, msg := range m.messages[:i] {
+		if msg.Role == "assistant" {
+			count++
+		}
+	}
+	return count
+}
+
+// formatCodeBlockRefs lists the code blocks of an answer for use with /code
+func formatCodeBlockRefs(blocks []codeBlock, answer int) string {
+	refs := make([]string, len(blocks))
+	for i, block := range blocks {
+		lang := block.Lang
+		if lang == "" {
+			lang = "text"
+		}
+		refs[i] = fmt.Sprintf("[%s] %s", codeBlockID(answer, i+1), lang)
+	}
+	return "Code: " + strings.Join(refs, " · ")
+}
+
+// findCodeBlock returns the block with the given ID. "M" alone is block M
+// of the last answer with code.
+func (m Model) findCodeBlock(id string) (codeBlock, string, error) {
+	answerPart, blockPart, qualified := strings.Cut(id, ".")
+	if !qualified {
+		answerPart, blockPart = "", id
+	}
+	n, err := strconv.Atoi(blockPart)
+	if err != nil || n < 1 {
+		return codeBlock{}, "", fmt.Errorf("invalid code block %q (use /code to list them)", id)
+	}
+
+	answer := 0
+	var lastWithCode []codeBlock
+	lastAnswer := 0
+	for _, msg := range m.messages {
+		if msg.Role != "assistant" {
+			continue
+		}
+		answer++
+		blocks := extractCodeBlocks(msg.Content)
+		if qualified && strconv.Itoa(answer) == answerPart {
+			if n > len(blocks) {
+				break
+			}
+			return blocks[n-1], codeBlockID(answer, n), nil
+		}
+		if len(blocks) > 0 {
+			lastWithCode, lastAnswer = blocks, answer
+		}
+	}
+	if !qualified && n <= len(lastWithCode) {
+		return lastWithCode[n-1], codeBlockID(lastAnswer, n), nil
+	}
+	return codeBlock{}, "", fmt.Errorf("no code block %s (use /code to list them)", id)
+}
+
+// codeActionMsg reports the result of saving or running a code block
+type codeActionMsg struct {
+	id     string
+	action string
+	path   string
+	result interface{}
+	err    error
+}
+
+// handleCodeCommand runs "/code [ID [copy|save [path]|apply [path]|run]]".
+// Saving and running are confirmed by sending the same command again, and
+// applying in the permit dialog; all of them go through the tool manager so
+// the security rules and hooks of write_file and run_command apply.
+func (m *Model) handleCodeCommand(input string, args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.listCodeBlocks()
+		return nil
+	}
+
+	block, id, err := m.findCodeBlock(args[0])
+	if err != nil {
+		m.addSystemMessage(err.Error())
+		return nil
+	}
+	action := "copy"
+	if len(args) > 1 {
+		action = strings.ToLower(args[1])
+	}
+
+	switch action {
+	case "copy":
+		if err := writeClipboard(block.Code); err != nil {
+			m.addSystemMessage(err.Error())
+			return nil
+		}
+		m.addSystemMessage(fmt.Sprintf("Copied [%s] (%d lines) to the clipboard", id, block.lines()))
+		return nil
+
+	case "save":
+		path := block.Path
+		if len(args) > 2 {
+			path = args[2]
+		}
+		if path == "" {
+			m.addSystemMessage("Usage: /code ID save <path>")
+			return nil
+		}
+		if !m.confirmCodeAction(input, fmt.Sprintf("Save [%s] (%s, %d lines) to %s?", id, block.Lang, block.lines(), path)) {
+			return nil
+		}
+		return m.runCodeTool(id, action, path, "write_file", map[string]interface{}{"path": path, "content": block.Code + "\n"})
+
+	case "apply":
+		path := block.Path
+		if len(args) > 2 {
+			path = args[2]
+		}
+		if path == "" {
+			m.addSystemMessage("Usage: /code ID apply <path>")
+			return nil
+		}
+		m.applyCodeBlock(id, block, path)
+		return nil
+
+	case "run":
+		if !block.runnable() {
+			m.addSystemMessage(fmt.Sprintf("[%s] is a %s block; only shell blocks (sh, bash, zsh, shell, console) can be run", id, block.Lang))
+			return nil
+		}
+		command := block.command()
+		if !m.confirmCodeAction(input, fmt.Sprintf("Run [%s]?\n  $ %s", id, strings.ReplaceAll(command, "\n", "\n  $ "))) {
+			return nil
+		}
+		return m.runCodeTool(id, action, "", tools.RunCommandToolName, map[string]interface{}{"command": command})
+
+	default:
+		m.addSystemMessage("Usage: /code [ID [copy|save [path]|apply [path]|run]]")
+		return nil
+	}
+}
+
+// confirmCodeAction reports whether input was already shown for
+// confirmation; otherwise it shows the question and remembers input
+func (m *Model) confirmCodeAction(input, question string) bool {
+	input = strings.Join(strings.Fields(input), " ")
+	if m.codeActionConfirm == input {
+		m.codeActionConfirm = ""
+		return true
+	}
+	m.codeActionConfirm = input
+	m.addSystemMessage(question + "\nSend the same command again to confirm.")
+	return false
+}
+
+// runCodeTool executes a code block action with a tool in the background
+func (m *Model) runCodeTool(id, action, path, tool string, params map[string]interface{}) tea.Cmd {
+	if m.toolManager == nil {
+		m.addSystemMessage("Tools are not available")
+		return nil
+	}
+	manager := m.toolManager
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+		result, err := manager.Execute(ctx, tool, params)
+		return codeActionMsg{id: id, action: action, path: path, result: result, err: err}
+	}
+}
+
+// formatCodeAction describes the result of a code block action
+func formatCodeAction(msg codeActionMsg) string {
+	if msg.err != nil {
+		return fmt.Sprintf("Failed to %s [%s]: %v", msg.action, msg.id, msg.err)
+	}
+	switch msg.action {
+	case "save":
+		return fmt.Sprintf("Saved [%s] to %s", msg.id, msg.path)
+	case "apply":
+		return fmt.Sprintf("Applied [%s] to %s", msg.id, msg.path)
+	}
+
+	result, _ := msg.result.(map[string]interface{})
+	output, _ := result["output"].(string)
+	status := fmt.Sprintf("exit %v", result["exit_code"])
+	if canceled, _ := result["canceled"].(bool); canceled {
+		status = "canceled"
+	}
+	text := fmt.Sprintf("Ran [%s] (%s)", msg.id, status)
+	if output = strings.TrimRight(output, "\n"); output != "" {
+		text += ":\n" + output
+	}
+	return text
+}
+
+// listCodeBlocks lists the most recent code blocks of the conversation
+func (m *Model) listCodeBlocks() {
+	var lines []string
+	answer := 0
+	for _, msg := range m.messages {
+		if msg.Role != "assistant" {
+			continue
+		}
+		answer++
+		for i, block := range extractCodeBlocks(msg.Content) {
+			first, _, _ := strings.Cut(strings.TrimSpace(block.Code), "\n")
+			lang := block.Lang
+			if lang == "" {
+				lang = "text"
+			}
+			lines = append(lines, fmt.Sprintf("  [%s] %s, %d lines: %s", codeBlockID(answer, i+1), lang, block.lines(), truncateLine(first, 60)))
+		}
+	}
+	if len(lines) == 0 {
+		m.addSystemMessage("No code blocks in this conversation yet")
+		return
+	}
+	if len(lines) > maxListedCodeBlocks {
+		lines = lines[len(lines)-maxListedCodeBlocks:]
+	}
+	m.addSystemMessage("Code blocks:\n" + strings.Join(lines, "\n") +
+		"\nUse /code ID [copy|save [path]|apply [path]|run]; ID may be just the block number of the last answer.")
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/tools"
+)
+
+func TestExtractCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []codeBlock
+	}{
+		{
+			name:    "language and file name",
+			content: "Here:\n```go:main.go\npackage main\n```\nand\n```bash\necho hi\n```",
+			want: []codeBlock{
+				{Lang: "go", Path: "main.go", Code: "package main"},
+				{Lang: "bash", Code: "echo hi"},
+			},
+		},
+		{
+			name:    "longer fence keeps inner fences",
+			content: "````markdown\n```go\nx\n```\n````",
+			want:    []codeBlock{{Lang: "markdown", Code: "```go\nx\n```"}},
+		},
+		{
+			name:    "tildes and no language",
+			content: "~~~\nplain\n~~~",
+			want:    []codeBlock{{Code: "plain"}},
+		},
+		{
+			name:    "unclosed block is ignored",
+			content: "```go\npackage main",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extractCodeBlocks(tt.content))
+		})
+	}
+
+	assert.Equal(t, "ls\ngo test ./...", codeBlock{Lang: "console", Code: "$ ls\nfile.go\n$ go test ./..."}.command())
+}
+
+func TestHandleCodeCommand(t *testing.T) {
+	m := newTabsTestModel()
+	m.messages = []Message{
+		{ID: "1", Role: "user", Content: "how?"},
+		{ID: "2", Role: "assistant", Content: "```go\npackage main\n```"},
+		{ID: "3", Role: "assistant", Content: "```sh\necho hello\n```\n```python\nprint(1)\n```"},
+	}
+	manager := tools.NewManager(nil, nil)
+	require.NoError(t, manager.Register(tools.NewRunCommandTool(nil, t.TempDir())))
+	m.toolManager = manager
+
+	block, id, err := m.findCodeBlock("1.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1", id)
+	assert.Equal(t, "go", block.Lang)
+
+	// A bare number is a block of the last answer with code
+	_, id, err = m.findCodeBlock("2")
+	require.NoError(t, err)
+	assert.Equal(t, "2.2", id)
+
+	_, _, err = m.findCodeBlock("1.2")
+	assert.ErrorContains(t, err, "no code block 1.2")
+
+	var copied string
+	original := writeClipboard
+	writeClipboard = func(text string) error { copied = text; return nil }
+	t.Cleanup(func() { writeClipboard = original })
+	assert.Nil(t, m.handleCodeCommand("/code 1.1", []string{"1.1"}))
+	assert.Equal(t, "package main", copied)
+
+	m.handleCodeCommand("/code 2.2 run", []string{"2.2", "run"})
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "only shell blocks")
+
+	// Running asks for confirmation first
+	assert.Nil(t, m.handleCodeCommand("/code 2.1 run", []string{"2.1", "run"}))
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Run [2.1]?\n  $ echo hello")
+	cmd := m.handleCodeCommand("/code 2.1 run", []string{"2.1", "run"})
+	require.NotNil(t, cmd)
+	msg, ok := cmd().(codeActionMsg)
+	require.True(t, ok)
+	assert.Equal(t, "Ran [2.1] (exit 0):\nhello", formatCodeAction(msg))
+
+	assert.Equal(t, "Code: [2.1] sh · [2.2] python", formatCodeBlockRefs(extractCodeBlocks(m.messages[2].Content), 2))
+}
+
+func TestApplyCodeBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tprintln(1)\n}\n"), 0644))
+
+	m := newTabsTestModel()
+	m.messages = []Message{
+		{ID: "1", Role: "assistant", Content: "```go\nfunc main() {\n\tprintln(2)\n}\n```"},
+	}
+	manager := tools.NewManager(nil, nil)
+	require.NoError(t, manager.Register(tools.NewWriteFileTool(nil)))
+	m.toolManager = manager
+
+	// The diff is shown in the permit dialog before anything is written
+	assert.Nil(t, m.handleCodeCommand("/code 1 apply "+path, []string{"1", "apply", path}))
+	require.True(t, m.permitDialogVisible)
+	require.True(t, m.permitLocal)
+	assert.Contains(t, m.permitPreviews["code-1.1"], "-\tprintln(1)\n+\tprintln(2)\n")
+	assert.Contains(t, m.renderPermitDialog(), "Changes:")
+
+	_, cmd := m.exitPermitMode(true)
+	require.NotNil(t, cmd)
+	assert.False(t, m.permitDialogVisible)
+	msg, ok := cmd().(codeActionMsg)
+	require.True(t, ok)
+	assert.Equal(t, "Applied [1.1] to "+path, formatCodeAction(msg))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {\n\tprintln(2)\n}\n", string(data))
+
+	// Denying writes nothing and tells the model nothing
+	m.messages = append(m.messages, Message{ID: "2", Role: "assistant", Content: "```go\nfunc main() {\n}\n```"})
+	m.handleCodeCommand("/code 1 apply "+path, []string{"1", "apply", path})
+	require.True(t, m.permitDialogVisible)
+	_, cmd = m.exitPermitMode(false)
+	assert.Nil(t, cmd)
+	assert.Equal(t, "Code block not applied", m.messages[len(m.messages)-1].Content)
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultCollapseLines is used when no configuration is loaded
+const defaultCollapseLines = 40
+
+// collapseLines returns the number of lines answers are collapsed to, or 0
+// when they are always shown in full
+func (m Model) collapseLines() int {
+	lines := defaultCollapseLines
+	if m.config != nil {
+		lines = m.config.UI.CollapseLines
+	}
+	if lines < 0 {
+		return 0
+	}
+	return lines
+}
+
+// collapseBlock shortens the rendered block of a long answer unless it was
+// expanded
+func (m Model) collapseBlock(msg Message, block string) string {
+	limit := m.collapseLines()
+	if limit == 0 || msg.Role != "assistant" || m.expanded[msg.ID] {
+		return block
+	}
+
+	lines := strings.Split(block, "\n")
+	if len(lines) <= limit {
+		return block
+	}
+	return strings.Join(lines[:limit], "\n") +
+		fmt.Sprintf("\n▸ %d more lines (Ctrl+Y, then z to expand)", len(lines)-limit)
+}
+
+// toggleCollapsed expands the answer at the top of the viewport (or the
+// first answer starting in view), or collapses it again
+func (m *Model) toggleCollapsed() {
+	i := m.topMessage()
+	if i < 0 || m.messages[i].Role != "assistant" {
+		i = -1
+		for j, line := range m.messageLines {
+			if line >= m.viewport.YOffset && line < m.viewport.YOffset+m.viewport.Height &&
+				m.messages[j].Role == "assistant" {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return
+		}
+	}
+
+	if m.expanded == nil {
+		m.expanded = make(map[string]bool)
+	}
+	id := m.messages[i].ID
+	if m.expanded[id] {
+		delete(m.expanded, id)
+	} else {
+		m.expanded[id] = true
+	}
+
+	// A collapsed answer is scrolled back into view
+	offset := m.viewport.YOffset
+	m.renderMessages()
+	m.viewport.SetYOffset(min(offset, m.messageLines[i]))
+}
+
+// expandMessages expands the answers at the given message indices, so text
+// in their collapsed part can be seen
+func (m *Model) expandMessages(indices []int) {
+	for _, i := range indices {
+		if m.messages[i].Role != "assistant" {
+			continue
+		}
+		if m.expanded == nil {
+			m.expanded = make(map[string]bool)
+		}
+		m.expanded[m.messages[i].ID] = true
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestCollapseLongAnswers(t *testing.T) {
+	lines := make([]string, 12)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+
+	m := newTabsTestModel()
+	m.keymap = DefaultKeyMap()
+	m.config = config.NewDefaultConfig()
+	m.config.UI.CollapseLines = 5
+	m.messages = []Message{
+		{ID: "q", Role: "user", Content: "question"},
+		{ID: "a", Role: "assistant", Content: strings.Join(lines, "\n")},
+	}
+	m.updateViewportContent()
+
+	content := m.viewport.View()
+	assert.Contains(t, content, "line 4")
+	assert.NotContains(t, content, "line 12")
+	assert.Contains(t, content, "▸ 7 more lines")
+
+	// z expands the answer in view, and collapses it again
+	m.currentMode = ModeScroll
+	m.previousMode = ModeInsert
+	m.viewport.GotoTop()
+	press := func() {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+		m = updated.(Model)
+	}
+	press()
+	assert.True(t, m.expanded["a"])
+	m.viewport.GotoBottom()
+	assert.Contains(t, m.viewport.View(), "line 12")
+
+	press()
+	assert.False(t, m.expanded["a"])
+
+	// Searching expands answers that match
+	m.performSearch("line 11")
+	assert.True(t, m.expanded["a"])
+	assert.Len(t, m.viewMatches, 1)
+
+	// Negative disables collapsing
+	m.config.UI.CollapseLines = -1
+	assert.Equal(t, 0, m.collapseLines())
+}
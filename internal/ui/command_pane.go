@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+const (
+	// commandPaneLines is the number of output lines shown while a command runs
+	commandPaneLines = 8
+
+	// commandPaneLimit is how much output the pane keeps, and the tail sent
+	// to the model when the command is canceled
+	commandPaneLimit = 16 * 1024
+
+	// commandPaneInterval is how often the pane is redrawn
+	commandPaneInterval = 100 * time.Millisecond
+)
+
+// commandPaneTickMsg redraws the command pane while its command runs
+type commandPaneTickMsg struct {
+	pane *commandPane
+}
+
+// commandPane shows the output of the streaming tool call (run_command or
+// docker_build) a job is running. The tool writes to it from the execution
+// goroutine.
+type commandPane struct {
+	mu       sync.Mutex
+	command  string
+	output   *tools.TailBuffer
+	cancel   context.CancelFunc
+	started  time.Time
+	running  bool
+	canceled bool
+
+	scroll int // Lines scrolled back from the end; 0 follows the output
+}
+
+// start resets the pane for a new command; cancel stops just that command
+func (p *commandPane) start(command string, cancel context.CancelFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.command = command
+	p.output = tools.NewTailBuffer(commandPaneLimit)
+	p.cancel = cancel
+	p.started = time.Now()
+	p.running = true
+	p.canceled = false
+	p.scroll = 0
+}
+
+// Write implements io.Writer for the command output
+func (p *commandPane) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	output := p.output
+	p.mu.Unlock()
+	if output == nil {
+		return len(data), nil
+	}
+	return output.Write(data)
+}
+
+// finish marks the command done and reports whether the user canceled it
+func (p *commandPane) finish() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+	p.cancel = nil
+	return p.canceled
+}
+
+// cancelCommand stops the running command, leaving the rest of the job
+// running. It reports whether a command was running.
+func (p *commandPane) cancelCommand() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running || p.cancel == nil {
+		return false
+	}
+	p.canceled = true
+	p.cancel()
+	return true
+}
+
+// isRunning reports whether the pane's command is running
+func (p *commandPane) isRunning() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
+
+// transcript is the result sent to the model for a canceled command
+func (p *commandPane) transcript() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return map[string]interface{}{
+		"command":   p.command,
+		"exit_code": -1,
+		"canceled":  true,
+		"output":    p.output.String(),
+		"truncated": p.output.Truncated(),
+		"message":   "The user canceled the command",
+	}
+}
+
+// scrollBy moves the view of the output; positive deltas go back in time
+func (p *commandPane) scrollBy(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scroll = max(p.scroll+delta, 0)
+}
+
+// commandPaneTick schedules the next redraw of pane
+func commandPaneTick(pane *commandPane) tea.Cmd {
+	return tea.Tick(commandPaneInterval, func(time.Time) tea.Msg {
+		return commandPaneTickMsg{pane: pane}
+	})
+}
+
+// startCommandPane gives the active job a pane for the streaming tool calls
+// in calls, or clears it when there are none
+func (m *Model) startCommandPane(calls []ai.ToolCall) *commandPane {
+	job := m.activeJob()
+	if job == nil {
+		return nil
+	}
+	job.command = nil
+	for _, call := range calls {
+		if tools.StreamsOutput(call.Function.Name) {
+			job.command = &commandPane{}
+			break
+		}
+	}
+	return job.command
+}
+
+// paneCommand returns the command line the pane shows for a tool call
+func paneCommand(name string, params map[string]interface{}) string {
+	if name == tools.DockerBuildToolName {
+		return strings.Join(tools.DockerBuildArgs(params), " ")
+	}
+	command, _ := params["command"].(string)
+	return command
+}
+
+// runningCommand returns the pane of the active job's running command, or nil
+func (m *Model) runningCommand() *commandPane {
+	if job := m.activeJob(); job != nil && job.command.isRunning() {
+		return job.command
+	}
+	return nil
+}
+
+// commandPaneHeight is the number of screen lines the pane takes
+func (m *Model) commandPaneHeight() int {
+	if m.runningCommand() == nil {
+		return 0
+	}
+	return commandPaneLines + 3 // Title and border
+}
+
+// renderCommandPane draws the output of the running command
+func (m *Model) renderCommandPane() string {
+	pane := m.runningCommand()
+	if pane == nil {
+		return ""
+	}
+
+	pane.mu.Lock()
+	lines := strings.Split(strings.TrimRight(pane.output.String(), "\n"), "\n")
+	pane.scroll = min(pane.scroll, max(len(lines)-commandPaneLines, 0))
+	end := len(lines) - pane.scroll
+	title := fmt.Sprintf("$ %s  (%s)", pane.command, formatDuration(time.Since(pane.started)))
+	if pane.scroll > 0 {
+		title += fmt.Sprintf("  ↑ %d lines", pane.scroll)
+	}
+	pane.mu.Unlock()
+
+	visible := lines[max(end-commandPaneLines, 0):end]
+	for len(visible) < commandPaneLines {
+		visible = append(visible, "")
+	}
+
+	width := max(m.width-2, 20)
+	body := make([]string, 0, len(visible)+1)
+	body = append(body, lipgloss.NewStyle().Bold(true).Render(truncateLine(title, width-2)))
+	for _, line := range visible {
+		body = append(body, truncateLine(line, width-2))
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("241")).
+		Width(width).
+		Render(strings.Join(body, "\n"))
+}
+
+// truncateLine cuts line to width runes
+func truncateLine(line string, width int) string {
+	runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+	if len(runes) <= width {
+		return string(runes)
+	}
+	return string(runes[:max(width-1, 0)]) + "…"
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+func TestCommandPane(t *testing.T) {
+	m := newTabsTestModel()
+	m.keymap = DefaultKeyMap()
+	m.jobs = newJobList()
+	m.jobContext("run tests")
+
+	pane := m.startCommandPane([]ai.ToolCall{toolCall("1", "read_file"), toolCall("2", tools.RunCommandToolName)})
+	require.NotNil(t, pane)
+	assert.Nil(t, m.runningCommand(), "the pane shows once the command starts")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pane.start("go test ./...", cancel)
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(pane, "line %d\n", i)
+	}
+	require.Equal(t, pane, m.runningCommand())
+	assert.Equal(t, commandPaneLines+3, m.commandPaneHeight())
+
+	// The pane follows the end of the output, and scrolls back with PgUp
+	view := m.renderCommandPane()
+	assert.Contains(t, view, "$ go test ./...")
+	assert.Contains(t, view, "line 20")
+	assert.NotContains(t, view, "line 12")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = updated.(Model)
+	view = m.renderCommandPane()
+	assert.Contains(t, view, "line 12")
+	assert.NotContains(t, view, "line 20")
+	assert.Contains(t, view, "↑ 4 lines")
+
+	// Ctrl+C cancels just the command
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updated.(Model)
+	assert.Error(t, ctx.Err())
+	assert.NotNil(t, m.activeJob(), "the job keeps running")
+	assert.Equal(t, "Command canceled", m.messages[len(m.messages)-1].Content)
+
+	assert.True(t, pane.finish())
+	assert.Nil(t, m.runningCommand())
+	transcript := pane.transcript()
+	assert.Equal(t, true, transcript["canceled"])
+	assert.Contains(t, transcript["output"], "line 1\n")
+
+	// Calls without commands have no pane
+	assert.Nil(t, m.startCommandPane([]ai.ToolCall{toolCall("3", "read_file")}))
+	assert.Nil(t, m.activeJob().command)
+}
+
+func TestPaneCommand(t *testing.T) {
+	assert.Equal(t, "go test ./...", paneCommand(tools.RunCommandToolName, map[string]interface{}{"command": "go test ./..."}))
+	assert.Equal(t, "docker build --progress=plain -t app:dev .", paneCommand(tools.DockerBuildToolName, map[string]interface{}{"tag": "app:dev"}))
+
+	m := newTabsTestModel()
+	m.jobs = newJobList()
+	m.jobContext("build image")
+	assert.NotNil(t, m.startCommandPane([]ai.ToolCall{toolCall("1", tools.DockerBuildToolName)}), "image builds stream to the pane too")
+}
@@ -0,0 +1,677 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/artifacts"
+	"github.com/common-creation/coda/internal/changes"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/explain"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/symbols"
+	"github.com/common-creation/coda/internal/tools"
+	"github.com/common-creation/coda/internal/workflow"
+)
+
+// slashCommandHelp lists the slash commands shown in the help view
+var slashCommandHelp = []string{
+	"/artifacts [all|open N]: List the session's generated artifacts, or open one",
+	"/changes [all|diff|<file>]: Show files changed by tools in this session, or their diffs",
+	"/code [ID [copy|save [path]|apply [path]|run]]: List the code blocks of answers, or copy, save, apply as a patch or run one",
+	"/continue: Finish a response that was interrupted when CODA last exited",
+	"/delete N: Delete message N from the session (an answer with its tool results), so later prompts are sent without it",
+	"/diff [split]: Compare the last regenerated answer with the one it replaced (split: side by side)",
+	"/explain <file>[:start-end] [question]: Explain a file or line range without adding it to the session",
+	"/open [N]: List the links in answers, or open link N in the browser",
+	"/persona [name|off]: List the configured personas, or switch this conversation's system prompt preset and tool rules",
+	"/ping: Check that the AI provider responds, and show its recent latency and failures",
+	"/queue [clear]: List the prompts waiting for the current response, or drop them",
+	"/redact [N [text]]: List the session's messages by number, or replace message N (or only text in it) with a placeholder",
+	"/regenerate: Send the last message again for a new answer, then compare the answers with /diff",
+	"/jobs [cancel N|all]: Show running request/tool chains, or cancel them",
+	"/models [refresh]: Show the context window, prices and retirement of the model, or refresh model metadata",
+	"/fork [N]: Fork the session into a new one (keeping the first N messages)",
+	"/sessions: Open the session picker (Enter to switch, F to fork)",
+	"/scan [gosec|osv-scanner] [fix]: Scan the workspace with gosec and osv-scanner and list the findings by severity (fix: ask to fix them)",
+	"/scratch [insert|clear|<text>]: Open or close the scratchpad, insert it into the input, clear it, or add a line to it",
+	"/seal [verify]: Seal the session as a read-only record with a checksum (later messages go to a fork), or verify the seal",
+	"/set [reasoning <level|default>]: Show request settings, or change the reasoning effort for this conversation",
+	"/stats [all]: Show tool calls, failure rates and latencies of this session (or all sessions)",
+	"/task [bug|feature|tests]: List guided tasks, or fill in the form of one to assemble a detailed prompt",
+	"/tab [new [model]|close|next|prev|N]: List tabs, open one (optionally with another model), close, or switch",
+	"/workflow [name] [key=value...]: List workflows, or run one in the current session",
+}
+
+// handleSlashCommand executes a "/command args..." line typed in the input area
+func (m *Model) handleSlashCommand(input string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(input)
+	command := strings.ToLower(fields[0])
+	args := fields[1:]
+
+	m.logger.Debug("Executing slash command", "command", command, "args", args)
+
+	// Clear input and reset cursor
+	m.currentInput = ""
+	m.cursorPosition = 0
+	m.cursorColumn = 0
+	m.inputScrollPosition = 0
+
+	// Saving or running a code block is confirmed by the next command
+	if command != "/code" {
+		m.codeActionConfirm = ""
+	}
+
+	switch command {
+	case "/artifacts":
+		m.handleArtifactsCommand(args)
+	case "/changes":
+		m.showChanges(args)
+	case "/code":
+		return m, m.handleCodeCommand(input, args)
+	case "/continue":
+		return m, m.continueInterruptedResponse()
+	case "/delete":
+		m.handleDeleteCommand(args)
+	case "/diff":
+		m.showAnswerDiff(args)
+	case "/fork":
+		upTo := 0
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				m.addSystemMessage(fmt.Sprintf("Usage: /fork [N] (N = number of messages to keep, got %q)", args[0]))
+				return m, nil
+			}
+			upTo = n
+		}
+		m.forkSession(upTo)
+	case "/open":
+		m.handleOpenCommand(args)
+	case "/persona":
+		m.handlePersonaCommand(args)
+	case "/ping":
+		return m, m.ping()
+	case "/queue":
+		m.handleQueueCommand(args)
+	case "/sessions":
+		m.openSessionPicker()
+	case "/redact":
+		m.handleRedactCommand(args, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), fields[0])))
+	case "/regenerate":
+		return m.regenerate()
+	case "/scan":
+		return m, m.handleScanCommand(args)
+	case "/scratch":
+		m.handleScratchCommand(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), fields[0])))
+	case "/task":
+		m.handleTaskCommand(args)
+	case "/seal":
+		m.handleSealCommand(args)
+	case "/set":
+		m.handleSetCommand(args)
+	case "/stats":
+		m.showToolStats(args)
+	case "/jobs":
+		m.handleJobsCommand(args)
+	case "/models":
+		return m, m.handleModelsCommand(args)
+	case "/tab", "/tabs":
+		m.handleTabCommand(args)
+	case "/explain":
+		if len(args) == 0 {
+			m.addSystemMessage("Usage: /explain <file>[:start-end] [question]")
+			return m, nil
+		}
+		return m, m.explainCode(args[0], strings.Join(args[1:], " "))
+	case "/workflow":
+		if len(args) == 0 {
+			m.listWorkflows()
+			return m, nil
+		}
+		return m, m.runWorkflow(args[0], args[1:])
+	default:
+		m.addSystemMessage(fmt.Sprintf("Unknown command: %s", command))
+	}
+
+	return m, nil
+}
+
+// continueInterruptedResponse asks the model to finish the response that was
+// cut off when CODA last exited unexpectedly
+func (m *Model) continueInterruptedResponse() tea.Cmd {
+	if m.chatHandler == nil || !m.chatHandler.HasInterruptedResponse() {
+		m.addSystemMessage("There is no interrupted response to continue")
+		return nil
+	}
+
+	m.addSystemMessage("Continuing the interrupted response...")
+	m.loading = true
+	m.loadingStart = time.Now()
+
+	handler := m.chatHandler
+	ctx := m.jobContext("Continue interrupted response")
+	return tea.Batch(m.spinner.Tick, m.tickForTokenUpdates(), func() tea.Msg {
+		response, err := handler.ContinueInterruptedResponse(ctx, nil)
+		if err != nil {
+			return errorMsg{
+				error:      err,
+				userAction: "continuing an interrupted response",
+			}
+		}
+
+		return chatResponseMsg{
+			ID:         generateMessageID(),
+			Content:    response.Content,
+			Tokens:     response.TokenCount,
+			TokenUsage: response.TokenUsage,
+			ToolCalls:  response.ToolCalls,
+
+			UsageEstimated: response.UsageEstimated,
+		}
+	})
+}
+
+// explainCode explains a file or line range with a one-off request that is
+// shown in the conversation view but not recorded in the session
+func (m *Model) explainCode(spec, question string) tea.Cmd {
+	if m.chatHandler == nil || m.config == nil {
+		m.addSystemMessage("Explain is not available without a chat handler")
+		return nil
+	}
+
+	target, err := explain.ParseTarget(spec)
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Usage: /explain <file>[:start-end] [question] (%v)", err))
+		return nil
+	}
+
+	m.addSystemMessage(fmt.Sprintf("Explaining %s...", target))
+	m.loading = true
+	m.loadingStart = time.Now()
+
+	client := m.chatHandler.AIClient()
+	modelName := m.config.AI.Model
+	ctx := m.jobContext("Explain " + target.String())
+
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		req := explain.Request{Target: target, Question: question, Root: "."}
+		if strings.HasSuffix(target.Path, ".go") {
+			if idx, err := symbols.BuildIndex("."); err == nil {
+				req.Index = idx
+			}
+		}
+
+		messages, err := explain.BuildMessages(req)
+		if err == nil {
+			var content string
+			content, err = explain.Stream(ctx, client, modelName, messages, nil)
+			if err == nil {
+				return chatResponseMsg{ID: generateMessageID(), Content: content}
+			}
+		}
+
+		return errorMsg{
+			error:      err,
+			userAction: "explaining code",
+			metadata:   map[string]interface{}{"target": target.String()},
+		}
+	})
+}
+
+// workflowDoneMsg is sent when a workflow started with /workflow finishes
+type workflowDoneMsg struct {
+	result *workflow.Result
+	err    error
+}
+
+// listWorkflows shows the workflows available to /workflow
+func (m *Model) listWorkflows() {
+	workflows, errs := workflow.List(workflow.DefaultDirs())
+
+	var sb strings.Builder
+	if len(workflows) == 0 {
+		sb.WriteString(fmt.Sprintf("No workflows found in %s", strings.Join(workflow.DefaultDirs(), ", ")))
+	} else {
+		sb.WriteString("Workflows:")
+		for _, wf := range workflows {
+			sb.WriteString(fmt.Sprintf("\n  %s (%d steps) %s", wf.Name, len(wf.Steps), wf.Description))
+		}
+	}
+	for _, err := range errs {
+		sb.WriteString(fmt.Sprintf("\n  %v", err))
+	}
+	m.addSystemMessage(sb.String())
+}
+
+// runWorkflow runs a workflow in the background on the current session.
+// Tool calls the model makes are limited to the workflow's approve_tools
+// (or all tools when auto-approve is configured), since the permit dialog
+// cannot be shown while the workflow runs.
+func (m *Model) runWorkflow(name string, pairs []string) tea.Cmd {
+	if m.chatHandler == nil || m.toolManager == nil {
+		m.addSystemMessage("Workflows are not available without a chat handler")
+		return nil
+	}
+
+	wf, err := workflow.Find(name, workflow.DefaultDirs())
+	if err != nil {
+		m.addSystemMessage(err.Error())
+		return nil
+	}
+
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			m.addSystemMessage(fmt.Sprintf("Usage: /workflow <name> [key=value...] (got %q)", pair))
+			return nil
+		}
+		vars[key] = value
+	}
+
+	autoApprove := m.config != nil && m.config.Tools.AutoApprove
+	approver := chat.NewStaticApprovalHandler(autoApprove, wf.ApproveTools)
+	opts := []chat.ToolExecutorOption{chat.WithApprovalRules(m.approvalRules), chat.WithTimeout(10 * time.Minute)}
+	if m.config != nil {
+		opts = append(opts, chat.WithWorkspace(m.config.Tools.WorkspaceRoot))
+	}
+	executor := chat.NewToolExecutor(m.toolManager, nil, security.NewDefaultValidator("."), approver, opts...)
+	engine := workflow.NewEngine(
+		workflow.AgentPrompts{Runner: chat.NewPromptRunner(m.chatHandler, executor)},
+		workflow.ExecutorTools{Executor: executor},
+		workflow.WithVars(vars),
+	)
+
+	m.addSystemMessage(fmt.Sprintf("Running workflow %s (%d steps)...", wf.Name, len(wf.Steps)))
+	m.loading = true
+	m.loadingStart = time.Now()
+
+	ctx := m.jobContext("Workflow " + wf.Name)
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		result, err := engine.Run(ctx, wf)
+		return workflowDoneMsg{result: result, err: err}
+	})
+}
+
+// finishWorkflow shows the conversation the workflow produced and its summary
+func (m *Model) finishWorkflow(msg workflowDoneMsg) {
+	if session := m.chatHandler.GetCurrentSession(); session != nil {
+		m.loadSession(session)
+	}
+	m.loading = false
+	m.finishJob()
+
+	if msg.result != nil {
+		m.addSystemMessage(strings.TrimRight(msg.result.Summary(), "\n"))
+	}
+	if msg.err != nil {
+		m.addSystemMessage(fmt.Sprintf("Workflow stopped: %v", msg.err))
+	}
+}
+
+// handleArtifactsCommand lists the artifacts saved in the current session
+// (or all sessions) and opens one by its number in the list
+func (m *Model) handleArtifactsCommand(args []string) {
+	store := artifacts.NewStore("")
+	sessionID := ""
+	if m.chatHandler != nil {
+		if session := m.chatHandler.GetCurrentSession(); session != nil {
+			sessionID = session.ID
+		}
+	}
+
+	all := len(args) > 0 && args[0] == "all"
+	list := func() ([]artifacts.Artifact, error) {
+		if all {
+			return store.ListAll()
+		}
+		return store.List(sessionID)
+	}
+
+	if len(args) > 0 && args[0] == "open" {
+		if len(args) < 2 {
+			m.addSystemMessage("Usage: /artifacts open N")
+			return
+		}
+		n, err := strconv.Atoi(args[1])
+		items, listErr := list()
+		if listErr != nil {
+			m.addSystemMessage(fmt.Sprintf("Failed to list artifacts: %v", listErr))
+			return
+		}
+		if err != nil || n < 1 || n > len(items) {
+			m.addSystemMessage(fmt.Sprintf("No artifact %q (use /artifacts to list them)", args[1]))
+			return
+		}
+		if err := artifacts.Open(items[n-1].Path); err != nil {
+			m.addSystemMessage(err.Error())
+			return
+		}
+		m.addSystemMessage(fmt.Sprintf("Opened %s", items[n-1].Path))
+		return
+	}
+
+	items, err := list()
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Failed to list artifacts: %v", err))
+		return
+	}
+	if len(items) == 0 {
+		m.addSystemMessage(fmt.Sprintf("No artifacts yet (they are saved under %s/<session>/)", store.Root()))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Artifacts:")
+	for i, a := range items {
+		sb.WriteString(fmt.Sprintf("\n  %d. %s (%d bytes)", i+1, a.Path, a.Size))
+		if a.Description != "" {
+			sb.WriteString(" - " + a.Description)
+		}
+	}
+	sb.WriteString("\nUse /artifacts open N to open one")
+	m.addSystemMessage(sb.String())
+}
+
+// showChanges shows a diffstat of the files tools changed in the current
+// session ("all" for every session), or the diffs with "diff" or a file name
+func (m *Model) showChanges(args []string) {
+	if m.changeTracker == nil {
+		m.addSystemMessage("Change tracking is not available")
+		return
+	}
+
+	session := changes.AllSessions
+	if m.chatHandler != nil {
+		if current := m.chatHandler.GetCurrentSession(); current != nil {
+			session = current.ID
+		}
+	}
+	if len(args) > 0 && args[0] == "all" {
+		session = changes.AllSessions
+		args = args[1:]
+	}
+
+	fileChanges := m.changeTracker.Changes(session)
+	if len(fileChanges) == 0 {
+		m.addSystemMessage("No files have been changed in this session")
+		return
+	}
+
+	if len(args) == 0 {
+		m.addSystemMessage("Changes:\n" + changes.DiffStat(fileChanges) + "\nUse /changes diff or /changes <file> to see the diffs")
+		return
+	}
+
+	var sb strings.Builder
+	for _, c := range fileChanges {
+		if args[0] == "diff" || c.Path == args[0] {
+			sb.WriteString(c.Diff)
+		}
+	}
+	if sb.Len() == 0 {
+		m.addSystemMessage(fmt.Sprintf("%s has not been changed in this session", args[0]))
+		return
+	}
+	m.addSystemMessage(strings.TrimRight(sb.String(), "\n"))
+}
+
+// showToolStats shows how often each tool was called in the current session
+// ("all" for every session), how often it failed and how long it took
+func (m *Model) showToolStats(args []string) {
+	if m.toolManager == nil {
+		m.addSystemMessage("Tool statistics are not available")
+		return
+	}
+
+	session, scope := tools.AllSessions, "all sessions"
+	if len(args) == 0 || args[0] != "all" {
+		session, scope = "", "this session"
+		if m.chatHandler != nil {
+			if current := m.chatHandler.GetCurrentSession(); current != nil {
+				session = current.ID
+			}
+		}
+	}
+
+	usage := m.toolManager.Stats().Usage(session)
+	if len(usage) == 0 {
+		m.addSystemMessage(fmt.Sprintf("No tools have been called in %s", scope))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Tool usage in %s:\n", scope)
+	fmt.Fprintf(&sb, "%-20s %6s %12s %9s %9s %9s %9s\n", "Tool", "Calls", "Failed", "Total", "p50", "p90", "p99")
+	for _, u := range usage {
+		failed := fmt.Sprintf("%d (%.0f%%)", u.Failures, u.FailureRate()*100)
+		fmt.Fprintf(&sb, "%-20s %6d %12s %9s %9s %9s %9s\n", truncateLine(u.Name, 20), u.Calls, failed,
+			formatDuration(u.TotalTime), formatDuration(u.P50), formatDuration(u.P90), formatDuration(u.P99))
+	}
+	m.addSystemMessage(strings.TrimRight(sb.String(), "\n"))
+}
+
+// addSystemMessage appends a local system notice to the conversation view
+func (m *Model) addSystemMessage(content string) {
+	m.messages = append(m.messages, Message{
+		ID:        generateMessageID(),
+		Content:   content,
+		Role:      "system",
+		Timestamp: time.Now(),
+	})
+	m.updateViewportContent()
+}
+
+// forkSession forks the current session, keeping the first upTo messages
+func (m *Model) forkSession(upTo int) {
+	if m.chatHandler == nil {
+		m.addSystemMessage("Cannot fork: chat handler is not available")
+		return
+	}
+
+	source := m.chatHandler.GetCurrentSession()
+	fork, err := m.chatHandler.ForkSession(upTo)
+	if err != nil {
+		m.logger.Error("Failed to fork session", "error", err)
+		m.addSystemMessage(fmt.Sprintf("Failed to fork session: %v", err))
+		return
+	}
+
+	m.loadSession(fork)
+	m.addSystemMessage(fmt.Sprintf("Forked session %s from %s (%d messages kept)",
+		shortSessionID(fork.ID), shortSessionID(source.ID), len(sessionToMessages(fork))))
+}
+
+// startNewSession clears the conversation and starts a fresh chat session
+func (m *Model) startNewSession() {
+	m.messages = make([]Message, 0)
+	m.currentInput = ""
+	m.cursorPosition = 0
+	m.cursorColumn = 0
+	m.inputScrollPosition = 0
+	m.error = nil
+	m.loading = false
+	m.lastTokenUsage = nil
+	m.lastUsageExact = false
+	m.estimatedTokens = 0
+	m.userInputTokens = 0
+	m.allowedTools = nil
+	m.ruleMatches = nil
+	// Create a new session in chat handler
+	if m.chatHandler != nil {
+		if err := m.chatHandler.CreateNewSession(); err != nil {
+			m.logger.Error("Failed to create new session", "error", err)
+		}
+	}
+	// Update viewport to show welcome message
+	m.updateViewportContent()
+}
+
+// loadSession replaces the conversation view with the messages of a session
+func (m *Model) loadSession(session *chat.Session) {
+	m.messages = sessionToMessages(session)
+	m.error = nil
+	m.loading = false
+	m.lastTokenUsage = nil
+	m.lastUsageExact = false
+	m.estimatedTokens = 0
+	m.userInputTokens = 0
+	m.allowedTools = nil
+	m.ruleMatches = nil
+	m.updateViewportContent()
+}
+
+// sessionToMessages converts stored session messages into display messages
+func sessionToMessages(session *chat.Session) []Message {
+	messages := make([]Message, 0, len(session.Messages))
+	for i, msg := range session.Messages {
+		role := msg.Role
+		content := msg.Content
+
+		switch {
+		case role == ai.RoleSystem:
+			// System prompts are not part of the visible conversation
+			continue
+		case role == ai.RoleUser && strings.HasPrefix(content, "TOOL_RESULT["):
+			role = "tool"
+			content = strings.TrimPrefix(content, "TOOL_RESULT")
+			if len([]rune(content)) > 200 {
+				content = truncateRunes(content, 200) + "..."
+			}
+		}
+
+		messages = append(messages, Message{
+			ID:        fmt.Sprintf("%s_%d", session.ID, i),
+			Content:   content,
+			Role:      role,
+			Timestamp: session.LastActive,
+		})
+	}
+	return messages
+}
+
+// shortSessionID returns the first eight characters of a session ID
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// openSessionPicker switches to the session picker mode
+func (m *Model) openSessionPicker() {
+	if m.chatHandler == nil {
+		m.addSystemMessage("No sessions available")
+		return
+	}
+
+	m.pickerSessions = m.chatHandler.ListSessions()
+	if len(m.pickerSessions) == 0 {
+		m.addSystemMessage("No sessions available")
+		return
+	}
+
+	m.selectedPickerSession = 0
+	if current := m.chatHandler.GetCurrentSession(); current != nil {
+		for i, session := range m.pickerSessions {
+			if session.ID == current.ID {
+				m.selectedPickerSession = i
+				break
+			}
+		}
+	}
+
+	if m.currentMode != ModeSessions {
+		m.previousMode = m.currentMode
+		m.currentMode = ModeSessions
+	}
+}
+
+// closeSessionPicker leaves the session picker mode
+func (m *Model) closeSessionPicker() {
+	m.pickerSessions = nil
+	m.selectedPickerSession = 0
+	m.currentMode = m.previousMode
+}
+
+// handleSessionPickerKeys handles keys while the session picker is open
+func (m Model) handleSessionPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c", "q":
+		m.closeSessionPicker()
+	case "up", "k":
+		if m.selectedPickerSession > 0 {
+			m.selectedPickerSession--
+		}
+	case "down", "j":
+		if m.selectedPickerSession < len(m.pickerSessions)-1 {
+			m.selectedPickerSession++
+		}
+	case "enter":
+		selected := m.pickerSessions[m.selectedPickerSession]
+		m.closeSessionPicker()
+		if err := m.chatHandler.SwitchSession(selected.ID); err != nil {
+			m.addSystemMessage(fmt.Sprintf("Failed to switch session: %v", err))
+			return m, nil
+		}
+		m.loadSession(selected)
+	case "f", "F":
+		selected := m.pickerSessions[m.selectedPickerSession]
+		m.closeSessionPicker()
+		if err := m.chatHandler.SwitchSession(selected.ID); err != nil {
+			m.addSystemMessage(fmt.Sprintf("Failed to switch session: %v", err))
+			return m, nil
+		}
+		m.forkSession(0)
+	}
+
+	return m, nil
+}
+
+// renderSessionPicker renders the list of sessions for selection
+func (m Model) renderSessionPicker() string {
+	var content strings.Builder
+	content.WriteString("Sessions\n\n")
+
+	var currentID string
+	if m.chatHandler != nil {
+		if current := m.chatHandler.GetCurrentSession(); current != nil {
+			currentID = current.ID
+		}
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)
+	for i, session := range m.pickerSessions {
+		line := fmt.Sprintf("%s  %s  %3d messages",
+			shortSessionID(session.ID),
+			session.LastActive.Format("01-02 15:04"),
+			len(session.Messages))
+		if parent, ok := session.Context["forked_from"].(string); ok {
+			line += fmt.Sprintf("  (fork of %s)", shortSessionID(parent))
+		}
+		if session.ID == currentID {
+			line += "  *"
+		}
+
+		if i == m.selectedPickerSession {
+			content.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			content.WriteString("  " + line)
+		}
+		content.WriteString("\n")
+	}
+
+	contentWidth := m.width - 4
+	if contentWidth < 40 {
+		contentWidth = 40
+	}
+
+	return m.styles.UserInput.Width(contentWidth).Render(strings.TrimRight(content.String(), "\n"))
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+func TestSessionToMessages(t *testing.T) {
+	session := &chat.Session{
+		ID:         "session-1",
+		LastActive: time.Now(),
+		Messages: []ai.Message{
+			{Role: ai.RoleSystem, Content: "system prompt"},
+			{Role: ai.RoleUser, Content: "hello"},
+			{Role: ai.RoleAssistant, Content: "hi there"},
+			{Role: ai.RoleUser, Content: "TOOL_RESULT[read_file]: contents"},
+		},
+	}
+
+	messages := sessionToMessages(session)
+
+	assert.Len(t, messages, 3)
+	assert.Equal(t, "user", messages[0].Role)
+	assert.Equal(t, "hello", messages[0].Content)
+	assert.Equal(t, "assistant", messages[1].Role)
+	assert.Equal(t, "tool", messages[2].Role)
+	assert.Equal(t, "[read_file]: contents", messages[2].Content)
+
+	session.Messages = []ai.Message{{Role: ai.RoleUser, Content: "TOOL_RESULT[read_file]: " + strings.Repeat("é", 300)}}
+	messages = sessionToMessages(session)
+	assert.True(t, utf8.ValidString(messages[0].Content))
+	assert.Equal(t, 203, utf8.RuneCountInString(messages[0].Content))
+}
+
+func TestShowToolStats(t *testing.T) {
+	m := newTabsTestModel()
+	m.toolManager = tools.NewManager(nil, nil)
+
+	m.showToolStats(nil)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "No tools have been called in this session")
+
+	m.toolManager.Stats().Record("", "run_command", 2*time.Second, true)
+	m.toolManager.Stats().Record("", "run_command", time.Second, false)
+	m.showToolStats(nil)
+	content := m.messages[len(m.messages)-1].Content
+	assert.Contains(t, content, "Tool usage in this session")
+	assert.Regexp(t, `run_command\s+2\s+1 \(50%\)\s+3\.0s`, content)
+
+	m.showToolStats([]string{"all"})
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Tool usage in all sessions")
+}
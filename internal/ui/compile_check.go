@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+// compileGate tracks the compile check of the current turn: how many checks
+// failed in a row, the output of the last failing one, and whether it was
+// already sent back to the model
+type compileGate struct {
+	failures int
+	output   string
+	nudged   bool
+}
+
+// compileCheck returns the configured compile check while its retries are
+// not used up, or nil
+func (m *Model) compileCheck() *tools.CompileCheck {
+	if m.config == nil || m.config.Tools.CompileCheck.Command == "" {
+		return nil
+	}
+	if m.gate.failures >= m.config.Tools.CompileCheck.Retries() {
+		return nil
+	}
+	check := m.config.Tools.CompileCheck
+	return &tools.CompileCheck{
+		Command: check.Command,
+		Dir:     m.config.Tools.WorkspaceRoot,
+		Timeout: time.Duration(check.TimeoutSeconds) * time.Second,
+	}
+}
+
+// runCompileCheck runs check when a call in results changed files and
+// returns its result for the model
+func runCompileCheck(ctx context.Context, check *tools.CompileCheck, results []chat.ToolResult) (chat.ToolResult, bool) {
+	changed := false
+	for _, result := range results {
+		if result.Error == nil && tools.ChangesFiles(result.ToolName, result.Result) {
+			changed = true
+			break
+		}
+	}
+	if check == nil || !changed {
+		return chat.ToolResult{}, false
+	}
+
+	start := time.Now()
+	passed, output, err := check.Run(ctx)
+	info := map[string]interface{}{
+		"command": check.Command,
+		"passed":  passed,
+	}
+	if !passed && err == nil {
+		info["output"] = output
+		info["message"] = "The files you changed do not build; fix these errors"
+	}
+	return chat.ToolResult{
+		ToolName:   tools.CompileCheckName,
+		Result:     info,
+		Error:      err,
+		ExecutedAt: time.Now(),
+		Duration:   time.Since(start),
+	}, true
+}
+
+// noteCompileCheck updates the gate with the compile check among results
+func (m *Model) noteCompileCheck(results []chat.ToolResult) {
+	for _, result := range results {
+		if result.ToolName != tools.CompileCheckName || result.Error != nil {
+			continue
+		}
+		info, _ := result.Result.(map[string]interface{})
+		if passed, _ := info["passed"].(bool); passed {
+			m.gate = compileGate{}
+			return
+		}
+		output, _ := info["output"].(string)
+		m.gate.failures++
+		m.gate.output = output
+		m.gate.nudged = false
+		if retries := m.config.Tools.CompileCheck.Retries(); m.gate.failures >= retries {
+			m.addSystemMessage(fmt.Sprintf("The compile check still fails after %d attempts; it is paused until your next message", retries))
+		}
+		return
+	}
+}
+
+// sendCompileFailure asks the model to fix a failing compile check when it
+// ended its turn without doing so. It returns nil when there is nothing to
+// send or the failure was already sent.
+func (m *Model) sendCompileFailure() tea.Cmd {
+	if m.gate.output == "" || m.gate.nudged || m.chatHandler == nil || m.compileCheck() == nil {
+		return nil
+	}
+	m.gate.nudged = true
+
+	command := m.config.Tools.CompileCheck.Command
+	prompt := fmt.Sprintf("The compile check `%s` still fails after your changes:\n\n```\n%s\n```\n\nFix these errors.", command, strings.TrimSpace(m.gate.output))
+	m.addSystemMessage(fmt.Sprintf("The compile check failed; asking to fix it (attempt %d of %d)", m.gate.failures, m.config.Tools.CompileCheck.Retries()))
+
+	m.loading = true
+	m.loadingStart = time.Now()
+	return tea.Batch(
+		m.spinner.Tick,
+		m.streamChatResponse(m.jobContext("Fix compile errors"), prompt),
+		m.tickForTokenUpdates(),
+	)
+}
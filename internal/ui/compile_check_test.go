@@ -0,0 +1,71 @@
+//go:build !windows
+
+package ui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+func TestCompileCheckGate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	cfg := config.NewDefaultConfig()
+	cfg.Tools.WorkspaceRoot = dir
+	cfg.Tools.CompileCheck = config.CompileCheckConfig{Command: "test -f ok || { echo 'main.go:3: undefined: x'; exit 1; }", MaxRetries: 2}
+
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), cfg, nil)
+	defer handler.Close()
+	m := newTabsTestModel()
+	m.config = cfg
+	m.chatHandler = handler
+	lastMessage := func() string { return m.messages[len(m.messages)-1].Content }
+
+	wrote := []chat.ToolResult{{ToolName: "write_file", Result: map[string]interface{}{"path": "main.go"}}}
+	read := []chat.ToolResult{{ToolName: "read_file", Result: "package main"}}
+
+	// Only batches that change files are checked
+	_, ok := runCompileCheck(context.Background(), m.compileCheck(), read)
+	assert.False(t, ok)
+
+	result, ok := runCompileCheck(context.Background(), m.compileCheck(), wrote)
+	require.True(t, ok)
+	assert.Equal(t, tools.CompileCheckName, result.ToolName)
+	info := result.Result.(map[string]interface{})
+	assert.Equal(t, false, info["passed"])
+	assert.Equal(t, "main.go:3: undefined: x\n", info["output"])
+	assert.Equal(t, "[compile_check] ❌ test -f ok || { echo 'main.go:3: undefined: x'; exit 1; } fails; the errors were sent to the model", m.getToolResultSummary(result))
+
+	// A turn that ends with the build failing sends the errors back once
+	m.noteCompileCheck([]chat.ToolResult{result})
+	assert.Equal(t, 1, m.gate.failures)
+	require.NotNil(t, m.sendCompileFailure())
+	assert.Equal(t, "The compile check failed; asking to fix it (attempt 1 of 2)", lastMessage())
+	assert.Nil(t, m.sendCompileFailure(), "already sent")
+	m.finishJob()
+	m.loading = false
+
+	// The check pauses once the retries are used up
+	m.noteCompileCheck([]chat.ToolResult{result})
+	assert.Equal(t, "The compile check still fails after 2 attempts; it is paused until your next message", lastMessage())
+	assert.Nil(t, m.compileCheck())
+	assert.Nil(t, m.sendCompileFailure())
+
+	// A passing check resets the gate
+	m.gate = compileGate{failures: 1, output: "error"}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ok"), nil, 0644))
+	result, ok = runCompileCheck(context.Background(), m.compileCheck(), wrote)
+	require.True(t, ok)
+	m.noteCompileCheck([]chat.ToolResult{result})
+	assert.Equal(t, compileGate{}, m.gate)
+}
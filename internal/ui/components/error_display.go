@@ -13,10 +13,13 @@ import (
 
 // ErrorDisplay provides user-friendly error display functionality.
 type ErrorDisplay struct {
-	handler      *errors.ErrorHandler
-	currentError error
-	showDetails  bool
-	styles       ErrorStyles
+	handler        *errors.ErrorHandler
+	currentError   error
+	showDetails    bool
+	styles         ErrorStyles
+	remediation    errors.Remediation
+	selectedAction int
+	shownAt        time.Time
 }
 
 // ErrorStyles defines the styling for error display components.
@@ -26,6 +29,9 @@ type ErrorStyles struct {
 	ErrorMessage lipgloss.Style
 	ErrorDetail  lipgloss.Style
 	ActionHint   lipgloss.Style
+	Action       lipgloss.Style
+	ActiveAction lipgloss.Style
+	Countdown    lipgloss.Style
 	Timestamp    lipgloss.Style
 }
 
@@ -68,6 +74,19 @@ func DefaultErrorStyles() ErrorStyles {
 			Bold(true).
 			Margin(1, 0, 0, 0),
 
+		Action: lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(lipgloss.Color("7")),
+
+		ActiveAction: lipgloss.NewStyle().
+			Padding(0, 1).
+			Bold(true).
+			Reverse(true),
+
+		Countdown: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Bold(true),
+
 		Timestamp: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8")).
 			Align(lipgloss.Right),
@@ -77,6 +96,49 @@ func DefaultErrorStyles() ErrorStyles {
 // SetError sets the current error to display.
 func (e *ErrorDisplay) SetError(err error) {
 	e.currentError = err
+	e.selectedAction = 0
+	e.shownAt = time.Now()
+	e.remediation = e.handler.Remediation(err)
+}
+
+// Remediation returns the recovery suggestions for the current error.
+func (e *ErrorDisplay) Remediation() errors.Remediation {
+	return e.remediation
+}
+
+// SelectNextAction moves the action selection to the right.
+func (e *ErrorDisplay) SelectNextAction() {
+	if len(e.remediation.Actions) > 0 {
+		e.selectedAction = (e.selectedAction + 1) % len(e.remediation.Actions)
+	}
+}
+
+// SelectPrevAction moves the action selection to the left.
+func (e *ErrorDisplay) SelectPrevAction() {
+	if n := len(e.remediation.Actions); n > 0 {
+		e.selectedAction = (e.selectedAction - 1 + n) % n
+	}
+}
+
+// SelectedAction returns the currently highlighted remediation action.
+func (e *ErrorDisplay) SelectedAction() (errors.RemediationAction, bool) {
+	if e.selectedAction < 0 || e.selectedAction >= len(e.remediation.Actions) {
+		return errors.RemediationAction{}, false
+	}
+	return e.remediation.Actions[e.selectedAction], true
+}
+
+// RetryRemaining returns the time left until the automatic retry, or zero
+// when the current error is not retried automatically.
+func (e *ErrorDisplay) RetryRemaining() time.Duration {
+	if e.currentError == nil || e.remediation.RetryAfter <= 0 {
+		return 0
+	}
+	remaining := e.remediation.RetryAfter - time.Since(e.shownAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
 }
 
 // ToggleDetails toggles the display of error details.
@@ -111,6 +173,18 @@ func (e *ErrorDisplay) Render(width int) string {
 		content.WriteString("\n" + hint + "\n")
 	}
 
+	// Automatic retry countdown
+	if e.remediation.RetryAfter > 0 {
+		remaining := e.RetryRemaining()
+		countdown := e.styles.Countdown.Render(fmt.Sprintf("⏳ %d秒後に自動で再試行します", int(remaining.Seconds()+0.999)))
+		content.WriteString("\n" + countdown + "\n")
+	}
+
+	// Selectable remediation actions
+	if actions := e.renderActions(); actions != "" {
+		content.WriteString("\n" + actions + "\n")
+	}
+
 	// Details (if requested)
 	if e.showDetails {
 		details := e.getErrorDetails(e.currentError)
@@ -134,12 +208,39 @@ func (e *ErrorDisplay) Render(width int) string {
 	return errorBox
 }
 
+// renderActions renders the remediation actions as a row of buttons.
+func (e *ErrorDisplay) renderActions() string {
+	if len(e.remediation.Actions) == 0 {
+		return ""
+	}
+
+	buttons := make([]string, 0, len(e.remediation.Actions))
+	for i, action := range e.remediation.Actions {
+		label := action.Label
+		if action.Kind == errors.ActionShowCommand && action.Command != "" && i == e.selectedAction {
+			label = fmt.Sprintf("%s: %s", action.Label, action.Command)
+		}
+		if i == e.selectedAction {
+			buttons = append(buttons, e.styles.ActiveAction.Render(label))
+		} else {
+			buttons = append(buttons, e.styles.Action.Render(label))
+		}
+	}
+
+	return strings.Join(buttons, " ")
+}
+
 // getActionHint returns appropriate action hints based on error type.
 func (e *ErrorDisplay) getActionHint(err error) string {
 	if err == nil {
 		return ""
 	}
 
+	// Prefer the concrete remediation hint for this error
+	if e.remediation.Hint != "" {
+		return e.remediation.Hint
+	}
+
 	category := e.handler.ClassifyError(err)
 
 	switch category {
@@ -185,7 +286,9 @@ func (e *ErrorDisplay) getErrorDetails(err error) string {
 // getInstructions returns user instructions for handling the error.
 func (e *ErrorDisplay) getInstructions() string {
 	instructions := []string{
-		"Enter: エラーを閉じる",
+		"←/→: 操作を選択",
+		"Enter: 実行",
+		"Esc: エラーを閉じる",
 		"r: 再試行",
 	}
 
@@ -0,0 +1,74 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorDisplay_Remediation(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		firstAction errors.ActionKind
+		autoRetry   bool
+	}{
+		{
+			name:        "authentication suggests config command",
+			err:         ai.NewError(ai.ErrTypeAuthentication, "invalid api key"),
+			firstAction: errors.ActionShowCommand,
+		},
+		{
+			name:        "rate limit retries automatically",
+			err:         ai.NewError(ai.ErrTypeRateLimit, "too many requests"),
+			firstAction: errors.ActionRetry,
+			autoRetry:   true,
+		},
+		{
+			name:        "context length offers new session",
+			err:         ai.NewError(ai.ErrTypeContextLength, "context too long"),
+			firstAction: errors.ActionNewSession,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := NewErrorDisplay(nil)
+			display.SetError(tt.err)
+
+			remediation := display.Remediation()
+			assert.NotEmpty(t, remediation.Hint)
+
+			action, ok := display.SelectedAction()
+			assert.True(t, ok)
+			assert.Equal(t, tt.firstAction, action.Kind)
+			assert.Equal(t, tt.autoRetry, display.RetryRemaining() > 0)
+			assert.Contains(t, display.Render(400), remediation.Hint)
+		})
+	}
+}
+
+func TestErrorDisplay_ActionSelectionWraps(t *testing.T) {
+	display := NewErrorDisplay(nil)
+	display.SetError(ai.NewError(ai.ErrTypeServerError, "boom"))
+
+	count := len(display.Remediation().Actions)
+	assert.Greater(t, count, 1)
+
+	display.SelectPrevAction()
+	action, _ := display.SelectedAction()
+	assert.Equal(t, display.Remediation().Actions[count-1], action)
+
+	display.SelectNextAction()
+	action, _ = display.SelectedAction()
+	assert.Equal(t, display.Remediation().Actions[0], action)
+}
+
+func TestRetryAfterHonoursDetail(t *testing.T) {
+	err := ai.NewError(ai.ErrTypeRateLimit, "slow down").WithDetail("retry_after", 5)
+	assert.Equal(t, 5*time.Second, errors.RetryAfter(err))
+	assert.Equal(t, errors.DefaultRateLimitWait, errors.RetryAfter(ai.NewError(ai.ErrTypeRateLimit, "slow down")))
+}
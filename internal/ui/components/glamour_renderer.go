@@ -0,0 +1,95 @@
+package components
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// ContentRenderer renders markdown for the terminal, reflowing it to the
+// width set with SetMaxWidth
+type ContentRenderer interface {
+	Render(markdown string) string
+	SetMaxWidth(width int)
+}
+
+// glamourThemeStyles maps CODA themes to the glamour styles that suit them
+var glamourThemeStyles = map[string]string{
+	"default": "dark",
+	"dark":    "dark",
+	"light":   "light",
+}
+
+// GlamourStyleForTheme returns the glamour style used for a CODA theme
+func GlamourStyleForTheme(theme string) string {
+	if style, ok := glamourThemeStyles[theme]; ok {
+		return style
+	}
+	return "dark"
+}
+
+// GlamourRenderer renders markdown with glamour. Output glamour fails on is
+// rendered by the fallback renderer instead.
+type GlamourRenderer struct {
+	style    string
+	width    int
+	fallback ContentRenderer
+
+	mu       sync.Mutex
+	renderer *glamour.TermRenderer
+}
+
+// NewGlamourRenderer creates a renderer for a glamour style name or the path
+// of a JSON style file
+func NewGlamourRenderer(style string, fallback ContentRenderer) *GlamourRenderer {
+	return &GlamourRenderer{
+		style:    style,
+		width:    80,
+		fallback: fallback,
+	}
+}
+
+// SetMaxWidth sets the width text is wrapped at. Changing it rebuilds the
+// glamour renderer on the next Render.
+func (r *GlamourRenderer) SetMaxWidth(width int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if width != r.width {
+		r.width = width
+		r.renderer = nil
+	}
+	if r.fallback != nil {
+		r.fallback.SetMaxWidth(width)
+	}
+}
+
+// Render renders markdown content to styled terminal output
+func (r *GlamourRenderer) Render(markdown string) string {
+	if strings.TrimSpace(markdown) == "" {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.renderer == nil {
+		renderer, err := glamour.NewTermRenderer(
+			glamour.WithStylePath(r.style),
+			glamour.WithWordWrap(r.width),
+		)
+		if err == nil {
+			r.renderer = renderer
+		}
+	}
+	if r.renderer != nil {
+		if out, err := r.renderer.Render(markdown); err == nil {
+			return strings.Trim(out, "\n")
+		}
+	}
+	if r.fallback != nil {
+		return r.fallback.Render(markdown)
+	}
+	return markdown
+}
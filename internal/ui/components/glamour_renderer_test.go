@@ -0,0 +1,38 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlamourRendererReflows(t *testing.T) {
+	r := NewGlamourRenderer("ascii", nil)
+	text := strings.Repeat("word ", 30)
+
+	r.SetMaxWidth(40)
+	narrow := r.Render(text)
+	r.SetMaxWidth(100)
+	wide := r.Render(text)
+
+	assert.Contains(t, narrow, "word")
+	for _, line := range strings.Split(narrow, "\n") {
+		assert.LessOrEqual(t, lipgloss.Width(line), 40)
+	}
+	assert.Less(t, strings.Count(wide, "\n"), strings.Count(narrow, "\n"))
+}
+
+func TestGlamourRendererFallback(t *testing.T) {
+	fallback := newTestMarkdownRenderer()
+	r := NewGlamourRenderer("/no/such/style.json", fallback)
+
+	assert.Equal(t, fallback.Render("- [x] done"), r.Render("- [x] done"))
+	assert.Empty(t, r.Render("  "))
+}
+
+func TestGlamourStyleForTheme(t *testing.T) {
+	assert.Equal(t, "light", GlamourStyleForTheme("light"))
+	assert.Equal(t, "dark", GlamourStyleForTheme("unknown"))
+}
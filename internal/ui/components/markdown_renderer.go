@@ -17,6 +17,7 @@ type MarkdownRenderer struct {
 	preserveNewlines bool
 	listDepth        int
 	quoteDepth       int
+	footnotes        map[string]int // Footnote numbers by label, for the current Render
 }
 
 // MarkdownElement represents a parsed markdown element
@@ -27,8 +28,19 @@ type MarkdownElement struct {
 	Language string
 	Items    []string
 	Ordered  bool
+	Rows     [][]string       // Table rows, the first being the header
+	Align    []TableAlignment // Table column alignment
 }
 
+// TableAlignment is the alignment of a table column
+type TableAlignment int
+
+const (
+	AlignLeft TableAlignment = iota
+	AlignCenter
+	AlignRight
+)
+
 // ElementType represents different markdown element types
 type ElementType int
 
@@ -40,6 +52,16 @@ const (
 	ElementQuote
 	ElementHorizontalRule
 	ElementLineBreak
+	ElementTable
+	ElementFootnotes
+)
+
+var (
+	tableDelimiterRegex = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	footnoteDefRegex    = regexp.MustCompile(`^\[\^([^\]]+)\]:\s*(.*)$`)
+	footnoteRefRegex    = regexp.MustCompile(`\[\^([^\]]+)\]`)
+	strikethroughRegex  = regexp.MustCompile(`~~([^~]+)~~`)
+	taskListItemRegex   = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
 )
 
 // NewMarkdownRenderer creates a new markdown renderer
@@ -69,18 +91,48 @@ func (r *MarkdownRenderer) Render(markdown string) string {
 	}
 
 	elements := r.parseMarkdown(markdown)
+
+	// Footnotes are numbered in the order they are defined
+	r.footnotes = make(map[string]int)
+	for _, element := range elements {
+		if element.Type == ElementFootnotes {
+			for i, row := range element.Rows {
+				r.footnotes[row[0]] = i + 1
+			}
+		}
+	}
 	return r.renderElements(elements)
 }
 
 // parseMarkdown parses markdown text into elements
 func (r *MarkdownRenderer) parseMarkdown(markdown string) []MarkdownElement {
 	var elements []MarkdownElement
+	var footnotes [][]string
 	lines := strings.Split(markdown, "\n")
 	i := 0
 
 	for i < len(lines) {
 		line := lines[i]
 
+		// Footnote definitions are collected and rendered at the end
+		if matches := footnoteDefRegex.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+			footnotes = append(footnotes, []string{matches[1], matches[2]})
+			i++
+			continue
+		}
+
+		// Check for tables (a header row followed by a delimiter row)
+		if r.isTableStart(lines[i:]) {
+			rows, align, consumed := r.parseTable(lines[i:])
+			elements = append(elements, MarkdownElement{
+				Type:  ElementTable,
+				Rows:  rows,
+				Align: align,
+			})
+			i += consumed
+			continue
+		}
+
 		// Skip empty lines (will be handled as line breaks)
 		if strings.TrimSpace(line) == "" {
 			if len(elements) > 0 && elements[len(elements)-1].Type != ElementLineBreak {
@@ -156,6 +208,10 @@ func (r *MarkdownRenderer) parseMarkdown(markdown string) []MarkdownElement {
 		i += consumed
 	}
 
+	if len(footnotes) > 0 {
+		elements = append(elements, MarkdownElement{Type: ElementFootnotes, Rows: footnotes})
+	}
+
 	return elements
 }
 
@@ -196,6 +252,10 @@ func (r *MarkdownRenderer) renderElement(element MarkdownElement) string {
 		return r.renderQuote(element.Content)
 	case ElementHorizontalRule:
 		return r.renderHorizontalRule()
+	case ElementTable:
+		return r.renderTable(element.Rows, element.Align)
+	case ElementFootnotes:
+		return r.renderFootnotes(element.Rows)
 	case ElementLineBreak:
 		return "\n"
 	default:
@@ -286,9 +346,18 @@ func (r *MarkdownRenderer) renderList(items []string, ordered bool) string {
 			bullet = "• "
 		}
 
+		// Task list items show a checkbox instead of the bullet
+		if matches := taskListItemRegex.FindStringSubmatch(item); matches != nil {
+			bullet = "☐ "
+			if matches[1] != " " {
+				bullet = "☑ "
+			}
+			item = matches[2]
+		}
+
 		bulletStyle := r.styles.Bold.Foreground(r.styles.Colors.Primary)
 		itemContent := r.renderInlineElements(item)
-		itemContent = r.wrapText(itemContent, r.maxWidth-len(bullet))
+		itemContent = r.wrapText(itemContent, r.maxWidth-lipgloss.Width(bullet))
 
 		// Handle multi-line items
 		lines := strings.Split(itemContent, "\n")
@@ -296,7 +365,7 @@ func (r *MarkdownRenderer) renderList(items []string, ordered bool) string {
 			if j == 0 {
 				result.WriteString(bulletStyle.Render(bullet) + line + "\n")
 			} else {
-				result.WriteString(strings.Repeat(" ", len(bullet)) + line + "\n")
+				result.WriteString(strings.Repeat(" ", lipgloss.Width(bullet)) + line + "\n")
 			}
 		}
 	}
@@ -375,6 +444,20 @@ func (r *MarkdownRenderer) renderInlineElements(content string) string {
 		return r.styles.Code.Render(code)
 	})
 
+	// Strikethrough (~~text~~)
+	content = strikethroughRegex.ReplaceAllStringFunc(content, func(match string) string {
+		return lipgloss.NewStyle().Strikethrough(true).Render(strings.Trim(match, "~"))
+	})
+
+	// Footnote references ([^label])
+	content = footnoteRefRegex.ReplaceAllStringFunc(content, func(match string) string {
+		label := footnoteRefRegex.FindStringSubmatch(match)[1]
+		if n, ok := r.footnotes[label]; ok {
+			label = fmt.Sprint(n)
+		}
+		return r.styles.Muted.Render("[" + label + "]")
+	})
+
 	// Links [text](url) - just show the text
 	linkRegex := regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
 	content = linkRegex.ReplaceAllStringFunc(content, func(match string) string {
@@ -610,7 +693,8 @@ func (r *MarkdownRenderer) parseParagraph(lines []string) (string, int) {
 
 		// Stop at special markdown elements
 		if r.isHorizontalRule(line) || r.isList(line) || strings.HasPrefix(trimmed, "#") ||
-			strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "```") {
+			strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "```") ||
+			footnoteDefRegex.MatchString(trimmed) || (i > 0 && r.isTableStart(lines[i:])) {
 			break
 		}
 
@@ -637,6 +721,134 @@ func (r *MarkdownRenderer) isHorizontalRule(line string) bool {
 		strings.Count(trimmed, "_") >= 3 && strings.ReplaceAll(trimmed, "_", "") == ""
 }
 
+// isTableStart checks if lines start with a table header and delimiter row
+func (r *MarkdownRenderer) isTableStart(lines []string) bool {
+	if len(lines) < 2 || !strings.Contains(lines[0], "|") {
+		return false
+	}
+	delimiter := strings.TrimSpace(lines[1])
+	return strings.Contains(delimiter, "-") && tableDelimiterRegex.MatchString(delimiter) &&
+		len(splitTableRow(lines[0])) == len(splitTableRow(delimiter))
+}
+
+// parseTable parses a table and returns its rows, column alignment, and
+// lines consumed
+func (r *MarkdownRenderer) parseTable(lines []string) ([][]string, []TableAlignment, int) {
+	header := splitTableRow(lines[0])
+	align := make([]TableAlignment, len(header))
+	for i, cell := range splitTableRow(lines[1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			align[i] = AlignCenter
+		case right:
+			align[i] = AlignRight
+		}
+	}
+
+	rows := [][]string{header}
+	consumed := 2
+	for _, line := range lines[2:] {
+		if strings.TrimSpace(line) == "" || !strings.Contains(line, "|") {
+			break
+		}
+		// Rows are padded or cut to the header's column count
+		row := make([]string, len(header))
+		copy(row, splitTableRow(line))
+		rows = append(rows, row)
+		consumed++
+	}
+
+	return rows, align, consumed
+}
+
+// splitTableRow splits a table row into trimmed cells
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// renderTable renders a table with borders and aligned columns
+func (r *MarkdownRenderer) renderTable(rows [][]string, align []TableAlignment) string {
+	rendered := make([][]string, len(rows))
+	widths := make([]int, len(align))
+	for i, row := range rows {
+		rendered[i] = make([]string, len(row))
+		for j, cell := range row {
+			cell = r.renderInlineElements(cell)
+			if i == 0 {
+				cell = r.styles.Bold.Render(cell)
+			}
+			rendered[i][j] = cell
+			widths[j] = max(widths[j], lipgloss.Width(cell))
+		}
+	}
+
+	borderStyle := r.styles.Muted.Foreground(r.styles.Colors.Border)
+	rule := func(left, middle, right string) string {
+		parts := make([]string, len(widths))
+		for j, width := range widths {
+			parts[j] = strings.Repeat("─", width+2)
+		}
+		return borderStyle.Render(left+strings.Join(parts, middle)+right) + "\n"
+	}
+
+	var result strings.Builder
+	result.WriteString(rule("┌", "┬", "┐"))
+	for i, row := range rendered {
+		result.WriteString(borderStyle.Render("│"))
+		for j, cell := range row {
+			result.WriteString(" " + alignCell(cell, widths[j], align[j]) + " ")
+			result.WriteString(borderStyle.Render("│"))
+		}
+		result.WriteString("\n")
+		if i == 0 {
+			result.WriteString(rule("├", "┼", "┤"))
+		}
+	}
+	result.WriteString(rule("└", "┴", "┘"))
+
+	return result.String()
+}
+
+// alignCell pads a rendered cell to width
+func alignCell(cell string, width int, align TableAlignment) string {
+	padding := width - lipgloss.Width(cell)
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", padding) + cell
+	case AlignCenter:
+		return strings.Repeat(" ", padding/2) + cell + strings.Repeat(" ", padding-padding/2)
+	default:
+		return cell + strings.Repeat(" ", padding)
+	}
+}
+
+// renderFootnotes renders the footnote definitions as a numbered list
+func (r *MarkdownRenderer) renderFootnotes(footnotes [][]string) string {
+	var result strings.Builder
+	result.WriteString(r.styles.Muted.Render(strings.Repeat("─", min(20, r.maxWidth))) + "\n")
+	for i, footnote := range footnotes {
+		label := fmt.Sprintf("[%d] ", i+1)
+		text := r.wrapText(r.renderInlineElements(footnote[1]), r.maxWidth-len(label))
+		for j, line := range strings.Split(text, "\n") {
+			if j == 0 {
+				result.WriteString(r.styles.Muted.Render(label) + line + "\n")
+			} else {
+				result.WriteString(strings.Repeat(" ", len(label)) + line + "\n")
+			}
+		}
+	}
+	return result.String()
+}
+
 // Helper function
 func max(a, b int) int {
 	if a > b {
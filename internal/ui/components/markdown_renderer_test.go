@@ -0,0 +1,128 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/styles"
+)
+
+func newTestMarkdownRenderer() *MarkdownRenderer {
+	return NewMarkdownRenderer(styles.GetTheme("default").GetStyles(), nil)
+}
+
+func TestRenderTable(t *testing.T) {
+	r := newTestMarkdownRenderer()
+
+	out := r.Render("Results:\n| Name | Count | Status |\n|:-----|------:|:------:|\n| go | 7 | ok |\n| rust | 12 |\n\nAfter")
+
+	assert.Contains(t, out, "Results:")
+	assert.Contains(t, out, "┌──────┬───────┬────────┐")
+	assert.Contains(t, out, "│ Name │ Count │ Status │")
+	assert.Contains(t, out, "│ go   │     7 │   ok   │")
+	assert.Contains(t, out, "│ rust │    12 │        │")
+	assert.Contains(t, out, "└──────┴───────┴────────┘")
+	assert.Contains(t, out, "After")
+}
+
+func TestRenderGFMInline(t *testing.T) {
+	r := newTestMarkdownRenderer()
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "task list",
+			markdown: "- [ ] write tests\n- [x] ship it\n- plain",
+			want:     []string{"☐ write tests", "☑ ship it", "• plain"},
+		},
+		{
+			name:     "strikethrough",
+			markdown: "this is ~~wrong~~ right",
+			want:     []string{"wrong"},
+			notWant:  []string{"~~"},
+		},
+		{
+			name:     "footnotes",
+			markdown: "See the docs[^docs] and spec[^spec].\n\n[^spec]: The spec.\n[^docs]: https://example.com",
+			want:     []string{"docs[2]", "spec[1]", "[1] The spec.", "[2] https://example.com"},
+			notWant:  []string{"[^"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := r.Render(tt.markdown)
+			for _, want := range tt.want {
+				assert.Contains(t, out, want)
+			}
+			for _, notWant := range tt.notWant {
+				assert.NotContains(t, out, notWant)
+			}
+		})
+	}
+}
+
+func TestTableRequiresDelimiterRow(t *testing.T) {
+	r := newTestMarkdownRenderer()
+
+	out := r.Render("a | b\nnot a delimiter")
+	assert.False(t, strings.Contains(out, "┌"))
+}
+
+// FuzzParseMarkdown feeds the renderer the malformed markdown models write
+// while streaming or when they lose track of their output. Run it with
+//
+//	go test ./internal/ui/components -run '^$' -fuzz FuzzParseMarkdown
+func FuzzParseMarkdown(f *testing.F) {
+	seeds := []string{
+		"# Title\n\nSome **bold** and `code`.",
+		"```go\nfunc main() {\n",
+		"```\n```\n```",
+		"- a\n  - b\n    - c\n      - d\n        - e\n          - f",
+		"1. one\n2.\n3. three\n- mixed",
+		"> quote\n>\n> > nested\n\n>",
+		"| a | b |\n|---|---|\n| 1 |\n| 1 | 2 | 3 |",
+		"| a |\n|:-:|",
+		"---\n***\n___\n- - -",
+		"#\n##\n###### six\n####### seven",
+		"text[^1]\n\n[^1]:\n[^]: empty",
+		"~~unterminated **bold [link](http://x",
+		strings.Repeat("- ", 200) + "deep",
+		strings.Repeat("> ", 200) + "deep",
+		"\x00\xff\xfe\r\n\t",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	r := NewMarkdownRenderer(styles.GetTheme("default").GetStyles(), NewSyntaxHighlighter(styles.GetTheme("default").GetStyles()))
+	r.SetMaxWidth(60)
+
+	f.Fuzz(func(t *testing.T, markdown string) {
+		for _, element := range r.parseMarkdown(markdown) {
+			switch element.Type {
+			case ElementCodeBlock:
+				if !strings.Contains(markdown, element.Content) {
+					t.Fatalf("code block %q is not part of the input", element.Content)
+				}
+			case ElementHeading:
+				if element.Content == "" || element.Level < 1 {
+					t.Fatalf("empty heading: %+v", element)
+				}
+			case ElementTable:
+				for _, row := range element.Rows[1:] {
+					if len(row) != len(element.Rows[0]) {
+						t.Fatalf("row %q does not have the header's %d columns", row, len(element.Rows[0]))
+					}
+				}
+			}
+		}
+		r.Render(markdown)
+	})
+}
@@ -0,0 +1,77 @@
+package components
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/common-creation/coda/internal/styles"
+)
+
+// loadAnswer reads a representative answer with prose, lists, a table and
+// code blocks in several languages
+func loadAnswer(b *testing.B) string {
+	b.Helper()
+	data, err := os.ReadFile("testdata/answer.md")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(data)
+}
+
+func BenchmarkMarkdownRender(b *testing.B) {
+	answer := loadAnswer(b)
+	st := styles.GetTheme("default").GetStyles()
+
+	benchmarks := []struct {
+		name        string
+		highlighter bool
+		cold        bool // Code blocks are highlighted again on every render
+	}{
+		{name: "plain"},
+		{name: "highlighted", highlighter: true, cold: true},
+		{name: "highlighted cached", highlighter: true},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var highlighter *SyntaxHighlighter
+			if bm.highlighter {
+				highlighter = NewSyntaxHighlighter(st)
+			}
+			r := NewMarkdownRenderer(st, highlighter)
+			r.SetMaxWidth(100)
+
+			b.SetBytes(int64(len(answer)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if bm.cold {
+					highlighter.ClearCache()
+				}
+				r.Render(answer)
+			}
+		})
+	}
+}
+
+func BenchmarkSyntaxHighlight(b *testing.B) {
+	answer := loadAnswer(b)
+	fences := regexp.MustCompile("(?s)```(\\w+)\n(.*?)```").FindAllStringSubmatch(answer, -1)
+	if len(fences) == 0 {
+		b.Fatal("no code blocks in testdata/answer.md")
+	}
+	sh := NewSyntaxHighlighter(styles.GetTheme("default").GetStyles())
+
+	for _, fence := range fences {
+		language, code := fence[1], fence[2]
+		b.Run(language, func(b *testing.B) {
+			b.SetBytes(int64(len(code)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				// Measure highlighting rather than the cache
+				sh.ClearCache()
+				sh.Render(sh.Highlight(code, language), false)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package components
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 	"sync"
 
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/common-creation/coda/internal/styles"
 )
@@ -65,38 +67,21 @@ type HighlightTheme struct {
 	Background lipgloss.Style
 }
 
-// Language contains language-specific syntax rules
-type Language struct {
-	Name            string
-	Keywords        []string
-	Operators       []string
-	Types           []string
-	StringDelims    []string
-	CommentSingle   string
-	CommentMulti    [2]string
-	FunctionPattern *regexp.Regexp
-	NumberPattern   *regexp.Regexp
-	VariablePattern *regexp.Regexp
-}
-
-// SyntaxHighlighter provides syntax highlighting functionality
+// SyntaxHighlighter provides syntax highlighting functionality. Code is
+// tokenized by Chroma lexers, so multi-line comments and strings are
+// recognized across lines.
 type SyntaxHighlighter struct {
-	theme     HighlightTheme
-	languages map[string]Language
-	cache     map[string]HighlightedCode
-	mutex     sync.RWMutex
+	theme HighlightTheme
+	cache map[string]HighlightedCode
+	mutex sync.RWMutex
 }
 
 // NewSyntaxHighlighter creates a new syntax highlighter
 func NewSyntaxHighlighter(styles styles.Styles) *SyntaxHighlighter {
-	sh := &SyntaxHighlighter{
-		theme:     createHighlightTheme(styles),
-		languages: make(map[string]Language),
-		cache:     make(map[string]HighlightedCode),
+	return &SyntaxHighlighter{
+		theme: createHighlightTheme(styles),
+		cache: make(map[string]HighlightedCode),
 	}
-
-	sh.initializeLanguages()
-	return sh
 }
 
 // createHighlightTheme creates a syntax highlight theme from UI styles
@@ -116,135 +101,6 @@ func createHighlightTheme(styles styles.Styles) HighlightTheme {
 	}
 }
 
-// initializeLanguages sets up language definitions
-func (sh *SyntaxHighlighter) initializeLanguages() {
-	// Go language
-	sh.languages["go"] = Language{
-		Name: "Go",
-		Keywords: []string{
-			"break", "case", "chan", "const", "continue", "default", "defer",
-			"else", "fallthrough", "for", "func", "go", "goto", "if", "import",
-			"interface", "map", "package", "range", "return", "select", "struct",
-			"switch", "type", "var",
-		},
-		Types: []string{
-			"bool", "byte", "complex64", "complex128", "error", "float32", "float64",
-			"int", "int8", "int16", "int32", "int64", "rune", "string",
-			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
-		},
-		Operators:       []string{"+", "-", "*", "/", "%", "&", "|", "^", "<<", ">>", "&^", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=", "&^=", "&&", "||", "<-", "++", "--", "==", "<", ">", "=", "!", "!=", "<=", ">=", ":=", "...", "(", ")", "[", "]", "{", "}", ",", ";"},
-		StringDelims:    []string{`"`, "`"},
-		CommentSingle:   "//",
-		CommentMulti:    [2]string{"/*", "*/"},
-		FunctionPattern: regexp.MustCompile(`\b(\w+)\s*\(`),
-		NumberPattern:   regexp.MustCompile(`\b\d+(\.\d+)?\b`),
-		VariablePattern: regexp.MustCompile(`\b[a-zA-Z_]\w*\b`),
-	}
-
-	// Python language
-	sh.languages["python"] = Language{
-		Name: "Python",
-		Keywords: []string{
-			"and", "as", "assert", "break", "class", "continue", "def", "del",
-			"elif", "else", "except", "finally", "for", "from", "global", "if",
-			"import", "in", "is", "lambda", "nonlocal", "not", "or", "pass",
-			"raise", "return", "try", "while", "with", "yield", "async", "await",
-		},
-		Types: []string{
-			"bool", "int", "float", "complex", "str", "bytes", "bytearray",
-			"list", "tuple", "range", "dict", "set", "frozenset",
-		},
-		Operators:       []string{"+", "-", "*", "/", "//", "%", "**", "&", "|", "^", "~", "<<", ">>", "<", ">", "<=", ">=", "==", "!=", "=", "+=", "-=", "*=", "/=", "//=", "%=", "**=", "&=", "|=", "^=", "<<=", ">>="},
-		StringDelims:    []string{`"`, `'`, `"""`, `'''`},
-		CommentSingle:   "#",
-		FunctionPattern: regexp.MustCompile(`\bdef\s+(\w+)\s*\(`),
-		NumberPattern:   regexp.MustCompile(`\b\d+(\.\d+)?\b`),
-		VariablePattern: regexp.MustCompile(`\b[a-zA-Z_]\w*\b`),
-	}
-
-	// JavaScript language
-	sh.languages["javascript"] = Language{
-		Name: "JavaScript",
-		Keywords: []string{
-			"async", "await", "break", "case", "catch", "class", "const", "continue",
-			"debugger", "default", "delete", "do", "else", "export", "extends",
-			"finally", "for", "function", "if", "import", "in", "instanceof",
-			"let", "new", "return", "super", "switch", "this", "throw", "try",
-			"typeof", "var", "void", "while", "with", "yield",
-		},
-		Types: []string{
-			"boolean", "number", "string", "object", "undefined", "null", "symbol", "bigint",
-		},
-		Operators:       []string{"+", "-", "*", "/", "%", "**", "&", "|", "^", "~", "<<", ">>", ">>>", "<", ">", "<=", ">=", "==", "===", "!=", "!==", "=", "+=", "-=", "*=", "/=", "%=", "**=", "&=", "|=", "^=", "<<=", ">>=", ">>>=", "&&", "||", "!", "?", ":"},
-		StringDelims:    []string{`"`, `'`, "`"},
-		CommentSingle:   "//",
-		CommentMulti:    [2]string{"/*", "*/"},
-		FunctionPattern: regexp.MustCompile(`\b(\w+)\s*\(`),
-		NumberPattern:   regexp.MustCompile(`\b\d+(\.\d+)?\b`),
-		VariablePattern: regexp.MustCompile(`\b[a-zA-Z_$]\w*\b`),
-	}
-
-	// Add aliases
-	sh.languages["js"] = sh.languages["javascript"]
-	sh.languages["typescript"] = sh.languages["javascript"]
-	sh.languages["ts"] = sh.languages["javascript"]
-
-	// Rust language
-	sh.languages["rust"] = Language{
-		Name: "Rust",
-		Keywords: []string{
-			"as", "break", "const", "continue", "crate", "else", "enum", "extern",
-			"false", "fn", "for", "if", "impl", "in", "let", "loop", "match",
-			"mod", "move", "mut", "pub", "ref", "return", "self", "Self", "static",
-			"struct", "super", "trait", "true", "type", "unsafe", "use", "where", "while",
-		},
-		Types: []string{
-			"bool", "char", "i8", "i16", "i32", "i64", "i128", "isize",
-			"u8", "u16", "u32", "u64", "u128", "usize", "f32", "f64", "str", "String",
-		},
-		Operators:       []string{"+", "-", "*", "/", "%", "&", "|", "^", "!", "<<", ">>", "&&", "||", "<", ">", "<=", ">=", "==", "!=", "=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>="},
-		StringDelims:    []string{`"`, `'`},
-		CommentSingle:   "//",
-		CommentMulti:    [2]string{"/*", "*/"},
-		FunctionPattern: regexp.MustCompile(`\bfn\s+(\w+)\s*\(`),
-		NumberPattern:   regexp.MustCompile(`\b\d+(\.\d+)?\b`),
-		VariablePattern: regexp.MustCompile(`\b[a-zA-Z_]\w*\b`),
-	}
-
-	// JSON (simplified)
-	sh.languages["json"] = Language{
-		Name:          "JSON",
-		Keywords:      []string{"true", "false", "null"},
-		StringDelims:  []string{`"`},
-		NumberPattern: regexp.MustCompile(`\b-?\d+(\.\d+)?([eE][+-]?\d+)?\b`),
-	}
-
-	// YAML (simplified)
-	sh.languages["yaml"] = Language{
-		Name:          "YAML",
-		Keywords:      []string{"true", "false", "null", "yes", "no"},
-		StringDelims:  []string{`"`, `'`},
-		CommentSingle: "#",
-		NumberPattern: regexp.MustCompile(`\b-?\d+(\.\d+)?\b`),
-	}
-
-	// Shell/Bash
-	sh.languages["bash"] = Language{
-		Name: "Bash",
-		Keywords: []string{
-			"if", "then", "else", "elif", "fi", "case", "esac", "for", "while",
-			"until", "do", "done", "function", "return", "local", "export",
-			"unset", "readonly", "declare", "typeset", "let", "eval", "exec",
-		},
-		StringDelims:  []string{`"`, `'`},
-		CommentSingle: "#",
-		NumberPattern: regexp.MustCompile(`\b\d+\b`),
-	}
-
-	sh.languages["shell"] = sh.languages["bash"]
-	sh.languages["sh"] = sh.languages["bash"]
-}
-
 // Highlight highlights code and returns highlighted representation
 func (sh *SyntaxHighlighter) Highlight(code, language string) HighlightedCode {
 	// Check cache first
@@ -281,22 +137,28 @@ func (sh *SyntaxHighlighter) Highlight(code, language string) HighlightedCode {
 
 // highlightCode performs the actual syntax highlighting
 func (sh *SyntaxHighlighter) highlightCode(code, language string) HighlightedCode {
-	lang, exists := sh.languages[strings.ToLower(language)]
-	if !exists {
+	lexer := lexers.Get(language)
+	if lexer == nil {
 		// Return unhighlighted code
 		return sh.createPlainHighlight(code, language)
 	}
 
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return sh.createPlainHighlight(code, language)
+	}
+
+	// Tokens spanning lines (block comments, raw strings) are split per line
 	lines := strings.Split(code, "\n")
 	highlightedLines := make([]HighlightedLine, len(lines))
-
 	for i, line := range lines {
-		tokens := sh.tokenizeLine(line, lang)
-		highlightedLines[i] = HighlightedLine{
-			Tokens:     tokens,
-			LineNumber: i + 1,
-			Content:    line,
+		highlightedLines[i] = HighlightedLine{LineNumber: i + 1, Content: line}
+	}
+	for i, lineTokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		if i >= len(highlightedLines) {
+			break
 		}
+		highlightedLines[i].Tokens = convertTokens(lineTokens)
 	}
 
 	return HighlightedCode{
@@ -307,195 +169,55 @@ func (sh *SyntaxHighlighter) highlightCode(code, language string) HighlightedCod
 	}
 }
 
-// tokenizeLine tokenizes a single line of code
-func (sh *SyntaxHighlighter) tokenizeLine(line string, lang Language) []Token {
-	if line == "" {
-		return []Token{}
-	}
-
-	var tokens []Token
-	i := 0
-
-	for i < len(line) {
-		// Skip whitespace
-		if line[i] == ' ' || line[i] == '\t' {
-			start := i
-			for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
-				i++
-			}
-			tokens = append(tokens, Token{
-				Type:    TokenText,
-				Content: line[start:i],
-				Start:   start,
-				End:     i,
-			})
-			continue
-		}
-
-		// Check for comments
-		if lang.CommentSingle != "" && strings.HasPrefix(line[i:], lang.CommentSingle) {
-			// Single line comment - consume rest of line
-			tokens = append(tokens, Token{
-				Type:    TokenComment,
-				Content: line[i:],
-				Start:   i,
-				End:     len(line),
-			})
-			break
-		}
-
-		// Check for multi-line comments
-		if lang.CommentMulti[0] != "" && strings.HasPrefix(line[i:], lang.CommentMulti[0]) {
-			start := i
-			i += len(lang.CommentMulti[0])
-
-			// Find end of comment (simplified - doesn't handle multi-line)
-			end := strings.Index(line[i:], lang.CommentMulti[1])
-			if end != -1 {
-				i += end + len(lang.CommentMulti[1])
-			} else {
-				i = len(line)
-			}
-
-			tokens = append(tokens, Token{
-				Type:    TokenComment,
-				Content: line[start:i],
-				Start:   start,
-				End:     i,
-			})
-			continue
-		}
-
-		// Check for strings
-		stringFound := false
-		for _, delim := range lang.StringDelims {
-			if strings.HasPrefix(line[i:], delim) {
-				start := i
-				i += len(delim)
-
-				// Find closing delimiter
-				for i < len(line) {
-					if strings.HasPrefix(line[i:], delim) {
-						i += len(delim)
-						break
-					}
-					if line[i] == '\\' && i+1 < len(line) {
-						i += 2 // Skip escaped character
-					} else {
-						i++
-					}
-				}
-
-				tokens = append(tokens, Token{
-					Type:    TokenString,
-					Content: line[start:i],
-					Start:   start,
-					End:     i,
-				})
-				stringFound = true
-				break
-			}
-		}
-		if stringFound {
-			continue
-		}
-
-		// Check for numbers
-		if lang.NumberPattern != nil {
-			if match := lang.NumberPattern.FindStringIndex(line[i:]); match != nil && match[0] == 0 {
-				end := i + match[1]
-				tokens = append(tokens, Token{
-					Type:    TokenNumber,
-					Content: line[i:end],
-					Start:   i,
-					End:     end,
-				})
-				i = end
-				continue
-			}
-		}
-
-		// Check for operators
-		operatorFound := false
-		for _, op := range lang.Operators {
-			if strings.HasPrefix(line[i:], op) {
-				tokenType := TokenOperator
-				if op == "(" || op == ")" || op == "[" || op == "]" || op == "{" || op == "}" {
-					tokenType = TokenBracket
-				} else if op == "," || op == ";" || op == ":" {
-					tokenType = TokenDelimiter
-				}
-
-				tokens = append(tokens, Token{
-					Type:    tokenType,
-					Content: op,
-					Start:   i,
-					End:     i + len(op),
-				})
-				i += len(op)
-				operatorFound = true
-				break
-			}
-		}
-		if operatorFound {
+// convertTokens maps the Chroma tokens of one line to tokens
+func convertTokens(chromaTokens []chroma.Token) []Token {
+	tokens := make([]Token, 0, len(chromaTokens))
+	offset := 0
+	for _, ct := range chromaTokens {
+		content := strings.TrimSuffix(ct.Value, "\n")
+		if content == "" {
 			continue
 		}
-
-		// Check for identifiers (keywords, types, functions, variables)
-		if lang.VariablePattern != nil {
-			if match := lang.VariablePattern.FindStringIndex(line[i:]); match != nil && match[0] == 0 {
-				end := i + match[1]
-				word := line[i:end]
-
-				tokenType := TokenVariable
-
-				// Check if it's a keyword
-				for _, keyword := range lang.Keywords {
-					if word == keyword {
-						tokenType = TokenKeyword
-						break
-					}
-				}
-
-				// Check if it's a type
-				if tokenType == TokenVariable {
-					for _, typeName := range lang.Types {
-						if word == typeName {
-							tokenType = TokenType_
-							break
-						}
-					}
-				}
-
-				// Check if it's a function (simple check)
-				if tokenType == TokenVariable && end < len(line) && line[end] == '(' {
-					tokenType = TokenFunction
-				}
-
-				tokens = append(tokens, Token{
-					Type:    tokenType,
-					Content: word,
-					Start:   i,
-					End:     end,
-				})
-				i = end
-				continue
-			}
-		}
-
-		// Default: single character as text
 		tokens = append(tokens, Token{
-			Type:    TokenText,
-			Content: string(line[i]),
-			Start:   i,
-			End:     i + 1,
+			Type:    tokenTypeFor(ct.Type, content),
+			Content: content,
+			Start:   offset,
+			End:     offset + len(content),
 		})
-		i++
+		offset += len(content)
 	}
-
 	return tokens
 }
 
+// tokenTypeFor maps a Chroma token type to the token types styled by themes
+func tokenTypeFor(t chroma.TokenType, content string) TokenType {
+	switch {
+	case t == chroma.KeywordType || t == chroma.NameClass || t == chroma.NameBuiltin:
+		return TokenType_
+	case t.InCategory(chroma.Keyword):
+		return TokenKeyword
+	case t.InCategory(chroma.Comment):
+		return TokenComment
+	case t.InSubCategory(chroma.LiteralString):
+		return TokenString
+	case t.InSubCategory(chroma.LiteralNumber):
+		return TokenNumber
+	case t == chroma.NameFunction || t == chroma.NameFunctionMagic:
+		return TokenFunction
+	case t == chroma.NameVariable || t.InSubCategory(chroma.NameVariable):
+		return TokenVariable
+	case t.InCategory(chroma.Operator):
+		return TokenOperator
+	case t.InCategory(chroma.Punctuation):
+		if strings.ContainsAny(content, "()[]{}") {
+			return TokenBracket
+		}
+		return TokenDelimiter
+	default:
+		return TokenText
+	}
+}
+
 // createPlainHighlight creates unhighlighted code representation
 func (sh *SyntaxHighlighter) createPlainHighlight(code, language string) HighlightedCode {
 	lines := strings.Split(code, "\n")
@@ -575,20 +297,7 @@ func (sh *SyntaxHighlighter) getStyleForToken(tokenType TokenType, theme Highlig
 
 // GetSupportedLanguages returns a list of supported languages
 func (sh *SyntaxHighlighter) GetSupportedLanguages() []string {
-	sh.mutex.RLock()
-	defer sh.mutex.RUnlock()
-
-	var languages []string
-	seen := make(map[string]bool)
-
-	for lang := range sh.languages {
-		if !seen[lang] {
-			languages = append(languages, lang)
-			seen[lang] = true
-		}
-	}
-
-	return languages
+	return lexers.Names(false)
 }
 
 // SetTheme updates the highlighting theme
@@ -606,3 +315,52 @@ func (sh *SyntaxHighlighter) ClearCache() {
 	sh.cache = make(map[string]HighlightedCode)
 	sh.mutex.Unlock()
 }
+
+// ChromaTheme builds a highlight theme from a standard Chroma style such as
+// "monokai", "dracula" or "github"
+func ChromaTheme(name string) (HighlightTheme, error) {
+	style, ok := chromastyles.Registry[strings.ToLower(name)]
+	if !ok {
+		return HighlightTheme{}, fmt.Errorf("unknown syntax theme %q", name)
+	}
+
+	styleFor := func(types ...chroma.TokenType) lipgloss.Style {
+		entry := style.Get(types[0])
+		for _, t := range types[1:] {
+			if entry.Colour.IsSet() {
+				break
+			}
+			entry = style.Get(t)
+		}
+		s := lipgloss.NewStyle()
+		if entry.Colour.IsSet() {
+			s = s.Foreground(lipgloss.Color(entry.Colour.String()))
+		}
+		if entry.Bold == chroma.Yes {
+			s = s.Bold(true)
+		}
+		if entry.Italic == chroma.Yes {
+			s = s.Italic(true)
+		}
+		return s
+	}
+
+	background := lipgloss.NewStyle()
+	if bg := style.Get(chroma.Background).Background; bg.IsSet() {
+		background = background.Background(lipgloss.Color(bg.String()))
+	}
+
+	return HighlightTheme{
+		Keyword:    styleFor(chroma.Keyword),
+		String:     styleFor(chroma.LiteralString),
+		Comment:    styleFor(chroma.Comment),
+		Function:   styleFor(chroma.NameFunction, chroma.Name),
+		Number:     styleFor(chroma.LiteralNumber),
+		Operator:   styleFor(chroma.Operator),
+		Type:       styleFor(chroma.KeywordType, chroma.Keyword),
+		Variable:   styleFor(chroma.NameVariable, chroma.Name),
+		Bracket:    styleFor(chroma.Punctuation),
+		Delimiter:  styleFor(chroma.Punctuation),
+		Background: background,
+	}, nil
+}
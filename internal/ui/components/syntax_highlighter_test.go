@@ -0,0 +1,68 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/styles"
+)
+
+// tokenTypes returns the token types of a line keyed by content
+func tokenTypes(line HighlightedLine) map[string]TokenType {
+	types := make(map[string]TokenType)
+	for _, token := range line.Tokens {
+		types[token.Content] = token.Type
+	}
+	return types
+}
+
+func TestHighlightLanguages(t *testing.T) {
+	sh := NewSyntaxHighlighter(styles.GetTheme("default").GetStyles())
+
+	tests := []struct {
+		name     string
+		language string
+		code     string
+		line     int
+		token    string
+		want     TokenType
+	}{
+		{name: "go keyword", language: "go", code: "func main() {}", token: "func", want: TokenKeyword},
+		{name: "go function", language: "go", code: "func main() {}", token: "main", want: TokenFunction},
+		{name: "rust string", language: "rust", code: `let s = "hi";`, token: `"hi"`, want: TokenString},
+		{name: "alias", language: "sh", code: "echo 1 # done", token: "# done", want: TokenComment},
+		{name: "multi-line comment", language: "c", code: "/* first\nsecond */ int x;", line: 1, token: "second */", want: TokenComment},
+		{name: "multi-line string", language: "python", code: "x = \"\"\"a\nb\"\"\"", line: 1, token: `b"""`, want: TokenString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			highlighted := sh.Highlight(tt.code, tt.language)
+			require.Greater(t, len(highlighted.Lines), tt.line)
+			types := tokenTypes(highlighted.Lines[tt.line])
+			require.Contains(t, types, tt.token)
+			assert.Equal(t, tt.want, types[tt.token])
+		})
+	}
+}
+
+func TestHighlightUnknownLanguage(t *testing.T) {
+	sh := NewSyntaxHighlighter(styles.GetTheme("default").GetStyles())
+
+	highlighted := sh.Highlight("some text\nmore", "no-such-language")
+	require.Len(t, highlighted.Lines, 2)
+	assert.Equal(t, []Token{{Type: TokenText, Content: "more", End: 4}}, highlighted.Lines[1].Tokens)
+	assert.Equal(t, "  1 │ some text\n  2 │ more\n", sh.Render(highlighted, true))
+}
+
+func TestChromaTheme(t *testing.T) {
+	theme, err := ChromaTheme("Monokai")
+	require.NoError(t, err)
+	assert.NotEqual(t, lipgloss.NoColor{}, theme.Keyword.GetForeground())
+
+	_, err = ChromaTheme("nope")
+	assert.Error(t, err)
+}
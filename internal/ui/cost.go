@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// estimateCost returns the cost in USD of a request to model, or false when
+// the model's price is unknown
+func estimateCost(model string, promptTokens, completionTokens int) (float64, bool) {
+	info, ok := ai.LookupModel(model)
+	if !ok || (info.InputPrice == 0 && info.OutputPrice == 0) {
+		return 0, false
+	}
+	return (float64(promptTokens)*info.InputPrice + float64(completionTokens)*info.OutputPrice) / 1e6, true
+}
+
+// formatTokenCount formats a token count compactly
+func formatTokenCount(tokens int) string {
+	if tokens < 1000 {
+		return fmt.Sprintf("%d", tokens)
+	}
+	return fmt.Sprintf("%.1fk", float64(tokens)/1000)
+}
+
+// formatCost formats a cost in USD with precision for small amounts
+func formatCost(cost float64) string {
+	if cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// messageUsage describes the tokens of a message and the cost of the
+// request that produced it. Estimated counts are marked with "≈".
+func messageUsage(msg Message) string {
+	if msg.Tokens <= 0 {
+		return ""
+	}
+	// DO NOT CHANGE '≈' TO '~'
+	usage := fmt.Sprintf("≈%s tok", formatTokenCount(msg.Tokens))
+	if msg.TokensExact {
+		usage = fmt.Sprintf("%s tok", formatTokenCount(msg.Tokens))
+	}
+	if msg.Cost > 0 {
+		usage += " · " + formatCost(msg.Cost)
+	}
+	return usage
+}
+
+// annotateBlock right-aligns the usage of msg on the first line of its
+// rendered block, or on a line of its own when it does not fit
+func (m Model) annotateBlock(msg Message, block string) string {
+	if m.config != nil && m.config.UI.HideMessageUsage {
+		return block
+	}
+	usage := messageUsage(msg)
+	if usage == "" {
+		return block
+	}
+
+	width := m.viewport.Width
+	lines := strings.SplitN(block, "\n", 2)
+	note := m.styles.Muted.Render(usage)
+	if padding := width - lipgloss.Width(lines[0]) - lipgloss.Width(usage); padding >= 2 {
+		lines[0] += strings.Repeat(" ", padding) + note
+	} else {
+		lines[0] += "\n" + strings.Repeat(" ", max(width-lipgloss.Width(usage), 0)) + note
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cost, ok := estimateCost("gpt-4o-mini-2024-07-18", 1_000_000, 1_000_000)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.75, cost, 1e-9, "the more specific prefix wins over gpt-4o")
+
+	cost, ok = estimateCost("gpt-4.1", 2000, 500)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.008, cost, 1e-9)
+
+	_, ok = estimateCost("my-local-model", 10, 10)
+	assert.False(t, ok)
+}
+
+func TestMessageUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want string
+	}{
+		{"no tokens", Message{}, ""},
+		{"estimated", Message{Tokens: 42}, "≈42 tok"},
+		{"exact with cost", Message{Tokens: 1234, TokensExact: true, Cost: 0.0123}, "1.2k tok · $0.01"},
+		{"small cost", Message{Tokens: 10, TokensExact: true, Cost: 0.00042}, "10 tok · $0.0004"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, messageUsage(tt.msg))
+		})
+	}
+}
+
+func TestAnnotateBlock(t *testing.T) {
+	m := newTabsTestModel()
+	m.viewport.Width = 40
+	msg := Message{Role: "user", Tokens: 12}
+
+	block := m.annotateBlock(msg, "[15:04] user: hello\nsecond line")
+	lines := strings.Split(ansi.Strip(block), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, 40, lipgloss.Width(lines[0]))
+	assert.True(t, strings.HasSuffix(lines[0], "≈12 tok"))
+	assert.Equal(t, "second line", lines[1])
+
+	// A first line that is too long gets the annotation below it
+	block = m.annotateBlock(msg, "[15:04] user: "+strings.Repeat("x", 40))
+	lines = strings.Split(ansi.Strip(block), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[1], "≈12 tok"))
+
+	m.config = config.NewDefaultConfig()
+	m.config.UI.HideMessageUsage = true
+	assert.Equal(t, "[15:04] user: hello", m.annotateBlock(msg, "[15:04] user: hello"))
+}
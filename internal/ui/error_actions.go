@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/errors"
+)
+
+// errorCountdownTick schedules the next automatic retry countdown tick
+func errorCountdownTick(seq int) tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return errorCountdownMsg{seq: seq}
+	})
+}
+
+// executeErrorAction runs the remediation action selected in the error display
+func (m Model) executeErrorAction() (tea.Model, tea.Cmd) {
+	if m.errorDisplay == nil {
+		return m, func() tea.Msg { return dismissErrorMsg{} }
+	}
+
+	action, ok := m.errorDisplay.SelectedAction()
+	if !ok {
+		return m, func() tea.Msg { return dismissErrorMsg{} }
+	}
+
+	m.logger.Debug("Executing error remediation action", "action", action.Kind)
+
+	switch action.Kind {
+	case errors.ActionRetry:
+		m.error = nil
+		m.errorDisplay.SetError(nil)
+		return m, func() tea.Msg { return retryLastActionMsg{} }
+	case errors.ActionNewSession:
+		m.errorDisplay.SetError(nil)
+		m.startNewSession()
+		return m, nil
+	case errors.ActionShowCommand:
+		m.error = nil
+		m.errorDisplay.SetError(nil)
+		m.addSystemMessage(fmt.Sprintf("Run in your shell: %s", action.Command))
+		return m, nil
+	case errors.ActionQuit:
+		return m, tea.Quit
+	default:
+		return m, func() tea.Msg { return dismissErrorMsg{} }
+	}
+}
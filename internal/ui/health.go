@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// pingTimeout bounds a /ping request
+const pingTimeout = 15 * time.Second
+
+// healthReporter is implemented by AI clients that track their latency
+type healthReporter interface {
+	Health() ai.Health
+}
+
+// pingMsg reports the result of /ping
+type pingMsg struct {
+	latency time.Duration
+	err     error
+}
+
+// providerHealth returns the recent latency and failures of the AI provider
+func (m Model) providerHealth() (ai.Health, bool) {
+	if m.chatHandler == nil {
+		return ai.Health{}, false
+	}
+	reporter, ok := m.chatHandler.AIClient().(healthReporter)
+	if !ok {
+		return ai.Health{}, false
+	}
+	return reporter.Health(), true
+}
+
+// renderProviderHealth renders a colored dot with the median latency, or ""
+// before the first request
+func (m Model) renderProviderHealth() string {
+	health, ok := m.providerHealth()
+	if !ok || health.Status == ai.HealthUnknown {
+		return ""
+	}
+
+	color := lipgloss.Color("10")
+	label := formatDuration(health.P50)
+	switch health.Status {
+	case ai.HealthDegraded:
+		color = lipgloss.Color("11")
+	case ai.HealthDown:
+		color = lipgloss.Color("9")
+		label = "down"
+	}
+	return lipgloss.NewStyle().Foreground(color).Render("● " + label)
+}
+
+// ping checks that the provider responds, for /ping
+func (m *Model) ping() tea.Cmd {
+	if m.chatHandler == nil || m.chatHandler.AIClient() == nil {
+		m.addSystemMessage("No AI provider is configured")
+		return nil
+	}
+	client := m.chatHandler.AIClient()
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	m.addSystemMessage("Pinging the AI provider...")
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+		start := time.Now()
+		err := client.Ping(ctx)
+		return pingMsg{latency: time.Since(start), err: err}
+	}
+}
+
+// formatPing describes the result of /ping with the recent health
+func (m Model) formatPing(msg pingMsg) string {
+	text := fmt.Sprintf("Provider responded in %s", formatDuration(msg.latency))
+	if msg.err != nil {
+		text = fmt.Sprintf("Ping failed after %s: %v", formatDuration(msg.latency), msg.err)
+	}
+
+	if health, ok := m.providerHealth(); ok && health.Requests > 0 {
+		text += fmt.Sprintf("\nLast %d requests: %s, median %s", health.Requests, health.Status, formatDuration(health.P50))
+		if health.Failures > 0 {
+			text += fmt.Sprintf(", %d failed", health.Failures)
+		}
+	}
+	return text
+}
@@ -0,0 +1,20 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPing(t *testing.T) {
+	m := newTabsTestModel()
+
+	assert.Equal(t, "Provider responded in 250.0ms", m.formatPing(pingMsg{latency: 250 * time.Millisecond}))
+	assert.Equal(t, "Ping failed after 1.5s: unauthorized", m.formatPing(pingMsg{latency: 1500 * time.Millisecond, err: errors.New("unauthorized")}))
+
+	assert.Empty(t, m.renderProviderHealth(), "no provider")
+	assert.Nil(t, m.ping())
+	assert.Equal(t, "No AI provider is configured", m.messages[len(m.messages)-1].Content)
+}
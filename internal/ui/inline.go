@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// messageCursor remembers how far a conversation has been consumed by an
+// append-only output such as the scrollback or a transcript
+type messageCursor struct {
+	count  int
+	lastID string // ID of the last consumed message, to detect a replaced conversation
+}
+
+// advance returns the messages added since the last call. replaced reports
+// that the conversation was cleared or replaced (new session, /sessions), in
+// which case all of its messages are returned.
+func (c *messageCursor) advance(messages []Message) (added []Message, replaced bool) {
+	if c.count > len(messages) ||
+		(c.count > 0 && messages[c.count-1].ID != c.lastID) {
+		c.count = 0
+		replaced = true
+	}
+	added = messages[c.count:]
+
+	c.count = len(messages)
+	c.lastID = ""
+	if len(messages) > 0 {
+		c.lastID = messages[len(messages)-1].ID
+	}
+	return added, replaced
+}
+
+// printNewMessages prints the messages added to the active tab since the
+// last call above the input area, where they stay in the terminal scrollback.
+// It is only used in inline mode.
+func (m *Model) printNewMessages() tea.Cmd {
+	if !m.ready {
+		return nil
+	}
+
+	added, replaced := m.printed.advance(m.messages)
+	if len(added) == 0 && !replaced {
+		return nil
+	}
+
+	var lines []string
+	if replaced {
+		lines = append(lines, "── new conversation ──")
+	}
+
+	// Messages of other tabs are printed when their tab is active
+	prefix := ""
+	if len(m.tabs) > 1 {
+		prefix = fmt.Sprintf("[tab %d] ", m.activeTab+1)
+	}
+	links := m.linkCountBefore(len(m.messages) - len(added))
+	answers := m.answerCountBefore(len(m.messages) - len(added))
+	for _, msg := range added {
+		lines = append(lines, prefix+m.formatMessageLine(msg))
+		if msg.Role == "assistant" {
+			answers++
+			if blocks := extractCodeBlocks(msg.Content); len(blocks) > 0 {
+				lines = append(lines, formatCodeBlockRefs(blocks, answers))
+			}
+			refs := extractLinks(msg.Content)
+			if len(refs) > 0 {
+				lines = append(lines, formatLinkReferences(refs, links+1))
+				links += len(refs)
+			}
+		}
+	}
+	return tea.Println(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintNewMessages(t *testing.T) {
+	m := Model{ready: true, inline: true}
+	assert.Nil(t, m.printNewMessages())
+
+	m.messages = []Message{{ID: "1", Role: "user", Content: "hello"}}
+	assert.NotNil(t, m.printNewMessages())
+	assert.Equal(t, 1, m.printed.count)
+
+	// Nothing new to print
+	assert.Nil(t, m.printNewMessages())
+
+	m.messages = append(m.messages, Message{ID: "2", Role: "assistant", Content: "hi"})
+	assert.NotNil(t, m.printNewMessages())
+	assert.Equal(t, 2, m.printed.count)
+	assert.Equal(t, "2", m.printed.lastID)
+
+	// A replaced conversation of the same length is printed again
+	m.messages = []Message{{ID: "3", Role: "user", Content: "a"}, {ID: "4", Role: "assistant", Content: "b"}}
+	assert.NotNil(t, m.printNewMessages())
+	assert.Equal(t, "4", m.printed.lastID)
+
+	// Clearing the conversation prints a separator once
+	m.messages = nil
+	assert.NotNil(t, m.printNewMessages())
+	assert.Equal(t, 0, m.printed.count)
+	assert.Nil(t, m.printNewMessages())
+}
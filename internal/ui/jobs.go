@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// job is a request/tool chain running in a tab: the model request, the tool
+// calls it makes and the follow-up requests, until a final answer arrives
+type job struct {
+	id        int
+	tab       int
+	title     string
+	started   time.Time
+	rounds    int  // Responses received so far
+	toolCalls int  // Tool calls requested so far
+	waiting   bool // Waiting for the user to approve tool calls
+	executing bool // Running approved tool calls
+	canceled  bool
+
+	command *commandPane // Output of the run_command call being executed
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// jobList tracks the running job of each tab. It is shared by all copies of
+// the Model.
+type jobList struct {
+	nextID int
+	byTab  map[int]*job
+}
+
+func newJobList() *jobList {
+	return &jobList{byTab: make(map[int]*job)}
+}
+
+// sorted returns the running jobs in start order
+func (l *jobList) sorted() []*job {
+	jobs := make([]*job, 0, len(l.byTab))
+	for _, j := range l.byTab {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].id < jobs[b].id })
+	return jobs
+}
+
+// jobContext returns the context of the active tab's job, starting a job
+// titled title when none is running. Canceling the job cancels the context.
+func (m *Model) jobContext(title string) context.Context {
+	parent := m.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	if m.jobs == nil {
+		return parent
+	}
+
+	tab := m.activeTabID()
+	if j, ok := m.jobs.byTab[tab]; ok {
+		j.waiting = false
+		return j.ctx
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	m.jobs.nextID++
+	m.jobs.byTab[tab] = &job{
+		id:      m.jobs.nextID,
+		tab:     tab,
+		title:   jobTitle(title),
+		started: time.Now(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	return ctx
+}
+
+// activeJob returns the active tab's job, or nil
+func (m *Model) activeJob() *job {
+	if m.jobs == nil {
+		return nil
+	}
+	return m.jobs.byTab[m.activeTabID()]
+}
+
+// jobResponded records a response of the active tab's job. Responses with
+// tool calls keep the job running while the calls wait for approval.
+func (m *Model) jobResponded(toolCalls int) {
+	if m.jobs == nil {
+		return
+	}
+	if j, ok := m.jobs.byTab[m.activeTabID()]; ok {
+		j.rounds++
+		j.toolCalls += toolCalls
+		j.waiting = toolCalls > 0
+		if toolCalls == 0 {
+			m.finishJob()
+		}
+	}
+}
+
+// finishJob removes the active tab's job and returns it, or nil
+func (m *Model) finishJob() *job {
+	if m.jobs == nil {
+		return nil
+	}
+	tab := m.activeTabID()
+	j, ok := m.jobs.byTab[tab]
+	if !ok {
+		return nil
+	}
+	delete(m.jobs.byTab, tab)
+	j.cancel()
+	return j
+}
+
+// cancelJob cancels a job. A job waiting for tool call approval has no
+// request in flight, so its permit dialog is closed and it ends right away.
+func (m *Model) cancelJob(j *job) {
+	j.canceled = true
+	j.cancel()
+	if !j.waiting {
+		// The request fails with a context error and ends the job
+		return
+	}
+
+	delete(m.jobs.byTab, j.tab)
+	idx := m.tabIndex(j.tab)
+	if idx < 0 {
+		return
+	}
+	if idx == m.activeTab {
+		m.closePermitDialog()
+		m.addSystemMessage(fmt.Sprintf("Job %d canceled", j.id))
+		return
+	}
+
+	tab := m.tabs[idx]
+	tab.closePermitDialog()
+	tab.unread = true
+}
+
+// handleJobsCommand runs "/jobs [cancel N|all]"
+func (m *Model) handleJobsCommand(args []string) {
+	if len(args) == 0 {
+		m.listJobs()
+		return
+	}
+
+	if strings.ToLower(args[0]) != "cancel" || len(args) != 2 {
+		m.addSystemMessage("Usage: /jobs [cancel N|all]")
+		return
+	}
+	if m.jobs == nil || len(m.jobs.byTab) == 0 {
+		m.addSystemMessage("No jobs are running")
+		return
+	}
+
+	if strings.ToLower(args[1]) == "all" {
+		for _, j := range m.jobs.sorted() {
+			m.cancelJob(j)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Usage: /jobs cancel N (got %q)", args[1]))
+		return
+	}
+	for _, j := range m.jobs.byTab {
+		if j.id == id {
+			m.cancelJob(j)
+			return
+		}
+	}
+	m.addSystemMessage(fmt.Sprintf("No running job %d", id))
+}
+
+// listJobs shows the running jobs as a system message
+func (m *Model) listJobs() {
+	if m.jobs == nil || len(m.jobs.byTab) == 0 {
+		m.addSystemMessage("No jobs are running")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Jobs:")
+	for _, j := range m.jobs.sorted() {
+		state := "running"
+		switch {
+		case j.canceled:
+			state = "canceling"
+		case j.waiting:
+			state = "waiting for tool approval"
+		case j.executing:
+			state = "running tools"
+		}
+		fmt.Fprintf(&b, "\n  %d. tab %d: %s — %s for %s, %d responses, %d tool calls",
+			j.id, m.tabIndex(j.tab)+1, j.title, state, time.Since(j.started).Round(time.Second), j.rounds, j.toolCalls)
+	}
+	b.WriteString("\n/jobs cancel N stops a job, Ctrl+B moves the running job to the background")
+	m.addSystemMessage(b.String())
+}
+
+// backgroundJob leaves the active tab's job running in its tab and opens a
+// new tab for the next prompt
+func (m *Model) backgroundJob() {
+	if m.jobs == nil {
+		return
+	}
+	j, ok := m.jobs.byTab[m.activeTabID()]
+	if !ok {
+		m.addSystemMessage("No job is running in this tab")
+		return
+	}
+
+	tab := m.activeTab + 1
+	m.openTab("")
+	m.addSystemMessage(fmt.Sprintf("Job %d keeps running in tab %d; see /jobs for progress", j.id, tab))
+}
+
+// jobTitle shortens a prompt to a one-line job title
+func jobTitle(prompt string) string {
+	title := strings.Join(strings.Fields(prompt), " ")
+	if title == "" {
+		return "Conversation"
+	}
+	if runes := []rune(title); len(runes) > 40 {
+		return string(runes[:40]) + "..."
+	}
+	return title
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	m := newTabsTestModel()
+	m.jobs = newJobList()
+
+	ctx := m.jobContext("refactor the   parser\nplease")
+	require.Len(t, m.jobs.byTab, 1)
+	j := m.jobs.byTab[1]
+	assert.Equal(t, "refactor the parser please", j.title)
+
+	// Follow-up requests of the chain share the job
+	m.jobResponded(2)
+	assert.True(t, j.waiting)
+	assert.Equal(t, ctx, m.jobContext(""))
+	assert.False(t, j.waiting)
+
+	m.jobResponded(0)
+	assert.Empty(t, m.jobs.byTab)
+	assert.Error(t, ctx.Err())
+	assert.Equal(t, 2, j.rounds)
+	assert.Equal(t, 2, j.toolCalls)
+}
+
+func TestCancelJobWaitingForApproval(t *testing.T) {
+	m := newTabsTestModel()
+	m.jobs = newJobList()
+
+	// A background tab's job waits for tool approval
+	m.switchTab(1)
+	ctx := m.jobContext("deploy")
+	m.jobResponded(1)
+	m.previousMode = ModeInsert
+	m.currentMode = ModePermit
+	m.permitDialogVisible = true
+	m.pendingToolCalls = []ai.ToolCall{{ID: "call-1"}}
+	m.switchTab(0)
+
+	m.handleJobsCommand([]string{"cancel", "1"})
+
+	assert.Error(t, ctx.Err())
+	assert.Empty(t, m.jobs.byTab)
+	bg := m.tabs[1]
+	assert.False(t, bg.permitDialogVisible)
+	assert.Empty(t, bg.pendingToolCalls)
+	assert.Equal(t, ModeInsert, bg.currentMode)
+}
+
+func TestCtrlCCancelsRunningJob(t *testing.T) {
+	m := newTabsTestModel()
+	m.jobs = newJobList()
+	ctrlC := tea.KeyMsg{Type: tea.KeyCtrlC}
+
+	// During a request Ctrl+C cancels it instead of arming quit
+	ctx := m.jobContext("explain")
+	updated, _ := m.Update(ctrlC)
+	m = updated.(Model)
+	assert.Error(t, ctx.Err())
+	assert.True(t, m.jobs.byTab[1].canceled)
+	assert.True(t, m.lastCtrlCTime.IsZero())
+
+	// During tool execution the tools are canceled and the chain stops
+	m.finishJob()
+	m.jobContext("edit")
+	m.executeToolCalls(nil)
+	j := m.jobs.byTab[1]
+	require.True(t, j.executing)
+	updated, _ = m.Update(ctrlC)
+	m = updated.(Model)
+	assert.True(t, j.canceled)
+
+	updated, cmd := m.Update(toolExecutionMsg{})
+	m = updated.(Model)
+	assert.Empty(t, m.jobs.byTab)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "canceled during tool execution")
+	assert.Nil(t, cmd, "results of a canceled job are not sent back")
+
+	// Idle, the double press quit flow applies
+	updated, _ = m.Update(ctrlC)
+	m = updated.(Model)
+	assert.False(t, m.lastCtrlCTime.IsZero())
+}
+
+func TestJobTitle(t *testing.T) {
+	assert.Equal(t, "Conversation", jobTitle("  "))
+	assert.Equal(t, "short", jobTitle("short"))
+	assert.Equal(t, strings.Repeat("a", 40)+"...", jobTitle(strings.Repeat("a", 50)))
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"github.com/common-creation/coda/internal/journal"
+)
+
+// journalEntry summarizes the conversations of every tab for the journal.
+// The tabs must be stored first (closeTabs).
+func (m Model) journalEntry() journal.Entry {
+	var entry journal.Entry
+	seenModels := make(map[string]bool)
+	for _, tab := range m.tabs {
+		if tab.chatHandler != nil {
+			if session := tab.chatHandler.GetCurrentSession(); session != nil {
+				entry.Sessions = append(entry.Sessions, shortSessionID(session.ID))
+			}
+		}
+		if tab.config != nil && tab.config.AI.Model != "" && !seenModels[tab.config.AI.Model] {
+			seenModels[tab.config.AI.Model] = true
+			entry.Models = append(entry.Models, tab.config.AI.Model)
+		}
+		for _, msg := range tab.messages {
+			switch msg.Role {
+			case "user":
+				entry.Prompts++
+			case "assistant":
+				entry.Tokens += msg.Tokens
+				entry.Cost += msg.Cost
+			}
+		}
+	}
+	return entry
+}
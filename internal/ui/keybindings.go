@@ -17,6 +17,7 @@ const (
 	ModeSearch
 	ModeScroll
 	ModePermit
+	ModeSessions
 )
 
 // String returns the string representation of the mode
@@ -34,6 +35,8 @@ func (m Mode) String() string {
 		return "SCROLL"
 	case ModePermit:
 		return "PERMIT"
+	case ModeSessions:
+		return "SESSIONS"
 	default:
 		return "UNKNOWN"
 	}
@@ -149,8 +152,10 @@ type SearchModeKeyMap struct {
 // PermitModeKeyMap defines permit mode bindings for tool call approval
 type PermitModeKeyMap struct {
 	ExitMode   key.Binding // Exit permit mode (reject by default)
+	Confirm    key.Binding // Apply the selected option
 	Approve    key.Binding // Approve the tool call
 	Reject     key.Binding // Reject the tool call
+	DenyReason key.Binding // Reject the tool call with a reason sent to the model
 	SelectPrev key.Binding // Move selection to previous option (left arrow)
 	SelectNext key.Binding // Move selection to next option (right arrow)
 }
@@ -306,8 +311,10 @@ func DefaultSearchModeKeyMap() SearchModeKeyMap {
 func DefaultPermitModeKeyMap() PermitModeKeyMap {
 	return PermitModeKeyMap{
 		ExitMode:   key.NewBinding(key.WithKeys("esc", "ctrl+c")),
-		Approve:    key.NewBinding(key.WithKeys("enter", "y")),
+		Confirm:    key.NewBinding(key.WithKeys("enter")),
+		Approve:    key.NewBinding(key.WithKeys("y")),
 		Reject:     key.NewBinding(key.WithKeys("n", "esc")),
+		DenyReason: key.NewBinding(key.WithKeys("r")),
 		SelectPrev: key.NewBinding(key.WithKeys("left", "h")),
 		SelectNext: key.NewBinding(key.WithKeys("right", "l")),
 	}
@@ -484,8 +491,11 @@ func (km KeyMap) GetHelpText(mode Mode) []string {
 
 	case ModePermit:
 		help = append(help, "Permit Mode Commands:")
+		help = append(help, "  1/2/3: Deny / Allow / Allow the tools for the rest of the session")
+		help = append(help, fmt.Sprintf("  %s: Apply the selected option", km.getKeyStrings(km.Permit.Confirm)))
 		help = append(help, fmt.Sprintf("  %s: Approve tool call", km.getKeyStrings(km.Permit.Approve)))
 		help = append(help, fmt.Sprintf("  %s: Reject tool call", km.getKeyStrings(km.Permit.Reject)))
+		help = append(help, fmt.Sprintf("  %s: Reject tool call with a reason for the model", km.getKeyStrings(km.Permit.DenyReason)))
 		help = append(help, fmt.Sprintf("  %s: Select previous option", km.getKeyStrings(km.Permit.SelectPrev)))
 		help = append(help, fmt.Sprintf("  %s: Select next option", km.getKeyStrings(km.Permit.SelectNext)))
 		help = append(help, fmt.Sprintf("  %s: Exit permit mode", km.getKeyStrings(km.Permit.ExitMode)))
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/common-creation/coda/internal/artifacts"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// linkPattern matches [text](url) markdown links (capturing the URL) and
+// URLs written out in text
+var linkPattern = regexp.MustCompile(`\[[^\]]*\]\((https?://[^)\s]+)\)|https?://[^\s<>"'\x60\]\[]+`)
+
+// openLink opens a URL in the default browser. It is a variable so tests do
+// not launch a browser.
+var openLink = artifacts.Open
+
+// extractLinks returns the http(s) URLs in content in order of appearance,
+// without duplicates
+func extractLinks(content string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	add := func(link string) {
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	for _, match := range linkPattern.FindAllStringSubmatch(content, -1) {
+		if match[1] != "" {
+			add(match[1])
+		} else {
+			add(trimURL(match[0]))
+		}
+	}
+	return links
+}
+
+// trimURL removes punctuation that ends the sentence around a URL rather
+// than the URL itself
+func trimURL(link string) string {
+	for {
+		trimmed := strings.TrimRight(link, ".,;:!?*_")
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, "(") < strings.Count(trimmed, ")") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == link {
+			return link
+		}
+		link = trimmed
+	}
+}
+
+// links returns the URLs found in the assistant messages of the conversation.
+// The numbers shown next to them are their positions in the list plus one.
+func (m Model) links() []string {
+	var links []string
+	for _, msg := range m.messages {
+		if msg.Role == "assistant" {
+			links = append(links, extractLinks(msg.Content)...)
+		}
+	}
+	return links
+}
+
+// linkCountBefore returns the number of links in the assistant messages
+// before the i-th message, which is where the numbering of its links starts
+func (m Model) linkCountBefore(i int) int {
+	count := 0
+	for _, msg := range m.messages[:i] {
+		if msg.Role == "assistant" {
+			count += len(extractLinks(msg.Content))
+		}
+	}
+	return count
+}
+
+// formatLinkReferences lists the links of an assistant message, numbered
+// from first, for use with /open N
+func formatLinkReferences(links []string, first int) string {
+	if len(links) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("References:")
+	for i, link := range links {
+		fmt.Fprintf(&b, "\n  [%d] %s", first+i, link)
+	}
+	return b.String()
+}
+
+// formatDocSources lists the documentation sections an answer may cite
+func formatDocSources(sources []chat.DocSource) string {
+	var b strings.Builder
+	b.WriteString("Sources:")
+	for _, source := range sources {
+		b.WriteString("\n  " + source.Citation())
+	}
+	return b.String()
+}
+
+// handleOpenCommand lists the links of the conversation, or opens one by its
+// number in the default browser
+func (m *Model) handleOpenCommand(args []string) {
+	links := m.links()
+	if len(args) == 0 {
+		if len(links) == 0 {
+			m.addSystemMessage("No links in this conversation yet")
+			return
+		}
+		m.addSystemMessage(formatLinkReferences(links, 1) + "\nUse /open N to open one in the browser.")
+		return
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(links) {
+		m.addSystemMessage(fmt.Sprintf("No link %q (use /open to list them)", args[0]))
+		return
+	}
+	m.openLinkNumber(links, n)
+}
+
+// openLatestLink opens the last link the assistant wrote
+func (m *Model) openLatestLink() {
+	links := m.links()
+	if len(links) == 0 {
+		m.addSystemMessage("No links in this conversation yet")
+		return
+	}
+	m.openLinkNumber(links, len(links))
+}
+
+// openLinkNumber opens the n-th link in the default browser
+func (m *Model) openLinkNumber(links []string, n int) {
+	if err := openLink(links[n-1]); err != nil {
+		m.addSystemMessage(err.Error())
+		return
+	}
+	m.addSystemMessage(fmt.Sprintf("Opened [%d] %s", n, links[n-1]))
+}
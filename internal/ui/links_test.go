@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "no links here", nil},
+		{"bare", "See https://go.dev/doc/. It helps.", []string{"https://go.dev/doc/"}},
+		{"markdown", "Read [the docs](https://pkg.go.dev/fmt) first", []string{"https://pkg.go.dev/fmt"}},
+		{"order and duplicates",
+			"https://a.example, then [b](https://b.example) and https://a.example again",
+			[]string{"https://a.example", "https://b.example"}},
+		{"parenthesized", "(see https://example.com/x)", []string{"https://example.com/x"}},
+		{"balanced parentheses", "https://en.wikipedia.org/wiki/Go_(programming_language)",
+			[]string{"https://en.wikipedia.org/wiki/Go_(programming_language)"}},
+		{"code", "`https://example.com/api`", []string{"https://example.com/api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extractLinks(tt.content))
+		})
+	}
+}
+
+func TestOpenLinks(t *testing.T) {
+	var opened []string
+	orig := openLink
+	openLink = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	defer func() { openLink = orig }()
+
+	m := newTabsTestModel()
+	m.messages = []Message{
+		{ID: "1", Role: "user", Content: "https://user.example"},
+		{ID: "2", Role: "assistant", Content: "See https://one.example"},
+		{ID: "3", Role: "assistant", Content: "[two](https://two.example) and https://three.example"},
+	}
+
+	assert.Equal(t, []string{"https://one.example", "https://two.example", "https://three.example"}, m.links())
+	assert.Equal(t, 1, m.linkCountBefore(2))
+	assert.Equal(t, "References:\n  [2] https://two.example\n  [3] https://three.example",
+		formatLinkReferences(extractLinks(m.messages[2].Content), 2))
+
+	m.handleOpenCommand([]string{"2"})
+	assert.Equal(t, []string{"https://two.example"}, opened)
+
+	m.handleOpenCommand([]string{"9"})
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, `No link "9"`)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	m = updated.(Model)
+	assert.Equal(t, []string{"https://two.example", "https://three.example"}, opened)
+}
+
+func TestFormatDocSources(t *testing.T) {
+	sources := []chat.DocSource{
+		{N: 1, Path: "docs/setup.md", StartLine: 3, EndLine: 12, Title: "Install"},
+		{N: 2, Path: "README.md", StartLine: 1, EndLine: 8},
+	}
+	assert.Equal(t, "Sources:\n  [1] docs/setup.md:3-12 (Install)\n  [2] README.md:1-8", formatDocSources(sources))
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/styles"
+	"github.com/common-creation/coda/internal/ui/components"
+)
+
+// newContentRenderer creates the markdown renderer selected in the config,
+// or nil when markdown rendering is disabled. The glamour backend falls back
+// to the builtin renderer.
+func newContentRenderer(cfg *config.Config, themeName string, st styles.Styles) components.ContentRenderer {
+	if cfg == nil || !cfg.UI.MarkdownRendering {
+		return nil
+	}
+
+	var highlighter *components.SyntaxHighlighter
+	if cfg.UI.SyntaxHighlighting {
+		highlighter = components.NewSyntaxHighlighter(st)
+	}
+	builtin := components.NewMarkdownRenderer(st, highlighter)
+
+	if strings.EqualFold(cfg.UI.MarkdownBackend, "glamour") {
+		style := cfg.UI.GlamourStyle
+		if style == "" {
+			style = components.GlamourStyleForTheme(themeName)
+		}
+		return components.NewGlamourRenderer(style, builtin)
+	}
+	return builtin
+}
+
+// formatMessage formats a message for the chat view. Assistant answers are
+// rendered as markdown below their header line when a renderer is set.
+func (m Model) formatMessage(msg Message) string {
+	if m.markdown == nil || msg.Role != "assistant" || msg.Content == "" {
+		return m.formatMessageLine(msg)
+	}
+	return fmt.Sprintf("%s:\n%s", m.messageHeader(msg), m.markdown.Render(msg.Content))
+}
@@ -0,0 +1,43 @@
+package ui
+
+const (
+	// messageWindowSize is the number of most recent messages rendered into
+	// the viewport; older ones are rendered when scrolled to
+	messageWindowSize = 200
+
+	// messageWindowStep is the number of earlier messages rendered each time
+	// the top of the viewport is reached
+	messageWindowStep = 100
+)
+
+// hiddenMessages returns the number of earliest messages that are not
+// rendered into the viewport
+func (m Model) hiddenMessages() int {
+	window := m.messageWindow
+	if window <= 0 {
+		window = messageWindowSize
+	}
+	if len(m.messages) <= window {
+		return 0
+	}
+	return len(m.messages) - window
+}
+
+// revealEarlierMessages renders more of the earlier messages when the
+// viewport is scrolled to its top, keeping the lines in view where they are
+func (m *Model) revealEarlierMessages() {
+	hidden := m.hiddenMessages()
+	if hidden == 0 || !m.viewport.AtTop() {
+		return
+	}
+
+	before, matches := m.viewport.TotalLineCount(), len(m.viewMatches)
+	m.messageWindow = len(m.messages) - hidden + min(hidden, messageWindowStep)
+	m.renderMessages()
+	m.viewport.SetYOffset(m.viewport.TotalLineCount() - before)
+	// The current match keeps its place after the matches that appeared above it
+	if matches > 0 {
+		m.currentMatch += len(m.viewMatches) - matches
+		m.renderMessages()
+	}
+}
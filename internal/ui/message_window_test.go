@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageWindow(t *testing.T) {
+	m := newTabsTestModel()
+	m.viewport.Width = 80
+	m.viewport.Height = 10
+	m.messages = nil
+	for i := 0; i < 450; i++ {
+		m.messages = append(m.messages, Message{
+			ID:      fmt.Sprintf("m%d", i),
+			Role:    "assistant",
+			Content: fmt.Sprintf("answer %d https://example.com/%d", i, i),
+		})
+	}
+
+	m.updateViewportContent()
+	assert.Equal(t, 250, m.hiddenMessages())
+	assert.Len(t, m.rendered.entries, messageWindowSize, "only the window is rendered")
+	assert.Len(t, m.rendered.links, 450)
+	// Links are numbered across the whole conversation
+	assert.Contains(t, m.viewport.View(), "[450] https://example.com/449")
+
+	// Scrolling away from the top renders nothing more
+	m.viewport.LineUp(5)
+	m.revealEarlierMessages()
+	assert.Equal(t, 250, m.hiddenMessages())
+
+	// Reaching the top renders the next step of earlier messages and keeps
+	// the lines that were in view
+	m.viewport.GotoTop()
+	top := m.viewport.View()
+	m.revealEarlierMessages()
+	assert.Equal(t, 150, m.hiddenMessages())
+	assert.Len(t, m.rendered.entries, messageWindowSize+messageWindowStep)
+	assert.False(t, m.viewport.AtTop())
+	assert.Contains(t, m.viewport.View(), "answer 249 https://example.com/249")
+	assert.NotEqual(t, top, m.viewport.View())
+
+	// New content goes back to the bottom and the default window
+	m.updateViewportContent()
+	assert.Equal(t, 250, m.hiddenMessages())
+	assert.Len(t, m.rendered.entries, messageWindowSize)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,15 +16,27 @@ import (
 	"github.com/charmbracelet/log"
 
 	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/changes"
 	"github.com/common-creation/coda/internal/chat"
 	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/container"
 	"github.com/common-creation/coda/internal/errors"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/startup"
 	"github.com/common-creation/coda/internal/styles"
 	"github.com/common-creation/coda/internal/tokenizer"
 	"github.com/common-creation/coda/internal/tools"
+	"github.com/common-creation/coda/internal/transcript"
 	"github.com/common-creation/coda/internal/ui/components"
 )
 
+// viewClock and viewDir return the time and working directory the view
+// shows; the snapshot tests fix them
+var (
+	viewClock = time.Now
+	viewDir   = os.Getwd
+)
+
 // ViewType represents the currently active view
 type ViewType int
 
@@ -42,6 +55,14 @@ type Message struct {
 	Timestamp time.Time
 	Tokens    int
 	Error     error
+	Duration  time.Duration // Time taken to answer or to execute the tool
+
+	TokensExact bool    // Tokens was reported by the API rather than estimated
+	Cost        float64 // USD cost of the request that produced the message (0 if unknown)
+
+	Sources []chat.DocSource // Documentation sections the answer may cite
+
+	FallbackModel string // Fallback model that answered instead of the configured one
 }
 
 // Removed old KeyMap definition - now using the advanced keybindings system
@@ -59,61 +80,65 @@ type Model struct {
 	// statusView StatusView
 	// helpView   HelpView
 
+	// State of the active tab's conversation, swapped as a whole when
+	// switching tabs
+	conversation
+
 	// Application state
-	activeView   ViewType
-	messages     []Message
-	currentInput string
-	showHelp     bool
-	loading      bool
-	error        error
+	activeView ViewType
+	showHelp   bool
+	error      error
 
 	// Spinner and timing
 	spinner spinner.Model
 
 	// Viewport for chat history
 	viewport        viewport.Model
-	loadingStart    time.Time
-	estimatedTokens int       // Estimated tokens for the current request
-	userInputTokens int       // Estimated tokens for just the user input
-	lastTokenUsage  *ai.Usage // Last response token usage
-
-	// Streaming state
-	streamingContent strings.Builder // Buffer for streaming content
+	tokenizerWarned bool // Whether the user was told token counts are approximate
 
 	// Styles
 	styles styles.Styles
 
-	// Input mode state - Always INSERT mode for IME support
-	currentMode   Mode
-	previousMode  Mode
-	commandBuffer string
-	searchBuffer  string
-	searchResults []int // indices of matching messages
-	currentMatch  int
+	// Markdown renderer for assistant answers (nil renders plain text)
+	markdown components.ContentRenderer
+
+	// Slow work run in the background: token counts and large answers
+	workers *workerPool
+
+	// Rendered messages, so only new or changed ones are rendered again
+	rendered *renderCache
+
+	messageLines []int // Viewport line each message starts at (-1 if not rendered)
+	pendingG     bool  // "g" was pressed in scroll mode; "gg" jumps to the first message
 
-	// Tool call permit dialog state
-	pendingToolCalls     []ai.ToolCall // Tool calls waiting for user approval
-	selectedPermitOption int           // Currently selected option (0=reject, 1=approve)
-	permitDialogVisible  bool          // Whether permit dialog is currently visible
+	// Approval rules answering tool calls without the permit dialog
+	approvalRules *security.ApprovalRules
 
-	// Cursor position management
-	cursorPosition int // カーソル位置（rune単位）
-	cursorColumn   int // 現在の列位置（上下移動時の列位置保持用）
+	// Startup tasks still running in the background when the TUI opened
+	startup *startup.Tracker
+
+	// Session tabs; the active tab's state lives in conversation
+	tabs      []*sessionTab
+	activeTab int // Index of the active tab
+	nextTabID int // ID of the most recently opened tab
+	jobs      *jobList
+
+	// Session picker state
+	pickerSessions        []*chat.Session // Sessions listed in the picker
+	selectedPickerSession int             // Index of the highlighted session
 
 	// Cursor styles
 	cursorStyle      lipgloss.Style // 文字列中のカーソル用（背景色反転）
 	blockCursorStyle lipgloss.Style // 行末カーソル用（ブロックシンボル）
 
 	// Input scroll position
-	inputScrollPosition int  // 入力欄のスクロール位置
 	inputNeedsScrollbar bool // 入力欄にスクロールバーが必要か
 	inputTotalLines     int  // 入力の総行数
 	inputDisplayHeight  int  // 表示される行数
 
 	// Dependencies
-	config           *config.Config
-	chatHandler      *chat.ChatHandler
 	toolManager      *tools.Manager
+	changeTracker    *changes.Tracker
 	logger           *log.Logger
 	ctx              context.Context
 	errorHandler     *errors.ErrorHandler
@@ -121,6 +146,8 @@ type Model struct {
 	errorBanner      *components.ErrorBanner
 	toast            *components.ToastNotification
 	showErrorDetails bool
+	errorSeq         int    // Incremented for every displayed error
+	saveErrorShown   string // Autosave failure already shown in a toast
 
 	// Configuration
 	keymap KeyMap
@@ -128,6 +155,12 @@ type Model struct {
 	// Initial message to send on startup
 	initialMessage string
 
+	// Inline mode state
+	inline bool
+
+	// Transcript mirroring the conversation of every tab
+	transcript *transcript.Writer
+
 	// Ctrl+C double press handling
 	lastCtrlCTime time.Time
 	ctrlCMessage  string
@@ -146,10 +179,25 @@ type ModelOptions struct {
 	Config         *config.Config
 	ChatHandler    *chat.ChatHandler
 	ToolManager    *tools.Manager
+	ChangeTracker  *changes.Tracker
 	Logger         *log.Logger
 	Context        context.Context
 	ErrorHandler   *errors.ErrorHandler
 	InitialMessage string // Initial message to send on startup
+
+	// Inline renders without the alternate screen: finished messages are
+	// printed to the terminal scrollback and only the input area is redrawn
+	Inline bool
+
+	// Transcript receives every finished message as it is added
+	Transcript *transcript.Writer
+
+	// ApprovalRules answer matching tool calls without asking (optional)
+	ApprovalRules *security.ApprovalRules
+
+	// Startup tracks the tasks still running in the background; they are
+	// listed in the status line and their notices shown when ready (optional)
+	Startup *startup.Tracker
 }
 
 // NewModel creates a new UI model
@@ -173,58 +221,64 @@ func NewModel(opts ModelOptions) Model {
 		height: 24,
 		ready:  false,
 
-		// Initialize application state
-		activeView:   ViewChat,
-		messages:     make([]Message, 0),
-		currentInput: "",
-		showHelp:     false,
-		loading:      false,
-		error:        nil,
-
-		// Initialize spinner and timing
-		spinner:         s,
-		loadingStart:    time.Time{},
-		estimatedTokens: 0,
-		userInputTokens: 0,
-		lastTokenUsage:  nil,
-
-		// Initialize streaming state
-		streamingContent: strings.Builder{},
-
-		// Initialize styles
-		styles: theme.GetStyles(),
+		conversation: conversation{
+			config:       opts.Config,
+			chatHandler:  opts.ChatHandler,
+			messages:     make([]Message, 0),
+			currentInput: "",
+			loading:      false,
+
+			// Initialize timing
+			loadingStart:    time.Time{},
+			estimatedTokens: 0,
+			userInputTokens: 0,
+			lastTokenUsage:  nil,
+
+			// Initialize input mode state - Always INSERT mode for IME support
+			currentMode:   ModeInsert, // Always start in Insert mode for IME
+			previousMode:  ModeInsert,
+			commandBuffer: "",
+			searchBuffer:  "",
+			searchResults: make([]int, 0),
+			currentMatch:  0,
+
+			// Initialize tool call permit dialog state
+			pendingToolCalls:     make([]ai.ToolCall, 0),
+			selectedPermitOption: 0, // Default to reject (0)
+			permitDialogVisible:  false,
+
+			// Initialize cursor position
+			cursorPosition:      0,
+			cursorColumn:        0,
+			inputScrollPosition: 0,
+		},
 
-		// Initialize input mode state - Always INSERT mode for IME support
-		currentMode:   ModeInsert, // Always start in Insert mode for IME
-		previousMode:  ModeInsert,
-		commandBuffer: "",
-		searchBuffer:  "",
-		searchResults: make([]int, 0),
-		currentMatch:  0,
+		// Initialize application state
+		activeView: ViewChat,
+		showHelp:   false,
+		error:      nil,
 
-		// Initialize tool call permit dialog state
-		pendingToolCalls:     make([]ai.ToolCall, 0),
-		selectedPermitOption: 0, // Default to reject (0)
-		permitDialogVisible:  false,
+		// Initialize spinner
+		spinner: s,
 
-		// Initialize cursor position
-		cursorPosition: 0,
-		cursorColumn:   0,
+		// Initialize styles
+		styles:   theme.GetStyles(),
+		markdown: newContentRenderer(opts.Config, themeName, theme.GetStyles()),
+		workers:  newWorkerPool(newContentRenderer(opts.Config, themeName, theme.GetStyles())),
+		rendered: newRenderCache(),
 
 		// Initialize cursor styles
 		cursorStyle:      lipgloss.NewStyle().Reverse(true),
 		blockCursorStyle: lipgloss.NewStyle(),
 
 		// Initialize input scroll position
-		inputScrollPosition: 0,
 		inputNeedsScrollbar: false,
 		inputTotalLines:     0,
 		inputDisplayHeight:  0,
 
 		// Set dependencies
-		config:           opts.Config,
-		chatHandler:      opts.ChatHandler,
 		toolManager:      opts.ToolManager,
+		changeTracker:    opts.ChangeTracker,
 		logger:           opts.Logger,
 		ctx:              opts.Context,
 		errorHandler:     opts.ErrorHandler,
@@ -233,11 +287,20 @@ func NewModel(opts ModelOptions) Model {
 		toast:            nil,
 		showErrorDetails: false,
 
+		// The first tab uses the handler passed by the caller
+		tabs:      []*sessionTab{{id: 1, conversation: conversation{config: opts.Config, chatHandler: opts.ChatHandler}}},
+		nextTabID: 1,
+		jobs:      newJobList(),
+
 		// Set keymap
 		keymap: DefaultKeyMap(),
 
 		// Set initial message
 		initialMessage: opts.InitialMessage,
+		inline:         opts.Inline,
+		transcript:     opts.Transcript,
+		approvalRules:  opts.ApprovalRules,
+		startup:        opts.Startup,
 
 		// Initialize Ctrl+C double press handling
 		lastCtrlCTime: time.Time{},
@@ -257,9 +320,16 @@ func NewModel(opts ModelOptions) Model {
 func (m Model) Init() tea.Cmd {
 	m.logger.Debug("Initializing UI model")
 
+	var enterScreen tea.Cmd
+	if !m.inline {
+		enterScreen = tea.EnterAltScreen
+	}
+
 	return tea.Batch(
-		tea.EnterAltScreen,
+		enterScreen,
 		m.spinner.Tick,
+		m.autoSaveTick(),
+		m.startupTick(),
 		func() tea.Msg {
 			return readyMsg{}
 		},
@@ -268,6 +338,47 @@ func (m Model) Init() tea.Cmd {
 
 // Update implements tea.Model interface
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var updated tea.Model
+	var cmd tea.Cmd
+
+	// Results of commands started in another tab are applied to that tab
+	if routed, ok := msg.(tabMsg); ok {
+		updated, cmd = m.updateTab(routed)
+	} else {
+		tab := m.activeTabID()
+		updated, cmd = m.update(msg)
+		cmd = tagTabCmd(tab, cmd)
+	}
+
+	// Some handlers return the model by pointer
+	if ptr, isPtr := updated.(*Model); isPtr {
+		updated = *ptr
+	}
+	model, ok := updated.(Model)
+	if !ok {
+		return updated, cmd
+	}
+	model.teeNewMessages()
+	if model.inline {
+		if print := model.printNewMessages(); print != nil {
+			cmd = tea.Batch(print, cmd)
+		}
+	}
+
+	// Start the slow work queued while updating
+	if model.workers != nil {
+		if model.chatHandler != nil {
+			model.countTextTokens(model.chatHandler.GetSystemPrompt())
+		}
+		if work := model.workers.start(); work != nil {
+			cmd = tea.Batch(cmd, work)
+		}
+	}
+	return model, cmd
+}
+
+// update applies a message to the active tab
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	// Update viewport only when in scroll mode or for mouse events
@@ -307,6 +418,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if vpCmd != nil {
 			cmds = append(cmds, vpCmd)
 		}
+		m.revealEarlierMessages()
 	}
 
 	switch msg := msg.(type) {
@@ -342,6 +454,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.Height = viewportHeight
 		}
 
+		// Answers are reflowed to the new width
+		if m.markdown != nil {
+			m.markdown.SetMaxWidth(viewportWidth)
+		}
+		if m.workers != nil {
+			m.workers.setWidth(viewportWidth)
+		}
+
 		// Update viewport content
 		m.updateViewportContent()
 
@@ -357,6 +477,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case readyMsg:
 		m.ready = true
 		m.logger.Debug("UI model ready")
+		if m.startup != nil {
+			m.startup.Mark("first frame")
+		}
+
+		// Inline mode has no chat view, so the header goes to the scrollback
+		if m.inline {
+			cmds = append(cmds, tea.Println(m.renderHeader()))
+		}
+
+		// Show a session resumed with --continue
+		if m.chatHandler != nil {
+			if session := m.chatHandler.GetCurrentSession(); session != nil && len(session.Messages) > 0 {
+				m.loadSession(session)
+			}
+			if m.chatHandler.IsSessionSealed() {
+				m.addSystemMessage("This session is sealed: it is kept as it is, and sending a message continues in a fork")
+			} else if m.chatHandler.IsSessionReadOnly() {
+				m.addSystemMessage("This session is open in another CODA instance: it is read-only and changes will not be saved")
+			} else if m.chatHandler.HasInterruptedResponse() {
+				m.addSystemMessage("The last response was interrupted when CODA exited. Type /continue to finish it.")
+			}
+		}
 
 		// Send initial message if provided
 		if m.initialMessage != "" {
@@ -367,41 +509,94 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case chatResponseMsg:
+		// The session is saved after each response
+		if m.chatHandler != nil {
+			m.reportSaveError(m.chatHandler.SaveError())
+		}
+
 		// Use completion tokens for assistant message
 		assistantTokens := 0
 		if msg.TokenUsage != nil {
 			assistantTokens = msg.TokenUsage.CompletionTokens
 		}
 
+		var duration time.Duration
+		if !m.loadingStart.IsZero() {
+			duration = time.Since(m.loadingStart)
+		}
+		var cost float64
+		if msg.TokenUsage != nil && m.config != nil {
+			model := m.config.AI.Model
+			if msg.FallbackModel != "" {
+				model = msg.FallbackModel
+			}
+			cost, _ = estimateCost(model, msg.TokenUsage.PromptTokens, msg.TokenUsage.CompletionTokens)
+		}
 		m.messages = append(m.messages, Message{
-			ID:        msg.ID,
-			Content:   msg.Content,
-			Role:      "assistant",
-			Timestamp: time.Now(),
-			Tokens:    assistantTokens,
+			ID:          msg.ID,
+			Content:     msg.Content,
+			Role:        "assistant",
+			Timestamp:   time.Now(),
+			Tokens:      assistantTokens,
+			Duration:    duration,
+			TokensExact: msg.TokenUsage != nil && !msg.UsageEstimated,
+			Cost:        cost,
+			Sources:     msg.Sources,
+
+			FallbackModel: msg.FallbackModel,
 		})
 		m.loading = false
 		m.lastTokenUsage = msg.TokenUsage
-		// Reset streaming state
-		m.streamingContent.Reset()
+		m.lastUsageExact = msg.TokenUsage != nil && !msg.UsageEstimated
 		// Reset user input tokens
 		m.userInputTokens = 0
 		// Update viewport content with new message
 		m.updateViewportContent()
+		m.jobResponded(len(msg.ToolCalls))
+
+		// The turn is over without tool calls: send a failing compile check
+		// back, or the next queued prompt
+		if len(msg.ToolCalls) == 0 {
+			m.noteRegenerated()
+			if cmd := m.sendCompileFailure(); cmd != nil {
+				cmds = append(cmds, cmd)
+			} else {
+				cmds = append(cmds, m.sendQueuedPrompt())
+			}
+		}
 
-		// Check for tool calls and enter permit mode if needed
-		if len(msg.ToolCalls) > 0 {
-			m.pendingToolCalls = msg.ToolCalls
+		// Approval rules and tools allowed for the session answer without asking
+		ask, run, denied := m.decideToolCalls(msg.ToolCalls)
+		if len(ask) == 0 && len(msg.ToolCalls) > 0 {
+			cmds = append(cmds, m.runToolCalls(run, denied))
+		} else if len(ask) > 0 {
+			// Enter permit mode for the other tool calls
+			m.pendingToolCalls = ask
+			m.ruleApproved, m.ruleDenied = run, denied
 			m.permitDialogVisible = true
-			m.selectedPermitOption = 0 // Default to reject
+			m.selectedPermitOption = permitDeny // Default to reject
 			// Store current mode and switch to permit mode
 			if m.currentMode != ModePermit {
 				m.previousMode = m.currentMode
 				m.currentMode = ModePermit
 			}
+			// Unanswered dialogs fall back to the configured default decision
+			m.permitSeq++
+			m.permitDeadline = time.Time{}
+			if timeout := m.permitTimeout(); timeout > 0 {
+				m.permitDeadline = time.Now().Add(timeout)
+				cmds = append(cmds, permitCountdownTick(m.permitSeq))
+			}
 		}
 
 	case errorMsg:
+		// Canceled jobs are reported without the error dialog
+		if job := m.finishJob(); job != nil && job.canceled {
+			m.loading = false
+			m.addSystemMessage(fmt.Sprintf("Job %d canceled", job.id))
+			break
+		}
+
 		m.error = msg.error
 		m.loading = false
 
@@ -411,8 +606,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Update error display
+		m.errorSeq++
 		if m.errorDisplay != nil {
 			m.errorDisplay.SetError(msg.error)
+			// Start the countdown for errors that are retried automatically
+			if m.errorDisplay.Remediation().RetryAfter > 0 {
+				cmds = append(cmds, errorCountdownTick(m.errorSeq))
+			}
 		}
 
 		// Create toast notification for user errors
@@ -433,6 +633,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.toast = nil
 
+	case errorCountdownMsg:
+		// Ignore ticks for errors that were dismissed or replaced
+		if m.error != nil && msg.seq == m.errorSeq && m.errorDisplay != nil {
+			if m.errorDisplay.RetryRemaining() > 0 {
+				cmds = append(cmds, errorCountdownTick(msg.seq))
+			} else {
+				m.error = nil
+				m.errorDisplay.SetError(nil)
+				cmds = append(cmds, func() tea.Msg { return retryLastActionMsg{} })
+			}
+		}
+
+	case permitCountdownMsg:
+		// Ignore ticks for dialogs that were already answered
+		if m.permitDialogVisible && msg.seq == m.permitSeq && !m.permitDeadline.IsZero() {
+			if time.Now().Before(m.permitDeadline) {
+				cmds = append(cmds, permitCountdownTick(msg.seq))
+			} else {
+				_, cmd := m.applyPermitDefault()
+				cmds = append(cmds, cmd)
+			}
+		}
+
 	case toggleErrorDetailsMsg:
 		m.showErrorDetails = !m.showErrorDetails
 		if m.errorDisplay != nil {
@@ -440,15 +663,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case retryLastActionMsg:
-		// Find the last user message and retry
-		for i := len(m.messages) - 1; i >= 0; i-- {
-			if m.messages[i].Role == "user" {
-				m.currentInput = m.messages[i].Content
-				_, cmd := m.sendMessage()
-				cmds = append(cmds, cmd)
-				break
-			}
-		}
+		_, cmd := m.retryLastMessage()
+		cmds = append(cmds, cmd)
 
 	case clearCtrlCMsg:
 		// Clear the Ctrl+C message if it hasn't been cleared already
@@ -471,9 +687,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case toolExecutionMsg:
 		// Tool execution completed, send results to LLM
 		m.logger.Debug("Tool execution completed", "count", len(msg.results))
+		if job := m.activeJob(); job != nil {
+			job.executing = false
+			if job.canceled {
+				// Keep what the tools did before the cancel, but stop the chain
+				m.recordToolResults(msg.results)
+				m.finishJob()
+				m.addSystemMessage(fmt.Sprintf("Job %d canceled during tool execution", job.id))
+				return m, nil
+			}
+		}
 		// Convert tool results to messages and send back to LLM
+		m.noteCompileCheck(msg.results)
 		return m, m.sendToolResults(msg.results)
 
+	case commandPaneTickMsg:
+		// Redraw the command output until the command finishes
+		if msg.pane.isRunning() {
+			return m, commandPaneTick(msg.pane)
+		}
+		return m, nil
+
 	case loadingMsg:
 		m.loading = msg.loading
 
@@ -489,6 +723,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case screenRefreshMsg:
 		// Screen refresh - just return to trigger a View() redraw
 		return m, nil
+
+	case workflowDoneMsg:
+		m.finishWorkflow(msg)
+
+	case promptSuggestionMsg:
+		m.showSuggestion(msg)
+
+	case modelMetadataMsg:
+		m.addSystemMessage(formatModelRefresh(msg))
+
+	case codeActionMsg:
+		m.addSystemMessage(formatCodeAction(msg))
+
+	case autoSaveMsg:
+		cmds = append(cmds, m.autoSave(), m.autoSaveTick())
+
+	case workerDoneMsg:
+		m.applyWorkerResult(msg)
+
+	case startupTickMsg:
+		cmds = append(cmds, m.checkStartup())
+
+	case autoSaveDoneMsg:
+		m.reportSaveError(msg.err)
+
+	case pingMsg:
+		m.addSystemMessage(m.formatPing(msg))
+
+	case scanMsg:
+		cmds = append(cmds, m.finishScan(msg))
+
+	case taskPromptMsg:
+		m.applyTaskPrompt(msg)
 	}
 
 	// Update view components (when implemented)
@@ -524,10 +791,19 @@ func (m Model) View() string {
 		view.WriteString("\n")
 	}
 
-	// Main content
+	// Main content; inline mode prints the conversation to the scrollback instead
 	if m.showHelp {
 		view.WriteString(m.renderHelp())
-	} else {
+	} else if !m.inline {
+		// The output of a running command takes the bottom of the chat area,
+		// and input problems and queued prompts are shown under the input
+		paneHeight := m.commandPaneHeight()
+		underLines := 0
+		if under := m.renderUnderInput(); under != "" {
+			underLines = strings.Count(under, "\n") + 1
+		}
+		m.viewport.Height = max(m.viewport.Height-paneHeight-m.scratchpadHeight()-m.taskFormHeight()-underLines, 1)
+
 		// Render viewport and scrollbar side by side
 		chatView := m.renderChat()
 		scrollbarView := m.renderScrollbar()
@@ -558,6 +834,9 @@ func (m Model) View() string {
 		}
 
 		view.WriteString(strings.Join(combined, "\n"))
+		if paneHeight > 0 {
+			view.WriteString("\n" + m.renderCommandPane())
+		}
 	}
 
 	// Error banner for less critical errors
@@ -583,9 +862,24 @@ func (m Model) View() string {
 		view.WriteString(loadingMsg)
 	}
 
+	if scratchpad := m.renderScratchpad(); scratchpad != "" {
+		view.WriteString("\n")
+		view.WriteString(scratchpad)
+	}
+
+	if form := m.renderTaskForm(); form != "" {
+		view.WriteString("\n")
+		view.WriteString(form)
+	}
+
 	view.WriteString("\n")
 	view.WriteString(m.renderInput())
 
+	if under := m.renderUnderInput(); under != "" {
+		view.WriteString("\n")
+		view.WriteString(under)
+	}
+
 	// Token usage display (right-aligned below input)
 	if tokenUsage := m.renderTokenUsage(); tokenUsage != "" {
 		view.WriteString("\n")
@@ -617,12 +911,51 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handlePermitModeKeys(msg)
 	}
 
+	// Session picker captures all keys while open
+	if m.currentMode == ModeSessions {
+		return m.handleSessionPickerKeys(msg)
+	}
+
+	// A large paste waits for the user to choose how to use it
+	if m.pendingPaste != "" {
+		m.handlePasteKeys(key)
+		return m, nil
+	}
+
+	// The scratchpad panel takes the typing while it is open
+	if m.scratchpadOpen {
+		m.handleScratchpadKeys(msg)
+		return m, nil
+	}
+
+	// So does an open task form
+	if m.taskForm != nil {
+		return m, m.handleTaskFormKeys(msg)
+	}
+	if key == "ctrl+k" && m.error == nil {
+		m.toggleScratchpad()
+		return m, nil
+	}
+
 	// Handle error-specific key bindings first (when error is displayed)
 	if m.error != nil {
 		switch key {
-		case "enter", "esc":
+		case "esc":
 			// Dismiss error
 			return m, func() tea.Msg { return dismissErrorMsg{} }
+		case "enter":
+			// Run the selected remediation action
+			return m.executeErrorAction()
+		case "left", "shift+tab":
+			if m.errorDisplay != nil {
+				m.errorDisplay.SelectPrevAction()
+			}
+			return m, nil
+		case "right", "tab":
+			if m.errorDisplay != nil {
+				m.errorDisplay.SelectNextAction()
+			}
+			return m, nil
 		case "d":
 			// Toggle error details
 			return m, func() tea.Msg { return toggleErrorDetailsMsg{} }
@@ -641,9 +974,52 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Session tabs can be switched at any time
+	if m.handleTabKeys(key) {
+		m.suggestion = ""
+		return m, nil
+	}
+
+	// Searching takes all keys; browsing the conversation keeps Ctrl+C and F1
+	if m.currentMode == ModeSearch {
+		return m.handleSearchModeKeys(msg)
+	}
+	if m.currentMode == ModeScroll && key != "ctrl+c" && key != "f1" {
+		return m.handleScrollModeKeys(msg)
+	}
+
+	// Input problems are shown until the input is edited
+	if key != "enter" {
+		m.inputErrors = nil
+	}
+
+	// A prompt suggestion is accepted with Tab and dropped by any other key
+	if m.suggestion != "" && key == "tab" {
+		m.acceptSuggestion()
+		return m, nil
+	}
+	m.suggestion = ""
+	if key == "ctrl+@" {
+		return m, m.requestSuggestion()
+	}
+
 	// Handle global keys
 	switch key {
 	case "ctrl+c":
+		// A running command is canceled on its own, and the agent carries on
+		// with its output
+		if pane := m.runningCommand(); pane != nil && pane.cancelCommand() {
+			m.addSystemMessage("Command canceled")
+			return m, nil
+		}
+
+		// A running request or tool execution is canceled first; quitting
+		// takes a double press once nothing is running
+		if job := m.activeJob(); job != nil && !job.waiting && !job.canceled {
+			m.cancelJob(job)
+			return m, nil
+		}
+
 		// Check if this is a double press within 1 second
 		now := time.Now()
 		if !m.lastCtrlCTime.IsZero() && now.Sub(m.lastCtrlCTime) < time.Second {
@@ -662,12 +1038,30 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.showHelp = !m.showHelp
 		}
 		return m, nil
-	case "enter":
-		// Enter で送信
-		if strings.TrimSpace(m.currentInput) != "" {
-			return m.sendMessage()
+	case "pgup", "pgdown":
+		// Scroll the output of a running command
+		if pane := m.runningCommand(); pane != nil {
+			delta := commandPaneLines / 2
+			if key == "pgdown" {
+				delta = -delta
+			}
+			pane.scrollBy(delta)
 		}
 		return m, nil
+	case "enter":
+		// Enter で送信
+		input := strings.TrimSpace(m.currentInput)
+		if input == "" {
+			// Prompts queued before a failed turn are resumed with Enter
+			return m, m.sendQueuedPrompt()
+		}
+		// Prompts sent while a turn is running wait for it to finish, and
+		// go behind the prompts already queued
+		if (m.busy() || len(m.queuedPrompts) > 0) && !strings.HasPrefix(input, "/") {
+			m.queuePrompt(input)
+			return m, m.sendQueuedPrompt()
+		}
+		return m.sendMessage()
 	case "ctrl+j":
 		// Ctrl+J (Shift+Enter in iTerm2) で改行を挿入
 		m.insertTextAtCursor("\n")
@@ -747,32 +1141,20 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Tick(time.Second, func(t time.Time) tea.Msg {
 			return clearEscMsg{}
 		})
+	case "ctrl+b":
+		m.backgroundJob()
+		return m, nil
+	case "ctrl+g":
+		m.openLatestLink()
+		return m, nil
 	case "ctrl+n":
 		// Check if this is a double press within 1 second
 		now := time.Now()
 		if !m.lastCtrlNTime.IsZero() && now.Sub(m.lastCtrlNTime) < time.Second {
 			// Second press within 1 second, create new session
-			m.messages = make([]Message, 0)
-			m.currentInput = ""
-			m.cursorPosition = 0
-			m.cursorColumn = 0
-			m.inputScrollPosition = 0
-			m.error = nil
-			m.loading = false
-			m.streamingContent.Reset()
-			m.lastTokenUsage = nil
-			m.estimatedTokens = 0
-			m.userInputTokens = 0
 			m.ctrlNMessage = ""
 			m.lastCtrlNTime = time.Time{}
-			// Create a new session in chat handler
-			if m.chatHandler != nil {
-				if err := m.chatHandler.CreateNewSession(); err != nil {
-					m.logger.Error("Failed to create new session", "error", err)
-				}
-			}
-			// Update viewport to show welcome message
-			m.updateViewportContent()
+			m.startNewSession()
 			return m, nil
 		}
 		// First press or too much time passed
@@ -784,6 +1166,12 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		})
 	}
 
+	// Large pastes are offered as an attachment instead
+	if threshold := m.pasteThreshold(); msg.Paste && threshold > 0 && len(msg.Runes) > threshold {
+		m.pendingPaste = string(msg.Runes)
+		return m, nil
+	}
+
 	// Handle regular text input (including IME)
 	if msg.Runes != nil && len(msg.Runes) > 0 {
 		m.insertTextAtCursor(string(msg.Runes))
@@ -876,12 +1264,7 @@ func (m Model) handleNormalModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	if m.keymap.IsMatch(key, m.keymap.Normal.SearchMode) {
 		m.previousMode = m.currentMode
-		m.currentMode = ModeSearch
-		if key == "/" {
-			m.searchBuffer = "/"
-		} else {
-			m.searchBuffer = "?"
-		}
+		m.startSearch(key)
 		return m, nil
 	}
 
@@ -1021,6 +1404,17 @@ func (m Model) handleCommandModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// startSearch switches to search mode to type a query. "?" searches
+// backward from the top of the view.
+func (m *Model) startSearch(key string) {
+	m.currentMode = ModeSearch
+	m.searchBackward = key == "?"
+	m.searchBuffer = "/"
+	if m.searchBackward {
+		m.searchBuffer = "?"
+	}
+}
+
 // handleSearchModeKeys handles keys in search mode
 func (m Model) handleSearchModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
@@ -1029,45 +1423,44 @@ func (m Model) handleSearchModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.keymap.IsMatch(key, m.keymap.Search.ExitMode) {
 		m.currentMode = m.previousMode
 		m.searchBuffer = ""
-		m.searchResults = make([]int, 0)
+		m.clearSearch()
 		return m, nil
 	}
 
-	// Execute search
+	// Execute search and browse the matches in scroll mode
 	if m.keymap.IsMatch(key, m.keymap.Search.Execute) {
 		m.performSearch(m.searchBuffer[1:]) // Remove the '/' or '?'
-		m.currentMode = m.previousMode
+		m.searchBuffer = ""
+		m.currentMode = ModeScroll
 		return m, nil
 	}
 
-	// Navigate search results
+	// Add characters to search buffer. They take precedence over the match
+	// navigation keys, which are used as n/N once the search is executed.
+	if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+		m.searchBuffer += string(msg.Runes)
+		return m, nil
+	}
+
+	// Navigate the matches of the previous search
 	if m.keymap.IsMatch(key, m.keymap.Search.NextMatch) {
-		if len(m.searchResults) > 0 {
-			m.currentMatch = (m.currentMatch + 1) % len(m.searchResults)
-		}
+		m.nextMatch(false)
 		return m, nil
 	}
 
 	if m.keymap.IsMatch(key, m.keymap.Search.PrevMatch) {
-		if len(m.searchResults) > 0 {
-			m.currentMatch = (m.currentMatch - 1 + len(m.searchResults)) % len(m.searchResults)
-		}
+		m.nextMatch(true)
 		return m, nil
 	}
 
 	// Handle backspace
 	if key == "backspace" {
-		if len(m.searchBuffer) > 1 { // Keep the '/' or '?'
-			m.searchBuffer = m.searchBuffer[:len(m.searchBuffer)-1]
+		if runes := []rune(m.searchBuffer); len(runes) > 1 { // Keep the '/' or '?'
+			m.searchBuffer = string(runes[:len(runes)-1])
 		}
 		return m, nil
 	}
 
-	// Add characters to search buffer
-	if len(key) == 1 && key != "\x00" {
-		m.searchBuffer += key
-	}
-
 	return m, nil
 }
 
@@ -1078,25 +1471,41 @@ func (m Model) handleScrollModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Exit scroll mode with Esc or Ctrl+Y
 	if key == "esc" || key == "ctrl+y" {
 		m.currentMode = m.previousMode
+		m.clearSearch()
+		return m, nil
+	}
+
+	// Jump between messages
+	if m.handleJumpKeys(key) {
+		return m, nil
+	}
+	if key == "z" {
+		m.toggleCollapsed()
+		return m, nil
+	}
+
+	// Search the conversation and jump between the matches
+	if m.keymap.IsMatch(key, m.keymap.Normal.SearchMode) {
+		m.startSearch(key)
+		return m, nil
+	}
+	if m.searchQuery != "" && m.keymap.IsMatch(key, m.keymap.Search.NextMatch) {
+		m.nextMatch(false)
+		return m, nil
+	}
+	if m.searchQuery != "" && m.keymap.IsMatch(key, m.keymap.Search.PrevMatch) {
+		m.nextMatch(true)
 		return m, nil
 	}
 
-	// In scroll mode, let viewport handle arrow keys
-	// The actual scrolling is handled by viewport update in Update()
+	// Arrow and page keys are handled by the viewport update in Update()
 	switch key {
-	case "up":
-		m.viewport.LineUp(1)
-	case "down":
-		m.viewport.LineDown(1)
-	case "pgup":
-		m.viewport.ViewUp()
-	case "pgdown":
-		m.viewport.ViewDown()
 	case "home":
 		m.viewport.GotoTop()
 	case "end":
 		m.viewport.GotoBottom()
 	}
+	m.revealEarlierMessages()
 
 	return m, nil
 }
@@ -1105,14 +1514,39 @@ func (m Model) handleScrollModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handlePermitModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 
+	// Typing a reason for denying or a confirmation takes all keys
+	if m.typingPermitReason {
+		return m.handlePermitReasonKeys(msg)
+	}
+	if m.typingPermitConfirm {
+		return m.handlePermitConfirmKeys(msg)
+	}
+
 	// Exit permit mode with rejection
 	if m.keymap.IsMatch(key, m.keymap.Permit.ExitMode) {
 		return m.exitPermitMode(false) // false = reject
 	}
 
+	// Apply the selected option, or the option with the pressed number
+	if m.keymap.IsMatch(key, m.keymap.Permit.Confirm) {
+		return m.applyPermitOption(m.selectedPermitOption)
+	}
+	switch key {
+	case "1", "2", "3":
+		return m.applyPermitOption(int(key[0] - '1'))
+	}
+
 	// Approve tool call
 	if m.keymap.IsMatch(key, m.keymap.Permit.Approve) {
-		return m.exitPermitMode(true) // true = approve
+		return m.applyPermitOption(permitAllow)
+	}
+
+	// Start typing a reason for denying; the dialog no longer times out
+	if m.keymap.IsMatch(key, m.keymap.Permit.DenyReason) {
+		m.typingPermitReason = true
+		m.permitReason = ""
+		m.permitDeadline = time.Time{}
+		return m, nil
 	}
 
 	// Reject tool call
@@ -1120,15 +1554,15 @@ func (m Model) handlePermitModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.exitPermitMode(false) // false = reject
 	}
 
-	// Move selection left (reject)
+	// Move selection left (towards deny)
 	if m.keymap.IsMatch(key, m.keymap.Permit.SelectPrev) {
-		m.selectedPermitOption = 0 // 0 = reject
+		m.selectedPermitOption = max(m.selectedPermitOption-1, permitDeny)
 		return m, nil
 	}
 
-	// Move selection right (approve)
+	// Move selection right (towards allow for session)
 	if m.keymap.IsMatch(key, m.keymap.Permit.SelectNext) {
-		m.selectedPermitOption = 1 // 1 = approve
+		m.selectedPermitOption = min(m.selectedPermitOption+1, permitAllowSession)
 		return m, nil
 	}
 
@@ -1137,14 +1571,10 @@ func (m Model) handlePermitModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // exitPermitMode exits permit mode and handles the tool call decision
 func (m *Model) exitPermitMode(approved bool) (tea.Model, tea.Cmd) {
-	// Reset permit dialog state
-	m.permitDialogVisible = false
-	toolCalls := m.pendingToolCalls
-	m.pendingToolCalls = make([]ai.ToolCall, 0)
-	m.selectedPermitOption = 0
-
-	// Return to previous mode
-	m.currentMode = m.previousMode
+	if m.permitLocal {
+		return m, m.answerLocalPermit(approved)
+	}
+	toolCalls := m.closePermitDialog()
 
 	// Create screen refresh command
 	refreshCmd := func() tea.Msg { return screenRefreshMsg{} }
@@ -1152,10 +1582,12 @@ func (m *Model) exitPermitMode(approved bool) (tea.Model, tea.Cmd) {
 	if approved {
 		m.logger.Debug("Tool calls approved", "count", len(toolCalls))
 		// Execute tool calls and send results back to LLM
-		return m, tea.Batch(m.executeToolCalls(toolCalls), refreshCmd)
+		return m, tea.Batch(m.resumeToolCalls(toolCalls, nil), refreshCmd)
 	} else {
 		// Tool calls rejected
 		m.logger.Debug("Tool calls rejected", "count", len(toolCalls))
+		m.ruleApproved, m.ruleDenied = nil, nil
+		m.finishJob()
 		m.messages = append(m.messages, Message{
 			ID:        generateMessageID(),
 			Content:   "Tool calls rejected by user",
@@ -1169,6 +1601,26 @@ func (m *Model) exitPermitMode(approved bool) (tea.Model, tea.Cmd) {
 	}
 }
 
+// closePermitDialog resets the permit dialog and returns the pending tool calls
+func (c *conversation) closePermitDialog() []ai.ToolCall {
+	c.permitDialogVisible = false
+	toolCalls := c.pendingToolCalls
+	c.pendingToolCalls = make([]ai.ToolCall, 0)
+	c.selectedPermitOption = 0
+	c.permitDeadline = time.Time{}
+	c.typingPermitReason = false
+	c.permitReason = ""
+	c.permitDangers = nil
+	c.permitPreviews = nil
+	c.permitLocal = false
+	c.typingPermitConfirm = false
+	c.permitConfirm = ""
+
+	// Return to previous mode
+	c.currentMode = c.previousMode
+	return toolCalls
+}
+
 // sendMessage sends the current input as a chat message
 func (m *Model) sendMessage() (tea.Model, tea.Cmd) {
 	// Trim whitespace and check if empty
@@ -1177,22 +1629,46 @@ func (m *Model) sendMessage() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Estimate tokens for the user message (for display in message list)
-	estimatedTokens := 0
-	if m.config != nil && m.config.AI.Model != "" {
-		if tokens, err := EstimateUserMessageTokens(trimmedInput, m.config.AI.Model); err == nil {
-			estimatedTokens = tokens
-		} else {
-			m.logger.Debug("Failed to estimate user message tokens", "error", err)
-		}
+	// Unknown commands and missing files are reported instead of sending
+	if !m.checkInput(trimmedInput) {
+		return m, nil
+	}
+
+	// Slash commands are handled locally and never sent to the model
+	if strings.HasPrefix(trimmedInput, "/") {
+		return m.handleSlashCommand(trimmedInput)
+	}
+
+	// A sealed session is not changed; the message goes to a fork of it
+	if !m.forkSealedSession() {
+		return m, nil
 	}
 
+	// Compile check retries start over with each message
+	m.gate = compileGate{}
+
+	// Attached pastes are sent as a reference to their file with an excerpt
+	prompt := m.expandPastes(trimmedInput)
+
+	// Warn before history is dropped to fit the context window
+	if !m.confirmPromptBudget(prompt) {
+		return m, nil
+	}
+
+	// Estimate tokens for the user message (for display in message list)
+	userMsgID := generateMessageID()
+	estimatedTokens := m.messageTokens(userMsgID, prompt)
+
 	// Save user input tokens for display
 	m.userInputTokens = estimatedTokens
 
-	// Estimate total prompt tokens (for display during thinking)
-	if m.chatHandler != nil {
-		if promptTokens, err := m.chatHandler.EstimatePromptTokens(trimmedInput); err == nil {
+	// Estimate total prompt tokens (for display during thinking). With a
+	// worker pool it is summed from the counts already known rather than
+	// counting the whole conversation again.
+	if m.workers != nil {
+		m.estimatedTokens = m.knownPromptTokens() + estimatedTokens
+	} else if m.chatHandler != nil {
+		if promptTokens, err := m.chatHandler.EstimatePromptTokens(prompt); err == nil {
 			m.estimatedTokens = promptTokens
 		} else {
 			// Fallback to just user message tokens
@@ -1205,7 +1681,7 @@ func (m *Model) sendMessage() (tea.Model, tea.Cmd) {
 
 	// Add user message with token count
 	userMsg := Message{
-		ID:        generateMessageID(),
+		ID:        userMsgID,
 		Content:   trimmedInput,
 		Role:      "user",
 		Timestamp: time.Now(),
@@ -1223,13 +1699,11 @@ func (m *Model) sendMessage() (tea.Model, tea.Cmd) {
 	m.loading = true
 	m.loadingStart = time.Now()
 	m.error = nil
-	// Reset streaming state
-	m.streamingContent.Reset()
 
 	// Send to chat handler
 	return m, tea.Batch(
 		m.spinner.Tick,
-		m.streamChatResponse(trimmedInput),
+		m.streamChatResponse(m.jobContext(trimmedInput), prompt),
 		m.tickForTokenUpdates(), // Poll for token updates during streaming
 	)
 }
@@ -1242,10 +1716,10 @@ func (m Model) tickForTokenUpdates() tea.Cmd {
 }
 
 // streamChatResponse handles the streaming chat response
-func (m *Model) streamChatResponse(input string) tea.Cmd {
+func (m *Model) streamChatResponse(ctx context.Context, input string) tea.Cmd {
 	return func() tea.Msg {
 		// Call handler without token callback since we're using ChatHandler's internal state
-		response, err := m.chatHandler.HandleMessageWithResponse(m.ctx, input, nil)
+		response, err := m.chatHandler.HandleMessageWithResponse(ctx, input, nil)
 
 		if err != nil {
 			return errorMsg{
@@ -1262,12 +1736,26 @@ func (m *Model) streamChatResponse(input string) tea.Cmd {
 			Tokens:     response.TokenCount,
 			TokenUsage: response.TokenUsage,
 			ToolCalls:  response.ToolCalls,
+
+			UsageEstimated: response.UsageEstimated,
+			Sources:        response.Sources,
+			FallbackModel:  response.FallbackModel,
 		}
 	}
 }
 
 // updateViewportContent updates the viewport with chat messages
 func (m *Model) updateViewportContent() {
+	// Earlier messages loaded by scrolling up are out of view at the bottom
+	m.messageWindow = 0
+	m.renderMessages()
+	// Auto-scroll to bottom when new content is added
+	m.viewport.GotoBottom()
+}
+
+// renderMessages renders the messages in the message window into the
+// viewport without moving it
+func (m *Model) renderMessages() {
 	var content strings.Builder
 
 	// Always show header (CODA figlet + model info) at the top
@@ -1282,20 +1770,72 @@ func (m *Model) updateViewportContent() {
 	}
 
 	// Show chat messages
-	for _, msg := range m.messages {
-		// Format the message with timestamp and role
-		msgLine := fmt.Sprintf("[%s] %s: %s",
-			msg.Timestamp.Format("15:04"),
-			msg.Role,
-			msg.Content)
+	if m.rendered == nil {
+		m.rendered = newRenderCache()
+	}
+	hidden := m.hiddenMessages()
+	links := 0
+	for _, msg := range m.messages[:hidden] {
+		links += len(m.rendered.linksOf(msg))
+	}
+	answers := m.answerCountBefore(hidden)
+	if hidden > 0 {
+		content.WriteString(fmt.Sprintf("↑ %d earlier messages (scroll up to load them)", hidden))
+		content.WriteString("\n")
+	}
+
+	// The line each message starts at, for jumping to it
+	m.messageLines = make([]int, len(m.messages))
+	line := strings.Count(content.String(), "\n")
+	for i := range m.messages[:hidden] {
+		m.messageLines[i] = -1
+	}
 
-		content.WriteString(msgLine)
+	shown := m.messages[hidden:]
+	for i, msg := range shown {
+		m.messageLines[hidden+i] = line
+		// Large answers are shown as they are until rendered in the background
+		var rendered renderedMessage
+		if header := m.messageHeader(msg); m.renderLater(msg, header) {
+			rendered = m.rendered.placeholder(msg, m.viewport.Width, header, m.formatMessageLine)
+		} else {
+			rendered = m.rendered.get(msg, m.viewport.Width, header, m.formatMessage)
+		}
+		block := m.annotateBlock(msg, m.collapseBlock(msg, rendered.block))
+		content.WriteString(block)
 		content.WriteString("\n")
+		line += strings.Count(block, "\n") + 1
+		if msg.Role == "assistant" {
+			answers++
+		}
+		if len(rendered.codeBlocks) > 0 {
+			refs := m.styles.Muted.Render(formatCodeBlockRefs(rendered.codeBlocks, answers))
+			content.WriteString(refs)
+			content.WriteString("\n")
+			line += strings.Count(refs, "\n") + 1
+		}
+		if len(rendered.links) > 0 {
+			refs := formatLinkReferences(rendered.links, links+1)
+			content.WriteString(refs)
+			content.WriteString("\n")
+			line += strings.Count(refs, "\n") + 1
+			links += len(rendered.links)
+		}
+		if len(msg.Sources) > 0 {
+			sources := m.styles.Muted.Render(formatDocSources(msg.Sources))
+			content.WriteString(sources)
+			content.WriteString("\n")
+			line += strings.Count(sources, "\n") + 1
+		}
 	}
+	m.rendered.prune(shown, m.messages)
 
-	m.viewport.SetContent(content.String())
-	// Auto-scroll to bottom when new content is added
-	m.viewport.GotoBottom()
+	m.viewport.SetContent(m.applySearch(content.String()))
+}
+
+// formatMessageLine formats a message with its timestamp and role
+func (m Model) formatMessageLine(msg Message) string {
+	return fmt.Sprintf("%s: %s", m.messageHeader(msg), msg.Content)
 }
 
 // renderChat renders the chat view using viewport
@@ -1309,7 +1849,7 @@ func (m Model) renderLoadingMessage() string {
 		return ""
 	}
 
-	elapsed := time.Since(m.loadingStart)
+	elapsed := viewClock().Sub(m.loadingStart)
 
 	// Determine the status message based on streaming tokens
 	statusMsg := "Thinking..."
@@ -1494,7 +2034,7 @@ func (m Model) renderHeader() string { // Create header content ( DO NOT format
 // renderWelcomeMessage renders the welcome message box
 func (m Model) renderWelcomeMessage() string {
 	// Get current working directory
-	cwd, err := os.Getwd()
+	cwd, err := viewDir()
 	if err != nil {
 		cwd = "unknown"
 	}
@@ -1512,6 +2052,9 @@ func (m Model) renderWelcomeMessage() string {
 		fmt.Sprintf("   model: %s", modelName),
 		fmt.Sprintf("   cwd: %s", cwd),
 	}
+	if m.toolManager != nil && m.toolManager.IsDryRun() {
+		lines = append(lines, "   dry-run: write tools report diffs without changing files")
+	}
 	content := strings.Join(lines, "\n")
 
 	// Use the same style as input area
@@ -1535,16 +2078,56 @@ func (m Model) renderStatus() string {
 	if m.error != nil {
 		return fmt.Sprintf("Error: %s", m.error.Error())
 	}
-	return ""
+	return m.startupStatus()
 }
 
 // renderHelpLine renders the help line
 func (m Model) renderHelpLine() string {
+	if m.runningCommand() != nil {
+		return " Ctrl+C:cancel the command, PgUp/PgDn:scroll its output"
+	}
 	if m.currentMode == ModeScroll {
-		return " Arrows:scroll, Home/End:top/bottom, Ctrl+Y:return to input"
+		if m.searchQuery != "" {
+			return fmt.Sprintf(" %s, n/N:next/previous match, /:search, Esc:return to input", m.searchStatus())
+		}
+		return " Arrows:scroll, gg/G:first/last message, [/]:user messages, {/}:tool calls, z:expand/collapse, /:search, Ctrl+Y:return to input"
+	}
+	if m.currentMode == ModeSearch {
+		return " Type to search the conversation, Enter:search, Esc:cancel"
+	}
+	if m.currentMode == ModePermit && m.typingPermitReason {
+		return " Type why the tool calls are denied, Enter:deny and tell the model, Esc:back"
+	}
+	if m.currentMode == ModePermit && m.typingPermitConfirm {
+		return fmt.Sprintf(" Type %s to approve the dangerous tool calls, Enter:confirm, Esc:back", escalationWord)
 	}
 	if m.currentMode == ModePermit {
-		return " Left/Right:select, Enter:confirm, Esc:reject"
+		return " 1:deny, 2:allow, 3:allow for session, R:deny with reason, Left/Right:select, Enter:confirm, Esc:reject"
+	}
+	if m.currentMode == ModeSessions {
+		return " Up/Down:select, Enter:switch, F:fork selected, Esc:close"
+	}
+	if m.scratchpadOpen {
+		return " Type notes (not sent), Enter:newline, Ctrl+U:delete line, Ctrl+O:insert into input, Esc/Ctrl+K:close"
+	}
+	if m.taskForm != nil {
+		return " Tab/Enter:next field, Shift+Tab:previous, Ctrl+U:clear field, Ctrl+S:build prompt, Esc:cancel"
+	}
+	if m.pendingPaste != "" {
+		return fmt.Sprintf(" Large paste (%d chars): A/Enter:attach as artifact, I:insert as is, Esc:discard", len([]rune(m.pendingPaste)))
+	}
+	if m.ghostText() != "" {
+		return " Tab:accept suggestion, any other key:dismiss"
+	}
+	if job := m.activeJob(); job != nil && !job.waiting && m.ctrlCMessage == "" {
+		// Ctrl+C cancels the running job before it quits
+		if job.canceled {
+			return " Canceling..., Ctrl+B:background, F1:help, Ctrl+C twice:quit"
+		}
+		if job.executing {
+			return " Running tools, Ctrl+B:background, F1:help, Ctrl+C:cancel tools"
+		}
+		return " Enter:send, Ctrl+J:newline, Ctrl+B:background, Ctrl+Y:scroll, F1:help, Ctrl+C:cancel request"
 	}
 	if m.ctrlCMessage != "" {
 		// Show warning when Ctrl+C was pressed once
@@ -1577,6 +2160,9 @@ func (m Model) renderTokenUsage() string {
 	// Format the usage string
 	// DO NOT CHANGE '≈' TO '~'
 	usageStr := fmt.Sprintf("Context usage: ≈%d / %d (%.1f%%)", usedTokens, tokenLimit, usagePercent)
+	if tokenizer.Degraded() != nil {
+		usageStr = fmt.Sprintf("Context usage: ≈%d / %d (%.1f%%, approximate)", usedTokens, tokenLimit, usagePercent)
+	}
 
 	// Apply color based on usage
 	var style lipgloss.Style
@@ -1591,14 +2177,26 @@ func (m Model) renderTokenUsage() string {
 		style = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
 	}
 
+	// The provider's health is shown before the usage
+	usage := style.Render(usageStr)
+	if health := m.renderProviderHealth(); health != "" {
+		usage = health + "  " + usage
+	}
+
 	// Right-align the usage display
 	totalWidth := m.width - 2
-	if totalWidth < len(usageStr) {
-		return style.Render(usageStr)
+	if totalWidth < lipgloss.Width(usage) {
+		return usage
 	}
 
-	padding := totalWidth - len(usageStr)
-	return strings.Repeat(" ", padding) + style.Render(usageStr)
+	// Open tabs are listed on the left of the same line
+	tabBar := m.renderTabBar()
+	padding := totalWidth - lipgloss.Width(usage) - lipgloss.Width(tabBar)
+	if padding < 1 {
+		tabBar = ""
+		padding = totalWidth - lipgloss.Width(usage)
+	}
+	return tabBar + strings.Repeat(" ", padding) + usage
 }
 
 // renderInput renders the input area
@@ -1647,6 +2245,8 @@ func (m Model) renderInput() string {
 
 	case ModePermit:
 		return m.renderPermitDialog()
+	case ModeSessions:
+		return m.renderSessionPicker()
 	case ModeNormal:
 		if m.currentInput != "" {
 			content = fmt.Sprintf("> %s", m.currentInput)
@@ -1739,7 +2339,7 @@ func (m *Model) renderMultilineInput() (string, bool) {
 			content = fmt.Sprintf("> %s%s%s", before, m.cursorStyle.Render(cursorChar), after)
 		} else {
 			// カーソルが行末にある場合
-			content = fmt.Sprintf("> %s▉", lines[0])
+			content = fmt.Sprintf("> %s▉%s", lines[0], m.renderGhostText())
 		}
 
 		// 罫線で囲む（モードに応じて色を変更）
@@ -1816,7 +2416,7 @@ func (m *Model) renderMultilineInput() (string, bool) {
 				result += fmt.Sprintf("%s%s%s%s\n", prefix, before, m.cursorStyle.Render(cursorChar), after)
 			} else {
 				// カーソルが行末にある場合
-				result += fmt.Sprintf("%s%s▉\n", prefix, line)
+				result += fmt.Sprintf("%s%s▉%s\n", prefix, line, m.renderGhostText())
 			}
 		} else {
 			result += fmt.Sprintf("%s%s\n", prefix, line)
@@ -1861,60 +2461,86 @@ func (m Model) renderPermitDialog() string {
 
 	var dialogContent strings.Builder
 
-	// Dialog title
-	dialogContent.WriteString("🔧 Tool Call Permission Required\n\n")
+	// Dialog title; dangerous calls stand out
+	if m.permitEscalated() {
+		title := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+		dialogContent.WriteString(title.Render("⚠ Dangerous Tool Call: type "+escalationWord+" to approve") + "\n\n")
+	} else {
+		dialogContent.WriteString("🔧 Tool Call Permission Required\n\n")
+	}
 
-	// Show tool details
+	// Show tool details and what the selected option does to each call
 	for i, toolCall := range m.pendingToolCalls {
 		if i > 0 {
 			dialogContent.WriteString("\n")
 		}
-		dialogContent.WriteString(fmt.Sprintf("Tool %d: %s\n", i+1, toolCall.Function.Name))
+		dialogContent.WriteString(fmt.Sprintf("Tool %d: %s  → %s\n", i+1, toolCall.Function.Name, m.permitDecision(toolCall)))
+		dialogContent.WriteString(m.renderPermitDangers(toolCall.ID))
 
-		// Format and show arguments
+		// Show the diff of previewed calls, or the arguments
+		if preview, ok := m.permitPreviews[toolCall.ID]; ok {
+			dialogContent.WriteString(fmt.Sprintf("Changes:\n%s\n", renderDiffPreview(preview)))
+			continue
+		}
 		formattedArgs := m.formatToolArguments(toolCall.Function.Arguments)
 		dialogContent.WriteString(fmt.Sprintf("Arguments:\n%s\n", formattedArgs))
 	}
 
 	dialogContent.WriteString("\n")
 
-	// Render selection buttons
-	rejectStyle := lipgloss.NewStyle().
-		Padding(0, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("241"))
-
-	approveStyle := lipgloss.NewStyle().
-		Padding(0, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("241"))
-
-	// Highlight selected option
-	if m.selectedPermitOption == 0 {
-		// Reject is selected
-		rejectStyle = rejectStyle.
-			BorderForeground(lipgloss.Color("9")).
-			Foreground(lipgloss.Color("9")).
-			Bold(true)
-	} else {
-		// Approve is selected
-		approveStyle = approveStyle.
-			BorderForeground(lipgloss.Color("10")).
-			Foreground(lipgloss.Color("10")).
-			Bold(true)
+	// The reason typed for denying replaces the buttons
+	if m.typingPermitReason {
+		dialogContent.WriteString(fmt.Sprintf("Reason for denying (sent to the model): %s_", m.permitReason))
+		return m.permitDialogStyle().Render(dialogContent.String())
+	}
+	if m.typingPermitConfirm {
+		dialogContent.WriteString(fmt.Sprintf("Type %q to %s: %s_", escalationWord, strings.ToLower(permitOptionLabels[m.permitConfirmOption]), m.permitConfirm))
+		return m.permitDialogStyle().Render(dialogContent.String())
 	}
 
-	rejectButton := rejectStyle.Render("Deny")
-	approveButton := approveStyle.Render("Allow")
+	// Render selection buttons, numbered for quick selection
+	buttonColors := []lipgloss.Color{"9", "10", "10"}
+	buttons := make([]string, 0, 2*len(permitOptionLabels))
+	for i, label := range permitOptionLabels {
+		style := lipgloss.NewStyle().
+			Padding(0, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("241"))
+
+		// Highlight selected option
+		if i == m.selectedPermitOption {
+			style = style.
+				BorderForeground(buttonColors[i]).
+				Foreground(buttonColors[i]).
+				Bold(true)
+		}
+		if i > 0 {
+			buttons = append(buttons, "  ")
+		}
+		buttons = append(buttons, style.Render(fmt.Sprintf("%d %s", i+1, label)))
+	}
 
 	// Combine buttons horizontally
-	buttons := lipgloss.JoinHorizontal(lipgloss.Center, rejectButton, "  ", approveButton)
-	dialogContent.WriteString(buttons)
+	dialogContent.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, buttons...))
 
+	if countdown := m.permitCountdownText(); countdown != "" {
+		dialogContent.WriteString("\n" + countdown)
+	}
+
+	return m.permitDialogStyle().Render(dialogContent.String())
+}
+
+// permitDialogStyle returns the style of the permit dialog box
+func (m Model) permitDialogStyle() lipgloss.Style {
 	// Apply dialog styling
 	dialogStyle := m.styles.UserInput.
 		BorderForeground(lipgloss.Color("#b40028")). // Corporate color for attention
 		Padding(1, 2)
+	if m.permitEscalated() {
+		dialogStyle = dialogStyle.
+			Border(lipgloss.ThickBorder()).
+			BorderForeground(lipgloss.Color("9"))
+	}
 
 	// Calculate content width
 	contentWidth := m.width - 4
@@ -1922,7 +2548,7 @@ func (m Model) renderPermitDialog() string {
 		contentWidth = 40
 	}
 
-	return dialogStyle.Width(contentWidth).Render(dialogContent.String())
+	return dialogStyle.Width(contentWidth)
 }
 
 // formatToolArguments formats JSON arguments in a readable key-value format
@@ -1941,9 +2567,16 @@ func (m Model) formatToolArguments(args string) string {
 		return fmt.Sprintf("  %s", args)
 	}
 
-	// Format as key-value pairs
+	// Format as key-value pairs, in the same order every time
+	keys := make([]string, 0, len(jsonData))
+	for key := range jsonData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	var formatted strings.Builder
-	for key, value := range jsonData {
+	for _, key := range keys {
+		value := jsonData[key]
 		// Format the value based on its type
 		var valueStr string
 		switch v := value.(type) {
@@ -1990,11 +2623,25 @@ func (m Model) renderHelp() string {
 		help += line + "\n"
 	}
 
+	help += "\nSlash Commands:\n"
+	for _, line := range slashCommandHelp {
+		help += "  " + line + "\n"
+	}
+
+	help += "\nTabs:\n"
+	help += "  Ctrl+T: Open a tab, Ctrl+W: Close the tab\n"
+	help += "  Alt+1-9: Go to tab N, Alt+Left/Right: Previous/next tab\n"
+	help += "  Ctrl+B: Keep the running job in the background and open a new tab\n"
+	help += "  Ctrl+G: Open the latest link in the browser\n"
+	help += "  Ctrl+K: Open or close the session's scratchpad (Ctrl+O inserts it into the input)\n"
+
 	help += "\nAdvanced Features:\n"
 	help += "- Vim-style modes: Normal, Insert, Command, Search\n"
 	help += "- Customizable key bindings via configuration\n"
 	help += "- Context-sensitive help based on current mode\n"
-	help += "- Search through chat history with highlighting\n"
+	help += "- Search through chat history with highlighting (Ctrl+Y, then / or ?; n/N to jump)\n"
+	help += "- Jump between messages while scrolling: gg/G first/last, [/] user messages, {/} tool calls\n"
+	help += "- Long answers are collapsed; z in scroll mode expands the one at the top of the view\n"
 	help += "- Command mode for advanced operations\n\n"
 
 	help += "Configuration:\n"
@@ -2031,6 +2678,12 @@ type chatResponseMsg struct {
 	Tokens     int           // Total tokens (deprecated)
 	TokenUsage *ai.Usage     // Detailed token usage
 	ToolCalls  []ai.ToolCall // Tool calls requested by AI
+
+	UsageEstimated bool // TokenUsage was estimated locally, not reported by the provider
+
+	Sources []chat.DocSource // Documentation attached to the request
+
+	FallbackModel string // Fallback model that answered, if any
 }
 
 type errorMsg struct {
@@ -2050,6 +2703,16 @@ type toggleErrorDetailsMsg struct{}
 
 type retryLastActionMsg struct{}
 
+// permitCountdownMsg drives the timeout of the permit dialog with the given sequence number
+type permitCountdownMsg struct {
+	seq int
+}
+
+// errorCountdownMsg drives the automatic retry countdown of the error with the given sequence number
+type errorCountdownMsg struct {
+	seq int
+}
+
 type loadingMsg struct {
 	loading bool
 }
@@ -2091,12 +2754,36 @@ func (m *Model) executeCommand(command string) tea.Cmd {
 
 // executeToolCalls executes the approved tool calls and returns a command to send results back to LLM
 func (m *Model) executeToolCalls(toolCalls []ai.ToolCall) tea.Cmd {
+	// Tools that store per-session data (e.g. artifacts) need the session ID
+	ctx := m.jobContext("")
+	if job := m.activeJob(); job != nil {
+		job.executing = true
+	}
+	if m.chatHandler != nil {
+		if session := m.chatHandler.GetCurrentSession(); session != nil {
+			ctx = tools.WithSessionID(ctx, session.ID)
+		}
+	}
+	pane := m.startCommandPane(toolCalls)
+	check := m.compileCheck()
+
 	return tea.Cmd(func() tea.Msg {
 		results := make([]chat.ToolResult, 0, len(toolCalls))
 
 		for _, toolCall := range toolCalls {
 			startTime := time.Now()
 
+			// Calls after a cancel are not started
+			if err := ctx.Err(); err != nil {
+				results = append(results, chat.ToolResult{
+					ToolCallID: toolCall.ID,
+					ToolName:   toolCall.Function.Name,
+					Error:      fmt.Errorf("tool call canceled: %w", err),
+					ExecutedAt: startTime,
+				})
+				continue
+			}
+
 			// Parse tool call arguments
 			var params map[string]interface{}
 			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &params); err != nil {
@@ -2111,8 +2798,24 @@ func (m *Model) executeToolCalls(toolCalls []ai.ToolCall) tea.Cmd {
 				continue
 			}
 
+			// Commands stream their output to the pane and can be canceled
+			// without canceling the job
+			callCtx := ctx
+			var stopCommand context.CancelFunc
+			if pane != nil && tools.StreamsOutput(toolCall.Function.Name) {
+				callCtx, stopCommand = context.WithCancel(ctx)
+				pane.start(paneCommand(toolCall.Function.Name, params), stopCommand)
+				callCtx = tools.WithOutputStream(callCtx, pane)
+			}
+
 			// Execute the tool
-			result, err := m.toolManager.Execute(m.ctx, toolCall.Function.Name, params)
+			result, err := m.toolManager.Execute(callCtx, toolCall.Function.Name, params)
+			if stopCommand != nil {
+				if pane.finish() {
+					result, err = pane.transcript(), nil
+				}
+				stopCommand()
+			}
 			results = append(results, chat.ToolResult{
 				ToolCallID: toolCall.ID,
 				ToolName:   toolCall.Function.Name,
@@ -2123,87 +2826,74 @@ func (m *Model) executeToolCalls(toolCalls []ai.ToolCall) tea.Cmd {
 			})
 		}
 
+		// Build after the edits so the model sees what it broke
+		if ctx.Err() == nil {
+			if result, ok := runCompileCheck(ctx, check, results); ok {
+				results = append(results, result)
+			}
+		}
+
 		return toolExecutionMsg{results: results}
 	})
 }
 
-// sendToolResults sends tool execution results back to the LLM
-func (m *Model) sendToolResults(results []chat.ToolResult) tea.Cmd {
+// recordToolResults adds tool results to the session and the conversation
+func (m *Model) recordToolResults(results []chat.ToolResult) {
 	// Add tool results as messages to the session
 	for _, result := range results {
-		content := ""
-		if result.Error != nil {
-			content = fmt.Sprintf("Tool execution failed: %v", result.Error)
-		} else if result.Result == nil {
-			// Handle nil result explicitly
-			content = "Tool executed successfully"
-		} else {
-			// Convert result to string
-			switch v := result.Result.(type) {
-			case string:
-				content = v
-			case []byte:
-				content = string(v)
-			default:
-				if data, err := json.Marshal(v); err == nil {
-					content = string(data)
-				} else {
-					content = fmt.Sprintf("%v", v)
-				}
-			}
-		}
-
-		// Ensure content is never empty
-		if content == "" {
-			content = "Tool executed successfully with empty result"
-		}
-
 		// Add tool result as user message with special formatting (text-based approach)
-		toolResultText := fmt.Sprintf("TOOL_RESULT[%s]: %s", result.ToolName, content)
-		message := ai.Message{
-			Role:    ai.RoleUser,
-			Content: toolResultText,
-		}
+		message := chat.ToolResultMessage(result)
+		toolResultText := message.Content
 
 		// Add message to current session
-		if err := m.chatHandler.AddMessageToSession(message); err != nil {
-			m.logger.Error("Failed to add tool result message", "error", err)
+		if m.chatHandler != nil {
+			if err := m.chatHandler.AddMessageToSession(message); err != nil {
+				m.logger.Error("Failed to add tool result message", "error", err)
+			}
 		}
 
 		// Calculate tokens for tool result
-		toolResultTokens := 0
-		if m.config != nil && m.config.AI.Model != "" {
-			tokens, err := tokenizer.EstimateUserMessageTokens(toolResultText, m.config.AI.Model)
-			if err != nil {
-				m.logger.Debug("Failed to estimate tool result tokens", "error", err)
-			} else {
-				toolResultTokens = tokens
-			}
-		}
+		toolResultID := generateMessageID()
+		toolResultTokens := m.messageTokens(toolResultID, toolResultText)
 
 		// Add to UI messages for display with brief summary
 		briefSummary := m.getToolResultSummary(result)
 		m.messages = append(m.messages, Message{
-			ID:        generateMessageID(),
+			ID:        toolResultID,
 			Content:   briefSummary,
 			Role:      "tool",
 			Timestamp: result.ExecutedAt,
 			Tokens:    toolResultTokens,
+			Duration:  result.Duration,
 		})
+
+		if warning := chat.InjectionWarning(result); warning != "" {
+			m.messages = append(m.messages, Message{
+				ID:        generateMessageID(),
+				Content:   warning,
+				Role:      "system",
+				Timestamp: time.Now(),
+			})
+		}
 	}
 
 	// Update viewport with new messages
 	m.updateViewportContent()
+}
+
+// sendToolResults sends tool execution results back to the LLM
+func (m *Model) sendToolResults(results []chat.ToolResult) tea.Cmd {
+	m.recordToolResults(results)
 
 	// Set loading state for LLM response
 	m.loading = true
 	m.loadingStart = time.Now()
-	m.streamingContent.Reset()
 
 	// Send continuation request to LLM without adding new user message
+	ctx := m.jobContext("")
 	return tea.Cmd(func() tea.Msg {
 		// Use ContinueConversation to continue with tool results
-		response, err := m.chatHandler.ContinueConversation(m.ctx, nil)
+		response, err := m.chatHandler.ContinueConversation(ctx, nil)
 		if err != nil {
 			return errorMsg{
 				error:      err,
@@ -2217,27 +2907,11 @@ func (m *Model) sendToolResults(results []chat.ToolResult) tea.Cmd {
 			Tokens:     response.TokenCount,
 			TokenUsage: response.TokenUsage,
 			ToolCalls:  response.ToolCalls,
-		}
-	})
-}
-
-// performSearch performs a search in the chat history
-func (m *Model) performSearch(query string) {
-	m.searchResults = make([]int, 0)
-	m.currentMatch = 0
 
-	if query == "" {
-		return
-	}
-
-	// Search through messages
-	for i, message := range m.messages {
-		if strings.Contains(strings.ToLower(message.Content), strings.ToLower(query)) {
-			m.searchResults = append(m.searchResults, i)
+			UsageEstimated: response.UsageEstimated,
+			FallbackModel:  response.FallbackModel,
 		}
-	}
-
-	m.logger.Debug("Search completed", "query", query, "results", len(m.searchResults))
+	})
 }
 
 // getCurrentModeString returns a string representation of the current mode for display
@@ -2255,6 +2929,8 @@ func (m Model) getCurrentModeString() string {
 		return "SCROLL"
 	case ModePermit:
 		return "PERMIT"
+	case ModeSessions:
+		return "SESSIONS"
 	default:
 		return "UNKNOWN"
 	}
@@ -2293,7 +2969,11 @@ func (m *Model) getToolResultSummary(result chat.ToolResult) string {
 
 	// Handle error case
 	if result.Error != nil {
-		return fmt.Sprintf("[%s] ❌ Failed: %v", toolName, result.Error)
+		return fmt.Sprintf("[%s] ❌ Failed: %s", toolName, tools.FormatError(result.ToolName, result.Error))
+	}
+
+	if tools.IsDryRunResult(result.Result) {
+		return fmt.Sprintf("[%s] 🔍 Dry run: no changes made", toolName)
 	}
 
 	// Generate brief summary based on tool type
@@ -2322,6 +3002,175 @@ func (m *Model) getToolResultSummary(result chat.ToolResult) string {
 		}
 		return fmt.Sprintf("[%s] ✅ Search completed", toolName)
 
+	case tools.SemanticSearchToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			return fmt.Sprintf("[%s] ✅ Found %v related snippets", toolName, info["count"])
+		}
+		return fmt.Sprintf("[%s] ✅ Search completed", toolName)
+
+	case tools.RunCommandToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if canceled, _ := info["canceled"].(bool); canceled {
+				return fmt.Sprintf("[%s] ⏹ Canceled: %v", toolName, info["command"])
+			}
+			if code, _ := info["exit_code"].(int); code != 0 {
+				return fmt.Sprintf("[%s] ❌ %v exited with code %d", toolName, info["command"], code)
+			}
+			return fmt.Sprintf("[%s] ✅ %v", toolName, info["command"])
+		}
+		return fmt.Sprintf("[%s] ✅ Command finished", toolName)
+
+	case tools.ValidateDockerfileToolName, tools.ValidateComposeToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if issues, ok := info["issues"].([]container.Issue); ok && len(issues) > 0 {
+				if valid, _ := info["valid"].(bool); !valid {
+					return fmt.Sprintf("[%s] ❌ %v: %d issues", toolName, info["path"], len(issues))
+				}
+				return fmt.Sprintf("[%s] ⚠️ %v: %d warnings", toolName, info["path"], len(issues))
+			}
+			return fmt.Sprintf("[%s] ✅ %v has no issues", toolName, info["path"])
+		}
+		return fmt.Sprintf("[%s] ✅ Validated", toolName)
+
+	case tools.ValidateK8sToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if valid, _ := info["valid"].(bool); !valid {
+				return fmt.Sprintf("[%s] ❌ %v", toolName, info["summary"])
+			}
+			return fmt.Sprintf("[%s] ✅ %v", toolName, info["summary"])
+		}
+		return fmt.Sprintf("[%s] ✅ Validated", toolName)
+
+	case tools.K8sDiffToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if changed, _ := info["changed"].(bool); changed {
+				return fmt.Sprintf("[%s] ⚠️ Applying would change the cluster", toolName)
+			}
+			return fmt.Sprintf("[%s] ✅ The cluster matches the manifests", toolName)
+		}
+		return fmt.Sprintf("[%s] ✅ Diff finished", toolName)
+
+	case tools.QueryDBToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if columns, _ := info["columns"].(int); columns == 0 {
+				return fmt.Sprintf("[%s] ✅ Statement executed on %v", toolName, info["database"])
+			}
+			rows, _ := info["rows"].(int)
+			if shown, _ := info["shown_rows"].(int); shown < rows {
+				return fmt.Sprintf("[%s] ✅ %d of %d rows from %v", toolName, shown, rows, info["database"])
+			}
+			return fmt.Sprintf("[%s] ✅ %d rows from %v", toolName, rows, info["database"])
+		}
+		return fmt.Sprintf("[%s] ✅ Query finished", toolName)
+
+	case tools.HTTPRequestToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			status, _ := info["status"].(int)
+			request, _ := info["request"].(map[string]interface{})
+			icon := "✅"
+			if status >= 400 {
+				icon = "⚠️"
+			}
+			return fmt.Sprintf("[%s] %s %v %v → %d", toolName, icon, request["method"], request["url"], status)
+		}
+		return fmt.Sprintf("[%s] ✅ Request sent", toolName)
+
+	case tools.EvalSnippetToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if message, ok := info["message"].(string); ok {
+				return fmt.Sprintf("[%s] ❌ %s", toolName, message)
+			}
+			if code, _ := info["exit_code"].(int); code != 0 {
+				return fmt.Sprintf("[%s] ❌ Exited with code %d", toolName, code)
+			}
+			return fmt.Sprintf("[%s] ✅ Ran %v snippet", toolName, info["language"])
+		}
+		return fmt.Sprintf("[%s] ✅ Snippet finished", toolName)
+
+	case tools.DockerBuildToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if canceled, _ := info["canceled"].(bool); canceled {
+				return fmt.Sprintf("[%s] ⏹ Build canceled", toolName)
+			}
+			if code, _ := info["exit_code"].(int); code != 0 {
+				return fmt.Sprintf("[%s] ❌ Build failed with code %d", toolName, code)
+			}
+			if image, ok := info["image"].(string); ok {
+				return fmt.Sprintf("[%s] ✅ Built %s", toolName, image)
+			}
+		}
+		return fmt.Sprintf("[%s] ✅ Build finished", toolName)
+
+	case "save_artifact":
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			return fmt.Sprintf("[%s] ✅ Saved %v", toolName, info["path"])
+		}
+		return fmt.Sprintf("[%s] ✅ Artifact saved", toolName)
+
+	case tools.CompileCheckName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if passed, _ := info["passed"].(bool); passed {
+				return fmt.Sprintf("[%s] ✅ %v passes", toolName, info["command"])
+			}
+			return fmt.Sprintf("[%s] ❌ %v fails; the errors were sent to the model", toolName, info["command"])
+		}
+		return fmt.Sprintf("[%s] ✅ Completed", toolName)
+
+	case tools.DepsOutdatedToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			return fmt.Sprintf("[%s] ✅ %v outdated dependencies", toolName, info["count"])
+		}
+		return fmt.Sprintf("[%s] ✅ Completed", toolName)
+
+	case tools.DepsBumpToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			return fmt.Sprintf("[%s] ✅ %v %v -> %v", toolName, info["module"], info["from"], info["to"])
+		}
+		return fmt.Sprintf("[%s] ✅ Completed", toolName)
+
+	case tools.DepsTestToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if passed, _ := info["passed"].(bool); !passed {
+				return fmt.Sprintf("[%s] ❌ %v fails", toolName, info["failed"])
+			}
+			return fmt.Sprintf("[%s] ✅ Build and tests pass", toolName)
+		}
+		return fmt.Sprintf("[%s] ✅ Completed", toolName)
+
+	case tools.SecurityScanToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if count, _ := info["count"].(int); count > 0 {
+				return fmt.Sprintf("[%s] ⚠️ %v", toolName, info["summary"])
+			}
+			return fmt.Sprintf("[%s] ✅ No findings", toolName)
+		}
+		return fmt.Sprintf("[%s] ✅ Scan finished", toolName)
+
+	case tools.RunBenchmarksToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if regressions, ok := info["regressions"].(int); ok {
+				if regressions > 0 {
+					return fmt.Sprintf("[%s] ⚠️ %d regressions compared to %v", toolName, regressions, info["compared_to"])
+				}
+				return fmt.Sprintf("[%s] ✅ No regressions compared to %v", toolName, info["compared_to"])
+			}
+			if saved, ok := info["saved_as"].(string); ok {
+				return fmt.Sprintf("[%s] ✅ Saved results as %s", toolName, saved)
+			}
+		}
+		return fmt.Sprintf("[%s] ✅ Benchmarks finished", toolName)
+
+	case tools.RenameSymbolToolName:
+		if info, ok := result.Result.(map[string]interface{}); ok {
+			if files, ok := info["changed_files"].([]string); ok {
+				if applied, _ := info["applied"].(bool); applied {
+					return fmt.Sprintf("[%s] ✅ Renamed %v to %v in %d files", toolName, info["symbol"], info["new_name"], len(files))
+				}
+				return fmt.Sprintf("[%s] 🔍 Renaming %v to %v would change: %s", toolName, info["symbol"], info["new_name"], strings.Join(files, ", "))
+			}
+		}
+		return fmt.Sprintf("[%s] ✅ Completed", toolName)
+
 	default:
 		return fmt.Sprintf("[%s] ✅ Completed", toolName)
 	}
@@ -2493,61 +3342,36 @@ func (m Model) getCursorLineAndColumn() (int, int) {
 
 // getModelTokenLimit returns the token limit for the given model
 func getModelTokenLimit(model string) int {
-	// gpt-5-series models (gpt-5, gpt-5-mini, etc.) have 400k context
-	if strings.HasPrefix(model, "gpt-5") {
-		return 400000
+	if info, ok := ai.LookupModel(model); ok && info.ContextWindow > 0 {
+		return info.ContextWindow
 	}
 
-	// o-series models (o1, o3, etc.) have 200k context
+	// Newer o-series models not in the metadata yet have at least 200k context
 	if strings.HasPrefix(model, "o") {
 		return 200000
 	}
 
-	// GPT-4.1 models (gpt-4.1, gpt-4.1 mini) have 1M context
-	if strings.HasPrefix(model, "gpt-4.1") {
-		return 1000000
-	}
-
-	// GPT-4 Turbo and newer models or 4-omni
-	if strings.Contains(model, "gpt-4-turbo") || strings.Contains(model, "o3") || strings.HasPrefix(model, "gpt-4o") {
-		return 128000
-	}
-
-	// GPT-4 (older versions)
-	if strings.Contains(model, "gpt-4-32k") {
-		return 32768
-	}
-	if strings.Contains(model, "gpt-4") {
-		return 8192
-	}
-
-	// GPT-3.5 Turbo
-	if strings.Contains(model, "gpt-3.5-turbo-16k") {
-		return 16384
-	}
-	if strings.Contains(model, "gpt-3.5-turbo") {
-		return 4096
-	}
-
 	// Default for unknown models
 	return 8192
 }
 
 // calculateSessionTokens calculates the total token usage for the current session
 func (m Model) calculateSessionTokens() int {
+	// Prefer the provider-reported usage of the last response when available
+	if !m.loading && m.lastUsageExact && m.lastTokenUsage != nil && m.lastTokenUsage.TotalTokens > 0 {
+		return m.lastTokenUsage.TotalTokens + m.tokensAfterLastAssistant()
+	}
+
 	totalTokens := 0
 
 	// Calculate actual system prompt tokens
 	if m.chatHandler != nil {
 		systemPrompt := m.chatHandler.GetSystemPrompt()
 		if systemPrompt != "" && m.config != nil && m.config.AI.Model != "" {
-			// Use tokenizer for accurate system prompt token count
-			systemTokens, err := tokenizer.EstimateUserMessageTokens(systemPrompt, m.config.AI.Model)
-			if err != nil {
-				// Fallback to rough estimate on error
-				systemTokens = 800
-			}
-			totalTokens += systemTokens
+			// Use tokenizer for accurate system prompt token count,
+			// approximated until it is counted in the background or when
+			// the model has no usable encoding
+			totalTokens += m.textTokens(systemPrompt)
 		} else {
 			// Fallback if no handler or config available
 			totalTokens += 800
@@ -2579,3 +3403,16 @@ func (m Model) calculateSessionTokens() int {
 
 	return totalTokens
 }
+
+// tokensAfterLastAssistant sums the tokens of messages added after the last
+// assistant response (e.g. tool results not yet sent to the model)
+func (m Model) tokensAfterLastAssistant() int {
+	tokens := 0
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "assistant" {
+			break
+		}
+		tokens += m.messages[i].Tokens
+	}
+	return tokens
+}
@@ -19,18 +19,20 @@ func TestRenderChatWithStyles(t *testing.T) {
 		height:   24,
 		ready:    true,
 		styles:   theme.GetStyles(),
-		messages: []Message{
-			{
-				ID:        "1",
-				Content:   "User message",
-				Role:      "user",
-				Timestamp: time.Now(),
-			},
-			{
-				ID:        "2",
-				Content:   "Assistant message",
-				Role:      "assistant",
-				Timestamp: time.Now(),
+		conversation: conversation{
+			messages: []Message{
+				{
+					ID:        "1",
+					Content:   "User message",
+					Role:      "user",
+					Timestamp: time.Now(),
+				},
+				{
+					ID:        "2",
+					Content:   "Assistant message",
+					Role:      "assistant",
+					Timestamp: time.Now(),
+				},
 			},
 		},
 	}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// modelMetadataMsg reports the result of a model metadata refresh
+type modelMetadataMsg struct {
+	refresh ai.ModelRefresh
+	err     error
+}
+
+// handleModelsCommand shows the metadata of the configured model, or
+// refreshes the metadata with "/models refresh"
+func (m *Model) handleModelsCommand(args []string) tea.Cmd {
+	if m.config == nil {
+		m.addSystemMessage("Model metadata is not available")
+		return nil
+	}
+	if len(args) == 0 {
+		m.addSystemMessage(formatModelInfo(m.config.AI.Model, ai.DefaultModelRegistry(), time.Now()))
+		return nil
+	}
+	if len(args) > 1 || strings.ToLower(args[0]) != "refresh" {
+		m.addSystemMessage("Usage: /models [refresh]")
+		return nil
+	}
+
+	var client ai.Client
+	if m.chatHandler != nil {
+		client = m.chatHandler.AIClient()
+	}
+	cfg := m.config.AI
+	m.addSystemMessage("Refreshing model metadata...")
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		refresh, err := ai.DefaultModelRegistry().Refresh(ctx, client, cfg.MetadataURL, cfg.MetadataCache)
+		return modelMetadataMsg{refresh: refresh, err: err}
+	}
+}
+
+// formatModelInfo describes what the registry knows about model
+func formatModelInfo(model string, registry *ai.ModelRegistry, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Model %s:", model)
+
+	info, ok := registry.Lookup(model)
+	switch {
+	case !ok:
+		b.WriteString("\n  no metadata (context window and cost are estimated)")
+	default:
+		if info.ContextWindow > 0 {
+			fmt.Fprintf(&b, "\n  context window: %d tokens", info.ContextWindow)
+		}
+		if info.InputPrice > 0 || info.OutputPrice > 0 {
+			fmt.Fprintf(&b, "\n  price: $%.2f input, $%.2f output per 1M tokens", info.InputPrice, info.OutputPrice)
+		}
+	}
+
+	if d, ok := ai.LookupDeprecation(model); ok {
+		retires := "deprecated"
+		if !d.RetiresAt.IsZero() {
+			retires = "retires " + d.RetiresAt.Format("2006-01-02")
+		}
+		if d.Replacement != "" {
+			retires += " (replacement: " + d.Replacement + ")"
+		}
+		fmt.Fprintf(&b, "\n  %s", retires)
+	}
+
+	if listed, known := registry.Listed(model); known && !listed {
+		b.WriteString("\n  not listed by the provider")
+	}
+
+	if updated := registry.UpdatedAt(); updated.IsZero() {
+		b.WriteString("\nMetadata: bundled with CODA.")
+	} else {
+		fmt.Fprintf(&b, "\nMetadata: refreshed %s.", formatRelativeTime(updated, now))
+	}
+	b.WriteString(" Run /models refresh to update it.")
+	return b.String()
+}
+
+// formatModelRefresh reports the result of a refresh
+func formatModelRefresh(msg modelMetadataMsg) string {
+	var parts []string
+	if msg.refresh.Updated > 0 {
+		parts = append(parts, fmt.Sprintf("%d metadata entries", msg.refresh.Updated))
+	}
+	if msg.refresh.Listed > 0 {
+		parts = append(parts, fmt.Sprintf("%d models listed by the provider", msg.refresh.Listed))
+	}
+
+	var b strings.Builder
+	if len(parts) > 0 {
+		fmt.Fprintf(&b, "Model metadata refreshed: %s", strings.Join(parts, ", "))
+	}
+	if msg.err != nil {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Model metadata refresh failed: %v", msg.err)
+	}
+	if b.Len() == 0 {
+		b.WriteString("Model metadata refreshed")
+	}
+	return b.String()
+}
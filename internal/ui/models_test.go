@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+func TestFormatModelInfo(t *testing.T) {
+	registry := ai.NewModelRegistry()
+	now := time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "Model gpt-4.1:\n"+
+		"  context window: 1000000 tokens\n"+
+		"  price: $2.00 input, $8.00 output per 1M tokens\n"+
+		"Metadata: bundled with CODA. Run /models refresh to update it.",
+		formatModelInfo("gpt-4.1", registry, now))
+
+	info := formatModelInfo("o1-mini", registry, now)
+	assert.Contains(t, info, "retires 2025-10-27 (replacement: o4-mini)")
+
+	info = formatModelInfo("my-local-model", registry, now)
+	assert.Contains(t, info, "no metadata")
+}
+
+func TestFormatModelRefresh(t *testing.T) {
+	msg := modelMetadataMsg{refresh: ai.ModelRefresh{Updated: 3, Listed: 40}}
+	assert.Equal(t, "Model metadata refreshed: 3 metadata entries, 40 models listed by the provider", formatModelRefresh(msg))
+}
@@ -0,0 +1,102 @@
+package ui
+
+// handleJumpKeys moves the viewport between messages in scroll mode and
+// reports whether key was a jump key
+func (m *Model) handleJumpKeys(key string) bool {
+	pendingG := m.pendingG
+	m.pendingG = false
+
+	switch key {
+	case "g":
+		if pendingG {
+			m.jumpToMessage(0)
+		} else {
+			m.pendingG = true
+		}
+	case "G":
+		m.jumpToMessage(len(m.messages) - 1)
+	case "]":
+		m.jumpToNext(isUserMessage, true)
+	case "[":
+		m.jumpToNext(isUserMessage, false)
+	case "}":
+		m.jumpToNext(startsToolBlock, true)
+	case "{":
+		m.jumpToNext(startsToolBlock, false)
+	default:
+		return false
+	}
+	return true
+}
+
+// isUserMessage reports whether the i-th message was written by the user
+func isUserMessage(messages []Message, i int) bool {
+	return messages[i].Role == "user"
+}
+
+// startsToolBlock reports whether the i-th message is the first of a run of
+// tool results
+func startsToolBlock(messages []Message, i int) bool {
+	return messages[i].Role == "tool" && (i == 0 || messages[i-1].Role != "tool")
+}
+
+// jumpToNext jumps to the next message after the one at the top of the
+// viewport that matches, or the previous one when forward is false
+func (m *Model) jumpToNext(matches func([]Message, int) bool, forward bool) {
+	// Above the first rendered message, the hidden ones are before the view
+	top := m.topMessage()
+	if top < 0 {
+		top = m.hiddenMessages() - 1
+	}
+
+	if forward {
+		for i := top + 1; i < len(m.messages); i++ {
+			if matches(m.messages, i) {
+				m.jumpToMessage(i)
+				return
+			}
+		}
+		return
+	}
+
+	// A message scrolled partly out of view is the previous one
+	from := top - 1
+	if top >= 0 && m.messageLines[top] >= 0 && m.messageLines[top] < m.viewport.YOffset {
+		from = top
+	}
+	for i := from; i >= 0; i-- {
+		if matches(m.messages, i) {
+			m.jumpToMessage(i)
+			return
+		}
+	}
+}
+
+// topMessage returns the index of the message shown at the top of the
+// viewport, or -1 when the view is above the first rendered message
+func (m Model) topMessage() int {
+	top := -1
+	for i, line := range m.messageLines {
+		if line < 0 {
+			continue
+		}
+		if line > m.viewport.YOffset {
+			break
+		}
+		top = i
+	}
+	return top
+}
+
+// jumpToMessage scrolls the i-th message to the top of the viewport,
+// rendering earlier messages first if it is not rendered yet
+func (m *Model) jumpToMessage(i int) {
+	if i < 0 || i >= len(m.messages) {
+		return
+	}
+	if i >= len(m.messageLines) || m.messageLines[i] < 0 {
+		m.messageWindow = len(m.messages) - i
+		m.renderMessages()
+	}
+	m.viewport.SetYOffset(m.messageLines[i])
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJumpNavigation(t *testing.T) {
+	m := newTabsTestModel()
+	m.viewport.Width = 80
+	m.viewport.Height = 3
+	m.keymap = DefaultKeyMap()
+	m.messages = nil
+	roles := []string{"user", "assistant", "tool", "tool", "assistant"}
+	for i := 0; i < 300; i++ {
+		m.messages = append(m.messages, Message{
+			ID:      fmt.Sprintf("m%d", i),
+			Role:    roles[i%len(roles)],
+			Content: fmt.Sprintf("message %d", i),
+		})
+	}
+	m.updateViewportContent()
+	m.currentMode = ModeScroll
+	m.previousMode = ModeInsert
+
+	press := func(keys ...string) {
+		for _, k := range keys {
+			updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+			m = updated.(Model)
+		}
+	}
+
+	// gg renders the hidden messages and goes to the first one
+	press("g", "g")
+	assert.Equal(t, 0, m.hiddenMessages())
+	assert.Equal(t, 0, m.topMessage())
+	assert.Contains(t, m.viewport.View(), "message 0")
+
+	press("]")
+	assert.Equal(t, 5, m.topMessage())
+	press("}")
+	assert.Equal(t, 7, m.topMessage(), "the next tool call block")
+	press("}")
+	assert.Equal(t, 12, m.topMessage(), "tool results after the first of a block are skipped")
+	press("[")
+	assert.Equal(t, 10, m.topMessage())
+	press("{")
+	assert.Equal(t, 7, m.topMessage())
+
+	press("G")
+	assert.Contains(t, m.viewport.View(), "message 299")
+
+	// A single g does nothing
+	press("g", "j")
+	assert.False(t, m.pendingG)
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/common-creation/coda/internal/artifacts"
+)
+
+const (
+	// defaultPasteThreshold is used when no configuration is loaded
+	defaultPasteThreshold = 10000
+
+	// Lines of an attached paste quoted at its start and end in the prompt
+	pasteHeadLines = 40
+	pasteTailLines = 10
+
+	// Characters of an attached paste quoted at most in the prompt
+	pasteExcerptChars = 4000
+)
+
+// pastePlaceholder matches the placeholders of attached pastes in the input
+var pastePlaceholder = regexp.MustCompile(`\[paste #(\d+): [^\]]*\]`)
+
+// pasteAttachment is a large paste saved as an artifact and referenced from
+// the input by a placeholder
+type pasteAttachment struct {
+	path  string
+	text  string
+	lines int
+}
+
+// pasteThreshold returns the paste size above which the user is asked how to
+// use the paste, or 0 when every paste is inserted
+func (m Model) pasteThreshold() int {
+	threshold := defaultPasteThreshold
+	if m.config != nil {
+		threshold = m.config.UI.PasteThreshold
+	}
+	if threshold < 0 {
+		return 0
+	}
+	return threshold
+}
+
+// handlePasteKeys answers the question asked for a large paste
+func (m *Model) handlePasteKeys(key string) {
+	switch strings.ToLower(key) {
+	case "a", "enter":
+		if err := m.attachPaste(m.pendingPaste); err != nil {
+			m.addSystemMessage(fmt.Sprintf("Failed to attach the paste: %v", err))
+			return
+		}
+		m.pendingPaste = ""
+	case "i":
+		m.insertTextAtCursor(m.pendingPaste)
+		m.pendingPaste = ""
+	case "esc":
+		m.pendingPaste = ""
+	}
+}
+
+// attachPaste saves text as an artifact of the session and inserts a
+// placeholder for it at the cursor. The placeholder is expanded into a
+// reference to the file with an excerpt when the message is sent.
+func (m *Model) attachPaste(text string) error {
+	sessionID := ""
+	if m.chatHandler != nil {
+		if session := m.chatHandler.GetCurrentSession(); session != nil {
+			sessionID = session.ID
+		}
+	}
+
+	id := len(m.pastes) + 1
+	name := fmt.Sprintf("paste-%s-%d.txt", time.Now().Format("20060102-150405"), id)
+	artifact, err := artifacts.NewStore("").Save(sessionID, name, "Pasted into the input", []byte(text))
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Count(text, "\n") + 1
+	m.pastes = append(m.pastes, pasteAttachment{path: artifact.Path, text: text, lines: lines})
+	m.insertTextAtCursor(fmt.Sprintf("[paste #%d: %d chars, %d lines]", id, len([]rune(text)), lines))
+	return nil
+}
+
+// expandPastes replaces paste placeholders in input with what the model is
+// sent: where the paste is saved and an excerpt of it
+func (m Model) expandPastes(input string) string {
+	return pastePlaceholder.ReplaceAllStringFunc(input, func(placeholder string) string {
+		id, _ := strconv.Atoi(pastePlaceholder.FindStringSubmatch(placeholder)[1])
+		if id < 1 || id > len(m.pastes) {
+			return placeholder
+		}
+		paste := m.pastes[id-1]
+
+		lines := strings.Split(paste.text, "\n")
+		head, tail := lines, []string(nil)
+		if len(lines) > pasteHeadLines+pasteTailLines {
+			head, tail = lines[:pasteHeadLines], lines[len(lines)-pasteTailLines:]
+		}
+		excerpt := truncateRunes(strings.Join(head, "\n"), pasteExcerptChars)
+		if tail != nil || excerpt != paste.text {
+			excerpt += "\n... (omitted; read the file with read_file, using offset to go through it in chunks)"
+			if tail != nil {
+				excerpt += "\n" + truncateRunes(strings.Join(tail, "\n"), pasteExcerptChars/4)
+			}
+		}
+
+		return fmt.Sprintf("[Pasted content: %d characters, %d lines, saved to %s. Excerpt:\n%s\n]",
+			len([]rune(paste.text)), paste.lines, paste.path, excerpt)
+	})
+}
+
+// truncateRunes shortens s to at most n runes
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
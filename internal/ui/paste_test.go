@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestLargePasteAsksFirst(t *testing.T) {
+	m := newTabsTestModel()
+	m.config = config.NewDefaultConfig()
+	m.config.UI.PasteThreshold = 10
+
+	// Small pastes go straight into the input
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("short"), Paste: true})
+	m = updated.(Model)
+	assert.Equal(t, "short", m.currentInput)
+	assert.Empty(t, m.pendingPaste)
+
+	large := strings.Repeat("x", 20)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(large), Paste: true})
+	m = updated.(Model)
+	assert.Equal(t, large, m.pendingPaste)
+	assert.Equal(t, "short", m.currentInput)
+	assert.Contains(t, m.renderHelpLine(), "Large paste (20 chars)")
+
+	// Inserting it anyway
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m = updated.(Model)
+	assert.Empty(t, m.pendingPaste)
+	assert.Equal(t, "short"+large, m.currentInput)
+
+	// Disabled by a negative threshold
+	m.config.UI.PasteThreshold = -1
+	assert.Equal(t, 0, m.pasteThreshold())
+}
+
+func TestExpandPastes(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	m := Model{conversation: conversation{pastes: []pasteAttachment{
+		{path: "a.txt", text: "one\ntwo", lines: 2},
+		{path: "b.txt", text: strings.Join(lines, "\n"), lines: 100},
+	}}}
+
+	short := m.expandPastes("see [paste #1: 7 chars, 2 lines] please")
+	assert.Equal(t, "see [Pasted content: 7 characters, 2 lines, saved to a.txt. Excerpt:\none\ntwo\n] please", short)
+
+	long := m.expandPastes("[paste #2: 791 chars, 100 lines]")
+	require.Contains(t, long, "saved to b.txt")
+	assert.Contains(t, long, "line 40\n... (omitted; read the file with read_file")
+	assert.Contains(t, long, "line 91\n")
+	assert.NotContains(t, long, "line 41\n")
+
+	// Unknown placeholders are left alone
+	assert.Equal(t, "[paste #9: typed]", m.expandPastes("[paste #9: typed]"))
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/security"
+)
+
+// escalationWord has to be typed to approve dangerous tool calls
+const escalationWord = "yes"
+
+// toolCallDangers returns why call is high-risk, or nil
+func (m Model) toolCallDangers(call ai.ToolCall) []string {
+	var params map[string]interface{}
+	_ = json.Unmarshal([]byte(call.Function.Arguments), &params)
+
+	workspace := "."
+	if m.config != nil && m.config.Tools.WorkspaceRoot != "" {
+		workspace = m.config.Tools.WorkspaceRoot
+	}
+	return security.DangerousArguments(call.Function.Name, params, workspace)
+}
+
+// permitEscalated reports whether the permit dialog asks about dangerous calls
+func (m Model) permitEscalated() bool {
+	return len(m.permitDangers) > 0
+}
+
+// confirmDangerousCalls asks to type the escalation word before option is
+// applied; the dialog no longer times out
+func (m *Model) confirmDangerousCalls(option int) (tea.Model, tea.Cmd) {
+	m.typingPermitConfirm = true
+	m.permitConfirmOption = option
+	m.permitConfirm = ""
+	m.permitDeadline = time.Time{}
+	return m, nil
+}
+
+// handlePermitConfirmKeys edits the confirmation of dangerous calls. Enter
+// applies the chosen option once the escalation word is typed; Esc goes
+// back to the options.
+func (m *Model) handlePermitConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if strings.EqualFold(strings.TrimSpace(m.permitConfirm), escalationWord) {
+			return m.applyPermitOption(m.permitConfirmOption)
+		}
+		m.permitConfirm = ""
+	case tea.KeyEsc:
+		m.typingPermitConfirm = false
+		m.permitConfirm = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.permitConfirm); len(runes) > 0 {
+			m.permitConfirm = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.permitConfirm += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// renderPermitDangers lists why the call with id is dangerous
+func (m Model) renderPermitDangers(id string) string {
+	dangers := m.permitDangers[id]
+	if len(dangers) == 0 {
+		return ""
+	}
+
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+	var b strings.Builder
+	for _, danger := range dangers {
+		b.WriteString(style.Render("⚠ "+danger) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+)
+
+func TestDangerousToolCallsEscalate(t *testing.T) {
+	rules, err := security.NewApprovalRules([]config.ApprovalRule{
+		{Name: "commands", Tool: "run_command", Action: config.PermitApprove},
+	}, nil)
+	require.NoError(t, err)
+
+	dangerous := toolCall("1", "run_command")
+	dangerous.Function.Arguments = `{"command": "sudo rm -rf /tmp/x"}`
+
+	m := newPermitTestModel()
+	m.currentMode = ModeInsert
+	m.permitDialogVisible = false
+	m.approvalRules = rules
+	m.config = config.NewDefaultConfig()
+	m.config.Tools.Permit = config.PermitConfig{TimeoutSeconds: 30, Default: config.PermitApprove}
+	m.allowTool("run_command")
+
+	// Neither the rule nor the session allowance approves the call
+	updated, _ := m.Update(chatResponseMsg{ID: "r", ToolCalls: []ai.ToolCall{dangerous}})
+	m = updated.(Model)
+	require.Equal(t, ModePermit, m.currentMode)
+	assert.Equal(t, []string{"deletes files recursively (rm -rf)", "runs with elevated privileges (sudo)"}, m.permitDangers["1"])
+	assert.False(t, m.permitDefaultApproves(), "dangerous calls are never approved on timeout")
+	view := m.renderPermitDialog()
+	assert.Contains(t, view, "Dangerous Tool Call: type yes to approve")
+	assert.Contains(t, view, "⚠ runs with elevated privileges (sudo)")
+
+	press := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			updated, _ := m.Update(msg)
+			m = updated.(Model)
+		}
+	}
+
+	// Allowing asks for the escalation word instead
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	assert.True(t, m.typingPermitConfirm)
+	assert.True(t, m.permitDeadline.IsZero())
+	assert.Contains(t, m.renderPermitDialog(), `Type "yes" to allow: _`)
+
+	// Anything else keeps the dialog open
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, ModePermit, m.currentMode)
+	assert.Empty(t, m.permitConfirm)
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("yes")}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.Nil(t, m.permitDangers)
+	assert.False(t, m.typingPermitConfirm)
+}
+
+func TestSafeToolCallsSkipEscalation(t *testing.T) {
+	m := newPermitTestModel(toolCall("1", "read_file"))
+	m.config = config.NewDefaultConfig()
+
+	updated, _ := m.applyPermitOption(permitAllow)
+	m = *updated.(*Model)
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.False(t, m.typingPermitConfirm)
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// Options of the permit dialog, also chosen with the keys 1, 2 and 3
+const (
+	permitDeny = iota
+	permitAllow
+	permitAllowSession
+)
+
+// permitOptionLabels are the button labels of the permit dialog options
+var permitOptionLabels = []string{"Deny", "Allow", "Allow for session"}
+
+// applyPermitOption answers the permit dialog with option. Allowing for the
+// session also allows later calls of the same tools without asking.
+func (m *Model) applyPermitOption(option int) (tea.Model, tea.Cmd) {
+	// Dangerous calls are only allowed after typing the escalation word
+	if option != permitDeny && m.permitEscalated() && !m.typingPermitConfirm {
+		return m.confirmDangerousCalls(option)
+	}
+
+	switch option {
+	case permitAllowSession:
+		names := make([]string, 0, len(m.pendingToolCalls))
+		for _, call := range m.pendingToolCalls {
+			if !m.allowedTools[call.Function.Name] {
+				names = append(names, call.Function.Name)
+			}
+			m.allowTool(call.Function.Name)
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			m.addSystemMessage(fmt.Sprintf("Allowed for this session: %s", strings.Join(names, ", ")))
+		}
+		return m.exitPermitMode(true)
+	case permitAllow:
+		return m.exitPermitMode(true)
+	default:
+		return m.exitPermitMode(false)
+	}
+}
+
+// allowTool allows a tool to run without asking for the rest of the session
+func (m *Model) allowTool(name string) {
+	if m.allowedTools == nil {
+		m.allowedTools = make(map[string]bool)
+	}
+	m.allowedTools[name] = true
+}
+
+// toolCallsAllowed reports whether every call is of a tool allowed for the
+// session
+func (m Model) toolCallsAllowed(calls []ai.ToolCall) bool {
+	for _, call := range calls {
+		if !m.allowedTools[call.Function.Name] {
+			return false
+		}
+	}
+	return len(calls) > 0
+}
+
+// permitDecision describes what the selected option does to call
+func (m Model) permitDecision(call ai.ToolCall) string {
+	switch m.selectedPermitOption {
+	case permitAllow:
+		if m.allowedTools[call.Function.Name] {
+			return "Allow (already allowed for this session)"
+		}
+		return "Allow once"
+	case permitAllowSession:
+		if m.allowedTools[call.Function.Name] {
+			return "Allow (already allowed for this session)"
+		}
+		return "Allow, and allow " + call.Function.Name + " for this session"
+	default:
+		return "Deny"
+	}
+}
+
+// handlePermitReasonKeys edits the reason for denying the tool calls. Enter
+// denies them with the reason; Esc goes back to the options.
+func (m *Model) handlePermitReasonKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if strings.TrimSpace(m.permitReason) == "" {
+			return m.exitPermitMode(false)
+		}
+		return m.denyWithReason(strings.TrimSpace(m.permitReason))
+	case tea.KeyEsc:
+		m.typingPermitReason = false
+		m.permitReason = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.permitReason); len(runes) > 0 {
+			m.permitReason = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.permitReason += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// denyWithReason denies the pending tool calls and sends the reason back as
+// their results, so the model can adjust its approach
+func (m *Model) denyWithReason(reason string) (tea.Model, tea.Cmd) {
+	if m.permitLocal {
+		return m, m.answerLocalPermit(false)
+	}
+	toolCalls := m.closePermitDialog()
+	m.logger.Debug("Tool calls denied with reason", "count", len(toolCalls), "reason", reason)
+	m.addSystemMessage(fmt.Sprintf("Tool calls denied: %s", reason))
+	if m.chatHandler == nil || len(toolCalls) == 0 {
+		m.ruleApproved, m.ruleDenied = nil, nil
+		m.finishJob()
+		return m, nil
+	}
+
+	results := make([]chat.ToolResult, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		results = append(results, chat.ToolResult{
+			ToolCallID: toolCall.ID,
+			ToolName:   toolCall.Function.Name,
+			Error:      fmt.Errorf("tool call denied by the user: %s", reason),
+			ExecutedAt: time.Now(),
+		})
+	}
+	return m, m.resumeToolCalls(nil, results)
+}
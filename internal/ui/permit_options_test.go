@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+func newPermitTestModel(calls ...ai.ToolCall) Model {
+	m := newTabsTestModel()
+	m.keymap = DefaultKeyMap()
+	m.previousMode = ModeInsert
+	m.currentMode = ModePermit
+	m.permitDialogVisible = true
+	m.pendingToolCalls = calls
+	return m
+}
+
+func toolCall(id, name string) ai.ToolCall {
+	call := ai.ToolCall{ID: id}
+	call.Function.Name = name
+	return call
+}
+
+func TestPermitQuickSelect(t *testing.T) {
+	press := func(m Model, key string) Model {
+		var msg tea.KeyMsg
+		if key == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		} else {
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		updated, _ := m.Update(msg)
+		return updated.(Model)
+	}
+
+	// 1 denies
+	m := press(newPermitTestModel(toolCall("1", "write_file")), "1")
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.Equal(t, "Tool calls rejected by user", m.messages[len(m.messages)-1].Content)
+
+	// Enter applies the selected option, which starts at Deny
+	m = newPermitTestModel(toolCall("1", "write_file"))
+	assert.Equal(t, "Deny", m.permitDecision(m.pendingToolCalls[0]))
+	m = press(m, "l")
+	assert.Equal(t, "Allow once", m.permitDecision(m.pendingToolCalls[0]))
+	m = press(m, "l")
+	m = press(m, "l")
+	assert.Equal(t, permitAllowSession, m.selectedPermitOption, "the selection stops at the last option")
+	assert.Contains(t, m.renderPermitDialog(), "→ Allow, and allow write_file for this session")
+	assert.Contains(t, m.renderPermitDialog(), "3 Allow for session")
+
+	// 3 allows the tools for the rest of the session
+	m = press(newPermitTestModel(toolCall("1", "write_file"), toolCall("2", "read_file")), "3")
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.True(t, m.allowedTools["write_file"])
+	assert.True(t, m.allowedTools["read_file"])
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Allowed for this session: read_file, write_file")
+
+	// Later calls of allowed tools run without the dialog
+	updated, cmd := m.Update(chatResponseMsg{ID: "r", ToolCalls: []ai.ToolCall{toolCall("3", "read_file")}})
+	m = updated.(Model)
+	assert.NotNil(t, cmd)
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.False(t, m.permitDialogVisible)
+
+	// Calls of other tools still ask
+	updated, _ = m.Update(chatResponseMsg{ID: "s", ToolCalls: []ai.ToolCall{toolCall("4", "read_file"), toolCall("5", "run_command")}})
+	m = updated.(Model)
+	assert.Equal(t, ModePermit, m.currentMode)
+	m.selectedPermitOption = permitAllow
+	assert.Equal(t, "Allow (already allowed for this session)", m.permitDecision(m.pendingToolCalls[0]))
+	assert.Equal(t, "Allow once", m.permitDecision(m.pendingToolCalls[1]))
+
+	// A new session forgets them
+	m.startNewSession()
+	assert.False(t, m.toolCallsAllowed([]ai.ToolCall{toolCall("6", "read_file")}))
+}
+
+func TestDenyWithReason(t *testing.T) {
+	m := newPermitTestModel(toolCall("1", "query_db"))
+	m.permitDeadline = time.Now().Add(time.Minute)
+
+	for _, k := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("r")},
+		{Type: tea.KeyRunes, Runes: []rune("use staging")},
+		{Type: tea.KeySpace, Runes: []rune(" ")},
+		{Type: tea.KeyRunes, Runes: []rune("DBx")},
+		{Type: tea.KeyBackspace},
+	} {
+		updated, _ := m.Update(k)
+		m = updated.(Model)
+	}
+	assert.True(t, m.typingPermitReason)
+	assert.Equal(t, "use staging DB", m.permitReason)
+	assert.True(t, m.permitDeadline.IsZero(), "typing a reason stops the timeout")
+	assert.Contains(t, m.renderPermitDialog(), "Reason for denying (sent to the model): use staging DB_")
+
+	// Esc goes back to the options, keeping the dialog open
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	assert.False(t, m.typingPermitReason)
+	assert.Equal(t, ModePermit, m.currentMode)
+
+	for _, k := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("r")},
+		{Type: tea.KeyRunes, Runes: []rune("not now")},
+		{Type: tea.KeyEnter},
+	} {
+		updated, _ := m.Update(k)
+		m = updated.(Model)
+	}
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.False(t, m.typingPermitReason)
+	assert.Equal(t, "Tool calls denied: not now", m.messages[len(m.messages)-1].Content)
+}
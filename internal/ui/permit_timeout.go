@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+)
+
+// permitCountdownTick schedules the next permit dialog timeout tick
+func permitCountdownTick(seq int) tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return permitCountdownMsg{seq: seq}
+	})
+}
+
+// permitTimeout returns how long the permit dialog waits for an answer (0 waits forever)
+func (m Model) permitTimeout() time.Duration {
+	if m.config == nil || m.config.Tools.Permit.TimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(m.config.Tools.Permit.TimeoutSeconds) * time.Second
+}
+
+// permitDefaultApproves reports whether timed out dialogs approve the tool
+// calls. Dangerous calls are never approved by default.
+func (m Model) permitDefaultApproves() bool {
+	return m.config != nil && m.config.Tools.Permit.Default == config.PermitApprove && !m.permitEscalated()
+}
+
+// permitCountdownText describes the pending default decision
+func (m Model) permitCountdownText() string {
+	if m.permitDeadline.IsZero() {
+		return ""
+	}
+	remaining := time.Until(m.permitDeadline).Round(time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+	decision := "Deny"
+	if m.permitDefaultApproves() {
+		decision = "Allow"
+	}
+	return fmt.Sprintf("No answer: %s in %s", decision, remaining)
+}
+
+// applyPermitDefault answers a timed out permit dialog with the configured
+// default decision. Denied calls are reported to the model as failed tool
+// results so the agent loop can carry on.
+func (m *Model) applyPermitDefault() (tea.Model, tea.Cmd) {
+	timeout := m.permitTimeout()
+
+	if m.permitDefaultApproves() {
+		m.logger.Debug("Permit dialog timed out, approving by default")
+		m.addSystemMessage(fmt.Sprintf("No answer within %s: tool calls approved by default policy", timeout))
+		return m.exitPermitMode(true)
+	}
+
+	m.logger.Debug("Permit dialog timed out, denying by default")
+	toolCalls := m.closePermitDialog()
+	m.addSystemMessage(fmt.Sprintf("No answer within %s: tool calls denied by default policy", timeout))
+	if m.chatHandler == nil || len(toolCalls) == 0 {
+		m.ruleApproved, m.ruleDenied = nil, nil
+		return m, nil
+	}
+
+	results := make([]chat.ToolResult, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		results = append(results, chat.ToolResult{
+			ToolCallID: toolCall.ID,
+			ToolName:   toolCall.Function.Name,
+			Error:      fmt.Errorf("tool call denied: the user did not answer the permission prompt within %s", timeout),
+			ExecutedAt: time.Now(),
+		})
+	}
+	return m, m.resumeToolCalls(nil, results)
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+)
+
+// handlePersonaCommand runs "/persona [name|off]": it lists the configured
+// personas, or applies one to the conversation of the active tab
+func (m *Model) handlePersonaCommand(args []string) {
+	if m.chatHandler == nil || m.config == nil {
+		m.addSystemMessage("Personas are not available")
+		return
+	}
+	if len(args) == 0 {
+		m.listPersonas()
+		return
+	}
+
+	name := args[0]
+	if name == "off" || name == "default" {
+		m.chatHandler.SetPersona("", config.Persona{})
+		m.personaRules = nil
+		m.addSystemMessage("Persona cleared; using the default system prompt")
+		return
+	}
+
+	persona, ok := m.config.Personas[name]
+	if !ok {
+		m.addSystemMessage(fmt.Sprintf("Unknown persona %q (use /persona to list them)", name))
+		return
+	}
+	rules, err := personaApprovalRules(name, persona)
+	if err != nil {
+		m.addSystemMessage(err.Error())
+		return
+	}
+
+	m.chatHandler.SetPersona(name, persona)
+	m.personaRules = rules
+	text := fmt.Sprintf("Persona %s active", name)
+	if persona.Description != "" {
+		text += ": " + persona.Description
+	}
+	if n := rules.Len(); n > 0 {
+		text += fmt.Sprintf(" (%d approval rules)", n)
+	}
+	m.addSystemMessage(text)
+}
+
+// personaApprovalRules compiles the rules of a persona. Unnamed rules are
+// named after the persona so their matches are counted apart from the
+// configured rules.
+func personaApprovalRules(name string, persona config.Persona) (*security.ApprovalRules, error) {
+	if len(persona.Rules) == 0 {
+		return nil, nil
+	}
+	rules := make([]config.ApprovalRule, len(persona.Rules))
+	for i, rule := range persona.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("%s rule %d", name, i+1)
+		}
+		rules[i] = rule
+	}
+	compiled, err := security.NewApprovalRules(rules, nil)
+	if err != nil {
+		return nil, fmt.Errorf("persona %s: %w", name, err)
+	}
+	return compiled, nil
+}
+
+// listPersonas shows the configured personas, marking the active one
+func (m *Model) listPersonas() {
+	if len(m.config.Personas) == 0 {
+		m.addSystemMessage("No personas configured (add them under personas in the config)")
+		return
+	}
+
+	names := make([]string, 0, len(m.config.Personas))
+	for name := range m.config.Personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	active := m.chatHandler.Persona()
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		marker := "  "
+		if name == active {
+			marker = "* "
+		}
+		line := marker + name
+		if description := m.config.Personas[name].Description; description != "" {
+			line += ": " + description
+		}
+		lines = append(lines, line)
+	}
+	m.addSystemMessage("Personas:\n" + strings.Join(lines, "\n") + "\nUse /persona <name> to switch, /persona off for the default prompt.")
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestPersonaCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.NewDefaultConfig()
+	cfg.Personas = map[string]config.Persona{
+		"reviewer": {
+			Description: "Reviews without editing",
+			Prompt:      "Act as a strict code reviewer.",
+			Rules:       []config.ApprovalRule{{Tool: "write_file", Action: config.PermitDeny}},
+		},
+		"architect": {Prompt: "Think about module boundaries."},
+	}
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), cfg, nil)
+	defer handler.Close()
+
+	m := newTabsTestModel()
+	m.config = cfg
+	m.chatHandler = handler
+	lastMessage := func() string { return m.messages[len(m.messages)-1].Content }
+
+	m.handlePersonaCommand(nil)
+	assert.Equal(t, "Personas:\n  architect\n  reviewer: Reviews without editing\nUse /persona <name> to switch, /persona off for the default prompt.", lastMessage())
+
+	m.handlePersonaCommand([]string{"reviewer"})
+	assert.Equal(t, "Persona reviewer active: Reviews without editing (1 approval rules)", lastMessage())
+	assert.Equal(t, "reviewer", handler.Persona())
+	assert.Contains(t, handler.GetSystemPrompt(), "## Persona: reviewer\nAct as a strict code reviewer.")
+
+	// The persona's rules decide before the configured ones
+	match := m.matchApprovalRule(ai.ToolCall{Function: ai.FunctionCall{Name: "write_file", Arguments: `{"path":"a.go"}`}})
+	require.NotNil(t, match)
+	assert.False(t, match.Approved())
+	assert.Equal(t, "reviewer rule 1", match.Rule)
+	assert.Nil(t, m.matchApprovalRule(ai.ToolCall{Function: ai.FunctionCall{Name: "read_file"}}))
+
+	m.handlePersonaCommand([]string{"architect"})
+	assert.NotContains(t, handler.GetSystemPrompt(), "strict code reviewer")
+	assert.Nil(t, m.personaRules)
+
+	m.handlePersonaCommand([]string{"off"})
+	assert.Empty(t, handler.Persona())
+	assert.NotContains(t, handler.GetSystemPrompt(), "## Persona")
+
+	m.handlePersonaCommand([]string{"tester"})
+	assert.Equal(t, `Unknown persona "tester" (use /persona to list them)`, lastMessage())
+}
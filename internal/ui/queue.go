@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxQueuedPrompts bounds the prompts waiting for the current turn
+const maxQueuedPrompts = 10
+
+// busy reports whether the active tab is waiting for a response or for
+// tool calls to finish
+func (m *Model) busy() bool {
+	return m.loading || m.activeJob() != nil
+}
+
+// queuePrompt keeps input to send once the current turn finishes
+func (m *Model) queuePrompt(input string) {
+	if len(m.queuedPrompts) >= maxQueuedPrompts {
+		m.inputErrors = []string{fmt.Sprintf("At most %d prompts can be queued", maxQueuedPrompts)}
+		return
+	}
+	m.queuedPrompts = append(m.queuedPrompts, input)
+	m.currentInput = ""
+	m.cursorPosition = 0
+	m.cursorColumn = 0
+	m.inputScrollPosition = 0
+}
+
+// sendQueuedPrompt sends the oldest queued prompt when the tab is idle and
+// the input is empty
+func (m *Model) sendQueuedPrompt() tea.Cmd {
+	if len(m.queuedPrompts) == 0 || m.busy() || strings.TrimSpace(m.currentInput) != "" {
+		return nil
+	}
+	m.currentInput = m.queuedPrompts[0]
+	m.queuedPrompts = m.queuedPrompts[1:]
+	m.cursorPosition = len([]rune(m.currentInput))
+	_, cmd := m.sendMessage()
+	return cmd
+}
+
+// handleQueueCommand lists the queued prompts, or drops them with "clear"
+func (m *Model) handleQueueCommand(args []string) {
+	switch {
+	case len(args) == 1 && strings.ToLower(args[0]) == "clear":
+		m.addSystemMessage(fmt.Sprintf("Dropped %d queued prompts", len(m.queuedPrompts)))
+		m.queuedPrompts = nil
+	case len(args) > 0:
+		m.addSystemMessage("Usage: /queue [clear]")
+	case len(m.queuedPrompts) == 0:
+		m.addSystemMessage("No prompts are queued. Prompts sent while a response is running are queued and sent when it finishes.")
+	default:
+		var sb strings.Builder
+		sb.WriteString("Queued prompts:")
+		for i, prompt := range m.queuedPrompts {
+			fmt.Fprintf(&sb, "\n  %d. %s", i+1, prompt)
+		}
+		m.addSystemMessage(sb.String())
+	}
+}
+
+// renderQueue renders the queued prompts, one line each
+func (m Model) renderQueue() string {
+	if len(m.queuedPrompts) == 0 {
+		return ""
+	}
+	width := max(m.width-16, 20)
+	lines := make([]string, len(m.queuedPrompts))
+	for i, prompt := range m.queuedPrompts {
+		first, _, _ := strings.Cut(prompt, "\n")
+		lines[i] = m.styles.Muted.Render(fmt.Sprintf("  queued %d: %s", i+1, ansi.Truncate(first, width, "…")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderUnderInput renders what is shown under the input box: problems with
+// the input and the queued prompts
+func (m Model) renderUnderInput() string {
+	var parts []string
+	if inputErrors := m.renderInputErrors(); inputErrors != "" {
+		parts = append(parts, inputErrors)
+	}
+	if queue := m.renderQueue(); queue != "" {
+		parts = append(parts, queue)
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeQueue(t *testing.T) {
+	m := newTabsTestModel()
+	m.jobs = newJobList()
+	m.loading = true
+
+	for _, prompt := range []string{"first follow-up", "second follow-up"} {
+		m.currentInput = prompt
+		updated, _ := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+		m = updated.(Model)
+	}
+	assert.Equal(t, []string{"first follow-up", "second follow-up"}, m.queuedPrompts)
+	assert.Empty(t, m.currentInput)
+	assert.Contains(t, m.renderUnderInput(), "queued 2: second follow-up")
+
+	// Slash commands run right away
+	m.currentInput = "/queue"
+	updated, _ := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	m = *updated.(*Model)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "1. first follow-up")
+
+	// Finishing the turn sends the oldest queued prompt
+	updated, _ = m.Update(chatResponseMsg{ID: "r", Content: "done"})
+	m = updated.(Model)
+	assert.Equal(t, []string{"second follow-up"}, m.queuedPrompts)
+	assert.True(t, m.loading)
+	assert.Equal(t, "first follow-up", m.messages[len(m.messages)-1].Content)
+
+	m.handleQueueCommand([]string{"clear"})
+	assert.Empty(t, m.queuedPrompts)
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// redactExcerptLength bounds the excerpt of each message listed by /redact
+const redactExcerptLength = 60
+
+// handleRedactCommand runs "/redact [N [text]]": without arguments it lists
+// the messages of the session by number; with a number it replaces text in
+// that message, or its whole content, with a placeholder. rest is the line
+// after the command, as the text may have spaces.
+func (m *Model) handleRedactCommand(args []string, rest string) {
+	if m.chatHandler == nil || m.chatHandler.GetCurrentSession() == nil {
+		m.addSystemMessage("There is no session to redact")
+		return
+	}
+	if len(args) == 0 {
+		m.listSessionMessages()
+		return
+	}
+
+	index, ok := m.sessionMessageIndex(args[0])
+	if !ok {
+		m.addSystemMessage(fmt.Sprintf("Usage: /redact [N [text]] (no message %q; use /redact to list them)", args[0]))
+		return
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(rest, args[0]))
+
+	original, err := m.chatHandler.RedactMessage(index, text)
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Cannot redact message %s: %v", args[0], err))
+		return
+	}
+
+	if i := m.displayedMessage(original); i >= 0 {
+		if text == "" {
+			m.messages[i].Content = chat.RedactedText
+		} else {
+			m.messages[i].Content = strings.ReplaceAll(m.messages[i].Content, text, chat.RedactedText)
+		}
+	}
+	if text != "" {
+		// Tool results are shown as summaries that may quote the text too
+		for i := range m.messages {
+			if m.messages[i].Role == "tool" {
+				m.messages[i].Content = strings.ReplaceAll(m.messages[i].Content, text, chat.RedactedText)
+			}
+		}
+	}
+	m.addSystemMessage(fmt.Sprintf("Redacted message %s; later prompts are sent without the redacted text", args[0]))
+}
+
+// handleDeleteCommand runs "/delete N": it removes message N of the session,
+// with the tool results of an answer that called tools
+func (m *Model) handleDeleteCommand(args []string) {
+	if m.chatHandler == nil || m.chatHandler.GetCurrentSession() == nil {
+		m.addSystemMessage("There is no session to delete messages from")
+		return
+	}
+	if len(args) == 0 {
+		m.addSystemMessage("Usage: /delete N (use /redact to list the messages by number)")
+		return
+	}
+
+	index, ok := m.sessionMessageIndex(args[0])
+	if !ok {
+		m.addSystemMessage(fmt.Sprintf("Usage: /delete N (no message %q; use /redact to list them)", args[0]))
+		return
+	}
+	removed, err := m.chatHandler.DeleteMessage(index)
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Cannot delete message %s: %v", args[0], err))
+		return
+	}
+
+	if i := m.displayedMessage(removed[0]); i >= 0 {
+		m.messages = append(m.messages[:i], m.messages[i+1:]...)
+	}
+	text := fmt.Sprintf("Deleted message %s from the session", args[0])
+	if len(removed) > 1 {
+		text += fmt.Sprintf(" with %d tool results", len(removed)-1)
+	}
+	m.addSystemMessage(text)
+}
+
+// listSessionMessages shows the messages of the session with the numbers
+// /redact and /delete take
+func (m *Model) listSessionMessages() {
+	session := m.chatHandler.GetCurrentSession()
+	var lines []string
+	n := 0
+	for _, msg := range session.Messages {
+		if msg.Role == ai.RoleSystem {
+			continue
+		}
+		n++
+		role := msg.Role
+		if chat.IsToolResult(msg) {
+			role = "tool"
+		}
+		excerpt := strings.Join(strings.Fields(msg.Content), " ")
+		lines = append(lines, fmt.Sprintf("  %d. %s: %s", n, role, truncateLine(excerpt, redactExcerptLength)))
+	}
+	if n == 0 {
+		m.addSystemMessage("The session has no messages")
+		return
+	}
+	m.addSystemMessage("Messages:\n" + strings.Join(lines, "\n") + "\nUse /redact N [text] to redact a message (or only text in it), /delete N to delete it.")
+}
+
+// sessionMessageIndex converts the number of a listed message into its
+// index in the session, which starts with the system prompt
+func (m *Model) sessionMessageIndex(arg string) (int, bool) {
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < 1 {
+		return 0, false
+	}
+	n := 0
+	for i, msg := range m.chatHandler.GetCurrentSession().Messages {
+		if msg.Role == ai.RoleSystem {
+			continue
+		}
+		if n++; n == number {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// displayedMessage finds the conversation message showing a session message,
+// or -1. Answers are shown with a note about the tool calls they request,
+// and tool results only as a summary, which is not matched.
+func (m *Model) displayedMessage(msg ai.Message) int {
+	if chat.IsToolResult(msg) || msg.Content == "" {
+		return -1
+	}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		shown := m.messages[i]
+		if shown.Role != msg.Role {
+			continue
+		}
+		if shown.Content == msg.Content || (msg.Role == ai.RoleAssistant && strings.HasPrefix(shown.Content, msg.Content)) {
+			return i
+		}
+	}
+	return -1
+}
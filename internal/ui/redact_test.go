@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestRedactAndDelete(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), config.NewDefaultConfig(), nil)
+	defer handler.Close()
+	require.NoError(t, handler.CreateNewSession())
+	session := []ai.Message{
+		{Role: ai.RoleUser, Content: "my token is sk-secret 123, list the files"},
+		{Role: ai.RoleAssistant, Content: "Listing them.", ToolCalls: []ai.ToolCall{{ID: "1", Function: ai.FunctionCall{Name: "list_files", Arguments: `{"path":"."}`}}}},
+		{Role: ai.RoleUser, Content: "TOOL_RESULT[list_files]: main.go"},
+		{Role: ai.RoleAssistant, Content: "There is main.go."},
+	}
+	for _, msg := range session {
+		require.NoError(t, handler.AddMessageToSession(msg))
+	}
+
+	m := newTabsTestModel()
+	m.chatHandler = handler
+	m.messages = []Message{
+		{ID: "1", Role: "user", Content: "my token is sk-secret 123, list the files"},
+		{ID: "2", Role: "assistant", Content: "Listing them.[Tool calls requested: 1]"},
+		{ID: "3", Role: "tool", Content: "list_files: 1 file"},
+		{ID: "4", Role: "assistant", Content: "There is main.go."},
+	}
+	lastMessage := func() string { return m.messages[len(m.messages)-1].Content }
+
+	m.handleRedactCommand(nil, "")
+	assert.Equal(t, "Messages:\n  1. user: my token is sk-secret 123, list the files\n  2. assistant: Listing them.\n  3. tool: TOOL_RESULT[list_files]: main.go\n  4. assistant: There is main.go.\nUse /redact N [text] to redact a message (or only text in it), /delete N to delete it.", lastMessage())
+
+	m.handleRedactCommand([]string{"1", "sk-secret", "123"}, "1 sk-secret 123")
+	assert.Equal(t, "Redacted message 1; later prompts are sent without the redacted text", lastMessage())
+	assert.Equal(t, "my token is [redacted], list the files", m.messages[0].Content)
+	assert.Equal(t, "my token is [redacted], list the files", handler.GetCurrentSession().Messages[0].Content)
+
+	m.handleRedactCommand([]string{"4", "password"}, "4 password")
+	assert.Equal(t, `Cannot redact message 4: the message does not contain "password"`, lastMessage())
+
+	m.handleRedactCommand([]string{"3"}, "3")
+	assert.Equal(t, "TOOL_RESULT[list_files]: [redacted]", handler.GetCurrentSession().Messages[2].Content)
+
+	// Deleting an answer that called tools removes their results too
+	m.handleDeleteCommand([]string{"2"})
+	assert.Equal(t, "Deleted message 2 from the session with 1 tool results", lastMessage())
+	messages := handler.GetCurrentSession().Messages
+	require.Len(t, messages, 2)
+	assert.Equal(t, "There is main.go.", messages[1].Content)
+	assert.NotContains(t, m.messages, Message{ID: "2", Role: "assistant", Content: "Listing them.[Tool calls requested: 1]"})
+
+	m.handleDeleteCommand([]string{"9"})
+	assert.Equal(t, `Usage: /delete N (no message "9"; use /redact to list them)`, lastMessage())
+}
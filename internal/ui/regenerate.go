@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/textdiff"
+)
+
+// answerRetry remembers the answer replaced by /regenerate so the new one
+// can be compared with it
+type answerRetry struct {
+	previous string
+	// turn is the index of the resent user message in the messages
+	turn int
+}
+
+// regenerate sends the last user message again in place of its answer
+func (m *Model) regenerate() (tea.Model, tea.Cmd) {
+	if m.chatHandler == nil {
+		m.addSystemMessage("Regenerating is not available without a chat handler")
+		return m, nil
+	}
+	if m.busy() {
+		m.addSystemMessage("Wait for the current response to finish before regenerating")
+		return m, nil
+	}
+
+	turn := lastUserMessage(m.messages)
+	previous := answerAfter(m.messages, turn)
+	if turn < 0 || previous == "" {
+		m.addSystemMessage("There is no answer to regenerate")
+		return m, nil
+	}
+	if err := m.chatHandler.DropLastTurn(); err != nil {
+		m.addSystemMessage(fmt.Sprintf("Cannot regenerate: %v", err))
+		return m, nil
+	}
+
+	input := m.messages[turn].Content
+	m.messages = m.messages[:turn]
+	m.retry = &answerRetry{previous: previous, turn: turn}
+	m.currentInput = input
+	return m.sendMessage()
+}
+
+// retryLastMessage sends the last user message again after its request
+// failed. The handler already saved the message, so the unanswered turn is
+// dropped first rather than added to the conversation twice.
+func (m *Model) retryLastMessage() (tea.Model, tea.Cmd) {
+	turn := lastUserMessage(m.messages)
+	if turn < 0 {
+		return m, nil
+	}
+	if m.chatHandler != nil {
+		if session := m.chatHandler.GetCurrentSession(); session != nil {
+			if n := len(session.Messages); n > 0 && session.Messages[n-1].Role == ai.RoleUser {
+				if err := m.chatHandler.DropLastTurn(); err != nil {
+					m.logger.Debug("Failed to drop the failed turn", "error", err)
+				}
+			}
+		}
+	}
+
+	input := m.messages[turn].Content
+	m.messages = m.messages[:turn]
+	m.currentInput = input
+	return m.sendMessage()
+}
+
+// noteRegenerated tells what changed once a regenerated answer is complete
+func (m *Model) noteRegenerated() {
+	if m.retry == nil || lastUserMessage(m.messages) != m.retry.turn {
+		return
+	}
+	current := answerAfter(m.messages, m.retry.turn)
+	_, added, removed := textdiff.Unified("answer", m.retry.previous, current, true, true)
+	if added == 0 && removed == 0 {
+		m.addSystemMessage("The regenerated answer is the same as the previous one")
+		return
+	}
+	m.addSystemMessage(fmt.Sprintf("The regenerated answer adds %d lines and removes %d; /diff shows the changes (/diff split side by side)", added, removed))
+}
+
+// showAnswerDiff runs "/diff [split]": it compares the last regenerated
+// answer with the one it replaced, as a unified diff or in two columns
+func (m *Model) showAnswerDiff(args []string) {
+	if m.retry == nil || m.retry.turn >= len(m.messages) || m.messages[m.retry.turn].Role != "user" {
+		m.addSystemMessage("No regenerated answer to compare (use /regenerate first)")
+		return
+	}
+	current := answerAfter(m.messages, m.retry.turn)
+	if current == "" {
+		m.addSystemMessage("The regenerated answer is not complete yet")
+		return
+	}
+
+	if len(args) > 0 && args[0] == "split" {
+		diff := textdiff.SideBySide(m.retry.previous, current, max((m.width-8)/2, 20))
+		if diff == "" {
+			m.addSystemMessage("The regenerated answer is the same as the previous one")
+			return
+		}
+		m.addSystemMessage("Previous answer | Regenerated answer\n" + diff)
+		return
+	}
+
+	diff, _, _ := textdiff.Unified("answer", m.retry.previous, current, true, true)
+	if diff == "" {
+		m.addSystemMessage("The regenerated answer is the same as the previous one")
+		return
+	}
+	diff = strings.Replace(diff, "--- a/answer\n+++ b/answer\n", "--- previous answer\n+++ regenerated answer\n", 1)
+	m.addSystemMessage(strings.TrimRight(diff, "\n"))
+}
+
+// lastUserMessage returns the index of the last user message, or -1
+func lastUserMessage(messages []Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
+// answerAfter joins the assistant messages that answered the user message
+// at turn, ending in a newline so the answers compare as whole lines
+func answerAfter(messages []Message, turn int) string {
+	if turn < 0 {
+		return ""
+	}
+	var parts []string
+	for _, msg := range messages[turn+1:] {
+		if msg.Role == "user" {
+			break
+		}
+		if msg.Role == "assistant" && strings.TrimSpace(msg.Content) != "" {
+			parts = append(parts, strings.TrimSpace(msg.Content))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestRegenerate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.NewDefaultConfig()
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), cfg, nil)
+	defer handler.Close()
+	require.NoError(t, handler.CreateNewSession())
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleUser, Content: "first"}))
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleAssistant, Content: "one"}))
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleUser, Content: "write a loop"}))
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleAssistant, Content: "for i := 0; i < n; i++ {\n\tsum += i\n}"}))
+
+	m := newTabsTestModel()
+	m.config = cfg
+	m.chatHandler = handler
+	m.messages = []Message{
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: "one"},
+		{Role: "user", Content: "write a loop"},
+		{Role: "assistant", Content: "for i := 0; i < n; i++ {\n\tsum += i\n}"},
+	}
+
+	m.showAnswerDiff(nil)
+	assert.Equal(t, "No regenerated answer to compare (use /regenerate first)", m.messages[len(m.messages)-1].Content)
+	m.messages = m.messages[:4]
+
+	_, cmd := m.regenerate()
+	assert.NotNil(t, cmd)
+	assert.True(t, m.loading)
+	require.Len(t, m.messages, 3, "the old answer is replaced")
+	assert.Equal(t, "write a loop", m.messages[2].Content)
+	assert.Len(t, handler.GetCurrentSession().Messages, 2, "the turn is sent again")
+
+	updated, _ := m.Update(chatResponseMsg{ID: "new", Content: "for i := range n {\n\tsum += i\n}"})
+	m = updated.(Model)
+	assert.Equal(t, "The regenerated answer adds 1 lines and removes 1; /diff shows the changes (/diff split side by side)", m.messages[len(m.messages)-1].Content)
+
+	m.showAnswerDiff(nil)
+	assert.Equal(t, "--- previous answer\n+++ regenerated answer\n@@ -1,3 +1,3 @@\n-for i := 0; i < n; i++ {\n+for i := range n {\n \tsum += i\n }", m.messages[len(m.messages)-1].Content)
+
+	m.showAnswerDiff([]string{"split"})
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Previous answer | Regenerated answer\nfor i := 0; i < n; i++ {")
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "| for i := range n {")
+}
+
+func TestRegenerateWithoutAnswer(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.NewDefaultConfig()
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), cfg, nil)
+	defer handler.Close()
+
+	m := newTabsTestModel()
+	m.config = cfg
+	m.chatHandler = handler
+	m.messages = []Message{{Role: "system", Content: "Welcome"}}
+
+	_, cmd := m.regenerate()
+	assert.Nil(t, cmd)
+	assert.Equal(t, "There is no answer to regenerate", m.messages[len(m.messages)-1].Content)
+}
+
+func TestRetryLastMessage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.NewDefaultConfig()
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), cfg, nil)
+	defer handler.Close()
+	require.NoError(t, handler.CreateNewSession())
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleUser, Content: "first"}))
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleAssistant, Content: "one"}))
+
+	m := newTabsTestModel()
+	m.config = cfg
+	m.chatHandler = handler
+	m.messages = []Message{
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: "one"},
+		{Role: "user", Content: "second"},
+	}
+
+	// The request failed before the message was saved: the answered turn stays
+	_, cmd := m.retryLastMessage()
+	assert.NotNil(t, cmd)
+	assert.Len(t, handler.GetCurrentSession().Messages, 2)
+
+	// The request failed after the message was saved: it is not saved twice
+	m.loading = false
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleUser, Content: "second"}))
+	_, cmd = m.retryLastMessage()
+	assert.NotNil(t, cmd)
+	assert.Len(t, handler.GetCurrentSession().Messages, 2, "the unanswered turn is dropped before it is sent again")
+	require.Len(t, m.messages, 3)
+	assert.Equal(t, "second", m.messages[2].Content)
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+// loadTranscript reads a representative conversation: questions, tool
+// results and long answers with code blocks
+func loadTranscript(b *testing.B) []Message {
+	b.Helper()
+	data, err := os.ReadFile("testdata/transcript.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var messages []Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		b.Fatal(err)
+	}
+	for i := range messages {
+		messages[i].ID = fmt.Sprintf("m%d", i)
+	}
+	return messages
+}
+
+func BenchmarkUpdateViewportContent(b *testing.B) {
+	transcript := loadTranscript(b)
+
+	benchmarks := []struct {
+		name string
+		cold bool // Every message is rendered again, as after a resize
+	}{
+		{name: "cached"},
+		{name: "cold", cold: true},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			m := newTabsTestModel()
+			m.config = config.NewDefaultConfig()
+			m.markdown = newContentRenderer(m.config, "default", m.styles)
+			m.messages = transcript
+			m.updateViewportContent()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if bm.cold {
+					m.rendered = nil
+				}
+				m.updateViewportContent()
+			}
+		})
+	}
+}
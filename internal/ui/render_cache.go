@@ -0,0 +1,129 @@
+package ui
+
+// renderedMessage is a message rendered into the viewport, together with
+// what it was rendered from
+type renderedMessage struct {
+	role    string
+	content string
+	width   int
+	header  string // Timestamp and role line, which changes with relative timestamps
+
+	block      string      // The rendered message
+	links      []string    // The links listed under it
+	codeBlocks []codeBlock // The code blocks of an answer, listed under it for /code
+}
+
+// messageLinks are the links found in a message's content
+type messageLinks struct {
+	content string
+	links   []string
+}
+
+// renderCache keeps rendered messages by message ID so the viewport only
+// re-renders messages that are new, changed, or reflowed to another width.
+// The links of messages are kept separately, as they are needed to number
+// links even for messages that are not rendered.
+type renderCache struct {
+	entries map[string]renderedMessage
+	links   map[string]messageLinks
+}
+
+// newRenderCache creates an empty render cache
+func newRenderCache() *renderCache {
+	return &renderCache{
+		entries: make(map[string]renderedMessage),
+		links:   make(map[string]messageLinks),
+	}
+}
+
+// get returns msg rendered at width below header, calling render only when
+// the cached block is missing or stale
+func (c *renderCache) get(msg Message, width int, header string, render func(Message) string) renderedMessage {
+	if c.fresh(msg, width, header) {
+		return c.entries[msg.ID]
+	}
+
+	rendered := c.placeholder(msg, width, header, render)
+	// Messages without an ID cannot be told apart; render them every time
+	if msg.ID != "" {
+		c.entries[msg.ID] = rendered
+	}
+	return rendered
+}
+
+// fresh reports whether msg is cached as rendered at width below header
+func (c *renderCache) fresh(msg Message, width int, header string) bool {
+	cached, ok := c.entries[msg.ID]
+	return ok && cached.width == width && cached.header == header &&
+		cached.role == msg.Role && cached.content == msg.Content
+}
+
+// placeholder returns msg rendered by render without caching it, for a
+// message shown as it is while it is rendered in the background
+func (c *renderCache) placeholder(msg Message, width int, header string, render func(Message) string) renderedMessage {
+	rendered := renderedMessage{
+		role:    msg.Role,
+		content: msg.Content,
+		width:   width,
+		header:  header,
+		block:   render(msg),
+		links:   c.linksOf(msg),
+	}
+	if msg.Role == "assistant" {
+		rendered.codeBlocks = extractCodeBlocks(msg.Content)
+	}
+	return rendered
+}
+
+// put caches a message rendered in the background
+func (c *renderCache) put(msg Message, width int, header, block string) {
+	if msg.ID == "" {
+		return
+	}
+	c.entries[msg.ID] = c.placeholder(msg, width, header, func(Message) string {
+		return block
+	})
+}
+
+// linksOf returns the links listed under msg
+func (c *renderCache) linksOf(msg Message) []string {
+	if msg.Role != "assistant" {
+		return nil
+	}
+	if cached, ok := c.links[msg.ID]; ok && cached.content == msg.Content {
+		return cached.links
+	}
+	links := extractLinks(msg.Content)
+	if msg.ID != "" {
+		c.links[msg.ID] = messageLinks{content: msg.Content, links: links}
+	}
+	return links
+}
+
+// prune drops the rendered messages that are not in shown and the links of
+// messages that are not in messages. Entries of other tabs are dropped too
+// and rebuilt when their tab is shown again.
+func (c *renderCache) prune(shown, messages []Message) {
+	if len(c.entries) > len(shown) {
+		keep := make(map[string]bool, len(shown))
+		for _, msg := range shown {
+			keep[msg.ID] = true
+		}
+		for id := range c.entries {
+			if !keep[id] {
+				delete(c.entries, id)
+			}
+		}
+	}
+	if len(c.links) > len(messages) {
+		keep := make(map[string]bool, len(messages))
+		for _, msg := range messages {
+			keep[msg.ID] = true
+		}
+		for id := range c.links {
+			if !keep[id] {
+				delete(c.links, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCache(t *testing.T) {
+	cache := newRenderCache()
+	renders := 0
+	render := func(msg Message) string {
+		renders++
+		return msg.Role + ": " + msg.Content
+	}
+
+	msg := Message{ID: "1", Role: "assistant", Content: "see https://go.dev"}
+	assert.Equal(t, "assistant: see https://go.dev", cache.get(msg, 80, "", render).block)
+	assert.Equal(t, []string{"https://go.dev"}, cache.get(msg, 80, "", render).links)
+	assert.Equal(t, 1, renders, "unchanged messages are not rendered again")
+
+	cache.get(msg, 100, "", render)
+	assert.Equal(t, 2, renders, "a new width reflows the message")
+
+	cache.get(msg, 100, "[just now] assistant", render)
+	assert.Equal(t, 3, renders, "a new header renders the message again")
+
+	msg.Content += " and more"
+	assert.Equal(t, "assistant: see https://go.dev and more", cache.get(msg, 100, "", render).block)
+	assert.Equal(t, 4, renders, "changed content is rendered again")
+
+	noID := Message{Role: "system", Content: "hi"}
+	cache.get(noID, 100, "", render)
+	cache.get(noID, 100, "", render)
+	assert.Equal(t, 6, renders, "messages without an ID are not cached")
+
+	cache.get(Message{ID: "2", Role: "user", Content: "x"}, 100, "", render)
+	cache.prune([]Message{{ID: "2"}}, []Message{{ID: "1"}, {ID: "2"}})
+	assert.Len(t, cache.entries, 1)
+	assert.Contains(t, cache.entries, "2")
+	assert.Contains(t, cache.links, "1", "links are kept for messages that are not shown")
+}
+
+func TestUpdateViewportContentUsesCache(t *testing.T) {
+	m := newTabsTestModel()
+	m.messages = []Message{
+		{ID: "1", Role: "user", Content: "question"},
+		{ID: "2", Role: "assistant", Content: "answer https://go.dev"},
+	}
+	m.updateViewportContent()
+	first := m.viewport.View()
+	assert.Len(t, m.rendered.entries, 2)
+
+	m.messages = append(m.messages, Message{ID: "3", Role: "assistant", Content: "and https://pkg.go.dev"})
+	m.updateViewportContent()
+	assert.Len(t, m.rendered.entries, 3)
+	assert.Equal(t, []string{"https://pkg.go.dev"}, m.rendered.entries["3"].links)
+	assert.NotEqual(t, first, m.viewport.View())
+}
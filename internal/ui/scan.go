@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/tools"
+	"github.com/common-creation/coda/internal/vulnscan"
+)
+
+// scanTimeout bounds a /scan run
+const scanTimeout = 10 * time.Minute
+
+// scanMsg reports the result of /scan
+type scanMsg struct {
+	report *vulnscan.Report
+	err    error
+	// fix sends the findings to the model to be fixed
+	fix bool
+}
+
+// handleScanCommand runs "/scan [gosec|osv-scanner] [fix]" in the background
+func (m *Model) handleScanCommand(args []string) tea.Cmd {
+	scanners := vulnscan.Scanners()
+	fix := false
+	for _, arg := range args {
+		switch name := strings.ToLower(arg); {
+		case name == "fix":
+			fix = true
+		case name == "all":
+			scanners = vulnscan.Scanners()
+		default:
+			scanner, ok := vulnscan.Find(name)
+			if !ok {
+				m.addSystemMessage("Usage: /scan [gosec|osv-scanner] [fix]")
+				return nil
+			}
+			scanners = []*vulnscan.Scanner{scanner}
+		}
+	}
+
+	dir := "."
+	if m.config != nil && m.config.Tools.WorkspaceRoot != "" {
+		dir = m.config.Tools.WorkspaceRoot
+	}
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	names := make([]string, len(scanners))
+	for i, s := range scanners {
+		names[i] = s.Name
+	}
+	m.addSystemMessage(fmt.Sprintf("Scanning with %s...", strings.Join(names, " and ")))
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+		defer cancel()
+		report, err := vulnscan.Scan(ctx, dir, scanners)
+		return scanMsg{report: report, err: err, fix: fix}
+	}
+}
+
+// finishScan shows the findings of /scan and, for "/scan fix", asks the
+// model to fix them
+func (m *Model) finishScan(msg scanMsg) tea.Cmd {
+	if msg.err != nil {
+		m.addSystemMessage(fmt.Sprintf("Security scan failed: %v", msg.err))
+		return nil
+	}
+	m.addSystemMessage(msg.report.String())
+	if !msg.fix || len(msg.report.Findings) == 0 {
+		return nil
+	}
+	m.queuedPrompts = append(m.queuedPrompts, scanFixPrompt(msg.report))
+	return m.sendQueuedPrompt()
+}
+
+// scanFixPrompt asks the model to fix the findings of a scan
+func scanFixPrompt(report *vulnscan.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "A security scan (%s) reported %s:\n", strings.Join(report.Scanned, ", "), report.CountsText())
+	for _, f := range report.Findings {
+		fmt.Fprintf(&b, "- [%s] %s %s: %s", f.Severity, f.ID, f.Location(), f.Title)
+		if f.Fixed != "" {
+			fmt.Fprintf(&b, " (fixed in %s)", f.Fixed)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nFix them, most severe first. Upgrade vulnerable dependencies to a fixed version; for source findings, change the code rather than suppressing the warning, unless it is a false positive (then explain why). ")
+	fmt.Fprintf(&b, "Run %s again afterwards to confirm.", tools.SecurityScanToolName)
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/vulnscan"
+)
+
+func TestFinishScan(t *testing.T) {
+	m := newTabsTestModel()
+	lastMessage := func() string { return m.messages[len(m.messages)-1].Content }
+
+	assert.Nil(t, m.handleScanCommand([]string{"trivy"}))
+	assert.Equal(t, "Usage: /scan [gosec|osv-scanner] [fix]", lastMessage())
+
+	require.NotNil(t, m.handleScanCommand([]string{"osv", "fix"}))
+	assert.Equal(t, "Scanning with osv-scanner...", lastMessage())
+
+	report := &vulnscan.Report{
+		Scanned: []string{"gosec"},
+		Findings: []vulnscan.Finding{
+			{Scanner: "gosec", ID: "G101", Severity: vulnscan.SeverityHigh, Title: "Potential hardcoded credentials", File: "config.go", Line: 12},
+		},
+	}
+	m.finishScan(scanMsg{report: report})
+	assert.Equal(t, "Security scan (gosec): 1 HIGH\n  [HIGH] G101 config.go:12: Potential hardcoded credentials", lastMessage())
+	assert.Empty(t, m.queuedPrompts)
+
+	// The fix prompt waits for the running turn
+	m.loading = true
+	m.finishScan(scanMsg{report: report, fix: true})
+	require.Len(t, m.queuedPrompts, 1)
+	assert.Contains(t, m.queuedPrompts[0], "- [HIGH] G101 config.go:12: Potential hardcoded credentials\n")
+	assert.Contains(t, m.queuedPrompts[0], "Run security_scan again afterwards to confirm.")
+
+	m.finishScan(scanMsg{err: assert.AnError})
+	assert.Contains(t, lastMessage(), "Security scan failed")
+}
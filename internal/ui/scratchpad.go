@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// scratchpadLines is the number of lines of notes shown in the panel
+const scratchpadLines = 6
+
+// toggleScratchpad opens the scratchpad panel with the notes of the session,
+// or closes it and saves them
+func (m *Model) toggleScratchpad() {
+	if m.scratchpadOpen {
+		m.closeScratchpad()
+		return
+	}
+	if m.chatHandler != nil {
+		m.scratchpad = m.chatHandler.Scratchpad()
+	}
+	m.scratchpadOpen = true
+}
+
+// closeScratchpad closes the panel and saves the notes with the session
+func (m *Model) closeScratchpad() {
+	m.scratchpadOpen = false
+	m.saveScratchpad()
+}
+
+// saveScratchpad stores the notes with the session
+func (m *Model) saveScratchpad() {
+	if m.chatHandler == nil {
+		return
+	}
+	if err := m.chatHandler.SetScratchpad(m.scratchpad); err != nil {
+		m.addSystemMessage(err.Error())
+	}
+}
+
+// insertScratchpad inserts the notes into the input at the cursor
+func (m *Model) insertScratchpad() {
+	if strings.TrimSpace(m.scratchpad) == "" {
+		m.addSystemMessage("The scratchpad is empty")
+		return
+	}
+	m.insertTextAtCursor(strings.TrimRight(m.scratchpad, "\n"))
+}
+
+// handleScratchpadKeys edits the notes while the panel is open. Typing goes
+// to the notes; Ctrl+O inserts them into the input and closes the panel.
+func (m *Model) handleScratchpadKeys(msg tea.KeyMsg) {
+	switch key := msg.String(); key {
+	case "esc", "ctrl+k", "ctrl+c":
+		m.closeScratchpad()
+	case "ctrl+o":
+		m.closeScratchpad()
+		m.insertScratchpad()
+	case "enter", "ctrl+j":
+		m.scratchpad += "\n"
+	case "backspace":
+		if runes := []rune(m.scratchpad); len(runes) > 0 {
+			m.scratchpad = string(runes[:len(runes)-1])
+		}
+	case "ctrl+u":
+		// Delete the last line
+		if i := strings.LastIndex(strings.TrimSuffix(m.scratchpad, "\n"), "\n"); i >= 0 {
+			m.scratchpad = m.scratchpad[:i+1]
+		} else {
+			m.scratchpad = ""
+		}
+	default:
+		if len(msg.Runes) > 0 {
+			m.scratchpad += string(msg.Runes)
+		} else if key == "space" {
+			m.scratchpad += " "
+		}
+	}
+}
+
+// handleScratchCommand runs "/scratch [insert|clear|<text>]": without
+// arguments it toggles the panel, and text is added as a new line
+func (m *Model) handleScratchCommand(args string) {
+	switch args {
+	case "":
+		m.toggleScratchpad()
+		return
+	case "insert":
+		if m.chatHandler != nil {
+			m.scratchpad = m.chatHandler.Scratchpad()
+		}
+		m.insertScratchpad()
+		return
+	}
+
+	if m.chatHandler != nil {
+		m.scratchpad = m.chatHandler.Scratchpad()
+	}
+	if args == "clear" {
+		m.scratchpad = ""
+		m.saveScratchpad()
+		m.addSystemMessage("Scratchpad cleared")
+		return
+	}
+	if m.scratchpad != "" && !strings.HasSuffix(m.scratchpad, "\n") {
+		m.scratchpad += "\n"
+	}
+	m.scratchpad += args
+	m.saveScratchpad()
+	m.addSystemMessage(fmt.Sprintf("Added to the scratchpad (%d lines)", strings.Count(m.scratchpad, "\n")+1))
+}
+
+// scratchpadHeight is the number of screen lines the panel takes
+func (m *Model) scratchpadHeight() int {
+	if !m.scratchpadOpen {
+		return 0
+	}
+	return scratchpadLines + 3 // Title and border
+}
+
+// renderScratchpad draws the end of the notes with a cursor after them
+func (m *Model) renderScratchpad() string {
+	if !m.scratchpadOpen {
+		return ""
+	}
+
+	width := max(m.width-2, 20)
+	lines := strings.Split(m.scratchpad, "\n")
+	visible := lines[max(len(lines)-scratchpadLines, 0):]
+	for i, line := range visible {
+		visible[i] = truncateLine(line, width-3)
+	}
+	visible[len(visible)-1] += m.blockCursorStyle.Render(" ")
+	for len(visible) < scratchpadLines {
+		visible = append(visible, "")
+	}
+
+	title := "Scratchpad (not sent)"
+	if hidden := len(lines) - scratchpadLines; hidden > 0 {
+		title += fmt.Sprintf("  ↑ %d lines", hidden)
+	}
+	body := make([]string, 0, len(visible)+1)
+	body = append(body, lipgloss.NewStyle().Bold(true).Render(title))
+	body = append(body, visible...)
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("241")).
+		Width(width).
+		Render(strings.Join(body, "\n"))
+}
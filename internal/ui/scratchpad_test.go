@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScratchpadKeys(t *testing.T) {
+	m := newTabsTestModel()
+	m.currentInput = "Fix "
+	m.cursorPosition = 4
+
+	updated, _ := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlK})
+	m = updated.(Model)
+	assert.True(t, m.scratchpadOpen)
+
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("the parser")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("draft")},
+		{Type: tea.KeyCtrlU},
+		{Type: tea.KeyRunes, Runes: []rune("tests toox")},
+		{Type: tea.KeyBackspace},
+	}
+	for _, key := range keys {
+		updated, _ = m.handleKeyPress(key)
+		m = updated.(Model)
+	}
+	assert.Equal(t, "the parser\ntests too", m.scratchpad)
+	assert.Equal(t, "Fix ", m.currentInput, "typing goes to the scratchpad, not the input")
+	assert.Contains(t, m.renderScratchpad(), "tests too")
+
+	updated, _ = m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(Model)
+	assert.False(t, m.scratchpadOpen)
+	assert.Equal(t, "Fix the parser\ntests too", m.currentInput)
+	assert.Equal(t, "the parser\ntests too", m.scratchpad, "inserting keeps the notes")
+	assert.Empty(t, m.renderScratchpad())
+}
+
+func TestScratchCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		scratchpad string
+		args       string
+		want       string
+		message    string
+	}{
+		{"add to empty", "", "check the logs", "check the logs", "Added to the scratchpad (1 lines)"},
+		{"add a line", "first", "second", "first\nsecond", "Added to the scratchpad (2 lines)"},
+		{"clear", "first\nsecond", "clear", "", "Scratchpad cleared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTabsTestModel()
+			m.scratchpad = tt.scratchpad
+
+			m.handleScratchCommand(tt.args)
+			assert.Equal(t, tt.want, m.scratchpad)
+			assert.Equal(t, tt.message, m.messages[len(m.messages)-1].Content)
+		})
+	}
+
+	m := newTabsTestModel()
+	m.handleScratchCommand("")
+	assert.True(t, m.scratchpadOpen)
+	m.handleScratchCommand("")
+	assert.False(t, m.scratchpadOpen)
+
+	m.handleScratchCommand("insert")
+	assert.Equal(t, "The scratchpad is empty", m.messages[len(m.messages)-1].Content)
+	m.scratchpad = "notes\n"
+	m.handleScratchCommand("insert")
+	assert.Equal(t, "notes", m.currentInput)
+}
+
+func TestRenderScratchpadShowsTheEnd(t *testing.T) {
+	m := newTabsTestModel()
+	m.scratchpadOpen = true
+	for i := 0; i < scratchpadLines+2; i++ {
+		m.scratchpad += strings.Repeat("x", i+1) + "\n"
+	}
+	m.scratchpad += "last"
+
+	out := m.renderScratchpad()
+	assert.Contains(t, out, "↑ 3 lines")
+	assert.Contains(t, out, "last")
+	assert.NotContains(t, out, "│x ")
+	assert.Equal(t, scratchpadLines+3, strings.Count(out, "\n")+1)
+}
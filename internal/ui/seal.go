@@ -0,0 +1,46 @@
+package ui
+
+import "fmt"
+
+// handleSealCommand runs "/seal [verify]": it seals the current session so
+// it can be kept as a record, or checks that a sealed session is unchanged
+func (m *Model) handleSealCommand(args []string) {
+	if m.chatHandler == nil {
+		m.addSystemMessage("Sealing is not available without a chat handler")
+		return
+	}
+
+	if len(args) > 0 && args[0] == "verify" {
+		metadata, err := m.chatHandler.VerifySessionSeal()
+		if err != nil {
+			m.addSystemMessage(fmt.Sprintf("Seal check failed: %v", err))
+			return
+		}
+		m.addSystemMessage(fmt.Sprintf("The session is unchanged since it was sealed on %s (sha256 %s)", metadata.SealedAt.Format("2006-01-02 15:04"), metadata.Checksum))
+		return
+	}
+
+	metadata, err := m.chatHandler.SealSession()
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("Cannot seal the session: %v", err))
+		return
+	}
+	m.addSystemMessage(fmt.Sprintf("Session %s sealed with %d messages (sha256 %s). It will not change any more; sending a message continues in a fork.", shortSessionID(metadata.ID), metadata.MessageCount, metadata.Checksum))
+}
+
+// forkSealedSession moves the conversation to a fork before a message is
+// sent in a sealed session, and tells the user
+func (m *Model) forkSealedSession() bool {
+	if m.chatHandler == nil {
+		return true
+	}
+	fork, err := m.chatHandler.ForkSealedSession()
+	if err != nil {
+		m.addSystemMessage(err.Error())
+		return false
+	}
+	if fork != nil {
+		m.addSystemMessage(fmt.Sprintf("This session is sealed; continuing in the fork %s", shortSessionID(fork.ID)))
+	}
+	return true
+}
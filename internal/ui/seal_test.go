@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestSealSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	handler := chat.NewChatHandler(nil, nil, nil, chat.NewSessionManager(time.Hour, 100000), config.NewDefaultConfig(), nil)
+	defer handler.Close()
+	require.NoError(t, handler.CreateNewSession())
+
+	m := newTabsTestModel()
+	m.chatHandler = handler
+	lastMessage := func() string { return m.messages[len(m.messages)-1].Content }
+
+	m.handleSealCommand(nil)
+	assert.Equal(t, "Cannot seal the session: there is no conversation to seal", lastMessage())
+
+	require.NoError(t, handler.AddMessageToSession(ai.Message{Role: ai.RoleUser, Content: "approve the migration"}))
+	sealed := handler.GetCurrentSession()
+	m.handleSealCommand(nil)
+	assert.Contains(t, lastMessage(), "sealed with 1 messages (sha256 ")
+	assert.True(t, handler.IsSessionSealed())
+	assert.True(t, handler.IsSessionReadOnly())
+
+	m.handleSealCommand([]string{"verify"})
+	assert.Contains(t, lastMessage(), "The session is unchanged since it was sealed on ")
+
+	// Sending continues in a fork and leaves the sealed session alone
+	assert.True(t, m.forkSealedSession())
+	fork := handler.GetCurrentSession()
+	assert.NotEqual(t, sealed.ID, fork.ID)
+	assert.Len(t, fork.Messages, 1)
+	assert.Contains(t, lastMessage(), "This session is sealed; continuing in the fork ")
+	assert.False(t, handler.IsSessionSealed())
+
+	// A changed file breaks the seal
+	sessionDir, err := chat.GetProjectSessionPath()
+	require.NoError(t, err)
+	path := filepath.Join(sessionDir, "sessions", sealed.ID+".json")
+	require.NoError(t, os.Chmod(path, 0644))
+	require.NoError(t, os.WriteFile(path, []byte(`{"id":"edited"}`), 0644))
+	require.NoError(t, handler.SwitchSession(sealed.ID))
+	_, err = handler.VerifySessionSeal()
+	assert.ErrorIs(t, err, chat.ErrSealBroken)
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// viewMatch is an occurrence of the search query in the viewport content
+type viewMatch struct {
+	line       int
+	start, end int // Rune offsets in the unstyled line
+}
+
+// findMatches returns the occurrences of query in lines, ignoring case and
+// styling
+func findMatches(lines []string, query string) []viewMatch {
+	needle := []rune(strings.ToLower(query))
+	if len(needle) == 0 {
+		return nil
+	}
+
+	var matches []viewMatch
+	for i, line := range lines {
+		hay := []rune(ansi.Strip(line))
+		for start := 0; start+len(needle) <= len(hay); start++ {
+			if runesEqualFold(hay[start:start+len(needle)], needle) {
+				matches = append(matches, viewMatch{line: i, start: start, end: start + len(needle)})
+				start += len(needle) - 1
+			}
+		}
+	}
+	return matches
+}
+
+// runesEqualFold reports whether a equals the lower-case runes of b,
+// ignoring the case of a
+func runesEqualFold(a, b []rune) bool {
+	for i := range a {
+		if unicode.ToLower(a[i]) != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// highlightMatches marks the matches in lines. Lines with a match lose their
+// other styling so the highlight can be placed in the plain text.
+func highlightMatches(lines []string, matches []viewMatch, current int, match, currentMatch lipgloss.Style) {
+	for i := 0; i < len(matches); {
+		line := matches[i].line
+		plain := []rune(ansi.Strip(lines[line]))
+
+		var b strings.Builder
+		pos := 0
+		for ; i < len(matches) && matches[i].line == line; i++ {
+			style := match
+			if i == current {
+				style = currentMatch
+			}
+			b.WriteString(string(plain[pos:matches[i].start]))
+			b.WriteString(style.Render(string(plain[matches[i].start:matches[i].end])))
+			pos = matches[i].end
+		}
+		b.WriteString(string(plain[pos:]))
+		lines[line] = b.String()
+	}
+}
+
+// applySearch finds the search query in the viewport content and highlights
+// its matches
+func (m *Model) applySearch(content string) string {
+	if m.searchQuery == "" {
+		m.viewMatches = nil
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	m.viewMatches = findMatches(lines, m.searchQuery)
+	if m.currentMatch >= len(m.viewMatches) {
+		m.currentMatch = 0
+	}
+	highlightMatches(lines, m.viewMatches, m.currentMatch,
+		m.styles.Highlight, lipgloss.NewStyle().Reverse(true).Bold(true))
+	return strings.Join(lines, "\n")
+}
+
+// performSearch searches the chat history for query, highlights the matches
+// in the viewport and jumps to the first one in the search direction
+func (m *Model) performSearch(query string) {
+	m.searchResults = make([]int, 0)
+	m.currentMatch = 0
+	m.searchQuery = query
+
+	if query == "" {
+		m.renderMessages()
+		return
+	}
+
+	// Search through messages
+	for i, message := range m.messages {
+		if strings.Contains(strings.ToLower(message.Content), strings.ToLower(query)) {
+			m.searchResults = append(m.searchResults, i)
+		}
+	}
+
+	// Render every message from the earliest match in full so all matches
+	// can be jumped to
+	m.expandMessages(m.searchResults)
+	if len(m.searchResults) > 0 && m.searchResults[0] < m.hiddenMessages() {
+		m.messageWindow = len(m.messages) - m.searchResults[0]
+	}
+
+	offset := m.viewport.YOffset
+	m.renderMessages()
+	m.logger.Debug("Search completed", "query", query, "results", len(m.searchResults), "matches", len(m.viewMatches))
+	if len(m.viewMatches) == 0 {
+		return
+	}
+
+	// Start from the first match below the top of the view, or the last
+	// above it when searching backward
+	first := 0
+	if m.searchBackward {
+		first = len(m.viewMatches) - 1
+		for first > 0 && m.viewMatches[first].line >= offset {
+			first--
+		}
+	} else {
+		for first < len(m.viewMatches)-1 && m.viewMatches[first].line < offset {
+			first++
+		}
+	}
+	m.jumpToMatch(first)
+}
+
+// nextMatch jumps to the next match in the search direction, or the
+// previous one when reverse is set
+func (m *Model) nextMatch(reverse bool) {
+	if len(m.viewMatches) == 0 {
+		return
+	}
+	step := 1
+	if m.searchBackward != reverse {
+		step = -1
+	}
+	m.jumpToMatch((m.currentMatch + step + len(m.viewMatches)) % len(m.viewMatches))
+}
+
+// jumpToMatch makes the i-th match current and scrolls it into the middle
+// of the viewport
+func (m *Model) jumpToMatch(i int) {
+	m.currentMatch = i
+	m.renderMessages()
+	m.viewport.SetYOffset(m.viewMatches[i].line - m.viewport.Height/2)
+}
+
+// clearSearch removes the search highlighting from the viewport
+func (m *Model) clearSearch() {
+	if m.searchQuery == "" {
+		return
+	}
+	m.searchQuery = ""
+	m.searchResults = make([]int, 0)
+	m.currentMatch = 0
+	offset := m.viewport.YOffset
+	m.renderMessages()
+	m.viewport.SetYOffset(offset)
+}
+
+// searchStatus describes the current match for the help line
+func (m Model) searchStatus() string {
+	if len(m.viewMatches) == 0 {
+		return fmt.Sprintf("No matches for %q", m.searchQuery)
+	}
+	return fmt.Sprintf("Match %d/%d for %q", m.currentMatch+1, len(m.viewMatches), m.searchQuery)
+}
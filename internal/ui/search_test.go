@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMatches(t *testing.T) {
+	lines := []string{
+		"plain Go text",
+		lipgloss.NewStyle().Bold(true).Render("styled go") + " and GO",
+		"nothing",
+		"gogo",
+	}
+
+	assert.Equal(t, []viewMatch{
+		{line: 0, start: 6, end: 8},
+		{line: 1, start: 7, end: 9},
+		{line: 1, start: 14, end: 16},
+		{line: 3, start: 0, end: 2},
+		{line: 3, start: 2, end: 4},
+	}, findMatches(lines, "go"))
+	assert.Empty(t, findMatches(lines, ""))
+	assert.Empty(t, findMatches(lines, "rust"))
+
+	matches := findMatches(lines, "go")
+	highlightMatches(lines, matches, 1, lipgloss.NewStyle(), lipgloss.NewStyle())
+	assert.Equal(t, "styled go and GO", ansi.Strip(lines[1]), "highlighting keeps the text")
+}
+
+func TestSearchViewport(t *testing.T) {
+	m := newTabsTestModel()
+	m.viewport.Width = 80
+	m.viewport.Height = 5
+	m.keymap = DefaultKeyMap()
+	m.messages = nil
+	for i := 0; i < 40; i++ {
+		content := fmt.Sprintf("message %d", i)
+		if i%10 == 3 {
+			content += " needle"
+		}
+		m.messages = append(m.messages, Message{ID: fmt.Sprintf("m%d", i), Role: "user", Content: content})
+	}
+	m.updateViewportContent()
+	m.currentMode = ModeScroll
+	m.previousMode = ModeInsert
+	m.viewport.GotoTop()
+
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(Model)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// n and N are typed into the query
+	press(runes("/"), runes("N"), runes("eedle"))
+	assert.Equal(t, ModeSearch, m.currentMode)
+	assert.Equal(t, "/Needle", m.searchBuffer)
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, ModeScroll, m.currentMode)
+	require.Len(t, m.viewMatches, 4)
+	assert.Equal(t, []int{3, 13, 23, 33}, m.searchResults)
+	assert.Equal(t, 0, m.currentMatch)
+	assert.Contains(t, m.renderHelpLine(), `Match 1/4 for "Needle"`)
+	assert.Contains(t, m.viewport.View(), "message 3 needle")
+
+	press(runes("n"), runes("n"))
+	assert.Equal(t, 2, m.currentMatch)
+	assert.Contains(t, m.viewport.View(), "message 23 needle")
+
+	press(runes("N"), runes("N"), runes("N"))
+	assert.Equal(t, 3, m.currentMatch, "N wraps around")
+
+	// Esc clears the highlighting and returns to the input
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Equal(t, ModeInsert, m.currentMode)
+	assert.Empty(t, m.viewMatches)
+	assert.Empty(t, m.searchQuery)
+}
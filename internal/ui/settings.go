@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/common-creation/coda/internal/ai"
+)
+
+// handleSetCommand shows the request settings of this conversation, or
+// changes one with "/set <name> <value>"
+func (m *Model) handleSetCommand(args []string) {
+	if m.chatHandler == nil || m.config == nil {
+		m.addSystemMessage("Settings are not available")
+		return
+	}
+	if len(args) == 0 {
+		m.addSystemMessage(m.formatSettings())
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "reasoning", "reasoning_effort":
+		if len(args) != 2 {
+			m.addSystemMessage("Usage: /set reasoning <level|default>")
+			return
+		}
+		effort := strings.ToLower(args[1])
+		if effort == "default" {
+			effort = ""
+		}
+		if err := m.chatHandler.SetReasoningEffort(effort); err != nil {
+			m.addSystemMessage(fmt.Sprintf("Cannot set reasoning effort: %v", err))
+			return
+		}
+		switch current := m.chatHandler.ReasoningEffort(); {
+		case current == "":
+			m.addSystemMessage("Reasoning effort reset to the model default")
+		case effort == "":
+			m.addSystemMessage(fmt.Sprintf("Reasoning effort reset to the configured %s", current))
+		default:
+			m.addSystemMessage(fmt.Sprintf("Reasoning effort set to %s", current))
+		}
+	default:
+		m.addSystemMessage(fmt.Sprintf("Unknown setting %q (available: reasoning)", args[0]))
+	}
+}
+
+// formatSettings lists the current settings and the values the model accepts
+func (m *Model) formatSettings() string {
+	effort := m.chatHandler.ReasoningEffort()
+	if effort == "" {
+		effort = "default"
+	}
+	supported := "not supported by " + m.config.AI.Model
+	if efforts := ai.ReasoningEfforts(m.config.AI.Model); efforts != nil {
+		supported = strings.Join(efforts, ", ")
+	}
+	return fmt.Sprintf("Settings:\n  reasoning: %s (%s)\nChange one with /set <name> <value>.", effort, supported)
+}
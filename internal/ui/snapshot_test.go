@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/errors"
+)
+
+// The snapshots in testdata/TestViewSnapshots are the views of key states at
+// fixed sizes. After an intended change to the layout, update them with
+//
+//	go test ./internal/ui -run TestViewSnapshots -update
+//
+// and review the diff of the .golden files.
+
+// snapshotTime is the time the snapshots are taken at
+var snapshotTime = time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+// clockTime matches a time of day with seconds
+var clockTime = regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}\b`)
+
+// frozenModel runs a model without the commands it returns, so that only
+// the messages a test sends change it: no spinner ticks, timers or requests
+type frozenModel struct {
+	Model
+}
+
+func (f frozenModel) Init() tea.Cmd {
+	return nil
+}
+
+func (f frozenModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, _ := f.Model.Update(msg)
+	return frozenModel{updated.(Model)}, nil
+}
+
+// newSnapshotModel returns a model whose view depends on nothing but its state
+func newSnapshotModel(t *testing.T) Model {
+	t.Helper()
+	clock, dir := viewClock, viewDir
+	t.Cleanup(func() {
+		viewClock, viewDir = clock, dir
+	})
+	viewClock = func() time.Time { return snapshotTime }
+	viewDir = func() (string, error) { return "/home/user/project", nil }
+
+	cfg := config.NewDefaultConfig()
+	cfg.AI.Model = "gpt-4"
+	return NewModel(ModelOptions{
+		Config:       cfg,
+		Logger:       log.New(io.Discard),
+		ErrorHandler: errors.NewErrorHandler(errors.Config{LogLevel: "fatal"}),
+	})
+}
+
+// snapshotMessages is a short conversation
+func snapshotMessages() []Message {
+	return []Message{
+		{ID: "u1", Role: "user", Content: "Add a --verbose flag to the build command", Timestamp: snapshotTime, Tokens: 12},
+		{ID: "a1", Role: "assistant", Content: "I'll add the flag in `cmd/build.go` and print each step when it is set.", Timestamp: snapshotTime, Tokens: 20},
+	}
+}
+
+func TestViewSnapshots(t *testing.T) {
+	// Styles are rendered without colors so the snapshots are readable
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.Ascii)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	writeFile := ai.ToolCall{ID: "call_1", Type: "function"}
+	writeFile.Function.Name = "write_file"
+	writeFile.Function.Arguments = `{"path":"cmd/build.go","content":"package cmd\n"}`
+
+	states := []struct {
+		name  string
+		setup func(m *Model)
+		msgs  []tea.Msg
+	}{
+		{name: "welcome"},
+		{
+			name: "streaming",
+			setup: func(m *Model) {
+				m.messages = snapshotMessages()[:1]
+				m.loading = true
+				m.loadingStart = snapshotTime.Add(-1500 * time.Millisecond)
+				m.estimatedTokens = 1250
+			},
+		},
+		{
+			name: "permit dialog",
+			setup: func(m *Model) {
+				m.messages = snapshotMessages()
+				m.pendingToolCalls = []ai.ToolCall{writeFile}
+				m.permitDialogVisible = true
+				m.selectedPermitOption = permitDeny
+				m.previousMode = ModeInsert
+				m.currentMode = ModePermit
+			},
+			// Select the next option
+			msgs: []tea.Msg{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")}},
+		},
+		{
+			name: "error banner",
+			setup: func(m *Model) {
+				m.messages = snapshotMessages()
+			},
+			msgs: []tea.Msg{errorMsg{error: fmt.Errorf("open docs/PLAN.md: file not found")}},
+		},
+	}
+	sizes := []struct{ width, height int }{{80, 24}, {120, 40}}
+
+	for _, state := range states {
+		for _, size := range sizes {
+			t.Run(fmt.Sprintf("%s %dx%d", state.name, size.width, size.height), func(t *testing.T) {
+				m := newSnapshotModel(t)
+				if state.setup != nil {
+					state.setup(&m)
+				}
+
+				// The first frame follows the size of the terminal
+				tm := teatest.NewTestModel(t, frozenModel{m}, teatest.WithInitialTermSize(size.width, size.height))
+				tm.Send(readyMsg{})
+				for _, msg := range state.msgs {
+					tm.Send(msg)
+				}
+				require.NoError(t, tm.Quit())
+				final := tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(frozenModel)
+
+				// The error box shows the time it is rendered at
+				view := clockTime.ReplaceAllString(final.View(), "hh:mm:ss")
+				golden.RequireEqual(t, []byte(view))
+			})
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startupPollInterval is how often the background startup tasks are checked
+const startupPollInterval = 100 * time.Millisecond
+
+// startupTickMsg checks on the background startup tasks
+type startupTickMsg struct{}
+
+// startupTick schedules the next check while startup tasks are running
+func (m Model) startupTick() tea.Cmd {
+	if m.startup == nil {
+		return nil
+	}
+	return tea.Tick(startupPollInterval, func(time.Time) tea.Msg {
+		return startupTickMsg{}
+	})
+}
+
+// checkStartup shows what the background startup tasks reported and keeps
+// polling until they are done
+func (m *Model) checkStartup() tea.Cmd {
+	for _, notice := range m.startup.TakeNotices() {
+		m.addSystemMessage(notice)
+	}
+	if len(m.startup.Pending()) == 0 {
+		return nil
+	}
+	return m.startupTick()
+}
+
+// startupStatus describes the startup tasks still running
+func (m Model) startupStatus() string {
+	if m.startup == nil {
+		return ""
+	}
+	pending := m.startup.Pending()
+	if len(pending) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s Starting: %s", m.spinner.View(), strings.Join(pending, ", "))
+}
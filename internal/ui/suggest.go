@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// suggestionTimeout bounds a prompt suggestion request
+const suggestionTimeout = 15 * time.Second
+
+// promptSuggestionMsg carries a suggested prompt for the input it was
+// requested for
+type promptSuggestionMsg struct {
+	input      string
+	suggestion string
+	err        error
+}
+
+// requestSuggestion asks the suggestion model for a likely next prompt when
+// prompt suggestions are enabled
+func (m *Model) requestSuggestion() tea.Cmd {
+	if m.config == nil || m.chatHandler == nil {
+		return nil
+	}
+	if !m.config.UI.PromptSuggestions {
+		m.addSystemMessage("Prompt suggestions are off. Enable ui.prompt_suggestions to use them; the last messages of the conversation are then sent to the suggestion model.")
+		return nil
+	}
+
+	model := m.config.UI.SuggestionModel
+	if model == "" {
+		model = m.config.AI.Model
+	}
+	var history []ai.Message
+	if session := m.chatHandler.GetCurrentSession(); session != nil {
+		history = append(history, session.Messages...)
+	}
+	client := m.chatHandler.AIClient()
+	input := m.currentInput
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), suggestionTimeout)
+		defer cancel()
+		suggestion, err := chat.SuggestPrompt(ctx, client, model, history, input)
+		return promptSuggestionMsg{input: input, suggestion: suggestion, err: err}
+	}
+}
+
+// showSuggestion shows a suggestion unless the input changed meanwhile
+func (m *Model) showSuggestion(msg promptSuggestionMsg) {
+	if msg.err != nil {
+		m.addSystemMessage(fmt.Sprintf("No prompt suggestion: %v", msg.err))
+		return
+	}
+	if msg.input != m.currentInput || msg.suggestion == "" || msg.suggestion == msg.input {
+		return
+	}
+	m.suggestion = msg.suggestion
+	m.suggestionInput = msg.input
+}
+
+// ghostText returns the suggestion as shown after the input: the rest of
+// the prompt when it continues the input, otherwise the whole prompt that
+// Tab puts in its place
+func (m Model) ghostText() string {
+	if m.suggestion == "" || m.currentInput != m.suggestionInput || m.cursorPosition != len([]rune(m.currentInput)) {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(m.suggestion, m.currentInput); ok {
+		return rest
+	}
+	return " ⇥ " + m.suggestion
+}
+
+// acceptSuggestion replaces the input with the suggested prompt
+func (m *Model) acceptSuggestion() {
+	m.currentInput = m.suggestion
+	m.cursorPosition = len([]rune(m.currentInput))
+	m.updateCursorColumn()
+	m.suggestion = ""
+}
+
+// renderGhostText renders the ghost text shown after the cursor
+func (m Model) renderGhostText() string {
+	ghost := m.ghostText()
+	if ghost == "" {
+		return ""
+	}
+	return m.styles.Muted.Render(ghost)
+}
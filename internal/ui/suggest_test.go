@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptSuggestion(t *testing.T) {
+	m := newTabsTestModel()
+	m.currentInput = "add tests"
+	m.cursorPosition = len(m.currentInput)
+
+	// Suggestions for an input that changed meanwhile are dropped
+	m.showSuggestion(promptSuggestionMsg{input: "add", suggestion: "add a README"})
+	assert.Empty(t, m.ghostText())
+
+	m.showSuggestion(promptSuggestionMsg{input: "add tests", suggestion: "add tests for the parser"})
+	assert.Equal(t, " for the parser", m.ghostText())
+
+	updated, _ := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	assert.Equal(t, "add tests for the parser", m.currentInput)
+	assert.Equal(t, len("add tests for the parser"), m.cursorPosition)
+	assert.Empty(t, m.ghostText())
+
+	// A suggestion that does not continue the input replaces it on Tab
+	m.showSuggestion(promptSuggestionMsg{input: m.currentInput, suggestion: "run the tests"})
+	assert.Equal(t, " ⇥ run the tests", m.ghostText())
+
+	// Any other key dismisses it
+	updated, _ = m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	m = updated.(Model)
+	assert.Equal(t, "add tests for the parser!", m.currentInput)
+	assert.Empty(t, m.suggestion)
+}
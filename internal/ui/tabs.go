@@ -0,0 +1,426 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+)
+
+// conversation is the state of one tab's conversation. The active tab's
+// conversation is embedded in Model; switching tabs swaps it as a whole, so
+// no per-conversation state leaks from one tab into another.
+type conversation struct {
+	config      *config.Config
+	chatHandler *chat.ChatHandler
+
+	messages     []Message
+	currentInput string
+	loading      bool
+
+	loadingStart    time.Time
+	estimatedTokens int       // Estimated tokens for the current request
+	userInputTokens int       // Estimated tokens for just the user input
+	lastTokenUsage  *ai.Usage // Last response token usage
+	lastUsageExact  bool      // Whether lastTokenUsage was reported by the provider
+
+	// Number of most recent messages rendered into the viewport (0 uses
+	// messageWindowSize); it grows as earlier messages are scrolled to
+	messageWindow int
+
+	// IDs of long answers expanded to their full length
+	expanded map[string]bool
+
+	// Input mode state - Always INSERT mode for IME support
+	currentMode   Mode
+	previousMode  Mode
+	commandBuffer string
+	searchBuffer  string
+	searchResults []int // indices of matching messages
+
+	// Search highlighted in the viewport
+	searchQuery    string
+	searchBackward bool        // Started with '?'; n jumps to earlier matches
+	viewMatches    []viewMatch // Matches in the viewport content
+	currentMatch   int         // Index into viewMatches
+
+	// Tool call permit dialog state
+	pendingToolCalls     []ai.ToolCall       // Tool calls waiting for user approval
+	selectedPermitOption int                 // Currently selected option (permitDeny, permitAllow, permitAllowSession)
+	allowedTools         map[string]bool     // Tools allowed without asking for the rest of the session
+	permitDialogVisible  bool                // Whether permit dialog is currently visible
+	permitSeq            int                 // Sequence number of the current permit dialog
+	typingPermitReason   bool                // A reason for denying the tool calls is being typed
+	permitReason         string              // Reason sent to the model with the denial
+	permitDangers        map[string][]string // Why pending calls are dangerous, by tool call ID
+	permitPreviews       map[string]string   // Diffs shown instead of the arguments, by tool call ID
+	permitLocal          bool                // The calls come from a /code action, not the model
+	typingPermitConfirm  bool                // The escalation word is being typed to approve dangerous calls
+	permitConfirm        string              // Text typed to approve dangerous calls
+	permitConfirmOption  int                 // Option applied once dangerous calls are confirmed
+	permitDeadline       time.Time           // When an unanswered dialog applies the default decision
+
+	// Approval rule state of the session
+	ruleMatches  map[string]int          // Matches of each rule in the session
+	ruleApproved []ai.ToolCall           // Calls approved by a rule, run once the dialog is answered
+	ruleDenied   []chat.ToolResult       // Results of calls denied by a rule, sent with the answer
+	personaRules *security.ApprovalRules // Rules of the persona chosen with /persona, checked first
+
+	// Cursor position management
+	cursorPosition int // カーソル位置（rune単位）
+	cursorColumn   int // 現在の列位置（上下移動時の列位置保持用）
+
+	// Input scroll position
+	inputScrollPosition int // 入力欄のスクロール位置
+
+	// Message whose context window warning was shown; sending it again confirms
+	budgetConfirmed string
+
+	// Large paste waiting for the user to attach, insert or discard it
+	pendingPaste string
+	// Pastes attached as artifacts, referenced by placeholders in the input
+	pastes []pasteAttachment
+
+	// Problems with the input found before sending, shown under the input,
+	// and the input whose missing @-mentions sending again confirms
+	inputErrors       []string
+	mentionsConfirmed string
+
+	// Prompts sent while a turn was running, sent in order as turns finish
+	queuedPrompts []string
+
+	// The /code command waiting to be sent again to confirm saving or
+	// running a code block
+	codeActionConfirm string
+
+	// Notes of the session kept out of the conversation, edited in the
+	// scratchpad panel while it is open
+	scratchpad     string
+	scratchpadOpen bool
+
+	// Intake form of the guided task opened with /task
+	taskForm *taskForm
+
+	// Answer replaced by /regenerate, compared with the new one by /diff
+	retry *answerRetry
+
+	// Compile checks of the current turn (tools.compile_check)
+	gate compileGate
+
+	// Suggested prompt shown as ghost text until a key other than Tab is
+	// pressed, and the input it was requested for
+	suggestion      string
+	suggestionInput string
+
+	printed messageCursor // Messages already printed to the scrollback in inline mode
+	teed    messageCursor // Messages already written to the transcript
+}
+
+// sessionTab is an independent conversation in the TUI. The active tab's
+// conversation lives in Model and is stored here when switching away.
+type sessionTab struct {
+	id     int
+	forked bool // The chat handler was created for this tab and is closed with it
+
+	conversation
+	scrollOffset int
+
+	unread bool // A response arrived while the tab was in the background
+}
+
+// tabMsg carries the result of a command started in a tab
+type tabMsg struct {
+	tab int
+	msg tea.Msg
+}
+
+// tagTabCmd routes the results of cmd back to the tab that started it, so a
+// response finishing in the background does not land in the active tab
+func tagTabCmd(tab int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			cmds := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				cmds[i] = tagTabCmd(tab, c)
+			}
+			return cmds
+		case chatResponseMsg, errorMsg, toolExecutionMsg, tokenUpdateMsg, permitCountdownMsg, workflowDoneMsg:
+			return tabMsg{tab: tab, msg: msg}
+		default:
+			return msg
+		}
+	}
+}
+
+// updateTab applies a routed message to the tab that started the command
+func (m Model) updateTab(routed tabMsg) (tea.Model, tea.Cmd) {
+	idx := m.tabIndex(routed.tab)
+	if idx < 0 {
+		// The tab was closed
+		return m, nil
+	}
+	if idx == m.activeTab {
+		updated, cmd := m.update(routed.msg)
+		return updated, tagTabCmd(routed.tab, cmd)
+	}
+
+	// Swap the background tab in, apply the message and swap back
+	active := m.activeTab
+	m.storeTab()
+	m.restoreTab(idx)
+	updated, cmd := m.update(routed.msg)
+	bg := updated.(Model)
+	bg.teeNewMessages()
+	bg.storeTab()
+	switch routed.msg.(type) {
+	case chatResponseMsg, errorMsg, workflowDoneMsg:
+		bg.tabs[idx].unread = true
+	}
+	bg.restoreTab(active)
+	return bg, tagTabCmd(routed.tab, cmd)
+}
+
+// activeTabID returns the ID of the active tab
+func (m Model) activeTabID() int {
+	if m.activeTab < len(m.tabs) {
+		return m.tabs[m.activeTab].id
+	}
+	return 0
+}
+
+// tabIndex returns the position of the tab with the given ID, or -1
+func (m Model) tabIndex(id int) int {
+	for i, tab := range m.tabs {
+		if tab.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// storeTab saves the live conversation into the active tab
+func (m *Model) storeTab() {
+	tab := m.tabs[m.activeTab]
+	tab.conversation = m.conversation
+	tab.scrollOffset = m.viewport.YOffset
+}
+
+// restoreTab makes the tab at idx active and loads its conversation
+func (m *Model) restoreTab(idx int) {
+	tab := m.tabs[idx]
+	m.activeTab = idx
+	m.conversation = tab.conversation
+	m.renderMessages()
+	m.viewport.SetYOffset(tab.scrollOffset)
+}
+
+// switchTab activates the tab at idx
+func (m *Model) switchTab(idx int) {
+	if idx < 0 || idx >= len(m.tabs) || idx == m.activeTab {
+		return
+	}
+	m.storeTab()
+	m.restoreTab(idx)
+	m.tabs[idx].unread = false
+}
+
+// cycleTab activates the next (delta 1) or previous (delta -1) tab
+func (m *Model) cycleTab(delta int) {
+	if len(m.tabs) < 2 {
+		return
+	}
+	m.switchTab((m.activeTab + delta + len(m.tabs)) % len(m.tabs))
+}
+
+// openTab starts a new conversation in its own tab. modelName selects the
+// model of the tab; empty keeps the model of the active tab.
+func (m *Model) openTab(modelName string) {
+	if m.chatHandler == nil || m.config == nil {
+		m.addSystemMessage("Cannot open a tab: chat handler is not available")
+		return
+	}
+
+	cfg := m.config
+	if modelName != "" && modelName != m.config.AI.Model {
+		copied := *m.config
+		copied.AI.Model = modelName
+		cfg = &copied
+	}
+
+	m.storeTab()
+	m.nextTabID++
+	m.tabs = append(m.tabs, &sessionTab{
+		id:     m.nextTabID,
+		forked: true,
+		conversation: conversation{
+			config:      cfg,
+			chatHandler: m.chatHandler.Fork(cfg),
+			messages:    make([]Message, 0),
+			currentMode: ModeInsert,
+
+			// The forked handler keeps the persona
+			personaRules: m.personaRules,
+		},
+	})
+	m.restoreTab(len(m.tabs) - 1)
+	m.logger.Debug("Opened tab", "id", m.nextTabID, "model", cfg.AI.Model)
+}
+
+// closeTab closes the active tab. The last tab cannot be closed.
+func (m *Model) closeTab() {
+	if len(m.tabs) < 2 {
+		m.addSystemMessage("Cannot close the last tab")
+		return
+	}
+	if m.loading {
+		m.addSystemMessage("Cannot close a tab while it is waiting for a response")
+		return
+	}
+
+	m.finishJob()
+	if m.tabs[m.activeTab].forked && m.chatHandler != nil {
+		if err := m.chatHandler.Close(); err != nil {
+			m.logger.Error("Failed to close tab", "error", err)
+		}
+	}
+
+	idx := m.activeTab
+	m.tabs = append(m.tabs[:idx], m.tabs[idx+1:]...)
+	if idx >= len(m.tabs) {
+		idx = len(m.tabs) - 1
+	}
+	m.restoreTab(idx)
+	m.tabs[idx].unread = false
+}
+
+// closeTabs releases the chat handlers of tabs opened in the UI
+func (m Model) closeTabs() {
+	m.storeTab()
+	for _, tab := range m.tabs {
+		if tab.forked && tab.chatHandler != nil {
+			tab.chatHandler.Close()
+		}
+	}
+}
+
+// handleTabCommand runs "/tab [new [model]|close|next|prev|N]"
+func (m *Model) handleTabCommand(args []string) {
+	if len(args) == 0 {
+		m.listTabs()
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "new":
+		modelName := ""
+		if len(args) > 1 {
+			modelName = args[1]
+		}
+		m.openTab(modelName)
+	case "close":
+		m.closeTab()
+	case "next":
+		m.cycleTab(1)
+	case "prev":
+		m.cycleTab(-1)
+	default:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(m.tabs) {
+			m.addSystemMessage(fmt.Sprintf("Usage: /tab [new [model]|close|next|prev|1-%d]", len(m.tabs)))
+			return
+		}
+		m.switchTab(n - 1)
+	}
+}
+
+// listTabs shows the open tabs as a system message
+func (m *Model) listTabs() {
+	m.storeTab()
+
+	var b strings.Builder
+	b.WriteString("Tabs:")
+	for i, tab := range m.tabs {
+		marker := " "
+		if i == m.activeTab {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "\n%s %d. %s (%d messages)%s", marker, i+1, tabModelName(tab), len(tab.messages), tabStatus(tab))
+	}
+	b.WriteString("\nAlt+1-9 or /tab N to switch, Ctrl+T to open, Ctrl+W to close")
+	m.addSystemMessage(b.String())
+}
+
+// handleTabKeys handles the tab keybindings, reporting whether key was one
+func (m *Model) handleTabKeys(key string) bool {
+	switch key {
+	case "ctrl+t":
+		m.openTab("")
+	case "ctrl+w":
+		m.closeTab()
+	case "alt+right", "ctrl+pgdown":
+		m.cycleTab(1)
+	case "alt+left", "ctrl+pgup":
+		m.cycleTab(-1)
+	default:
+		if len(key) == 5 && strings.HasPrefix(key, "alt+") && key[4] >= '1' && key[4] <= '9' {
+			m.switchTab(int(key[4] - '1'))
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// renderTabBar renders the tab bar shown in the status line when more than
+// one tab is open
+func (m Model) renderTabBar() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+
+	activeStyle := lipgloss.NewStyle().Reverse(true).Bold(true)
+	inactiveStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	parts := make([]string, 0, len(m.tabs))
+	for i, tab := range m.tabs {
+		if i == m.activeTab {
+			parts = append(parts, activeStyle.Render(fmt.Sprintf(" %d:%s ", i+1, tabModelName(tab))))
+			continue
+		}
+		parts = append(parts, inactiveStyle.Render(fmt.Sprintf(" %d:%s%s ", i+1, tabModelName(tab), tabStatus(tab))))
+	}
+	return " " + strings.Join(parts, "")
+}
+
+// tabModelName returns the model used by a tab
+func tabModelName(tab *sessionTab) string {
+	if tab.config == nil {
+		return "?"
+	}
+	return tab.config.AI.Model
+}
+
+// tabStatus describes background activity of a tab
+func tabStatus(tab *sessionTab) string {
+	switch {
+	case tab.permitDialogVisible:
+		return " [permit]"
+	case tab.loading:
+		return " [working]"
+	case tab.unread:
+		return " [new]"
+	}
+	return ""
+}
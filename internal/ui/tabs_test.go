@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"io"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/styles"
+)
+
+func newTabsTestModel() Model {
+	return Model{
+		viewport: viewport.New(80, 20),
+		width:    80,
+		height:   24,
+		ready:    true,
+		styles:   styles.GetTheme("default").GetStyles(),
+		logger:   log.New(io.Discard),
+		conversation: conversation{
+			currentMode: ModeInsert,
+			messages:    []Message{{ID: "1", Role: "user", Content: "first tab"}},
+		},
+		tabs: []*sessionTab{
+			{id: 1},
+			{id: 2, conversation: conversation{currentMode: ModeInsert, loading: true}},
+		},
+		nextTabID: 2,
+	}
+}
+
+func TestTagTabCmd(t *testing.T) {
+	cmd := tagTabCmd(2, tea.Batch(
+		func() tea.Msg { return chatResponseMsg{Content: "done"} },
+		func() tea.Msg { return clearEscMsg{} },
+	))
+	require.NotNil(t, cmd)
+
+	batch, ok := cmd().(tea.BatchMsg)
+	require.True(t, ok)
+	require.Len(t, batch, 2)
+
+	assert.Equal(t, tabMsg{tab: 2, msg: chatResponseMsg{Content: "done"}}, batch[0]())
+	assert.Equal(t, clearEscMsg{}, batch[1]())
+	assert.Nil(t, tagTabCmd(1, nil))
+}
+
+func TestBackgroundTabResponse(t *testing.T) {
+	m := newTabsTestModel()
+
+	updated, _ := m.Update(tabMsg{tab: 2, msg: chatResponseMsg{ID: "r", Content: "background answer"}})
+	m = updated.(Model)
+
+	// The active tab is untouched
+	assert.Equal(t, 0, m.activeTab)
+	require.Len(t, m.messages, 1)
+	assert.Equal(t, "first tab", m.messages[0].Content)
+
+	// The background tab received the response
+	bg := m.tabs[1]
+	require.Len(t, bg.messages, 1)
+	assert.Equal(t, "background answer", bg.messages[0].Content)
+	assert.False(t, bg.loading)
+	assert.True(t, bg.unread)
+
+	m.switchTab(1)
+	assert.Equal(t, "background answer", m.messages[0].Content)
+	assert.False(t, m.tabs[1].unread)
+
+	m.switchTab(0)
+	assert.Equal(t, "first tab", m.messages[0].Content)
+}
+
+func TestClosedTabResultsAreDropped(t *testing.T) {
+	m := newTabsTestModel()
+
+	updated, cmd := m.Update(tabMsg{tab: 7, msg: chatResponseMsg{Content: "late"}})
+	m = updated.(Model)
+
+	assert.Nil(t, cmd)
+	assert.Len(t, m.messages, 1)
+}
+
+func TestTabKeys(t *testing.T) {
+	m := newTabsTestModel()
+
+	assert.True(t, m.handleTabKeys("alt+2"))
+	assert.Equal(t, 1, m.activeTab)
+	assert.True(t, m.handleTabKeys("alt+right"))
+	assert.Equal(t, 0, m.activeTab)
+	assert.True(t, m.handleTabKeys("alt+9"))
+	assert.Equal(t, 0, m.activeTab)
+	assert.False(t, m.handleTabKeys("a"))
+}
+
+func TestTabSwitchKeepsConversationState(t *testing.T) {
+	states := []struct {
+		name   string
+		set    func(c *conversation)
+		active func(c *conversation) bool
+	}{
+		{"scratchpad", func(c *conversation) { c.scratchpad, c.scratchpadOpen = "notes", true }, func(c *conversation) bool { return c.scratchpadOpen && c.scratchpad == "notes" }},
+		{"expanded", func(c *conversation) { c.expanded = map[string]bool{"1": true} }, func(c *conversation) bool { return c.expanded["1"] }},
+		{"search", func(c *conversation) { c.searchQuery, c.searchResults = "first", []int{0} }, func(c *conversation) bool { return c.searchQuery == "first" && len(c.searchResults) == 1 }},
+		{"suggestion", func(c *conversation) { c.suggestion, c.suggestionInput = "run the tests", "run" }, func(c *conversation) bool { return c.suggestion == "run the tests" }},
+		{"task form", func(c *conversation) { c.taskForm = &taskForm{} }, func(c *conversation) bool { return c.taskForm != nil }},
+		{"paste", func(c *conversation) { c.pendingPaste, c.pastes = "big", []pasteAttachment{{path: "a.txt"}} }, func(c *conversation) bool { return c.pendingPaste == "big" && len(c.pastes) == 1 }},
+		{"code action", func(c *conversation) { c.codeActionConfirm = "/code save 1" }, func(c *conversation) bool { return c.codeActionConfirm == "/code save 1" }},
+		{"permit reason", func(c *conversation) { c.typingPermitReason, c.permitReason = true, "no" }, func(c *conversation) bool { return c.typingPermitReason && c.permitReason == "no" }},
+		{"permit confirm", func(c *conversation) { c.typingPermitConfirm, c.permitConfirm = true, "ye" }, func(c *conversation) bool { return c.typingPermitConfirm && c.permitConfirm == "ye" }},
+	}
+
+	for _, tt := range states {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTabsTestModel()
+			tt.set(&m.conversation)
+
+			m.switchTab(1)
+			assert.False(t, tt.active(&m.conversation), "state leaked into the other tab")
+
+			m.switchTab(0)
+			assert.True(t, tt.active(&m.conversation), "state was lost when switching back")
+		})
+	}
+}
+
+func TestBackgroundTabResponseKeepsScratchpad(t *testing.T) {
+	m := newTabsTestModel()
+	m.scratchpad, m.scratchpadOpen = "active notes", true
+
+	updated, _ := m.Update(tabMsg{tab: 2, msg: chatResponseMsg{ID: "r", Content: "background answer"}})
+	m = updated.(Model)
+
+	assert.True(t, m.scratchpadOpen)
+	assert.Equal(t, "active notes", m.scratchpad)
+	assert.False(t, m.tabs[1].scratchpadOpen)
+	assert.Empty(t, m.tabs[1].scratchpad)
+}
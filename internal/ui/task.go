@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/symbols"
+	"github.com/common-creation/coda/internal/tasks"
+)
+
+// taskForm is the intake form of a guided task being filled in
+type taskForm struct {
+	template tasks.Template
+	values   []string
+	field    int
+	err      string
+}
+
+// taskPromptMsg carries the prompt assembled from a submitted task form
+type taskPromptMsg struct {
+	prompt string
+	err    error
+}
+
+// handleTaskCommand runs "/task [name]": it lists the guided tasks, or opens
+// the form of one
+func (m *Model) handleTaskCommand(args []string) {
+	if len(args) == 0 {
+		lines := []string{"Guided tasks:"}
+		for _, t := range tasks.Builtin() {
+			lines = append(lines, fmt.Sprintf("  %s: %s", t.Name, t.Description))
+		}
+		lines = append(lines, "Use /task <name> to fill in its form; the assembled prompt is put in the input for review.")
+		m.addSystemMessage(strings.Join(lines, "\n"))
+		return
+	}
+
+	template, ok := tasks.Lookup(args[0])
+	if !ok {
+		m.addSystemMessage(fmt.Sprintf("Unknown task %q (use /task to list them)", args[0]))
+		return
+	}
+	m.taskForm = &taskForm{template: template, values: make([]string, len(template.Fields))}
+}
+
+// handleTaskFormKeys edits the open task form. Enter moves to the next field
+// and submits on the last one; Ctrl+S submits from any field.
+func (m *Model) handleTaskFormKeys(msg tea.KeyMsg) tea.Cmd {
+	form := m.taskForm
+	form.err = ""
+	last := len(form.values) - 1
+
+	switch key := msg.String(); key {
+	case "esc", "ctrl+c":
+		m.taskForm = nil
+	case "tab", "down":
+		form.field = min(form.field+1, last)
+	case "shift+tab", "up":
+		form.field = max(form.field-1, 0)
+	case "enter":
+		if form.field < last {
+			form.field++
+			return nil
+		}
+		return m.submitTaskForm()
+	case "ctrl+s":
+		return m.submitTaskForm()
+	case "backspace":
+		if runes := []rune(form.values[form.field]); len(runes) > 0 {
+			form.values[form.field] = string(runes[:len(runes)-1])
+		}
+	case "ctrl+u":
+		form.values[form.field] = ""
+	default:
+		if len(msg.Runes) > 0 {
+			form.values[form.field] += string(msg.Runes)
+		} else if key == "space" {
+			form.values[form.field] += " "
+		}
+	}
+	return nil
+}
+
+// submitTaskForm closes the form and assembles its prompt in the
+// background, since locating the named symbols indexes the workspace
+func (m *Model) submitTaskForm() tea.Cmd {
+	form := m.taskForm
+	values := make(map[string]string, len(form.values))
+	for i, field := range form.template.Fields {
+		values[field.Key] = form.values[i]
+		if field.Required && strings.TrimSpace(form.values[i]) == "" {
+			form.field = i
+			form.err = fmt.Sprintf("%s is required", field.Label)
+			return nil
+		}
+	}
+	m.taskForm = nil
+
+	template := form.template
+	return func() tea.Msg {
+		idx, err := symbols.BuildIndex(".")
+		if err != nil {
+			// Named files are still listed, without symbol locations
+			idx = nil
+		}
+		prompt, err := tasks.Build(template, values, idx)
+		return taskPromptMsg{prompt: prompt, err: err}
+	}
+}
+
+// applyTaskPrompt puts an assembled prompt in the input to be reviewed and sent
+func (m *Model) applyTaskPrompt(msg taskPromptMsg) {
+	if msg.err != nil {
+		m.addSystemMessage(fmt.Sprintf("Could not build the task prompt: %v", msg.err))
+		return
+	}
+	if strings.TrimSpace(m.currentInput) != "" {
+		m.insertTextAtCursor("\n\n" + msg.prompt)
+	} else {
+		m.currentInput = ""
+		m.cursorPosition = 0
+		m.insertTextAtCursor(msg.prompt)
+	}
+	m.addSystemMessage("The task prompt is in the input; edit it if needed and press Enter to send it")
+}
+
+// taskFormHeight is the number of screen lines the form takes
+func (m *Model) taskFormHeight() int {
+	if m.taskForm == nil {
+		return 0
+	}
+	height := len(m.taskForm.values) + 3 // Title and border
+	if m.taskForm.err != "" {
+		height++
+	}
+	return height
+}
+
+// renderTaskForm draws the fields of the open task form, showing the hint
+// of the focused field while it is empty
+func (m *Model) renderTaskForm() string {
+	form := m.taskForm
+	if form == nil {
+		return ""
+	}
+
+	width := max(m.width-2, 20)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	focusStyle := lipgloss.NewStyle().Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Task: " + form.template.Description)}
+	for i, field := range form.template.Fields {
+		label := field.Label
+		if field.Required {
+			label += "*"
+		}
+		value := form.values[i]
+		if i != form.field {
+			lines = append(lines, labelStyle.Render("  "+label+": ")+truncateLine(value, width-len(label)-6))
+			continue
+		}
+		// Keep the end of a long answer visible while typing
+		if runes, room := []rune(value), max(width-len(label)-7, 1); len(runes) > room {
+			value = "…" + string(runes[len(runes)-room+1:])
+		}
+		line := focusStyle.Render("> "+label+": ") + value + m.blockCursorStyle.Render(" ")
+		if form.values[i] == "" && field.Hint != "" {
+			line += " " + hintStyle.Render(field.Hint)
+		}
+		lines = append(lines, line)
+	}
+	if form.err != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(form.err))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("241")).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskForm(t *testing.T) {
+	m := newTabsTestModel()
+	m.handleTaskCommand([]string{"tests"})
+	require.NotNil(t, m.taskForm)
+	assert.Contains(t, m.renderTaskForm(), "files, functions or types", "the hint of the empty focused field")
+
+	// The required first field must be answered
+	updated, cmd := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(Model)
+	assert.Nil(t, cmd)
+	assert.Equal(t, "Code to test is required", m.taskForm.err)
+
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("parser.go")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("empty inputx")},
+		{Type: tea.KeyBackspace},
+		{Type: tea.KeyShiftTab},
+	}
+	for _, key := range keys {
+		updated, _ = m.handleKeyPress(key)
+		m = updated.(Model)
+	}
+	assert.Equal(t, []string{"parser.go", "empty input", ""}, m.taskForm.values)
+	assert.Zero(t, m.taskForm.field)
+	assert.Empty(t, m.currentInput, "typing goes to the form, not the input")
+	assert.Empty(t, m.taskForm.err)
+
+	updated, cmd = m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(Model)
+	assert.Nil(t, m.taskForm)
+	require.NotNil(t, cmd)
+
+	msg, ok := cmd().(taskPromptMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	m.applyTaskPrompt(msg)
+	assert.Contains(t, m.currentInput, "Write tests for the following code in this workspace.\n\n## Code to test\nparser.go\n\n## Cases to cover\nempty input")
+	assert.Contains(t, m.currentInput, "## Relevant code\n- @parser.go")
+	assert.Equal(t, len([]rune(m.currentInput)), m.cursorPosition)
+}
+
+func TestTaskCommand(t *testing.T) {
+	m := newTabsTestModel()
+
+	m.handleTaskCommand(nil)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "  bug: Fix a bug\n  feature: Add a feature\n  tests: Write tests")
+
+	m.handleTaskCommand([]string{"refactor"})
+	assert.Equal(t, `Unknown task "refactor" (use /task to list them)`, m.messages[len(m.messages)-1].Content)
+	assert.Nil(t, m.taskForm)
+
+	m.handleTaskCommand([]string{"bug"})
+	updated, _ := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, updated.(Model).taskForm)
+}
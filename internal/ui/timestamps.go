@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timestampMode returns how message timestamps are shown: "absolute",
+// "relative" or "none"
+func (m Model) timestampMode() string {
+	if m.config == nil || m.config.UI.Timestamps == "" {
+		return "absolute"
+	}
+	return strings.ToLower(m.config.UI.Timestamps)
+}
+
+// messageHeader formats what is shown before a message: its timestamp, role
+// and how long it took to produce
+func (m Model) messageHeader(msg Message) string {
+	var b strings.Builder
+	switch m.timestampMode() {
+	case "none":
+	case "relative":
+		fmt.Fprintf(&b, "[%s] ", formatRelativeTime(msg.Timestamp, time.Now()))
+	default:
+		fmt.Fprintf(&b, "[%s] ", msg.Timestamp.Format("15:04"))
+	}
+	b.WriteString(msg.Role)
+	if msg.FallbackModel != "" {
+		fmt.Fprintf(&b, " via %s (fallback)", msg.FallbackModel)
+	}
+	if msg.Duration > 0 && (m.config == nil || !m.config.UI.HideDurations) {
+		fmt.Fprintf(&b, " (%s)", formatDuration(msg.Duration))
+	}
+	return b.String()
+}
+
+// formatRelativeTime formats t as the time passed until now
+func formatRelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
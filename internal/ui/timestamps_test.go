@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3*time.Hour + 10*time.Minute, "3h ago"},
+		{50 * time.Hour, "2d ago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatRelativeTime(now.Add(-tt.ago), now))
+		})
+	}
+}
+
+func TestMessageHeader(t *testing.T) {
+	stamp := time.Date(2025, 1, 2, 15, 4, 0, 0, time.Local)
+	msg := Message{Role: "assistant", Timestamp: stamp, Duration: 2500 * time.Millisecond}
+
+	m := Model{}
+	assert.Equal(t, "[15:04] assistant (2.5s)", m.messageHeader(msg))
+
+	m.config = config.NewDefaultConfig()
+	m.config.UI.Timestamps = "none"
+	assert.Equal(t, "assistant (2.5s)", m.messageHeader(msg))
+
+	m.config.UI.HideDurations = true
+	assert.Equal(t, "assistant", m.messageHeader(msg))
+
+	m.config.UI.Timestamps = "relative"
+	msg.Timestamp = time.Now().Add(-10 * time.Minute)
+	assert.Equal(t, "[10m ago] assistant", m.messageHeader(msg))
+
+	msg.FallbackModel = "gpt-4.1"
+	assert.Equal(t, "[10m ago] assistant via gpt-4.1 (fallback)", m.messageHeader(msg))
+	msg.FallbackModel = ""
+
+	// Messages without a duration have no badge
+	m.config.UI.HideDurations = false
+	msg.Duration = 0
+	assert.Equal(t, "[10m ago] assistant: hi", m.formatMessageLine(Message{Role: "assistant", Content: "hi", Timestamp: msg.Timestamp}))
+}
@@ -16,3 +16,44 @@ func EstimateTokens(messages []ai.Message, model string) (int, error) {
 func EstimateUserMessageTokens(message string, model string) (int, error) {
 	return tokenizer.EstimateUserMessageTokens(message, model)
 }
+
+// countTokens counts the tokens of text for the configured model. When the
+// tokenizer is unavailable the count is approximated and the user is warned
+// once instead of every count failing.
+func (m *Model) countTokens(text string) int {
+	if m.config == nil || m.config.AI.Model == "" {
+		return 0
+	}
+	tokens, approximate := tokenizer.Count(text, m.config.AI.Model)
+	if approximate {
+		m.warnApproximateTokens()
+	}
+	return tokens
+}
+
+// warnApproximateTokens tells the user once that token counts are
+// approximate
+func (m *Model) warnApproximateTokens() {
+	if !m.tokenizerWarned {
+		m.tokenizerWarned = true
+		reason := "the tokenizer could not encode the text"
+		if err := tokenizer.Degraded(); err != nil {
+			reason = err.Error()
+		}
+		m.logger.Warn("Token counts are approximate", "error", reason)
+		m.addSystemMessage("Token counts are approximate: " + reason)
+	}
+}
+
+// knownPromptTokens sums the counts known for the system prompt and the
+// messages of the conversation, without counting anything again
+func (m Model) knownPromptTokens() int {
+	tokens := 0
+	if m.chatHandler != nil {
+		tokens += m.textTokens(m.chatHandler.GetSystemPrompt())
+	}
+	for _, msg := range m.messages {
+		tokens += msg.Tokens
+	}
+	return tokens
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"github.com/common-creation/coda/internal/transcript"
+)
+
+// teeNewMessages writes the messages added to the active tab since the last
+// call to the transcript. Background tabs are teed while a result is applied
+// to them, so the transcript follows every conversation as it happens.
+func (m *Model) teeNewMessages() {
+	if m.transcript == nil {
+		return
+	}
+
+	added, replaced := m.teed.advance(m.messages)
+	tab := 0
+	if len(m.tabs) > 1 {
+		tab = m.activeTabID()
+	}
+
+	if replaced {
+		m.writeTranscript(transcript.Entry{Tab: tab, Role: "system", Content: "new conversation"})
+	}
+	for _, msg := range added {
+		m.writeTranscript(transcript.Entry{
+			Time:    msg.Timestamp,
+			Tab:     tab,
+			Role:    msg.Role,
+			Content: msg.Content,
+			Tokens:  msg.Tokens,
+		})
+	}
+}
+
+// writeTranscript writes one entry. A failing transcript is logged once and
+// then turned off so it cannot disrupt the session.
+func (m *Model) writeTranscript(entry transcript.Entry) {
+	if m.transcript == nil {
+		return
+	}
+	if err := m.transcript.Write(entry); err != nil {
+		if m.logger != nil {
+			m.logger.Error("Transcript disabled", "error", err)
+		}
+		m.transcript = nil
+	}
+}
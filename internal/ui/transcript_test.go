@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/transcript"
+)
+
+func TestTranscriptFollowsAllTabs(t *testing.T) {
+	var out bytes.Buffer
+	m := newTabsTestModel()
+	m.transcript = transcript.New(&out, transcript.FormatJSONL)
+
+	// A response for the background tab is teed along with the active tab
+	updated, _ := m.Update(tabMsg{tab: 2, msg: chatResponseMsg{ID: "r", Content: "background answer"}})
+	m = updated.(Model)
+
+	// Nothing new is written twice
+	updated, _ = m.Update(clearEscMsg{})
+	m = updated.(Model)
+
+	var entries []transcript.Entry
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var entry transcript.Entry
+		require.NoError(t, json.Unmarshal(line, &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2, entries[0].Tab)
+	assert.Equal(t, "background answer", entries[0].Content)
+	assert.Equal(t, 1, entries[1].Tab)
+	assert.Equal(t, "first tab", entries[1].Content)
+}
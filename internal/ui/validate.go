@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fileMention matches @-mentions that look like paths: "@main.go",
+// "@internal/ui/" (an @ after a word character is an email address)
+var fileMention = regexp.MustCompile(`(?:^|[^\w@])@([\w.\-~/]*[./][\w.\-~/]*)`)
+
+// slashCommandNames returns the commands listed in slashCommandHelp
+func slashCommandNames() []string {
+	names := []string{"/tabs"}
+	for _, help := range slashCommandHelp {
+		name, _, _ := strings.Cut(help, " ")
+		name, _, _ = strings.Cut(name, ":")
+		names = append(names, name)
+	}
+	return names
+}
+
+// validateCommand returns why a slash command cannot run, or "" when it is
+// a known command
+func validateCommand(input string) string {
+	command := strings.ToLower(strings.Fields(input)[0])
+	for _, name := range slashCommandNames() {
+		if command == name {
+			return ""
+		}
+	}
+	problem := fmt.Sprintf("Unknown command %s", command)
+	if suggestion := closestCommand(command); suggestion != "" {
+		problem += fmt.Sprintf(" (did you mean %s?)", suggestion)
+	}
+	return problem
+}
+
+// validateMentions reports the files mentioned with @ that are not found
+// under root
+func validateMentions(input, root string) []string {
+	var problems []string
+	for _, match := range fileMention.FindAllStringSubmatch(input, -1) {
+		path := strings.TrimRight(match[1], ".")
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+			problems = append(problems, fmt.Sprintf("@%s: no such file or directory", path))
+		}
+	}
+	return problems
+}
+
+// checkInput validates the input before it is sent and records the
+// problems to show under the input. Unknown commands always stop; missing
+// @-mentions stop until the same input is sent again.
+func (m *Model) checkInput(input string) bool {
+	m.inputErrors = nil
+	if strings.HasPrefix(input, "/") {
+		if problem := validateCommand(input); problem != "" {
+			m.inputErrors = []string{problem}
+			return false
+		}
+		return true
+	}
+
+	if input == m.mentionsConfirmed {
+		return true
+	}
+	workspace := "."
+	if m.config != nil && m.config.Tools.WorkspaceRoot != "" {
+		workspace = m.config.Tools.WorkspaceRoot
+	}
+	if problems := validateMentions(input, workspace); len(problems) > 0 {
+		m.inputErrors = problems
+		m.mentionsConfirmed = input
+		return false
+	}
+	return true
+}
+
+// closestCommand returns the known command closest to command, if it is
+// likely a typo of it
+func closestCommand(command string) string {
+	best, bestDistance := "", 3
+	for _, name := range slashCommandNames() {
+		if d := editDistance(command, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// renderInputErrors renders the problems found by checkInput under the
+// input box
+func (m Model) renderInputErrors() string {
+	if len(m.inputErrors) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(m.inputErrors)+1)
+	for _, problem := range m.inputErrors {
+		lines = append(lines, m.styles.StatusError.Render("  ✗ "+problem))
+	}
+	if m.mentionsConfirmed != "" && m.mentionsConfirmed == strings.TrimSpace(m.currentInput) {
+		lines = append(lines, m.styles.Muted.Render("    Press Enter again to send anyway"))
+	}
+	return strings.Join(lines, "\n")
+}
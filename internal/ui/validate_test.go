@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/config"
+)
+
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/fork 3", ""},
+		{"/TABS", ""},
+		{"/models refresh", ""},
+		{"/forkk", "Unknown command /forkk (did you mean /fork?)"},
+		{"/deploy now", "Unknown command /deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, validateCommand(tt.input))
+		})
+	}
+}
+
+func TestValidateMentions(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "internal", "ui"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), nil, 0644))
+
+	assert.Empty(t, validateMentions("look at @main.go and @internal/ui/.", root))
+	assert.Empty(t, validateMentions("mail me at dev@example.com, thanks @alice", root))
+	assert.Equal(t, []string{"@cmd/root.go: no such file or directory"},
+		validateMentions("why does @cmd/root.go fail?", root))
+}
+
+func TestSendChecksInput(t *testing.T) {
+	m := newTabsTestModel()
+	m.config = config.NewDefaultConfig()
+	m.config.Tools.WorkspaceRoot = t.TempDir()
+
+	m.currentInput = "/stast"
+	updated, _ := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	m = *updated.(*Model)
+	assert.Equal(t, []string{"Unknown command /stast (did you mean /stats?)"}, m.inputErrors)
+	assert.Equal(t, "/stast", m.currentInput, "the input is kept for fixing")
+
+	// Editing the input clears the problems
+	updated, _ = m.handleKeyPress(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(Model)
+	assert.Empty(t, m.inputErrors)
+
+	// Missing mentions are sent when Enter is pressed again
+	m.currentInput = "explain @missing.go"
+	assert.False(t, m.checkInput(m.currentInput))
+	assert.Contains(t, m.renderInputErrors(), "Press Enter again to send anyway")
+	assert.True(t, m.checkInput(m.currentInput))
+}
@@ -111,16 +111,18 @@ func TestUpdateViewportContent(t *testing.T) {
 	// Create a model
 	model := Model{
 		viewport: viewport.New(80, 20),
-		messages: []Message{
-			{
-				Content:   "Hello",
-				Role:      "user",
-				Timestamp: time.Now(),
-			},
-			{
-				Content:   "Hi there!",
-				Role:      "assistant",
-				Timestamp: time.Now(),
+		conversation: conversation{
+			messages: []Message{
+				{
+					Content:   "Hello",
+					Role:      "user",
+					Timestamp: time.Now(),
+				},
+				{
+					Content:   "Hi there!",
+					Role:      "assistant",
+					Timestamp: time.Now(),
+				},
 			},
 		},
 	}
@@ -0,0 +1,195 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/common-creation/coda/internal/review"
+	"github.com/common-creation/coda/internal/styles"
+)
+
+// ReviewView displays review findings grouped by file
+type ReviewView struct {
+	result     *review.Result
+	styles     styles.Styles
+	exportPath string
+
+	// Flattened findings in display order
+	entries  []review.Finding
+	cursor   int
+	expanded map[int]bool
+
+	width  int
+	height int
+	status string
+}
+
+// NewReviewView creates a review view. exportPath is where 'e' writes the markdown report.
+func NewReviewView(result *review.Result, styles styles.Styles, exportPath string) *ReviewView {
+	rv := &ReviewView{
+		result:     result,
+		styles:     styles,
+		exportPath: exportPath,
+		expanded:   make(map[int]bool),
+		width:      80,
+		height:     24,
+	}
+	for _, group := range result.ByFile() {
+		rv.entries = append(rv.entries, group.Findings...)
+	}
+	return rv
+}
+
+// Init implements tea.Model
+func (rv *ReviewView) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (rv *ReviewView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		rv.width = msg.Width
+		rv.height = msg.Height
+
+	case tea.KeyMsg:
+		rv.status = ""
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return rv, tea.Quit
+		case "up", "k":
+			if rv.cursor > 0 {
+				rv.cursor--
+			}
+		case "down", "j":
+			if rv.cursor < len(rv.entries)-1 {
+				rv.cursor++
+			}
+		case "home", "g":
+			rv.cursor = 0
+		case "end", "G":
+			rv.cursor = max(len(rv.entries)-1, 0)
+		case "enter", " ":
+			rv.expanded[rv.cursor] = !rv.expanded[rv.cursor]
+		case "a":
+			expand := len(rv.expanded) < len(rv.entries)
+			for i := range rv.entries {
+				rv.expanded[i] = expand
+			}
+			if !expand {
+				rv.expanded = make(map[int]bool)
+			}
+		case "e":
+			rv.export()
+		}
+	}
+
+	return rv, nil
+}
+
+// export writes the markdown report to the export path
+func (rv *ReviewView) export() {
+	if err := os.WriteFile(rv.exportPath, []byte(rv.result.Markdown()), 0644); err != nil {
+		rv.status = fmt.Sprintf("Export failed: %v", err)
+		return
+	}
+	rv.status = fmt.Sprintf("Exported to %s", rv.exportPath)
+}
+
+// View implements tea.Model
+func (rv *ReviewView) View() string {
+	header := rv.styles.Header.Render(rv.title())
+	footer := rv.styles.Muted.Render("↑/↓ move • enter expand • a expand all • e export markdown • q quit")
+	if rv.status != "" {
+		footer = rv.styles.StatusActive.Render(rv.status) + "  " + footer
+	}
+
+	var lines []string
+	cursorLine := 0
+	if len(rv.entries) == 0 {
+		lines = append(lines, rv.styles.Muted.Render("No findings."))
+	}
+
+	currentFile := ""
+	for i, f := range rv.entries {
+		if f.File != currentFile {
+			currentFile = f.File
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, rv.styles.Bold.Render(f.File))
+		}
+
+		if i == rv.cursor {
+			cursorLine = len(lines)
+		}
+		lines = append(lines, rv.renderFinding(i, f)...)
+	}
+
+	// Keep the selected finding on screen
+	available := max(rv.height-4, 1)
+	offset := 0
+	if cursorLine >= available {
+		offset = cursorLine - available/2
+	}
+	end := min(offset+available, len(lines))
+	body := strings.Join(lines[offset:end], "\n")
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, "", body, "", footer)
+}
+
+func (rv *ReviewView) title() string {
+	counts := rv.result.CountBySeverity()
+	return fmt.Sprintf("Review: %d file(s), %d critical, %d warning, %d suggestion, %d nit",
+		len(rv.result.Files), counts[review.SeverityCritical], counts[review.SeverityWarning],
+		counts[review.SeveritySuggest], counts[review.SeverityNit])
+}
+
+func (rv *ReviewView) renderFinding(index int, f review.Finding) []string {
+	marker := "  "
+	if index == rv.cursor {
+		marker = "> "
+	}
+
+	location := "     "
+	if f.Line > 0 {
+		location = fmt.Sprintf("%5d", f.Line)
+	}
+
+	line := fmt.Sprintf("%s%s %s %s", marker, location, rv.severityLabel(f.Severity), f.Title)
+	if index == rv.cursor {
+		line = rv.styles.Highlight.Render(line)
+	}
+
+	lines := []string{line}
+	if rv.expanded[index] {
+		width := max(rv.width-10, 20)
+		wrap := lipgloss.NewStyle().Width(width).PaddingLeft(8)
+		if f.Detail != "" {
+			lines = append(lines, wrap.Render(f.Detail))
+		}
+		if f.Suggestion != "" {
+			lines = append(lines, wrap.Render("Suggestion: "+f.Suggestion))
+		}
+	}
+	return lines
+}
+
+func (rv *ReviewView) severityLabel(severity string) string {
+	style := lipgloss.NewStyle().Bold(true)
+	switch severity {
+	case review.SeverityCritical:
+		style = style.Foreground(rv.styles.Colors.Error)
+	case review.SeverityWarning:
+		style = style.Foreground(rv.styles.Colors.Warning)
+	case review.SeveritySuggest:
+		style = style.Foreground(rv.styles.Colors.Info)
+	default:
+		style = style.Foreground(rv.styles.Colors.Muted)
+	}
+	return style.Render(fmt.Sprintf("%-10s", severity))
+}
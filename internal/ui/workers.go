@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/common-creation/coda/internal/tokenizer"
+	"github.com/common-creation/coda/internal/ui/components"
+)
+
+const (
+	// largeMessageSize is the size from which an answer's markdown is
+	// rendered in the background; smaller answers render within a frame
+	largeMessageSize = 8 * 1024
+
+	// maxWorkers bounds the background jobs running at once
+	maxWorkers = 4
+
+	// maxCachedCounts bounds the token counts kept for texts
+	maxCachedCounts = 32
+)
+
+// workerPool runs the slow work of the UI off the Update path: counting
+// tokens and rendering large answers with their syntax highlighting. Jobs
+// are queued while Update runs and started as commands when it returns;
+// their results come back as messages, so keystrokes are never held up by
+// them. The pool is shared by the copies of the model and, apart from the
+// jobs themselves, only used from Update and View.
+type workerPool struct {
+	slots    chan struct{}
+	queue    []tea.Cmd
+	inFlight map[string]bool
+
+	// counts are the tokens of texts counted in the background
+	counts map[string]int
+
+	// markdown renders for the jobs: renderers keep state while rendering,
+	// so the jobs do not share the one the model renders with
+	renderMu sync.Mutex
+	markdown components.ContentRenderer
+	width    int
+}
+
+// workerDoneMsg carries the result of a background job
+type workerDoneMsg struct {
+	key    string
+	result tea.Msg
+}
+
+// tokenCountMsg is the count of a text counted in the background
+type tokenCountMsg struct {
+	text        string
+	tokens      int
+	approximate bool
+}
+
+// messageTokensMsg is the count of a message counted in the background
+type messageTokensMsg struct {
+	id          string
+	tokens      int
+	approximate bool
+}
+
+// renderedMsg is an answer rendered in the background
+type renderedMsg struct {
+	msg    Message
+	width  int
+	header string
+	block  string
+}
+
+// newWorkerPool creates a pool rendering with markdown, which may be nil
+// when markdown rendering is disabled
+func newWorkerPool(markdown components.ContentRenderer) *workerPool {
+	return &workerPool{
+		slots:    make(chan struct{}, maxWorkers),
+		inFlight: make(map[string]bool),
+		counts:   make(map[string]int),
+		markdown: markdown,
+	}
+}
+
+// submit queues a job unless one with the same key is queued or running
+func (p *workerPool) submit(key string, job func() tea.Msg) {
+	if p.inFlight[key] {
+		return
+	}
+	p.inFlight[key] = true
+	p.queue = append(p.queue, func() tea.Msg {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+		return workerDoneMsg{key: key, result: job()}
+	})
+}
+
+// start returns the queued jobs as a command
+func (p *workerPool) start() tea.Cmd {
+	if len(p.queue) == 0 {
+		return nil
+	}
+	jobs := p.queue
+	p.queue = nil
+	return tea.Batch(jobs...)
+}
+
+// finish forgets a job once its result arrived
+func (p *workerPool) finish(key string) {
+	delete(p.inFlight, key)
+}
+
+// setWidth sets the width answers are rendered at from now on
+func (p *workerPool) setWidth(width int) {
+	p.width = width
+}
+
+// hashKey returns a short key for a possibly long text
+func hashKey(kind string, texts ...string) string {
+	h := fnv.New64a()
+	for _, text := range texts {
+		h.Write([]byte(text))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s:%x", kind, h.Sum64())
+}
+
+// textTokens returns the tokens of text when they were counted, or an
+// approximation while they are counted in the background
+func (m Model) textTokens(text string) int {
+	if m.workers == nil {
+		tokens, _ := tokenizer.Count(text, m.config.AI.Model)
+		return tokens
+	}
+	if tokens, ok := m.workers.counts[text]; ok {
+		return tokens
+	}
+	return tokenizer.ApproximateTokens(text)
+}
+
+// countTextTokens counts text in the background unless it was counted
+func (m *Model) countTextTokens(text string) {
+	if m.workers == nil || m.config == nil || m.config.AI.Model == "" || text == "" {
+		return
+	}
+	if _, ok := m.workers.counts[text]; ok {
+		return
+	}
+	model := m.config.AI.Model
+	m.workers.submit(hashKey("tokens", text), func() tea.Msg {
+		tokens, approximate := countInBackground(text, model)
+		return tokenCountMsg{text: text, tokens: tokens, approximate: approximate}
+	})
+}
+
+// messageTokens returns the tokens of a new message's content. With a
+// worker pool the count is approximated and the message updated when the
+// background count arrives.
+func (m *Model) messageTokens(id, content string) int {
+	if m.workers == nil || m.config == nil || m.config.AI.Model == "" {
+		return m.countTokens(content)
+	}
+	if content == "" {
+		return 0
+	}
+	model := m.config.AI.Model
+	m.workers.submit(hashKey("message", id), func() tea.Msg {
+		tokens, approximate := countInBackground(content, model)
+		return messageTokensMsg{id: id, tokens: tokens, approximate: approximate}
+	})
+	return tokenizer.ApproximateTokens(content)
+}
+
+// countInBackground counts text for model, waiting for an encoding that is
+// still loading rather than approximating as counts on the Update path do
+func countInBackground(text, model string) (tokens int, approximate bool) {
+	tokens, err := tokenizer.EstimateUserMessageTokens(text, model)
+	if err != nil {
+		return tokenizer.ApproximateTokens(text), true
+	}
+	return tokens, false
+}
+
+// renderLater reports whether msg is rendered in the background, queuing
+// the job when it is not rendered yet
+func (m *Model) renderLater(msg Message, header string) bool {
+	if m.workers == nil || m.workers.markdown == nil || m.markdown == nil ||
+		msg.Role != "assistant" || len(msg.Content) < largeMessageSize {
+		return false
+	}
+	width := m.viewport.Width
+	if m.rendered.fresh(msg, width, header) {
+		return false
+	}
+
+	pool := m.workers
+	markdownWidth := pool.width
+	pool.submit(hashKey("render", msg.ID, msg.Content, header, fmt.Sprint(width)), func() tea.Msg {
+		pool.renderMu.Lock()
+		defer pool.renderMu.Unlock()
+		if markdownWidth > 0 {
+			pool.markdown.SetMaxWidth(markdownWidth)
+		}
+		block := fmt.Sprintf("%s:\n%s", header, pool.markdown.Render(msg.Content))
+		return renderedMsg{msg: msg, width: width, header: header, block: block}
+	})
+	return true
+}
+
+// applyWorkerResult applies the result of a background job
+func (m *Model) applyWorkerResult(done workerDoneMsg) {
+	m.workers.finish(done.key)
+
+	switch result := done.result.(type) {
+	case tokenCountMsg:
+		if len(m.workers.counts) >= maxCachedCounts {
+			m.workers.counts = make(map[string]int)
+		}
+		m.workers.counts[result.text] = result.tokens
+		if result.approximate {
+			m.warnApproximateTokens()
+		}
+
+	case messageTokensMsg:
+		m.setMessageTokens(result.id, result.tokens)
+		if result.approximate {
+			m.warnApproximateTokens()
+		}
+
+	case renderedMsg:
+		if m.rendered == nil {
+			return
+		}
+		m.rendered.put(result.msg, result.width, result.header, result.block)
+		atBottom := m.viewport.AtBottom()
+		m.renderMessages()
+		if atBottom {
+			m.viewport.GotoBottom()
+		}
+	}
+}
+
+// setMessageTokens updates the count of a message in whichever tab it is.
+// The request being sent is estimated again when its prompt is recounted.
+func (m *Model) setMessageTokens(id string, tokens int) {
+	for i := range m.messages {
+		if m.messages[i].ID == id {
+			if m.loading && i == len(m.messages)-1 && m.messages[i].Role == "user" {
+				m.estimatedTokens += tokens - m.messages[i].Tokens
+			}
+			m.messages[i].Tokens = tokens
+			return
+		}
+	}
+	for t := range m.tabs {
+		for i := range m.tabs[t].messages {
+			if m.tabs[t].messages[i].ID == id {
+				m.tabs[t].messages[i].Tokens = tokens
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/tokenizer"
+)
+
+// newWorkersTestModel returns a model rendering markdown with a worker pool
+func newWorkersTestModel() Model {
+	m := newTabsTestModel()
+	m.config = config.NewDefaultConfig()
+	m.config.AI.Model = "gpt-4"
+	m.markdown = newContentRenderer(m.config, "default", m.styles)
+	m.workers = newWorkerPool(newContentRenderer(m.config, "default", m.styles))
+	return m
+}
+
+// runJobs runs the queued jobs and returns their results
+func runJobs(t *testing.T, m Model) []workerDoneMsg {
+	t.Helper()
+	cmd := m.workers.start()
+	require.NotNil(t, cmd, "jobs are queued")
+
+	var done []workerDoneMsg
+	var collect func(msg tea.Msg)
+	collect = func(msg tea.Msg) {
+		switch msg := msg.(type) {
+		case tea.BatchMsg:
+			for _, cmd := range msg {
+				collect(cmd())
+			}
+		case workerDoneMsg:
+			done = append(done, msg)
+		}
+	}
+	collect(cmd())
+	return done
+}
+
+func TestWorkerPoolDeduplicatesJobs(t *testing.T) {
+	pool := newWorkerPool(nil)
+	assert.Nil(t, pool.start(), "nothing to start")
+
+	runs := 0
+	job := func() tea.Msg {
+		runs++
+		return nil
+	}
+	pool.submit("a", job)
+	pool.submit("a", job)
+	cmd := pool.start()
+	require.NotNil(t, cmd)
+	cmd()
+	assert.Equal(t, 1, runs, "a job already queued is not queued again")
+
+	pool.submit("a", job)
+	assert.Nil(t, pool.start(), "a job still running is not queued again")
+
+	pool.finish("a")
+	pool.submit("a", job)
+	assert.NotNil(t, pool.start())
+}
+
+func TestMessageTokensCountedInBackground(t *testing.T) {
+	m := newWorkersTestModel()
+	content := "Please explain what this function does and how to test it."
+
+	approximate := m.messageTokens("u1", content)
+	assert.Equal(t, tokenizer.ApproximateTokens(content), approximate)
+	m.messages = append(m.messages, Message{ID: "u1", Role: "user", Content: content, Tokens: approximate})
+	m.loading = true
+	m.estimatedTokens = 1000 + approximate
+
+	done := runJobs(t, m)
+	require.Len(t, done, 1)
+	m.applyWorkerResult(done[0])
+
+	exact, _ := tokenizer.Count(content, "gpt-4")
+	assert.Equal(t, exact, m.messages[len(m.messages)-1].Tokens)
+	assert.Equal(t, 1000+exact, m.estimatedTokens, "the request's estimate follows the exact count")
+	assert.Empty(t, m.workers.inFlight)
+}
+
+func TestSystemPromptTokensCountedInBackground(t *testing.T) {
+	m := newWorkersTestModel()
+	prompt := strings.Repeat("You are a helpful coding assistant. ", 20)
+
+	assert.Equal(t, tokenizer.ApproximateTokens(prompt), m.textTokens(prompt), "approximated until counted")
+	m.countTextTokens(prompt)
+	for _, done := range runJobs(t, m) {
+		m.applyWorkerResult(done)
+	}
+
+	exact, _ := tokenizer.Count(prompt, "gpt-4")
+	assert.Equal(t, exact, m.textTokens(prompt))
+	m.countTextTokens(prompt)
+	assert.Nil(t, m.workers.start(), "a counted text is not counted again")
+}
+
+func TestLargeAnswersRenderedInBackground(t *testing.T) {
+	m := newWorkersTestModel()
+	answer := "# Result\n\n" + strings.Repeat("Some **bold** words in a long answer.\n\n", largeMessageSize/30)
+	m.messages = append(m.messages,
+		Message{ID: "small", Role: "assistant", Content: "A **short** answer"},
+		Message{ID: "large", Role: "assistant", Content: answer},
+	)
+	m.updateViewportContent()
+
+	assert.True(t, m.rendered.fresh(m.messages[1], m.viewport.Width, m.messageHeader(m.messages[1])), "small answers render right away")
+	assert.NotContains(t, m.rendered.entries, "large", "large answers are shown as they are meanwhile")
+
+	done := runJobs(t, m)
+	require.Len(t, done, 1)
+	m.applyWorkerResult(done[0])
+
+	large := m.messages[2]
+	require.True(t, m.rendered.fresh(large, m.viewport.Width, m.messageHeader(large)))
+	assert.NotContains(t, m.rendered.entries["large"].block, "**bold**", "the markdown is rendered")
+
+	m.updateViewportContent()
+	assert.Nil(t, m.workers.start(), "a rendered answer is not rendered again")
+}
@@ -0,0 +1,146 @@
+package updater
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// BinaryName returns the executable name inside release archives
+func BinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "coda.exe"
+	}
+	return "coda"
+}
+
+// ExtractBinary returns the named executable from a .tar.gz or .zip archive
+func ExtractBinary(archiveName string, data []byte, binary string) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(archiveName, ".tar.gz"):
+		return extractFromTarGz(data, binary)
+	case strings.HasSuffix(archiveName, ".zip"):
+		return extractFromZip(data, binary)
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", archiveName)
+	}
+}
+
+func extractFromTarGz(data []byte, binary string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || path.Base(header.Name) != binary {
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", binary, err)
+		}
+		return content, nil
+	}
+
+	return nil, fmt.Errorf("%s not found in archive", binary)
+}
+
+func extractFromZip(data []byte, binary string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || path.Base(file.Name) != binary {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", binary, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxDownloadSize))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", binary, err)
+		}
+		return content, nil
+	}
+
+	return nil, fmt.Errorf("%s not found in archive", binary)
+}
+
+// ReplaceExecutable atomically replaces the binary at target with content.
+// The new binary is written next to the target and renamed over it, so an
+// interrupted update never leaves a partially written executable behind.
+func ReplaceExecutable(target string, content []byte) error {
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return fmt.Errorf("failed to stat executable: %w", err)
+	}
+
+	dir := filepath.Dir(resolved)
+	tmp, err := os.CreateTemp(dir, ".coda-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file (is %s writable?): %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0111); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+
+	// Windows cannot rename over a running executable, so move it aside first
+	if runtime.GOOS == "windows" {
+		old := resolved + ".old"
+		os.Remove(old)
+		if err := os.Rename(resolved, old); err != nil {
+			return fmt.Errorf("failed to move current binary aside: %w", err)
+		}
+		if err := os.Rename(tmpPath, resolved); err != nil {
+			os.Rename(old, resolved)
+			return fmt.Errorf("failed to install new binary: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(tmpPath, resolved); err != nil {
+		return fmt.Errorf("failed to install new binary: %w", err)
+	}
+	return nil
+}
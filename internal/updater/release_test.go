@@ -0,0 +1,62 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// releaseWorkflow is the part of .github/workflows/release.yml the updater
+// depends on
+type releaseWorkflow struct {
+	Jobs map[string]struct {
+		Steps []struct {
+			Name string            `yaml:"name"`
+			Run  string            `yaml:"run"`
+			With map[string]string `yaml:"with"`
+		} `yaml:"steps"`
+	} `yaml:"jobs"`
+}
+
+// buildLine matches the "build GOOS GOARCH ARCHIVE" calls of the workflow
+var buildLine = regexp.MustCompile(`(?m)^\s*build (\w+) (\w+) (\S+)\s*$`)
+
+func TestReleaseWorkflowAssetNames(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", ".github", "workflows", "release.yml"))
+	require.NoError(t, err)
+	var wf releaseWorkflow
+	require.NoError(t, yaml.Unmarshal(data, &wf))
+
+	var archives [][]string
+	var draft, published bool
+	for _, job := range wf.Jobs {
+		for _, step := range job.Steps {
+			archives = append(archives, buildLine.FindAllStringSubmatch(step.Run, -1)...)
+			if step.With["tag_name"] != "" {
+				draft = step.With["draft"] == "true"
+				assert.Equal(t, "false", step.With["prerelease"], "coda update skips prereleases")
+			}
+			if strings.Contains(step.Run, `"draft": false`) {
+				published = true
+			}
+		}
+	}
+
+	require.NotEmpty(t, archives, "release workflow builds no archives")
+	platforms := make(map[string]bool)
+	for _, archive := range archives {
+		goos, goarch, name := archive[1], archive[2], archive[3]
+		assert.Equal(t, AssetName(goos, goarch), name, "archive for %s/%s", goos, goarch)
+		platforms[goos+"/"+goarch] = true
+	}
+	for _, platform := range []string{"linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64", "windows/amd64"} {
+		assert.True(t, platforms[platform], "no release archive for %s", platform)
+	}
+	assert.True(t, !draft || published, "the release is left as a draft, which coda update never sees")
+}
@@ -0,0 +1,275 @@
+// Package updater implements self-update support for the coda binary using
+// GitHub releases published by goreleaser.
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRepository is the GitHub repository releases are fetched from
+	DefaultRepository = "common-creation/coda"
+
+	// DefaultAPIBase is the GitHub REST API endpoint
+	DefaultAPIBase = "https://api.github.com"
+
+	// ChecksumsAsset is the checksum file name produced by goreleaser
+	ChecksumsAsset = "checksums.txt"
+
+	// SignatureAsset is the detached ed25519 signature of ChecksumsAsset
+	SignatureAsset = "checksums.txt.sig"
+
+	// maxDownloadSize bounds asset downloads
+	maxDownloadSize = 200 * 1024 * 1024 // 200MB
+)
+
+// Asset is a downloadable file attached to a release
+type Asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+}
+
+// Release is a GitHub release
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Version returns the release version without the leading "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// FindAsset returns the asset with the given name
+func (r *Release) FindAsset(name string) (*Asset, bool) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], true
+		}
+	}
+	return nil, false
+}
+
+// Updater talks to the GitHub releases API
+type Updater struct {
+	client     *http.Client
+	apiBase    string
+	repository string
+}
+
+// Option configures an Updater
+type Option func(*Updater)
+
+// WithHTTPClient sets the HTTP client used for API calls and downloads
+func WithHTTPClient(client *http.Client) Option {
+	return func(u *Updater) {
+		u.client = client
+	}
+}
+
+// WithAPIBase overrides the GitHub API endpoint
+func WithAPIBase(base string) Option {
+	return func(u *Updater) {
+		u.apiBase = strings.TrimRight(base, "/")
+	}
+}
+
+// WithRepository overrides the "owner/name" repository
+func WithRepository(repository string) Option {
+	return func(u *Updater) {
+		u.repository = repository
+	}
+}
+
+// New creates an Updater
+func New(opts ...Option) *Updater {
+	u := &Updater{
+		client:     &http.Client{Timeout: 60 * time.Second},
+		apiBase:    DefaultAPIBase,
+		repository: DefaultRepository,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// LatestRelease returns the latest published, non-prerelease release
+func (u *Updater) LatestRelease(ctx context.Context) (*Release, error) {
+	var release Release
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", u.apiBase, u.repository)
+	if err := u.getJSON(ctx, url, &release); err != nil {
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// ReleasesSince returns the published releases newer than current, up to and
+// including latest, ordered newest first. Used to build the changelog diff.
+func (u *Updater) ReleasesSince(ctx context.Context, current string) ([]Release, error) {
+	var releases []Release
+	url := fmt.Sprintf("%s/repos/%s/releases?per_page=50", u.apiBase, u.repository)
+	if err := u.getJSON(ctx, url, &releases); err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+
+	var newer []Release
+	for _, r := range releases {
+		if r.Draft || r.Prerelease {
+			continue
+		}
+		if CompareVersions(r.Version(), current) > 0 {
+			newer = append(newer, r)
+		}
+	}
+
+	sort.Slice(newer, func(i, j int) bool {
+		return CompareVersions(newer[i].Version(), newer[j].Version()) > 0
+	})
+	return newer, nil
+}
+
+// Download fetches an asset into memory
+func (u *Updater) Download(ctx context.Context, asset *Asset) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.BrowserDownloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: HTTP %d", asset.Name, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", asset.Name, err)
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("asset %s exceeds maximum download size", asset.Name)
+	}
+
+	return data, nil
+}
+
+func (u *Updater) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub API returned HTTP %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// AssetName returns the archive name goreleaser produces for a platform,
+// e.g. coda_Linux_x86_64.tar.gz or coda_Windows_x86_64.zip
+func AssetName(goos, goarch string) string {
+	osName := strings.ToUpper(goos[:1]) + goos[1:]
+
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	}
+
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+
+	return fmt.Sprintf("coda_%s_%s.%s", osName, arch, ext)
+}
+
+// CurrentAssetName returns the archive name for the running platform
+func CurrentAssetName() string {
+	return AssetName(runtime.GOOS, runtime.GOARCH)
+}
+
+// CompareVersions compares two dotted versions (an optional "v" prefix and
+// pre-release suffix are ignored). It returns -1, 0 or 1. Unparseable
+// versions such as "dev" sort before any release.
+func CompareVersions(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return parts, false
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
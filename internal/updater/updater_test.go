@@ -0,0 +1,68 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.4", "1.2.3", 1},
+		{"1.10.0", "1.9.9", 1},
+		{"1.2", "1.2.1", -1},
+		{"2.0.0-rc1", "1.9.0", 1},
+		{"dev", "0.0.1", -1},
+		{"0.0.1", "dev", 1},
+		{"dev", "unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s_vs_%s", tt.a, tt.b), func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+		})
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	assert.Equal(t, "coda_Linux_x86_64.tar.gz", AssetName("linux", "amd64"))
+	assert.Equal(t, "coda_Darwin_arm64.tar.gz", AssetName("darwin", "arm64"))
+	assert.Equal(t, "coda_Windows_i386.zip", AssetName("windows", "386"))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("binary contents")
+	sum := sha256.Sum256(data)
+	checksums := []byte(fmt.Sprintf("%s  coda_Linux_x86_64.tar.gz\n%s  other.zip\n",
+		hex.EncodeToString(sum[:]), hex.EncodeToString(make([]byte, 32))))
+
+	assert.NoError(t, VerifyChecksum(data, checksums, "coda_Linux_x86_64.tar.gz"))
+	assert.Error(t, VerifyChecksum([]byte("tampered"), checksums, "coda_Linux_x86_64.tar.gz"))
+	assert.Error(t, VerifyChecksum(data, checksums, "missing.tar.gz"))
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	message := []byte("checksums")
+	signature := ed25519.Sign(priv, message)
+	key := base64.StdEncoding.EncodeToString(pub)
+
+	assert.NoError(t, VerifySignature(message, signature, key))
+	assert.NoError(t, VerifySignature(message, []byte(base64.StdEncoding.EncodeToString(signature)), key))
+	assert.Error(t, VerifySignature([]byte("tampered"), signature, key))
+	assert.Error(t, VerifySignature(message, signature, "not-a-key"))
+}
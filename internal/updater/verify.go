@@ -0,0 +1,91 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ParseChecksums parses a goreleaser checksums.txt ("<sha256>  <name>" per line)
+func ParseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %w", err)
+	}
+
+	return sums, nil
+}
+
+// VerifyChecksum checks data against the entry for name in checksums.txt
+func VerifyChecksum(data []byte, checksums []byte, name string) error {
+	sums, err := ParseChecksums(checksums)
+	if err != nil {
+		return err
+	}
+
+	expected, ok := sums[name]
+	if !ok {
+		return fmt.Errorf("no checksum listed for %s", name)
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, expected, actual)
+	}
+
+	return nil
+}
+
+// VerifySignature checks a detached ed25519 signature over message.
+// publicKey and signature may be raw bytes or base64 encoded.
+func VerifySignature(message, signature []byte, publicKey string) error {
+	key, err := decodeKeyMaterial([]byte(publicKey), ed25519.PublicKeySize)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+
+	sig, err := decodeKeyMaterial(signature, ed25519.SignatureSize)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), message, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	return nil
+}
+
+// decodeKeyMaterial accepts raw or base64 encoded bytes of the expected size
+func decodeKeyMaterial(data []byte, size int) ([]byte, error) {
+	if len(data) == size {
+		return data, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not base64: %w", err)
+	}
+	if len(decoded) != size {
+		return nil, fmt.Errorf("expected %d bytes, got %d", size, len(decoded))
+	}
+	return decoded, nil
+}
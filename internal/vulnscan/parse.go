@@ -0,0 +1,206 @@
+package vulnscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gosecOutput is the part of "gosec -fmt=json" output that is used
+type gosecOutput struct {
+	Issues []struct {
+		Severity string `json:"severity"`
+		RuleID   string `json:"rule_id"`
+		Details  string `json:"details"`
+		File     string `json:"file"`
+		Code     string `json:"code"`
+		// Line is a number or a range such as "12-14"
+		Line string `json:"line"`
+		CWE  struct {
+			ID string `json:"id"`
+		} `json:"cwe"`
+	} `json:"Issues"`
+}
+
+// ParseGosec reads the findings of "gosec -fmt=json", with file paths made
+// relative to dir
+func ParseGosec(data []byte, dir string) ([]Finding, error) {
+	var output gosecOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+	findings := make([]Finding, 0, len(output.Issues))
+	for _, issue := range output.Issues {
+		first, _, _ := strings.Cut(issue.Line, "-")
+		line, _ := strconv.Atoi(first)
+		title := issue.Details
+		if issue.CWE.ID != "" {
+			title += fmt.Sprintf(" (CWE-%s)", issue.CWE.ID)
+		}
+		findings = append(findings, Finding{
+			ID:       issue.RuleID,
+			Severity: normalizeSeverity(issue.Severity),
+			Title:    title,
+			File:     relativePath(issue.File, dir),
+			Line:     line,
+			Details:  strings.TrimSpace(issue.Code),
+		})
+	}
+	return findings, nil
+}
+
+// osvOutput is the part of "osv-scanner --format json" output that is used
+type osvOutput struct {
+	Results []struct {
+		Source struct {
+			Path string `json:"path"`
+		} `json:"source"`
+		Packages []struct {
+			Package struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"package"`
+			Vulnerabilities []osvVulnerability `json:"vulnerabilities"`
+			Groups          []struct {
+				IDs         []string `json:"ids"`
+				MaxSeverity string   `json:"max_severity"`
+			} `json:"groups"`
+		} `json:"packages"`
+	} `json:"results"`
+}
+
+type osvVulnerability struct {
+	ID               string   `json:"id"`
+	Summary          string   `json:"summary"`
+	Details          string   `json:"details"`
+	Aliases          []string `json:"aliases"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// ParseOSV reads the findings of "osv-scanner --format json". Aliases of one
+// vulnerability (e.g. a GO- and a GHSA- advisory) are reported once, with
+// the highest severity of the group.
+func ParseOSV(data []byte, dir string) ([]Finding, error) {
+	var output osvOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, result := range output.Results {
+		source := relativePath(result.Source.Path, dir)
+		for _, pkg := range result.Packages {
+			byID := make(map[string]osvVulnerability, len(pkg.Vulnerabilities))
+			for _, v := range pkg.Vulnerabilities {
+				byID[v.ID] = v
+			}
+			reported := make(map[string]bool)
+			for _, group := range pkg.Groups {
+				if len(group.IDs) == 0 {
+					continue
+				}
+				v, ok := byID[group.IDs[0]]
+				if !ok {
+					continue
+				}
+				severity := cvssSeverity(group.MaxSeverity)
+				if severity == SeverityUnknown {
+					severity = normalizeSeverity(v.DatabaseSpecific.Severity)
+				}
+				findings = append(findings, osvFinding(v, pkg.Package.Name, pkg.Package.Version, source, severity, group.IDs[1:]))
+				for _, id := range group.IDs {
+					reported[id] = true
+				}
+			}
+			// Output without groups still lists every vulnerability
+			for _, v := range pkg.Vulnerabilities {
+				if !reported[v.ID] {
+					findings = append(findings, osvFinding(v, pkg.Package.Name, pkg.Package.Version, source, normalizeSeverity(v.DatabaseSpecific.Severity), v.Aliases))
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+func osvFinding(v osvVulnerability, name, version, source, severity string, aliases []string) Finding {
+	title := v.Summary
+	if title == "" {
+		title, _, _ = strings.Cut(strings.TrimSpace(v.Details), "\n")
+	}
+	if len(aliases) > 0 {
+		title += " (" + strings.Join(aliases, ", ") + ")"
+	}
+	return Finding{
+		ID:       v.ID,
+		Severity: severity,
+		Title:    title,
+		File:     source,
+		Package:  name,
+		Version:  version,
+		Fixed:    fixedVersion(v, name),
+	}
+}
+
+// fixedVersion returns the first version fixing the vulnerability in the
+// package
+func fixedVersion(v osvVulnerability, name string) string {
+	for _, affected := range v.Affected {
+		if affected.Package.Name != "" && affected.Package.Name != name {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			for _, event := range r.Events {
+				if event.Fixed != "" {
+					return event.Fixed
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// cvssSeverity maps a CVSS score to its qualitative severity
+func cvssSeverity(score string) string {
+	value, err := strconv.ParseFloat(score, 64)
+	switch {
+	case err != nil || value <= 0:
+		return SeverityUnknown
+	case value >= 9:
+		return SeverityCritical
+	case value >= 7:
+		return SeverityHigh
+	case value >= 4:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// normalizeSeverity maps scanner severities to the ones of this package
+func normalizeSeverity(severity string) string {
+	switch strings.ToUpper(strings.TrimSpace(severity)) {
+	case "CRITICAL":
+		return SeverityCritical
+	case "HIGH":
+		return SeverityHigh
+	case "MEDIUM", "MODERATE":
+		return SeverityMedium
+	case "LOW":
+		return SeverityLow
+	default:
+		return SeverityUnknown
+	}
+}
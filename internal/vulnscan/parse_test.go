@@ -0,0 +1,125 @@
+package vulnscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gosecJSON = `{
+	"Golang errors": {},
+	"Issues": [
+		{
+			"severity": "MEDIUM",
+			"confidence": "HIGH",
+			"cwe": {"id": "22", "url": "https://cwe.mitre.org/data/definitions/22.html"},
+			"rule_id": "G304",
+			"details": "Potential file inclusion via variable",
+			"file": "/work/app/internal/load.go",
+			"code": "41: \tdata, err := os.ReadFile(path)\n",
+			"line": "41",
+			"column": "15"
+		},
+		{
+			"severity": "HIGH",
+			"confidence": "MEDIUM",
+			"cwe": {"id": "78"},
+			"rule_id": "G204",
+			"details": "Subprocess launched with variable",
+			"file": "/work/app/cmd/run.go",
+			"code": "",
+			"line": "10-12"
+		}
+	],
+	"Stats": {"files": 2, "lines": 100, "nosec": 0, "found": 2}
+}`
+
+func TestParseGosec(t *testing.T) {
+	findings, err := ParseGosec([]byte(gosecJSON), "/work/app")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+
+	assert.Equal(t, Finding{
+		ID:       "G304",
+		Severity: SeverityMedium,
+		Title:    "Potential file inclusion via variable (CWE-22)",
+		File:     "internal/load.go",
+		Line:     41,
+		Details:  "41: \tdata, err := os.ReadFile(path)",
+	}, findings[0])
+	assert.Equal(t, "cmd/run.go:10", findings[1].Location())
+	assert.Equal(t, SeverityHigh, findings[1].Severity)
+
+	_, err = ParseGosec([]byte("Results:\n"), "/work/app")
+	assert.Error(t, err)
+}
+
+const osvJSON = `{
+	"results": [{
+		"source": {"path": "/work/app/go.mod", "type": "lockfile"},
+		"packages": [{
+			"package": {"name": "golang.org/x/net", "version": "0.1.0", "ecosystem": "Go"},
+			"vulnerabilities": [
+				{
+					"id": "GO-2023-1571",
+					"summary": "Denial of service via crafted HTTP/2 stream",
+					"aliases": ["CVE-2022-41723", "GHSA-vvpx-j8f3-3w6h"],
+					"affected": [{
+						"package": {"name": "golang.org/x/net", "ecosystem": "Go"},
+						"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.7.0"}]}]
+					}]
+				},
+				{
+					"id": "GHSA-vvpx-j8f3-3w6h",
+					"summary": "Uncontrolled Resource Consumption",
+					"database_specific": {"severity": "MODERATE"}
+				},
+				{
+					"id": "GO-2024-0001",
+					"details": "Header parsing panics.\nMore details.",
+					"database_specific": {"severity": "LOW"}
+				}
+			],
+			"groups": [
+				{"ids": ["GO-2023-1571", "GHSA-vvpx-j8f3-3w6h"], "max_severity": "7.5"}
+			]
+		}]
+	}]
+}`
+
+func TestParseOSV(t *testing.T) {
+	findings, err := ParseOSV([]byte(osvJSON), "/work/app")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+
+	assert.Equal(t, Finding{
+		ID:       "GO-2023-1571",
+		Severity: SeverityHigh,
+		Title:    "Denial of service via crafted HTTP/2 stream (GHSA-vvpx-j8f3-3w6h)",
+		File:     "go.mod",
+		Package:  "golang.org/x/net",
+		Version:  "0.1.0",
+		Fixed:    "0.7.0",
+	}, findings[0])
+	assert.Equal(t, "GO-2024-0001", findings[1].ID)
+	assert.Equal(t, SeverityLow, findings[1].Severity)
+	assert.Equal(t, "Header parsing panics.", findings[1].Title)
+}
+
+func TestCVSSSeverity(t *testing.T) {
+	tests := []struct {
+		score string
+		want  string
+	}{
+		{"9.8", SeverityCritical},
+		{"7.5", SeverityHigh},
+		{"5.3", SeverityMedium},
+		{"2.1", SeverityLow},
+		{"", SeverityUnknown},
+		{"CVSS:3.1/AV:N", SeverityUnknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, cvssSeverity(tt.score), tt.score)
+	}
+}
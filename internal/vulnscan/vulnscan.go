@@ -0,0 +1,287 @@
+// Package vulnscan runs security scanners (gosec for source code,
+// osv-scanner for dependencies) and reports their findings in one format.
+package vulnscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severities from most to least severe
+const (
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+	SeverityMedium   = "MEDIUM"
+	SeverityLow      = "LOW"
+	SeverityUnknown  = "UNKNOWN"
+)
+
+// severityRank orders severities, most severe first
+var severityRank = map[string]int{
+	SeverityCritical: 0,
+	SeverityHigh:     1,
+	SeverityMedium:   2,
+	SeverityLow:      3,
+	SeverityUnknown:  4,
+}
+
+// ErrUnavailable is returned when a scanner is not installed
+var ErrUnavailable = errors.New("scanner is not installed")
+
+// Finding is one issue reported by a scanner
+type Finding struct {
+	Scanner  string `json:"scanner"`
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+	// File and Line locate source findings
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Package, Version and Fixed describe dependency findings
+	Package string `json:"package,omitempty"`
+	Version string `json:"version,omitempty"`
+	Fixed   string `json:"fixed_version,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// Location returns where the finding is: file:line, or package@version with
+// the manifest declaring it
+func (f Finding) Location() string {
+	switch {
+	case f.Package != "" && f.File != "":
+		return fmt.Sprintf("%s@%s (%s)", f.Package, f.Version, f.File)
+	case f.Package != "":
+		return f.Package + "@" + f.Version
+	case f.Line > 0:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	default:
+		return f.File
+	}
+}
+
+// AtLeast reports whether the finding is at least as severe as severity
+func (f Finding) AtLeast(severity string) bool {
+	rank, ok := severityRank[strings.ToUpper(severity)]
+	if !ok {
+		return true
+	}
+	return severityRank[f.Severity] <= rank
+}
+
+// Scanner runs one security scanner
+type Scanner struct {
+	// Name is the scanner name, also its command
+	Name string
+	// Command is the executable to run (Name when empty)
+	Command string
+	args    []string
+	parse   func(data []byte, dir string) ([]Finding, error)
+	install string
+}
+
+// Gosec scans Go source code for security problems
+func Gosec() *Scanner {
+	return &Scanner{
+		Name:    "gosec",
+		args:    []string{"-fmt=json", "-quiet", "./..."},
+		parse:   ParseGosec,
+		install: "go install github.com/securego/gosec/v2/cmd/gosec@latest",
+	}
+}
+
+// OSVScanner scans dependencies for known vulnerabilities
+func OSVScanner() *Scanner {
+	return &Scanner{
+		Name:    "osv-scanner",
+		args:    []string{"--format", "json", "--recursive", "."},
+		parse:   ParseOSV,
+		install: "go install github.com/google/osv-scanner/cmd/osv-scanner@latest",
+	}
+}
+
+// Scanners returns the supported scanners
+func Scanners() []*Scanner {
+	return []*Scanner{Gosec(), OSVScanner()}
+}
+
+// Installed returns the supported scanners that are installed
+func Installed() []*Scanner {
+	var installed []*Scanner
+	for _, s := range Scanners() {
+		if s.Available() {
+			installed = append(installed, s)
+		}
+	}
+	return installed
+}
+
+// Find returns the supported scanner with the name (or "osv" for osv-scanner)
+func Find(name string) (*Scanner, bool) {
+	if name == "osv" {
+		name = "osv-scanner"
+	}
+	for _, s := range Scanners() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Scanner) command() string {
+	if s.Command != "" {
+		return s.Command
+	}
+	return s.Name
+}
+
+// Available reports whether the scanner is installed
+func (s *Scanner) Available() bool {
+	_, err := exec.LookPath(s.command())
+	return err == nil
+}
+
+// Run scans dir. Both scanners exit with 1 when they find issues, so the
+// exit code only counts as a failure when the output is not a report.
+func (s *Scanner) Run(ctx context.Context, dir string) ([]Finding, error) {
+	cmd := exec.CommandContext(ctx, s.command(), s.args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if errors.Is(runErr, exec.ErrNotFound) || errors.Is(runErr, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w (%s)", s.Name, ErrUnavailable, s.install)
+	}
+
+	findings, err := s.parse(stdout.Bytes(), dir)
+	if err != nil {
+		if runErr != nil {
+			message := strings.TrimSpace(stderr.String())
+			if message == "" {
+				message = runErr.Error()
+			}
+			return nil, fmt.Errorf("%s failed: %s", s.Name, message)
+		}
+		return nil, fmt.Errorf("failed to parse %s output: %w", s.Name, err)
+	}
+	for i := range findings {
+		findings[i].Scanner = s.Name
+	}
+	return findings, nil
+}
+
+// Report gathers the findings of several scanners
+type Report struct {
+	Findings []Finding
+	// Scanned lists the scanners that ran
+	Scanned []string
+	// Skipped maps scanners that did not run to the reason
+	Skipped map[string]string
+}
+
+// Scan runs the scanners on dir and sorts the findings by severity.
+// Scanners that are missing or fail are recorded in Skipped; it is an error
+// only when none of them ran.
+func Scan(ctx context.Context, dir string, scanners []*Scanner) (*Report, error) {
+	report := &Report{Skipped: make(map[string]string)}
+	var errs []error
+	for _, s := range scanners {
+		findings, err := s.Run(ctx, dir)
+		if err != nil {
+			report.Skipped[s.Name] = err.Error()
+			errs = append(errs, err)
+			continue
+		}
+		report.Scanned = append(report.Scanned, s.Name)
+		report.Findings = append(report.Findings, findings...)
+	}
+	if len(report.Scanned) == 0 {
+		return nil, fmt.Errorf("no scanner ran: %w", errors.Join(errs...))
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank[report.Findings[i].Severity] < severityRank[report.Findings[j].Severity]
+	})
+	return report, nil
+}
+
+// Filter keeps the findings at least as severe as severity
+func (r *Report) Filter(severity string) {
+	if severity == "" {
+		return
+	}
+	kept := r.Findings[:0]
+	for _, f := range r.Findings {
+		if f.AtLeast(severity) {
+			kept = append(kept, f)
+		}
+	}
+	r.Findings = kept
+}
+
+// Counts returns the number of findings per severity
+func (r *Report) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+// CountsText describes the counts, e.g. "2 HIGH, 1 LOW"
+func (r *Report) CountsText() string {
+	counts := r.Counts()
+	var parts []string
+	for _, severity := range []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	if len(parts) == 0 {
+		return "no findings"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String lists the findings, one per line
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Security scan (%s): %s", strings.Join(r.Scanned, ", "), r.CountsText())
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "\n  [%s] %s %s: %s", f.Severity, f.ID, f.Location(), f.Title)
+		if f.Fixed != "" {
+			fmt.Fprintf(&b, " (fixed in %s)", f.Fixed)
+		}
+	}
+	names := make([]string, 0, len(r.Skipped))
+	for name := range r.Skipped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n  skipped %s", r.Skipped[name])
+	}
+	return b.String()
+}
+
+// relativePath returns path relative to dir when it is inside it
+func relativePath(path, dir string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(absDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package vulnscan
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScanner writes a script printing output and exiting with code
+func fakeScanner(t *testing.T, scanner *Scanner, output string, code int) *Scanner {
+	t.Helper()
+	data := filepath.Join(t.TempDir(), "output.json")
+	require.NoError(t, os.WriteFile(data, []byte(output), 0644))
+	script := filepath.Join(t.TempDir(), scanner.Name)
+	content := "#!/bin/sh\ncat " + data + "\nexit " + string(rune('0'+code)) + "\n"
+	require.NoError(t, os.WriteFile(script, []byte(content), 0755))
+	scanner.Command = script
+	return scanner
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	gosec := fakeScanner(t, Gosec(), `{"Issues": [{"severity": "LOW", "rule_id": "G104", "details": "Errors unhandled", "file": "`+filepath.Join(dir, "main.go")+`", "line": "7"}]}`, 1)
+	osv := fakeScanner(t, OSVScanner(), osvJSON, 1)
+
+	report, err := Scan(context.Background(), dir, []*Scanner{gosec, osv})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gosec", "osv-scanner"}, report.Scanned)
+	require.Len(t, report.Findings, 3)
+	assert.Equal(t, SeverityHigh, report.Findings[0].Severity, "most severe first")
+	assert.Equal(t, "osv-scanner", report.Findings[0].Scanner)
+	assert.Equal(t, "main.go:7", report.Findings[1].Location())
+
+	text := report.String()
+	assert.Contains(t, text, "Security scan (gosec, osv-scanner): 1 HIGH, 2 LOW")
+	assert.Contains(t, text, "[HIGH] GO-2023-1571 golang.org/x/net@0.1.0 (/work/app/go.mod)", "paths outside dir stay absolute")
+	assert.Contains(t, text, "(fixed in 0.7.0)")
+
+	report.Filter(SeverityHigh)
+	assert.Len(t, report.Findings, 1)
+}
+
+func TestScanSkipsUnavailableScanners(t *testing.T) {
+	dir := t.TempDir()
+	missing := Gosec()
+	missing.Command = filepath.Join(dir, "no-such-gosec")
+	broken := fakeScanner(t, OSVScanner(), "not json", 2)
+
+	_, err := Scan(context.Background(), dir, []*Scanner{missing, broken})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gosec: scanner is not installed")
+	assert.Contains(t, err.Error(), "osv-scanner failed")
+
+	assert.True(t, errors.Is(err, ErrUnavailable))
+
+	osv := fakeScanner(t, OSVScanner(), `{"results": []}`, 0)
+	report, err := Scan(context.Background(), dir, []*Scanner{missing, osv})
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings)
+	assert.Contains(t, report.Skipped, "gosec")
+	assert.Contains(t, report.String(), "no findings")
+}
@@ -0,0 +1,288 @@
+// Package watch detects changes to files matching glob patterns by polling
+// and reports them in debounced batches.
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often the file tree is scanned
+	DefaultInterval = time.Second
+
+	// DefaultDebounce is how long the tree must be quiet before a batch is reported
+	DefaultDebounce = 2 * time.Second
+)
+
+// skipDirs are never scanned
+var skipDirs = map[string]bool{
+	".git":         true,
+	".coda":        true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Op is the kind of change to a file
+type Op string
+
+const (
+	OpCreated  Op = "created"
+	OpModified Op = "modified"
+	OpRemoved  Op = "removed"
+)
+
+// Change is a single file change
+type Change struct {
+	Path string
+	Op   Op
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Option configures a Watcher
+type Option func(*Watcher)
+
+// WithInterval sets the polling interval
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithDebounce sets how long changes must settle before they are reported
+func WithDebounce(d time.Duration) Option {
+	return func(w *Watcher) {
+		if d >= 0 {
+			w.debounce = d
+		}
+	}
+}
+
+// WithExcludes ignores files matching any of the patterns
+func WithExcludes(patterns ...string) Option {
+	return func(w *Watcher) {
+		for _, p := range patterns {
+			w.excludes = append(w.excludes, compileGlob(p))
+		}
+	}
+}
+
+// Watcher polls a directory tree for changes to matching files
+type Watcher struct {
+	root     string
+	patterns []*regexp.Regexp
+	excludes []*regexp.Regexp
+	interval time.Duration
+	debounce time.Duration
+}
+
+// New creates a watcher for files under root matching any of the glob
+// patterns. Patterns are relative to root and support *, ? and ** (any
+// number of directories).
+func New(root string, patterns []string, opts ...Option) *Watcher {
+	w := &Watcher{
+		root:     root,
+		interval: DefaultInterval,
+		debounce: DefaultDebounce,
+	}
+	for _, p := range patterns {
+		w.patterns = append(w.patterns, compileGlob(p))
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run calls onChange with each debounced batch of changes until ctx is done.
+// onChange runs on the watcher's goroutine; changes made while it runs (for
+// example by the handler itself) are folded into the next baseline rather
+// than reported, so a handler that edits watched files does not retrigger
+// itself.
+func (w *Watcher) Run(ctx context.Context, onChange func([]Change)) error {
+	snapshot := w.scan()
+	pending := make(map[string]Op)
+	var lastChange time.Time
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current := w.scan()
+		changes := diff(snapshot, current)
+		snapshot = current
+
+		if len(changes) > 0 {
+			for _, c := range changes {
+				pending[c.Path] = mergeOp(pending[c.Path], c.Op)
+			}
+			lastChange = time.Now()
+		}
+
+		if len(pending) == 0 || time.Since(lastChange) < w.debounce {
+			continue
+		}
+
+		batch := make([]Change, 0, len(pending))
+		for path, op := range pending {
+			if op != "" {
+				batch = append(batch, Change{Path: path, Op: op})
+			}
+		}
+		pending = make(map[string]Op)
+		if len(batch) == 0 {
+			continue
+		}
+		sort.Slice(batch, func(i, j int) bool { return batch[i].Path < batch[j].Path })
+
+		onChange(batch)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		snapshot = w.scan()
+	}
+}
+
+// mergeOp combines successive changes to the same file within a batch.
+// A file created and removed again nets out to no change ("").
+func mergeOp(previous, next Op) Op {
+	switch {
+	case previous == "":
+		return next
+	case previous == OpCreated && next == OpRemoved:
+		return ""
+	case previous == OpCreated:
+		return OpCreated
+	case previous == OpRemoved && next == OpCreated:
+		return OpModified
+	default:
+		return next
+	}
+}
+
+// scan records the modification time and size of every matching file
+func (w *Watcher) scan() map[string]fileState {
+	files := make(map[string]fileState)
+
+	filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != w.root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !w.Matches(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+
+	return files
+}
+
+// Matches reports whether a slash-separated path relative to the root is watched
+func (w *Watcher) Matches(rel string) bool {
+	for _, re := range w.excludes {
+		if re.MatchString(rel) {
+			return false
+		}
+	}
+	for _, re := range w.patterns {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// diff lists the changes between two snapshots, sorted by path
+func diff(before, after map[string]fileState) []Change {
+	var changes []Change
+	for path, state := range after {
+		old, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Op: OpCreated})
+		case !old.modTime.Equal(state.modTime) || old.size != state.size:
+			changes = append(changes, Change{Path: path, Op: OpModified})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, Change{Path: path, Op: OpRemoved})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern
+func MatchGlob(pattern, path string) bool {
+	return compileGlob(pattern).MatchString(path)
+}
+
+// compileGlob converts a glob to an anchored regular expression. A pattern
+// without a slash matches the base name in any directory, like .gitignore.
+func compileGlob(pattern string) *regexp.Regexp {
+	pattern = filepath.ToSlash(strings.TrimPrefix(pattern, "./"))
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && strings.HasPrefix(pattern[i:], "**/"):
+			// Zero or more directories
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
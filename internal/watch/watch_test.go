@@ -0,0 +1,111 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/chat/handler.go", true},
+		{"*.go", "internal/chat/handler.go", true},
+		{"internal/*.go", "internal/chat/handler.go", false},
+		{"internal/**", "internal/chat/handler.go", true},
+		{"docs/**/*.md", "docs/guide/intro.md", true},
+		{"docs/**/*.md", "docs/readme.md", true},
+		{"docs/**/*.md", "src/readme.md", false},
+		{"./cmd/?oot.go", "cmd/root.go", true},
+		{"*.go", "main.go.orig", false},
+		{"*_test.go", "pkg/a_test.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchGlob(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestDiffAndMergeOp(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileState{
+		"a.go": {modTime: now, size: 1},
+		"b.go": {modTime: now, size: 1},
+		"c.go": {modTime: now, size: 1},
+	}
+	after := map[string]fileState{
+		"a.go": {modTime: now, size: 1},
+		"b.go": {modTime: now.Add(time.Second), size: 1},
+		"d.go": {modTime: now, size: 1},
+	}
+
+	assert.Equal(t, []Change{
+		{Path: "b.go", Op: OpModified},
+		{Path: "c.go", Op: OpRemoved},
+		{Path: "d.go", Op: OpCreated},
+	}, diff(before, after))
+
+	assert.Equal(t, Op(""), mergeOp(OpCreated, OpRemoved))
+	assert.Equal(t, OpCreated, mergeOp(OpCreated, OpModified))
+	assert.Equal(t, OpModified, mergeOp(OpRemoved, OpCreated))
+	assert.Equal(t, OpRemoved, mergeOp(OpModified, OpRemoved))
+}
+
+func TestWatcherRun(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "pkg"), 0755))
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+	write("pkg/a.go", "package pkg")
+
+	w := New(root, []string{"**/*.go"},
+		WithExcludes("*_test.go"),
+		WithInterval(10*time.Millisecond),
+		WithDebounce(50*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	batches := make(chan []Change, 4)
+	go w.Run(ctx, func(changes []Change) {
+		// Edits made by the handler itself must not trigger another run
+		write("pkg/generated.go", "package pkg")
+		batches <- changes
+	})
+
+	// Let the watcher take its baseline, then make a burst of changes
+	time.Sleep(50 * time.Millisecond)
+	write("pkg/a.go", "package pkg // edited")
+	write("pkg/b.go", "package pkg")
+	write("pkg/b_test.go", "package pkg")
+	write("notes.txt", "ignored")
+
+	select {
+	case batch := <-batches:
+		assert.Equal(t, []Change{
+			{Path: "pkg/a.go", Op: OpModified},
+			{Path: "pkg/b.go", Op: OpCreated},
+		}, batch)
+	case <-ctx.Done():
+		t.Fatal("no batch reported")
+	}
+
+	select {
+	case batch := <-batches:
+		t.Fatalf("unexpected second batch: %v", batch)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,658 @@
+// Package web serves a browser frontend for CODA over HTTP: the chat view,
+// a prompt box and the tool approval dialog, backed by a small JSON API and a
+// server-sent event stream.
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// SessionSource provides the conversation shown in the chat view
+type SessionSource interface {
+	GetCurrentSession() *chat.Session
+}
+
+// PromptRunner answers a prompt, executing tool calls (chat.PromptRunner)
+type PromptRunner interface {
+	Run(ctx context.Context, prompt string) (*chat.RunResult, error)
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithToken requires the token on every API request
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithRole sets the role of the WithToken token (default: admin)
+func WithRole(role string) Option {
+	return func(s *Server) {
+		if role != "" {
+			s.role = role
+		}
+	}
+}
+
+// WithOperators lets each operator prompt and answer approvals with their
+// own token, within their role
+func WithOperators(operators ...Operator) Option {
+	return func(s *Server) {
+		s.operators = append(s.operators, operators...)
+	}
+}
+
+// WithSpectatorToken lets requests with the token follow the session
+// read-only: they can load it and stream its events but not prompt, cancel
+// or answer approvals
+func WithSpectatorToken(token string) Option {
+	return func(s *Server) {
+		s.spectatorToken = token
+	}
+}
+
+// WithAutoApprove approves every tool call without asking the browser
+func WithAutoApprove(autoApprove bool) Option {
+	return func(s *Server) {
+		s.autoApprove = autoApprove
+	}
+}
+
+// Operator prompts and answers approvals with their own token. Their role
+// (config.RoleViewer, RoleEditor or RoleAdmin) limits the tool categories
+// their prompts may request and whose approvals they may answer.
+type Operator struct {
+	Name  string
+	Role  string
+	Token string
+}
+
+// Event is sent to browsers on the event stream
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Event types
+const (
+	EventPromptStarted    = "prompt_started"
+	EventMessage          = "message"
+	EventToolCall         = "tool_call"
+	EventToolDone         = "tool_done"
+	EventApprovalRequest  = "approval_request"
+	EventApprovalResolved = "approval_resolved"
+	EventPromptDone       = "prompt_done"
+)
+
+// Message is a conversation entry shown in the chat view
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// SessionState is the conversation and activity returned by /api/session
+type SessionState struct {
+	SessionID string      `json:"session_id,omitempty"`
+	Messages  []Message   `json:"messages"`
+	Running   bool        `json:"running"`
+	Approvals []*Approval `json:"approvals"`
+
+	// ReadOnly is set for spectators
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// Operator and Role identify the token's operator; Categories are the
+	// tool categories the role may request and approve
+	Operator   string   `json:"operator,omitempty"`
+	Role       string   `json:"role,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// Approval is a tool call waiting for the user's decision
+type Approval struct {
+	ID        string                 `json:"id"`
+	Tool      string                 `json:"tool"`
+	Category  string                 `json:"category"`
+	Params    map[string]interface{} `json:"params"`
+	Dangers   []string               `json:"dangers,omitempty"` // Why a high-risk call needs confirmation
+	CreatedAt time.Time              `json:"created_at"`
+
+	answer chan bool
+}
+
+// Server serves the web frontend and its API
+type Server struct {
+	sessions       SessionSource
+	runner         PromptRunner
+	token          string
+	role           string
+	operators      []Operator
+	spectatorToken string
+	autoApprove    bool
+
+	subMu       sync.Mutex
+	subscribers map[chan Event]struct{}
+
+	approvalMu sync.Mutex
+	approvals  map[string]*Approval
+	nextID     int
+
+	// The chat handler has a single current session, so prompts are serialized
+	promptMu sync.Mutex
+	running  bool
+	cancel   context.CancelFunc
+	// promptRole is the role of the operator who sent the running prompt
+	promptRole string
+}
+
+// NewServer creates a web server showing the sessions' current conversation
+func NewServer(sessions SessionSource, opts ...Option) *Server {
+	s := &Server{
+		sessions:    sessions,
+		role:        config.RoleAdmin,
+		subscribers: make(map[chan Event]struct{}),
+		approvals:   make(map[string]*Approval),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetRunner sets the prompt runner. It is separate from NewServer because the
+// runner's tool executor normally uses the server as its ApprovalHandler and
+// reports progress to Observe.
+func (s *Server) SetRunner(runner PromptRunner) {
+	s.runner = runner
+}
+
+// GenerateToken returns a random token for WithToken
+func GenerateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Handler returns the HTTP handler serving the frontend and the API
+func (s *Server) Handler() http.Handler {
+	static, _ := fs.Sub(staticFiles, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("/api/session", s.readable(s.handleSession))
+	mux.HandleFunc("/api/prompt", s.authorized(s.handlePrompt))
+	mux.HandleFunc("/api/cancel", s.authorized(s.handleCancel))
+	mux.HandleFunc("/api/approvals", s.authorized(s.handleApprovals))
+	mux.HandleFunc("/api/approvals/", s.authorized(s.handleApproval))
+	mux.HandleFunc("/api/events", s.readable(s.handleEvents))
+	return mux
+}
+
+// Serve serves on listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("web server failed: %w", err)
+	case <-ctx.Done():
+		s.cancelPrompt()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("failed to shut down web server: %w", err)
+		}
+		return nil
+	}
+}
+
+// Access levels granted by a request's token
+const (
+	accessNone = iota
+	accessSpectator
+	accessFull
+)
+
+// access returns what the request's token grants and, for full access, the
+// operator it belongs to. Browsers pass the token as a query parameter since
+// EventSource cannot set headers.
+func (s *Server) access(r *http.Request) (int, Operator) {
+	owner := Operator{Name: "owner", Role: s.role}
+	if s.token == "" {
+		return accessFull, owner
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+		return accessFull, owner
+	}
+	for _, op := range s.operators {
+		if op.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(op.Token)) == 1 {
+			return accessFull, op
+		}
+	}
+	if s.spectatorToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.spectatorToken)) == 1 {
+		return accessSpectator, Operator{}
+	}
+	return accessNone, Operator{}
+}
+
+// authorized rejects requests without the configured token
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch level, _ := s.access(r); level {
+		case accessNone:
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		case accessSpectator:
+			writeError(w, http.StatusForbidden, "spectators have read-only access")
+		default:
+			next(w, r)
+		}
+	}
+}
+
+// readable is like authorized but also accepts the spectator token
+func (s *Server) readable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if level, _ := s.access(r); level == accessNone {
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.promptMu.Lock()
+	running := s.running
+	s.promptMu.Unlock()
+
+	level, op := s.access(r)
+	response := SessionState{
+		Messages:   []Message{},
+		Running:    running,
+		Approvals:  s.pendingApprovals(),
+		ReadOnly:   level == accessSpectator,
+		Operator:   op.Name,
+		Role:       op.Role,
+		Categories: security.RoleCategories(op.Role),
+	}
+	if session := s.sessions.GetCurrentSession(); session != nil {
+		response.SessionID = session.ID
+		response.Messages = sessionMessages(session)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	text := strings.TrimSpace(body.Text)
+	if text == "" {
+		writeError(w, http.StatusBadRequest, "prompt is empty")
+		return
+	}
+	if s.runner == nil {
+		writeError(w, http.StatusServiceUnavailable, "prompts are not available")
+		return
+	}
+
+	s.promptMu.Lock()
+	if s.running {
+		s.promptMu.Unlock()
+		writeError(w, http.StatusConflict, "a prompt is already running")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	_, op := s.access(r)
+	s.running = true
+	s.cancel = cancel
+	s.promptRole = op.Role
+	s.promptMu.Unlock()
+
+	s.broadcast(Event{Type: EventPromptStarted, Data: Message{Role: ai.RoleUser, Content: text}})
+	go s.runPrompt(ctx, text)
+
+	writeJSON(w, http.StatusAccepted, map[string]bool{"accepted": true})
+}
+
+// runPrompt answers a prompt in the background, reporting progress as events
+func (s *Server) runPrompt(ctx context.Context, text string) {
+	result, err := s.runner.Run(ctx, text)
+
+	s.promptMu.Lock()
+	s.running = false
+	s.cancel()
+	s.cancel = nil
+	s.promptMu.Unlock()
+
+	done := map[string]interface{}{}
+	if result != nil {
+		done["turns"] = result.Turns
+		done["tool_calls"] = len(result.ToolResults)
+	}
+	if err != nil {
+		done["error"] = err.Error()
+	}
+	s.broadcast(Event{Type: EventPromptDone, Data: done})
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"canceled": s.cancelPrompt()})
+}
+
+// cancelPrompt cancels the running prompt, reporting whether one was running
+func (s *Server) cancelPrompt() bool {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	if s.cancel == nil {
+		return false
+	}
+	s.cancel()
+	return true
+}
+
+func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.pendingApprovals())
+}
+
+// handleApproval answers POST /api/approvals/{id} with {"approve": bool}
+func (s *Server) handleApproval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var body struct {
+		Approve bool `json:"approve"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/approvals/")
+	_, op := s.access(r)
+	if category, ok := s.approvalCategory(id); ok && !slices.Contains(security.RoleCategories(op.Role), category) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("the %s role cannot answer approvals of %s tools", op.Role, category))
+		return
+	}
+	if !s.resolveApproval(id, body.Approve) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no pending approval %q", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"approved": body.Approve})
+}
+
+// handleEvents streams events to the browser as server-sent events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// Observe reports prompt runner progress to browsers (chat.WithRunObserver)
+func (s *Server) Observe(event chat.RunEvent) {
+	switch event.Kind {
+	case chat.RunEventMessage:
+		s.broadcast(Event{Type: EventMessage, Data: Message{Role: ai.RoleAssistant, Content: event.Text}})
+	case chat.RunEventToolCall:
+		s.broadcast(Event{Type: EventToolCall, Data: map[string]string{"tool": event.Tool, "arguments": event.Text}})
+	case chat.RunEventToolDone:
+		data := map[string]string{"tool": event.Tool}
+		if event.Err != nil {
+			data["error"] = event.Err.Error()
+		}
+		s.broadcast(Event{Type: EventToolDone, Data: data})
+	case chat.RunEventWarning:
+		s.broadcast(Event{Type: EventMessage, Data: Message{Role: ai.RoleSystem, Content: event.Text}})
+	}
+}
+
+// RequestApproval implements chat.ApprovalHandler by asking the browsers.
+// Tools the role of the prompt's operator cannot request are refused
+// without asking.
+func (s *Server) RequestApproval(ctx context.Context, tool string, params map[string]interface{}) (bool, error) {
+	return s.ask(ctx, tool, params, nil)
+}
+
+// ConfirmDangerous implements chat.DangerConfirmer by asking the browsers,
+// showing why the call is dangerous. It is asked even with auto-approval.
+func (s *Server) ConfirmDangerous(ctx context.Context, tool string, params map[string]interface{}, dangers []string) (bool, error) {
+	return s.ask(ctx, tool, params, dangers)
+}
+
+// ask broadcasts an approval request and waits for an operator to answer it
+func (s *Server) ask(ctx context.Context, tool string, params map[string]interface{}, dangers []string) (bool, error) {
+	if err := s.checkRole(tool); err != nil {
+		return false, err
+	}
+
+	s.approvalMu.Lock()
+	s.nextID++
+	approval := &Approval{
+		ID:        fmt.Sprintf("%d", s.nextID),
+		Tool:      tool,
+		Category:  security.ToolCategory(tool),
+		Params:    params,
+		Dangers:   dangers,
+		CreatedAt: time.Now(),
+		answer:    make(chan bool, 1),
+	}
+	s.approvals[approval.ID] = approval
+	s.approvalMu.Unlock()
+
+	s.broadcast(Event{Type: EventApprovalRequest, Data: approval})
+
+	select {
+	case approved := <-approval.answer:
+		return approved, nil
+	case <-ctx.Done():
+		s.approvalMu.Lock()
+		delete(s.approvals, approval.ID)
+		s.approvalMu.Unlock()
+		s.broadcast(Event{Type: EventApprovalResolved, Data: map[string]interface{}{"id": approval.ID, "approved": false}})
+		return false, ctx.Err()
+	}
+}
+
+// IsAutoApproved implements chat.ApprovalHandler. Auto-approval stays
+// within the role of the prompt's operator.
+func (s *Server) IsAutoApproved(tool string) bool {
+	return s.autoApprove && s.checkRole(tool) == nil
+}
+
+// checkRole returns an error when the running prompt's operator may not
+// request tool
+func (s *Server) checkRole(tool string) error {
+	s.promptMu.Lock()
+	role := s.promptRole
+	s.promptMu.Unlock()
+	if role == "" {
+		return nil
+	}
+	return security.CheckRole(role, tool)
+}
+
+// approvalCategory returns the tool category of a pending approval
+func (s *Server) approvalCategory(id string) (string, bool) {
+	s.approvalMu.Lock()
+	defer s.approvalMu.Unlock()
+	approval, ok := s.approvals[id]
+	if !ok {
+		return "", false
+	}
+	return approval.Category, true
+}
+
+func (s *Server) resolveApproval(id string, approved bool) bool {
+	s.approvalMu.Lock()
+	approval, ok := s.approvals[id]
+	delete(s.approvals, id)
+	s.approvalMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	approval.answer <- approved
+	s.broadcast(Event{Type: EventApprovalResolved, Data: map[string]interface{}{"id": id, "approved": approved}})
+	return true
+}
+
+func (s *Server) pendingApprovals() []*Approval {
+	s.approvalMu.Lock()
+	defer s.approvalMu.Unlock()
+
+	approvals := make([]*Approval, 0, len(s.approvals))
+	for _, approval := range s.approvals {
+		approvals = append(approvals, approval)
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+	return approvals
+}
+
+func (s *Server) subscribe() chan Event {
+	ch := make(chan Event, 64)
+	s.subMu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan Event) {
+	s.subMu.Lock()
+	delete(s.subscribers, ch)
+	s.subMu.Unlock()
+}
+
+// broadcast sends an event to every connected browser. Browsers that fall
+// behind miss events and resynchronize from /api/session.
+func (s *Server) broadcast(event Event) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// sessionMessages converts stored session messages into chat view entries
+func sessionMessages(session *chat.Session) []Message {
+	messages := make([]Message, 0, len(session.Messages))
+	for _, msg := range session.Messages {
+		switch {
+		case msg.Role == ai.RoleSystem:
+			continue
+		case msg.Role == ai.RoleUser && strings.HasPrefix(msg.Content, "TOOL_RESULT["):
+			messages = append(messages, Message{Role: "tool", Content: strings.TrimPrefix(msg.Content, "TOOL_RESULT")})
+		default:
+			messages = append(messages, Message{Role: msg.Role, Content: msg.Content})
+		}
+	}
+	return messages
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
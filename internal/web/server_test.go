@@ -0,0 +1,362 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/config"
+	"github.com/common-creation/coda/internal/security"
+)
+
+type fakeSessions struct {
+	session *chat.Session
+}
+
+func (f *fakeSessions) GetCurrentSession() *chat.Session {
+	return f.session
+}
+
+// approvingRunner asks for approval of one tool call and reports the answer
+type approvingRunner struct {
+	server *Server
+}
+
+func (r *approvingRunner) Run(ctx context.Context, prompt string) (*chat.RunResult, error) {
+	approved, err := r.server.RequestApproval(ctx, "write_file", map[string]interface{}{"path": "main.go"})
+	if err != nil {
+		return nil, err
+	}
+	output := "denied"
+	if approved {
+		output = "approved"
+	}
+	r.server.Observe(chat.RunEvent{Kind: chat.RunEventMessage, Text: output})
+	return &chat.RunResult{Output: output, Turns: 1}, nil
+}
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	sessions := &fakeSessions{session: &chat.Session{
+		ID: "session-1",
+		Messages: []ai.Message{
+			{Role: ai.RoleSystem, Content: "system prompt"},
+			{Role: ai.RoleUser, Content: "hello"},
+			{Role: ai.RoleUser, Content: "TOOL_RESULT[read_file]: data"},
+			{Role: ai.RoleAssistant, Content: "hi"},
+		},
+	}}
+	server := NewServer(sessions, WithToken("secret"))
+	server.SetRunner(&approvingRunner{server: server})
+
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return server, ts
+}
+
+func request(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	tests := []struct {
+		name string
+		url  string
+		auth string
+		want int
+	}{
+		{name: "missing token", url: "/api/session", want: http.StatusUnauthorized},
+		{name: "wrong token", url: "/api/session", auth: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "header token", url: "/api/session", auth: "Bearer secret", want: http.StatusOK},
+		{name: "query token", url: "/api/session?token=secret", want: http.StatusOK},
+		{name: "frontend is public", url: "/", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.url, nil)
+			require.NoError(t, err)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestServerSession(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	resp := request(t, http.MethodGet, ts.URL+"/api/session", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var session struct {
+		SessionID string    `json:"session_id"`
+		Messages  []Message `json:"messages"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&session))
+	assert.Equal(t, "session-1", session.SessionID)
+	assert.Equal(t, []Message{
+		{Role: "user", Content: "hello"},
+		{Role: "tool", Content: "[read_file]: data"},
+		{Role: "assistant", Content: "hi"},
+	}, session.Messages)
+}
+
+func TestServerPromptWithApproval(t *testing.T) {
+	server, ts := newTestServer(t)
+
+	// Subscribe before prompting so no event is missed
+	events := server.subscribe()
+	defer server.unsubscribe(events)
+
+	resp := request(t, http.MethodPost, ts.URL+"/api/prompt", `{"text":"write main.go"}`)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// Only one prompt runs at a time
+	resp = request(t, http.MethodPost, ts.URL+"/api/prompt", `{"text":"again"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	assert.Equal(t, EventPromptStarted, nextEvent(t, events).Type)
+	approval := nextEvent(t, events)
+	require.Equal(t, EventApprovalRequest, approval.Type)
+	id := approval.Data.(*Approval).ID
+
+	resp = request(t, http.MethodPost, ts.URL+"/api/approvals/"+id, `{"approve":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, EventApprovalResolved, nextEvent(t, events).Type)
+	message := nextEvent(t, events)
+	require.Equal(t, EventMessage, message.Type)
+	assert.Equal(t, "approved", message.Data.(Message).Content)
+	assert.Equal(t, EventPromptDone, nextEvent(t, events).Type)
+
+	// Answering twice fails
+	resp = request(t, http.MethodPost, ts.URL+"/api/approvals/"+id, `{"approve":false}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServerConfirmDangerous(t *testing.T) {
+	server, ts := newTestServer(t)
+	events := server.subscribe()
+	defer server.unsubscribe(events)
+
+	answer := make(chan bool, 1)
+	go func() {
+		approved, _ := server.ConfirmDangerous(context.Background(), "run_command", map[string]interface{}{"command": "sudo reboot"}, []string{"runs sudo"})
+		answer <- approved
+	}()
+
+	event := nextEvent(t, events)
+	require.Equal(t, EventApprovalRequest, event.Type)
+	approval := event.Data.(*Approval)
+	assert.Equal(t, []string{"runs sudo"}, approval.Dangers)
+
+	resp := request(t, http.MethodPost, ts.URL+"/api/approvals/"+approval.ID, `{"approve":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, <-answer)
+}
+
+func TestServerCancelDeniesPendingApproval(t *testing.T) {
+	server, ts := newTestServer(t)
+	events := server.subscribe()
+	defer server.unsubscribe(events)
+
+	request(t, http.MethodPost, ts.URL+"/api/prompt", `{"text":"write"}`)
+	nextEvent(t, events)
+	require.Equal(t, EventApprovalRequest, nextEvent(t, events).Type)
+
+	resp := request(t, http.MethodPost, ts.URL+"/api/cancel", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, EventApprovalResolved, nextEvent(t, events).Type)
+	done := nextEvent(t, events)
+	require.Equal(t, EventPromptDone, done.Type)
+	assert.Contains(t, done.Data.(map[string]interface{})["error"], "canceled")
+	assert.Empty(t, server.pendingApprovals())
+}
+
+func TestServerEventStream(t *testing.T) {
+	server, ts := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/events?token=secret", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Wait for the stream to subscribe
+	require.Eventually(t, func() bool {
+		server.subMu.Lock()
+		defer server.subMu.Unlock()
+		return len(server.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	server.Observe(chat.RunEvent{Kind: chat.RunEventToolDone, Tool: "read_file"})
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, `data: {"type":"tool_done","data":{"tool":"read_file"}}`+"\n", line)
+}
+
+func nextEvent(t *testing.T, events chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestServerSpectator(t *testing.T) {
+	sessions := &fakeSessions{session: &chat.Session{ID: "session-1"}}
+	server := NewServer(sessions, WithToken("secret"), WithSpectatorToken("watch"))
+	server.SetRunner(&approvingRunner{server: server})
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/session", http.StatusOK},
+		{http.MethodPost, "/api/prompt", http.StatusForbidden},
+		{http.MethodPost, "/api/cancel", http.StatusForbidden},
+		{http.MethodGet, "/api/approvals", http.StatusForbidden},
+		{http.MethodPost, "/api/approvals/1", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path+"?token=watch", strings.NewReader(`{"text":"hi"}`))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+
+	spectator, err := NewSpectator(ts.URL+"/?token=watch", "")
+	require.NoError(t, err)
+	state, err := spectator.Session(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", state.SessionID)
+	assert.True(t, state.ReadOnly)
+
+	// The full token is not read-only
+	resp := request(t, http.MethodGet, ts.URL+"/api/session", "")
+	var full SessionState
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&full))
+	assert.False(t, full.ReadOnly)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan Event, 1)
+	go spectator.Follow(ctx, func(event Event) { events <- event })
+
+	require.Eventually(t, func() bool {
+		server.subMu.Lock()
+		defer server.subMu.Unlock()
+		return len(server.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	server.Observe(chat.RunEvent{Kind: chat.RunEventToolCall, Tool: "read_file", Text: `{"path":"a.go"}`})
+	event := nextEvent(t, events)
+	assert.Equal(t, EventToolCall, event.Type)
+	assert.Equal(t, map[string]interface{}{"tool": "read_file", "arguments": `{"path":"a.go"}`}, event.Data)
+
+	wrong, err := NewSpectator(ts.URL, "nope")
+	require.NoError(t, err)
+	_, err = wrong.Session(context.Background())
+	assert.ErrorContains(t, err, "invalid or missing token")
+}
+
+func TestServerOperatorRoles(t *testing.T) {
+	sessions := &fakeSessions{session: &chat.Session{ID: "session-1"}}
+	server := NewServer(sessions, WithToken("secret"), WithOperators(
+		Operator{Name: "participants", Role: config.RoleViewer, Token: "view"},
+		Operator{Name: "assistant", Role: config.RoleEditor, Token: "edit"},
+	))
+	server.SetRunner(&approvingRunner{server: server})
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	post := func(path, token, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/session?token=view", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var state SessionState
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	resp.Body.Close()
+	assert.Equal(t, "participants", state.Operator)
+	assert.Equal(t, []string{security.CategoryRead}, state.Categories)
+	assert.False(t, state.ReadOnly)
+
+	events := server.subscribe()
+	defer server.unsubscribe(events)
+
+	// A viewer's prompt cannot request write_file, not even for approval
+	require.Equal(t, http.StatusAccepted, post("/api/prompt", "view", `{"text":"write main.go"}`).StatusCode)
+	assert.Equal(t, EventPromptStarted, nextEvent(t, events).Type)
+	done := nextEvent(t, events)
+	require.Equal(t, EventPromptDone, done.Type)
+	assert.Equal(t, "write_file is a write tool, which the viewer role cannot request", done.Data.(map[string]interface{})["error"])
+
+	// An editor's prompt can, but a viewer cannot answer the approval
+	require.Equal(t, http.StatusAccepted, post("/api/prompt", "edit", `{"text":"write main.go"}`).StatusCode)
+	nextEvent(t, events)
+	approval := nextEvent(t, events)
+	require.Equal(t, EventApprovalRequest, approval.Type)
+	assert.Equal(t, security.CategoryWrite, approval.Data.(*Approval).Category)
+	id := approval.Data.(*Approval).ID
+
+	resp = post("/api/approvals/"+id, "view", `{"approve":true}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = post("/api/approvals/"+id, "edit", `{"approve":true}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, EventApprovalResolved, nextEvent(t, events).Type)
+	assert.Equal(t, "approved", nextEvent(t, events).Data.(Message).Content)
+	assert.Equal(t, EventPromptDone, nextEvent(t, events).Type)
+
+	// Auto-approval stays within the role
+	server.autoApprove = true
+	server.promptRole = config.RoleViewer
+	assert.True(t, server.IsAutoApproved("read_file"))
+	assert.False(t, server.IsAutoApproved("write_file"))
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Spectator follows a served session from another terminal through the
+// same API the browser uses
+type Spectator struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewSpectator creates a client for the server at rawURL. The token may be
+// given in the URL's token query parameter, as printed by coda serve.
+func NewSpectator(rawURL, token string) (*Spectator, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", rawURL)
+	}
+	if token == "" {
+		token = u.Query().Get("token")
+	}
+	return &Spectator{
+		baseURL: u.Scheme + "://" + u.Host,
+		token:   token,
+		client:  &http.Client{},
+	}, nil
+}
+
+// Session returns the conversation so far
+func (c *Spectator) Session(ctx context.Context) (*SessionState, error) {
+	resp, err := c.get(ctx, "/api/session")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var state SessionState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("invalid session response: %w", err)
+	}
+	return &state, nil
+}
+
+// Follow calls handle for every event until ctx is cancelled or the server
+// closes the stream
+func (c *Spectator) Follow(ctx context.Context, handle func(Event)) error {
+	resp, err := c.get(ctx, "/api/events")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			var event Event
+			if json.Unmarshal([]byte(data), &event) == nil {
+				handle(event)
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err == io.EOF {
+				return fmt.Errorf("server closed the event stream")
+			}
+			return fmt.Errorf("event stream failed: %w", err)
+		}
+	}
+}
+
+func (c *Spectator) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", c.baseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<10)).Decode(&body)
+		if body.Error == "" {
+			body.Error = resp.Status
+		}
+		return nil, fmt.Errorf("%s: %s", path, body.Error)
+	}
+	return resp, nil
+}
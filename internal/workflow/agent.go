@@ -0,0 +1,56 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/common-creation/coda/internal/ai"
+	"github.com/common-creation/coda/internal/chat"
+)
+
+// AgentPrompts runs prompt steps through a chat.PromptRunner, so the model
+// can call tools while answering
+type AgentPrompts struct {
+	Runner *chat.PromptRunner
+}
+
+// RunPrompt implements PromptRunner
+func (a AgentPrompts) RunPrompt(ctx context.Context, prompt string) (string, error) {
+	result, err := a.Runner.Run(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
+
+// ExecutorTools runs tool steps through a chat.ToolExecutor, so they are
+// subject to the same approval, rules and high-risk checks as the tools the
+// model calls. Workflows may come from a repository, so their steps are not
+// trusted more than the model is.
+type ExecutorTools struct {
+	Executor *chat.ToolExecutor
+}
+
+// InvokeTool implements ToolInvoker
+func (e ExecutorTools) InvokeTool(ctx context.Context, name string, params map[string]interface{}) (string, error) {
+	args, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode parameters of %s: %w", name, err)
+	}
+	call := ai.ToolCall{ID: "workflow-" + name, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = string(args)
+
+	results, err := e.Executor.ExecuteToolCalls(ctx, []ai.ToolCall{call})
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("tool %s produced no result", name)
+	}
+	if results[0].Error != nil {
+		return "", results[0].Error
+	}
+	return chat.ToolResultContent(results[0]), nil
+}
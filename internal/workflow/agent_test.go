@@ -0,0 +1,64 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/common-creation/coda/internal/chat"
+	"github.com/common-creation/coda/internal/security"
+	"github.com/common-creation/coda/internal/tools"
+)
+
+// commandTool records the commands it is asked to run
+type commandTool struct {
+	commands []interface{}
+}
+
+func (c *commandTool) Name() string        { return "run_command" }
+func (c *commandTool) Description() string { return "runs commands" }
+func (c *commandTool) Schema() tools.ToolSchema {
+	return tools.ToolSchema{Type: "object", Properties: map[string]tools.Property{}}
+}
+func (c *commandTool) Validate(params map[string]interface{}) error { return nil }
+func (c *commandTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	c.commands = append(c.commands, params["command"])
+	return "ran", nil
+}
+
+func TestExecutorToolsApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		all     bool
+		approve []string
+		command string
+		wantErr string
+	}{
+		{name: "listed in approve_tools", approve: []string{"run_command"}, command: "make docs"},
+		{name: "not listed", command: "make docs", wantErr: "not approved"},
+		{name: "high-risk with auto-approve", all: true, command: "sudo rm -rf /var/lib/app", wantErr: "high-risk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &commandTool{}
+			manager := tools.NewManager(nil, nil)
+			require.NoError(t, manager.Register(tool))
+			approver := chat.NewStaticApprovalHandler(tt.all, tt.approve)
+			invoker := ExecutorTools{Executor: chat.NewToolExecutor(manager, nil, security.NewDefaultValidator("."), approver)}
+
+			output, err := invoker.InvokeTool(context.Background(), "run_command", map[string]interface{}{"command": tt.command})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Empty(t, tool.commands)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ran", output)
+			assert.Equal(t, []interface{}{tt.command}, tool.commands)
+		})
+	}
+}
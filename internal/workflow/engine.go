@@ -0,0 +1,294 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PromptRunner runs a prompt step and returns the final response
+type PromptRunner interface {
+	RunPrompt(ctx context.Context, prompt string) (string, error)
+}
+
+// ToolInvoker runs a tool step and returns its output as text
+type ToolInvoker interface {
+	InvokeTool(ctx context.Context, name string, params map[string]interface{}) (string, error)
+}
+
+// StepStatus is the outcome of a step
+type StepStatus string
+
+const (
+	StatusSucceeded StepStatus = "succeeded"
+	StatusFailed    StepStatus = "failed"
+	StatusSkipped   StepStatus = "skipped"
+)
+
+// StepResult records what a step did. Later steps reference it in templates
+// as .Steps.<name>.Output, .Steps.<name>.Status and .Steps.<name>.Error.
+type StepResult struct {
+	Name     string
+	Kind     string
+	Status   StepStatus
+	Output   string
+	Error    string
+	Duration time.Duration
+}
+
+// Result is the outcome of a workflow run
+type Result struct {
+	Workflow string
+	Steps    []StepResult
+	Failed   bool
+}
+
+// Summary returns a one line per step report
+func (r *Result) Summary() string {
+	var sb strings.Builder
+	status := "completed"
+	if r.Failed {
+		status = "failed"
+	}
+	sb.WriteString(fmt.Sprintf("Workflow %s %s\n", r.Workflow, status))
+	for _, step := range r.Steps {
+		line := fmt.Sprintf("  %-9s %s (%s)", step.Status, step.Name, step.Kind)
+		if step.Status != StatusSkipped {
+			line += fmt.Sprintf(" %s", step.Duration.Round(time.Millisecond))
+		}
+		if step.Error != "" {
+			line += ": " + step.Error
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// Observer is called before each step (result is nil) and after it
+type Observer func(index, total int, step Step, result *StepResult)
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// WithObserver reports step progress
+func WithObserver(observer Observer) Option {
+	return func(e *Engine) {
+		e.observer = observer
+	}
+}
+
+// WithVars overrides workflow variables
+func WithVars(vars map[string]string) Option {
+	return func(e *Engine) {
+		e.vars = vars
+	}
+}
+
+// Engine executes workflows
+type Engine struct {
+	prompts  PromptRunner
+	tools    ToolInvoker
+	observer Observer
+	vars     map[string]string
+}
+
+// NewEngine creates an engine that runs prompt steps with prompts and tool
+// steps with tools
+func NewEngine(prompts PromptRunner, tools ToolInvoker, opts ...Option) *Engine {
+	e := &Engine{
+		prompts: prompts,
+		tools:   tools,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Run executes the workflow steps in order. Step failures are recorded in the
+// result; the returned error is only set when the run could not continue.
+func (e *Engine) Run(ctx context.Context, wf *Workflow) (*Result, error) {
+	vars := make(map[string]string, len(wf.Vars)+len(e.vars))
+	for k, v := range wf.Vars {
+		vars[k] = v
+	}
+	for k, v := range e.vars {
+		vars[k] = v
+	}
+
+	steps := make(map[string]StepResult, len(wf.Steps))
+	data := map[string]interface{}{
+		"Vars":  vars,
+		"Steps": steps,
+	}
+
+	result := &Result{Workflow: wf.Name}
+	for i, step := range wf.Steps {
+		if err := ctx.Err(); err != nil {
+			result.Failed = true
+			return result, err
+		}
+		if e.observer != nil {
+			e.observer(i, len(wf.Steps), step, nil)
+		}
+
+		stepResult := e.runStep(ctx, step, data)
+		steps[step.Name] = stepResult
+		result.Steps = append(result.Steps, stepResult)
+
+		if e.observer != nil {
+			e.observer(i, len(wf.Steps), step, &stepResult)
+		}
+
+		if stepResult.Status == StatusFailed && !step.ContinueOnError {
+			result.Failed = true
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (e *Engine) runStep(ctx context.Context, step Step, data map[string]interface{}) StepResult {
+	start := time.Now()
+	result := StepResult{Name: step.Name, Kind: step.Kind()}
+	fail := func(err error) StepResult {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	if step.If != "" {
+		ok, err := evalCondition(step.If, data)
+		if err != nil {
+			return fail(fmt.Errorf("invalid condition: %w", err))
+		}
+		if !ok {
+			result.Status = StatusSkipped
+			return result
+		}
+	}
+
+	var (
+		output string
+		err    error
+	)
+	if step.Tool != "" {
+		if e.tools == nil {
+			return fail(fmt.Errorf("tool steps are not available"))
+		}
+		var params map[string]interface{}
+		if params, err = renderParams(step.Params, data); err != nil {
+			return fail(err)
+		}
+		output, err = e.tools.InvokeTool(ctx, step.Tool, params)
+	} else {
+		if e.prompts == nil {
+			return fail(fmt.Errorf("prompt steps are not available"))
+		}
+		var prompt string
+		if prompt, err = render(step.Prompt, data); err != nil {
+			return fail(err)
+		}
+		output, err = e.prompts.RunPrompt(ctx, prompt)
+	}
+
+	result.Output = output
+	if err != nil {
+		return fail(err)
+	}
+	result.Status = StatusSucceeded
+	result.Duration = time.Since(start)
+	return result
+}
+
+// templateFuncs are available in prompts, parameters and conditions
+var templateFuncs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+	"replace":   strings.ReplaceAll,
+}
+
+func render(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("step").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// evalCondition renders the condition and interprets it as a boolean. A bare
+// expression such as `contains .Steps.test.Output "FAIL"` is wrapped in {{ }}.
+func evalCondition(cond string, data interface{}) (bool, error) {
+	if !strings.Contains(cond, "{{") {
+		cond = "{{ " + cond + " }}"
+	}
+
+	out, err := render(cond, data)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(out)) {
+	case "", "false", "0", "no", "<no value>":
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// renderParams renders every string in the (possibly nested) parameters
+func renderParams(params map[string]interface{}, data interface{}) (map[string]interface{}, error) {
+	rendered := make(map[string]interface{}, len(params))
+
+	// Render in a stable order so errors are deterministic
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, err := renderValue(params[k], data)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", k, err)
+		}
+		rendered[k] = v
+	}
+	return rendered, nil
+}
+
+func renderValue(value interface{}, data interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return render(v, data)
+	case map[string]interface{}:
+		return renderParams(v, data)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
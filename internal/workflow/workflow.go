@@ -0,0 +1,180 @@
+// Package workflow runs YAML-defined multi-step recipes made of prompt
+// templates, tool invocations and conditionals on earlier outputs.
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a named sequence of steps
+type Workflow struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description,omitempty"`
+	Vars        map[string]string `yaml:"vars,omitempty"`
+
+	// ApproveTools lists the tools that tool steps and the model during
+	// prompt steps may call without asking. Other tool calls are denied
+	// unless auto-approve is on.
+	ApproveTools []string `yaml:"approve_tools,omitempty"`
+
+	Steps []Step `yaml:"steps"`
+
+	// Path is the file the workflow was loaded from
+	Path string `yaml:"-"`
+}
+
+// Step is a single prompt or tool invocation. Prompt, tool parameters and
+// the condition are Go templates over .Vars and .Steps.<name>.
+type Step struct {
+	Name   string                 `yaml:"name"`
+	Prompt string                 `yaml:"prompt,omitempty"`
+	Tool   string                 `yaml:"tool,omitempty"`
+	Params map[string]interface{} `yaml:"params,omitempty"`
+
+	// If skips the step unless the condition renders to a true value
+	If string `yaml:"if,omitempty"`
+
+	// ContinueOnError keeps running later steps when this one fails
+	ContinueOnError bool `yaml:"continue_on_error,omitempty"`
+}
+
+// Kind returns "prompt" or "tool"
+func (s Step) Kind() string {
+	if s.Tool != "" {
+		return "tool"
+	}
+	return "prompt"
+}
+
+// Parse decodes and validates a workflow definition
+func Parse(data []byte) (*Workflow, error) {
+	var wf Workflow
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Validate checks the workflow structure and fills in default step names
+func (w *Workflow) Validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow %q has no steps", w.Name)
+	}
+
+	seen := make(map[string]bool)
+	for i := range w.Steps {
+		step := &w.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step%d", i+1)
+		}
+		if seen[step.Name] {
+			return fmt.Errorf("duplicate step name %q", step.Name)
+		}
+		seen[step.Name] = true
+
+		switch {
+		case step.Prompt == "" && step.Tool == "":
+			return fmt.Errorf("step %q needs a prompt or a tool", step.Name)
+		case step.Prompt != "" && step.Tool != "":
+			return fmt.Errorf("step %q has both a prompt and a tool", step.Name)
+		}
+	}
+
+	return nil
+}
+
+// Load reads a workflow file. The name defaults to the file name.
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	wf, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if wf.Name == "" {
+		wf.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	wf.Path = path
+	return wf, nil
+}
+
+// DefaultDirs returns the directories searched for workflows: the project's
+// .coda/workflows first, then ~/.coda/workflows
+func DefaultDirs() []string {
+	dirs := []string{filepath.Join(".coda", "workflows")}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".coda", "workflows"))
+	}
+	return dirs
+}
+
+// Find loads a workflow by file path or by name from dirs
+func Find(nameOrPath string, dirs []string) (*Workflow, error) {
+	if isWorkflowFile(nameOrPath) || strings.ContainsRune(nameOrPath, filepath.Separator) {
+		return Load(nameOrPath)
+	}
+
+	for _, dir := range dirs {
+		for _, ext := range []string{".yaml", ".yml"} {
+			path := filepath.Join(dir, nameOrPath+ext)
+			if _, err := os.Stat(path); err == nil {
+				return Load(path)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("workflow %q not found in %s", nameOrPath, strings.Join(dirs, ", "))
+}
+
+// List loads every workflow in dirs. Earlier directories take precedence
+// for workflows with the same name; files that fail to load are returned
+// as errors alongside the valid workflows.
+func List(dirs []string) ([]*Workflow, []error) {
+	var (
+		result []*Workflow
+		errs   []error
+		seen   = make(map[string]bool)
+	)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !isWorkflowFile(entry.Name()) {
+				continue
+			}
+			wf, err := Load(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if seen[wf.Name] {
+				continue
+			}
+			seen[wf.Name] = true
+			result = append(result, wf)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, errs
+}
+
+func isWorkflowFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,181 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "valid", yaml: "steps:\n  - prompt: hi\n  - tool: read_file\n    params: {path: x}\n"},
+		{name: "no steps", yaml: "name: empty\n", wantErr: "has no steps"},
+		{name: "empty step", yaml: "steps:\n  - name: a\n", wantErr: "needs a prompt or a tool"},
+		{name: "prompt and tool", yaml: "steps:\n  - prompt: hi\n    tool: read_file\n", wantErr: "both a prompt and a tool"},
+		{name: "duplicate names", yaml: "steps:\n  - {name: a, prompt: x}\n  - {name: a, prompt: y}\n", wantErr: "duplicate step name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf, err := Parse([]byte(tt.yaml))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "step1", wf.Steps[0].Name)
+			assert.Equal(t, "tool", wf.Steps[1].Kind())
+		})
+	}
+}
+
+type fakePrompts struct {
+	prompts []string
+	reply   func(prompt string) (string, error)
+}
+
+func (f *fakePrompts) RunPrompt(ctx context.Context, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	return f.reply(prompt)
+}
+
+type fakeTools struct {
+	calls []map[string]interface{}
+}
+
+func (f *fakeTools) InvokeTool(ctx context.Context, name string, params map[string]interface{}) (string, error) {
+	f.calls = append(f.calls, params)
+	if name == "broken" {
+		return "", errors.New("tool exploded")
+	}
+	return "1.0.0\n", nil
+}
+
+func TestEngineRun(t *testing.T) {
+	wf, err := Parse([]byte(`
+name: release
+vars:
+  version: "1.0.0"
+steps:
+  - name: current
+    tool: read_file
+    params:
+      path: "{{.Vars.dir}}/VERSION"
+      nested: ["{{.Vars.version}}", 3]
+  - name: bump
+    if: ne (trim .Steps.current.Output) .Vars.version
+    prompt: Bump to {{.Vars.version}}
+  - name: changelog
+    if: "{{ if eq .Steps.bump.Status \"succeeded\" }}yes{{ end }}"
+    prompt: Update the changelog after {{.Steps.bump.Output}}
+  - name: flaky
+    tool: broken
+    continue_on_error: true
+  - name: report
+    prompt: "flaky: {{.Steps.flaky.Status}}"
+`))
+	require.NoError(t, err)
+
+	prompts := &fakePrompts{reply: func(prompt string) (string, error) { return "done: " + prompt, nil }}
+	tools := &fakeTools{}
+
+	var started []string
+	engine := NewEngine(prompts, tools,
+		WithVars(map[string]string{"version": "1.1.0", "dir": "pkg"}),
+		WithObserver(func(index, total int, step Step, result *StepResult) {
+			if result == nil {
+				started = append(started, step.Name)
+			}
+		}),
+	)
+
+	result, err := engine.Run(context.Background(), wf)
+	require.NoError(t, err)
+	assert.False(t, result.Failed)
+	assert.Equal(t, []string{"current", "bump", "changelog", "flaky", "report"}, started)
+
+	require.Len(t, tools.calls, 2)
+	assert.Equal(t, "pkg/VERSION", tools.calls[0]["path"])
+	assert.Equal(t, []interface{}{"1.1.0", 3}, tools.calls[0]["nested"])
+
+	assert.Equal(t, []string{
+		"Bump to 1.1.0",
+		"Update the changelog after done: Bump to 1.1.0",
+		"flaky: failed",
+	}, prompts.prompts)
+
+	statuses := make([]StepStatus, len(result.Steps))
+	for i, step := range result.Steps {
+		statuses[i] = step.Status
+	}
+	assert.Equal(t, []StepStatus{StatusSucceeded, StatusSucceeded, StatusSucceeded, StatusFailed, StatusSucceeded}, statuses)
+	assert.Contains(t, result.Summary(), "tool exploded")
+}
+
+func TestEngineRunStopsOnFailure(t *testing.T) {
+	wf, err := Parse([]byte(`
+steps:
+  - name: skipped
+    if: "false"
+    prompt: never
+  - name: fails
+    prompt: "{{.Steps.missing.Output}}"
+  - name: after
+    prompt: never
+`))
+	require.NoError(t, err)
+
+	prompts := &fakePrompts{reply: func(string) (string, error) { return "", nil }}
+	result, err := NewEngine(prompts, nil).Run(context.Background(), wf)
+	require.NoError(t, err)
+
+	assert.True(t, result.Failed)
+	require.Len(t, result.Steps, 2)
+	assert.Equal(t, StatusSkipped, result.Steps[0].Status)
+	assert.Equal(t, StatusFailed, result.Steps[1].Status)
+	assert.Contains(t, result.Steps[1].Error, "missing")
+	assert.Empty(t, prompts.prompts)
+}
+
+func TestFindAndList(t *testing.T) {
+	project := t.TempDir()
+	home := t.TempDir()
+	write := func(dir, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write(project, "release.yaml", "description: project release\nsteps:\n  - prompt: a\n")
+	write(home, "release.yml", "description: home release\nsteps:\n  - prompt: b\n")
+	write(home, "docs.yml", "steps:\n  - prompt: c\n")
+	write(home, "broken.yaml", "steps: []\n")
+	write(home, "notes.txt", "ignored")
+
+	dirs := []string{project, home}
+
+	wf, err := Find("release", dirs)
+	require.NoError(t, err)
+	assert.Equal(t, "project release", wf.Description)
+	assert.Equal(t, "release", wf.Name)
+
+	wf, err = Find(filepath.Join(home, "docs.yml"), dirs)
+	require.NoError(t, err)
+	assert.Equal(t, "docs", wf.Name)
+
+	_, err = Find("missing", dirs)
+	assert.Error(t, err)
+
+	workflows, errs := List(dirs)
+	require.Len(t, workflows, 2)
+	assert.Equal(t, "docs", workflows[0].Name)
+	assert.Equal(t, "project release", workflows[1].Description)
+	assert.Len(t, errs, 1)
+}
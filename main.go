@@ -32,7 +32,7 @@ func main() {
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	// Start shutdown handler in background
 	go func() {
@@ -42,6 +42,11 @@ func main() {
 		// Cancel context to signal shutdown
 		cancel()
 
+		// Save the open sessions first; the exit below skips their Close
+		if err := cmd.SaveSessions(); err != nil {
+			cmd.ShowWarning("Failed to save sessions: %v", err)
+		}
+
 		// Attempt graceful MCP shutdown with timeout
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
@@ -61,6 +66,10 @@ func main() {
 			cmd.ShowWarning("MCP shutdown timed out")
 		}
 
+		if err := cmd.CloseAuditLog(); err != nil {
+			cmd.ShowWarning("Failed to close audit log: %v", err)
+		}
+
 		// Force exit if needed
 		os.Exit(0)
 	}()
@@ -164,6 +164,11 @@ func (m *MockAIClient) ListModels(ctx context.Context) ([]ai.Model, error) {
 	}, nil
 }
 
+// Capabilities implements ai.Client interface
+func (m *MockAIClient) Capabilities() ai.Capabilities {
+	return ai.Capabilities{Tools: true, JSONSchema: true, StreamingUsage: true}
+}
+
 // Ping implements ai.Client interface
 func (m *MockAIClient) Ping(ctx context.Context) error {
 	m.mu.RLock()